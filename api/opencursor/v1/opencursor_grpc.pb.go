@@ -0,0 +1,262 @@
+// openCursor 的 gRPC 接口，由 "openCursor serve --grpc-addr" 提供。
+// 修改后重新生成 Go 代码：
+//   protoc --go_out=. --go_opt=paths=source_relative \
+//          --go-grpc_out=. --go-grpc_opt=paths=source_relative \
+//          api/opencursor/v1/opencursor.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: api/opencursor/v1/opencursor.proto
+
+package opencursorv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	OpenCursor_Query_FullMethodName        = "/opencursor.v1.OpenCursor/Query"
+	OpenCursor_StreamEvents_FullMethodName = "/opencursor.v1.OpenCursor/StreamEvents"
+	OpenCursor_ApproveTool_FullMethodName  = "/opencursor.v1.OpenCursor/ApproveTool"
+	OpenCursor_ListSessions_FullMethodName = "/opencursor.v1.OpenCursor/ListSessions"
+)
+
+// OpenCursorClient is the client API for OpenCursor service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type OpenCursorClient interface {
+	// Query 运行一次查询，查询结束时返回。取消调用会在当前步骤完成后停止查询
+	Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*QueryResponse, error)
+	// StreamEvents 订阅之后所有查询的事件，直到客户端取消调用
+	StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (OpenCursor_StreamEventsClient, error)
+	// ApproveTool 回复 ApprovalRequest 事件
+	ApproveTool(ctx context.Context, in *ApproveToolRequest, opts ...grpc.CallOption) (*ApproveToolResponse, error)
+	// ListSessions 列出保存的会话，最近更新的在前
+	ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error)
+}
+
+type openCursorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewOpenCursorClient(cc grpc.ClientConnInterface) OpenCursorClient {
+	return &openCursorClient{cc}
+}
+
+func (c *openCursorClient) Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*QueryResponse, error) {
+	out := new(QueryResponse)
+	err := c.cc.Invoke(ctx, OpenCursor_Query_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *openCursorClient) StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (OpenCursor_StreamEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &OpenCursor_ServiceDesc.Streams[0], OpenCursor_StreamEvents_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &openCursorStreamEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type OpenCursor_StreamEventsClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type openCursorStreamEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *openCursorStreamEventsClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *openCursorClient) ApproveTool(ctx context.Context, in *ApproveToolRequest, opts ...grpc.CallOption) (*ApproveToolResponse, error) {
+	out := new(ApproveToolResponse)
+	err := c.cc.Invoke(ctx, OpenCursor_ApproveTool_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *openCursorClient) ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error) {
+	out := new(ListSessionsResponse)
+	err := c.cc.Invoke(ctx, OpenCursor_ListSessions_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// OpenCursorServer is the server API for OpenCursor service.
+// All implementations must embed UnimplementedOpenCursorServer
+// for forward compatibility
+type OpenCursorServer interface {
+	// Query 运行一次查询，查询结束时返回。取消调用会在当前步骤完成后停止查询
+	Query(context.Context, *QueryRequest) (*QueryResponse, error)
+	// StreamEvents 订阅之后所有查询的事件，直到客户端取消调用
+	StreamEvents(*StreamEventsRequest, OpenCursor_StreamEventsServer) error
+	// ApproveTool 回复 ApprovalRequest 事件
+	ApproveTool(context.Context, *ApproveToolRequest) (*ApproveToolResponse, error)
+	// ListSessions 列出保存的会话，最近更新的在前
+	ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error)
+	mustEmbedUnimplementedOpenCursorServer()
+}
+
+// UnimplementedOpenCursorServer must be embedded to have forward compatible implementations.
+type UnimplementedOpenCursorServer struct {
+}
+
+func (UnimplementedOpenCursorServer) Query(context.Context, *QueryRequest) (*QueryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Query not implemented")
+}
+func (UnimplementedOpenCursorServer) StreamEvents(*StreamEventsRequest, OpenCursor_StreamEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamEvents not implemented")
+}
+func (UnimplementedOpenCursorServer) ApproveTool(context.Context, *ApproveToolRequest) (*ApproveToolResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ApproveTool not implemented")
+}
+func (UnimplementedOpenCursorServer) ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSessions not implemented")
+}
+func (UnimplementedOpenCursorServer) mustEmbedUnimplementedOpenCursorServer() {}
+
+// UnsafeOpenCursorServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to OpenCursorServer will
+// result in compilation errors.
+type UnsafeOpenCursorServer interface {
+	mustEmbedUnimplementedOpenCursorServer()
+}
+
+func RegisterOpenCursorServer(s grpc.ServiceRegistrar, srv OpenCursorServer) {
+	s.RegisterService(&OpenCursor_ServiceDesc, srv)
+}
+
+func _OpenCursor_Query_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OpenCursorServer).Query(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OpenCursor_Query_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OpenCursorServer).Query(ctx, req.(*QueryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OpenCursor_StreamEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OpenCursorServer).StreamEvents(m, &openCursorStreamEventsServer{stream})
+}
+
+type OpenCursor_StreamEventsServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type openCursorStreamEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *openCursorStreamEventsServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _OpenCursor_ApproveTool_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ApproveToolRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OpenCursorServer).ApproveTool(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OpenCursor_ApproveTool_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OpenCursorServer).ApproveTool(ctx, req.(*ApproveToolRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OpenCursor_ListSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSessionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OpenCursorServer).ListSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OpenCursor_ListSessions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OpenCursorServer).ListSessions(ctx, req.(*ListSessionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// OpenCursor_ServiceDesc is the grpc.ServiceDesc for OpenCursor service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var OpenCursor_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "opencursor.v1.OpenCursor",
+	HandlerType: (*OpenCursorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Query",
+			Handler:    _OpenCursor_Query_Handler,
+		},
+		{
+			MethodName: "ApproveTool",
+			Handler:    _OpenCursor_ApproveTool_Handler,
+		},
+		{
+			MethodName: "ListSessions",
+			Handler:    _OpenCursor_ListSessions_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamEvents",
+			Handler:       _OpenCursor_StreamEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "api/opencursor/v1/opencursor.proto",
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"openCursor/internal/config"
+	"openCursor/internal/tools"
+
+	"github.com/spf13/cobra"
+)
+
+// apply-plan 命令参数
+var applyPlanCheck bool // --check 只检查工作区是否变化
+
+// applyPlanCmd 应用 --dry-run 保存的修改计划
+var applyPlanCmd = &cobra.Command{
+	Use:   "apply-plan <file>",
+	Short: "Apply a change plan saved by a --dry-run run",
+	Long: `Apply the file changes of a change plan saved by openCursor --dry-run.
+
+The plan records a SHA-256 hash of every file it modifies or deletes. Before
+anything is written the workspace is checked: if a file changed since the dry
+run, no longer exists, or a file to be created already exists, nothing is
+applied. The changes are then applied atomically: the new contents are written
+to temporary files next to their targets first and swapped in afterwards; if
+any step fails, the files already replaced are restored.
+
+Commands recorded in the plan are not executed; they are listed so they can be
+run by hand. --check only verifies the workspace and prints the plan.
+
+Examples:
+  openCursor --dry-run "Rename the Config type to Settings"
+  openCursor apply-plan ~/.opencursor/plans/20240131-101500-a1b2c3.json --check
+  openCursor apply-plan ~/.opencursor/plans/20240131-101500-a1b2c3.json`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		plan, err := loadChangePlan(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if plan.Query != "" {
+			fmt.Printf("📋 %s（%s）\n", firstLine(plan.Query), plan.WorkDir)
+		}
+
+		if applyPlanCheck {
+			printChangePlan(os.Stdout, plan)
+			if drift := plan.Drift(); len(drift) > 0 {
+				fmt.Fprintf(os.Stderr, "\nError: the workspace changed since the dry run:\n")
+				for _, line := range drift {
+					fmt.Fprintf(os.Stderr, "  %s\n", line)
+				}
+				os.Exit(1)
+			}
+			fmt.Printf("\n✅ 工作区没有变化，可以应用\n")
+			return
+		}
+
+		if err := plan.Apply(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\nNothing was applied.\n", err)
+			os.Exit(1)
+		}
+		for _, file := range plan.Files {
+			fmt.Printf("%s %s\n", planActionLabel(file.Action), file.Path)
+		}
+		fmt.Printf("\n✅ 已应用 %d 个文件的修改\n", len(plan.Files))
+		if len(plan.Commands) > 0 {
+			fmt.Printf("\n计划中未执行的命令（请按需手动运行）:\n")
+			for _, command := range plan.Commands {
+				fmt.Printf("  $ %s\n", command.Command)
+			}
+		}
+	},
+}
+
+// loadChangePlan 按配置文件安装安全策略后读取修改计划，应用时与代理运行时一样按策略检查每个文件
+func loadChangePlan(path string) (*tools.ChangePlan, error) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := tools.SetSecurityPolicy(cfg.SecurityPolicy); err != nil {
+		return nil, err
+	}
+	return tools.LoadChangePlan(path)
+}
+
+func init() {
+	applyPlanCmd.Flags().BoolVar(&applyPlanCheck, "check", false, "Only check that the workspace has not changed since the dry run and print the plan")
+	rootCmd.AddCommand(applyPlanCmd)
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"openCursor/internal/tools"
+)
+
+// TestApplyPlanSecurityPolicy apply-plan 按配置文件中的安全策略拒绝计划，不写入任何文件
+func TestApplyPlanSecurityPolicy(t *testing.T) {
+	dir := t.TempDir()
+	workDir := filepath.Join(dir, "work")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	config := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(config, []byte("security_policy:\n  deny_paths: [\"secrets/**\"]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	planFile := filepath.Join(dir, "plan.json")
+	err := tools.SaveChangePlan(planFile, &tools.ChangePlan{
+		Version:   1,
+		CreatedAt: time.Now(),
+		WorkDir:   workDir,
+		Files: []tools.PlannedFile{
+			{Path: "ok.txt", Action: tools.PlanCreate, Content: "x\n"},
+			{Path: "secrets/key.txt", Action: tools.PlanCreate, Content: "x\n"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saved := configPath
+	configPath = config
+	defer func() {
+		configPath = saved
+		tools.SetSecurityPolicy(tools.SecurityPolicy{})
+	}()
+
+	plan, err := loadChangePlan(planFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = plan.Apply()
+	if err == nil || !strings.Contains(err.Error(), "denied by the security policy") {
+		t.Fatalf("Apply() error = %v, want a security policy denial", err)
+	}
+	for _, name := range []string{"ok.txt", "secrets/key.txt"} {
+		if _, err := os.Stat(filepath.Join(workDir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s was written despite the denial", name)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"openCursor/internal/credentials"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// auth 命令参数
+var (
+	authBaseURL   string // --base-url 密钥对应的模型服务地址
+	authFromStdin bool   // --stdin 从标准输入读取密钥
+)
+
+// authCmd 管理保存在系统钥匙串中的API密钥
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Manage API keys stored in the system keychain",
+	Long: `Store API keys in the operating system's keychain instead of plaintext
+environment variables or shell history: the macOS Keychain, the Secret Service
+(GNOME Keyring, KWallet) on Linux, or the Windows Credential Manager.
+
+Keys are stored per model service, identified by its base URL (BASE_URL or
+--base-url, default "` + defaultBaseURL + `"). When OPENAI_API_KEY is not set,
+openCursor reads the key for the current BASE_URL from the keychain.
+OPENAI_API_KEY always takes precedence.
+
+Examples:
+  openCursor auth login
+  openCursor auth login --base-url https://api.openai.com/v1
+  pass show deepseek | openCursor auth login --stdin
+  openCursor auth status
+  openCursor auth logout`,
+}
+
+// authLoginCmd 保存API密钥
+var authLoginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Store an API key in the system keychain",
+	Long: `Prompt for an API key without echoing it and store it in the system keychain
+for the model service. An existing key for the same service is replaced.
+With --stdin the key is read from standard input, for use in scripts.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		baseURL := authTarget()
+		apiKey, err := readAPIKey(baseURL)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := credentials.Save(baseURL, apiKey); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("🔑 已将 %s 的API密钥保存到系统钥匙串: %s\n", credentials.Account(baseURL), credentials.Mask(apiKey))
+	},
+}
+
+// authLogoutCmd 删除API密钥
+var authLogoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Remove the stored API key from the system keychain",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		baseURL := authTarget()
+		err := credentials.Delete(baseURL)
+		if errors.Is(err, credentials.ErrNotFound) {
+			fmt.Printf("钥匙串中没有 %s 的API密钥\n", credentials.Account(baseURL))
+			return
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("🗑  已删除 %s 的API密钥\n", credentials.Account(baseURL))
+	},
+}
+
+// authStatusCmd 显示当前使用的API密钥来源
+var authStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show where the API key for the model service comes from",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		baseURL := authTarget()
+		fmt.Printf("模型服务: %s\n", baseURL)
+		if apiKey := os.Getenv("OPENAI_API_KEY"); apiKey != "" {
+			fmt.Printf("使用环境变量 OPENAI_API_KEY: %s\n", credentials.Mask(apiKey))
+			return
+		}
+		apiKey, err := credentials.Load(baseURL)
+		if errors.Is(err, credentials.ErrNotFound) {
+			fmt.Println("未设置 OPENAI_API_KEY，钥匙串中也没有密钥，请运行 openCursor auth login")
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("使用系统钥匙串中的密钥: %s\n", credentials.Mask(apiKey))
+	},
+}
+
+// authTarget 要管理的模型服务地址：--base-url 优先于 BASE_URL
+func authTarget() string {
+	if authBaseURL != "" {
+		return authBaseURL
+	}
+	return apiBaseURL()
+}
+
+// readAPIKey 从终端读取密钥（不回显），或在 --stdin 及标准输入不是终端时读取第一行
+func readAPIKey(baseURL string) (string, error) {
+	var apiKey string
+	fd := int(os.Stdin.Fd())
+	if !authFromStdin && term.IsTerminal(fd) {
+		fmt.Fprintf(os.Stderr, "%s 的API密钥: ", credentials.Account(baseURL))
+		data, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the API key: %w", err)
+		}
+		apiKey = string(data)
+	} else {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("failed to read the API key from stdin: %w", err)
+		}
+		apiKey = line
+	}
+	apiKey = strings.TrimSpace(apiKey)
+	if apiKey == "" {
+		return "", fmt.Errorf("the API key is empty")
+	}
+	return apiKey, nil
+}
+
+func init() {
+	authCmd.PersistentFlags().StringVar(&authBaseURL, "base-url", "", "Model service the key belongs to (default: BASE_URL or "+defaultBaseURL+")")
+	authLoginCmd.Flags().BoolVar(&authFromStdin, "stdin", false, "Read the API key from standard input")
+	authCmd.AddCommand(authLoginCmd)
+	authCmd.AddCommand(authLogoutCmd)
+	authCmd.AddCommand(authStatusCmd)
+	rootCmd.AddCommand(authCmd)
+}
\ No newline at end of file
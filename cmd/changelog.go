@@ -0,0 +1,334 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"openCursor/internal/client"
+	"openCursor/internal/config"
+	"openCursor/internal/tools"
+
+	"github.com/spf13/cobra"
+)
+
+// changelog 命令参数
+var (
+	changelogSince   string // --since 起始引用（不含），默认为最近的标签
+	changelogUntil   string // --until 结束引用，默认为 HEAD
+	changelogRelease string // --release 新版本的标题
+	changelogOutput  string // --output 发布说明输出路径
+	changelogUpdate  bool   // --update 把发布说明加入 CHANGELOG
+	changelogFile    string // --file 要更新的 CHANGELOG 文件
+	changelogYes     bool   // --yes 不确认直接写入 CHANGELOG
+)
+
+// changelogMaxIterations 生成发布说明时允许的最多模型调用轮数
+const changelogMaxIterations = 20
+
+// changelogMaxLogBytes 附加到提示词中的提交记录上限
+const changelogMaxLogBytes = 150000
+
+// changelogBodyLines 每个提交附加的正文行数上限
+const changelogBodyLines = 6
+
+// emptyTreeHash git 空树的哈希，没有起始引用时与其比较得到全部改动
+const emptyTreeHash = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// changelogGroups 发布说明的分组说明，生成与更新共用
+const changelogGroups = `Group the entries by what the change means to users of the project, not by commit,
+using these groups in this order and leaving out empty ones: Breaking changes, Added,
+Changed, Fixed, Performance, Security, Deprecated, Removed, Documentation, Internal.
+Merge commits that belong to the same change into one entry, leave out noise (typo
+fixes in comments, version bumps, changes reverted within the range), and write each
+entry as one short line a user understands, mentioning the affected command, option or
+API. Do not invent changes that the commits do not show.`
+
+// changelogPrompt 生成发布说明的指令，%s 依次为提交范围与版本标题
+const changelogPrompt = `Write the release notes for the commits attached above (%[1]s).
+
+` + changelogGroups + `
+
+Use the read-only tools (git_log, read_file, grep_search) only when a commit message is
+too vague to tell what changed. Your final answer must be the Markdown section itself,
+starting with "## %[2]s" and using "### <group>" headings with "- " entries. Do not wrap
+it in a code fence and do not add any text before or after it.`
+
+// changelogUpdatePrompt 更新 CHANGELOG 的指令，%s 依次为文件路径、提交范围与版本标题
+const changelogUpdatePrompt = `Add the release notes for the commits attached above (%[2]s) to %[1]s.
+
+The current content of %[1]s is attached as well; it is empty when the file does not
+exist yet. Add a new section titled %[3]s above the previous releases, following the
+heading levels, group names, entry style, dates and link format the file already uses
+(for a new file, start with a "# Changelog" title and use Keep a Changelog style).
+If the file already has a section for %[3]s, add the missing entries to it instead.
+
+` + changelogGroups + `
+
+Edit %[1]s with the edit tools, always using the relative path %[1]s (write_file only
+when the file is new), and do not change the existing releases. Finish with one sentence
+describing what you added.`
+
+// changelogCmd 生成发布说明
+var changelogCmd = &cobra.Command{
+	Use:   "changelog",
+	Short: "Generate release notes from the git history",
+	Long: `Read the commits and diff stats since the last release and have the model write
+grouped release notes (Breaking changes, Added, Changed, Fixed, ...).
+
+The range starts after --since (default: the most recent tag reachable from --until,
+or the first commit when there are no tags) and ends at --until (default: HEAD).
+The notes are printed to standard output, or written to --output.
+
+With --update the notes are added to CHANGELOG.md (or --file) with the edit tools,
+following the format the file already uses. The agent works on a scratch copy; the
+resulting diff is shown and written only after confirmation (or with --yes).
+
+Examples:
+  openCursor changelog
+  openCursor changelog --since v1.2.0 --release v1.3.0
+  openCursor changelog --since v1.2.0 --output notes.md
+  openCursor changelog --since v1.2.0 --release "1.3.0 - 2024-06-01" --update`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if strings.HasPrefix(changelogSince, "-") || strings.HasPrefix(changelogUntil, "-") {
+			fmt.Fprintf(os.Stderr, "Error: invalid ref\n")
+			os.Exit(1)
+		}
+		if changelogUpdate && changelogOutput != "" {
+			fmt.Fprintf(os.Stderr, "Error: --update cannot be combined with --output\n")
+			os.Exit(1)
+		}
+
+		workDir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to get current directory: %v\n", err)
+			os.Exit(1)
+		}
+		log, stats, description, err := changelogHistory(workDir, changelogSince, changelogUntil)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if log == "" {
+			fmt.Fprintf(os.Stderr, "Nothing to release: there are no commits in %s\n", description)
+			return
+		}
+		release := changelogRelease
+		if release == "" {
+			release = "Unreleased"
+		}
+
+		apiKey, baseURL, model := loadAPISettings()
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if changelogUpdate {
+			updateChangelog(cfg, apiKey, baseURL, model, workDir, log, stats, description, release)
+			return
+		}
+
+		// 只注册只读工具
+		if err := setupAgentTools(cfg, baseURL, agentToolSetup{register: tools.RegisterDefaultReadOnlyTools, skipApproval: true, workDir: workDir}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		aiClient := client.NewClient(apiKey, baseURL, model)
+		aiClient.SetToolManager(tools.GetDefaultManager())
+		aiClient.SetMaxIterations(changelogMaxIterations)
+		aiClient.AddContext("git_log", log)
+		aiClient.AddContext("diff_stat", stats)
+		// 发布说明写到标准输出，模型的流式输出改到标准错误
+		aiClient.SetOutput(os.Stderr)
+		fmt.Fprintf(os.Stderr, "📝 Writing release notes for %s\n", description)
+		if err := aiClient.StreamQueryWithTools(fmt.Sprintf(changelogPrompt, description, release)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		notes := trimMarkdownFence(aiClient.LastResponse())
+		if notes == "" {
+			fmt.Fprintf(os.Stderr, "Error: the model did not produce release notes within %d steps\n", changelogMaxIterations)
+			os.Exit(1)
+		}
+		if changelogOutput == "" {
+			fmt.Fprintln(os.Stderr)
+			fmt.Println(notes)
+			return
+		}
+		path := changelogOutput
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(workDir, path)
+		}
+		if err := os.WriteFile(path, []byte(notes+"\n"), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to write release notes: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "\nRelease notes written to %s\n", path)
+	},
+}
+
+// changelogHistory 提交范围内的提交记录（不含合并提交）、改动统计与范围的描述
+func changelogHistory(workDir, since, until string) (string, string, string, error) {
+	if until == "" {
+		until = "HEAD"
+	}
+	if since == "" {
+		// 默认从最近的标签开始，没有标签时包括全部历史
+		if tag, err := gitOutput(workDir, "describe", "--tags", "--abbrev=0", until); err == nil {
+			since = strings.TrimSpace(tag)
+		}
+	}
+	revisions := until
+	base := emptyTreeHash
+	description := "all commits up to " + until
+	if since != "" {
+		revisions = since + ".." + until
+		base = since
+		description = revisions
+	}
+
+	output, err := gitOutput(workDir, "log", "--no-merges", "--format=%h%x1f%an%x1f%s%x1f%b%x1e", revisions, "--")
+	if err != nil {
+		return "", "", "", err
+	}
+	var log strings.Builder
+	count := 0
+	for _, record := range strings.Split(output, "\x1e") {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x1f", 4)
+		if len(fields) < 4 {
+			continue
+		}
+		count++
+		if log.Len() > changelogMaxLogBytes {
+			continue
+		}
+		fmt.Fprintf(&log, "- %s %s (%s)\n", fields[0], fields[2], fields[1])
+		lines := 0
+		for _, line := range strings.Split(strings.TrimSpace(fields[3]), "\n") {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			if lines == changelogBodyLines {
+				log.WriteString("    ...\n")
+				break
+			}
+			lines++
+			fmt.Fprintf(&log, "    %s\n", strings.TrimRight(line, " \r"))
+		}
+	}
+	if count == 0 {
+		return "", "", description, nil
+	}
+	text := fmt.Sprintf("# %d commits in %s\n%s", count, description, log.String())
+	if log.Len() > changelogMaxLogBytes {
+		text += "[log truncated: use the git_log tool for the older commits]\n"
+	}
+
+	stats, err := gitOutput(workDir, "diff", "--stat=120", "--stat-count=80", base, until, "--")
+	if err != nil {
+		return "", "", "", err
+	}
+	return text, stats, description, nil
+}
+
+// updateChangelog 在草稿副本上用编辑工具把发布说明加入 CHANGELOG，确认后写回
+func updateChangelog(cfg *config.Config, apiKey, baseURL, model, workDir, log, stats, description, release string) {
+	path := changelogFile
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workDir, path)
+	}
+	rel, err := filepath.Rel(workDir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		rel = filepath.Base(path)
+	}
+	displayPath := filepath.ToSlash(rel)
+	original, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// 草稿目录：original/ 保存原文件用于diff，edited/ 作为代理的工作目录
+	scratch, err := os.MkdirTemp("", "opencursor-changelog-")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer os.RemoveAll(scratch)
+	editedDir := filepath.Join(scratch, "edited")
+	for _, dir := range []string{"original", "edited"} {
+		copyPath := filepath.Join(scratch, dir, rel)
+		err := os.MkdirAll(filepath.Dir(copyPath), 0755)
+		if err == nil {
+			err = os.WriteFile(copyPath, original, 0644)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to create scratch copy: %v\n", err)
+			os.RemoveAll(scratch)
+			os.Exit(1)
+		}
+	}
+
+	// 只注册编辑工具，工作目录为草稿目录
+	if err := setupAgentTools(cfg, baseURL, agentToolSetup{register: tools.RegisterDefaultEditTools, skipApproval: true, workDir: editedDir}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.RemoveAll(scratch)
+		os.Exit(1)
+	}
+
+	aiClient := client.NewClient(apiKey, baseURL, model)
+	aiClient.SetToolManager(tools.GetDefaultManager())
+	aiClient.SetMaxIterations(changelogMaxIterations)
+	aiClient.AddContext("git_log", log)
+	aiClient.AddContext("diff_stat", stats)
+	aiClient.AddContext("file", fmt.Sprintf("%s\n```\n%s\n```", displayPath, strings.TrimRight(string(original), "\n")))
+	fmt.Fprintf(os.Stderr, "📝 Adding release notes for %s to %s\n", description, displayPath)
+	if err := aiClient.StreamQueryWithTools(fmt.Sprintf(changelogUpdatePrompt, displayPath, description, release)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.RemoveAll(scratch)
+		os.Exit(1)
+	}
+
+	edited, err := os.ReadFile(filepath.Join(editedDir, rel))
+	if err != nil || bytes.Equal(edited, original) {
+		fmt.Printf("\nNo changes to %s\n", displayPath)
+		return
+	}
+	fmt.Printf("\n%s", editDiff(scratch, rel))
+	if !changelogYes && !confirm(fmt.Sprintf("更新 %s? [y/N] ", displayPath)) {
+		fmt.Println("未更新")
+		return
+	}
+
+	// 期间文件被其他程序修改时不覆盖
+	current, err := os.ReadFile(path)
+	if (err != nil && !os.IsNotExist(err)) || !bytes.Equal(current, original) {
+		fmt.Fprintf(os.Stderr, "Error: %s changed while generating the release notes; not updated\n", displayPath)
+		os.RemoveAll(scratch)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(path, edited, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to write %s: %v\n", displayPath, err)
+		os.RemoveAll(scratch)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ 已更新 %s\n", displayPath)
+}
+
+func init() {
+	changelogCmd.Flags().StringVar(&changelogSince, "since", "", "Start after this tag or commit (default: the most recent tag)")
+	changelogCmd.Flags().StringVar(&changelogUntil, "until", "HEAD", "End at this tag or commit")
+	changelogCmd.Flags().StringVar(&changelogRelease, "release", "", `Title of the new release section (default: "Unreleased")`)
+	changelogCmd.Flags().StringVarP(&changelogOutput, "output", "o", "", "Write the release notes to this file instead of standard output")
+	changelogCmd.Flags().BoolVar(&changelogUpdate, "update", false, "Add the release notes to the changelog file with the edit tools")
+	changelogCmd.Flags().StringVar(&changelogFile, "file", "CHANGELOG.md", "Changelog file updated by --update")
+	changelogCmd.Flags().BoolVarP(&changelogYes, "yes", "y", false, "With --update, write the change without asking for confirmation")
+	rootCmd.AddCommand(changelogCmd)
+}
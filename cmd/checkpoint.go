@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"openCursor/internal/client"
+	"openCursor/internal/tools"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// 运行期间保存与恢复检查点的输入命令
+const (
+	checkpointCommand = "/checkpoint"
+	rollbackCommand   = "/rollback"
+)
+
+// fileState 文件在某一时刻的内容
+type fileState struct {
+	content string
+	exists  bool
+}
+
+// checkpoint 命名检查点：对话与当时所有改动过的文件的内容
+type checkpoint struct {
+	messages []openai.ChatCompletionMessage // 不含系统提示词
+	files    map[string]fileState           // 键为绝对路径
+}
+
+// checkpointStore 本次运行中保存的检查点，以及修改类工具改动过的文件
+type checkpointStore struct {
+	mu          sync.Mutex
+	shadow      *tools.ShadowBranch  // 开启 --auto-commit 时同时在影子分支上提交
+	original    map[string]fileState // 每个文件第一次被改动前的内容
+	checkpoints map[string]*checkpoint
+}
+
+// newCheckpointStore 创建检查点存储，shadow 可以为nil
+func newCheckpointStore(shadow *tools.ShadowBranch) *checkpointStore {
+	return &checkpointStore{
+		shadow:      shadow,
+		original:    make(map[string]fileState),
+		checkpoints: make(map[string]*checkpoint),
+	}
+}
+
+// observe 记录修改类工具改动的文件及其改动前的内容
+func (s *checkpointStore) observe(change tools.FileChange) {
+	path := change.Path
+	if !filepath.IsAbs(path) {
+		// 路径相对于工具的工作目录，/cd 切换时进程的当前目录随之切换
+		dir, _ := os.Getwd()
+		path = filepath.Join(dir, path)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.original[path]; !ok {
+		s.original[path] = fileState{content: change.Before, exists: !change.Created}
+	}
+}
+
+// save 保存对话与所有改动过的文件的当前内容
+func (s *checkpointStore) save(name string, history []openai.ChatCompletionMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	files := make(map[string]fileState, len(s.original))
+	for path := range s.original {
+		files[path] = readFileState(path)
+	}
+	_, replaced := s.checkpoints[name]
+	s.checkpoints[name] = &checkpoint{messages: history, files: files}
+
+	note := ""
+	if s.shadow != nil {
+		if commit, err := s.shadow.Checkpoint("Checkpoint: " + name); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to commit checkpoint: %v\n", err)
+		} else {
+			note = fmt.Sprintf("，影子分支提交 %s", shortHash(commit))
+		}
+	}
+	verb := "已保存"
+	if replaced {
+		verb = "已覆盖"
+	}
+	fmt.Fprintf(os.Stderr, "\n📌 %s检查点 %s（%d 条消息，%d 个改动过的文件%s）\n", verb, name, len(history), len(files), note)
+}
+
+// rollback 将对话与改动过的文件恢复到检查点：检查点之后才改动的文件恢复为第一次改动前的内容。
+// 检查点不存在时返回 false
+func (s *checkpointStore) rollback(name string) ([]openai.ChatCompletionMessage, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp, ok := s.checkpoints[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "\n❌ 没有名为 %s 的检查点%s\n", name, s.names())
+		return nil, false
+	}
+
+	var restored, failed []string
+	for path, original := range s.original {
+		target, ok := cp.files[path]
+		if !ok {
+			target = original
+		}
+		if current := readFileState(path); current == target {
+			continue
+		}
+		var err error
+		if target.exists {
+			if err = os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+				err = os.WriteFile(path, []byte(target.content), 0644)
+			}
+		} else {
+			err = os.Remove(path)
+		}
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", path, err))
+			continue
+		}
+		restored = append(restored, path)
+	}
+	if s.shadow != nil && len(restored) > 0 {
+		if _, err := s.shadow.Checkpoint("Rollback to checkpoint: " + name); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to commit rollback: %v\n", err)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "\n⏪ 已回滚到检查点 %s（恢复了 %d 个文件）\n", name, len(restored))
+	sort.Strings(failed)
+	for _, failure := range failed {
+		fmt.Fprintf(os.Stderr, "   ❌ %s\n", failure)
+	}
+
+	history := append([]openai.ChatCompletionMessage(nil), cp.messages...)
+	history = append(history, openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleUser,
+		Content: fmt.Sprintf("<user_rollback>\nThe user rolled the conversation and the files you edited back to the checkpoint %q. Everything after it was undone, including your edits; continue from this state and do not assume the later changes exist.\n</user_rollback>", name),
+	})
+	return history, true
+}
+
+// names 错误信息中列出已保存的检查点
+func (s *checkpointStore) names() string {
+	if len(s.checkpoints) == 0 {
+		return "（还没有保存检查点）"
+	}
+	names := make([]string, 0, len(s.checkpoints))
+	for name := range s.checkpoints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return "（已保存: " + strings.Join(names, ", ") + "）"
+}
+
+// readFileState 读取文件的当前内容
+func readFileState(path string) fileState {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fileState{}
+	}
+	return fileState{content: string(data), exists: true}
+}
+
+// handleCheckpointCommand 处理运行期间输入的 /checkpoint <名称> 与 /rollback <名称> [补充指令]，
+// 在当前步骤完成后执行。不是这两个命令时返回 false
+func handleCheckpointCommand(aiClient *client.Client, store *checkpointStore, line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || (fields[0] != checkpointCommand && fields[0] != rollbackCommand) {
+		return false
+	}
+	if len(fields) == 1 {
+		fmt.Fprintf(os.Stderr, "❌ 用法: %s <名称>\n", fields[0])
+		return true
+	}
+	name := fields[1]
+
+	if fields[0] == checkpointCommand {
+		aiClient.BetweenSteps(func(history []openai.ChatCompletionMessage) []openai.ChatCompletionMessage {
+			store.save(name, history)
+			return history
+		})
+		fmt.Fprintf(os.Stderr, "📌 将在当前步骤完成后保存检查点 %s\n", name)
+		return true
+	}
+
+	instruction := strings.Join(fields[2:], " ")
+	aiClient.BetweenSteps(func(history []openai.ChatCompletionMessage) []openai.ChatCompletionMessage {
+		restored, ok := store.rollback(name)
+		if !ok {
+			return history
+		}
+		if instruction != "" {
+			aiClient.Steer(instruction)
+		}
+		return restored
+	})
+	fmt.Fprintf(os.Stderr, "⏪ 将在当前步骤完成后回滚到检查点 %s\n", name)
+	return true
+}
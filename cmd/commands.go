@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"openCursor/internal/commands"
+	"openCursor/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+// runCommandCmd 运行用户定义的命令
+var runCommandCmd = &cobra.Command{
+	Use:   "run-command [name] [args...]",
+	Short: "Run a custom command defined in .opencursor/commands",
+	Long: `Run a user-defined command: a Markdown file in .opencursor/commands/<name>.md of the
+project (or ~/.opencursor/commands/<name>.md for all projects) whose content is a
+prompt template. Without a name the available commands are listed.
+
+The same commands can be used as the query of the root command, e.g.
+openCursor "/fix-imports ./internal/api", which also accepts every root flag.
+
+Command file (.opencursor/commands/add-logging.md):
+  ---
+  description: Add structured logging to a package
+  args:
+    - name: package
+      required: true
+    - name: level
+      default: info
+  ---
+  Add structured logging at {{level}} level to every exported function in {{package}}.
+  Use the logger the project already uses.
+
+Placeholders: {{name}} for a declared argument, $1 … $9 for positional arguments and
+$ARGUMENTS for all of them. Arguments are given in order or as name=value. Without
+any placeholder the arguments are appended to the prompt.
+
+Examples:
+  openCursor run-command
+  openCursor run-command add-logging ./internal/api
+  openCursor run-command add-logging package=./internal/api level=debug`,
+	Args: cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		available, err := loadCommands()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if len(args) == 0 {
+			if len(available) == 0 {
+				fmt.Println("No custom commands found in .opencursor/commands or ~/.opencursor/commands")
+				return
+			}
+			writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			for _, command := range available {
+				fmt.Fprintf(writer, "%s\t%s\n", command.Usage(), command.Description)
+			}
+			writer.Flush()
+			return
+		}
+
+		command, ok := commands.Find(available, args[0])
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: unknown command %q%s\n", args[0], commandNames(available))
+			os.Exit(1)
+		}
+		query, err := command.Expand(args[1:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		rootCmd.Run(cmd, []string{query})
+	},
+}
+
+// loadCommands 读取项目与用户级的自定义命令
+func loadCommands() ([]*commands.Command, error) {
+	workDir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	return commands.Load(commands.Dirs(workDir, config.DefaultDir())...)
+}
+
+// expandSlashCommand 查询以 /<name> 开头且存在同名自定义命令时展开为命令的提示词，
+// 否则原样返回（如以 / 开头的路径）
+func expandSlashCommand(query string) (string, error) {
+	if !strings.HasPrefix(query, "/") {
+		return query, nil
+	}
+	name, rest := strings.TrimSpace(query), ""
+	if i := strings.IndexAny(name, " \t\n"); i >= 0 {
+		name, rest = name[:i], name[i+1:]
+	}
+	available, err := loadCommands()
+	if err != nil {
+		return "", err
+	}
+	command, ok := commands.Find(available, name)
+	if !ok {
+		return query, nil
+	}
+	args, err := commands.SplitArgs(rest)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	expanded, err := command.Expand(args)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(progress, "📜 %s → %s\n", name, command.Path)
+	return expanded, nil
+}
+
+// commandNames 错误信息中列出可用的命令
+func commandNames(available []*commands.Command) string {
+	if len(available) == 0 {
+		return ""
+	}
+	names := make([]string, len(available))
+	for i, command := range available {
+		names[i] = command.Name
+	}
+	return " (available: " + strings.Join(names, ", ") + ")"
+}
+
+func init() {
+	runCommandCmd.ValidArgsFunction = completeCustomCommands
+	rootCmd.AddCommand(runCommandCmd)
+}
\ No newline at end of file
@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"openCursor/internal/client"
+
+	"github.com/spf13/cobra"
+)
+
+// complete 命令参数
+var (
+	completeFile      string   // --file 要补全的文件
+	completeLine      int      // --line 光标所在行（从1开始）
+	completeCol       int      // --col 光标所在列（从1开始，按字符计）
+	completeStdin     bool     // --stdin 从标准输入读取文件内容（编辑器中未保存的缓冲区）
+	completeURL       string   // --fim-url FIM 接口地址
+	completeModel     string   // --fim-model FIM 模型
+	completeFormat    string   // --fim-format 提示格式
+	completeMaxTokens int      // --max-tokens 补全的最大token数
+	completeStop      []string // --stop 额外的停止序列
+	completeJSON      bool     // --json 以JSON输出
+)
+
+// 光标前后发送给模型的内容上限（字节），超出时从较远处按行截掉
+const (
+	completeMaxPrefix = 12000
+	completeMaxSuffix = 4000
+)
+
+// completeCmd 填充中间（FIM）代码补全
+var completeCmd = &cobra.Command{
+	Use:   "complete --file <path> --line <n> --col <n>",
+	Short: "Complete code at a cursor position with a fill-in-the-middle model",
+	Long: `Return the text to insert at a cursor position, using a fill-in-the-middle (FIM)
+completion endpoint: the code before and after the cursor is sent and the model
+generates what goes in between. Meant for editor plugins that want inline
+completions from openCursor alongside chat. The insertion is written to stdout
+as-is (no trailing newline is added); with --json as {"text", "model", "line", "col"}.
+
+--line and --col are 1-based; --col counts characters, and may point just past
+the end of the line. With --stdin the content of the file is read from stdin, so
+a plugin can send an unsaved buffer; --file is then only used for its name.
+
+The endpoint is --fim-url, FIM_BASE_URL, or the API base URL (BASE_URL); for
+api.deepseek.com the /beta endpoint, which serves FIM, is used automatically. The
+model is --fim-model, FIM_MODEL or MODEL. --fim-format selects how the code around
+the cursor is sent:
+  suffix          the suffix parameter of the completions API (DeepSeek, default)
+  starcoder       <fim_prefix>/<fim_suffix>/<fim_middle> tokens in the prompt
+                  (StarCoder models served by TGI, vLLM, Ollama, ...)
+  deepseek-coder  the FIM tokens of self-hosted DeepSeek Coder models
+
+Examples:
+  openCursor complete --file main.go --line 120 --col 8
+  openCursor complete --file main.go --line 12 --col 1 --stop "\n\n" --json
+  FIM_BASE_URL=http://localhost:8080/v1 openCursor complete --fim-format starcoder --file app.py --line 3 --col 5`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if completeFile == "" {
+			fmt.Fprintf(os.Stderr, "Error: --file is required\n")
+			os.Exit(1)
+		}
+		if !validFIMFormat(completeFormat) {
+			fmt.Fprintf(os.Stderr, "Error: unknown --fim-format %q (available: %s)\n", completeFormat, strings.Join(client.FIMFormats, ", "))
+			os.Exit(1)
+		}
+
+		var content []byte
+		var err error
+		if completeStdin {
+			content, err = io.ReadAll(os.Stdin)
+		} else {
+			content, err = os.ReadFile(completeFile)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		prefix, suffix, err := splitAtCursor(string(content), completeLine, completeCol)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", completeFile, err)
+			os.Exit(1)
+		}
+		prefix, suffix = trimCompletionContext(prefix, suffix)
+
+		apiKey, baseURL, model := loadAPISettings()
+		if completeModel != "" {
+			model = completeModel
+		} else if env := os.Getenv("FIM_MODEL"); env != "" {
+			model = env
+		}
+		fimURL := completeURL
+		if fimURL == "" {
+			fimURL = os.Getenv("FIM_BASE_URL")
+		}
+		if fimURL == "" {
+			fimURL = fimBaseURL(baseURL)
+		}
+
+		// 停止序列中的 \n 与 \t 按换行与制表符处理，便于在命令行中指定
+		stops := make([]string, len(completeStop))
+		for i, stop := range completeStop {
+			stops[i] = strings.NewReplacer(`\n`, "\n", `\t`, "\t").Replace(stop)
+		}
+
+		aiClient := client.NewClient(apiKey, fimURL, model)
+		text, err := aiClient.FillInMiddle(prefix, suffix, client.FIMOptions{
+			Format:    completeFormat,
+			MaxTokens: completeMaxTokens,
+			Stop:      stops,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitCodeFor(err))
+		}
+
+		if completeJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			result := map[string]interface{}{"text": text, "model": model, "line": completeLine, "col": completeCol}
+			if err := encoder.Encode(result); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		fmt.Print(text)
+	},
+}
+
+// validFIMFormat 是否为支持的FIM提示格式
+func validFIMFormat(format string) bool {
+	for _, name := range client.FIMFormats {
+		if format == name {
+			return true
+		}
+	}
+	return false
+}
+
+// fimBaseURL FIM 接口地址：DeepSeek 的 FIM 只在 /beta 下提供，其他服务使用原地址
+func fimBaseURL(baseURL string) string {
+	parsed, err := url.Parse(baseURL)
+	if err != nil || parsed.Host != "api.deepseek.com" {
+		return baseURL
+	}
+	parsed.Path = "/beta"
+	return parsed.String()
+}
+
+// splitAtCursor 按光标位置（行列从1开始，列按字符计）将内容分为前后两部分
+func splitAtCursor(content string, line, col int) (string, string, error) {
+	lines := strings.SplitAfter(content, "\n")
+	if line < 1 || line > len(lines) {
+		return "", "", fmt.Errorf("line %d is out of range (1-%d)", line, len(lines))
+	}
+	offset := 0
+	for _, text := range lines[:line-1] {
+		offset += len(text)
+	}
+	current := []rune(strings.TrimSuffix(lines[line-1], "\n"))
+	if col < 1 || col > len(current)+1 {
+		return "", "", fmt.Errorf("column %d is out of range for line %d (1-%d)", col, line, len(current)+1)
+	}
+	offset += len(string(current[:col-1]))
+	return content[:offset], content[offset:], nil
+}
+
+// trimCompletionContext 光标前后的内容超出上限时，从离光标较远的一端按整行截掉
+func trimCompletionContext(prefix, suffix string) (string, string) {
+	if len(prefix) > completeMaxPrefix {
+		prefix = prefix[len(prefix)-completeMaxPrefix:]
+		if newline := strings.IndexByte(prefix, '\n'); newline >= 0 {
+			prefix = prefix[newline+1:]
+		}
+	}
+	if len(suffix) > completeMaxSuffix {
+		suffix = suffix[:completeMaxSuffix]
+		if newline := strings.LastIndexByte(suffix, '\n'); newline >= 0 {
+			suffix = suffix[:newline+1]
+		}
+	}
+	return prefix, suffix
+}
+
+func init() {
+	completeCmd.Flags().StringVar(&completeFile, "file", "", "File to complete (with --stdin only used for its name)")
+	completeCmd.Flags().IntVar(&completeLine, "line", 1, "Line of the cursor (1-based)")
+	completeCmd.Flags().IntVar(&completeCol, "col", 1, "Column of the cursor in characters (1-based)")
+	completeCmd.Flags().BoolVar(&completeStdin, "stdin", false, "Read the content of the file from stdin (e.g. an unsaved editor buffer)")
+	completeCmd.Flags().StringVar(&completeURL, "fim-url", "", "Base URL of the FIM completions endpoint (default: FIM_BASE_URL, or BASE_URL with /beta for DeepSeek)")
+	completeCmd.Flags().StringVar(&completeModel, "fim-model", "", "Model for the completion (default: FIM_MODEL or MODEL)")
+	completeCmd.Flags().StringVar(&completeFormat, "fim-format", client.FIMFormatSuffix, fmt.Sprintf("How the code around the cursor is sent (%s)", strings.Join(client.FIMFormats, ", ")))
+	completeCmd.Flags().IntVar(&completeMaxTokens, "max-tokens", 128, "Maximum number of tokens to generate")
+	completeCmd.Flags().StringArrayVar(&completeStop, "stop", nil, "Stop the completion at this sequence, \\n and \\t meaning newline and tab (repeatable)")
+	completeCmd.Flags().BoolVar(&completeJSON, "json", false, "Print the completion as JSON")
+	completeCmd.RegisterFlagCompletionFunc("fim-format", completeValues(client.FIMFormats...))
+	rootCmd.AddCommand(completeCmd)
+}
@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"openCursor/internal/config"
+	"openCursor/internal/roles"
+	"openCursor/internal/session"
+	"openCursor/internal/tools"
+	"openCursor/internal/workflow"
+
+	"github.com/spf13/cobra"
+)
+
+// completionDescriptionLength 补全候选说明的最大长度
+const completionDescriptionLength = 60
+
+// completionCmd 生成shell补全脚本
+var completionCmd = &cobra.Command{
+	Use:   "completion <bash|zsh|fish|powershell>",
+	Short: "Generate the shell completion script",
+	Long: `Generate the completion script for bash, zsh, fish or PowerShell. Besides
+commands and flags it completes role names, saved session and workflow run IDs,
+optional tools, custom /commands, schedule names and config keys.
+
+Bash (requires bash-completion):
+  source <(openCursor completion bash)
+  # permanently:
+  openCursor completion bash > ~/.local/share/bash-completion/completions/openCursor
+
+Zsh:
+  openCursor completion zsh > "${fpath[1]}/_openCursor"
+  # compinit must be enabled, e.g. autoload -U compinit; compinit in ~/.zshrc
+
+Fish:
+  openCursor completion fish > ~/.config/fish/completions/openCursor.fish
+
+PowerShell:
+  openCursor completion powershell | Out-String | Invoke-Expression
+  # permanently: add the line above to $PROFILE`,
+	Args:                  cobra.ExactValidArgs(1),
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch args[0] {
+		case "bash":
+			return rootCmd.GenBashCompletionV2(os.Stdout, true)
+		case "zsh":
+			return rootCmd.GenZshCompletion(os.Stdout)
+		case "fish":
+			return rootCmd.GenFishCompletion(os.Stdout, true)
+		default:
+			return rootCmd.GenPowerShellCompletionWithDesc(os.Stdout)
+		}
+	},
+}
+
+// completeValues 补全固定的取值
+func completeValues(values ...string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeDirs 补全目录
+func completeDirs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return nil, cobra.ShellCompDirectiveFilterDirs
+}
+
+// completeRoles 补全内置与配置文件中的角色
+func completeRoles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var custom map[string]roles.Role
+	if cfg, err := config.Load(configPath); err == nil {
+		custom = cfg.Roles
+	}
+	all := roles.Merge(custom)
+	var candidates []string
+	for _, name := range roles.Names(all) {
+		candidates = append(candidates, completionCandidate(name, all[name].Description))
+	}
+	return candidates, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeSessions 补全保存的会话ID，最近的在前，说明为会话的查询
+func completeSessions(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	sessions, _ := session.List(config.SessionsDir())
+	var candidates []string
+	for _, s := range sessions {
+		candidates = append(candidates, completionCandidate(s.ID, s.Query))
+	}
+	return candidates, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+// completeWorkflowRuns 补全工作流运行记录ID
+func completeWorkflowRuns(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	runs, _ := workflow.ListRuns(config.WorkflowsDir())
+	var candidates []string
+	for _, run := range runs {
+		candidates = append(candidates, completionCandidate(run.ID, run.Workflow))
+	}
+	return candidates, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+// completeWorkflowSteps 补全工作流文件（第一个参数）中的步骤名
+func completeWorkflowSteps(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	wf, err := workflow.Load(args[0])
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var candidates []string
+	for _, step := range wf.Steps {
+		candidates = append(candidates, step.Name)
+	}
+	return candidates, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+// completeWorkflowFile 第一个参数补全YAML文件
+func completeWorkflowFile(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return []string{"yaml", "yml"}, cobra.ShellCompDirectiveFilterFileExt
+}
+
+// completeImages 补全图片文件
+func completeImages(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return []string{"png", "jpg", "jpeg", "gif", "webp"}, cobra.ShellCompDirectiveFilterFileExt
+}
+
+// completeSchemaFile 补全 JSON Schema 文件
+func completeSchemaFile(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return []string{"json"}, cobra.ShellCompDirectiveFilterFileExt
+}
+
+// completeOptionalTools 补全可选工具名
+func completeOptionalTools(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return tools.OptionalToolNames(), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeQuery 查询的第一个词以 / 开头时补全自定义命令，其他词补全文件名（查询可以不加引号）
+func completeQuery(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 || !strings.HasPrefix(toComplete, "/") {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	available, _ := loadCommands()
+	var candidates []string
+	for _, command := range available {
+		candidates = append(candidates, completionCandidate("/"+command.Name, command.Description))
+	}
+	return candidates, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeCustomCommands 第一个参数补全自定义命令名
+func completeCustomCommands(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	available, _ := loadCommands()
+	var candidates []string
+	for _, command := range available {
+		candidates = append(candidates, completionCandidate(command.Name, command.Description))
+	}
+	return candidates, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeSchedules 补全配置文件中的定时任务名
+func completeSchedules(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var candidates []string
+	for _, job := range cfg.Schedules {
+		candidates = append(candidates, completionCandidate(job.Name, job.Cron))
+	}
+	return candidates, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeConfigKeys 第一个参数补全配置项：已设置的键及所有顶层键
+func completeConfigKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	seen := make(map[string]bool)
+	var candidates []string
+	settings, _ := config.List(configPath)
+	for _, setting := range settings {
+		seen[setting.Key] = true
+		candidates = append(candidates, completionCandidate(setting.Key, setting.Value))
+	}
+	for _, key := range config.Keys() {
+		if !seen[key] {
+			candidates = append(candidates, key)
+		}
+	}
+	return candidates, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completionCandidate 带说明的补全候选，说明只取第一行并截断
+func completionCandidate(value, description string) string {
+	description = firstLine(description)
+	if runes := []rune(description); len(runes) > completionDescriptionLength {
+		description = string(runes[:completionDescriptionLength-1]) + "…"
+	}
+	if description == "" {
+		return value
+	}
+	return value + "\t" + description
+}
+
+func init() {
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	rootCmd.AddCommand(completionCmd)
+}
+
+// completeTrashSessions 第一个参数补全回收站中的会话
+func completeTrashSessions(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	workDir, err := os.Getwd()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	sessions, _ := tools.ListTrash(workDir)
+	candidates := make([]string, 0, len(sessions))
+	for _, sess := range sessions {
+		candidates = append(candidates, completionCandidate(sess.ID, strconv.Itoa(len(sess.Entries))+" deleted"))
+	}
+	return candidates, cobra.ShellCompDirectiveNoFileComp
+}
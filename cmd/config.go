@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"openCursor/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+// configCmd 查看与修改配置文件
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Read and change settings in the config file",
+	Long: `Read and change settings in the config file (--config, default
+~/.opencursor/config.yaml) without editing the YAML by hand.
+
+Keys are dotted paths into the file, e.g. model, approval or
+rate_limits.default.max_concurrent. Quote map keys that contain dots:
+rate_limits."api.deepseek.com".requests_per_minute. List items are addressed
+by index, e.g. enable_tools.0.
+
+Values are parsed as YAML, so true, 42 and [browser, lsp] have their usual types.
+Changes are validated before they are written; comments and other settings in
+the file are preserved. See openCursor --help for the available settings.
+
+Examples:
+  openCursor config list
+  openCursor config get model
+  openCursor config set model deepseek-reasoner
+  openCursor config set approval ask
+  openCursor config set enable_tools "[browser]"
+  openCursor config set rate_limits.default.max_concurrent 2
+  openCursor config unset approval`,
+}
+
+// configListCmd 列出所有设置
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the settings in the config file",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		settings, err := config.List(configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(settings) == 0 {
+			fmt.Printf("%s 中没有任何设置\n", configPath)
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, setting := range settings {
+			fmt.Fprintf(w, "%s\t%s\n", setting.Key, setting.Value)
+		}
+		w.Flush()
+	},
+}
+
+// configGetCmd 读取一项设置
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print a setting from the config file",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		value, err := config.Get(configPath, args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(value)
+	},
+}
+
+// configSetCmd 修改一项设置
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Change a setting in the config file",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := config.Set(configPath, args[0], args[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ 已设置 %s = %s\n", args[0], args[1])
+	},
+}
+
+// configUnsetCmd 删除一项设置
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Remove a setting from the config file",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := config.Unset(configPath, args[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("🗑  已删除 %s\n", args[0])
+	},
+}
+
+func init() {
+	configGetCmd.ValidArgsFunction = completeConfigKeys
+	configSetCmd.ValidArgsFunction = completeConfigKeys
+	configUnsetCmd.ValidArgsFunction = completeConfigKeys
+	configCmd.AddCommand(configListCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	rootCmd.AddCommand(configCmd)
+}
\ No newline at end of file
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"openCursor/internal/config"
+	"openCursor/internal/daemon"
+	"openCursor/internal/schedule"
+
+	"github.com/spf13/cobra"
+)
+
+// daemon 命令参数
+var (
+	daemonQueueDir      string        // --queue 队列目录
+	daemonWorkers       int           // --workers 同时运行的任务数
+	daemonPoll          time.Duration // --poll 检查队列的间隔
+	daemonNoCheckpoints bool          // --no-checkpoints 不为任务开启 --autocommit
+	daemonWorkDir       string        // submit --workdir 任务的工作目录
+	daemonTimeout       string        // submit --timeout 任务超时
+	daemonArgs          []string      // submit --arg 追加给 openCursor 的参数
+)
+
+// daemonCmd 在后台处理任务队列
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Process queued tasks autonomously",
+	Long: `Watch a queue directory and process the tasks dropped into it one after another,
+without supervision, for "fire and forget" batch work such as large refactors.
+
+Queue layout (default ~/.opencursor/queue):
+  pending/    Tasks waiting to run: <id>.json as written by "daemon submit",
+              or a .md/.txt file whose content is the prompt
+  running/    Tasks claimed by the daemon
+  done/       Results of successful tasks (<id>.json)
+  failed/     Results of failed, timed out or interrupted tasks
+  artifacts/  Per run: output.log, changes.patch and run.json
+  audit.log   Every daemon and task event as one JSON object per line
+
+Each task runs as a separate, non-interactive openCursor process in its workdir.
+Unless --no-checkpoints is given it runs with --autocommit, so every change is
+committed to an opencursor/<session> branch that can be inspected and rolled back.
+Tasks left in running/ by a daemon that stopped are moved to failed/, not re-run.
+
+Task file (pending/<id>.json):
+  {"prompt": "...", "workdir": "~/src/app", "args": ["--repo-map"], "timeout": "45m"}
+
+Examples:
+  openCursor daemon
+  openCursor daemon --workers 2
+  openCursor daemon submit "Migrate all handlers to the new logger" --workdir ~/src/api
+  openCursor daemon status`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		queue := &daemon.Queue{Dir: expandHome(daemonQueueDir)}
+
+		executable, err := os.Executable()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to locate the openCursor executable: %v\n", err)
+			os.Exit(1)
+		}
+		workDir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to get current directory: %v\n", err)
+			os.Exit(1)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		d := daemon.New(queue, daemon.Options{
+			Workers:      daemonWorkers,
+			PollInterval: daemonPoll,
+			WorkDir:      workDir,
+			Checkpoints:  !daemonNoCheckpoints,
+			Run: schedule.RunOptions{
+				Executable:   executable,
+				BaseArgs:     []string{"--config", configPath},
+				ArtifactsDir: filepath.Join(queue.Dir, "artifacts"),
+			},
+			Log: os.Stdout,
+		})
+		if err := d.Start(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// daemonSubmitCmd 将任务加入队列
+var daemonSubmitCmd = &cobra.Command{
+	Use:   "submit <prompt>",
+	Short: "Add a task to the daemon's queue",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		workDir := daemonWorkDir
+		if workDir == "" {
+			workDir, _ = os.Getwd()
+		}
+		workDir, err := filepath.Abs(expandHome(workDir))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if daemonTimeout != "" {
+			if d, err := time.ParseDuration(daemonTimeout); err != nil || d <= 0 {
+				fmt.Fprintf(os.Stderr, "Error: invalid timeout %q\n", daemonTimeout)
+				os.Exit(1)
+			}
+		}
+
+		queue := &daemon.Queue{Dir: expandHome(daemonQueueDir)}
+		id, err := queue.Submit(daemon.Task{
+			Prompt:  args[0],
+			WorkDir: workDir,
+			Args:    daemonArgs,
+			Timeout: daemonTimeout,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Queued task %s\n", id)
+	},
+}
+
+// daemonStatusCmd 列出队列中的任务
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "List the tasks in the daemon's queue",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		queue := &daemon.Queue{Dir: expandHome(daemonQueueDir)}
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "STATE\tTASK\tUPDATED\tDETAILS")
+		for _, state := range []string{daemon.StateRunning, daemon.StatePending, daemon.StateFailed, daemon.StateDone} {
+			entries, err := queue.List(state)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			for _, entry := range entries {
+				fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", state, entry.ID, entry.Modified.Format("2006-01-02 15:04"), taskDetails(entry))
+			}
+		}
+		writer.Flush()
+	},
+}
+
+// taskDetails 状态列表中任务的简要说明：待处理任务为提示词，已完成任务为运行摘要
+func taskDetails(entry daemon.Entry) string {
+	if entry.State == daemon.StateDone || entry.State == daemon.StateFailed {
+		var result daemon.Result
+		data, err := os.ReadFile(entry.Path)
+		if err != nil || json.Unmarshal(data, &result) != nil {
+			return ""
+		}
+		if result.Record != nil {
+			return schedule.Summary(result.Record)
+		}
+		return result.Error
+	}
+
+	task, err := daemon.ReadTask(entry)
+	if err != nil {
+		return err.Error()
+	}
+	prompt := firstLine(task.Prompt)
+	if len([]rune(prompt)) > 60 {
+		prompt = string([]rune(prompt)[:60]) + "…"
+	}
+	return prompt
+}
+
+func init() {
+	daemonCmd.PersistentFlags().StringVar(&daemonQueueDir, "queue", filepath.Join(config.DefaultDir(), "queue"), "Queue directory")
+	daemonCmd.Flags().IntVar(&daemonWorkers, "workers", 1, "Number of tasks run at the same time")
+	daemonCmd.Flags().DurationVar(&daemonPoll, "poll", daemon.DefaultPollInterval, "How often the queue is checked for new tasks")
+	daemonCmd.Flags().BoolVar(&daemonNoCheckpoints, "no-checkpoints", false, "Do not run tasks with --autocommit")
+	daemonSubmitCmd.Flags().StringVar(&daemonWorkDir, "workdir", "", "Directory the task runs in (default: the current directory)")
+	daemonSubmitCmd.Flags().StringVar(&daemonTimeout, "timeout", "", "Timeout of the task, e.g. 45m (default 30m)")
+	daemonSubmitCmd.Flags().StringArrayVar(&daemonArgs, "arg", nil, "Extra openCursor flag for the task (repeatable), e.g. --arg=--repo-map")
+	daemonCmd.AddCommand(daemonSubmitCmd)
+	daemonCmd.AddCommand(daemonStatusCmd)
+	rootCmd.AddCommand(daemonCmd)
+}
\ No newline at end of file
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"openCursor/internal/config"
+	"openCursor/internal/tools"
+)
+
+// dryRunPrompt 试运行时追加到系统提示词的说明
+const dryRunPrompt = `This is a DRY RUN. File edits are recorded in a change plan instead of being written to disk; reading a file you changed returns the planned content. Commands, tests and git operations are recorded but NOT executed, so their output is unavailable: do not retry them or wait for their results. Make the complete set of changes the task needs, then summarize them.`
+
+// finishDryRun 输出试运行的修改计划，有文件修改时保存以便之后用 apply-plan 应用
+func finishDryRun(workDir, query, sessionID string) {
+	plan := tools.DryRunPlan(workDir)
+	plan.Query = query
+	plan.SessionID = sessionID
+	printChangePlan(os.Stdout, plan)
+	if len(plan.Files) == 0 {
+		return
+	}
+	path := planFile
+	if path == "" {
+		path = filepath.Join(config.PlansDir(), sessionID+".json")
+	}
+	if err := tools.SaveChangePlan(path, plan); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		return
+	}
+	fmt.Fprintf(progress, "\n💾 修改计划已保存，应用: openCursor apply-plan %s\n", path)
+}
+
+// printChangePlan 输出试运行的修改计划：每个文件的diff与没有执行的命令
+func printChangePlan(w io.Writer, plan *tools.ChangePlan) {
+	if plan.Empty() {
+		fmt.Fprintf(w, "\n📋 修改计划: 没有修改\n")
+		return
+	}
+	fmt.Fprintf(w, "\n📋 修改计划（试运行，未写入磁盘）: %d 个文件，%d 条命令\n", len(plan.Files), len(plan.Commands))
+	for _, file := range plan.Files {
+		fmt.Fprintf(w, "\n%s %s\n", planActionLabel(file.Action), file.Path)
+		fmt.Fprint(w, file.Diff)
+	}
+	if len(plan.Commands) > 0 {
+		fmt.Fprintf(w, "\n未执行的命令:\n")
+		for _, command := range plan.Commands {
+			fmt.Fprintf(w, "  $ %s\n", command.Command)
+			if command.Explanation != "" {
+				fmt.Fprintf(w, "    # %s\n", firstLine(strings.TrimSpace(command.Explanation)))
+			}
+		}
+	}
+}
+
+// planActionLabel 修改类型的显示名称
+func planActionLabel(action string) string {
+	switch action {
+	case tools.PlanCreate:
+		return "新建"
+	case tools.PlanDelete:
+		return "删除"
+	default:
+		return "修改"
+	}
+}
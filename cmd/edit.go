@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"openCursor/internal/client"
+	"openCursor/internal/config"
+	"openCursor/internal/tools"
+
+	"github.com/spf13/cobra"
+)
+
+// edit 命令参数
+var (
+	editYes           bool // --yes 不确认直接应用
+	editMaxIterations int  // --max-iterations 允许的最多模型调用轮数
+)
+
+// editMaxFileBytes 可编辑文件的大小上限（整个文件会附加到提示词中）
+const editMaxFileBytes = 256 * 1024
+
+// editPromptTemplate 单文件编辑的指令，%s 依次为文件路径与用户的要求
+const editPromptTemplate = `Edit the file %[1]s attached above as follows:
+
+%[2]s
+
+The attached content is the complete, current file. Make the change with the edit tools
+(search_replace for targeted changes, write_file only when most of the file changes),
+always using the relative path %[1]s. Only this file can be changed and no commands can
+be run. Keep the existing style, and do not touch unrelated code.
+Finish with one or two sentences describing the change.`
+
+// editCmd 限定在单个文件上的快速编辑
+var editCmd = &cobra.Command{
+	Use:   "edit <file> <instruction>",
+	Short: "Edit a single file and apply the change after confirmation",
+	Long: `Scope the agent to one file: the whole file is given to the model, which can only use
+the file edit tools on it. The agent works on a scratch copy; the resulting diff is
+shown and the change is written to the file only after confirmation (or with --yes).
+
+This is a much tighter loop than the general agent for quick, local changes.
+
+Examples:
+  openCursor edit main.go "Add a --verbose flag"
+  openCursor edit src/api/client.ts "Retry failed requests up to 3 times"
+  openCursor edit config.yaml "Raise the connection pool size to 50" --yes`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		file, instruction := args[0], args[1]
+
+		workDir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to get current directory: %v\n", err)
+			os.Exit(1)
+		}
+		path, err := filepath.Abs(expandHome(file))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			fmt.Fprintf(os.Stderr, "Error: %s is not a file\n", file)
+			os.Exit(1)
+		}
+		if info.Size() > editMaxFileBytes {
+			fmt.Fprintf(os.Stderr, "Error: %s is too large for edit (%d bytes, limit %d); use the agent instead\n", file, info.Size(), editMaxFileBytes)
+			os.Exit(1)
+		}
+		original, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if bytes.IndexByte(original, 0) >= 0 {
+			fmt.Fprintf(os.Stderr, "Error: %s is a binary file\n", file)
+			os.Exit(1)
+		}
+
+		// 文件在草稿目录中的相对路径，工作目录之外的文件只保留文件名
+		rel, err := filepath.Rel(workDir, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			rel = filepath.Base(path)
+		}
+
+		apiKey, baseURL, model := loadAPISettings()
+
+		// 加载配置文件
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		// 草稿目录：original/ 保存原文件用于diff，edited/ 作为代理的工作目录
+		scratch, err := os.MkdirTemp("", "opencursor-edit-")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer os.RemoveAll(scratch)
+		editedDir := filepath.Join(scratch, "edited")
+		for _, dir := range []string{"original", "edited"} {
+			copyPath := filepath.Join(scratch, dir, rel)
+			err := os.MkdirAll(filepath.Dir(copyPath), 0755)
+			if err == nil {
+				err = os.WriteFile(copyPath, original, 0644)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: Failed to create scratch copy: %v\n", err)
+				os.RemoveAll(scratch)
+				os.Exit(1)
+			}
+		}
+
+		// 只注册编辑工具，工作目录为草稿目录
+		err = setupAgentTools(cfg, baseURL, agentToolSetup{register: tools.RegisterDefaultEditTools, skipApproval: true, workDir: editedDir})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.RemoveAll(scratch)
+			os.Exit(1)
+		}
+
+		displayPath := filepath.ToSlash(rel)
+		aiClient := client.NewClient(apiKey, baseURL, model)
+		aiClient.SetToolManager(tools.GetDefaultManager())
+		aiClient.SetMaxIterations(editMaxIterations)
+		aiClient.AddContext("file", fmt.Sprintf("%s\n```\n%s\n```", displayPath, strings.TrimRight(string(original), "\n")))
+
+		if err := aiClient.StreamQueryWithTools(fmt.Sprintf(editPromptTemplate, displayPath, instruction)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.RemoveAll(scratch)
+			os.Exit(1)
+		}
+
+		edited, err := os.ReadFile(filepath.Join(editedDir, rel))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: the edited file is missing: %v\n", err)
+			os.RemoveAll(scratch)
+			os.Exit(1)
+		}
+		if bytes.Equal(edited, original) {
+			fmt.Printf("\nNo changes to %s\n", displayPath)
+			return
+		}
+
+		fmt.Printf("\n%s", editDiff(scratch, rel))
+		if !editYes && !confirm(fmt.Sprintf("应用对 %s 的修改? [y/N] ", displayPath)) {
+			fmt.Println("未应用修改")
+			return
+		}
+
+		// 会话期间文件被其他程序修改时不覆盖
+		current, err := os.ReadFile(path)
+		if err != nil || !bytes.Equal(current, original) {
+			fmt.Fprintf(os.Stderr, "Error: %s changed while editing; not applied\n", file)
+			os.RemoveAll(scratch)
+			os.Exit(1)
+		}
+		if err := os.WriteFile(path, edited, info.Mode().Perm()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to write %s: %v\n", file, err)
+			os.RemoveAll(scratch)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ 已修改 %s\n", displayPath)
+	},
+}
+
+// editDiff 原文件与修改后文件的统一diff，路径显示为相对路径
+func editDiff(scratch, rel string) string {
+	rel = filepath.ToSlash(rel)
+	command := exec.Command("git", "diff", "--no-index", "--no-color", "--no-ext-diff", "--", "original/"+rel, "edited/"+rel)
+	command.Dir = scratch
+	output, err := command.Output()
+	// 有差异时 git diff --no-index 以状态1退出
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		err = nil
+	}
+	if err != nil || len(output) == 0 {
+		return fmt.Sprintf("(cannot show the diff: git is required)\n+++ %s (edited)\n%s\n", rel, readFileOrEmpty(filepath.Join(scratch, "edited", rel)))
+	}
+
+	// 只替换文件头中的草稿目录前缀
+	lines := strings.SplitAfter(string(output), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "diff --git ") || strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "+++ ") {
+			line = strings.ReplaceAll(line, "a/original/", "a/")
+			lines[i] = strings.ReplaceAll(line, "b/edited/", "b/")
+		}
+		if strings.HasPrefix(line, "@@") {
+			break
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+// readFileOrEmpty 读取文件内容，失败时返回空字符串
+func readFileOrEmpty(path string) string {
+	data, _ := os.ReadFile(path)
+	return string(data)
+}
+
+// confirm 在终端上请求确认，无法读取输入时视为拒绝
+func confirm(prompt string) bool {
+	fmt.Print(prompt)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Println()
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func init() {
+	editCmd.Flags().BoolVarP(&editYes, "yes", "y", false, "Apply the change without asking for confirmation")
+	editCmd.Flags().IntVar(&editMaxIterations, "max-iterations", 10, "Model calls allowed, including tool calls")
+	rootCmd.AddCommand(editCmd)
+}
\ No newline at end of file
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"openCursor/internal/client"
+)
+
+// eventsStderr --events 不带值时写到标准错误
+const eventsStderr = "stderr"
+
+// eventsOutput --events 不带路径时事件写入的原标准错误
+var eventsOutput = os.Stderr
+
+// reserveStderrForEvents 标准错误只留给事件：之后写到 os.Stderr 的警告与错误改写到标准输出，避免与事件交错
+func reserveStderrForEvents() {
+	eventsOutput = os.Stderr
+	os.Stderr = os.Stdout
+}
+
+// openEventStream 打开 --events 的目标（stderr 或文件、命名管道的路径），
+// 返回将事件逐行写为 JSON 的回调与关闭目标的函数
+func openEventStream(target string) (func(client.Event), func(), error) {
+	var out io.Writer = eventsOutput
+	closeFn := func() {}
+	if target != eventsStderr {
+		// 命名管道在有读取方之前会阻塞
+		file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open event stream: %w", err)
+		}
+		out = file
+		closeFn = func() { file.Close() }
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetEscapeHTML(false)
+	handler := func(event client.Event) {
+		encoder.Encode(event)
+	}
+	return handler, closeFn, nil
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"errors"
+
+	"openCursor/internal/client"
+)
+
+// 运行查询的退出码，供脚本与 CI 按结果分支而不必解析输出
+const (
+	exitOK             = 0   // 完成（--exit-code 时为完成并修改了文件）
+	exitError          = 1   // 其他错误（参数、配置、网络等）
+	exitNoChanges      = 2   // --exit-code：完成但没有修改任何文件（--ask 总是如此）
+	exitAuthFailed     = 3   // 没有 API 密钥或密钥被拒绝
+	exitModelError     = 4   // 模型服务返回错误（模型不存在、额度用尽、限流、服务端错误等）
+	exitToolDenied     = 5   // --exit-code：完成，但有命令被拒绝执行
+	exitBudgetExceeded = 6   // 超出 --max-tokens 或 --max-cost
+	exitInterrupted    = 130 // 被 Ctrl+C 中断
+)
+
+// exitCodeFor 运行失败时的退出码
+func exitCodeFor(err error) int {
+	var provider *client.ProviderError
+	switch {
+	case errors.Is(err, client.ErrInterrupted):
+		return exitInterrupted
+	case errors.Is(err, client.ErrBudgetExceeded):
+		return exitBudgetExceeded
+	case client.IsAuthError(err):
+		return exitAuthFailed
+	case errors.As(err, &provider):
+		return exitModelError
+	default:
+		return exitError
+	}
+}
+
+// exitCodeForSuccess 运行成功时的退出码，只有 --exit-code 时才区分有命令被拒绝、修改了文件或没有修改
+func exitCodeForSuccess(edits, denied int) int {
+	switch {
+	case !exitCodes:
+		return exitOK
+	case denied > 0:
+		return exitToolDenied
+	case edits > 0:
+		return exitOK
+	default:
+		return exitNoChanges
+	}
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"openCursor/internal/session"
+	"openCursor/internal/tools"
+)
+
+// TestExitCodeDeleteOnly 只用 delete_file 删除了文件的运行也算修改了文件，--exit-code 时不返回 2
+func TestExitCodeDeleteOnly(t *testing.T) {
+	workDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workDir, "old.txt"), []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	registry := tools.NewRegistry()
+	if err := registry.RegisterAllTools(); err != nil {
+		t.Fatal(err)
+	}
+	registry.SetWorkDirectory(workDir)
+	saved := tools.DefaultRegistry
+	tools.DefaultRegistry = registry
+	defer func() { tools.DefaultRegistry = saved }()
+
+	result, err := registry.GetManager().ExecuteTool("delete_file", map[string]interface{}{"target_file": "old.txt"})
+	if err != nil || !result.Success {
+		t.Fatalf("delete_file failed: %v %s", err, result.Error)
+	}
+
+	sess := session.New(workDir, "model", "remove old.txt")
+	sess.AddTurn(session.Turn{Query: sess.Query})
+	edits := recordSessionEdits(sess)
+	if edits != 1 {
+		t.Errorf("recordSessionEdits() = %d, want 1", edits)
+	}
+
+	exitCodes = true
+	defer func() { exitCodes = false }()
+	if code := exitCodeForSuccess(edits, 0); code != exitOK {
+		t.Errorf("exit code = %d, want %d", code, exitOK)
+	}
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import "testing"
+
+// TestFixAliasIsQuery "openCursor fix ..." 只有在是 fix-command 的形式时才运行 fix-command
+func TestFixAliasIsQuery(t *testing.T) {
+	tests := []struct {
+		args  []string
+		query bool
+	}{
+		{[]string{"fix", "the", "race", "in", "manager.go"}, true},
+		{[]string{"fix", "the race in manager.go"}, true},
+		{[]string{"--model", "gpt-4o", "fix", "the", "race"}, true},
+		{[]string{"fix", "--", "go", "test", "./..."}, false},
+		{[]string{"fix", "--yes", "--", "make", "test"}, false},
+		{[]string{"fix", "--last-command"}, false},
+		{[]string{"fix", "--shell-init", "bash"}, false},
+		{[]string{"fix", "--help"}, false},
+		{[]string{"fix"}, false},
+		{[]string{"fix-command", "--last-command"}, false},
+		{[]string{"explain", "the", "fix"}, false},
+	}
+	for _, tt := range tests {
+		if got := fixAliasIsQuery(tt.args); got != tt.query {
+			t.Errorf("fixAliasIsQuery(%q) = %v, want %v", tt.args, got, tt.query)
+		}
+	}
+}
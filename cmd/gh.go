@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"openCursor/internal/config"
+	"openCursor/internal/github"
+
+	"github.com/spf13/cobra"
+)
+
+// GitHub 相关参数（openCursor gh issue 与根命令 --issue 共用，--post-comment 也用于 --mr）
+var (
+	issueNumber int    // --issue 附加的 issue 或 PR 编号
+	githubRepo  string // --github-repo 仓库 owner/name，默认取 origin 远程
+	postComment bool   // --post-comment 将结果发表为评论
+)
+
+// 附加到提示词中的PR/MR diff上限，评论中的patch上限（GitHub评论最长65536个字符）
+const (
+	issueMaxDiffBytes   = 60000
+	commentMaxPatchSize = 50000
+)
+
+// ghCmd GitHub 集成
+var ghCmd = &cobra.Command{
+	Use:   "gh",
+	Short: "Work on GitHub issues and pull requests",
+}
+
+// ghIssueCmd 以 issue 或 PR 作为上下文运行
+var ghIssueCmd = &cobra.Command{
+	Use:   "issue <number> [query]",
+	Short: "Work on a GitHub issue or pull request",
+	Long: `Fetch a GitHub issue or pull request (title, description, labels, comments and,
+for pull requests, the review comments and diff) and run the agent with it as context.
+Without a query the agent resolves the issue, or reviews the pull request.
+
+The repository is taken from the origin remote unless --repo is given. Set GITHUB_TOKEN
+(or GH_TOKEN, or github_token in the config file) for private repositories and for
+--comment; for GitHub Enterprise set GITHUB_API_URL or github_api_url, e.g.
+https://github.example.com/api/v3.
+
+With --comment the agent's final answer and the resulting working tree patch are posted
+back as a comment.
+
+This is the same as openCursor --issue <number> "<query>", which also accepts every
+other flag of the root command.
+
+Examples:
+  openCursor gh issue 42
+  openCursor gh issue 42 "Find the root cause, but do not change any code"
+  openCursor gh issue 108 --comment
+  openCursor gh issue 7 --repo octo-org/octo-repo`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		number, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+		if err != nil || number <= 0 {
+			fmt.Fprintf(os.Stderr, "Error: invalid issue number %q\n", args[0])
+			os.Exit(1)
+		}
+		issueNumber = number
+
+		query := "Work on this GitHub issue or pull request. For an issue, find the cause, make the change and verify it. For a pull request, review the changes and point out problems. Finish with a short summary."
+		if len(args) == 2 {
+			query = args[1]
+		}
+		rootCmd.Run(cmd, []string{query})
+	},
+}
+
+// newGitHubClient 根据环境变量与配置文件创建 GitHub 客户端，并确定仓库
+func newGitHubClient(cfg *config.Config, workDir string) (*github.Client, github.Repo, error) {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		token = os.Getenv("GH_TOKEN")
+	}
+	if token == "" {
+		token = cfg.GitHubToken
+	}
+	apiURL := os.Getenv("GITHUB_API_URL")
+	if apiURL == "" {
+		apiURL = cfg.GitHubAPIURL
+	}
+
+	spec := githubRepo
+	if spec == "" {
+		remote := exec.Command("git", "remote", "get-url", "origin")
+		remote.Dir = workDir
+		output, err := remote.Output()
+		if err != nil {
+			return nil, github.Repo{}, fmt.Errorf("cannot determine the GitHub repository: no origin remote, pass the repository as owner/name")
+		}
+		spec = strings.TrimSpace(string(output))
+	}
+	repo, err := github.ParseRepo(spec)
+	if err != nil {
+		return nil, github.Repo{}, err
+	}
+	return github.NewClient(apiURL, token), repo, nil
+}
+
+// fetchIssueContext 读取 --issue 指定的 issue 或 PR，返回附加到提示词中的内容
+func fetchIssueContext(gh *github.Client, repo github.Repo) (*github.Issue, string, error) {
+	issue, err := gh.FetchIssue(context.Background(), repo, issueNumber)
+	if err != nil {
+		return nil, "", err
+	}
+	return issue, issue.Context(issueMaxDiffBytes), nil
+}
+
+// postIssueComment 将最终回答与工作区的改动发表为评论
+func postIssueComment(gh *github.Client, repo github.Repo, issue *github.Issue, summary, workDir string) (string, error) {
+	return gh.PostComment(context.Background(), repo, issue.Number, resultComment(summary, workDir))
+}
+
+// resultComment 评论内容：最终回答，以及折叠的工作区patch
+func resultComment(summary, workDir string) string {
+	var sb strings.Builder
+	sb.WriteString(strings.TrimSpace(summary))
+
+	diffCmd := exec.Command("git", "diff", "HEAD")
+	diffCmd.Dir = workDir
+	if output, err := diffCmd.Output(); err == nil && len(output) > 0 {
+		patch := strings.TrimRight(string(output), "\n")
+		note := ""
+		if len(patch) > commentMaxPatchSize {
+			patch = patch[:commentMaxPatchSize]
+			if cut := strings.LastIndex(patch, "\n"); cut > 0 {
+				patch = patch[:cut]
+			}
+			note = fmt.Sprintf("\n\n_Patch truncated to %d bytes._", commentMaxPatchSize)
+		}
+		fmt.Fprintf(&sb, "\n\n<details><summary>Patch</summary>\n\n```diff\n%s\n```%s\n\n</details>", patch, note)
+	}
+	sb.WriteString("\n\n<sub>Posted by openCursor</sub>")
+	return sb.String()
+}
+
+func init() {
+	ghIssueCmd.Flags().StringVar(&githubRepo, "repo", "", "Repository as owner/name (default: the origin remote)")
+	ghIssueCmd.Flags().BoolVar(&postComment, "comment", false, "Post the agent's final answer and the resulting patch as a comment")
+	ghCmd.AddCommand(ghIssueCmd)
+	rootCmd.AddCommand(ghCmd)
+}
\ No newline at end of file
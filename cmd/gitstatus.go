@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// gitStatusMaxEntries 附加的 git status 最多列出的文件数
+const gitStatusMaxEntries = 100
+
+// gitStatusContext 当前分支与未提交的改动（git status --porcelain），附加到会话的第一次查询中，
+// 让代理知道用户进行中的工作
+func gitStatusContext(workDir string) (string, error) {
+	output, err := gitOutput(workDir, "status", "--porcelain=v1", "--branch")
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Current branch: %s\n", strings.TrimPrefix(lines[0], "## "))
+	changes := lines[1:]
+	if len(changes) == 0 {
+		sb.WriteString("The working tree is clean.\n")
+		return sb.String(), nil
+	}
+	sb.WriteString("Uncommitted changes (git status --porcelain). They are likely the user's work in progress: build on them and do not overwrite, revert or duplicate them unless asked.\n")
+	for i, line := range changes {
+		if i == gitStatusMaxEntries {
+			fmt.Fprintf(&sb, "... and %d more\n", len(changes)-i)
+			break
+		}
+		sb.WriteString(line + "\n")
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"openCursor/internal/config"
+	"openCursor/internal/gitlab"
+
+	"github.com/spf13/cobra"
+)
+
+// GitLab 相关参数（openCursor gl mr 与根命令 --mr 共用）
+var (
+	mrNumber      int    // --mr 附加的合并请求编号（IID）
+	gitlabProject string // --gitlab-project 项目路径，默认取 origin 远程
+)
+
+// glCmd GitLab 集成
+var glCmd = &cobra.Command{
+	Use:   "gl",
+	Short: "Work on GitLab merge requests",
+}
+
+// glMRCmd 以合并请求作为上下文运行
+var glMRCmd = &cobra.Command{
+	Use:   "mr <iid> [query]",
+	Short: "Work on a GitLab merge request",
+	Long: `Fetch a GitLab merge request (title, description, labels, the discussion including
+review comments on the code, and the diff) and run the agent with it as context.
+Without a query the agent reviews the merge request.
+
+The project is taken from the origin remote unless --project is given. Self-hosted
+instances are supported: the instance URL is taken from GITLAB_URL or gitlab_url in
+the config file, or else from the host of the origin remote. Set GITLAB_TOKEN (or
+gitlab_token in the config file) for private projects and for --comment.
+
+With --comment the agent's final answer and the resulting working tree patch are posted
+back as a note on the merge request.
+
+This is the same as openCursor --mr <iid> "<query>", which also accepts every other
+flag of the root command.
+
+Examples:
+  openCursor gl mr 17
+  openCursor gl mr 17 "Address the unresolved review comments"
+  openCursor gl mr 17 --comment
+  openCursor gl mr 5 --project platform/backend/billing`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		number, err := strconv.Atoi(strings.TrimPrefix(args[0], "!"))
+		if err != nil || number <= 0 {
+			fmt.Fprintf(os.Stderr, "Error: invalid merge request number %q\n", args[0])
+			os.Exit(1)
+		}
+		mrNumber = number
+
+		query := "Review this merge request: check the changes for bugs and missing tests, and address the open review comments. Finish with a short summary."
+		if len(args) == 2 {
+			query = args[1]
+		}
+		rootCmd.Run(cmd, []string{query})
+	},
+}
+
+// newGitLabClient 根据环境变量与配置文件创建 GitLab 客户端，并确定项目
+func newGitLabClient(cfg *config.Config, workDir string) (*gitlab.Client, gitlab.Project, error) {
+	token := os.Getenv("GITLAB_TOKEN")
+	if token == "" {
+		token = cfg.GitLabToken
+	}
+
+	spec := gitlabProject
+	if spec == "" {
+		remote := exec.Command("git", "remote", "get-url", "origin")
+		remote.Dir = workDir
+		output, err := remote.Output()
+		if err != nil {
+			return nil, gitlab.Project{}, fmt.Errorf("cannot determine the GitLab project: no origin remote, pass the project as group/name")
+		}
+		spec = strings.TrimSpace(string(output))
+	}
+	project, err := gitlab.ParseProject(spec)
+	if err != nil {
+		return nil, gitlab.Project{}, err
+	}
+
+	// 实例地址：环境变量、配置文件，否则取远程地址的主机
+	baseURL := os.Getenv("GITLAB_URL")
+	if baseURL == "" {
+		baseURL = cfg.GitLabURL
+	}
+	if baseURL == "" && project.Host != "" && project.Host != "gitlab.com" {
+		baseURL = "https://" + project.Host
+	}
+	return gitlab.NewClient(baseURL, token), project, nil
+}
+
+// fetchMergeRequestContext 读取 --mr 指定的合并请求，返回附加到提示词中的内容
+func fetchMergeRequestContext(gl *gitlab.Client, project gitlab.Project) (*gitlab.MergeRequest, string, error) {
+	mr, err := gl.FetchMergeRequest(context.Background(), project, mrNumber)
+	if err != nil {
+		return nil, "", err
+	}
+	return mr, mr.Context(issueMaxDiffBytes), nil
+}
+
+// postMergeRequestNote 将最终回答与工作区的改动发表为合并请求的评论
+func postMergeRequestNote(gl *gitlab.Client, project gitlab.Project, mr *gitlab.MergeRequest, summary, workDir string) (string, error) {
+	return gl.PostNote(context.Background(), project, mr, resultComment(summary, workDir))
+}
+
+func init() {
+	glMRCmd.Flags().StringVar(&gitlabProject, "project", "", "Project path such as group/name (default: the origin remote)")
+	glMRCmd.Flags().BoolVar(&postComment, "comment", false, "Post the agent's final answer and the resulting patch as a note")
+	glCmd.AddCommand(glMRCmd)
+	rootCmd.AddCommand(glCmd)
+}
\ No newline at end of file
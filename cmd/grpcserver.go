@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	opencursorv1 "openCursor/api/opencursor/v1"
+	"openCursor/internal/client"
+	"openCursor/internal/config"
+	"openCursor/internal/rpc"
+	"openCursor/internal/session"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// grpcService 以 gRPC 提供 rpcServer 的查询服务
+type grpcService struct {
+	opencursorv1.UnimplementedOpenCursorServer
+	server *rpcServer
+}
+
+// grpcQueryOwner 一次 gRPC Query 调用，调用取消时停止其查询
+type grpcQueryOwner struct{}
+
+// Query 运行查询，调用取消时在当前步骤完成后停止
+func (g *grpcService) Query(ctx context.Context, req *opencursorv1.QueryRequest) (*opencursorv1.QueryResponse, error) {
+	type reply struct {
+		result *queryResult
+		err    *rpc.Error
+	}
+	done := make(chan reply, 1)
+	owner := &grpcQueryOwner{}
+	params := queryParams{Query: req.GetQuery(), SessionID: req.GetSessionId(), Approval: req.GetApproval()}
+	if rpcErr := g.server.startQuery(params, owner, nil, func(result *queryResult, rpcErr *rpc.Error) {
+		done <- reply{result, rpcErr}
+	}); rpcErr != nil {
+		return nil, grpcError(rpcErr)
+	}
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return nil, grpcError(r.err)
+		}
+		return &opencursorv1.QueryResponse{
+			SessionId: r.result.SessionID,
+			Status:    r.result.Status,
+			Response:  r.result.Response,
+			Usage:     grpcUsage(r.result.Usage, r.result.Cost),
+		}, nil
+	case <-ctx.Done():
+		g.server.stopOwner(owner)
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+// StreamEvents 发送之后所有查询的事件，直到调用取消
+func (g *grpcService) StreamEvents(req *opencursorv1.StreamEventsRequest, stream opencursorv1.OpenCursor_StreamEventsServer) error {
+	ctx := stream.Context()
+	events := make(chan *opencursorv1.Event, 64)
+	unsubscribe := g.server.subscribe(func(method string, params interface{}) {
+		event := grpcEvent(params)
+		if event == nil || (req.GetSessionId() != "" && event.SessionId != req.GetSessionId()) {
+			return
+		}
+		select {
+		case events <- event:
+		case <-ctx.Done():
+		}
+	})
+	defer unsubscribe()
+
+	for {
+		select {
+		case event := <-events:
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// ApproveTool 回复等待中的命令确认
+func (g *grpcService) ApproveTool(ctx context.Context, req *opencursorv1.ApproveToolRequest) (*opencursorv1.ApproveToolResponse, error) {
+	if rpcErr := g.server.approve(req.GetApprovalId(), req.GetApproved()); rpcErr != nil {
+		return nil, status.Error(codes.NotFound, rpcErr.Message)
+	}
+	return &opencursorv1.ApproveToolResponse{}, nil
+}
+
+// ListSessions 列出保存的会话
+func (g *grpcService) ListSessions(ctx context.Context, req *opencursorv1.ListSessionsRequest) (*opencursorv1.ListSessionsResponse, error) {
+	sessions, err := session.List(config.SessionsDir())
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	resp := &opencursorv1.ListSessionsResponse{}
+	for _, sess := range sessions {
+		if req.GetWorkDir() != "" && sess.WorkDir != req.GetWorkDir() {
+			continue
+		}
+		if req.GetLimit() > 0 && len(resp.Sessions) >= int(req.GetLimit()) {
+			break
+		}
+		resp.Sessions = append(resp.Sessions, &opencursorv1.Session{
+			Id:          sess.ID,
+			WorkDir:     sess.WorkDir,
+			Model:       sess.Model,
+			Query:       sess.Query,
+			CreatedAt:   sess.CreatedAt.Format(time.RFC3339),
+			UpdatedAt:   sess.UpdatedAt.Format(time.RFC3339),
+			Interrupted: sess.Interrupted,
+			Turns:       int32(len(sess.History())),
+		})
+	}
+	return resp, nil
+}
+
+// grpcEvent 将查询的通知转换为 gRPC 事件，无法转换时返回nil
+func grpcEvent(params interface{}) *opencursorv1.Event {
+	switch p := params.(type) {
+	case queryEvent:
+		event := &opencursorv1.Event{SessionId: p.SessionID}
+		switch p.Type {
+		case client.EventTextDelta:
+			event.Event = &opencursorv1.Event_TextDelta{TextDelta: &opencursorv1.TextDelta{Text: p.Text}}
+		case client.EventToolStart:
+			event.Event = &opencursorv1.Event_ToolStart{ToolStart: &opencursorv1.ToolStart{
+				ToolCallId: p.ToolCallID,
+				Tool:       p.Tool,
+				Arguments:  p.Arguments,
+			}}
+		case client.EventToolEnd:
+			event.Event = &opencursorv1.Event_ToolEnd{ToolEnd: &opencursorv1.ToolEnd{
+				ToolCallId: p.ToolCallID,
+				Tool:       p.Tool,
+				Status:     p.Status,
+				Result:     p.Result,
+			}}
+		case client.EventUsage:
+			if p.Usage == nil {
+				return nil
+			}
+			event.Event = &opencursorv1.Event_Usage{Usage: grpcUsage(*p.Usage, p.Cost)}
+		default:
+			return nil
+		}
+		return event
+	case approvalRequest:
+		return &opencursorv1.Event{
+			SessionId: p.SessionID,
+			Event: &opencursorv1.Event_ApprovalRequest{ApprovalRequest: &opencursorv1.ApprovalRequest{
+				ApprovalId:  p.ApprovalID,
+				Command:     p.Command,
+				Explanation: p.Explanation,
+			}},
+		}
+	case fileChangeEvent:
+		return &opencursorv1.Event{
+			SessionId: p.SessionID,
+			Event: &opencursorv1.Event_FileChange{FileChange: &opencursorv1.FileChange{
+				Tool:    p.Tool,
+				Path:    p.Path,
+				Before:  p.Before,
+				After:   p.After,
+				Created: p.Created,
+				Deleted: p.Deleted,
+			}},
+		}
+	}
+	return nil
+}
+
+// grpcUsage 转换用量
+func grpcUsage(usage client.Usage, cost float64) *opencursorv1.Usage {
+	return &opencursorv1.Usage{
+		PromptTokens:     int64(usage.PromptTokens),
+		CompletionTokens: int64(usage.CompletionTokens),
+		Estimated:        usage.Estimated,
+		Cost:             cost,
+	}
+}
+
+// grpcError 将 JSON-RPC 错误转换为 gRPC 状态
+func grpcError(rpcErr *rpc.Error) error {
+	code := codes.Internal
+	switch rpcErr.Code {
+	case rpc.CodeInvalidParams:
+		code = codes.InvalidArgument
+	case rpc.CodeRequestFailed:
+		code = codes.Aborted
+	}
+	return status.Error(code, rpcErr.Message)
+}
\ No newline at end of file
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"openCursor/internal/config"
+	"openCursor/internal/session"
+
+	"github.com/spf13/cobra"
+)
+
+// history 命令参数
+var (
+	historyGrep  string // --grep 按正则表达式筛选查询
+	historyLimit int    // --limit 最多显示的查询数
+	historyHere  bool   // --here 只显示当前目录的会话
+)
+
+// historyQueryWidth 列表中查询的最大显示长度
+const historyQueryWidth = 60
+
+// historyEntry 历史记录中的一次查询
+type historyEntry struct {
+	session *session.Session
+	turn    session.Turn
+}
+
+// historyCmd 列出之前的查询
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List past queries with their session IDs",
+	Long: `List past queries from the saved sessions, newest first, with their time,
+workspace, model, token usage and estimated cost, and the ID of the session to
+continue with openCursor --resume <id>. Token counts marked with ~ are estimated;
+the cost is shown when the model's price is known (see pricing: in the config file).
+
+--grep filters by a case-insensitive regular expression matched against the
+query, the workspace and the session ID.
+
+Examples:
+  openCursor history
+  openCursor history --here
+  openCursor history --grep "race|deadlock" --limit 50`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var pattern *regexp.Regexp
+		if historyGrep != "" {
+			var err error
+			pattern, err = regexp.Compile("(?i)" + historyGrep)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: invalid --grep pattern: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		workDir := ""
+		if historyHere {
+			var err error
+			workDir, err = os.Getwd()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: Failed to get working directory: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		sessions, err := session.List(config.SessionsDir())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		var entries []historyEntry
+		for _, s := range sessions {
+			if workDir != "" && s.WorkDir != workDir {
+				continue
+			}
+			for _, turn := range s.History() {
+				if pattern != nil && !pattern.MatchString(turn.Query) && !pattern.MatchString(s.WorkDir) && !pattern.MatchString(s.ID) {
+					continue
+				}
+				entries = append(entries, historyEntry{session: s, turn: turn})
+			}
+		}
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].turn.StartedAt.After(entries[j].turn.StartedAt)
+		})
+		if len(entries) == 0 {
+			fmt.Println("没有找到历史记录")
+			return
+		}
+		if historyLimit > 0 && len(entries) > historyLimit {
+			entries = entries[:historyLimit]
+		}
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "TIME\tSESSION\tMODEL\tTOKENS\tCOST\tWORKSPACE\tQUERY")
+		for _, entry := range entries {
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				entry.turn.StartedAt.Local().Format("2006-01-02 15:04"),
+				entry.session.ID,
+				entry.turn.Model,
+				formatTurnTokens(entry.turn),
+				formatTurnCost(entry.turn),
+				shortenHome(entry.session.WorkDir),
+				truncateQuery(entry.turn.Query, historyQueryWidth))
+		}
+		writer.Flush()
+	},
+}
+
+// formatTurnTokens 查询的token用量，估计值前加 ~，没有记录时为 -
+func formatTurnTokens(turn session.Turn) string {
+	total := turn.PromptTokens + turn.CompletionTokens
+	if total == 0 {
+		return "-"
+	}
+	if turn.Estimated {
+		return fmt.Sprintf("~%d", total)
+	}
+	return fmt.Sprintf("%d", total)
+}
+
+// formatTurnCost 查询的估计费用，价格未知时为 -
+func formatTurnCost(turn session.Turn) string {
+	if turn.Cost == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("$%.4f", turn.Cost)
+}
+
+// shortenHome 将主目录开头的路径显示为 ~
+func shortenHome(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+	if path == home {
+		return "~"
+	}
+	if strings.HasPrefix(path, home+string(os.PathSeparator)) {
+		return "~" + path[len(home):]
+	}
+	return path
+}
+
+// truncateQuery 查询压缩为一行，超过 n 个字符时截断
+func truncateQuery(query string, n int) string {
+	query = strings.Join(strings.Fields(query), " ")
+	if runes := []rune(query); len(runes) > n {
+		return string(runes[:n-1]) + "…"
+	}
+	return query
+}
+
+func init() {
+	historyCmd.Flags().StringVar(&historyGrep, "grep", "", "Only list queries matching this regular expression (case-insensitive)")
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "Maximum number of queries to list (0 for all)")
+	historyCmd.Flags().BoolVar(&historyHere, "here", false, "Only list sessions started in the current directory")
+	rootCmd.AddCommand(historyCmd)
+}
\ No newline at end of file
@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"openCursor/internal/config"
+	"openCursor/internal/embeddings"
+	"openCursor/internal/index"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// index 命令参数
+var (
+	indexStats bool // --stats 只显示统计，不更新索引
+	indexClear bool // --clear 删除索引
+)
+
+// indexProgressWidth 进度条宽度
+const indexProgressWidth = 30
+
+// indexCmd 构建工作区的语义索引
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Build or update the semantic code index of the workspace",
+	Long: `Build or incrementally update the semantic index used by the codebase_search
+tool: text files are split along function and class boundaries, embedded with the
+configured embeddings provider and written to the configured vector store (see
+embeddings: and index: in the config file). Only new or changed files are
+embedded again; deleted files are removed.
+
+Files ignored by .gitignore or .opencursorignore (same syntax, only affects the
+index), hidden files, dependency and build directories, binary files and files
+over 512 KB are skipped.
+
+Examples:
+  openCursor index
+  openCursor index --stats
+  openCursor index --clear`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if indexStats && indexClear {
+			fmt.Fprintf(os.Stderr, "Error: --stats and --clear cannot be used together\n")
+			os.Exit(1)
+		}
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if !cfg.Embeddings.Enabled() {
+			fmt.Fprintf(os.Stderr, "Error: no embeddings provider configured (set embeddings: in the config file, e.g. provider: local)\n")
+			os.Exit(1)
+		}
+		// 只有 openai 服务在未单独配置密钥时使用对话模型的密钥
+		apiKey, baseURL := "", apiBaseURL()
+		if strings.EqualFold(cfg.Embeddings.Provider, embeddings.ProviderOpenAI) && cfg.Embeddings.APIKeyEnv == "" {
+			apiKey, baseURL, _ = loadAPISettings()
+		}
+		embedder, err := embeddings.New(cfg.Embeddings, apiKey, baseURL)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		workDir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to get current directory: %v\n", err)
+			os.Exit(1)
+		}
+
+		idx, err := index.Open(workDir, embedder, cfg.Index)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer idx.Close()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		switch {
+		case indexClear:
+			if err := idx.Clear(ctx); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println("🗑️  已删除索引")
+			return
+		case indexStats:
+			printIndexStats(idx.Stats())
+			return
+		}
+
+		started := time.Now()
+		progress := newIndexProgress()
+		update, err := idx.Update(ctx, progress.update)
+		progress.finish()
+		if err != nil {
+			if ctx.Err() != nil {
+				fmt.Fprintf(os.Stderr, "⚠️  已中断，已完成的部分已保存（%d 个文件），再次运行将继续\n", update.Indexed)
+				os.Exit(130)
+			}
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ 索引已更新（%s）: %d 个文件重新索引，%d 个未变化，%d 个已删除；写入 %d 个代码段，约 %d tokens\n",
+			time.Since(started).Round(time.Millisecond), update.Indexed, update.Unchanged, update.Removed, update.Chunks, update.Tokens)
+		if update.Ignored+update.TooLarge+update.Binary > 0 {
+			fmt.Printf("   跳过: %d 个被忽略，%d 个超过大小限制，%d 个二进制文件\n", update.Ignored, update.TooLarge, update.Binary)
+		}
+		fmt.Println()
+		printIndexStats(idx.Stats())
+	},
+}
+
+// indexProgress 终端中显示索引进度条，非终端时不输出
+type indexProgress struct {
+	enabled bool
+	last    time.Time
+	shown   bool
+}
+
+// newIndexProgress 创建进度显示
+func newIndexProgress() *indexProgress {
+	return &indexProgress{enabled: term.IsTerminal(int(os.Stderr.Fd()))}
+}
+
+// update 更新进度条（最多每100毫秒刷新一次）
+func (p *indexProgress) update(done, total int) {
+	if !p.enabled || total == 0 {
+		return
+	}
+	if done < total && time.Since(p.last) < 100*time.Millisecond {
+		return
+	}
+	p.last = time.Now()
+	filled := done * indexProgressWidth / total
+	fmt.Fprintf(os.Stderr, "\r[%s%s] %d/%d 个文件", strings.Repeat("█", filled), strings.Repeat("░", indexProgressWidth-filled), done, total)
+	p.shown = true
+}
+
+// finish 清除进度条
+func (p *indexProgress) finish() {
+	if p.shown {
+		fmt.Fprintf(os.Stderr, "\r%s\r", strings.Repeat(" ", indexProgressWidth+30))
+	}
+}
+
+// printIndexStats 输出索引统计
+func printIndexStats(stats *index.Stats) {
+	if stats.Files == 0 {
+		fmt.Println("索引为空，运行 openCursor index 构建")
+		return
+	}
+	fmt.Printf("📚 %s\n", stats.Root)
+	fmt.Printf("   嵌入服务: %s\n", stats.Embedder)
+	fmt.Printf("   向量存储: %s\n", strings.TrimSuffix(stats.Store, ":"))
+	fmt.Printf("   %d 个文件，%d 个代码段，约 %d tokens，本地占用 %s\n", stats.Files, stats.Chunks, stats.Tokens, formatBytes(stats.DiskBytes))
+	if !stats.UpdatedAt.IsZero() {
+		fmt.Printf("   更新于 %s\n", stats.UpdatedAt.Local().Format("2006-01-02 15:04:05"))
+	}
+	fmt.Println()
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "EXTENSION\tFILES\tCHUNKS\tTOKENS")
+	for _, ext := range stats.Extensions {
+		fmt.Fprintf(writer, "%s\t%d\t%d\t%d\n", ext.Extension, ext.Files, ext.Chunks, ext.Tokens)
+	}
+	writer.Flush()
+}
+
+// formatBytes 以 KB/MB 显示字节数
+func formatBytes(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", size)
+}
+
+func init() {
+	indexCmd.Flags().BoolVar(&indexStats, "stats", false, "Show statistics of the current index without updating it")
+	indexCmd.Flags().BoolVar(&indexClear, "clear", false, "Delete the index (vectors, keyword index and manifest)")
+	rootCmd.AddCommand(indexCmd)
+}
\ No newline at end of file
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"os"
+
+	"openCursor/internal/rpc"
+	"openCursor/internal/tools"
+
+	"github.com/spf13/cobra"
+)
+
+// lspLikeCmd 通过标准输入输出提供 JSON-RPC 接口，供编辑器插件使用
+var lspLikeCmd = &cobra.Command{
+	Use:   "lsp-like",
+	Short: "Serve JSON-RPC over stdio as the backend of an editor extension",
+	Long: `Speak JSON-RPC 2.0 over stdin and stdout, framed with Content-Length headers
+like the Language Server Protocol, so that a VS Code or Neovim extension can run
+queries in the current directory and show their progress without parsing
+terminal output. Everything else openCursor prints goes to stderr.
+
+Requests:
+  initialize    {}                                   -> server, version, model and work_dir
+  query         {"query": "...", "session_id": "...", "approval": "ask"}
+                Runs a query (one at a time). session_id continues a saved
+                session, approval overrides the configured approval mode.
+                Answered when the run ends with session_id, status (completed,
+                interrupted or budget_exceeded), response, usage and cost
+  cancel        {}                                   Stops the running query after the current step
+  approveTool   {"approval_id": "1", "approved": true}  Answers a tool/approvalRequest
+  shutdown      {}                                   Cancels the running query and waits for it
+
+Notifications sent by the server (all with the session_id of the query):
+  query/textDelta       text of the model's answer as it streams
+  query/toolStart       tool_call_id, tool and arguments of a tool call
+  query/toolEnd         tool_call_id, tool, status (done or failed) and result
+  query/usage           usage and cost so far, after every model call
+  tool/approvalRequest  approval_id, command and explanation of a command that
+                        needs approval in ask mode; answer it with approveTool
+  diff/applied          tool, path, before, after, created and deleted for every
+                        file a tool changed, e.g. to refresh buffers or show a diff
+
+Send the exit notification to stop the server.
+
+Examples:
+  openCursor lsp-like`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		// 标准输出只用于协议消息，其他输出改到标准错误
+		protocolOut := os.Stdout
+		os.Stdout = os.Stderr
+
+		server := newRPCServer(tools.ToolsetAll)
+		shutdown := server.serveConn(rpc.NewConn(rpc.NewHeaderStream(os.Stdin, protocolOut)))
+		server.close()
+		if !shutdown {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lspLikeCmd)
+}
\ No newline at end of file
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"openCursor/internal/client"
+	"openCursor/internal/config"
+	"openCursor/internal/repomap"
+	"openCursor/internal/tools"
+
+	"github.com/spf13/cobra"
+)
+
+// onboard 命令参数
+var (
+	onboardOutput    string // --output 报告输出路径
+	onboardMapTokens int    // --repo-map-tokens 附加的仓库地图token预算
+)
+
+// onboardMaxIterations 生成报告时允许的最多模型调用轮数
+const onboardMaxIterations = 30
+
+// onboardPrompt 生成架构概览文档的指令
+const onboardPrompt = `Write an onboarding document for a developer who is new to this repository.
+
+Explore the codebase with the available read-only tools before writing: start from the
+repository map attached above, read the entry points, the build files and the main
+packages or modules, and list the project tasks (Makefile, Taskfile) to find the real
+build and test commands. Do not guess: only describe what you verified in the code.
+
+Your final answer must be the document itself, in Markdown, with these sections:
+
+# <Project name> onboarding
+## Overview            What the project does and the main technologies it uses
+## Repository layout   The important directories and modules and what each is responsible for
+## Entry points        Binaries, commands, servers or public APIs and where they start
+## Data flow           How a typical request, command or job moves through the modules
+## Build, test and run The exact commands, with prerequisites and environment variables
+## Conventions         Coding patterns, error handling, configuration and test layout to follow
+## Where to start      A short reading order of files for a first day
+
+Reference files as relative paths in backticks. Do not wrap the document in a code fence
+and do not add any text before or after it.`
+
+// onboardCmd 生成仓库入门文档
+var onboardCmd = &cobra.Command{
+	Use:   "onboard [dir]",
+	Short: "Generate an architecture overview of the repository",
+	Long: `Explore the repository with read-only tools and the repository map, and write an
+onboarding document describing its modules, entry points, data flow and build/test
+commands. The agent cannot modify files or run commands while doing so.
+
+The report is saved to .opencursor/ONBOARDING.md in the repository unless --output
+is given.
+
+Examples:
+  openCursor onboard
+  openCursor onboard ~/src/my-service
+  openCursor onboard --output docs/ARCHITECTURE.md`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		apiKey, baseURL, model := loadAPISettings()
+
+		// 确定仓库目录
+		workDir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to get current directory: %v\n", err)
+			os.Exit(1)
+		}
+		if len(args) == 1 {
+			workDir, err = filepath.Abs(expandHome(args[0]))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if info, err := os.Stat(workDir); err != nil || !info.IsDir() {
+			fmt.Fprintf(os.Stderr, "Error: %s is not a directory\n", workDir)
+			os.Exit(1)
+		}
+
+		// 加载配置文件
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		configureEmbeddings(cfg, apiKey, baseURL)
+
+		// 只注册只读工具
+		if err := setupAgentTools(cfg, baseURL, agentToolSetup{register: tools.RegisterDefaultReadOnlyTools, skipApproval: true, workDir: workDir}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		aiClient := client.NewClient(apiKey, baseURL, model)
+		aiClient.SetToolManager(tools.GetDefaultManager())
+		aiClient.SetMaxIterations(onboardMaxIterations)
+
+		// 附加仓库地图作为探索起点
+		repoMap, err := repomap.Generate(repomap.Options{
+			Root:        workDir,
+			TokenBudget: onboardMapTokens,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to generate repository map: %v\n", err)
+		} else if repoMap.Text != "" {
+			aiClient.AddContext("repo_map", repoMap.Text)
+		}
+
+		err = aiClient.StreamQueryWithTools(onboardPrompt)
+		tools.ShutdownLanguageServers()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		report := trimMarkdownFence(aiClient.LastResponse())
+		if report == "" {
+			fmt.Fprintf(os.Stderr, "Error: the model did not produce a report within %d steps\n", onboardMaxIterations)
+			os.Exit(1)
+		}
+
+		// 写入报告
+		output := onboardOutput
+		if output == "" {
+			output = filepath.Join(workDir, ".opencursor", "ONBOARDING.md")
+		} else if !filepath.IsAbs(output) {
+			output = filepath.Join(workDir, output)
+		}
+		if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to create directory: %v\n", err)
+			os.Exit(1)
+		}
+		if err := os.WriteFile(output, []byte(report+"\n"), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to write report: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("\nOnboarding report written to %s\n", output)
+	},
+}
+
+// trimMarkdownFence 去掉模型可能包裹在整个文档外的 ```markdown 代码块
+func trimMarkdownFence(text string) string {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "```") || !strings.HasSuffix(text, "```") {
+		return text
+	}
+	firstLine, rest, ok := strings.Cut(text, "\n")
+	if !ok || strings.Contains(strings.TrimPrefix(firstLine, "```"), "`") {
+		return text
+	}
+	return strings.TrimSpace(strings.TrimSuffix(rest, "```"))
+}
+
+func init() {
+	onboardCmd.Flags().StringVarP(&onboardOutput, "output", "o", "", "Path of the report (default: <dir>/.opencursor/ONBOARDING.md)")
+	onboardCmd.Flags().IntVar(&onboardMapTokens, "repo-map-tokens", 4096, "Token budget of the repository map given to the agent")
+	rootCmd.AddCommand(onboardCmd)
+}
\ No newline at end of file
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"openCursor/internal/tools"
+
+	"github.com/spf13/cobra"
+)
+
+// restore 命令参数
+var restoreForce bool // --force 覆盖原位置已存在的文件
+
+// restoreCmd 从回收站恢复代理删除的文件
+var restoreCmd = &cobra.Command{
+	Use:   "restore [session] [path...]",
+	Short: "Restore files the agent deleted from the trash",
+	Long: `Restore files and directories deleted by the agent's delete_file tool.
+
+Deleted files are not removed but moved to .opencursor/trash/<session>/ in the
+working directory. Without arguments the sessions in the trash and their deleted
+files are listed. With a session ID everything that session deleted is moved
+back; with paths (relative to the working directory) only those files, or the
+files under those directories, are restored. A file whose original location
+exists again is skipped unless --force is given.
+
+The trash is never emptied automatically; remove .opencursor/trash (or a session
+directory in it) to free the space.
+
+Examples:
+  openCursor restore
+  openCursor restore 20240131-101500-a1b2c3
+  openCursor restore 20240131-101500-a1b2c3 internal/api/old_handler.go`,
+	Args: cobra.ArbitraryArgs,
+	ValidArgsFunction: completeTrashSessions,
+	Run: func(cmd *cobra.Command, args []string) {
+		workDir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to get current directory: %v\n", err)
+			os.Exit(1)
+		}
+
+		if len(args) == 0 {
+			sessions, err := tools.ListTrash(workDir)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if len(sessions) == 0 {
+				fmt.Println("回收站是空的")
+				return
+			}
+			writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(writer, "SESSION\tDELETED\tPATH")
+			for _, sess := range sessions {
+				for _, entry := range sess.Entries {
+					path := displayPath(workDir, entry.OriginalPath)
+					if entry.Dir {
+						path += string(filepath.Separator)
+					}
+					fmt.Fprintf(writer, "%s\t%s\t%s\n", sess.ID, entry.DeletedAt.Local().Format("2006-01-02 15:04"), path)
+				}
+			}
+			writer.Flush()
+			return
+		}
+
+		restored, err := tools.RestoreFromTrash(workDir, args[0], args[1:], restoreForce)
+		for _, entry := range restored {
+			fmt.Printf("♻️  已恢复 %s\n", displayPath(workDir, entry.OriginalPath))
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// displayPath 工作目录内的路径显示为相对路径
+func displayPath(workDir, path string) string {
+	if rel, err := filepath.Rel(workDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
+
+func init() {
+	restoreCmd.Flags().BoolVar(&restoreForce, "force", false, "Overwrite files that exist again at their original location")
+	rootCmd.AddCommand(restoreCmd)
+}
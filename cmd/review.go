@@ -0,0 +1,280 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"openCursor/internal/client"
+	"openCursor/internal/config"
+	"openCursor/internal/diffsummary"
+	"openCursor/internal/review"
+	"openCursor/internal/tools"
+
+	"github.com/spf13/cobra"
+)
+
+// review 命令参数
+var (
+	reviewStaged bool   // --staged 审查暂存区的改动
+	reviewFormat string // --format 输出格式：text、json、sarif
+	reviewOutput string // --output 结果输出路径
+	reviewFailOn string // --fail-on 存在该严重程度及以上的发现时以非零状态退出
+)
+
+// reviewMaxIterations 审查时允许的最多模型调用轮数
+const reviewMaxIterations = 20
+
+// reviewMaxDiffBytes 附加到提示词中的diff上限
+const reviewMaxDiffBytes = 200000
+
+// reviewSummaryTokens diff超出上限时附加的分层摘要的token预算
+const reviewSummaryTokens = 8000
+
+// reviewPrompt 代码审查的指令
+const reviewPrompt = `Review the diff attached above as an experienced reviewer of this repository.
+
+Look for bugs, incorrect edge cases, race conditions, resource leaks, security problems,
+broken error handling, missing or wrong tests, and changes that do not follow the
+conventions of the surrounding code. Use the available read-only tools to read the
+changed files and their callers when the diff alone is not enough to be sure. Do not
+report style nits that a formatter would fix, and do not report problems in code that
+the diff does not change unless the change breaks it.
+
+Your final answer must be a single JSON object and nothing else:
+
+{
+  "summary": "One or two sentences on the overall change and its quality",
+  "findings": [
+    {
+      "file": "path/relative/to/repo.go",
+      "line": 42,
+      "end_line": 45,
+      "severity": "error | warning | note",
+      "title": "Short description of the problem",
+      "message": "Why it is a problem, with the concrete scenario that breaks",
+      "suggestion": "How to fix it"
+    }
+  ]
+}
+
+Use "error" for bugs and security problems, "warning" for likely problems and missing
+tests, and "note" for improvements. Line numbers refer to the new version of the file.
+Return an empty findings array if the change looks correct.`
+
+// reviewCmd 审查代码改动
+var reviewCmd = &cobra.Command{
+	Use:   "review [ref]",
+	Short: "Review a diff and report findings",
+	Long: `Feed a diff to the model with a dedicated review prompt and report structured
+findings (file, line, severity, suggestion). The agent can read the repository with
+read-only tools but cannot modify files or run commands.
+
+Which changes are reviewed:
+  openCursor review               uncommitted changes to tracked files (git diff HEAD)
+  openCursor review --staged      staged changes (git diff --cached)
+  openCursor review main          changes since the branch left main (merge base)
+  openCursor review v1.2..v1.3    a commit range, as given
+
+Output formats (--format):
+  text    human-readable findings (default)
+  json    {"summary": ..., "findings": [...]}
+  sarif   SARIF 2.1.0, for example for GitHub code scanning
+
+With --fail-on the command exits with status 1 when there is a finding of that
+severity or higher, so it can gate a CI job.
+
+Examples:
+  openCursor review
+  openCursor review --staged
+  openCursor review origin/main --format sarif --output review.sarif
+  openCursor review main --format json --fail-on error`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		switch reviewFormat {
+		case "text", "json", "sarif":
+		default:
+			fmt.Fprintf(os.Stderr, "Error: unknown format %q (use text, json or sarif)\n", reviewFormat)
+			os.Exit(1)
+		}
+		if reviewFailOn != "" && reviewFailOn != "none" && !review.ValidSeverity(reviewFailOn) {
+			fmt.Fprintf(os.Stderr, "Error: unknown severity %q for --fail-on (use error, warning, note or none)\n", reviewFailOn)
+			os.Exit(1)
+		}
+		ref := ""
+		if len(args) == 1 {
+			ref = args[0]
+		}
+		if strings.HasPrefix(ref, "-") {
+			fmt.Fprintf(os.Stderr, "Error: invalid ref %q\n", ref)
+			os.Exit(1)
+		}
+		if reviewStaged && ref != "" {
+			fmt.Fprintf(os.Stderr, "Error: --staged cannot be combined with a ref\n")
+			os.Exit(1)
+		}
+
+		apiKey, baseURL, model := loadAPISettings()
+
+		workDir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to get current directory: %v\n", err)
+			os.Exit(1)
+		}
+
+		diff, description, err := reviewDiff(workDir, ref, reviewStaged)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if strings.TrimSpace(diff) == "" {
+			fmt.Fprintf(os.Stderr, "Nothing to review: %s is empty\n", description)
+			return
+		}
+		if len(diff) > reviewMaxDiffBytes {
+			// diff太大时先附加分层摘要，让模型看到全部改动的概况，再按剩余空间截断diff
+			summary := diffsummary.Summarize(diffsummary.Parse(diff), reviewSummaryTokens)
+			diff = "## Summary of the whole diff\n" + summary.Text +
+				"\n## Diff (truncated)\n" + diff[:reviewMaxDiffBytes-len(summary.Text)] +
+				"\n[diff truncated: use the summary above and read the remaining files with the tools]\n"
+		}
+
+		// 加载配置文件
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		// 只注册只读工具
+		if err := setupAgentTools(cfg, baseURL, agentToolSetup{register: tools.RegisterDefaultReadOnlyTools, skipApproval: true, workDir: workDir}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		aiClient := client.NewClient(apiKey, baseURL, model)
+		aiClient.SetToolManager(tools.GetDefaultManager())
+		aiClient.SetMaxIterations(reviewMaxIterations)
+		aiClient.AddContext("diff", fmt.Sprintf("# %s\n%s", description, diff))
+
+		// JSON/SARIF写到标准输出时，模型的流式输出改到标准错误，保证标准输出可以直接解析
+		if reviewFormat != "text" && reviewOutput == "" {
+			aiClient.SetOutput(os.Stderr)
+		}
+		fmt.Fprintf(os.Stderr, "🔍 Reviewing %s\n", description)
+		err = aiClient.StreamQueryWithTools(reviewPrompt)
+		tools.ShutdownLanguageServers()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		report, err := review.Parse(trimMarkdownFence(aiClient.LastResponse()))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		var output []byte
+		switch reviewFormat {
+		case "json":
+			output, err = json.MarshalIndent(report, "", "  ")
+		case "sarif":
+			output, err = report.SARIF(version)
+		default:
+			output = []byte(report.Text())
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(output) > 0 && output[len(output)-1] != '\n' {
+			output = append(output, '\n')
+		}
+
+		if reviewOutput == "" {
+			if reviewFormat == "text" {
+				fmt.Println()
+			}
+			os.Stdout.Write(output)
+		} else {
+			path := reviewOutput
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(workDir, path)
+			}
+			if err := os.WriteFile(path, output, 0644); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: Failed to write review: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "\nReview with %d finding(s) written to %s\n", len(report.Findings), path)
+		}
+
+		if reviewFailOn != "" && reviewFailOn != "none" {
+			if n := report.Count(reviewFailOn); n > 0 {
+				fmt.Fprintf(os.Stderr, "%d finding(s) of severity %s or higher\n", n, reviewFailOn)
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+// reviewDiff 取得要审查的diff及其描述
+func reviewDiff(workDir, ref string, staged bool) (string, string, error) {
+	args := []string{"diff", "--no-color", "--no-ext-diff"}
+	var description string
+	switch {
+	case staged:
+		args = append(args, "--cached")
+		description = "staged changes"
+	case ref == "":
+		args = append(args, "HEAD")
+		description = "uncommitted changes"
+	case strings.Contains(ref, ".."):
+		args = append(args, ref)
+		description = "changes in " + ref
+	default:
+		// 与分支的分叉点比较，只审查当前分支引入的改动（含未提交的改动）
+		base, err := gitOutput(workDir, "merge-base", ref, "HEAD")
+		if err != nil {
+			return "", "", err
+		}
+		args = append(args, strings.TrimSpace(base))
+		description = "changes since " + ref
+	}
+
+	diff, err := gitOutput(workDir, args...)
+	if err != nil {
+		return "", "", err
+	}
+	return diff, description, nil
+}
+
+// gitOutput 在 dir 中运行git命令并返回标准输出，失败时带上git的错误信息
+func gitOutput(dir string, args ...string) (string, error) {
+	command := exec.Command("git", args...)
+	command.Dir = dir
+	var stderr strings.Builder
+	command.Stderr = &stderr
+	output, err := command.Output()
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], message)
+	}
+	return string(output), nil
+}
+
+func init() {
+	reviewCmd.Flags().BoolVar(&reviewStaged, "staged", false, "Review the staged changes")
+	reviewCmd.Flags().StringVar(&reviewFormat, "format", "text", "Output format: text, json or sarif")
+	reviewCmd.Flags().StringVarP(&reviewOutput, "output", "o", "", "Write the findings to a file instead of standard output")
+	reviewCmd.Flags().StringVar(&reviewFailOn, "fail-on", "none", "Exit with status 1 on findings of this severity or higher: error, warning, note or none")
+	reviewCmd.RegisterFlagCompletionFunc("format", completeValues("text", "json", "sarif"))
+	reviewCmd.RegisterFlagCompletionFunc("fail-on", completeValues("error", "warning", "note", "none"))
+	rootCmd.AddCommand(reviewCmd)
+}
\ No newline at end of file
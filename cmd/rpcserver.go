@@ -0,0 +1,476 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"openCursor/internal/client"
+	"openCursor/internal/config"
+	"openCursor/internal/rpc"
+	"openCursor/internal/session"
+	"openCursor/internal/tools"
+)
+
+// rpcServer lsp-like 与 serve 模式共用的查询服务，通过 JSON-RPC 与 gRPC 提供。
+// 所有连接共享当前目录与工具，同时只运行一个查询
+type rpcServer struct {
+	cfg     *config.Config
+	apiKey  string
+	baseURL string
+	model   string
+	workDir string
+
+	mu             sync.Mutex
+	active         *rpcQuery            // 正在运行的查询，没有时为nil
+	approvals      map[string]chan bool // 等待回复的命令确认
+	nextApproval   int
+	subscribers    map[int]func(method string, params interface{}) // 接收所有查询通知的订阅者
+	nextSubscriber int
+}
+
+// rpcQuery 一次正在运行的查询
+type rpcQuery struct {
+	sessionID string
+	client    *client.Client
+	owner     interface{}                             // 发起查询的连接，断开时取消查询
+	notify    func(method string, params interface{}) // 发送查询的通知给发起的连接，可以为nil
+	cancelled chan struct{}                           // 取消后关闭，结束等待中的命令确认
+	finished  chan struct{}                           // 查询结束并回复后关闭
+	once      sync.Once
+}
+
+// cancel 在当前步骤完成后停止查询
+func (q *rpcQuery) cancel() {
+	q.once.Do(func() {
+		close(q.cancelled)
+		q.client.Interrupt()
+		interruptSubtasks()
+	})
+}
+
+// queryParams query 请求的参数
+type queryParams struct {
+	Query     string `json:"query"`
+	SessionID string `json:"session_id,omitempty"`
+	Approval  string `json:"approval,omitempty"`
+}
+
+// queryResult query 请求的结果
+type queryResult struct {
+	SessionID string       `json:"session_id"`
+	Status    string       `json:"status"` // completed、interrupted 或 budget_exceeded
+	Response  string       `json:"response"`
+	Usage     client.Usage `json:"usage"`
+	Cost      float64      `json:"cost,omitempty"`
+}
+
+// approveParams approveTool 请求的参数
+type approveParams struct {
+	ApprovalID string `json:"approval_id"`
+	Approved   bool   `json:"approved"`
+}
+
+// queryEvent 查询过程中的通知参数
+type queryEvent struct {
+	SessionID string `json:"session_id"`
+	client.Event
+}
+
+// approvalRequest tool/approvalRequest 通知的参数
+type approvalRequest struct {
+	SessionID   string `json:"session_id"`
+	ApprovalID  string `json:"approval_id"`
+	Command     string `json:"command"`
+	Explanation string `json:"explanation"`
+}
+
+// fileChangeEvent diff/applied 通知的参数
+type fileChangeEvent struct {
+	SessionID string `json:"session_id"`
+	tools.FileChange
+}
+
+// eventMethods 客户端事件对应的通知方法
+var eventMethods = map[string]string{
+	client.EventTextDelta: "query/textDelta",
+	client.EventToolStart: "query/toolStart",
+	client.EventToolEnd:   "query/toolEnd",
+	client.EventUsage:     "query/usage",
+}
+
+// newRPCServer 按配置文件注册工具集中的工具并创建服务，出错时退出
+func newRPCServer(toolset string) *rpcServer {
+	apiKey, baseURL, model := loadAPISettings()
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	configureEmbeddings(cfg, apiKey, baseURL)
+
+	workDir, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to get current directory: %v\n", err)
+		os.Exit(1)
+	}
+	tools.SetSubtaskRunner(newSubtaskRunner(apiKey, baseURL, model))
+	err = setupAgentTools(cfg, baseURL, agentToolSetup{
+		register: func() error {
+			if err := tools.RegisterDefaultToolset(toolset); err != nil {
+				return err
+			}
+			// 可选工具可以执行程序，只在使用全部工具时注册
+			if toolset != tools.ToolsetAll {
+				return nil
+			}
+			tools.SetBrowserPath(cfg.BrowserPath)
+			return tools.RegisterDefaultOptionalTools(cfg.EnableTools)
+		},
+		workDir: workDir,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	server := &rpcServer{
+		cfg:         cfg,
+		apiKey:      apiKey,
+		baseURL:     baseURL,
+		model:       model,
+		workDir:     workDir,
+		approvals:   make(map[string]chan bool),
+		subscribers: make(map[int]func(method string, params interface{})),
+	}
+	tools.SetApprovalHandler(server.requestApproval)
+	tools.SetDefaultChangeObserver(server.fileChanged)
+	return server
+}
+
+// close 取消正在运行的查询并释放工具占用的资源
+func (s *rpcServer) close() {
+	s.mu.Lock()
+	query := s.active
+	s.mu.Unlock()
+	if query != nil {
+		query.cancel()
+		<-query.finished
+	}
+	tools.ShutdownLanguageServers()
+	tools.CloseCodeIndexes()
+}
+
+// serveConn 处理一个连接的消息直到收到 exit 通知或连接关闭，返回前取消该连接发起的查询。
+// 返回是否在 exit 前收到了 shutdown 请求
+func (s *rpcServer) serveConn(conn *rpc.Conn) bool {
+	shutdown := false
+	defer s.stopOwner(conn)
+	for {
+		msg, err := conn.Read()
+		if err != nil {
+			var rpcErr *rpc.Error
+			if errors.As(err, &rpcErr) {
+				conn.ReplyError(nil, rpcErr)
+				continue
+			}
+			if err != io.EOF {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+			return false
+		}
+		switch msg.Method {
+		case "exit":
+			return shutdown
+		case "":
+			continue // 服务端不发送请求，忽略响应
+		case "shutdown":
+			s.stopOwner(conn)
+			shutdown = true
+			if !msg.IsNotification() {
+				conn.Reply(msg.ID, nil)
+			}
+			continue
+		}
+		if shutdown {
+			if !msg.IsNotification() {
+				conn.ReplyError(msg.ID, rpc.Errorf(rpc.CodeInvalidRequest, "server is shutting down"))
+			}
+			continue
+		}
+
+		// query 在后台运行，结束时自行回复
+		if msg.Method == "query" && !msg.IsNotification() {
+			var params queryParams
+			rpcErr := decodeParams(msg.Params, &params)
+			if rpcErr == nil {
+				id := msg.ID
+				rpcErr = s.startQuery(params, conn, func(method string, params interface{}) {
+					conn.Notify(method, params)
+				}, func(result *queryResult, rpcErr *rpc.Error) {
+					if rpcErr != nil {
+						conn.ReplyError(id, rpcErr)
+					} else {
+						conn.Reply(id, result)
+					}
+				})
+			}
+			if rpcErr != nil {
+				conn.ReplyError(msg.ID, rpcErr)
+			}
+			continue
+		}
+		result, rpcErr := s.handle(msg)
+		if msg.IsNotification() {
+			continue
+		}
+		if rpcErr != nil {
+			conn.ReplyError(msg.ID, rpcErr)
+		} else {
+			conn.Reply(msg.ID, result)
+		}
+	}
+}
+
+// handle 处理 query、shutdown 与 exit 以外的请求或通知
+func (s *rpcServer) handle(msg *rpc.Message) (interface{}, *rpc.Error) {
+	switch msg.Method {
+	case "initialize":
+		return map[string]string{
+			"server":   "openCursor",
+			"version":  version,
+			"model":    s.model,
+			"work_dir": s.workDir,
+		}, nil
+	case "cancel":
+		s.mu.Lock()
+		query := s.active
+		s.mu.Unlock()
+		if query == nil {
+			return map[string]bool{"cancelled": false}, nil
+		}
+		query.cancel()
+		return map[string]bool{"cancelled": true}, nil
+	case "approveTool":
+		var params approveParams
+		if err := decodeParams(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		return nil, s.approve(params.ApprovalID, params.Approved)
+	}
+	return nil, rpc.Errorf(rpc.CodeMethodNotFound, "method %q not found", msg.Method)
+}
+
+// approve 回复等待中的命令确认
+func (s *rpcServer) approve(id string, approved bool) *rpc.Error {
+	s.mu.Lock()
+	reply, ok := s.approvals[id]
+	delete(s.approvals, id)
+	s.mu.Unlock()
+	if !ok {
+		return rpc.Errorf(rpc.CodeInvalidParams, "unknown approval_id %q", id)
+	}
+	reply <- approved
+	return nil
+}
+
+// subscribe 接收之后所有查询的通知，返回取消订阅的函数
+func (s *rpcServer) subscribe(handler func(method string, params interface{})) func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextSubscriber++
+	id := s.nextSubscriber
+	s.subscribers[id] = handler
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.subscribers, id)
+	}
+}
+
+// publish 将查询的通知发送给发起的连接与所有订阅者
+func (s *rpcServer) publish(query *rpcQuery, method string, params interface{}) {
+	if query.notify != nil {
+		query.notify(method, params)
+	}
+	s.mu.Lock()
+	handlers := make([]func(method string, params interface{}), 0, len(s.subscribers))
+	for _, handler := range s.subscribers {
+		handlers = append(handlers, handler)
+	}
+	s.mu.Unlock()
+	for _, handler := range handlers {
+		handler(method, params)
+	}
+}
+
+// decodeParams 解析请求参数
+func decodeParams(raw json.RawMessage, target interface{}) *rpc.Error {
+	if len(raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, target); err != nil {
+		return rpc.Errorf(rpc.CodeInvalidParams, "invalid params: %v", err)
+	}
+	return nil
+}
+
+// startQuery 在后台运行查询，结束时调用 reply。owner 断开时应调用 stopOwner
+func (s *rpcServer) startQuery(params queryParams, owner interface{}, notify func(method string, params interface{}), reply func(*queryResult, *rpc.Error)) *rpc.Error {
+	if params.Query == "" {
+		return rpc.Errorf(rpc.CodeInvalidParams, "query is required")
+	}
+	if params.Approval != "" && params.Approval != tools.ApprovalAuto && params.Approval != tools.ApprovalAsk && params.Approval != tools.ApprovalNever {
+		return rpc.Errorf(rpc.CodeInvalidParams, "invalid approval mode %q, expected %s, %s or %s", params.Approval, tools.ApprovalAuto, tools.ApprovalAsk, tools.ApprovalNever)
+	}
+
+	sess := session.New(s.workDir, s.model, params.Query)
+	if params.SessionID != "" {
+		loaded, err := session.Load(config.SessionsDir(), params.SessionID)
+		if err != nil {
+			return rpc.Errorf(rpc.CodeInvalidParams, "%v", err)
+		}
+		sess = loaded
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active != nil {
+		return rpc.Errorf(rpc.CodeRequestFailed, "a query is already running in session %s", s.active.sessionID)
+	}
+
+	if err := applyApproval(s.cfg, params.Approval); err != nil {
+		return rpc.Errorf(rpc.CodeInvalidParams, "%v", err)
+	}
+
+	aiClient := client.NewClient(s.apiKey, s.baseURL, s.model)
+	aiClient.SetOutput(io.Discard)
+	aiClient.SetToolManager(tools.GetDefaultManager())
+	aiClient.SetContextWindow(s.cfg.ContextWindow)
+	pricing, ok := s.cfg.Pricing[s.model]
+	if !ok {
+		pricing, _ = client.DefaultPricing(s.model)
+	}
+	aiClient.SetBudget(client.Budget{Pricing: pricing})
+	if len(sess.Messages) > 0 {
+		aiClient.SetHistory(sess.Messages)
+		attachRecentEdits(aiClient, sess)
+	}
+	query := &rpcQuery{
+		sessionID: sess.ID,
+		client:    aiClient,
+		owner:     owner,
+		notify:    notify,
+		cancelled: make(chan struct{}),
+		finished:  make(chan struct{}),
+	}
+	aiClient.SetEventHandler(func(event client.Event) {
+		if method, ok := eventMethods[event.Type]; ok {
+			s.publish(query, method, queryEvent{SessionID: sess.ID, Event: event})
+		}
+	})
+	s.active = query
+	go func() {
+		defer close(query.finished)
+		result, rpcErr := s.runQuery(query, sess, params.Query)
+		s.mu.Lock()
+		s.active = nil
+		s.mu.Unlock()
+		reply(result, rpcErr)
+	}()
+	return nil
+}
+
+// runQuery 运行查询并保存会话
+func (s *rpcServer) runQuery(query *rpcQuery, sess *session.Session, text string) (*queryResult, *rpc.Error) {
+	aiClient := query.client
+	startedAt := time.Now()
+	err := aiClient.StreamQueryWithTools(text)
+
+	interrupted := errors.Is(err, client.ErrInterrupted)
+	overBudget := errors.Is(err, client.ErrBudgetExceeded)
+	if len(aiClient.Messages()) > 0 {
+		sess.AddTurn(newSessionTurn(aiClient, text, s.model, startedAt))
+		recordSessionEdits(sess)
+		sess.SetMessages(aiClient.Messages())
+		sess.Interrupted = interrupted || overBudget
+		if saveErr := sess.Save(config.SessionsDir()); saveErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to save session: %v\n", saveErr)
+		}
+	}
+
+	result := &queryResult{
+		SessionID: sess.ID,
+		Status:    "completed",
+		Response:  aiClient.LastResponse(),
+		Usage:     aiClient.Usage(),
+		Cost:      aiClient.Cost(),
+	}
+	switch {
+	case interrupted:
+		result.Status = "interrupted"
+	case overBudget:
+		result.Status = "budget_exceeded"
+	case err != nil:
+		return nil, rpc.Errorf(rpc.CodeRequestFailed, "%v", err)
+	}
+	return result, nil
+}
+
+// requestApproval 请发起查询的连接或订阅者确认命令，查询取消时视为拒绝
+func (s *rpcServer) requestApproval(command, explanation string) bool {
+	s.mu.Lock()
+	query := s.active
+	if query == nil {
+		s.mu.Unlock()
+		return false
+	}
+	s.nextApproval++
+	id := strconv.Itoa(s.nextApproval)
+	reply := make(chan bool, 1)
+	s.approvals[id] = reply
+	s.mu.Unlock()
+
+	s.publish(query, "tool/approvalRequest", approvalRequest{
+		SessionID:   query.sessionID,
+		ApprovalID:  id,
+		Command:     command,
+		Explanation: explanation,
+	})
+	select {
+	case approved := <-reply:
+		return approved
+	case <-query.cancelled:
+		s.mu.Lock()
+		delete(s.approvals, id)
+		s.mu.Unlock()
+		return false
+	}
+}
+
+// fileChanged 通知发起查询的连接与订阅者工具修改了文件
+func (s *rpcServer) fileChanged(change tools.FileChange) {
+	s.mu.Lock()
+	query := s.active
+	s.mu.Unlock()
+	if query == nil {
+		return
+	}
+	s.publish(query, "diff/applied", fileChangeEvent{SessionID: query.sessionID, FileChange: change})
+}
+
+// stopOwner 取消连接发起的查询并等待其结束
+func (s *rpcServer) stopOwner(owner interface{}) {
+	s.mu.Lock()
+	query := s.active
+	s.mu.Unlock()
+	if query != nil && query.owner == owner {
+		query.cancel()
+		<-query.finished
+	}
+}
\ No newline at end of file
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"openCursor/internal/config"
+	"openCursor/internal/schedule"
+
+	"github.com/spf13/cobra"
+)
+
+// scheduleArtifactsDir --artifacts-dir 定时任务产物目录
+var scheduleArtifactsDir string
+
+// scheduleCmd 前台运行定时任务调度器
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Run configured tasks on a cron schedule",
+	Long: `Run the tasks configured under "schedules" in the config file on their cron
+schedules. The scheduler stays in the foreground until interrupted; run it under
+systemd, launchd, tmux or a container to keep it alive.
+
+Each run starts a separate, non-interactive openCursor process in the task's
+workdir (no stdin, OPENCURSOR_SCHEDULE_JOB set to the task name) and stores its
+artifacts in <artifacts-dir>/<name>/<timestamp>/:
+  output.log      Everything the agent printed
+  changes.patch   Changes made by the run (git repositories only), apply with git apply
+  run.json        Run record: timing, exit code, error and changed files
+
+Configuration (~/.opencursor/config.yaml):
+  schedules:
+    - name: deps-update
+      cron: "0 3 * * 1-5"          # minute hour day-of-month month day-of-week, or @daily
+      workdir: ~/src/my-service
+      prompt: Update outdated Go dependencies, run the tests and commit on a new branch
+      args: [--repo-map]           # extra openCursor flags
+      timeout: 45m                 # default 30m
+
+Examples:
+  openCursor schedule
+  openCursor schedule list
+  openCursor schedule run deps-update`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		entries, opts := loadSchedules()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		scheduler := schedule.NewScheduler(entries, opts, os.Stdout)
+		if err := scheduler.Start(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// scheduleListCmd 列出定时任务及下一次运行时间
+var scheduleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List configured schedules and their next run time",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		entries, _ := loadSchedules()
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "NAME\tCRON\tNEXT RUN\tWORKDIR")
+		now := time.Now()
+		for _, entry := range entries {
+			next := "never"
+			if t := entry.NextRun(now); !t.IsZero() {
+				next = t.Format("2006-01-02 15:04")
+			}
+			workDir := entry.Job.WorkDir
+			if workDir == "" {
+				workDir = "."
+			}
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", entry.Job.Name, entry.Job.Cron, next, workDir)
+		}
+		writer.Flush()
+	},
+}
+
+// scheduleRunCmd 立即执行一次指定的定时任务
+var scheduleRunCmd = &cobra.Command{
+	Use:   "run <name>",
+	Short: "Run a configured schedule once, now",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		entries, opts := loadSchedules()
+
+		entry, ok := schedule.Find(entries, args[0])
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: no schedule named %q\n", args[0])
+			os.Exit(1)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		opts.Output = os.Stdout
+		record, err := schedule.Run(ctx, entry.Job, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("\n[schedule] %s: %s\n", entry.Job.Name, schedule.Summary(record))
+		if record.Error != "" {
+			os.Exit(1)
+		}
+	},
+}
+
+// loadSchedules 从配置文件加载定时任务与运行选项
+func loadSchedules() ([]schedule.Entry, schedule.RunOptions) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// 支持在workdir中使用 ~ 表示主目录
+	for i := range cfg.Schedules {
+		cfg.Schedules[i].WorkDir = expandHome(cfg.Schedules[i].WorkDir)
+		cfg.Schedules[i].ArtifactsDir = expandHome(cfg.Schedules[i].ArtifactsDir)
+	}
+
+	entries, err := schedule.Load(cfg.Schedules)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(entries) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: no schedules configured in %s\n", configPath)
+		os.Exit(1)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to locate the openCursor executable: %v\n", err)
+		os.Exit(1)
+	}
+
+	return entries, schedule.RunOptions{
+		Executable:   executable,
+		BaseArgs:     []string{"--config", configPath},
+		ArtifactsDir: expandHome(scheduleArtifactsDir),
+	}
+}
+
+// expandHome 展开路径开头的 ~
+func expandHome(path string) string {
+	if path == "~" || len(path) > 1 && path[:2] == "~/" {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
+func init() {
+	scheduleCmd.PersistentFlags().StringVar(&scheduleArtifactsDir, "artifacts-dir", filepath.Join(config.DefaultDir(), "schedule"), "Directory for run logs, patches and records")
+	scheduleRunCmd.ValidArgsFunction = completeSchedules
+	scheduleCmd.AddCommand(scheduleListCmd)
+	scheduleCmd.AddCommand(scheduleRunCmd)
+	rootCmd.AddCommand(scheduleCmd)
+}
\ No newline at end of file
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"openCursor/internal/testsupport"
+
+	"github.com/spf13/cobra"
+)
+
+// selftestVerbose --verbose 同时列出通过的用例
+var selftestVerbose bool
+
+// selftestCmd 在当前环境中运行内置的工具用例
+var selftestCmd = &cobra.Command{
+	Use:   "selftest [tool...]",
+	Short: "Check that every tool works in this environment",
+	Long: `Run the built-in golden cases of every tool in temporary workspaces and compare the
+results with the expected ones. Use this to find problems specific to this machine,
+such as a missing or different ripgrep, shell or git, or path handling on Windows.
+The cases also cover the safety checks: the security policy, paths outside the
+workspace, the command decisions of openCursor ci, openCursor apply-plan
+(selected as apply_plan) and archive entries leaving the destination. Optional
+tools such as view_image are enabled for their own cases.
+
+Cases whose requirements (for example git) are missing, and tools that are not
+available (language server tools without an installed server), are skipped.
+No API key is needed and nothing outside the temporary workspaces is changed.
+
+Examples:
+  openCursor selftest
+  openCursor selftest grep_search git_log
+  openCursor selftest --verbose`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cases, err := testsupport.LoadGolden()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		cases = testsupport.Filter(cases, args)
+		if len(cases) == 0 {
+			fmt.Fprintf(os.Stderr, "Error: no cases match %s\n", strings.Join(args, ", "))
+			os.Exit(1)
+		}
+
+		// 环境信息，便于对照平台差异
+		fmt.Printf("Platform: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+		for _, name := range []string{"git", "rg", "sh"} {
+			location := "not found"
+			if path, err := exec.LookPath(name); err == nil {
+				location = path
+			}
+			fmt.Printf("  %-4s %s\n", name, location)
+		}
+		fmt.Println()
+
+		passed, failed, skipped := 0, 0, 0
+		for _, c := range cases {
+			outcome := testsupport.Run(c)
+			switch {
+			case outcome.Skipped != "":
+				skipped++
+				if selftestVerbose {
+					fmt.Printf("⏭  SKIP %s (%s)\n", c.ID(), outcome.Skipped)
+				}
+			case outcome.Passed():
+				passed++
+				if selftestVerbose {
+					fmt.Printf("✅ PASS %s (%dms)\n", c.ID(), outcome.Duration.Milliseconds())
+				}
+			default:
+				failed++
+				fmt.Printf("❌ FAIL %s\n", c.ID())
+				for _, failure := range outcome.Failures {
+					fmt.Printf("       %s\n", failure)
+				}
+			}
+		}
+
+		fmt.Printf("\n%d passed, %d failed, %d skipped\n", passed, failed, skipped)
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	selftestCmd.Flags().BoolVarP(&selftestVerbose, "verbose", "v", false, "Also list passed and skipped cases")
+	rootCmd.AddCommand(selftestCmd)
+}
\ No newline at end of file
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	opencursorv1 "openCursor/api/opencursor/v1"
+	"openCursor/internal/rpc"
+	"openCursor/internal/tools"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/net/websocket"
+	"google.golang.org/grpc"
+)
+
+// serve 命令参数
+var (
+	serveAddr         string   // --addr 监听地址
+	serveGRPCAddr     string   // --grpc-addr gRPC 服务的监听地址
+	serveAllowOrigins []string // --allow-origin 允许连接的浏览器页面来源
+)
+
+// serveCmd 以 HTTP 服务的形式提供查询接口
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve queries over HTTP and gRPC for browser UIs and other clients",
+	Long: `Run an HTTP server in the current directory for browser UIs and other clients.
+
+Endpoints:
+  /ws       WebSocket chat endpoint. Every text frame carries one JSON-RPC 2.0
+            message of the same protocol as "openCursor lsp-like": the client
+            sends query, cancel and approveTool requests, the server streams
+            query/textDelta, query/toolStart, query/toolEnd, query/usage,
+            tool/approvalRequest and diff/applied notifications and answers the
+            query when the run ends. With "approval": "ask" in a query every
+            command that is not known to be read-only waits for approveTool,
+            so the UI can show an interactive approval dialog.
+  /health   Returns "ok"
+
+With --grpc-addr the same service is also offered over gRPC for typed clients
+in other languages, see api/opencursor/v1/opencursor.proto: Query runs a query
+and returns when it ends (cancelling the call stops it), StreamEvents streams
+the events of all queries, including approval requests, ApproveTool answers
+them and ListSessions lists the saved sessions.
+
+All connections share the current directory and run one query at a time.
+Closing a connection cancels the query it started.
+
+The server listens on localhost by default and runs commands with your
+permissions: only expose it on other interfaces behind an authenticating proxy.
+Browsers may only connect from a page served by the same host or from an
+origin given with --allow-origin.
+
+Example:
+  openCursor serve --addr 127.0.0.1:8765
+  openCursor serve --grpc-addr 127.0.0.1:8766
+  // in the browser
+  const ws = new WebSocket("ws://127.0.0.1:8765/ws")
+  ws.send(JSON.stringify({jsonrpc: "2.0", id: 1, method: "query",
+                          params: {query: "Add a README", approval: "ask"}}))`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		listener, err := net.Listen("tcp", serveAddr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		var grpcListener net.Listener
+		if serveGRPCAddr != "" {
+			grpcListener, err = net.Listen("tcp", serveGRPCAddr)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		server := newRPCServer(tools.ToolsetAll)
+
+		mux := http.NewServeMux()
+		mux.Handle("/ws", websocket.Server{
+			Handshake: checkOrigin,
+			Handler: func(ws *websocket.Conn) {
+				server.serveConn(rpc.NewConn(rpc.NewWebSocketStream(ws)))
+			},
+		})
+		mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintln(w, "ok")
+		})
+		httpServer := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+		var grpcServer *grpc.Server
+		if grpcListener != nil {
+			grpcServer = grpc.NewServer()
+			opencursorv1.RegisterOpenCursorServer(grpcServer, &grpcService{server: server})
+			go func() {
+				if err := grpcServer.Serve(grpcListener); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+			}()
+			fmt.Printf("🔌 gRPC 正在监听 %s\n", grpcListener.Addr())
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			<-ctx.Done()
+			server.close()
+			if grpcServer != nil {
+				grpcServer.Stop()
+			}
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			httpServer.Shutdown(shutdownCtx)
+		}()
+
+		fmt.Printf("🌐 正在监听 http://%s（WebSocket: ws://%s/ws）\n", listener.Addr(), listener.Addr())
+		if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// checkOrigin 只接受非浏览器客户端（没有 Origin）、同一主机的页面与 --allow-origin 指定的来源，
+// 防止任意网页连接本地服务执行命令
+func checkOrigin(config *websocket.Config, req *http.Request) error {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	for _, allowed := range serveAllowOrigins {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return nil
+		}
+	}
+	parsed, err := url.Parse(origin)
+	if err != nil || !strings.EqualFold(parsed.Host, req.Host) {
+		return fmt.Errorf("origin %q not allowed", origin)
+	}
+	return nil
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "127.0.0.1:8765", "Address to listen on")
+	serveCmd.Flags().StringVar(&serveGRPCAddr, "grpc-addr", "", "Also serve the gRPC API on this address, e.g. 127.0.0.1:8766")
+	serveCmd.Flags().StringArrayVar(&serveAllowOrigins, "allow-origin", nil, "Origin of a browser page allowed to connect, e.g. http://localhost:3000 (repeatable, * allows any)")
+	rootCmd.AddCommand(serveCmd)
+}
\ No newline at end of file
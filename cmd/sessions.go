@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"openCursor/internal/config"
+	"openCursor/internal/session"
+
+	"github.com/spf13/cobra"
+)
+
+// sessions fork 命令参数
+var (
+	sessionsForkAt    int  // --at 分叉时保留的轮数
+	sessionsForkQuiet bool // --quiet 只输出新会话的ID
+)
+
+// sessionsCmd 列出保存的会话
+var sessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "List, inspect and fork saved sessions",
+	Long: `List the saved sessions, newest first, with their number of turns (queries),
+workspace and first query. Use "sessions show" to list the turns of a session and
+"sessions fork" to branch a session off at a turn, so an alternative approach can
+be explored without losing the original conversation.
+
+Examples:
+  openCursor sessions
+  openCursor sessions show 20240131-101500-a1b2c3
+  openCursor sessions fork 20240131-101500-a1b2c3 --at 12`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		sessions, err := session.List(config.SessionsDir())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(sessions) == 0 {
+			fmt.Println("没有保存的会话")
+			return
+		}
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "UPDATED\tSESSION\tTURNS\tWORKSPACE\tQUERY")
+		for _, s := range sessions {
+			turns := fmt.Sprintf("%d", len(s.History()))
+			if s.ForkedFrom != "" {
+				turns += fmt.Sprintf(" (fork of %s@%d)", s.ForkedFrom, s.ForkedAt)
+			}
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n",
+				s.UpdatedAt.Local().Format("2006-01-02 15:04"),
+				s.ID,
+				turns,
+				shortenHome(s.WorkDir),
+				truncateQuery(s.Query, historyQueryWidth))
+		}
+		writer.Flush()
+	},
+}
+
+// sessionsShowCmd 列出会话中的各轮查询
+var sessionsShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "List the turns of a saved session",
+	Long: `List the turns (queries) of a saved session with their number, time, model,
+token usage and cost. The FORK column shows whether the session can be forked
+after that turn: turns whose conversation was later summarized to fit the context
+window, or that were saved by an older version, cannot be forked except after the
+last turn.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSessions,
+	Run: func(cmd *cobra.Command, args []string) {
+		s, err := session.Load(config.SessionsDir(), args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Session:   %s\n", s.ID)
+		fmt.Printf("Workspace: %s\n", shortenHome(s.WorkDir))
+		if s.ForkedFrom != "" {
+			fmt.Printf("Forked:    from %s after turn %d\n", s.ForkedFrom, s.ForkedAt)
+		}
+		if s.Interrupted {
+			fmt.Println("Status:    interrupted")
+		}
+		fmt.Println()
+
+		history := s.History()
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "TURN\tTIME\tMODEL\tTOKENS\tCOST\tFORK\tQUERY")
+		for i, turn := range history {
+			fork := "yes"
+			if i < len(history)-1 && turn.End == 0 {
+				fork = "no"
+			}
+			fmt.Fprintf(writer, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				i+1,
+				turn.StartedAt.Local().Format("2006-01-02 15:04"),
+				turn.Model,
+				formatTurnTokens(turn),
+				formatTurnCost(turn),
+				fork,
+				truncateQuery(turn.Query, historyQueryWidth))
+		}
+		writer.Flush()
+	},
+}
+
+// sessionsForkCmd 从指定轮次分叉会话
+var sessionsForkCmd = &cobra.Command{
+	Use:   "fork <id> --at <turn>",
+	Short: "Copy a session up to a turn into a new session",
+	Long: `Create a new session containing the conversation of a saved session up to and
+including turn --at (see "sessions show" for the turn numbers), and print its ID.
+Continue the new session with openCursor --resume <new-id> "<query>" to try a
+different approach from that point; the original session is left unchanged.
+
+Only the conversation is forked: files the agent changed in later turns of the
+original session stay changed in the working tree. With --auto-commit each edit
+of the original run is a commit on the opencursor/<session> branch, which can be
+used to restore the files to the state after the fork turn.
+
+Examples:
+  openCursor sessions fork 20240131-101500-a1b2c3 --at 12
+  openCursor --resume $(openCursor sessions fork 20240131-101500-a1b2c3 --at 3 --quiet) "use a worker pool instead"`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSessions,
+	Run: func(cmd *cobra.Command, args []string) {
+		s, err := session.Load(config.SessionsDir(), args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		at := sessionsForkAt
+		if !cmd.Flags().Changed("at") {
+			at = len(s.History())
+		}
+
+		fork, err := s.Fork(at)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := fork.Save(config.SessionsDir()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if sessionsForkQuiet {
+			fmt.Println(fork.ID)
+			return
+		}
+		fmt.Printf("Forked %s after turn %d of %d: %s\n", s.ID, at, len(s.History()), fork.ID)
+		fmt.Printf("Continue with: openCursor --resume %s \"<query>\"\n", fork.ID)
+	},
+}
+
+func init() {
+	sessionsForkCmd.Flags().IntVar(&sessionsForkAt, "at", 0, "Keep the conversation up to and including this turn (default: all turns)")
+	sessionsForkCmd.Flags().BoolVarP(&sessionsForkQuiet, "quiet", "q", false, "Only print the ID of the new session")
+	sessionsCmd.AddCommand(sessionsShowCmd)
+	sessionsCmd.AddCommand(sessionsForkCmd)
+	rootCmd.AddCommand(sessionsCmd)
+}
@@ -0,0 +1,365 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+
+	"openCursor/internal/config"
+	"openCursor/internal/rpc"
+	"openCursor/internal/slack"
+	"openCursor/internal/tools"
+
+	"github.com/spf13/cobra"
+)
+
+// slack 命令参数
+var (
+	slackToolset  string // --toolset 提供给模型的工具集
+	slackApproval string // --approval 命令确认模式
+)
+
+// slackCmd 在 Slack 中回答问题
+var slackCmd = &cobra.Command{
+	Use:   "slack",
+	Short: "Answer questions in Slack threads as a bot",
+	Long: `Connect to Slack via Socket Mode and answer questions about the repository in the
+current directory. Mention the app in a channel or send it a direct message: the
+query runs here, and a summary of the tools it used and the answer are posted
+back to the thread. Every thread is its own session, so replies in the thread
+(no mention needed) are follow-ups with the earlier conversation. Queries run
+one after another.
+
+By default the bot only gets read-only tools: it can search and read the code
+but not change files or run commands. --toolset edit also lets it edit files,
+--toolset all gives it every tool; commands then need approval (--approval ask,
+the default): the bot posts the command to the thread and waits for a reply of
+"approve" or "deny".
+
+Setup:
+  1. Create a Slack app, enable Socket Mode and create an app-level token with
+     the connections:write scope (xapp-...)
+  2. Add the bot scopes app_mentions:read, chat:write, im:history and
+     channels:history, subscribe to the app_mention, message.im and
+     message.channels events and install the app (bot token xoxb-...)
+  3. export SLACK_APP_TOKEN=xapp-... SLACK_BOT_TOKEN=xoxb-...
+     (or slack_app_token and slack_bot_token in the config file)
+
+The sessions of the threads are recorded in ~/.opencursor/slack/threads.json.
+
+Examples:
+  openCursor slack
+  openCursor slack --toolset all --approval ask`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		appToken := os.Getenv("SLACK_APP_TOKEN")
+		if appToken == "" {
+			appToken = cfg.SlackAppToken
+		}
+		botToken := os.Getenv("SLACK_BOT_TOKEN")
+		if botToken == "" {
+			botToken = cfg.SlackBotToken
+		}
+		if appToken == "" || botToken == "" {
+			fmt.Fprintf(os.Stderr, "Error: SLACK_APP_TOKEN and SLACK_BOT_TOKEN are required (see openCursor slack --help)\n")
+			os.Exit(1)
+		}
+		if slackApproval != tools.ApprovalAuto && slackApproval != tools.ApprovalAsk {
+			fmt.Fprintf(os.Stderr, "Error: invalid approval mode %q, expected %s or %s\n", slackApproval, tools.ApprovalAuto, tools.ApprovalAsk)
+			os.Exit(1)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		sc := slack.NewClient(appToken, botToken)
+		userID, err := sc.AuthTest(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		threadsPath := filepath.Join(config.DefaultDir(), "slack", "threads.json")
+		threads, err := loadSlackThreads(threadsPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		bot := &slackBot{
+			client:      sc,
+			server:      newRPCServer(slackToolset),
+			userID:      userID,
+			jobs:        make(chan slack.Event, 100),
+			threads:     threads,
+			threadsPath: threadsPath,
+			pending:     make(map[string]string),
+		}
+		go bot.work(ctx)
+
+		err = sc.Listen(ctx, bot.handle, func() {
+			fmt.Printf("🔌 已连接到 Slack（工具集: %s）\n", slackToolset)
+		})
+		bot.server.close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// slackMention 消息中对用户的提及
+var slackMention = regexp.MustCompile(`<@[A-Z0-9]+>`)
+
+// slackBot 将 Slack 会话串中的消息作为查询运行
+type slackBot struct {
+	client *slack.Client
+	server *rpcServer
+	userID string // 机器人的用户ID
+	jobs   chan slack.Event
+
+	mu          sync.Mutex
+	threads     map[string]string // 会话串（频道:串的ts）对应的会话ID
+	threadsPath string
+	pending     map[string]string // 会话串中等待回复的命令确认ID
+}
+
+// handle 处理 Socket Mode 推送的事件：回复命令确认，或将查询加入队列
+func (b *slackBot) handle(event slack.Event) {
+	if event.Subtype != "" || event.BotID != "" || event.User == "" || event.User == b.userID {
+		return
+	}
+	key := event.Channel + ":" + event.Thread()
+	text := strings.TrimSpace(slackMention.ReplaceAllString(event.Text, ""))
+	mentioned := strings.Contains(event.Text, "<@"+b.userID+">")
+
+	// 等待确认的会话串中的回复
+	b.mu.Lock()
+	approvalID, waiting := b.pending[key]
+	_, tracked := b.threads[key]
+	b.mu.Unlock()
+	if waiting && event.Type == "message" {
+		if approved, ok := parseApproval(text); ok {
+			b.mu.Lock()
+			delete(b.pending, key)
+			b.mu.Unlock()
+			b.server.approve(approvalID, approved)
+			return
+		}
+	}
+
+	switch {
+	case event.Type == "app_mention":
+	case event.Type != "message" || mentioned:
+		return // 提及同时会推送 app_mention 事件
+	case event.ChannelType == "im":
+	case event.ThreadTS != "" && tracked:
+	default:
+		return
+	}
+	if text == "" {
+		return
+	}
+	event.Text = text
+	select {
+	case b.jobs <- event:
+	default:
+		go b.post(event, "⏳ 排队的问题太多，请稍后再试")
+	}
+}
+
+// parseApproval 解析对命令确认的回复
+func parseApproval(text string) (approved, ok bool) {
+	switch strings.ToLower(strings.Trim(text, " .!")) {
+	case "approve", "approved", "yes", "y", "ok", "批准", "同意":
+		return true, true
+	case "deny", "denied", "no", "n", "reject", "拒绝":
+		return false, true
+	}
+	return false, false
+}
+
+// work 依次运行队列中的查询
+func (b *slackBot) work(ctx context.Context) {
+	for {
+		select {
+		case event := <-b.jobs:
+			b.run(ctx, event)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// slackStep 查询中的一次工具调用
+type slackStep struct {
+	tool   string
+	failed bool
+}
+
+// run 运行一个会话串中的查询，并将工具调用摘要与回答发到会话串
+func (b *slackBot) run(ctx context.Context, event slack.Event) {
+	key := event.Channel + ":" + event.Thread()
+	b.mu.Lock()
+	sessionID := b.threads[key]
+	b.mu.Unlock()
+
+	var steps []slackStep
+	type reply struct {
+		result *queryResult
+		err    *rpc.Error
+	}
+	done := make(chan reply, 1)
+	notify := func(method string, params interface{}) {
+		switch p := params.(type) {
+		case queryEvent:
+			if method == "query/toolEnd" {
+				steps = append(steps, slackStep{tool: p.Tool, failed: p.Status == "failed"})
+			}
+		case approvalRequest:
+			b.mu.Lock()
+			b.pending[key] = p.ApprovalID
+			b.mu.Unlock()
+			b.post(event, fmt.Sprintf("❓ 需要确认执行命令: `%s`\n%s\n回复 approve 执行或 deny 拒绝", p.Command, p.Explanation))
+		}
+	}
+	rpcErr := b.server.startQuery(queryParams{Query: event.Text, SessionID: sessionID, Approval: slackApproval}, key, notify, func(result *queryResult, rpcErr *rpc.Error) {
+		done <- reply{result, rpcErr}
+	})
+	if rpcErr != nil {
+		b.post(event, "❌ "+rpcErr.Message)
+		return
+	}
+	fmt.Printf("💬 %s: %s\n", key, firstLine(event.Text))
+
+	var r reply
+	select {
+	case r = <-done:
+	case <-ctx.Done():
+		b.server.stopOwner(key)
+		r = <-done
+	}
+	b.mu.Lock()
+	delete(b.pending, key)
+	b.mu.Unlock()
+	if r.err != nil {
+		b.post(event, "❌ "+r.err.Message)
+		return
+	}
+
+	b.mu.Lock()
+	b.threads[key] = r.result.SessionID
+	err := saveSlackThreads(b.threadsPath, b.threads)
+	b.mu.Unlock()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	if summary := summarizeSlackSteps(steps); summary != "" {
+		b.post(event, summary)
+	}
+	answer := r.result.Response
+	switch r.result.Status {
+	case "interrupted":
+		answer += "\n\n⏸ 已中断"
+	case "budget_exceeded":
+		answer += "\n\n💰 已达到用量上限"
+	}
+	if strings.TrimSpace(answer) == "" {
+		answer = "（没有回答）"
+	}
+	b.post(event, answer)
+}
+
+// post 回复到事件所在的会话串，失败时只打印警告
+func (b *slackBot) post(event slack.Event, text string) {
+	if err := b.client.PostMessage(context.Background(), event.Channel, event.Thread(), text); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// summarizeSlackSteps 工具调用摘要，按工具首次使用的顺序列出次数
+func summarizeSlackSteps(steps []slackStep) string {
+	if len(steps) == 0 {
+		return ""
+	}
+	var order []string
+	counts := make(map[string]int)
+	failed := 0
+	for _, step := range steps {
+		if counts[step.tool] == 0 {
+			order = append(order, step.tool)
+		}
+		counts[step.tool]++
+		if step.failed {
+			failed++
+		}
+	}
+	parts := make([]string, 0, len(order))
+	for _, tool := range order {
+		if counts[tool] > 1 {
+			parts = append(parts, fmt.Sprintf("`%s` ×%d", tool, counts[tool]))
+		} else {
+			parts = append(parts, fmt.Sprintf("`%s`", tool))
+		}
+	}
+	summary := fmt.Sprintf("🔧 %d 次工具调用: %s", len(steps), strings.Join(parts, ", "))
+	if failed > 0 {
+		summary += fmt.Sprintf("（%d 次失败）", failed)
+	}
+	return summary
+}
+
+// loadSlackThreads 读取会话串对应的会话，文件不存在时返回空表
+func loadSlackThreads(path string) (map[string]string, error) {
+	threads := make(map[string]string)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return threads, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &threads); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return threads, nil
+}
+
+// saveSlackThreads 保存会话串对应的会话
+func saveSlackThreads(path string, threads map[string]string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	data, err := json.MarshalIndent(threads, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+func init() {
+	slackCmd.Flags().StringVar(&slackToolset, "toolset", tools.ToolsetReadOnly, "Tools available to queries: read_only, edit or all")
+	slackCmd.Flags().StringVar(&slackApproval, "approval", tools.ApprovalAsk, "Command approval mode with --toolset all: ask posts every command that is not known to be read-only to the thread for approval, auto runs it")
+	slackCmd.RegisterFlagCompletionFunc("toolset", completeValues(tools.ToolsetReadOnly, tools.ToolsetEdit, tools.ToolsetAll))
+	slackCmd.RegisterFlagCompletionFunc("approval", completeValues(tools.ApprovalAuto, tools.ApprovalAsk))
+	rootCmd.AddCommand(slackCmd)
+}
\ No newline at end of file
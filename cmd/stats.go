@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"openCursor/internal/config"
+	"openCursor/internal/session"
+
+	"github.com/spf13/cobra"
+)
+
+// stats 命令参数
+var (
+	statsDays int  // --days 统计最近的天数
+	statsHere bool // --here 只统计当前目录的会话
+	statsJSON bool // --json 以JSON输出
+)
+
+// statsBarWidth 每日用量条形图的最大宽度
+const statsBarWidth = 30
+
+// statsCmd 汇总用量统计
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show token usage, cost and activity per day and per model",
+	Long: `Aggregate the saved sessions (every query run with openCursor <query>, including
+scheduled and daemon tasks) into token usage, estimated cost, tool calls and file
+edits per day and per model.
+
+Token counts marked with ~ include estimates (the provider did not report usage);
+costs marked with + exclude queries whose model price is unknown (see pricing: in
+the config file). Sessions saved before usage was recorded count as queries only.
+
+Examples:
+  openCursor stats
+  openCursor stats --days 7 --here
+  openCursor stats --days 0 --json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var since time.Time
+		if statsDays > 0 {
+			now := time.Now()
+			since = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, -(statsDays - 1))
+		}
+		workDir := ""
+		if statsHere {
+			var err error
+			workDir, err = os.Getwd()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: Failed to get working directory: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		sessions, err := session.List(config.SessionsDir())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		stats := session.Aggregate(sessions, since, workDir)
+
+		if statsJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(stats); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if stats.Total.Queries == 0 {
+			fmt.Println("没有找到用量记录")
+			return
+		}
+
+		period := "全部"
+		if statsDays > 0 {
+			period = fmt.Sprintf("最近 %d 天", statsDays)
+		}
+		fmt.Printf("📊 %s: %d 次查询，%s tokens（输入 %d / 输出 %d），估计费用 %s，%d 次工具调用，%d 次文件修改\n\n",
+			period, stats.Total.Queries, formatStatsTokens(stats.Total), stats.Total.PromptTokens, stats.Total.CompletionTokens,
+			formatStatsCost(stats.Total), stats.Total.ToolCalls, stats.Total.Edits)
+
+		maxTokens := 0
+		for _, day := range stats.ByDay {
+			if day.Tokens() > maxTokens {
+				maxTokens = day.Tokens()
+			}
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "DAY\tQUERIES\tTOKENS\tCOST\tTOOL CALLS\tEDITS\t")
+		for _, day := range stats.ByDay {
+			bar := ""
+			if maxTokens > 0 {
+				bar = strings.Repeat("█", (day.Tokens()*statsBarWidth+maxTokens-1)/maxTokens)
+			}
+			fmt.Fprintf(writer, "%s\t%d\t%s\t%s\t%d\t%d\t%s\n", day.Key, day.Queries, formatStatsTokens(day.Totals), formatStatsCost(day.Totals), day.ToolCalls, day.Edits, bar)
+		}
+		writer.Flush()
+
+		fmt.Println()
+		writer = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "MODEL\tQUERIES\tTOKENS\tCOST\tTOOL CALLS\tEDITS")
+		for _, model := range stats.ByModel {
+			fmt.Fprintf(writer, "%s\t%d\t%s\t%s\t%d\t%d\n", model.Key, model.Queries, formatStatsTokens(model.Totals), formatStatsCost(model.Totals), model.ToolCalls, model.Edits)
+		}
+		writer.Flush()
+	},
+}
+
+// formatStatsTokens token数，包含估计值时前加 ~
+func formatStatsTokens(totals session.Totals) string {
+	if totals.Estimated {
+		return fmt.Sprintf("~%d", totals.Tokens())
+	}
+	return fmt.Sprintf("%d", totals.Tokens())
+}
+
+// formatStatsCost 估计费用，有价格未知的查询时后加 +
+func formatStatsCost(totals session.Totals) string {
+	if totals.Cost == 0 && totals.Unpriced {
+		return "-"
+	}
+	cost := fmt.Sprintf("$%.4f", totals.Cost)
+	if totals.Unpriced {
+		cost += "+"
+	}
+	return cost
+}
+
+func init() {
+	statsCmd.Flags().IntVar(&statsDays, "days", 30, "Number of days to include, counting today (0 for all)")
+	statsCmd.Flags().BoolVar(&statsHere, "here", false, "Only include sessions started in the current directory")
+	statsCmd.Flags().BoolVar(&statsJSON, "json", false, "Print the statistics as JSON")
+	rootCmd.AddCommand(statsCmd)
+}
\ No newline at end of file
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"openCursor/internal/client"
+	"openCursor/internal/tools"
+)
+
+// lineInput 由一个goroutine逐行读取标准输入：有提示在等待输入（如命令确认）时交给该提示，
+// 否则交给 idle 处理（运行中的补充指令）
+type lineInput struct {
+	mu      sync.Mutex
+	waiting []chan lineResult // 等待输入的提示，按先后顺序
+	idle    func(line string)
+	err     error // 输入已结束
+}
+
+// lineResult 读取到的一行或读取错误
+type lineResult struct {
+	line string
+	err  error
+}
+
+// newLineInput 开始读取输入
+func newLineInput(r io.Reader, idle func(line string)) *lineInput {
+	input := &lineInput{idle: idle}
+	go input.loop(bufio.NewReader(r))
+	return input
+}
+
+// loop 读取并分发每一行
+func (l *lineInput) loop(reader *bufio.Reader) {
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			l.mu.Lock()
+			l.err = err
+			waiting := l.waiting
+			l.waiting = nil
+			l.mu.Unlock()
+			for _, ch := range waiting {
+				ch <- lineResult{err: err}
+			}
+			return
+		}
+
+		l.mu.Lock()
+		if len(l.waiting) > 0 {
+			ch := l.waiting[0]
+			l.waiting = l.waiting[1:]
+			l.mu.Unlock()
+			ch <- lineResult{line: line}
+			continue
+		}
+		idle := l.idle
+		l.mu.Unlock()
+		if idle != nil {
+			go idle(line)
+		}
+	}
+}
+
+// ReadLine 等待下一行输入
+func (l *lineInput) ReadLine() (string, error) {
+	l.mu.Lock()
+	if l.err != nil {
+		l.mu.Unlock()
+		return "", l.err
+	}
+	ch := make(chan lineResult, 1)
+	l.waiting = append(l.waiting, ch)
+	l.mu.Unlock()
+
+	result := <-ch
+	return result.line, result.err
+}
+
+// enableSteering 运行期间接受补充指令：输入指令并回车后在下一步加入对话；
+// 只按回车则暂停工具循环，输入指令（或直接回车）后继续；/cd <目录> 切换工作目录；
+// /checkpoint <名称> 与 /rollback <名称> 保存与恢复对话和改动过的文件。
+// 命令确认改为从同一输入读取。标准输入不是终端时不启用
+func enableSteering(aiClient *client.Client, shadow *tools.ShadowBranch) {
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return
+	}
+
+	checkpoints := newCheckpointStore(shadow)
+	tools.SetDefaultChangeObserver(checkpoints.observe)
+
+	var input *lineInput
+	input = newLineInput(os.Stdin, func(line string) {
+		instruction := strings.TrimSpace(line)
+		if handleCdCommand(aiClient, instruction) || handleCheckpointCommand(aiClient, checkpoints, instruction) {
+			return
+		}
+		if instruction == "" {
+			aiClient.Pause()
+			fmt.Fprint(os.Stderr, "\n✋ 已暂停，输入补充指令后回车继续（直接回车则不加指令继续）: ")
+			answer, err := input.ReadLine()
+			if err != nil {
+				aiClient.Resume()
+				return
+			}
+			instruction = strings.TrimSpace(answer)
+		}
+		if instruction != "" {
+			aiClient.Steer(instruction)
+			fmt.Fprintf(os.Stderr, "📥 已收到补充指令，将在当前步骤完成后加入\n")
+		}
+		aiClient.Resume()
+	})
+	tools.SetApprovalInput(input.ReadLine)
+	fmt.Fprintln(progress, "💡 运行期间可输入补充指令并回车来调整方向，只按回车则暂停，/cd <目录> 切换工作目录，/checkpoint 与 /rollback <名称> 保存与回滚")
+}
\ No newline at end of file
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"openCursor/internal/client"
+	"openCursor/internal/tools"
+)
+
+// subtaskPromptTemplate 子代理的指令，%s 为父代理给出的子任务
+const subtaskPromptTemplate = `You are a sub-agent working on one isolated subtask for another agent, which does not
+see this conversation and only receives your final answer.
+
+Subtask:
+%s
+
+Work only on this subtask. Your final answer is returned to the other agent as the result:
+make it a concise, self-contained summary of what you found (with file paths and line
+numbers) or of the changes you made, including anything left undone.`
+
+// subtasks 正在运行的子代理，父代理被中断时一并中断
+var subtasks = struct {
+	sync.Mutex
+	count   int
+	running map[*client.Client]bool
+}{running: make(map[*client.Client]bool)}
+
+// newSubtaskRunner 创建以独立对话运行子代理的 spawn_task 运行器。
+// 子代理的输出以 [子任务 N] 为前缀写到标准输出（--quiet 时为标准错误）
+func newSubtaskRunner(apiKey, baseURL, model string) tools.SubtaskRunner {
+	return func(request tools.SubtaskRequest) (*tools.SubtaskReport, error) {
+		child := client.NewClient(apiKey, baseURL, model)
+		child.SetToolManager(request.Manager)
+		child.SetMaxIterations(request.MaxIterations)
+		if request.Context != "" {
+			child.AddContext("context", request.Context)
+		}
+
+		subtasks.Lock()
+		subtasks.count++
+		number := subtasks.count
+		subtasks.running[child] = true
+		subtasks.Unlock()
+		defer func() {
+			subtasks.Lock()
+			delete(subtasks.running, child)
+			subtasks.Unlock()
+		}()
+
+		out := &prefixWriter{out: progress, prefix: fmt.Sprintf("  [子任务 %d] ", number)}
+		child.SetOutput(out)
+		fmt.Fprintf(out, "🧩 %s\n", firstLine(request.Task))
+		err := child.StreamQueryWithTools(fmt.Sprintf(subtaskPromptTemplate, request.Task))
+		out.Flush()
+
+		report := &tools.SubtaskReport{Summary: child.LastResponse()}
+		for _, step := range child.Steps() {
+			call := step.Tool
+			if step.Target != "" {
+				call += " " + step.Target
+			}
+			report.ToolCalls = append(report.ToolCalls, call+": "+step.Status)
+		}
+		if err == client.ErrInterrupted {
+			report.Summary = "The sub-agent was interrupted by the user before it finished."
+			return report, nil
+		}
+		return report, err
+	}
+}
+
+// interruptSubtasks 中断所有正在运行的子代理
+func interruptSubtasks() {
+	subtasks.Lock()
+	defer subtasks.Unlock()
+	for child := range subtasks.running {
+		child.Interrupt()
+	}
+}
+
+// firstLine 文本的第一行
+func firstLine(text string) string {
+	line, _, more := strings.Cut(strings.TrimSpace(text), "\n")
+	if more {
+		return line + " …"
+	}
+	return line
+}
+
+// outputMu 所有子代理共用，保证并行子代理的输出按整行交错
+var outputMu sync.Mutex
+
+// prefixWriter 为每一行输出加上前缀，凑满一行再写出
+type prefixWriter struct {
+	out    io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		outputMu.Lock()
+		_, err := fmt.Fprintf(w.out, "%s%s\n", w.prefix, w.buf[:i])
+		outputMu.Unlock()
+		if err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush 写出最后不完整的一行
+func (w *prefixWriter) Flush() {
+	if len(w.buf) == 0 {
+		return
+	}
+	outputMu.Lock()
+	fmt.Fprintf(w.out, "%s%s\n", w.prefix, w.buf)
+	outputMu.Unlock()
+	w.buf = nil
+}
\ No newline at end of file
@@ -0,0 +1,341 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"openCursor/internal/client"
+	"openCursor/internal/config"
+	"openCursor/internal/session"
+	"openCursor/internal/tools"
+	"openCursor/internal/watch"
+	"openCursor/internal/worktree"
+
+	"github.com/spf13/cobra"
+)
+
+// watch 命令参数
+var (
+	watchOnChange      string        // --on-change 文件变化时执行的任务
+	watchPatterns      []string      // --pattern 只在匹配的文件变化时运行
+	watchDebounce      time.Duration // --debounce 变化停止多久后运行
+	watchMaxIterations int           // --max-iterations 每次运行允许的最多模型调用轮数
+	watchMaxCost       float64       // --max-cost 每次运行的估计费用上限（美元）
+)
+
+// watchPromptTemplate 文件变化触发的任务，%s 依次为变化的文件与任务
+const watchPromptTemplate = `The following files in the workspace were just changed by the user:
+%s
+%s
+
+This run was triggered automatically by the change. Keep it focused: only act on what the
+change affects, and finish with a short summary of what you did.`
+
+// maxListedChanges 提示与输出中最多列出的变化文件数
+const maxListedChanges = 30
+
+// watchCmd 监听工作区，文件变化时运行任务
+var watchCmd = &cobra.Command{
+	Use:   "watch --on-change <task>",
+	Short: "Watch the workspace and run a task whenever files change",
+	Long: `Watch the workspace and start a bounded agent run with the --on-change task
+whenever watched files change, e.g. to keep the tests green while you edit.
+
+Files ignored by .gitignore or .opencursorignore, hidden files and directories
+like node_modules are never watched; --pattern narrows the watch further (glob,
+repeatable; without a / it matches the file name, ** matches any directories).
+
+Changes are debounced: a run starts once no file has changed for --debounce.
+Only one run happens at a time. Changes made while a run is in progress are
+collected and handled by a single follow-up run. Changes made by the agent
+itself do not trigger new runs.
+
+Each run starts a new conversation that is told which files changed, is limited
+to --max-iterations model calls (and --max-cost, if set) and is saved as a
+session. Ctrl+C stops the current run after its current step; Ctrl+C while idle
+(or twice during a run) exits.
+
+Examples:
+  openCursor watch --on-change "Run the tests and fix failures"
+  openCursor watch --on-change "Update the API docs for changed handlers" --pattern "internal/api/**/*.go"
+  openCursor watch --on-change "Fix lint errors in the changed files" --pattern "*.ts" --pattern "*.tsx" --debounce 5s`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if strings.TrimSpace(watchOnChange) == "" {
+			fmt.Fprintf(os.Stderr, "Error: --on-change is required\n")
+			os.Exit(1)
+		}
+		var matchers []func(string) bool
+		for _, pattern := range watchPatterns {
+			matcher, err := tools.GlobMatcher(pattern)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			matchers = append(matchers, matcher)
+		}
+
+		apiKey, baseURL, model := loadAPISettings()
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		configureEmbeddings(cfg, apiKey, baseURL)
+
+		workDir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to get current directory: %v\n", err)
+			os.Exit(1)
+		}
+		tools.SetSubtaskRunner(newSubtaskRunner(apiKey, baseURL, model))
+		if err := setupAgentTools(cfg, baseURL, agentToolSetup{register: tools.RegisterDefaultTools, workDir: workDir}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer tools.CloseCodeIndexes()
+		defer tools.ShutdownLanguageServers()
+
+		pricing, ok := cfg.Pricing[model]
+		if !ok {
+			pricing, ok = client.DefaultPricing(model)
+		}
+		if watchMaxCost > 0 && !ok {
+			fmt.Fprintf(os.Stderr, "Error: --max-cost needs the price of model %q, set it under pricing: in the config file\n", model)
+			os.Exit(1)
+		}
+
+		opts := watch.Options{Debounce: watchDebounce}
+		if len(matchers) > 0 {
+			opts.Match = func(rel string) bool {
+				for _, match := range matchers {
+					if match(rel) {
+						return true
+					}
+				}
+				return false
+			}
+		}
+		watcher, err := watch.New(workDir, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to watch %s: %v\n", workDir, err)
+			os.Exit(1)
+		}
+		defer watcher.Close()
+
+		runner := &watchRunner{
+			workDir: workDir,
+			task:    watchOnChange,
+			newClient: func() *client.Client {
+				aiClient := client.NewClient(apiKey, baseURL, model)
+				aiClient.SetToolManager(tools.GetDefaultManager())
+				aiClient.SetContextWindow(cfg.ContextWindow)
+				aiClient.SetMaxIterations(watchMaxIterations)
+				aiClient.SetBudget(client.Budget{MaxCost: watchMaxCost, Pricing: pricing})
+				return aiClient
+			},
+			model: model,
+			known: make(map[string]string),
+		}
+
+		interrupts := make(chan os.Signal, 1)
+		signal.Notify(interrupts, os.Interrupt)
+		defer signal.Stop(interrupts)
+
+		fmt.Printf("👀 正在监听 %s，文件变化时运行: %s（Ctrl+C 退出）\n", workDir, firstLine(watchOnChange))
+		queued := make(map[string]bool) // 运行期间的变化，本次运行结束后处理
+		finished := make(chan []string) // 运行结束，送出本次运行修改的文件
+		running := false
+		for {
+			select {
+			case changed := <-watcher.Changes():
+				changed = runner.filterOwnChanges(changed)
+				if len(changed) == 0 {
+					continue
+				}
+				if running {
+					for _, rel := range changed {
+						queued[rel] = true
+					}
+					fmt.Printf("\n⏳ %d 个文件发生变化，将在本次运行结束后处理\n", len(changed))
+					continue
+				}
+				running = true
+				go runner.run(changed, finished)
+			case touched := <-finished:
+				running = false
+				runner.remember(touched)
+				var changed []string
+				for rel := range queued {
+					changed = append(changed, rel)
+				}
+				queued = make(map[string]bool)
+				if changed = runner.filterOwnChanges(changed); len(changed) > 0 {
+					running = true
+					go runner.run(changed, finished)
+				} else {
+					fmt.Printf("\n👀 继续监听文件变化...\n")
+				}
+			case err := <-watcher.Errors():
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			case <-interrupts:
+				if !running {
+					fmt.Println()
+					return
+				}
+				if !runner.interrupt() {
+					fmt.Fprintf(os.Stderr, "\nAborted\n")
+					os.Exit(130)
+				}
+				interruptSubtasks()
+				fmt.Fprintf(os.Stderr, "\n⏸  收到中断，将在当前步骤完成后停止本次运行（再次按 Ctrl+C 立即退出）\n")
+			}
+		}
+	},
+}
+
+// watchRunner 依次执行文件变化触发的运行
+type watchRunner struct {
+	workDir   string
+	task      string
+	model     string
+	newClient func() *client.Client
+	runs      int
+
+	mu      sync.Mutex
+	current *client.Client    // 正在进行的运行
+	known   map[string]string // 运行修改的文件及修改后的内容哈希，内容不变时的事件不再触发运行
+}
+
+// run 执行一次运行，结束后将运行修改的文件送到 finished
+func (r *watchRunner) run(changed []string, finished chan<- []string) {
+	r.runs++
+	fmt.Printf("\n🔁 第 %d 次运行，%d 个文件发生变化: %s\n", r.runs, len(changed), summarizePaths(changed))
+
+	var mu sync.Mutex
+	var touched []string
+	tools.SetDefaultChangeObserver(func(change tools.FileChange) {
+		if !filepath.IsAbs(change.Path) {
+			mu.Lock()
+			touched = append(touched, change.Path)
+			mu.Unlock()
+		}
+	})
+	defer tools.SetDefaultChangeObserver(nil)
+	baseline := worktree.Capture(r.workDir)
+
+	aiClient := r.newClient()
+	r.mu.Lock()
+	r.current = aiClient
+	r.mu.Unlock()
+
+	listed := changed
+	if len(listed) > maxListedChanges {
+		listed = listed[:maxListedChanges]
+	}
+	list := "- " + strings.Join(listed, "\n- ")
+	if len(changed) > len(listed) {
+		list += fmt.Sprintf("\n- ... and %d more", len(changed)-len(listed))
+	}
+	query := fmt.Sprintf(watchPromptTemplate, list, r.task)
+
+	startedAt := time.Now()
+	err := aiClient.StreamQueryWithTools(query)
+	switch {
+	case err == nil:
+		fmt.Printf("\n✅ 第 %d 次运行完成（%s），%s\n", r.runs, time.Since(startedAt).Round(time.Second), aiClient.UsageSummary())
+	case errors.Is(err, client.ErrInterrupted):
+		fmt.Printf("\n⏸  第 %d 次运行已中断\n", r.runs)
+	default:
+		fmt.Fprintf(os.Stderr, "Warning: run %d failed: %v\n", r.runs, err)
+	}
+
+	if len(aiClient.Messages()) > 0 {
+		sess := session.New(r.workDir, r.model, query)
+		sess.AddTurn(newSessionTurn(aiClient, query, r.model, startedAt))
+		recordSessionEdits(sess)
+		sess.SetMessages(aiClient.Messages())
+		sess.Interrupted = err != nil
+		if err := sess.Save(config.SessionsDir()); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to save session: %v\n", err)
+		}
+	}
+
+	r.mu.Lock()
+	r.current = nil
+	r.mu.Unlock()
+
+	// 命令（如格式化工具）做的修改只能通过 git 发现
+	mu.Lock()
+	touched = append(touched, baseline.Changes().Paths()...)
+	mu.Unlock()
+	finished <- touched
+}
+
+// interrupt 中断正在进行的运行，已经请求过中断时返回false
+func (r *watchRunner) interrupt() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.current == nil {
+		return true
+	}
+	return r.current.Interrupt()
+}
+
+// remember 记录运行修改的文件的当前内容
+func (r *watchRunner) remember(paths []string) {
+	for _, rel := range paths {
+		r.known[rel] = fileHash(filepath.Join(r.workDir, filepath.FromSlash(rel)))
+	}
+}
+
+// filterOwnChanges 去掉内容与运行修改后相同的文件（即运行自身的修改）
+func (r *watchRunner) filterOwnChanges(changed []string) []string {
+	var result []string
+	for _, rel := range changed {
+		if hash, ok := r.known[rel]; ok {
+			if fileHash(filepath.Join(r.workDir, filepath.FromSlash(rel))) == hash {
+				continue
+			}
+			delete(r.known, rel)
+		}
+		result = append(result, rel)
+	}
+	return result
+}
+
+// fileHash 文件内容的哈希，文件不存在时为空字符串
+func fileHash(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// summarizePaths 用于输出的文件列表，过长时省略
+func summarizePaths(paths []string) string {
+	const maxShown = 5
+	if len(paths) <= maxShown {
+		return strings.Join(paths, ", ")
+	}
+	return fmt.Sprintf("%s 等 %d 个", strings.Join(paths[:maxShown], ", "), len(paths))
+}
+
+func init() {
+	watchCmd.Flags().StringVar(&watchOnChange, "on-change", "", "Task to run whenever watched files change (required)")
+	watchCmd.Flags().StringArrayVar(&watchPatterns, "pattern", nil, "Only react to files matching this glob (repeatable, default: all files that are not ignored)")
+	watchCmd.Flags().DurationVar(&watchDebounce, "debounce", 2*time.Second, "Wait until no file has changed for this long before starting a run")
+	watchCmd.Flags().IntVar(&watchMaxIterations, "max-iterations", 20, "Model calls allowed per run, including tool calls")
+	watchCmd.Flags().Float64Var(&watchMaxCost, "max-cost", 0, "Stop a run once its estimated cost reaches this many US dollars")
+	rootCmd.AddCommand(watchCmd)
+}
\ No newline at end of file
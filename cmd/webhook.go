@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"openCursor/internal/client"
+	"openCursor/internal/config"
+	"openCursor/internal/session"
+	"openCursor/internal/webhook"
+	"openCursor/internal/worktree"
+
+	"golang.org/x/term"
+)
+
+// runWebhook 运行结束时发送摘要的 webhook
+type runWebhook struct {
+	cfg       webhook.Config
+	baseline  *worktree.Baseline
+	startedAt time.Time
+}
+
+// newRunWebhook 记录运行前的工作区状态。只在非交互运行（标准输入不是终端，如定时任务、daemon 或 CI）时
+// 发送配置文件中的 webhook，--webhook 指定的地址总是发送；都没有时返回nil
+func newRunWebhook(cfg *config.Config, workDir string) *runWebhook {
+	hook := cfg.Webhook
+	if webhookURL != "" {
+		hook.URL = webhookURL
+	} else if hook.URL == "" || term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil
+	}
+	return &runWebhook{cfg: hook, baseline: worktree.Capture(workDir), startedAt: time.Now()}
+}
+
+// send 发送运行摘要，失败时只打印警告
+func (w *runWebhook) send(aiClient *client.Client, sess *session.Session, query, model string, runErr error) {
+	if w == nil {
+		return
+	}
+	turn := newSessionTurn(aiClient, query, model, w.startedAt)
+	payload := &webhook.Payload{
+		Event:            webhook.EventRunFinished,
+		SessionID:        sess.ID,
+		Query:            query,
+		WorkDir:          sess.WorkDir,
+		Model:            model,
+		Job:              os.Getenv("OPENCURSOR_SCHEDULE_JOB"),
+		Success:          runErr == nil,
+		Status:           webhook.StatusCompleted,
+		StartedAt:        w.startedAt,
+		FinishedAt:       time.Now(),
+		ToolCalls:        turn.ToolCalls,
+		Edits:            turn.Edits,
+		PromptTokens:     turn.PromptTokens,
+		CompletionTokens: turn.CompletionTokens,
+		Cost:             turn.Cost,
+	}
+	payload.DurationSeconds = payload.FinishedAt.Sub(payload.StartedAt).Seconds()
+	changes := w.baseline.Changes()
+	payload.FilesChanged = changes.Paths()
+	payload.DiffStats = webhook.DiffStats{Files: len(changes.Files), Insertions: changes.Insertions, Deletions: changes.Deletions}
+	if runErr != nil {
+		payload.Error = runErr.Error()
+		switch {
+		case errors.Is(runErr, client.ErrInterrupted):
+			payload.Status = webhook.StatusInterrupted
+		case errors.Is(runErr, client.ErrBudgetExceeded):
+			payload.Status = webhook.StatusBudgetExceeded
+		default:
+			payload.Status = webhook.StatusFailed
+		}
+	}
+	if err := webhook.Send(context.Background(), w.cfg, payload); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
\ No newline at end of file
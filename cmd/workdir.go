@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"openCursor/internal/client"
+	"openCursor/internal/tools"
+
+	"github.com/spf13/cobra"
+)
+
+// workDirFlag --workdir 指定的工作目录，为空时使用当前目录
+var workDirFlag string
+
+// cdCommand 运行期间切换工作目录的输入命令
+const cdCommand = "/cd"
+
+// enterWorkDir 切换到 --workdir 指定的目录，之后的命令（配置、会话、工具）都以它为当前目录
+func enterWorkDir(cmd *cobra.Command, args []string) {
+	if workDirFlag == "" {
+		return
+	}
+	if _, err := changeDirectory(workDirFlag); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// changeDirectory 切换进程的当前目录，返回切换后的绝对路径
+func changeDirectory(dir string) (string, error) {
+	if strings.HasPrefix(dir, "~") {
+		if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, strings.TrimPrefix(dir, "~"))
+		}
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("invalid working directory %q: %w", dir, err)
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return "", fmt.Errorf("invalid working directory: %w", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("invalid working directory: %s is not a directory", abs)
+	}
+	if err := os.Chdir(abs); err != nil {
+		return "", fmt.Errorf("failed to change to %s: %w", abs, err)
+	}
+	return abs, nil
+}
+
+// resolveWorkspaces 合并配置文件中的工作区与 --workspace NAME=PATH 参数（参数优先）
+func resolveWorkspaces(base map[string]string, overrides []string, workDir string) ([]tools.Workspace, error) {
+	roots := make(map[string]string, len(base)+len(overrides))
+	for name, path := range base {
+		roots[name] = path
+	}
+	for _, override := range overrides {
+		name, path, ok := strings.Cut(override, "=")
+		if !ok || name == "" || path == "" {
+			return nil, fmt.Errorf("invalid --workspace value %q, expected NAME=PATH", override)
+		}
+		roots[name] = path
+	}
+	return tools.ResolveWorkspaces(roots, workDir)
+}
+
+// handleCdCommand 处理运行期间输入的 /cd <目录>：切换工具的工作目录并告知模型。
+// 不是 /cd 命令时返回 false
+func handleCdCommand(aiClient *client.Client, line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || fields[0] != cdCommand {
+		return false
+	}
+	if len(fields) == 1 {
+		dir, _ := os.Getwd()
+		fmt.Fprintf(os.Stderr, "📂 当前工作目录: %s\n", dir)
+		return true
+	}
+	dir, err := changeDirectory(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), cdCommand)))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return true
+	}
+	tools.SetDefaultWorkDirectory(dir)
+	aiClient.Steer(fmt.Sprintf("The working directory has been changed to %s. Relative paths and commands now refer to this directory.", dir))
+	fmt.Fprintf(os.Stderr, "📂 工作目录已切换到 %s，将在当前步骤完成后告知模型\n", dir)
+	return true
+}
@@ -0,0 +1,465 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"openCursor/internal/client"
+	"openCursor/internal/config"
+	"openCursor/internal/tools"
+	"openCursor/internal/workflow"
+
+	"github.com/spf13/cobra"
+)
+
+// workflow 命令参数
+var (
+	workflowVars          []string // --var 覆盖工作流变量
+	workflowResume        string   // --resume 继续之前的运行
+	workflowFrom          string   // --from 从指定步骤重新运行
+	workflowNoCheckpoints bool     // --no-checkpoints 不提交步骤检查点
+)
+
+// workflowCmd 工作流命令组
+var workflowCmd = &cobra.Command{
+	Use:   "workflow",
+	Short: "Run multi-step agent workflows defined in YAML",
+	Long: `Run a sequence of agent steps defined in a YAML file. Each step is a prompt template
+run as its own agent conversation, optionally limited to the tools it needs, and may
+declare success criteria that are verified independently before the next step starts.
+
+Workflow file (release-prep.yaml):
+  name: release-prep
+  description: Prepare a release
+  vars:
+    version: 1.0.0
+  steps:
+    - name: changelog
+      prompt: Update CHANGELOG.md with the changes since the last tag for {{version}}.
+      tools: [read_file, grep_search, git_log, search_replace, write_file]
+    - name: fix-tests
+      prompt: Make sure the whole test suite passes, fixing any failures.
+      max_iterations: 30      # model calls per attempt (default 20)
+      attempts: 3             # rounds when a criterion fails (default 1)
+      success:
+        - tests pass          # same as {tests: .}
+        - command: go vet ./...
+        - file_exists: CHANGELOG.md
+
+Every step gets the final answers of the previous steps as context. After a step
+succeeds the working tree is committed as a checkpoint to the opencursor/workflow-<id>
+branch (without touching the current branch or index), and the run is recorded in
+~/.opencursor/workflows/<id>.json. A failed or interrupted run continues with
+--resume <id>; --from <step> runs again from that step.`,
+}
+
+// workflowRunCmd 运行工作流
+var workflowRunCmd = &cobra.Command{
+	Use:   "run <file.yaml>",
+	Short: "Run a workflow",
+	Long: `Run the steps of a workflow file in order.
+
+Examples:
+  openCursor workflow run release-prep.yaml
+  openCursor workflow run release-prep.yaml --var version=2.3.0
+  openCursor workflow run release-prep.yaml --resume 20240131-101500-a1b2c3
+  openCursor workflow run release-prep.yaml --resume 20240131-101500-a1b2c3 --from fix-tests`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		file, err := filepath.Abs(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		wf, err := workflow.Load(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		apiKey, baseURL, model := loadAPISettings()
+
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		configureEmbeddings(cfg, apiKey, baseURL)
+		workDir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to get current directory: %v\n", err)
+			os.Exit(1)
+		}
+
+		// 新的运行，或继续之前的运行（变量沿用上次的值，--var 仍可覆盖）
+		var run *workflow.Run
+		if workflowResume != "" {
+			run, err = workflow.LoadRun(config.WorkflowsDir(), workflowResume)
+			if err == nil && run.WorkDir != workDir {
+				fmt.Fprintf(os.Stderr, "Warning: workflow run %s was started in %s\n", run.ID, run.WorkDir)
+			}
+			if err == nil {
+				run.Sync(wf)
+				if wf.Vars == nil {
+					wf.Vars = make(map[string]string)
+				}
+				for key, value := range run.Vars {
+					wf.Vars[key] = value
+				}
+			}
+		} else if workflowFrom != "" {
+			err = fmt.Errorf("--from requires --resume")
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		vars, err := wf.MergeVars(workflowVars)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if run == nil {
+			run = workflow.NewRun(wf, file, workDir, vars)
+		}
+		run.Vars = vars
+
+		start := run.NextStep()
+		if workflowFrom != "" {
+			index, ok := wf.Index(workflowFrom)
+			if !ok {
+				fmt.Fprintf(os.Stderr, "Error: unknown step %q\n", workflowFrom)
+				os.Exit(1)
+			}
+			for i := index; i < len(run.Steps); i++ {
+				run.Steps[i] = workflow.StepRun{Name: run.Steps[i].Name, Status: workflow.StatusPending}
+			}
+			start = index
+		}
+
+		// 展开全部提示词，变量缺失时在运行前报错
+		prompts := make([]string, len(wf.Steps))
+		for i, step := range wf.Steps {
+			if prompts[i], err = step.Render(vars); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		tools.SetSubtaskRunner(newSubtaskRunner(apiKey, baseURL, model))
+		err = setupAgentTools(cfg, baseURL, agentToolSetup{
+			// 成功条件使用默认注册器中的工具，与步骤可用的工具无关
+			register: func() error {
+				return tools.DefaultRegistry.RegisterTools([]string{"run_tests", "run_terminal_cmd"})
+			},
+			workDir: workDir,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer tools.ShutdownLanguageServers()
+
+		// 每个步骤完成后提交检查点
+		var shadow *tools.ShadowBranch
+		if !workflowNoCheckpoints {
+			shadow, err = tools.NewShadowBranch(workDir, "workflow-"+run.ID)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: Checkpoints disabled: %v\n", err)
+			} else {
+				defer shadow.Close()
+				run.Branch = shadow.Branch()
+			}
+		}
+		if err := run.Save(config.WorkflowsDir()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		// Ctrl+C 中断当前步骤，运行记录保存后可以继续
+		var current struct {
+			sync.Mutex
+			client *client.Client
+		}
+		interrupts := make(chan os.Signal, 1)
+		signal.Notify(interrupts, os.Interrupt)
+		go func() {
+			for range interrupts {
+				current.Lock()
+				aiClient := current.client
+				current.Unlock()
+				if aiClient == nil || !aiClient.Interrupt() {
+					fmt.Fprintf(os.Stderr, "\nAborted\n")
+					os.Exit(130)
+				}
+				interruptSubtasks()
+				fmt.Fprintf(os.Stderr, "\n⏸  收到中断，将在当前步骤完成后停止（再次按 Ctrl+C 立即退出）\n")
+			}
+		}()
+		defer signal.Stop(interrupts)
+
+		fmt.Printf("🗂  工作流 %s（运行 %s，%d 个步骤）\n", wf.Name, run.ID, len(wf.Steps))
+		for i := start; i < len(wf.Steps); i++ {
+			step := wf.Steps[i]
+			if run.Steps[i].Status == workflow.StatusDone {
+				continue
+			}
+			fmt.Printf("\n▶ 步骤 %d/%d: %s\n\n", i+1, len(wf.Steps), step.Name)
+
+			aiClient, err := newWorkflowClient(apiKey, baseURL, model, wf, run, step, workDir, cfg.Env)
+			if err == nil {
+				current.Lock()
+				current.client = aiClient
+				current.Unlock()
+				err = runWorkflowStep(aiClient, step, prompts[i], workDir, &run.Steps[i])
+			}
+
+			result := &run.Steps[i]
+			result.FinishedAt = time.Now()
+			if err != nil {
+				result.Status = workflow.StatusFailed
+				result.Error = err.Error()
+				if errors.Is(err, client.ErrInterrupted) {
+					result.Error = "interrupted"
+				}
+				if saveErr := run.Save(config.WorkflowsDir()); saveErr != nil {
+					fmt.Fprintf(os.Stderr, "Warning: Failed to save workflow run: %v\n", saveErr)
+				}
+				fmt.Fprintf(os.Stderr, "\nError: step %s failed: %v\n", step.Name, err)
+				fmt.Fprintf(os.Stderr, "Continue with: openCursor workflow run %s --resume %s\n", args[0], run.ID)
+				if shadow != nil {
+					shadow.Close()
+				}
+				tools.ShutdownLanguageServers()
+				if errors.Is(err, client.ErrInterrupted) {
+					os.Exit(130)
+				}
+				os.Exit(1)
+			}
+
+			result.Status = workflow.StatusDone
+			result.Error = ""
+			if shadow != nil {
+				checkpoint, err := shadow.Checkpoint(fmt.Sprintf("Workflow %s: step %d/%d %s", wf.Name, i+1, len(wf.Steps), step.Name))
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: Failed to commit checkpoint: %v\n", err)
+				} else {
+					result.Checkpoint = checkpoint
+					fmt.Printf("\n📌 检查点 %s: %s\n", shortHash(checkpoint), step.Name)
+				}
+			}
+			if err := run.Save(config.WorkflowsDir()); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: Failed to save workflow run: %v\n", err)
+			}
+			fmt.Printf("✅ 步骤完成: %s\n", step.Name)
+		}
+
+		fmt.Printf("\n🏁 工作流 %s 已完成（%d 个步骤）\n", wf.Name, len(wf.Steps))
+		if run.Branch != "" {
+			fmt.Printf("检查点: git log --oneline %s，回退到某一步: git restore --source <checkpoint> -- .\n", run.Branch)
+		}
+	},
+}
+
+// workflowValidateCmd 检查工作流文件
+var workflowValidateCmd = &cobra.Command{
+	Use:   "validate <file.yaml>",
+	Short: "Check a workflow file and list its steps",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		wf, err := workflow.Load(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		vars, err := wf.MergeVars(workflowVars)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		registry := tools.NewRegistry()
+		if err := registry.RegisterAllTools(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		failed := false
+		fmt.Printf("%s: %d step(s)\n", wf.Name, len(wf.Steps))
+		for i, step := range wf.Steps {
+			fmt.Printf("\n%d. %s\n", i+1, step.Name)
+			if _, err := step.Render(vars); err != nil {
+				fmt.Printf("   error: %v\n", err)
+				failed = true
+			}
+			if len(step.Tools) > 0 {
+				fmt.Printf("   tools: %s\n", strings.Join(step.Tools, ", "))
+				for _, name := range step.Tools {
+					if _, ok := registry.GetManager().GetTool(name); !ok {
+						fmt.Printf("   error: tool %q is not available\n", name)
+						failed = true
+					}
+				}
+			}
+			for _, check := range step.Success {
+				fmt.Printf("   success: %s\n", check)
+			}
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+// workflowStepContext 提供给步骤的工作流说明与之前步骤的结果
+func workflowStepContext(wf *workflow.Workflow, run *workflow.Run, index int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "You are running step %d of %d (%s) of the workflow %q.", index+1, len(wf.Steps), wf.Steps[index].Name, wf.Name)
+	if wf.Description != "" {
+		fmt.Fprintf(&sb, " Workflow goal: %s", wf.Description)
+	}
+	sb.WriteString("\nOnly do the work of this step; later steps take care of the rest.\n")
+	for i := 0; i < index; i++ {
+		if summary := strings.TrimSpace(run.Steps[i].Summary); summary != "" {
+			fmt.Fprintf(&sb, "\nResult of step %d (%s):\n%s\n", i+1, wf.Steps[i].Name, summary)
+		}
+	}
+	return sb.String()
+}
+
+// newWorkflowClient 为步骤创建客户端，工具限定为步骤声明的工具
+func newWorkflowClient(apiKey, baseURL, model string, wf *workflow.Workflow, run *workflow.Run, step workflow.Step, workDir string, env map[string]string) (*client.Client, error) {
+	registry := tools.NewRegistry()
+	var err error
+	if len(step.Tools) > 0 {
+		err = registry.RegisterTools(step.Tools)
+	} else {
+		err = registry.RegisterAllTools()
+	}
+	if err != nil {
+		return nil, err
+	}
+	registry.SetWorkDirectory(workDir)
+	registry.SetEnvironment(env)
+
+	index, _ := wf.Index(step.Name)
+	aiClient := client.NewClient(apiKey, baseURL, model)
+	aiClient.SetToolManager(registry.GetManager())
+	maxIterations := step.MaxIterations
+	if maxIterations == 0 {
+		maxIterations = workflow.DefaultMaxIterations
+	}
+	aiClient.SetMaxIterations(maxIterations)
+	aiClient.AddContext("workflow", workflowStepContext(wf, run, index))
+	return aiClient, nil
+}
+
+// runWorkflowStep 运行步骤直到成功条件全部满足，未满足时将失败原因交回模型，最多 attempts 轮
+func runWorkflowStep(aiClient *client.Client, step workflow.Step, prompt, workDir string, result *workflow.StepRun) error {
+	attempts := step.Attempts
+	if attempts == 0 {
+		attempts = 1
+	}
+	query := prompt
+	for attempt := 1; ; attempt++ {
+		result.Attempts = attempt
+		if err := aiClient.StreamQueryWithTools(query); err != nil {
+			return err
+		}
+		result.Summary = aiClient.LastResponse()
+
+		var failures []string
+		for _, check := range step.Success {
+			fmt.Printf("\n🔎 检查: %s\n", check)
+			ok, details, err := runWorkflowCheck(check, workDir)
+			if err != nil {
+				return err
+			}
+			if ok {
+				fmt.Printf("✅ 满足\n")
+				continue
+			}
+			fmt.Printf("❌ 未满足\n")
+			failures = append(failures, fmt.Sprintf("- %s\n%s", check, details))
+		}
+		if len(failures) == 0 {
+			return nil
+		}
+		if attempt >= attempts {
+			return fmt.Errorf("success criteria not met after %d attempt(s): %d failed", attempts, len(failures))
+		}
+
+		aiClient.SetHistory(aiClient.Messages())
+		query = "The step is not complete: these success criteria are not met when checked independently. Fix the problems.\n\n" + strings.Join(failures, "\n\n")
+	}
+}
+
+// runWorkflowCheck 独立检查一个成功条件，返回是否满足与未满足时的详情
+func runWorkflowCheck(check workflow.Check, workDir string) (bool, string, error) {
+	switch {
+	case check.Tests != "":
+		result, err := verifyTests(check.Tests, "")
+		if err != nil {
+			return false, "", err
+		}
+		return result.Passed, fmt.Sprintf("`%s` exited with code %d:\n%s", result.Command, result.ExitCode, result.Output), nil
+
+	case check.Command != "":
+		toolResult, err := tools.GetDefaultManager().ExecuteTool("run_terminal_cmd", map[string]interface{}{
+			"command":     check.Command,
+			"explanation": "Workflow success criterion",
+		})
+		if err != nil {
+			return false, "", err
+		}
+		if !toolResult.Success {
+			return false, "", fmt.Errorf("failed to run %q: %s", check.Command, toolResult.Error)
+		}
+		result, ok := toolResult.Result.(*tools.RunTerminalCmdResult)
+		if !ok {
+			return false, "", fmt.Errorf("unexpected result from run_terminal_cmd")
+		}
+		output := result.Output
+		if len(output) > 20000 {
+			output = "..." + output[len(output)-20000:]
+		}
+		return result.ExitCode == 0, fmt.Sprintf("`%s` exited with code %d:\n%s", result.Command, result.ExitCode, output), nil
+
+	default:
+		path := check.FileExists
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(workDir, path)
+		}
+		if _, err := os.Stat(path); err != nil {
+			return false, fmt.Sprintf("%s does not exist", check.FileExists), nil
+		}
+		return true, "", nil
+	}
+}
+
+// shortHash 提交的简短形式
+func shortHash(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
+
+func init() {
+	workflowCmd.PersistentFlags().StringArrayVar(&workflowVars, "var", nil, "Workflow variable NAME=VALUE (repeatable)")
+	workflowRunCmd.Flags().StringVar(&workflowResume, "resume", "", "Continue a failed or interrupted run, skipping the completed steps")
+	workflowRunCmd.Flags().StringVar(&workflowFrom, "from", "", "With --resume, run again from this step")
+	workflowRunCmd.Flags().BoolVar(&workflowNoCheckpoints, "no-checkpoints", false, "Do not commit a checkpoint after each step")
+	workflowRunCmd.RegisterFlagCompletionFunc("resume", completeWorkflowRuns)
+	workflowRunCmd.RegisterFlagCompletionFunc("from", completeWorkflowSteps)
+	workflowRunCmd.ValidArgsFunction = completeWorkflowFile
+	workflowValidateCmd.ValidArgsFunction = completeWorkflowFile
+	workflowCmd.AddCommand(workflowRunCmd)
+	workflowCmd.AddCommand(workflowValidateCmd)
+	rootCmd.AddCommand(workflowCmd)
+}
\ No newline at end of file
@@ -0,0 +1,109 @@
+package apischema
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 支持的schema格式
+const (
+	FormatOpenAPI = "openapi"
+	FormatProto   = "proto"
+)
+
+// 变更级别
+const (
+	SeverityBreaking    = "breaking"
+	SeverityNonBreaking = "non-breaking"
+)
+
+// Change 两个schema版本之间的一处变更
+type Change struct {
+	Severity string `json:"severity"`
+	Location string `json:"location"`
+	Message  string `json:"message"`
+}
+
+func breaking(location, message string) Change {
+	return Change{Severity: SeverityBreaking, Location: location, Message: message}
+}
+
+func nonBreaking(location, message string) Change {
+	return Change{Severity: SeverityNonBreaking, Location: location, Message: message}
+}
+
+// DetectFormat 根据文件名与内容识别schema格式
+func DetectFormat(path string, content []byte) (string, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".proto" {
+		return FormatProto, nil
+	}
+
+	if ext == ".yaml" || ext == ".yml" || ext == ".json" {
+		var doc map[string]interface{}
+		if err := yaml.Unmarshal(content, &doc); err != nil {
+			return "", fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		if _, ok := doc["openapi"]; ok {
+			return FormatOpenAPI, nil
+		}
+		if _, ok := doc["swagger"]; ok {
+			return FormatOpenAPI, nil
+		}
+	}
+
+	return "", fmt.Errorf("%s is not an OpenAPI/Swagger document or .proto file", path)
+}
+
+// Diff 比较同一schema文件的两个版本
+func Diff(format string, oldContent, newContent []byte) ([]Change, error) {
+	switch format {
+	case FormatProto:
+		oldProto, err := ParseProto(string(oldContent))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse old version: %w", err)
+		}
+		newProto, err := ParseProto(string(newContent))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse new version: %w", err)
+		}
+		return DiffProto(oldProto, newProto), nil
+
+	case FormatOpenAPI:
+		oldSpec, err := ParseOpenAPI(oldContent)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse old version: %w", err)
+		}
+		newSpec, err := ParseOpenAPI(newContent)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse new version: %w", err)
+		}
+		return DiffOpenAPI(oldSpec, newSpec), nil
+	}
+
+	return nil, fmt.Errorf("unsupported schema format: %s", format)
+}
+
+// sortedKeys 返回排序后的map键
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedInts 返回排序后的整数键
+func sortedInts[V any](m map[int]V) []int {
+	keys := make([]int, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	return keys
+}
\ No newline at end of file
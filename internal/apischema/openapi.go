@@ -0,0 +1,427 @@
+package apischema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// httpMethods OpenAPI中的操作方法
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// OpenAPI 解析后的OpenAPI/Swagger文档
+type OpenAPI struct {
+	Version    string                `json:"version"`
+	Title      string                `json:"title,omitempty"`
+	Operations map[string]*Operation `json:"operations"` // 键为 "GET /pets/{id}"
+	Schemas    map[string]*Schema    `json:"schemas"`
+}
+
+// Operation 单个API操作
+type Operation struct {
+	Method              string                `json:"method"`
+	Path                string                `json:"path"`
+	OperationID         string                `json:"operation_id,omitempty"`
+	Summary             string                `json:"summary,omitempty"`
+	Parameters          map[string]*Parameter `json:"parameters"` // 键为 "in:name"
+	HasRequestBody      bool                  `json:"has_request_body,omitempty"`
+	RequestBodyRequired bool                  `json:"request_body_required,omitempty"`
+	RequestSchema       string                `json:"request_schema,omitempty"`
+	Responses           map[string]string     `json:"responses"` // 状态码 -> schema类型
+}
+
+// Parameter 操作参数
+type Parameter struct {
+	Name     string `json:"name"`
+	In       string `json:"in"`
+	Required bool   `json:"required"`
+	Type     string `json:"type,omitempty"`
+}
+
+// Schema 组件schema（只保留兼容性相关的信息）
+type Schema struct {
+	Name          string              `json:"name"`
+	Type          string              `json:"type,omitempty"`
+	Properties    map[string]string   `json:"properties,omitempty"` // 属性名 -> 类型
+	Required      map[string]bool     `json:"required,omitempty"`
+	Enum          []string            `json:"enum,omitempty"`
+	PropertyEnums map[string][]string `json:"property_enums,omitempty"` // 属性名 -> 枚举值
+}
+
+// ParseOpenAPI 解析OpenAPI 3.x或Swagger 2.0文档（YAML或JSON）
+func ParseOpenAPI(data []byte) (*OpenAPI, error) {
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid YAML/JSON: %w", err)
+	}
+
+	spec := &OpenAPI{
+		Operations: make(map[string]*Operation),
+		Schemas:    make(map[string]*Schema),
+	}
+	if version, ok := doc["openapi"]; ok {
+		spec.Version = fmt.Sprint(version)
+	} else if version, ok := doc["swagger"]; ok {
+		spec.Version = fmt.Sprint(version)
+	} else {
+		return nil, fmt.Errorf("document has no 'openapi' or 'swagger' version field")
+	}
+	if info, ok := doc["info"].(map[string]interface{}); ok {
+		spec.Title, _ = info["title"].(string)
+	}
+
+	// 组件schema（OpenAPI 3: components.schemas, Swagger 2: definitions）
+	schemas, _ := doc["definitions"].(map[string]interface{})
+	if components, ok := doc["components"].(map[string]interface{}); ok {
+		if s, ok := components["schemas"].(map[string]interface{}); ok {
+			schemas = s
+		}
+	}
+	for name, raw := range schemas {
+		node, _ := raw.(map[string]interface{})
+		spec.Schemas[name] = parseSchema(name, node)
+	}
+
+	paths, _ := doc["paths"].(map[string]interface{})
+	for path, rawItem := range paths {
+		item, _ := rawItem.(map[string]interface{})
+		if item == nil {
+			continue
+		}
+		// 路径级参数对所有操作生效
+		shared, _ := item["parameters"].([]interface{})
+
+		for _, method := range httpMethods {
+			rawOp, ok := item[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			op := &Operation{
+				Method:     strings.ToUpper(method),
+				Path:       path,
+				Parameters: make(map[string]*Parameter),
+				Responses:  make(map[string]string),
+			}
+			op.OperationID, _ = rawOp["operationId"].(string)
+			op.Summary, _ = rawOp["summary"].(string)
+
+			opParams, _ := rawOp["parameters"].([]interface{})
+			for _, rawParam := range append(append([]interface{}{}, shared...), opParams...) {
+				param := resolveRef(doc, rawParam)
+				if param == nil {
+					continue
+				}
+				p := &Parameter{}
+				p.Name, _ = param["name"].(string)
+				p.In, _ = param["in"].(string)
+				p.Required, _ = param["required"].(bool)
+				if p.In == "body" {
+					// Swagger 2.0 的body参数等价于requestBody
+					op.HasRequestBody = true
+					op.RequestBodyRequired = p.Required
+					schema, _ := param["schema"].(map[string]interface{})
+					op.RequestSchema = schemaType(schema)
+					continue
+				}
+				if schema, ok := param["schema"].(map[string]interface{}); ok {
+					p.Type = schemaType(schema)
+				} else {
+					p.Type, _ = param["type"].(string)
+				}
+				op.Parameters[p.In+":"+p.Name] = p
+			}
+
+			if body := resolveRef(doc, rawOp["requestBody"]); body != nil {
+				op.HasRequestBody = true
+				op.RequestBodyRequired, _ = body["required"].(bool)
+				op.RequestSchema = contentSchemaType(body)
+			}
+
+			responses, _ := rawOp["responses"].(map[string]interface{})
+			for code, rawResp := range responses {
+				resp := resolveRef(doc, rawResp)
+				if resp == nil {
+					op.Responses[code] = ""
+					continue
+				}
+				if schema, ok := resp["schema"].(map[string]interface{}); ok {
+					op.Responses[code] = schemaType(schema)
+				} else {
+					op.Responses[code] = contentSchemaType(resp)
+				}
+			}
+
+			spec.Operations[op.Method+" "+path] = op
+		}
+	}
+
+	return spec, nil
+}
+
+// resolveRef 解析本地 $ref 引用（#/components/...）
+func resolveRef(doc map[string]interface{}, raw interface{}) map[string]interface{} {
+	node, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	ref, ok := node["$ref"].(string)
+	if !ok || !strings.HasPrefix(ref, "#/") {
+		return node
+	}
+
+	var current interface{} = doc
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		current = m[part]
+	}
+	resolved, _ := current.(map[string]interface{})
+	return resolved
+}
+
+// parseSchema 提取schema的兼容性相关信息
+func parseSchema(name string, node map[string]interface{}) *Schema {
+	schema := &Schema{
+		Name:          name,
+		Type:          schemaType(node),
+		Properties:    make(map[string]string),
+		Required:      make(map[string]bool),
+		PropertyEnums: make(map[string][]string),
+	}
+	if node == nil {
+		return schema
+	}
+
+	if props, ok := node["properties"].(map[string]interface{}); ok {
+		for propName, rawProp := range props {
+			prop, _ := rawProp.(map[string]interface{})
+			schema.Properties[propName] = schemaType(prop)
+			schema.PropertyEnums[propName] = enumValues(prop)
+		}
+	}
+	if required, ok := node["required"].([]interface{}); ok {
+		for _, item := range required {
+			if propName, ok := item.(string); ok {
+				schema.Required[propName] = true
+			}
+		}
+	}
+	schema.Enum = enumValues(node)
+
+	return schema
+}
+
+// enumValues 获取schema的枚举值
+func enumValues(node map[string]interface{}) []string {
+	var values []string
+	if enum, ok := node["enum"].([]interface{}); ok {
+		for _, value := range enum {
+			values = append(values, fmt.Sprint(value))
+		}
+	}
+	return values
+}
+
+// removedValues 返回旧列表中在新列表里不存在的值
+func removedValues(oldValues, newValues []string) []string {
+	present := make(map[string]bool, len(newValues))
+	for _, value := range newValues {
+		present[value] = true
+	}
+	var removed []string
+	for _, value := range oldValues {
+		if !present[value] {
+			removed = append(removed, value)
+		}
+	}
+	sort.Strings(removed)
+	return removed
+}
+
+// schemaType 生成schema的简短类型描述
+func schemaType(node map[string]interface{}) string {
+	if node == nil {
+		return ""
+	}
+	if ref, ok := node["$ref"].(string); ok {
+		return ref[strings.LastIndex(ref, "/")+1:]
+	}
+
+	typ, _ := node["type"].(string)
+	switch typ {
+	case "array":
+		items, _ := node["items"].(map[string]interface{})
+		return "array<" + schemaType(items) + ">"
+	case "":
+		for _, combinator := range []string{"oneOf", "anyOf", "allOf"} {
+			if list, ok := node[combinator].([]interface{}); ok {
+				var parts []string
+				for _, item := range list {
+					sub, _ := item.(map[string]interface{})
+					parts = append(parts, schemaType(sub))
+				}
+				return combinator + "(" + strings.Join(parts, ", ") + ")"
+			}
+		}
+		if _, ok := node["properties"]; ok {
+			return "object"
+		}
+		return ""
+	}
+
+	if format, ok := node["format"].(string); ok {
+		return typ + "(" + format + ")"
+	}
+	return typ
+}
+
+// contentSchemaType 获取requestBody/response中首选媒体类型的schema类型
+func contentSchemaType(node map[string]interface{}) string {
+	content, _ := node["content"].(map[string]interface{})
+	if len(content) == 0 {
+		return ""
+	}
+	mediaType := "application/json"
+	if _, ok := content[mediaType]; !ok {
+		mediaTypes := sortedKeys(content)
+		mediaType = mediaTypes[0]
+	}
+	media, _ := content[mediaType].(map[string]interface{})
+	schema, _ := media["schema"].(map[string]interface{})
+	return schemaType(schema)
+}
+
+// DiffOpenAPI 比较两个版本的OpenAPI文档
+func DiffOpenAPI(oldSpec, newSpec *OpenAPI) []Change {
+	var changes []Change
+
+	for _, key := range sortedKeys(oldSpec.Operations) {
+		oldOp := oldSpec.Operations[key]
+		newOp, ok := newSpec.Operations[key]
+		if !ok {
+			changes = append(changes, breaking(key, "operation removed"))
+			continue
+		}
+
+		// 参数
+		for _, paramKey := range sortedKeys(oldOp.Parameters) {
+			oldParam := oldOp.Parameters[paramKey]
+			location := fmt.Sprintf("%s parameter %s (%s)", key, oldParam.Name, oldParam.In)
+			newParam, ok := newOp.Parameters[paramKey]
+			if !ok {
+				changes = append(changes, breaking(location, "parameter removed"))
+				continue
+			}
+			if !oldParam.Required && newParam.Required {
+				changes = append(changes, breaking(location, "parameter became required"))
+			}
+			if oldParam.Type != newParam.Type {
+				changes = append(changes, breaking(location, fmt.Sprintf("parameter type changed from '%s' to '%s'", oldParam.Type, newParam.Type)))
+			}
+		}
+		for _, paramKey := range sortedKeys(newOp.Parameters) {
+			if _, ok := oldOp.Parameters[paramKey]; ok {
+				continue
+			}
+			param := newOp.Parameters[paramKey]
+			location := fmt.Sprintf("%s parameter %s (%s)", key, param.Name, param.In)
+			if param.Required {
+				changes = append(changes, breaking(location, "required parameter added"))
+			} else {
+				changes = append(changes, nonBreaking(location, "optional parameter added"))
+			}
+		}
+
+		// 请求体
+		if !oldOp.HasRequestBody && newOp.HasRequestBody && newOp.RequestBodyRequired {
+			changes = append(changes, breaking(key+" request body", "required request body added"))
+		} else if !oldOp.RequestBodyRequired && newOp.RequestBodyRequired && oldOp.HasRequestBody {
+			changes = append(changes, breaking(key+" request body", "request body became required"))
+		}
+		if oldOp.HasRequestBody && newOp.HasRequestBody && oldOp.RequestSchema != newOp.RequestSchema {
+			changes = append(changes, breaking(key+" request body", fmt.Sprintf("request schema changed from '%s' to '%s'", oldOp.RequestSchema, newOp.RequestSchema)))
+		}
+
+		// 响应
+		for _, code := range sortedKeys(oldOp.Responses) {
+			newSchema, ok := newOp.Responses[code]
+			location := fmt.Sprintf("%s response %s", key, code)
+			if !ok {
+				changes = append(changes, breaking(location, "response removed"))
+			} else if oldOp.Responses[code] != newSchema {
+				changes = append(changes, breaking(location, fmt.Sprintf("response schema changed from '%s' to '%s'", oldOp.Responses[code], newSchema)))
+			}
+		}
+		for _, code := range sortedKeys(newOp.Responses) {
+			if _, ok := oldOp.Responses[code]; !ok {
+				changes = append(changes, nonBreaking(fmt.Sprintf("%s response %s", key, code), "response added"))
+			}
+		}
+	}
+	for _, key := range sortedKeys(newSpec.Operations) {
+		if _, ok := oldSpec.Operations[key]; !ok {
+			changes = append(changes, nonBreaking(key, "operation added"))
+		}
+	}
+
+	// 组件schema
+	for _, name := range sortedKeys(oldSpec.Schemas) {
+		oldSchema := oldSpec.Schemas[name]
+		location := "schema " + name
+		newSchema, ok := newSpec.Schemas[name]
+		if !ok {
+			changes = append(changes, breaking(location, "schema removed"))
+			continue
+		}
+		if oldSchema.Type != newSchema.Type {
+			changes = append(changes, breaking(location, fmt.Sprintf("type changed from '%s' to '%s'", oldSchema.Type, newSchema.Type)))
+		}
+		for _, prop := range sortedKeys(oldSchema.Properties) {
+			newType, ok := newSchema.Properties[prop]
+			if !ok {
+				changes = append(changes, breaking(location, fmt.Sprintf("property '%s' removed", prop)))
+			} else if oldSchema.Properties[prop] != newType {
+				changes = append(changes, breaking(location, fmt.Sprintf("property '%s' type changed from '%s' to '%s'", prop, oldSchema.Properties[prop], newType)))
+			}
+		}
+		for _, prop := range sortedKeys(newSchema.Properties) {
+			if _, ok := oldSchema.Properties[prop]; !ok {
+				if newSchema.Required[prop] {
+					changes = append(changes, breaking(location, fmt.Sprintf("required property '%s' added", prop)))
+				} else {
+					changes = append(changes, nonBreaking(location, fmt.Sprintf("optional property '%s' added", prop)))
+				}
+			}
+		}
+		for _, prop := range sortedKeys(newSchema.Required) {
+			if _, existed := oldSchema.Properties[prop]; existed && !oldSchema.Required[prop] {
+				changes = append(changes, breaking(location, fmt.Sprintf("property '%s' became required", prop)))
+			}
+		}
+		if removed := removedValues(oldSchema.Enum, newSchema.Enum); len(oldSchema.Enum) > 0 && len(removed) > 0 {
+			changes = append(changes, breaking(location, fmt.Sprintf("enum values removed: %s", strings.Join(removed, ", "))))
+		}
+		for _, prop := range sortedKeys(oldSchema.PropertyEnums) {
+			oldValues := oldSchema.PropertyEnums[prop]
+			newValues, ok := newSchema.PropertyEnums[prop]
+			if !ok || len(oldValues) == 0 || len(newValues) == 0 {
+				continue
+			}
+			if removed := removedValues(oldValues, newValues); len(removed) > 0 {
+				changes = append(changes, breaking(location, fmt.Sprintf("property '%s' enum values removed: %s", prop, strings.Join(removed, ", "))))
+			}
+		}
+	}
+	for _, name := range sortedKeys(newSpec.Schemas) {
+		if _, ok := oldSpec.Schemas[name]; !ok {
+			changes = append(changes, nonBreaking("schema "+name, "schema added"))
+		}
+	}
+
+	return changes
+}
\ No newline at end of file
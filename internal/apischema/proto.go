@@ -0,0 +1,586 @@
+package apischema
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Proto 解析后的.proto文件
+type Proto struct {
+	Syntax   string              `json:"syntax,omitempty"`
+	Package  string              `json:"package,omitempty"`
+	Messages map[string]*Message `json:"messages"`
+	Enums    map[string]*Enum    `json:"enums"`
+	Services map[string]*Service `json:"services"`
+}
+
+// Message protobuf消息定义（嵌套消息以 Outer.Inner 形式展开到Proto.Messages中）
+type Message struct {
+	Name     string          `json:"name"`
+	Fields   map[int]*Field  `json:"fields"`
+	Reserved map[string]bool `json:"-"`
+	Line     int             `json:"line"`
+}
+
+// Field 消息字段
+type Field struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Number int    `json:"number"`
+	Label  string `json:"label,omitempty"` // "repeated", "optional", "required" or ""
+	Oneof  string `json:"oneof,omitempty"`
+}
+
+// Enum 枚举定义
+type Enum struct {
+	Name   string         `json:"name"`
+	Values map[string]int `json:"values"`
+	Line   int            `json:"line"`
+}
+
+// Service 服务定义
+type Service struct {
+	Name    string          `json:"name"`
+	Methods map[string]*RPC `json:"methods"`
+	Line    int             `json:"line"`
+}
+
+// RPC 服务方法
+type RPC struct {
+	Name            string `json:"name"`
+	Request         string `json:"request"`
+	Response        string `json:"response"`
+	ClientStreaming bool   `json:"client_streaming,omitempty"`
+	ServerStreaming bool   `json:"server_streaming,omitempty"`
+}
+
+// protoToken 词法单元
+type protoToken struct {
+	text string
+	line int
+}
+
+// tokenizeProto 将proto源码切分为词法单元（忽略注释）
+func tokenizeProto(src string) []protoToken {
+	var tokens []protoToken
+	line := 1
+	runes := []rune(src)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == '\n':
+			line++
+			i++
+		case unicode.IsSpace(r):
+			i++
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i+1 < len(runes) && !(runes[i] == '*' && runes[i+1] == '/') {
+				if runes[i] == '\n' {
+					line++
+				}
+				i++
+			}
+			i += 2
+		case r == '"' || r == '\'':
+			start := i
+			i++
+			for i < len(runes) && runes[i] != r {
+				if runes[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			i++
+			if i > len(runes) {
+				i = len(runes)
+			}
+			tokens = append(tokens, protoToken{text: string(runes[start:i]), line: line})
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '-' || r == '+':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '.' || runes[i] == '-' || runes[i] == '+') {
+				i++
+			}
+			tokens = append(tokens, protoToken{text: string(runes[start:i]), line: line})
+		default:
+			tokens = append(tokens, protoToken{text: string(r), line: line})
+			i++
+		}
+	}
+
+	return tokens
+}
+
+// protoParser proto递归下降解析器
+type protoParser struct {
+	tokens []protoToken
+	pos    int
+	proto  *Proto
+}
+
+func (p *protoParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos].text
+}
+
+func (p *protoParser) next() protoToken {
+	if p.pos >= len(p.tokens) {
+		return protoToken{}
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+	return tok
+}
+
+func (p *protoParser) expect(text string) error {
+	tok := p.next()
+	if tok.text != text {
+		return fmt.Errorf("line %d: expected '%s', found '%s'", tok.line, text, tok.text)
+	}
+	return nil
+}
+
+// skipStatement 跳过到分号为止的语句（处理嵌套的花括号与方括号）
+func (p *protoParser) skipStatement() {
+	depth := 0
+	for p.pos < len(p.tokens) {
+		tok := p.next().text
+		switch tok {
+		case "{", "[", "(":
+			depth++
+		case "}", "]", ")":
+			depth--
+			if depth == 0 && tok == "}" && p.peek() != ";" {
+				return
+			}
+		case ";":
+			if depth <= 0 {
+				return
+			}
+		}
+	}
+}
+
+// skipBlock 跳过一个 { ... } 代码块
+func (p *protoParser) skipBlock() {
+	depth := 0
+	for p.pos < len(p.tokens) {
+		switch p.next().text {
+		case "{":
+			depth++
+		case "}":
+			depth--
+			if depth == 0 {
+				return
+			}
+		}
+	}
+}
+
+// ParseProto 解析.proto文件内容
+func ParseProto(src string) (*Proto, error) {
+	p := &protoParser{
+		tokens: tokenizeProto(src),
+		proto: &Proto{
+			Messages: make(map[string]*Message),
+			Enums:    make(map[string]*Enum),
+			Services: make(map[string]*Service),
+		},
+	}
+
+	for p.pos < len(p.tokens) {
+		switch p.peek() {
+		case "syntax", "edition":
+			p.next()
+			if err := p.expect("="); err != nil {
+				return nil, err
+			}
+			p.proto.Syntax = strings.Trim(p.next().text, `"'`)
+			p.skipStatement()
+		case "package":
+			p.next()
+			p.proto.Package = p.next().text
+			p.skipStatement()
+		case "message":
+			if err := p.parseMessage(""); err != nil {
+				return nil, err
+			}
+		case "enum":
+			if err := p.parseEnum(""); err != nil {
+				return nil, err
+			}
+		case "service":
+			if err := p.parseService(); err != nil {
+				return nil, err
+			}
+		case "extend":
+			p.next()
+			p.skipBlock()
+		case ";":
+			p.next()
+		default:
+			// import/option等语句
+			p.skipStatement()
+		}
+	}
+
+	return p.proto, nil
+}
+
+// parseMessage 解析消息定义
+func (p *protoParser) parseMessage(scope string) error {
+	start := p.next() // "message"
+	name := p.next().text
+	if scope != "" {
+		name = scope + "." + name
+	}
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+
+	msg := &Message{
+		Name:     name,
+		Fields:   make(map[int]*Field),
+		Reserved: make(map[string]bool),
+		Line:     start.line,
+	}
+	p.proto.Messages[name] = msg
+
+	return p.parseMessageBody(msg, "")
+}
+
+// parseMessageBody 解析消息体直到右花括号
+func (p *protoParser) parseMessageBody(msg *Message, oneof string) error {
+	for p.pos < len(p.tokens) {
+		switch tok := p.peek(); tok {
+		case "}":
+			p.next()
+			return nil
+		case ";":
+			p.next()
+		case "message":
+			if err := p.parseMessage(msg.Name); err != nil {
+				return err
+			}
+		case "enum":
+			if err := p.parseEnum(msg.Name); err != nil {
+				return err
+			}
+		case "oneof":
+			p.next()
+			name := p.next().text
+			if err := p.expect("{"); err != nil {
+				return err
+			}
+			if err := p.parseMessageBody(msg, name); err != nil {
+				return err
+			}
+		case "reserved":
+			p.next()
+			for p.pos < len(p.tokens) && p.peek() != ";" {
+				item := strings.Trim(p.next().text, `"'`)
+				if item != "," && item != "to" {
+					msg.Reserved[item] = true
+				}
+			}
+			p.next()
+		case "option", "extensions", "extend":
+			if tok == "extend" {
+				p.next()
+				p.skipBlock()
+				continue
+			}
+			p.skipStatement()
+		default:
+			if err := p.parseField(msg, oneof); err != nil {
+				return err
+			}
+		}
+	}
+	return fmt.Errorf("unterminated message %s", msg.Name)
+}
+
+// parseField 解析字段定义
+func (p *protoParser) parseField(msg *Message, oneof string) error {
+	field := &Field{Oneof: oneof}
+
+	tok := p.next()
+	if tok.text == "repeated" || tok.text == "optional" || tok.text == "required" {
+		field.Label = tok.text
+		tok = p.next()
+	}
+
+	if tok.text == "map" {
+		// map<K, V>
+		var sb strings.Builder
+		sb.WriteString("map")
+		for p.pos < len(p.tokens) {
+			part := p.next().text
+			sb.WriteString(part)
+			if part == ">" {
+				break
+			}
+		}
+		field.Type = strings.ReplaceAll(sb.String(), ",", ", ")
+	} else {
+		field.Type = tok.text
+	}
+
+	field.Name = p.next().text
+	if err := p.expect("="); err != nil {
+		return fmt.Errorf("message %s: %w", msg.Name, err)
+	}
+	numberTok := p.next()
+	number, err := strconv.Atoi(numberTok.text)
+	if err != nil {
+		return fmt.Errorf("line %d: invalid field number '%s'", numberTok.line, numberTok.text)
+	}
+	field.Number = number
+	p.skipStatement()
+
+	msg.Fields[number] = field
+	return nil
+}
+
+// parseEnum 解析枚举定义
+func (p *protoParser) parseEnum(scope string) error {
+	start := p.next() // "enum"
+	name := p.next().text
+	if scope != "" {
+		name = scope + "." + name
+	}
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+
+	enum := &Enum{Name: name, Values: make(map[string]int), Line: start.line}
+	p.proto.Enums[name] = enum
+
+	for p.pos < len(p.tokens) {
+		switch p.peek() {
+		case "}":
+			p.next()
+			return nil
+		case ";":
+			p.next()
+		case "option", "reserved":
+			p.skipStatement()
+		default:
+			valueName := p.next().text
+			if err := p.expect("="); err != nil {
+				return fmt.Errorf("enum %s: %w", name, err)
+			}
+			number, _ := strconv.Atoi(p.next().text)
+			enum.Values[valueName] = number
+			p.skipStatement()
+		}
+	}
+	return fmt.Errorf("unterminated enum %s", name)
+}
+
+// parseService 解析服务定义
+func (p *protoParser) parseService() error {
+	start := p.next() // "service"
+	name := p.next().text
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+
+	service := &Service{Name: name, Methods: make(map[string]*RPC), Line: start.line}
+	p.proto.Services[name] = service
+
+	for p.pos < len(p.tokens) {
+		switch p.peek() {
+		case "}":
+			p.next()
+			return nil
+		case ";":
+			p.next()
+		case "rpc":
+			p.next()
+			rpc := &RPC{Name: p.next().text}
+			if err := p.expect("("); err != nil {
+				return fmt.Errorf("rpc %s: %w", rpc.Name, err)
+			}
+			if p.peek() == "stream" {
+				p.next()
+				rpc.ClientStreaming = true
+			}
+			rpc.Request = p.next().text
+			if err := p.expect(")"); err != nil {
+				return fmt.Errorf("rpc %s: %w", rpc.Name, err)
+			}
+			if err := p.expect("returns"); err != nil {
+				return fmt.Errorf("rpc %s: %w", rpc.Name, err)
+			}
+			if err := p.expect("("); err != nil {
+				return fmt.Errorf("rpc %s: %w", rpc.Name, err)
+			}
+			if p.peek() == "stream" {
+				p.next()
+				rpc.ServerStreaming = true
+			}
+			rpc.Response = p.next().text
+			if err := p.expect(")"); err != nil {
+				return fmt.Errorf("rpc %s: %w", rpc.Name, err)
+			}
+			if p.peek() == "{" {
+				p.skipBlock()
+			} else {
+				p.skipStatement()
+			}
+			service.Methods[rpc.Name] = rpc
+		default:
+			p.skipStatement()
+		}
+	}
+	return fmt.Errorf("unterminated service %s", name)
+}
+
+// DiffProto 比较两个版本的proto定义
+func DiffProto(oldProto, newProto *Proto) []Change {
+	var changes []Change
+
+	if oldProto.Package != newProto.Package {
+		changes = append(changes, breaking("package", fmt.Sprintf("package changed from '%s' to '%s'", oldProto.Package, newProto.Package)))
+	}
+
+	// 消息
+	for _, name := range sortedKeys(oldProto.Messages) {
+		oldMsg := oldProto.Messages[name]
+		newMsg, ok := newProto.Messages[name]
+		if !ok {
+			changes = append(changes, breaking("message "+name, "message removed"))
+			continue
+		}
+
+		for _, number := range sortedInts(oldMsg.Fields) {
+			oldField := oldMsg.Fields[number]
+			location := fmt.Sprintf("message %s field %d", name, number)
+			newField, ok := newMsg.Fields[number]
+			if !ok {
+				if newMsg.Reserved[strconv.Itoa(number)] || newMsg.Reserved[oldField.Name] {
+					changes = append(changes, nonBreaking(location, fmt.Sprintf("field '%s' removed and reserved", oldField.Name)))
+				} else {
+					changes = append(changes, breaking(location, fmt.Sprintf("field '%s' removed without reserving its number", oldField.Name)))
+				}
+				continue
+			}
+			if oldField.Type != newField.Type {
+				changes = append(changes, breaking(location, fmt.Sprintf("field '%s' type changed from '%s' to '%s'", oldField.Name, oldField.Type, newField.Type)))
+			}
+			if oldField.Label != newField.Label {
+				changes = append(changes, breaking(location, fmt.Sprintf("field '%s' label changed from '%s' to '%s'", oldField.Name, labelOrDefault(oldField.Label), labelOrDefault(newField.Label))))
+			}
+			if oldField.Name != newField.Name {
+				changes = append(changes, breaking(location, fmt.Sprintf("field renamed from '%s' to '%s' (breaks JSON encoding and generated code)", oldField.Name, newField.Name)))
+			}
+			if oldField.Oneof != newField.Oneof {
+				changes = append(changes, breaking(location, fmt.Sprintf("field '%s' moved between oneofs", oldField.Name)))
+			}
+		}
+
+		for _, number := range sortedInts(newMsg.Fields) {
+			if _, ok := oldMsg.Fields[number]; ok {
+				continue
+			}
+			field := newMsg.Fields[number]
+			location := fmt.Sprintf("message %s field %d", name, number)
+			if field.Label == "required" {
+				changes = append(changes, breaking(location, fmt.Sprintf("required field '%s' added", field.Name)))
+			} else {
+				changes = append(changes, nonBreaking(location, fmt.Sprintf("field '%s' added", field.Name)))
+			}
+		}
+	}
+	for _, name := range sortedKeys(newProto.Messages) {
+		if _, ok := oldProto.Messages[name]; !ok {
+			changes = append(changes, nonBreaking("message "+name, "message added"))
+		}
+	}
+
+	// 枚举
+	for _, name := range sortedKeys(oldProto.Enums) {
+		oldEnum := oldProto.Enums[name]
+		newEnum, ok := newProto.Enums[name]
+		if !ok {
+			changes = append(changes, breaking("enum "+name, "enum removed"))
+			continue
+		}
+		for _, valueName := range sortedKeys(oldEnum.Values) {
+			newNumber, ok := newEnum.Values[valueName]
+			if !ok {
+				changes = append(changes, breaking("enum "+name, fmt.Sprintf("value '%s' removed", valueName)))
+			} else if newNumber != oldEnum.Values[valueName] {
+				changes = append(changes, breaking("enum "+name, fmt.Sprintf("value '%s' number changed from %d to %d", valueName, oldEnum.Values[valueName], newNumber)))
+			}
+		}
+		for _, valueName := range sortedKeys(newEnum.Values) {
+			if _, ok := oldEnum.Values[valueName]; !ok {
+				changes = append(changes, nonBreaking("enum "+name, fmt.Sprintf("value '%s' added", valueName)))
+			}
+		}
+	}
+	for _, name := range sortedKeys(newProto.Enums) {
+		if _, ok := oldProto.Enums[name]; !ok {
+			changes = append(changes, nonBreaking("enum "+name, "enum added"))
+		}
+	}
+
+	// 服务
+	for _, name := range sortedKeys(oldProto.Services) {
+		oldService := oldProto.Services[name]
+		newService, ok := newProto.Services[name]
+		if !ok {
+			changes = append(changes, breaking("service "+name, "service removed"))
+			continue
+		}
+		for _, rpcName := range sortedKeys(oldService.Methods) {
+			oldRPC := oldService.Methods[rpcName]
+			location := fmt.Sprintf("service %s rpc %s", name, rpcName)
+			newRPC, ok := newService.Methods[rpcName]
+			if !ok {
+				changes = append(changes, breaking(location, "rpc removed"))
+				continue
+			}
+			if oldRPC.Request != newRPC.Request {
+				changes = append(changes, breaking(location, fmt.Sprintf("request type changed from '%s' to '%s'", oldRPC.Request, newRPC.Request)))
+			}
+			if oldRPC.Response != newRPC.Response {
+				changes = append(changes, breaking(location, fmt.Sprintf("response type changed from '%s' to '%s'", oldRPC.Response, newRPC.Response)))
+			}
+			if oldRPC.ClientStreaming != newRPC.ClientStreaming || oldRPC.ServerStreaming != newRPC.ServerStreaming {
+				changes = append(changes, breaking(location, "streaming mode changed"))
+			}
+		}
+		for _, rpcName := range sortedKeys(newService.Methods) {
+			if _, ok := oldService.Methods[rpcName]; !ok {
+				changes = append(changes, nonBreaking(fmt.Sprintf("service %s rpc %s", name, rpcName), "rpc added"))
+			}
+		}
+	}
+	for _, name := range sortedKeys(newProto.Services) {
+		if _, ok := oldProto.Services[name]; !ok {
+			changes = append(changes, nonBreaking("service "+name, "service added"))
+		}
+	}
+
+	return changes
+}
+
+// labelOrDefault 字段标签的展示名称
+func labelOrDefault(label string) string {
+	if label == "" {
+		return "singular"
+	}
+	return label
+}
\ No newline at end of file
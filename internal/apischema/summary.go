@@ -0,0 +1,254 @@
+package apischema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Summary schema文件的精简摘要
+type Summary struct {
+	Format    string `json:"format"`
+	Title     string `json:"title,omitempty"`   // OpenAPI 的标题或 proto 的包名
+	Version   string `json:"version,omitempty"` // OpenAPI 版本或 proto 语法
+	Endpoints int    `json:"endpoints"`         // 列出的操作或 RPC 方法数
+	Types     int    `json:"types"`             // 列出的 schema、消息与枚举数
+	Text      string `json:"summary"`
+}
+
+// Summarize 把 OpenAPI 文档或 .proto 文件压缩为每个操作、类型一到几行的摘要。
+// filter 不为空时只列出名称、路径或 operationId 包含它（不区分大小写）的操作与类型
+func Summarize(format string, content []byte, filter string) (*Summary, error) {
+	switch format {
+	case FormatOpenAPI:
+		spec, err := ParseOpenAPI(content)
+		if err != nil {
+			return nil, err
+		}
+		return summarizeOpenAPI(spec, strings.ToLower(filter)), nil
+	case FormatProto:
+		proto, err := ParseProto(string(content))
+		if err != nil {
+			return nil, err
+		}
+		return summarizeProto(proto, strings.ToLower(filter)), nil
+	}
+	return nil, fmt.Errorf("unsupported schema format: %s", format)
+}
+
+// matches 名称之一是否包含过滤文本（已转为小写）
+func matches(filter string, names ...string) bool {
+	if filter == "" {
+		return true
+	}
+	for _, name := range names {
+		if strings.Contains(strings.ToLower(name), filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// summarizeOpenAPI 按路径与方法列出操作（参数、请求体与响应），然后列出组件 schema
+func summarizeOpenAPI(spec *OpenAPI, filter string) *Summary {
+	summary := &Summary{Format: FormatOpenAPI, Title: spec.Title, Version: spec.Version}
+	var sb strings.Builder
+
+	operations := make([]*Operation, 0, len(spec.Operations))
+	for _, op := range spec.Operations {
+		if matches(filter, op.Method+" "+op.Path, op.OperationID) {
+			operations = append(operations, op)
+		}
+	}
+	sort.Slice(operations, func(i, j int) bool {
+		if operations[i].Path != operations[j].Path {
+			return operations[i].Path < operations[j].Path
+		}
+		return methodOrder(operations[i].Method) < methodOrder(operations[j].Method)
+	})
+	summary.Endpoints = len(operations)
+	fmt.Fprintf(&sb, "Endpoints (%d):\n", len(operations))
+	for _, op := range operations {
+		fmt.Fprintf(&sb, "  %s %s", op.Method, op.Path)
+		if op.OperationID != "" {
+			fmt.Fprintf(&sb, " [%s]", op.OperationID)
+		}
+		if op.Summary != "" {
+			fmt.Fprintf(&sb, " - %s", op.Summary)
+		}
+		sb.WriteString("\n")
+
+		var params []string
+		for _, key := range sortedKeys(op.Parameters) {
+			p := op.Parameters[key]
+			param := p.In + " " + p.Name
+			if p.Required {
+				param += "*"
+			}
+			if p.Type != "" {
+				param += ": " + p.Type
+			}
+			params = append(params, param)
+		}
+		if len(params) > 0 {
+			fmt.Fprintf(&sb, "      params: %s\n", strings.Join(params, ", "))
+		}
+		if op.HasRequestBody {
+			body := op.RequestSchema
+			if body == "" {
+				body = "(no schema)"
+			}
+			if op.RequestBodyRequired {
+				body += " (required)"
+			}
+			fmt.Fprintf(&sb, "      body: %s\n", body)
+		}
+		var responses []string
+		for _, code := range sortedKeys(op.Responses) {
+			if typ := op.Responses[code]; typ != "" {
+				responses = append(responses, code+" "+typ)
+			} else {
+				responses = append(responses, code)
+			}
+		}
+		if len(responses) > 0 {
+			fmt.Fprintf(&sb, "      responses: %s\n", strings.Join(responses, ", "))
+		}
+	}
+
+	var schemas []string
+	for _, name := range sortedKeys(spec.Schemas) {
+		if matches(filter, name) {
+			schemas = append(schemas, formatSchema(spec.Schemas[name]))
+		}
+	}
+	summary.Types = len(schemas)
+	fmt.Fprintf(&sb, "\nSchemas (%d):\n", len(schemas))
+	for _, schema := range schemas {
+		sb.WriteString("  " + schema + "\n")
+	}
+	summary.Text = sb.String()
+	return summary
+}
+
+// methodOrder 方法在摘要中的顺序
+func methodOrder(method string) int {
+	for i, m := range httpMethods {
+		if strings.EqualFold(m, method) {
+			return i
+		}
+	}
+	return len(httpMethods)
+}
+
+// formatSchema 一行的 schema：对象列出属性（必需的带 *），枚举列出取值
+func formatSchema(schema *Schema) string {
+	line := schema.Name
+	if len(schema.Enum) > 0 {
+		return line + " enum [" + strings.Join(schema.Enum, ", ") + "]"
+	}
+	if len(schema.Properties) == 0 {
+		if schema.Type != "" {
+			line += ": " + schema.Type
+		}
+		return line
+	}
+	var props []string
+	for _, name := range sortedKeys(schema.Properties) {
+		prop := name
+		if schema.Required[name] {
+			prop += "*"
+		}
+		if typ := schema.Properties[name]; typ != "" {
+			prop += ": " + typ
+		}
+		if enum := schema.PropertyEnums[name]; len(enum) > 0 {
+			prop += " [" + strings.Join(enum, "|") + "]"
+		}
+		props = append(props, prop)
+	}
+	return line + " {" + strings.Join(props, ", ") + "}"
+}
+
+// summarizeProto 列出服务的 RPC 方法，然后按定义顺序列出消息（字段按编号）与枚举
+func summarizeProto(proto *Proto, filter string) *Summary {
+	summary := &Summary{Format: FormatProto, Title: proto.Package, Version: proto.Syntax}
+	var sb strings.Builder
+
+	for _, name := range sortedKeys(proto.Services) {
+		service := proto.Services[name]
+		var methods []string
+		for _, methodName := range sortedKeys(service.Methods) {
+			rpc := service.Methods[methodName]
+			if !matches(filter, name, rpc.Name, rpc.Request, rpc.Response) {
+				continue
+			}
+			methods = append(methods, fmt.Sprintf("rpc %s(%s) returns (%s)", rpc.Name, streamType(rpc.Request, rpc.ClientStreaming), streamType(rpc.Response, rpc.ServerStreaming)))
+		}
+		if len(methods) == 0 {
+			continue
+		}
+		summary.Endpoints += len(methods)
+		fmt.Fprintf(&sb, "service %s\n", name)
+		for _, method := range methods {
+			sb.WriteString("  " + method + "\n")
+		}
+	}
+
+	// 消息与枚举按在文件中的位置排列
+	type definition struct {
+		line int
+		text string
+	}
+	var definitions []definition
+	for name, msg := range proto.Messages {
+		if !matches(filter, name) {
+			continue
+		}
+		var fields []string
+		for _, number := range sortedInts(msg.Fields) {
+			field := msg.Fields[number]
+			text := fmt.Sprintf("%d %s %s", number, field.Type, field.Name)
+			if field.Label != "" {
+				text = fmt.Sprintf("%d %s %s %s", number, field.Label, field.Type, field.Name)
+			}
+			if field.Oneof != "" {
+				text += " (oneof " + field.Oneof + ")"
+			}
+			fields = append(fields, text)
+		}
+		definitions = append(definitions, definition{msg.Line, "message " + name + " {" + strings.Join(fields, "; ") + "}"})
+	}
+	for name, enum := range proto.Enums {
+		if !matches(filter, name) {
+			continue
+		}
+		values := make([]string, 0, len(enum.Values))
+		for valueName := range enum.Values {
+			values = append(values, valueName)
+		}
+		sort.Slice(values, func(i, j int) bool { return enum.Values[values[i]] < enum.Values[values[j]] })
+		for i, valueName := range values {
+			values[i] = fmt.Sprintf("%s=%d", valueName, enum.Values[valueName])
+		}
+		definitions = append(definitions, definition{enum.Line, "enum " + name + " {" + strings.Join(values, ", ") + "}"})
+	}
+	sort.SliceStable(definitions, func(i, j int) bool { return definitions[i].line < definitions[j].line })
+	summary.Types = len(definitions)
+	if sb.Len() > 0 && len(definitions) > 0 {
+		sb.WriteString("\n")
+	}
+	for _, def := range definitions {
+		sb.WriteString(def.text + "\n")
+	}
+	summary.Text = sb.String()
+	return summary
+}
+
+// streamType RPC 的请求或响应类型，流式的带 stream 前缀
+func streamType(typ string, stream bool) string {
+	if stream {
+		return "stream " + typ
+	}
+	return typ
+}
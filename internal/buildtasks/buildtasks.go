@@ -0,0 +1,108 @@
+package buildtasks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// maxCommands 每个任务最多返回的命令数量
+const maxCommands = 5
+
+// Task 项目中定义的一个任务（Makefile目标或Taskfile任务）
+type Task struct {
+	Name         string   `json:"name"`
+	Source       string   `json:"source"`
+	Description  string   `json:"description,omitempty"`
+	Dependencies []string `json:"dependencies,omitempty"`
+	Commands     []string `json:"commands,omitempty"`
+	Aliases      []string `json:"aliases,omitempty"`
+	Default      bool     `json:"default,omitempty"`
+}
+
+// Kind 任务文件类型
+type Kind string
+
+const (
+	KindMakefile Kind = "make"
+	KindTaskfile Kind = "task"
+)
+
+// File 一个任务文件及其解析结果
+type File struct {
+	Path  string `json:"path"`
+	Kind  Kind   `json:"kind"`
+	Tasks []Task `json:"tasks"`
+}
+
+// 按优先级排列的默认文件名（与 make / task 自身的查找顺序一致）
+var (
+	makefileNames = []string{"GNUmakefile", "makefile", "Makefile"}
+	taskfileNames = []string{"Taskfile.yml", "taskfile.yml", "Taskfile.yaml", "taskfile.yaml", "Taskfile.dist.yml", "taskfile.dist.yml", "Taskfile.dist.yaml", "taskfile.dist.yaml"}
+)
+
+// Discover 在目录中查找 make 与 task 实际会使用的任务文件
+func Discover(dir string) []string {
+	var found []string
+	for _, group := range [][]string{makefileNames, taskfileNames} {
+		for _, name := range group {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				found = append(found, path)
+				break
+			}
+		}
+	}
+	return found
+}
+
+// DetectKind 根据文件名判断任务文件类型
+func DetectKind(path string) Kind {
+	base := filepath.Base(path)
+	for _, name := range taskfileNames {
+		if base == name {
+			return KindTaskfile
+		}
+	}
+	switch filepath.Ext(base) {
+	case ".yml", ".yaml":
+		return KindTaskfile
+	}
+	return KindMakefile
+}
+
+// Parse 解析任务文件
+func Parse(path string) (*File, error) {
+	kind := DetectKind(path)
+
+	var tasks []Task
+	var err error
+	switch kind {
+	case KindTaskfile:
+		tasks, err = parseTaskfile(path)
+	default:
+		tasks, err = parseMakefile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(tasks, func(i, j int) bool {
+		if tasks[i].Default != tasks[j].Default {
+			return tasks[i].Default
+		}
+		return tasks[i].Name < tasks[j].Name
+	})
+
+	return &File{Path: path, Kind: kind, Tasks: tasks}, nil
+}
+
+// limitCommands 截断过长的命令列表
+func limitCommands(commands []string) []string {
+	if len(commands) <= maxCommands {
+		return commands
+	}
+	limited := append([]string(nil), commands[:maxCommands]...)
+	return append(limited, fmt.Sprintf("... (%d more)", len(commands)-maxCommands))
+}
\ No newline at end of file
@@ -0,0 +1,271 @@
+package buildtasks
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxIncludeDepth include 递归的最大深度
+const maxIncludeDepth = 3
+
+// makeParser Makefile解析状态
+type makeParser struct {
+	root        string
+	targets     map[string]*Task
+	order       []string
+	phony       map[string]bool
+	defaultGoal string
+	firstGoal   string
+	visited     map[string]bool
+}
+
+// parseMakefile 解析Makefile，返回可直接调用的目标
+func parseMakefile(path string) ([]Task, error) {
+	p := &makeParser{
+		root:    path,
+		targets: make(map[string]*Task),
+		phony:   make(map[string]bool),
+		visited: make(map[string]bool),
+	}
+	if err := p.parseFile(path, 0); err != nil {
+		return nil, err
+	}
+
+	goal := p.defaultGoal
+	if goal == "" {
+		goal = p.firstGoal
+	}
+
+	tasks := make([]Task, 0, len(p.order))
+	for _, name := range p.order {
+		task := p.targets[name]
+		// 以文件名为目标的规则（如 build/app: main.go）只在声明为 .PHONY 或有描述时列出
+		if strings.ContainsAny(name, "/.") && !p.phony[name] && task.Description == "" {
+			continue
+		}
+		task.Default = name == goal
+		task.Commands = limitCommands(task.Commands)
+		tasks = append(tasks, *task)
+	}
+	return tasks, nil
+}
+
+// parseFile 解析单个Makefile（含 include 的文件）
+func (p *makeParser) parseFile(path string, depth int) error {
+	absPath, _ := filepath.Abs(path)
+	if p.visited[absPath] {
+		return nil
+	}
+	p.visited[absPath] = true
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	source := filepath.Base(path)
+	if rel, err := filepath.Rel(filepath.Dir(p.root), path); err == nil {
+		source = filepath.ToSlash(rel)
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var comments []string
+	var current []*Task
+	var pending string
+	inDefine := false
+	for scanner.Scan() {
+		raw := scanner.Text()
+
+		// 合并续行
+		if strings.HasSuffix(raw, "\\") {
+			pending += strings.TrimSuffix(raw, "\\") + " "
+			continue
+		}
+		line := pending + raw
+		pending = ""
+
+		// define ... endef 之间是多行变量，不含规则
+		if inDefine {
+			if strings.HasPrefix(strings.TrimSpace(line), "endef") {
+				inDefine = false
+			}
+			continue
+		}
+
+		// 配方行：归属于最近的规则
+		if strings.HasPrefix(line, "\t") {
+			command := strings.TrimSpace(line)
+			if command != "" && !strings.HasPrefix(command, "#") {
+				for _, task := range current {
+					task.Commands = append(task.Commands, command)
+				}
+			}
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			// 空行打断注释与目标的关联，但配方中允许出现空行
+			comments = nil
+			continue
+		case strings.HasPrefix(trimmed, "#"):
+			comment := strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
+			if comment != "" {
+				comments = append(comments, comment)
+			}
+			continue
+		}
+
+		directive := strings.Fields(trimmed)[0]
+		switch directive {
+		case "include", "-include", "sinclude":
+			if depth < maxIncludeDepth {
+				for _, name := range strings.Fields(trimmed)[1:] {
+					// 含变量的路径无法静态解析
+					if strings.Contains(name, "$") {
+						continue
+					}
+					matches, _ := filepath.Glob(filepath.Join(filepath.Dir(path), name))
+					for _, match := range matches {
+						if err := p.parseFile(match, depth+1); err != nil && directive == "include" {
+							return err
+						}
+					}
+				}
+			}
+			comments = nil
+			continue
+		case "define":
+			inDefine = true
+			comments = nil
+			continue
+		case "ifeq", "ifneq", "ifdef", "ifndef", "else", "endif", "export", "unexport", "override", "vpath":
+			comments = nil
+			continue
+		}
+
+		targets, deps, description, ok := parseRuleLine(trimmed)
+		if !ok {
+			// 变量赋值
+			if name, value, found := cutAssignment(trimmed); found && name == ".DEFAULT_GOAL" {
+				p.defaultGoal = value
+			}
+			comments = nil
+			current = nil
+			continue
+		}
+
+		if description == "" && len(comments) > 0 {
+			description = strings.Join(comments, " ")
+		}
+		comments = nil
+		current = nil
+
+		for _, name := range targets {
+			switch {
+			case name == ".PHONY":
+				for _, dep := range deps {
+					p.phony[dep] = true
+				}
+				continue
+			case strings.HasPrefix(name, "."), strings.Contains(name, "%"), strings.Contains(name, "$"):
+				// 特殊目标、模式规则与变量目标不是可直接调用的任务
+				continue
+			}
+
+			task, exists := p.targets[name]
+			if !exists {
+				task = &Task{Name: name, Source: source}
+				p.targets[name] = task
+				p.order = append(p.order, name)
+				if p.firstGoal == "" {
+					p.firstGoal = name
+				}
+			}
+			task.Dependencies = appendUnique(task.Dependencies, deps...)
+			if task.Description == "" {
+				task.Description = description
+			}
+			current = append(current, task)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return nil
+}
+
+// parseRuleLine 解析规则行 "targets: deps ## description"
+func parseRuleLine(line string) (targets, deps []string, description string, ok bool) {
+	// 行尾 "## 描述" 是常见的自文档约定
+	if idx := strings.Index(line, "##"); idx >= 0 {
+		description = strings.TrimSpace(line[idx+2:])
+		line = line[:idx]
+	} else if idx := strings.Index(line, "#"); idx >= 0 {
+		line = line[:idx]
+	}
+
+	colon := -1
+	for i := 0; i < len(line); i++ {
+		if line[i] == '=' {
+			return nil, nil, "", false
+		}
+		if line[i] == ':' {
+			colon = i
+			break
+		}
+	}
+	if colon <= 0 {
+		return nil, nil, "", false
+	}
+
+	rest := strings.TrimLeft(line[colon+1:], ":")
+	// := 与 ::= 是赋值；target: VAR = value 是目标变量
+	if strings.HasPrefix(rest, "=") || strings.Contains(rest, "=") {
+		return nil, nil, "", false
+	}
+	// 配方可以写在分号之后
+	if idx := strings.Index(rest, ";"); idx >= 0 {
+		rest = rest[:idx]
+	}
+	// 顺序依赖 (| deps) 也视为依赖
+	rest = strings.Replace(rest, "|", " ", 1)
+
+	targets = strings.Fields(line[:colon])
+	deps = strings.Fields(rest)
+	return targets, deps, description, len(targets) > 0
+}
+
+// cutAssignment 解析变量赋值 NAME := value
+func cutAssignment(line string) (string, string, bool) {
+	for _, op := range []string{"::=", ":=", "?=", "+=", "!=", "="} {
+		if idx := strings.Index(line, op); idx > 0 {
+			return strings.TrimSpace(line[:idx]), strings.TrimSpace(line[idx+len(op):]), true
+		}
+	}
+	return "", "", false
+}
+
+// appendUnique 追加不重复的元素
+func appendUnique(list []string, items ...string) []string {
+	for _, item := range items {
+		exists := false
+		for _, existing := range list {
+			if existing == item {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			list = append(list, item)
+		}
+	}
+	return list
+}
\ No newline at end of file
@@ -0,0 +1,121 @@
+package buildtasks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// taskfileDoc go-task Taskfile 的结构（只取introspection需要的字段）
+type taskfileDoc struct {
+	Tasks map[string]taskfileTask `yaml:"tasks"`
+}
+
+// taskfileTask Taskfile中的一个任务
+type taskfileTask struct {
+	Desc     string        `yaml:"desc"`
+	Summary  string        `yaml:"summary"`
+	Aliases  []string      `yaml:"aliases"`
+	Internal bool          `yaml:"internal"`
+	Deps     []interface{} `yaml:"deps"`
+	Cmds     []interface{} `yaml:"cmds"`
+}
+
+// UnmarshalYAML 支持任务的简写形式（字符串或命令列表）
+func (t *taskfileTask) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		t.Cmds = []interface{}{node.Value}
+		return nil
+	case yaml.SequenceNode:
+		return node.Decode(&t.Cmds)
+	}
+	type plain taskfileTask
+	return node.Decode((*plain)(t))
+}
+
+// parseTaskfile 解析Taskfile，跳过 internal 任务
+func parseTaskfile(path string) ([]Task, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var doc taskfileDoc
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	source := filepath.Base(path)
+	tasks := make([]Task, 0, len(doc.Tasks))
+	for name, def := range doc.Tasks {
+		if def.Internal {
+			continue
+		}
+
+		description := def.Desc
+		if description == "" {
+			// summary 可能是多行文本，只取第一行
+			description, _, _ = strings.Cut(strings.TrimSpace(def.Summary), "\n")
+		}
+
+		task := Task{
+			Name:        name,
+			Source:      source,
+			Description: description,
+			Aliases:     def.Aliases,
+			Default:     name == "default",
+		}
+		for _, dep := range def.Deps {
+			if name := taskReference(dep); name != "" {
+				task.Dependencies = append(task.Dependencies, name)
+			}
+		}
+		var commands []string
+		for _, cmd := range def.Cmds {
+			if command := taskCommand(cmd); command != "" {
+				commands = append(commands, command)
+			}
+		}
+		task.Commands = limitCommands(commands)
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// taskReference 解析依赖项（"name" 或 {task: name}）
+func taskReference(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		if name, ok := v["task"].(string); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// taskCommand 解析命令项（"cmd"、{cmd: ...}、{task: ...} 或 {defer: ...}）
+func taskCommand(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case map[string]interface{}:
+		if cmd, ok := v["cmd"].(string); ok {
+			return strings.TrimSpace(cmd)
+		}
+		if name, ok := v["task"].(string); ok {
+			return "task " + name
+		}
+		if deferred, ok := v["defer"]; ok {
+			if command := taskCommand(deferred); command != "" {
+				return "defer: " + command
+			}
+		}
+	}
+	return ""
+}
\ No newline at end of file
@@ -0,0 +1,125 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// ErrBudgetExceeded 本次运行的token用量或估计费用超出了设置的上限
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// Pricing 模型价格，单位为美元每百万tokens
+type Pricing struct {
+	Input  float64 `yaml:"input"`
+	Output float64 `yaml:"output"`
+}
+
+// defaultPricing 常用模型的公开价格（未命中缓存的输入价格），可在配置文件中覆盖
+var defaultPricing = map[string]Pricing{
+	"deepseek-chat":     {Input: 0.28, Output: 0.42},
+	"deepseek-reasoner": {Input: 0.28, Output: 0.42},
+	"gpt-4o":            {Input: 2.5, Output: 10},
+	"gpt-4o-mini":       {Input: 0.15, Output: 0.6},
+}
+
+// DefaultPricing 查找模型的内置价格
+func DefaultPricing(model string) (Pricing, bool) {
+	pricing, ok := defaultPricing[strings.ToLower(model)]
+	return pricing, ok
+}
+
+// Usage 累计的token用量
+type Usage struct {
+	PromptTokens     int  `json:"prompt_tokens"`
+	CompletionTokens int  `json:"completion_tokens"`
+	Estimated        bool `json:"estimated,omitempty"` // 服务端未返回用量，至少有一部分为估计值
+}
+
+// Total 输入与输出tokens之和
+func (u Usage) Total() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// Budget 单次运行的用量上限，为0的项不限制
+type Budget struct {
+	MaxTokens int
+	MaxCost   float64 // 美元，按 Pricing 估算
+	Pricing   Pricing
+}
+
+// SetBudget 设置用量上限，超出后在下一次调用模型或执行工具前停止
+func (c *Client) SetBudget(budget Budget) {
+	c.budget = budget
+}
+
+// Usage 客户端创建以来累计的token用量
+func (c *Client) Usage() Usage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.usage
+}
+
+// Cost 按预算中的价格估算的累计费用（美元）
+func (c *Client) Cost() float64 {
+	usage := c.Usage()
+	return (float64(usage.PromptTokens)*c.budget.Pricing.Input + float64(usage.CompletionTokens)*c.budget.Pricing.Output) / 1e6
+}
+
+// UsageSummary 用量与估计费用的简要说明
+func (c *Client) UsageSummary() string {
+	usage := c.Usage()
+	summary := fmt.Sprintf("%d tokens（输入 %d / 输出 %d）", usage.Total(), usage.PromptTokens, usage.CompletionTokens)
+	if c.budget.Pricing != (Pricing{}) {
+		summary += fmt.Sprintf("，估计费用 $%.4f", c.Cost())
+	}
+	if usage.Estimated {
+		summary += "（部分为估计值）"
+	}
+	return summary
+}
+
+// addUsage 累计一次请求的用量，服务端未返回用量时按请求与回复的内容估计
+func (c *Client) addUsage(reported *openai.Usage, request []openai.ChatCompletionMessage, toolDefs []openai.Tool, completion string, toolCalls []openai.ToolCall) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if reported != nil && reported.TotalTokens > 0 {
+		c.usage.PromptTokens += reported.PromptTokens
+		c.usage.CompletionTokens += reported.CompletionTokens
+		return
+	}
+	c.usage.PromptTokens += estimateMessagesTokens(request) + estimateToolsTokens(toolDefs)
+	c.usage.CompletionTokens += estimateCompletionTokens(nil, completion, toolCalls)
+	c.usage.Estimated = true
+}
+
+// estimateCompletionTokens 回复的输出tokens：优先使用服务端返回的用量，否则按内容估计
+func estimateCompletionTokens(reported *openai.Usage, completion string, toolCalls []openai.ToolCall) int {
+	if reported != nil && reported.TotalTokens > 0 {
+		return reported.CompletionTokens
+	}
+	output := estimateOutputTokens(completion)
+	for _, toolCall := range toolCalls {
+		output += estimateOutputTokens(toolCall.Function.Name + toolCall.Function.Arguments)
+	}
+	return int(output)
+}
+
+// checkBudget 用量超出上限时返回包装了 ErrBudgetExceeded 的错误
+func (c *Client) checkBudget() error {
+	usage := c.Usage()
+	if c.budget.MaxTokens > 0 && usage.Total() >= c.budget.MaxTokens {
+		return fmt.Errorf("%w: used %d of %d tokens", ErrBudgetExceeded, usage.Total(), c.budget.MaxTokens)
+	}
+	if c.budget.MaxCost > 0 && c.Cost() >= c.budget.MaxCost {
+		return fmt.Errorf("%w: estimated cost $%.4f reached the limit of $%.2f", ErrBudgetExceeded, c.Cost(), c.budget.MaxCost)
+	}
+	return nil
+}
+
+// hasBudget 是否设置了用量上限
+func (c *Client) hasBudget() bool {
+	return c.budget.MaxTokens > 0 || c.budget.MaxCost > 0
+}
\ No newline at end of file
@@ -5,6 +5,10 @@ import (
 	"openCursor/internal/tools"
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
 
 	"github.com/sashabaranov/go-openai"
 )
@@ -85,11 +89,49 @@ You MUST use the following format when citing code regions or blocks:
 This is the ONLY acceptable format for code citations. The format is ` + "`" + `startLine:endLine:filepath where startLine and endLine are line numbers.`
 )
 
+// AskSystemPrompt 不提供工具的问答模式使用的系统提示词
+const AskSystemPrompt = `You are a knowledgeable AI programming assistant answering a developer's question in their terminal.
+
+You have no tools in this mode: you cannot read files, search the codebase or run commands. Answer from your own knowledge and from any context attached to the message. If the answer depends on code or state you cannot see, say so briefly and tell the developer what to check, or suggest re-running the question without --ask so the agent can inspect the project.
+
+Be concise and direct. Format your answer in markdown and use code blocks for code and commands.`
+
+// contextBlock 附加到用户消息中的上下文信息
+type contextBlock struct {
+	name    string
+	content string
+}
+
 // Client DeepSeek客户端实现
 type Client struct {
-	client      *openai.Client
-	toolManager tools.ToolManager
-	model       string
+	client        *openai.Client
+	toolManager   tools.ToolManager
+	model         string
+	baseURL       string
+	contexts      []contextBlock // 附加在用户查询前的上下文
+	images        []*tools.Image // 附加到下一次查询的图片
+	promptParts   []string       // 追加在系统提示词后的内容（如角色说明）
+	maxIterations int            // 单次查询最多的模型调用轮数
+	lastResponse  string         // 最近一次查询的最终回复
+	out           io.Writer      // 模型回复与工具调用进度的输出
+	answerOut     io.Writer      // 安静模式下最终回复的输出，为空时最终回复也写到 out
+	contextWindow int            // 模型的上下文窗口（tokens），接近时自动摘要较早的对话
+	budget        Budget         // 单次运行的用量上限
+	usage         Usage          // 累计的token用量
+
+	history  []openai.ChatCompletionMessage // 继续会话时之前的对话
+	messages []openai.ChatCompletionMessage // 最近一次查询的完整对话
+	steps    []Step                         // 最近一次查询的工具调用步骤
+
+	mu           sync.Mutex
+	interrupted  bool               // 用户已请求中断
+	cancelStream context.CancelFunc // 取消当前的流式请求
+	resume       chan struct{}      // 暂停期间不为空，关闭后继续
+	steering     []string           // 运行中加入、尚未发送的补充指令
+	betweenSteps []func([]openai.ChatCompletionMessage) []openai.ChatCompletionMessage // 下一步开始前执行的函数
+	toolImages   []*tools.Image     // view_image 读取、尚未发送的图片
+	
+	eventHandler func(Event) // 结构化事件回调，为空时不发送
 }
 
 // NewClient 创建新的客户端
@@ -98,8 +140,12 @@ func NewClient(apiKey, baseURL, model string) *Client {
 	config.BaseURL = baseURL
 	
 	return &Client{
-		client: openai.NewClientWithConfig(config),
-		model:  model,
+		client:        openai.NewClientWithConfig(config),
+		model:         model,
+		baseURL:       baseURL,
+		maxIterations: 5,
+		out:           os.Stdout,
+		contextWindow: DefaultContextWindow,
 	}
 }
 
@@ -108,21 +154,81 @@ func (c *Client) SetToolManager(toolManager tools.ToolManager) {
 	c.toolManager = toolManager
 }
 
+// SetMaxIterations 设置单次查询最多的模型调用轮数（含工具调用轮次）
+func (c *Client) SetMaxIterations(n int) {
+	if n > 0 {
+		c.maxIterations = n
+	}
+}
+
+// SetOutput 设置模型回复与工具调用进度的输出位置，默认为标准输出
+func (c *Client) SetOutput(out io.Writer) {
+	c.out = out
+}
+
+// SetQuiet 安静模式：中间的回复与工具调用进度只写到 SetOutput 设置的输出（通常为标准错误），
+// 最终回复完整生成后写到 answer
+func (c *Client) SetQuiet(answer io.Writer) {
+	c.answerOut = answer
+}
+
+// LastResponse 获取最近一次查询中模型的最终回复
+func (c *Client) LastResponse() string {
+	return c.lastResponse
+}
+
+// AddContext 添加附加到用户查询前的上下文信息，以<name>标签包裹
+func (c *Client) AddContext(name, content string) {
+	c.contexts = append(c.contexts, contextBlock{name: name, content: content})
+}
+
+// AddSystemPrompt 在系统提示词后追加一段内容，如 --role 的角色说明
+func (c *Client) AddSystemPrompt(section string) {
+	if section = strings.TrimSpace(section); section != "" {
+		c.promptParts = append(c.promptParts, section)
+	}
+}
+
+// systemPrompt 基础系统提示词加上追加的内容
+func (c *Client) systemPrompt(base string) string {
+	if len(c.promptParts) == 0 {
+		return base
+	}
+	return base + "\n\n" + strings.Join(c.promptParts, "\n\n")
+}
+
+// buildUserMessage 构建用户消息，有附加上下文时使用<user_query>标签标注查询
+func (c *Client) buildUserMessage(query string) string {
+	if len(c.contexts) == 0 {
+		return query
+	}
+	
+	var sb strings.Builder
+	for _, block := range c.contexts {
+		sb.WriteString(fmt.Sprintf("<%s>\n%s\n</%s>\n\n", block.name, strings.TrimRight(block.content, "\n"), block.name))
+	}
+	sb.WriteString(fmt.Sprintf("<user_query>\n%s\n</user_query>", query))
+	return sb.String()
+}
+
 // StreamQueryWithTools 支持工具调用的查询（使用流式API）
 func (c *Client) StreamQueryWithTools(query string) error {
 	ctx := context.Background()
 	
-	// 构建消息列表
+	// 构建消息列表（继续会话时接在之前的对话之后）
 	messages := []openai.ChatCompletionMessage{
 		{
 			Role:    openai.ChatMessageRoleSystem,
-			Content: SystemPrompt,
-		},
-		{
-			Role:    openai.ChatMessageRoleUser,
-			Content: query,
+			Content: c.systemPrompt(SystemPrompt),
 		},
 	}
+	messages = append(messages, c.history...)
+	messages = append(messages, c.userMessage(query))
+	c.steps = nil
+	c.toolImages = nil
+	defer func() {
+		c.messages = c.runBetweenSteps(messages)
+	}()
 
 	// 获取可用工具并转换为OpenAI格式
 	var toolDefs []openai.Tool
@@ -132,8 +238,29 @@ func (c *Client) StreamQueryWithTools(query string) error {
 	}
 
 	// 对话循环，处理工具调用
-	maxIterations := 5 // 防止无限循环
-	for iteration := 0; iteration < maxIterations; iteration++ {
+	c.lastResponse = ""
+	compactedAfterError := false
+	rateLimitRetries := 0
+	for iteration := 0; iteration < c.maxIterations; iteration++ { // 防止无限循环
+		c.waitIfPaused()
+		if c.isInterrupted() {
+			return ErrInterrupted
+		}
+		if err := c.checkBudget(); err != nil {
+			return err
+		}
+		messages = c.runBetweenSteps(messages)
+		messages = c.appendSteering(messages)
+		
+		// 对话接近上下文上限时将较早的轮次压缩为摘要
+		compacted, _, err := c.compactIfNeeded(ctx, messages, toolDefs, false)
+		if err == ErrInterrupted {
+			return ErrInterrupted
+		} else if err != nil {
+			fmt.Fprintf(c.out, "\n⚠️  自动摘要失败: %v\n", err)
+		}
+		messages = compacted
+		
 		// 构建请求
 		req := openai.ChatCompletionRequest{
 			Model:    c.model,
@@ -145,16 +272,62 @@ func (c *Client) StreamQueryWithTools(query string) error {
 		if len(toolDefs) > 0 {
 			req.Tools = toolDefs
 		}
+		
+		// 设置了用量上限时请求服务端在最后返回实际用量
+		if c.hasBudget() {
+			req.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+		}
 
-		// 创建流式聊天完成请求
-		stream, err := c.client.CreateChatCompletionStream(ctx, req)
+		// 按服务地址的限额等待（子代理等并行的客户端共享限额）
+		limiter := limiterFor(c.baseURL)
+		acquireCtx, cancelAcquire := c.streamContext(ctx)
+		release, err := limiter.acquire(acquireCtx, estimateMessagesTokens(messages)+estimateToolsTokens(toolDefs))
+		cancelAcquire()
 		if err != nil {
-			return fmt.Errorf("failed to create chat completion stream: %w", err)
+			if c.isInterrupted() {
+				return ErrInterrupted
+			}
+			return err
+		}
+		
+		// 创建流式聊天完成请求，从发出请求开始计时
+		meter := newTokenMeter(c.out)
+		streamCtx, cancel := c.streamContext(ctx)
+		stream, err := c.client.CreateChatCompletionStream(streamCtx, req)
+		if err != nil {
+			cancel()
+			meter.Stop()
+			release()
+			if c.isInterrupted() {
+				return ErrInterrupted
+			}
+			// 服务端限流时等待后重试，避免整个运行失败
+			if isRateLimitError(err) && rateLimitRetries < maxRateLimitRetries {
+				if waitErr := c.waitForRetry(ctx, rateLimitRetries); waitErr == ErrInterrupted {
+					return ErrInterrupted
+				} else if waitErr == nil {
+					rateLimitRetries++
+					iteration--
+					continue
+				}
+			}
+			// 超出上下文窗口时压缩较早的对话后重试一次
+			if isContextLengthError(err) && !compactedAfterError {
+				compactedAfterError = true
+				if compacted, ok, compactErr := c.compactIfNeeded(ctx, messages, toolDefs, true); compactErr == nil && ok {
+					messages = compacted
+					iteration--
+					continue
+				}
+			}
+			return c.explainError("failed to create chat completion stream", err)
 		}
 
 		var assistantMessage openai.ChatCompletionMessage
 		var contentBuffer string
 		var toolCalls []openai.ToolCall
+		var usage *openai.Usage
+		rateLimitRetries = 0
 
 		// 处理流式响应
 		for {
@@ -164,7 +337,23 @@ func (c *Client) StreamQueryWithTools(query string) error {
 					break
 				}
 				stream.Close()
-				return fmt.Errorf("stream error: %w", err)
+				cancel()
+				meter.Stop()
+				release()
+				if c.isInterrupted() {
+					// 保留已输出的内容，丢弃不完整的工具调用
+					if contentBuffer != "" {
+						messages = append(messages, openai.ChatCompletionMessage{
+							Role:    openai.ChatMessageRoleAssistant,
+							Content: contentBuffer,
+						})
+					}
+					return ErrInterrupted
+				}
+				return c.explainError("stream error", err)
+			}
+			if response.Usage != nil {
+				usage = response.Usage
 			}
 
 			if len(response.Choices) > 0 {
@@ -173,7 +362,12 @@ func (c *Client) StreamQueryWithTools(query string) error {
 				// 处理文本内容
 				if delta.Content != "" {
 					contentBuffer += delta.Content
-					fmt.Print(delta.Content) // 实时输出
+					if c.answerOut == nil {
+						meter.Print(delta.Content) // 实时输出
+					} else {
+						meter.Add(delta.Content) // 安静模式下回复完成后再输出
+					}
+					c.emit(Event{Type: EventTextDelta, Text: delta.Content})
 				}
 				
 				// 处理工具调用
@@ -199,6 +393,7 @@ func (c *Client) StreamQueryWithTools(query string) error {
 						}
 						if toolCall.Function.Arguments != "" {
 							toolCalls[index].Function.Arguments += toolCall.Function.Arguments
+							meter.Add(toolCall.Function.Arguments)
 						}
 					}
 				}
@@ -206,6 +401,12 @@ func (c *Client) StreamQueryWithTools(query string) error {
 		}
 		
 		stream.Close()
+		cancel()
+		meter.Stop()
+		release()
+		c.addUsage(usage, messages, toolDefs, contentBuffer, toolCalls)
+		limiter.record(estimateCompletionTokens(usage, contentBuffer, toolCalls))
+		c.emitUsage()
 
 		// 构建完整的助手消息
 		assistantMessage = openai.ChatCompletionMessage{
@@ -217,31 +418,93 @@ func (c *Client) StreamQueryWithTools(query string) error {
 		// 检查是否有工具调用
 		if len(toolCalls) == 0 {
 			// 没有工具调用，对话结束
-			if contentBuffer != "" {
-				fmt.Println() // 换行
+			c.lastResponse = contentBuffer
+			if c.answerOut != nil {
+				if contentBuffer != "" {
+					fmt.Fprintln(c.answerOut, contentBuffer)
+				}
+			} else if contentBuffer != "" {
+				fmt.Fprintln(c.out) // 换行
+			}
+			// 最终回复也记入对话，继续会话时模型能看到之前的回答
+			messages = append(messages, assistantMessage)
+			// 回复期间加入了补充指令时继续对话
+			c.waitIfPaused()
+			if c.hasSteering() && !c.isInterrupted() {
+				continue
 			}
 			break
 		}
 
 		// 添加助手消息（包含工具调用）
 		messages = append(messages, assistantMessage)
+		if c.answerOut != nil && contentBuffer != "" {
+			fmt.Fprintln(c.out, contentBuffer)
+		}
+		
+		// 超出用量上限时不再执行工具调用
+		if err := c.checkBudget(); err != nil {
+			for _, skipped := range toolCalls {
+				c.recordStep(skipped, StepSkipped)
+			}
+			messages = append(messages, skippedToolMessages(toolCalls, "Skipped: the run stopped because it reached its token or cost budget before this tool call was executed.")...)
+			return err
+		}
 
-		// 执行工具调用
-		for _, toolCall := range toolCalls {
-			if toolCall.Type == "function" && toolCall.Function.Name != "" {
-				// 先告诉用户正在调用什么工具
-				fmt.Printf("\n🔧 正在调用工具: %s\n", toolCall.Function.Name)
-				
-				// 调试信息（可选）
-				fmt.Printf("[Debug] Tool Call: ID=%s, Args=%s\n", 
-					toolCall.ID, toolCall.Function.Arguments)
-				
-				result, err := c.executeToolCall(toolCall)
+		// 执行工具调用，连续的可并行工具调用（如多个 spawn_task）同时执行
+		for i := 0; i < len(toolCalls); {
+			// 已请求中断时不再执行剩余的工具调用
+			c.waitIfPaused()
+			if c.isInterrupted() {
+				for _, skipped := range toolCalls[i:] {
+					c.recordStep(skipped, StepSkipped)
+				}
+				messages = append(messages, skippedToolMessages(toolCalls[i:], "Skipped: the user interrupted the run before this tool call was executed.")...)
+				return ErrInterrupted
+			}
+			
+			batch := toolCalls[i : i+1]
+			if parallelTools[toolCalls[i].Function.Name] {
+				j := i + 1
+				for j < len(toolCalls) && toolCalls[j].Function.Name == toolCalls[i].Function.Name {
+					j++
+				}
+				batch = toolCalls[i:j]
+			}
+			i += len(batch)
+			
+			for _, toolCall := range batch {
+				if toolCall.Type == "function" && toolCall.Function.Name != "" {
+					// 先告诉用户正在调用什么工具
+					fmt.Fprintf(c.out, "\n🔧 正在调用工具: %s\n", toolCall.Function.Name)
+					
+					// 调试信息（可选）
+					fmt.Fprintf(c.out, "[Debug] Tool Call: ID=%s, Args=%s\n", 
+						toolCall.ID, toolCall.Function.Arguments)
+					c.emit(Event{Type: EventToolStart, ToolCallID: toolCall.ID, Tool: toolCall.Function.Name, Arguments: toolCall.Function.Arguments})
+				}
+			}
+			
+			results, errs := c.executeToolCalls(batch)
+			for k, toolCall := range batch {
+				if toolCall.Type != "function" || toolCall.Function.Name == "" {
+					continue
+				}
+				result, err := results[k], errs[k]
 				if err != nil {
-					fmt.Printf("❌ 工具执行失败 %s: %v\n", toolCall.Function.Name, err)
+					fmt.Fprintf(c.out, "❌ 工具执行失败 %s: %v\n", toolCall.Function.Name, err)
 					result = fmt.Sprintf("Error: %v", err)
+					c.recordStep(toolCall, StepFailed)
+					c.emitToolEnd(toolCall, StepFailed, result)
+				} else if strings.HasPrefix(result, "Tool execution failed") {
+					fmt.Fprintf(c.out, "✅ 工具执行完成: %s\n", toolCall.Function.Name)
+					c.recordStep(toolCall, StepFailed)
+					c.emitToolEnd(toolCall, StepFailed, result)
 				} else {
-					fmt.Printf("✅ 工具执行完成: %s\n", toolCall.Function.Name)
+					fmt.Fprintf(c.out, "✅ 工具执行完成: %s\n", toolCall.Function.Name)
+					c.printResultDiff(result)
+					c.recordStep(toolCall, StepDone)
+					c.emitToolEnd(toolCall, StepDone, result)
 				}
 
 				// 添加工具响应消息
@@ -252,6 +515,8 @@ func (c *Client) StreamQueryWithTools(query string) error {
 				})
 			}
 		}
+		// view_image 读取的图片跟在本轮所有工具结果之后
+		messages = c.appendToolImages(messages)
 	}
 
 	return nil
@@ -276,13 +541,53 @@ func (c *Client) convertToolsToOpenAI(toolSchemas []tools.ToolSchema) []openai.T
 	return openaiTools
 }
 
+// parallelTools 同一轮中连续调用时可以同时执行的工具
+var parallelTools = map[string]bool{
+	"spawn_task": true,
+}
+
+// maxParallelToolCalls 同时执行的工具调用上限
+const maxParallelToolCalls = 4
+
+// executeToolCalls 执行一批工具调用，多于一个时并发执行，结果按调用顺序返回
+func (c *Client) executeToolCalls(toolCalls []openai.ToolCall) ([]string, []error) {
+	results := make([]string, len(toolCalls))
+	errs := make([]error, len(toolCalls))
+	run := func(i int) {
+		if toolCalls[i].Type == "function" && toolCalls[i].Function.Name != "" {
+			results[i], errs[i] = c.executeToolCall(toolCalls[i])
+		}
+	}
+	if len(toolCalls) == 1 {
+		run(0)
+		return results, errs
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, maxParallelToolCalls)
+	for i := range toolCalls {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			run(i)
+		}(i)
+	}
+	wg.Wait()
+	return results, errs
+}
+
 // executeToolCall 执行工具调用
 func (c *Client) executeToolCall(toolCall openai.ToolCall) (string, error) {
 	if c.toolManager == nil {
 		return "", fmt.Errorf("tool manager not set")
 	}
 
-	// 解析参数
+	// 解析参数（参数来自模型输出，先限制大小）
+	if len(toolCall.Function.Arguments) > tools.MaxParamBytes {
+		return "", fmt.Errorf("tool arguments of %d bytes exceed the limit of %d bytes", len(toolCall.Function.Arguments), tools.MaxParamBytes)
+	}
 	var params map[string]interface{}
 	if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &params); err != nil {
 		return "", fmt.Errorf("failed to parse tool arguments: %w", err)
@@ -298,6 +603,9 @@ func (c *Client) executeToolCall(toolCall openai.ToolCall) (string, error) {
 	if !result.Success {
 		return fmt.Sprintf("Tool execution failed: %s", result.Error), nil
 	}
+	if view, ok := result.Result.(*tools.ViewImageResult); ok && view.Image != nil {
+		c.queueToolImage(view.Image)
+	}
 	
 	// 将结果序列化为JSON字符串
 	resultJSON, err := json.MarshalIndent(result.Result, "", "  ")
@@ -308,7 +616,7 @@ func (c *Client) executeToolCall(toolCall openai.ToolCall) (string, error) {
 	return string(resultJSON), nil
 }
 
-// StreamQuery 普通查询（不支持工具调用，使用流式API）
+// StreamQuery 普通查询（不提供工具，使用流式API），用于 --ask 问答模式
 func (c *Client) StreamQuery(query string) error {
 	ctx := context.Background()
 	
@@ -317,39 +625,57 @@ func (c *Client) StreamQuery(query string) error {
 		Messages: []openai.ChatCompletionMessage{
 			{
 				Role:    openai.ChatMessageRoleSystem,
-				Content: SystemPrompt,
-			},
-			{
-				Role:    openai.ChatMessageRoleUser,
-				Content: query,
+				Content: c.systemPrompt(AskSystemPrompt),
 			},
+			c.userMessage(query),
 		},
 		Stream: true,
 	}
 
+	limiter := limiterFor(c.baseURL)
+	release, err := limiter.acquire(ctx, estimateMessagesTokens(req.Messages))
+	if err != nil {
+		return err
+	}
+	defer release()
+	
+	// 没有工具调用，安静模式下回复直接流式写到最终回复的输出
+	out := c.out
+	if c.answerOut != nil {
+		out = c.answerOut
+	}
+	meter := newTokenMeter(out)
 	stream, err := c.client.CreateChatCompletionStream(ctx, req)
 	if err != nil {
-		return fmt.Errorf("failed to create chat completion stream: %w", err)
+		meter.Stop()
+		return c.explainError("failed to create chat completion stream", err)
 	}
 	defer stream.Close()
 
+	var contentBuffer strings.Builder
+	c.lastResponse = ""
 	for {
 		response, err := stream.Recv()
 		if err != nil {
 			if err.Error() == "EOF" {
 				break
 			}
-			return fmt.Errorf("stream error: %w", err)
+			meter.Stop()
+			return c.explainError("stream error", err)
 		}
 
 		if len(response.Choices) > 0 {
 			content := response.Choices[0].Delta.Content
 			if content != "" {
-				fmt.Print(content)
+				contentBuffer.WriteString(content)
+				meter.Print(content)
+				c.emit(Event{Type: EventTextDelta, Text: content})
 			}
 		}
 	}
+	meter.Stop()
+	c.lastResponse = contentBuffer.String()
 
-	fmt.Println() // 最后换行
+	fmt.Fprintln(out) // 最后换行
 	return nil
 } 
\ No newline at end of file
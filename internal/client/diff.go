@@ -0,0 +1,40 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// printResultDiff 显示编辑工具结果中的统一diff（diff字段），输出为终端时按增删着色
+func (c *Client) printResultDiff(result string) {
+	var payload struct {
+		Diff string `json:"diff"`
+	}
+	if err := json.Unmarshal([]byte(result), &payload); err != nil || payload.Diff == "" {
+		return
+	}
+	file, isFile := c.out.(*os.File)
+	color := isFile && isTerminal(file)
+	for _, line := range strings.Split(strings.TrimSuffix(payload.Diff, "\n"), "\n") {
+		code := ""
+		if color {
+			switch {
+			case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+				code = "\033[1m"
+			case strings.HasPrefix(line, "+"):
+				code = "\033[32m"
+			case strings.HasPrefix(line, "-"):
+				code = "\033[31m"
+			case strings.HasPrefix(line, "@@"):
+				code = "\033[36m"
+			}
+		}
+		if code == "" {
+			fmt.Fprintf(c.out, "   %s\n", line)
+		} else {
+			fmt.Fprintf(c.out, "   %s%s\033[0m\n", code, line)
+		}
+	}
+}
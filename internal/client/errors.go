@@ -0,0 +1,122 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// ProviderError 翻译后的模型服务错误，附带面向用户的处理建议
+type ProviderError struct {
+	Summary string // 简短描述
+	Hint    string // 处理建议
+	Auth    bool   // API 密钥无效或没有权限
+	Err     error  // 原始错误
+}
+
+// Error 实现error接口
+func (e *ProviderError) Error() string {
+	msg := e.Summary
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	if e.Hint != "" {
+		msg += "\nHint: " + e.Hint
+	}
+	return msg
+}
+
+// Unwrap 返回原始错误
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// IsAuthError 错误是否表示 API 密钥被拒绝
+func IsAuthError(err error) bool {
+	var provider *ProviderError
+	return errors.As(err, &provider) && provider.Auth
+}
+
+// errorDetails 提取服务端错误的HTTP状态码、错误码与小写的错误文本
+func errorDetails(err error) (status int, code, text string) {
+	var errType, message string
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	switch {
+	case errors.As(err, &apiErr):
+		status = apiErr.HTTPStatusCode
+		if apiErr.Code != nil {
+			code = fmt.Sprint(apiErr.Code)
+		}
+		errType = apiErr.Type
+		message = apiErr.Message
+	case errors.As(err, &reqErr):
+		status = reqErr.HTTPStatusCode
+		message = string(reqErr.Body)
+	}
+	return status, code, strings.ToLower(code + " " + errType + " " + message)
+}
+
+// isContextLength 错误是否表示请求超出了模型的上下文窗口
+func isContextLength(code, text string) bool {
+	return code == "context_length_exceeded" || strings.Contains(text, "context length") || strings.Contains(text, "context_length") || strings.Contains(text, "too many tokens")
+}
+
+// isContextLengthError 请求是否因超出上下文窗口被拒绝
+func isContextLengthError(err error) bool {
+	_, code, text := errorDetails(err)
+	return isContextLength(code, text)
+}
+
+// explainError 将常见的服务端错误翻译为带处理建议的错误，无法识别时保持原样包装
+func (c *Client) explainError(action string, err error) error {
+	status, code, text := errorDetails(err)
+
+	provider := &ProviderError{Err: err}
+	switch {
+	case isContextLength(code, text):
+		provider.Summary = "the request is larger than the model's context window"
+		provider.Hint = "shorten the request: drop --repo-map or lower repo_map_tokens, point the agent at fewer files, or split the task into smaller steps"
+	case code == "insufficient_quota" || status == http.StatusPaymentRequired || strings.Contains(text, "insufficient balance") || strings.Contains(text, "insufficient_quota"):
+		provider.Summary = "the account has no remaining quota or balance"
+		provider.Hint = fmt.Sprintf("top up the account or check the billing settings of the provider at %s, or use a different OPENAI_API_KEY", c.baseURL)
+	case code == "model_not_found" || strings.Contains(text, "model_not_found") || (strings.Contains(text, "model") && (status == http.StatusNotFound || strings.Contains(text, "does not exist"))):
+		provider.Summary = fmt.Sprintf("model %q is not available", c.model)
+		provider.Hint = fmt.Sprintf("set MODEL to a model offered by the provider at %s (default: deepseek-chat), and check that BASE_URL points to the provider you expect", c.baseURL)
+	case status == http.StatusUnauthorized || code == "invalid_api_key" || strings.Contains(text, "authentication"):
+		provider.Summary = "the API key was rejected"
+		provider.Auth = true
+		provider.Hint = fmt.Sprintf("check that OPENAI_API_KEY holds a valid key for %s; keys are not interchangeable between providers", c.baseURL)
+	case status == http.StatusForbidden:
+		provider.Summary = "the API key is not allowed to perform this request"
+		provider.Auth = true
+		provider.Hint = fmt.Sprintf("check that the key has access to model %q, or use a different MODEL", c.model)
+	case status == http.StatusNotFound:
+		provider.Summary = "the API endpoint was not found"
+		provider.Hint = fmt.Sprintf("check BASE_URL (currently %s); it usually ends with /v1", c.baseURL)
+	case status == http.StatusTooManyRequests || code == "rate_limit_exceeded":
+		provider.Summary = "the provider is rate limiting requests"
+		provider.Hint = "wait a moment and try again"
+	case status >= 500:
+		provider.Summary = "the provider returned a server error"
+		provider.Hint = "the service may be overloaded or down; try again later"
+	case isNetworkError(err):
+		provider.Summary = "could not reach the API"
+		provider.Hint = fmt.Sprintf("check your network connection, proxy settings and BASE_URL (currently %s)", c.baseURL)
+	default:
+		return fmt.Errorf("%s: %w", action, err)
+	}
+	return provider
+}
+
+// isNetworkError 判断是否为网络层错误（DNS、连接失败、超时）
+func isNetworkError(err error) bool {
+	var netErr net.Error
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	return errors.As(err, &dnsErr) || errors.As(err, &opErr) || (errors.As(err, &netErr) && netErr.Timeout())
+}
\ No newline at end of file
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"github.com/sashabaranov/go-openai"
+)
+
+// 事件类型
+const (
+	EventTextDelta = "text_delta" // 模型回复的增量文本
+	EventToolStart = "tool_start" // 开始执行工具调用
+	EventToolEnd   = "tool_end"   // 工具调用结束
+	EventUsage     = "usage"      // 一次模型调用结束后的累计用量
+)
+
+// Event 运行中的结构化事件，供编辑器插件、服务端等在不解析终端输出的情况下展示进度
+type Event struct {
+	Type       string  `json:"type"`
+	Text       string  `json:"text,omitempty"`         // text_delta
+	ToolCallID string  `json:"tool_call_id,omitempty"` // tool_start、tool_end
+	Tool       string  `json:"tool,omitempty"`
+	Arguments  string  `json:"arguments,omitempty"` // tool_start，JSON 字符串
+	Status     string  `json:"status,omitempty"`    // tool_end: done、failed 或 skipped
+	Result     string  `json:"result,omitempty"`    // tool_end，工具结果或错误信息
+	Usage      *Usage  `json:"usage,omitempty"`     // usage
+	Cost       float64 `json:"cost,omitempty"`      // usage，按价格估算的累计费用（美元）
+}
+
+// SetEventHandler 设置事件回调，回调在运行查询的协程中同步调用
+func (c *Client) SetEventHandler(handler func(Event)) {
+	c.eventHandler = handler
+}
+
+// emit 发送事件
+func (c *Client) emit(event Event) {
+	if c.eventHandler != nil {
+		c.eventHandler(event)
+	}
+}
+
+// emitToolEnd 发送工具调用结束事件
+func (c *Client) emitToolEnd(toolCall openai.ToolCall, status, result string) {
+	c.emit(Event{Type: EventToolEnd, ToolCallID: toolCall.ID, Tool: toolCall.Function.Name, Status: status, Result: result})
+}
+
+// emitUsage 发送累计用量事件
+func (c *Client) emitUsage() {
+	if c.eventHandler == nil {
+		return
+	}
+	usage := c.Usage()
+	c.emit(Event{Type: EventUsage, Usage: &usage, Cost: c.Cost()})
+}
\ No newline at end of file
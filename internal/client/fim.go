@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// 填充中间（FIM）补全的提示格式
+const (
+	FIMFormatSuffix        = "suffix"         // completions 接口的 suffix 参数（DeepSeek beta 等）
+	FIMFormatStarCoder     = "starcoder"      // <fim_prefix>…<fim_suffix>…<fim_middle>（StarCoder、通过 TGI/Ollama 等部署）
+	FIMFormatDeepSeekCoder = "deepseek-coder" // <｜fim▁begin｜>…<｜fim▁hole｜>…<｜fim▁end｜>（自行部署的 DeepSeek Coder）
+)
+
+// FIMFormats 支持的FIM提示格式
+var FIMFormats = []string{FIMFormatSuffix, FIMFormatStarCoder, FIMFormatDeepSeekCoder}
+
+// fimStops 各提示格式下模型表示结束的特殊token
+var fimStops = map[string][]string{
+	FIMFormatStarCoder:     {"<|endoftext|>", "<file_sep>", "<fim_prefix>"},
+	FIMFormatDeepSeekCoder: {"<｜end▁of▁sentence｜>", "<｜fim▁begin｜>"},
+}
+
+// FIMOptions 填充中间补全的参数
+type FIMOptions struct {
+	Format    string   // 提示格式，默认 FIMFormatSuffix
+	MaxTokens int      // 补全的最大token数
+	Stop      []string // 额外的停止序列
+}
+
+// FillInMiddle 使用 completions 接口补全 prefix 与 suffix 之间的内容，返回要插入的文本
+func (c *Client) FillInMiddle(prefix, suffix string, opts FIMOptions) (string, error) {
+	ctx := context.Background()
+	req := openai.CompletionRequest{
+		Model:     c.model,
+		MaxTokens: opts.MaxTokens,
+		Stop:      append(append([]string(nil), opts.Stop...), fimStops[opts.Format]...),
+	}
+	switch opts.Format {
+	case "", FIMFormatSuffix:
+		req.Prompt = prefix
+		req.Suffix = suffix
+	case FIMFormatStarCoder:
+		req.Prompt = "<fim_prefix>" + prefix + "<fim_suffix>" + suffix + "<fim_middle>"
+	case FIMFormatDeepSeekCoder:
+		req.Prompt = "<｜fim▁begin｜>" + prefix + "<｜fim▁hole｜>" + suffix + "<｜fim▁end｜>"
+	default:
+		return "", fmt.Errorf("unknown FIM format %q", opts.Format)
+	}
+	if len(req.Stop) > 4 {
+		// OpenAI 兼容接口最多接受4个停止序列，优先保留用户指定的
+		req.Stop = req.Stop[:4]
+	}
+
+	limiter := limiterFor(c.baseURL)
+	prompt := []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: prefix + suffix}}
+	release, err := limiter.acquire(ctx, estimateMessagesTokens(prompt))
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	resp, err := c.client.CreateCompletion(ctx, req)
+	if err != nil {
+		return "", c.explainError("failed to create completion", err)
+	}
+	text := ""
+	if len(resp.Choices) > 0 {
+		text = resp.Choices[0].Text
+	}
+	c.addUsage(&resp.Usage, prompt, nil, text, nil)
+	limiter.record(estimateCompletionTokens(&resp.Usage, text, nil))
+	return text, nil
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"openCursor/internal/tools"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// imageTokens 估算时每张图片计入的token数（按高分辨率图片的常见开销估计）
+const imageTokens = 1000
+
+// AttachImage 将图片附加到下一次查询的用户消息中，需要模型支持图片输入
+func (c *Client) AttachImage(img *tools.Image) {
+	c.images = append(c.images, img)
+}
+
+// userMessage 构建用户消息；附加了图片时使用文本加图片的多段内容，图片只随这一次查询发送
+func (c *Client) userMessage(query string) openai.ChatCompletionMessage {
+	text := c.buildUserMessage(query)
+	images := c.images
+	c.images = nil
+	if len(images) == 0 {
+		return openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: text}
+	}
+	return imageMessage(text, images)
+}
+
+// imageMessage 文本加图片的用户消息
+func imageMessage(text string, images []*tools.Image) openai.ChatCompletionMessage {
+	parts := []openai.ChatMessagePart{{Type: openai.ChatMessagePartTypeText, Text: text}}
+	for _, img := range images {
+		parts = append(parts, openai.ChatMessagePart{
+			Type:     openai.ChatMessagePartTypeImageURL,
+			ImageURL: &openai.ChatMessageImageURL{URL: img.DataURL(), Detail: openai.ImageURLDetailAuto},
+		})
+	}
+	return openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, MultiContent: parts}
+}
+
+// queueToolImage 记录 view_image 读取的图片。工具结果只能是文本，图片在本轮所有工具结果之后作为用户消息发送
+func (c *Client) queueToolImage(img *tools.Image) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.toolImages = append(c.toolImages, img)
+}
+
+// appendToolImages 将待发送的图片追加到对话中
+func (c *Client) appendToolImages(messages []openai.ChatCompletionMessage) []openai.ChatCompletionMessage {
+	c.mu.Lock()
+	images := c.toolImages
+	c.toolImages = nil
+	c.mu.Unlock()
+	if len(images) == 0 {
+		return messages
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Images requested with view_image:")
+	for _, img := range images {
+		fmt.Fprintf(&sb, "\n- %s", img.Path)
+	}
+	return append(messages, imageMessage(sb.String(), images))
+}
+
+// messageText 消息的文本内容，多段内容时拼接其中的文本，图片以 [image] 表示
+func messageText(message openai.ChatCompletionMessage) string {
+	if len(message.MultiContent) == 0 {
+		return message.Content
+	}
+	var parts []string
+	for _, part := range message.MultiContent {
+		switch part.Type {
+		case openai.ChatMessagePartTypeText:
+			parts = append(parts, part.Text)
+		case openai.ChatMessagePartTypeImageURL:
+			parts = append(parts, "[image]")
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// messageImages 消息中的图片数
+func messageImages(message openai.ChatCompletionMessage) int {
+	count := 0
+	for _, part := range message.MultiContent {
+		if part.Type == openai.ChatMessagePartTypeImageURL {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,171 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// ErrInterrupted 用户中断后，在当前步骤完成时返回
+var ErrInterrupted = errors.New("interrupted by user")
+
+// 步骤状态
+const (
+	StepDone    = "done"
+	StepFailed  = "failed"
+	StepSkipped = "skipped"
+)
+
+// Step 一次工具调用步骤
+type Step struct {
+	Tool   string
+	Target string
+	Status string
+}
+
+// stepTargetKeys 从工具参数中提取步骤目标时依次尝试的字段
+var stepTargetKeys = []string{"command", "target_file", "file_path", "path", "query", "pattern", "action", "directory", "target", "task"}
+
+// Interrupt 请求在当前步骤完成后停止，并取消正在进行的流式输出。
+// 已经请求过中断时返回false，调用方应立即退出
+func (c *Client) Interrupt() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.interrupted {
+		return false
+	}
+	c.interrupted = true
+	if c.cancelStream != nil {
+		c.cancelStream()
+	}
+	if c.resume != nil {
+		close(c.resume)
+		c.resume = nil
+	}
+	return true
+}
+
+// isInterrupted 是否已请求中断
+func (c *Client) isInterrupted() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.interrupted
+}
+
+// streamContext 为一次流式请求创建可被中断取消的上下文
+func (c *Client) streamContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	c.mu.Lock()
+	c.cancelStream = cancel
+	c.mu.Unlock()
+	return ctx, cancel
+}
+
+// recordStep 记录一次工具调用步骤
+func (c *Client) recordStep(toolCall openai.ToolCall, status string) {
+	step := Step{Tool: toolCall.Function.Name, Status: status}
+	var args map[string]interface{}
+	if json.Unmarshal([]byte(toolCall.Function.Arguments), &args) == nil {
+		for _, key := range stepTargetKeys {
+			if value, ok := args[key].(string); ok && value != "" {
+				step.Target = value
+				break
+			}
+		}
+	}
+	c.steps = append(c.steps, step)
+}
+
+// skippedToolMessages 为未执行的工具调用生成响应，保证会话可以继续
+func skippedToolMessages(toolCalls []openai.ToolCall, reason string) []openai.ChatCompletionMessage {
+	messages := make([]openai.ChatCompletionMessage, 0, len(toolCalls))
+	for _, toolCall := range toolCalls {
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:       openai.ChatMessageRoleTool,
+			Content:    reason,
+			ToolCallID: toolCall.ID,
+		})
+	}
+	return messages
+}
+
+// Steps 最近一次查询执行过或跳过的工具调用
+func (c *Client) Steps() []Step {
+	return c.steps
+}
+
+// Messages 最近一次查询的完整对话（不含系统提示词），用于持久化会话
+func (c *Client) Messages() []openai.ChatCompletionMessage {
+	if len(c.messages) > 0 && c.messages[0].Role == openai.ChatMessageRoleSystem {
+		return c.messages[1:]
+	}
+	return c.messages
+}
+
+// SetHistory 设置之前的对话，下一次查询将在其后继续
+func (c *Client) SetHistory(messages []openai.ChatCompletionMessage) {
+	c.history = messages
+}
+
+// InterruptSummary 生成中断后的摘要：已完成与未执行的步骤，以及工作区的改动
+func (c *Client) InterruptSummary(workDir string) string {
+	var sb strings.Builder
+	sb.WriteString("⏸  运行已中断\n")
+
+	var done, pending []Step
+	for _, step := range c.steps {
+		if step.Status == StepSkipped {
+			pending = append(pending, step)
+		} else {
+			done = append(done, step)
+		}
+	}
+
+	formatStep := func(icon string, step Step) {
+		target := step.Target
+		if len(target) > 80 {
+			target = target[:77] + "..."
+		}
+		fmt.Fprintf(&sb, "  %s %s %s\n", icon, step.Tool, target)
+	}
+
+	fmt.Fprintf(&sb, "\nCompleted steps (%d):\n", len(done))
+	if len(done) == 0 {
+		sb.WriteString("  (none)\n")
+	}
+	for _, step := range done {
+		icon := "✅"
+		if step.Status == StepFailed {
+			icon = "❌"
+		}
+		formatStep(icon, step)
+	}
+	if len(pending) > 0 {
+		fmt.Fprintf(&sb, "\nPending steps, not executed (%d):\n", len(pending))
+		for _, step := range pending {
+			formatStep("⏭ ", step)
+		}
+	}
+
+	// 工作区改动（仅git仓库）
+	cmd := exec.Command("git", "status", "--short")
+	cmd.Dir = workDir
+	if output, err := cmd.Output(); err == nil {
+		changes := strings.TrimRight(string(output), "\n")
+		if changes == "" {
+			sb.WriteString("\nWorking tree: no changes\n")
+		} else {
+			sb.WriteString("\nWorking tree changes (git status --short):\n")
+			for _, line := range strings.Split(changes, "\n") {
+				sb.WriteString("  " + line + "\n")
+			}
+		}
+	}
+
+	return sb.String()
+}
\ No newline at end of file
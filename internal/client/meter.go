@@ -0,0 +1,166 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// meterInterval 状态行刷新间隔
+const meterInterval = 200 * time.Millisecond
+
+// tokenMeter 流式输出期间在状态行显示输出token数与速度。
+// 只在标准输出与标准错误都是终端时启用；状态行只在光标位于行首时绘制，输出内容前先清除
+type tokenMeter struct {
+	mu      sync.Mutex
+	out     io.Writer // 正文输出（默认标准输出）
+	status  io.Writer // 状态行输出（标准错误）
+	enabled bool
+
+	start       time.Time
+	first       time.Time
+	tokens      float64
+	atLineStart bool
+	drawn       bool
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newTokenMeter 创建并启动token计量，正文写到 out
+func newTokenMeter(out io.Writer) *tokenMeter {
+	file, isFile := out.(*os.File)
+	m := &tokenMeter{
+		out:         out,
+		status:      os.Stderr,
+		enabled:     isFile && isTerminal(file) && isTerminal(os.Stderr),
+		start:       time.Now(),
+		atLineStart: true,
+		done:        make(chan struct{}),
+	}
+	if m.enabled {
+		m.wg.Add(1)
+		go m.loop()
+	}
+	return m
+}
+
+// isTerminal 判断文件是否为终端
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// estimateOutputTokens 粗略估计文本的token数：ASCII约4个字符一个token，其他字符约一个字符一个token
+func estimateOutputTokens(text string) float64 {
+	ascii := 0
+	other := 0
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return float64(ascii)/4 + float64(other)
+}
+
+// loop 定时刷新状态行
+func (m *tokenMeter) loop() {
+	defer m.wg.Done()
+	ticker := time.NewTicker(meterInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+			m.mu.Lock()
+			if m.atLineStart {
+				m.draw()
+			}
+			m.mu.Unlock()
+		}
+	}
+}
+
+// draw 绘制状态行（调用方持有锁）
+func (m *tokenMeter) draw() {
+	elapsed := time.Since(m.start)
+	var line string
+	if m.first.IsZero() {
+		line = fmt.Sprintf("⏳ waiting for the first token… %.1fs", elapsed.Seconds())
+	} else {
+		line = fmt.Sprintf("⏳ ~%d tokens · %.1f tok/s · first token after %.1fs", int(m.tokens), m.rate(), m.first.Sub(m.start).Seconds())
+	}
+	fmt.Fprintf(m.status, "\r\033[2m%s\033[0m\033[K", line)
+	m.drawn = true
+}
+
+// clear 清除状态行（调用方持有锁）
+func (m *tokenMeter) clear() {
+	if m.drawn {
+		fmt.Fprint(m.status, "\r\033[K")
+		m.drawn = false
+	}
+}
+
+// rate 首个token之后的输出速度（调用方持有锁）
+func (m *tokenMeter) rate() float64 {
+	if m.first.IsZero() {
+		return 0
+	}
+	seconds := time.Since(m.first).Seconds()
+	if seconds < 0.05 {
+		return 0
+	}
+	return m.tokens / seconds
+}
+
+// Add 记录收到但不直接显示的输出（如工具调用参数）
+func (m *tokenMeter) Add(text string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.first.IsZero() {
+		m.first = time.Now()
+	}
+	m.tokens += estimateOutputTokens(text)
+}
+
+// Print 输出正文并记录token数
+func (m *tokenMeter) Print(text string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.first.IsZero() {
+		m.first = time.Now()
+	}
+	m.tokens += estimateOutputTokens(text)
+	m.clear()
+	fmt.Fprint(m.out, text)
+	m.atLineStart = text[len(text)-1] == '\n'
+}
+
+// Stop 停止刷新，清除状态行并输出本轮的统计
+func (m *tokenMeter) Stop() {
+	if !m.enabled {
+		return
+	}
+	close(m.done)
+	m.wg.Wait()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clear()
+	if m.first.IsZero() {
+		return
+	}
+	if !m.atLineStart {
+		fmt.Fprintln(m.out)
+		m.atLineStart = true
+	}
+	fmt.Fprintf(m.status, "\033[2m~%d tokens in %.1fs · %.1f tok/s · first token after %.1fs\033[0m\n",
+		int(m.tokens), time.Since(m.start).Seconds(), m.rate(), m.first.Sub(m.start).Seconds())
+}
\ No newline at end of file
@@ -0,0 +1,163 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 服务端限流（429）时的重试
+const (
+	maxRateLimitRetries = 4
+	rateLimitBackoff    = 2 * time.Second // 第一次重试前的等待，之后每次加倍
+	rateLimitWindow     = time.Minute
+)
+
+// RateLimit 对同一模型服务的请求限制，为0的项不限制
+type RateLimit struct {
+	RequestsPerMinute int `yaml:"requests_per_minute,omitempty"`
+	TokensPerMinute   int `yaml:"tokens_per_minute,omitempty"` // 输入与输出tokens之和（估计值）
+	MaxConcurrent     int `yaml:"max_concurrent,omitempty"`    // 同时进行的请求数
+}
+
+// rateLimiter 以一分钟滑动窗口限制请求数与tokens，并限制并发请求数
+type rateLimiter struct {
+	limit RateLimit
+	slots chan struct{} // 并发请求的名额，不限制时为空
+
+	mu      sync.Mutex
+	entries []rateEntry
+}
+
+// rateEntry 窗口中的一次请求或一次用量补记
+type rateEntry struct {
+	at      time.Time
+	tokens  int
+	request bool
+}
+
+// limiters 按模型服务地址共享的限流器：子代理与服务模式中的多个客户端共用同一个限额
+var limiters = struct {
+	sync.Mutex
+	byURL map[string]*rateLimiter
+}{byURL: make(map[string]*rateLimiter)}
+
+// SetRateLimit 设置对某个模型服务地址（BASE_URL）的请求限制，之后以该地址创建的所有客户端共享
+func SetRateLimit(baseURL string, limit RateLimit) {
+	limiter := &rateLimiter{limit: limit}
+	if limit.MaxConcurrent > 0 {
+		limiter.slots = make(chan struct{}, limit.MaxConcurrent)
+	}
+	limiters.Lock()
+	defer limiters.Unlock()
+	limiters.byURL[strings.TrimRight(baseURL, "/")] = limiter
+}
+
+// limiterFor 查找服务地址的限流器，没有设置时返回nil
+func limiterFor(baseURL string) *rateLimiter {
+	limiters.Lock()
+	defer limiters.Unlock()
+	return limiters.byURL[strings.TrimRight(baseURL, "/")]
+}
+
+// acquire 等待并发名额与窗口中的额度，占用后记录本次请求。返回的函数释放并发名额
+func (l *rateLimiter) acquire(ctx context.Context, tokens int) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	release := func() {
+		if l.slots != nil {
+			<-l.slots
+		}
+	}
+
+	for {
+		wait := l.reserve(time.Now(), tokens)
+		if wait <= 0 {
+			return release, nil
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// reserve 额度足够时记录请求并返回0，否则返回需要等待的时间
+func (l *rateLimiter) reserve(now time.Time, tokens int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// 丢弃窗口外的记录
+	start := 0
+	for start < len(l.entries) && now.Sub(l.entries[start].at) >= rateLimitWindow {
+		start++
+	}
+	l.entries = l.entries[start:]
+
+	requests, used := 0, 0
+	for _, entry := range l.entries {
+		if entry.request {
+			requests++
+		}
+		used += entry.tokens
+	}
+
+	// 等到最早的记录移出窗口（单次请求超过每分钟token限额时，窗口为空即放行）
+	if len(l.entries) > 0 {
+		overRequests := l.limit.RequestsPerMinute > 0 && requests >= l.limit.RequestsPerMinute
+		overTokens := l.limit.TokensPerMinute > 0 && used+tokens > l.limit.TokensPerMinute
+		if overRequests || overTokens {
+			return l.entries[0].at.Add(rateLimitWindow).Sub(now) + 10*time.Millisecond
+		}
+	}
+	l.entries = append(l.entries, rateEntry{at: now, tokens: tokens, request: true})
+	return 0
+}
+
+// record 补记请求完成后的用量（如输出tokens）
+func (l *rateLimiter) record(tokens int) {
+	if l == nil || tokens <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, rateEntry{at: time.Now(), tokens: tokens})
+}
+
+// isRateLimitError 服务端是否因限流拒绝了请求（额度用尽不算，重试也不会成功）
+func isRateLimitError(err error) bool {
+	status, code, text := errorDetails(err)
+	if code == "insufficient_quota" || strings.Contains(text, "insufficient_quota") || strings.Contains(text, "insufficient balance") {
+		return false
+	}
+	return status == 429 || code == "rate_limit_exceeded"
+}
+
+// waitForRetry 服务端限流时按指数退避等待，可被中断
+func (c *Client) waitForRetry(ctx context.Context, attempt int) error {
+	delay := rateLimitBackoff << attempt
+	fmt.Fprintf(c.out, "\n⏳ 模型服务限流，%s 后重试（%d/%d）\n", delay, attempt+1, maxRateLimitRetries)
+	waitCtx, cancel := c.streamContext(ctx)
+	defer cancel()
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-waitCtx.Done():
+		if c.isInterrupted() {
+			return ErrInterrupted
+		}
+		return waitCtx.Err()
+	}
+}
\ No newline at end of file
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Steer 在运行中加入用户的补充指令，下一次请求模型前作为用户消息追加到对话中
+func (c *Client) Steer(instruction string) {
+	instruction = strings.TrimSpace(instruction)
+	if instruction == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.steering = append(c.steering, instruction)
+}
+
+// Pause 暂停工具循环：下一个步骤（工具调用或模型请求）开始前等待，直到 Resume 或中断
+func (c *Client) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.resume == nil {
+		c.resume = make(chan struct{})
+	}
+}
+
+// Resume 继续暂停的工具循环
+func (c *Client) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.resume != nil {
+		close(c.resume)
+		c.resume = nil
+	}
+}
+
+// waitIfPaused 暂停期间阻塞，直到继续或中断
+func (c *Client) waitIfPaused() {
+	c.mu.Lock()
+	resume := c.resume
+	c.mu.Unlock()
+	if resume != nil {
+		<-resume
+	}
+}
+
+// hasSteering 是否有尚未加入对话的补充指令
+func (c *Client) hasSteering() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.steering) > 0
+}
+
+// appendSteering 将待处理的补充指令作为用户消息追加到对话中
+func (c *Client) appendSteering(messages []openai.ChatCompletionMessage) []openai.ChatCompletionMessage {
+	c.mu.Lock()
+	instructions := c.steering
+	c.steering = nil
+	c.mu.Unlock()
+
+	for _, instruction := range instructions {
+		fmt.Fprintf(c.out, "\n📝 已加入补充指令: %s\n", instruction)
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleUser,
+			Content: fmt.Sprintf("<user_steering>\nThe user added an instruction while you were working. Take it into account from now on:\n%s\n</user_steering>", instruction),
+		})
+	}
+	return messages
+}
+
+// BetweenSteps 在下一步开始前（或查询结束时）执行 fn：fn 收到当前的对话（不含系统提示词），
+// 返回的对话替换当前的对话。用于运行中保存与回滚检查点
+func (c *Client) BetweenSteps(fn func(history []openai.ChatCompletionMessage) []openai.ChatCompletionMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.betweenSteps = append(c.betweenSteps, fn)
+}
+
+// runBetweenSteps 执行等待中的 BetweenSteps 函数，第一条消息为系统提示词
+func (c *Client) runBetweenSteps(messages []openai.ChatCompletionMessage) []openai.ChatCompletionMessage {
+	c.mu.Lock()
+	pending := c.betweenSteps
+	c.betweenSteps = nil
+	c.mu.Unlock()
+
+	for _, fn := range pending {
+		history := fn(append([]openai.ChatCompletionMessage(nil), messages[1:]...))
+		messages = append(messages[:1:1], history...)
+	}
+	return messages
+}
\ No newline at end of file
@@ -0,0 +1,181 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"openCursor/internal/jsonschema"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// maxStructuredAttempts 结构化回复不符合 schema 时最多请求的次数
+const maxStructuredAttempts = 3
+
+// ErrSchemaMismatch 多次请求后模型的回复仍不符合 schema
+var ErrSchemaMismatch = errors.New("the final answer does not match the schema")
+
+// structuredPrompt 请求模型按 JSON Schema 给出最终回复的指令
+const structuredPrompt = `<most_important_user_query>
+The task is finished. Give your final answer to it as a single JSON value that conforms to this JSON Schema:
+%s
+
+Base the answer on the work above and do not make anything up. Respond with the JSON value only: no markdown code fences and no text before or after it. Do not call any tools.
+</most_important_user_query>`
+
+// structuredRetryPrompt 回复不符合 schema 时要求修正的指令
+const structuredRetryPrompt = `<most_important_user_query>
+Your response does not conform to the JSON Schema:
+%s
+
+Respond again with only the corrected JSON value.
+</most_important_user_query>`
+
+// 请求结构化回复时使用的 response_format，服务不支持时依次降级
+const (
+	formatJSONSchema = iota // 按 schema 约束输出
+	formatJSONObject        // 只保证输出 JSON 对象
+	formatPrompt            // 只靠提示词，依赖校验与重试
+)
+
+// StructuredAnswer 在 StreamQueryWithTools 完成后请求模型按 JSON Schema 给出最终回复，
+// 优先使用服务的结构化输出（json_schema，其次 json_object），回复经过校验，
+// 不符合时带上错误重试。返回格式化后的 JSON，请求与回复记入对话
+func (c *Client) StructuredAnswer(schema *jsonschema.Schema) (string, error) {
+	ctx := context.Background()
+	if len(c.messages) == 0 {
+		return "", fmt.Errorf("no conversation to answer from")
+	}
+
+	prompt := openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleUser,
+		Content: fmt.Sprintf(structuredPrompt, schema.Raw()),
+	}
+	messages := append(append([]openai.ChatCompletionMessage(nil), c.messages...), prompt)
+	format := formatJSONSchema
+	var problems []string
+	for attempt := 0; attempt < maxStructuredAttempts; {
+		if c.isInterrupted() {
+			return "", ErrInterrupted
+		}
+		if err := c.checkBudget(); err != nil {
+			return "", err
+		}
+		req := openai.ChatCompletionRequest{
+			Model:          c.model,
+			Messages:       messages,
+			ResponseFormat: responseFormat(format, schema),
+		}
+
+		limiter := limiterFor(c.baseURL)
+		acquireCtx, cancelAcquire := c.streamContext(ctx)
+		release, err := limiter.acquire(acquireCtx, estimateMessagesTokens(messages))
+		cancelAcquire()
+		if err != nil {
+			if c.isInterrupted() {
+				return "", ErrInterrupted
+			}
+			return "", err
+		}
+		meter := newTokenMeter(c.out)
+		requestCtx, cancel := c.streamContext(ctx)
+		resp, err := c.client.CreateChatCompletion(requestCtx, req)
+		cancel()
+		meter.Stop()
+		release()
+		if err != nil {
+			if c.isInterrupted() {
+				return "", ErrInterrupted
+			}
+			// 服务不支持该 response_format 时降级后重试，不计入次数
+			if format < formatPrompt && isUnsupportedFormatError(err) {
+				format++
+				fmt.Fprintf(c.out, "\n⚠️  服务不支持当前的结构化输出格式，改用%s\n", formatName(format))
+				continue
+			}
+			return "", c.explainError("failed to create chat completion", err)
+		}
+
+		content := ""
+		if len(resp.Choices) > 0 {
+			content = resp.Choices[0].Message.Content
+		}
+		c.addUsage(&resp.Usage, messages, nil, content, nil)
+		limiter.record(estimateCompletionTokens(&resp.Usage, content, nil))
+		c.emitUsage()
+		attempt++
+
+		answer := []byte(trimCodeFence(content))
+		if problems = schema.Validate(answer); len(problems) == 0 {
+			var formatted bytes.Buffer
+			if err := json.Indent(&formatted, answer, "", "  "); err == nil {
+				answer = formatted.Bytes()
+			}
+			c.messages = append(c.messages, prompt, openai.ChatCompletionMessage{
+				Role:    openai.ChatMessageRoleAssistant,
+				Content: string(answer),
+			})
+			return string(answer), nil
+		}
+
+		fmt.Fprintf(c.out, "\n⚠️  回复不符合 schema（第 %d/%d 次）: %s\n", attempt, maxStructuredAttempts, problems[0])
+		messages = append(messages,
+			openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: content},
+			openai.ChatCompletionMessage{
+				Role:    openai.ChatMessageRoleUser,
+				Content: fmt.Sprintf(structuredRetryPrompt, "- "+strings.Join(problems, "\n- ")),
+			})
+	}
+	return "", fmt.Errorf("%w after %d attempts: %s", ErrSchemaMismatch, maxStructuredAttempts, strings.Join(problems, "; "))
+}
+
+// responseFormat 请求使用的 response_format
+func responseFormat(format int, schema *jsonschema.Schema) *openai.ChatCompletionResponseFormat {
+	switch format {
+	case formatJSONSchema:
+		return &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+			JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+				Name:   "final_answer",
+				Schema: schema.Raw(),
+			},
+		}
+	case formatJSONObject:
+		return &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
+	}
+	return nil
+}
+
+// formatName 提示信息中的格式名称
+func formatName(format int) string {
+	if format == formatJSONObject {
+		return " JSON 模式（json_object）"
+	}
+	return "提示词约束格式"
+}
+
+// isUnsupportedFormatError 请求是否因不支持 response_format 被拒绝
+func isUnsupportedFormatError(err error) bool {
+	status, code, text := errorDetails(err)
+	if isContextLength(code, text) {
+		return false
+	}
+	return status == http.StatusBadRequest || status == http.StatusUnprocessableEntity
+}
+
+// trimCodeFence 去掉回复外层的空白与 markdown 代码块
+func trimCodeFence(content string) string {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, "```") {
+		return content
+	}
+	if newline := strings.IndexByte(content, '\n'); newline >= 0 {
+		content = content[newline+1:]
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(content), "```"))
+}
@@ -0,0 +1,199 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// 自动摘要的参数
+const (
+	DefaultContextWindow   = 64000 // 默认的上下文窗口（tokens），可用 SetContextWindow 修改
+	summarizeThreshold     = 0.8   // 估计的请求大小超过上下文窗口的该比例时压缩较早的对话
+	keepRecentRatio        = 0.3   // 压缩后原样保留的最近对话占上下文窗口的比例
+	maxSummarizedToolChars = 4000  // 生成摘要时每条工具结果最多保留的字符数
+	messageOverheadTokens  = 4     // 每条消息的格式开销
+)
+
+// summarizePrompt 请求模型总结较早对话的指令
+const summarizePrompt = `<most_important_user_query>
+Summarize the conversation above so that the work can continue without it. The summary replaces these messages.
+Include: the user's original request and any later instructions, decisions that were made, the files read or changed (with paths and what changed), commands run and their relevant results, errors that are still open, and what remains to be done.
+Be concise and factual; keep exact paths, identifiers and error messages. Do not call any tools.
+</most_important_user_query>`
+
+// SetContextWindow 设置模型的上下文窗口（tokens），对话接近该上限时自动摘要较早的轮次
+func (c *Client) SetContextWindow(tokens int) {
+	if tokens > 0 {
+		c.contextWindow = tokens
+	}
+}
+
+// estimateMessagesTokens 粗略估计消息列表的token数
+func estimateMessagesTokens(messages []openai.ChatCompletionMessage) int {
+	total := 0.0
+	for _, message := range messages {
+		total += estimateOutputTokens(messageText(message)) + messageOverheadTokens + float64(messageImages(message)*imageTokens)
+		for _, toolCall := range message.ToolCalls {
+			total += estimateOutputTokens(toolCall.Function.Name + toolCall.Function.Arguments)
+		}
+	}
+	return int(total)
+}
+
+// estimateToolsTokens 粗略估计工具定义的token数
+func estimateToolsTokens(toolDefs []openai.Tool) int {
+	if len(toolDefs) == 0 {
+		return 0
+	}
+	data, err := json.Marshal(toolDefs)
+	if err != nil {
+		return 0
+	}
+	return int(estimateOutputTokens(string(data)))
+}
+
+// compactIfNeeded 估计的请求大小接近上下文窗口时（或 force 时）将较早的对话压缩为一条系统摘要，
+// 最近的对话原样保留。无法压缩时返回原消息
+func (c *Client) compactIfNeeded(ctx context.Context, messages []openai.ChatCompletionMessage, toolDefs []openai.Tool, force bool) ([]openai.ChatCompletionMessage, bool, error) {
+	toolTokens := estimateToolsTokens(toolDefs)
+	total := estimateMessagesTokens(messages) + toolTokens
+	if !force && float64(total) < float64(c.contextWindow)*summarizeThreshold {
+		return messages, false, nil
+	}
+
+	// 第一条为系统提示词，从末尾开始保留最近的对话，分界处不能落在工具结果上，
+	// 否则工具结果会与发起调用的助手消息分离
+	keepBudget := int(float64(c.contextWindow) * keepRecentRatio)
+	if force && keepBudget > total/3 {
+		// 服务端拒绝了请求，说明估计偏小，按实际对话的比例保留
+		keepBudget = total / 3
+	}
+	split := len(messages)
+	kept := 0
+	for split > 1 {
+		size := estimateMessagesTokens(messages[split-1 : split])
+		if kept+size > keepBudget && split < len(messages) {
+			break
+		}
+		kept += size
+		split--
+	}
+	for split < len(messages) && messages[split].Role == openai.ChatMessageRoleTool {
+		split++
+	}
+	if split <= 2 {
+		// 只有一条可压缩的消息，摘要无法变小
+		return messages, false, nil
+	}
+
+	summary, err := c.summarize(ctx, messages[:split])
+	if err != nil {
+		return messages, false, err
+	}
+
+	compacted := []openai.ChatCompletionMessage{
+		messages[0],
+		{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: fmt.Sprintf("<conversation_summary>\nThe earlier part of this conversation was summarized to fit the context window:\n%s\n</conversation_summary>", strings.TrimSpace(summary)),
+		},
+	}
+	// 保留的对话以用户消息开头，避免助手消息紧跟在系统消息之后
+	if len(messages[split:]) == 0 || messages[split].Role != openai.ChatMessageRoleUser {
+		compacted = append(compacted, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleUser,
+			Content: "Continue with the task described in the summary.",
+		})
+	}
+	compacted = append(compacted, messages[split:]...)
+
+	fmt.Fprintf(c.out, "\n🗜  对话接近上下文上限（约 %d/%d tokens），已将较早的 %d 条消息压缩为摘要（约 %d tokens）\n",
+		total, c.contextWindow, split-1, estimateMessagesTokens(compacted)+toolTokens)
+	return compacted, true, nil
+}
+
+// summarize 不提供工具，请求模型总结给定的对话。工具结果与过长的内容先截断，保证摘要请求本身不超出上下文
+func (c *Client) summarize(ctx context.Context, messages []openai.ChatCompletionMessage) (string, error) {
+	request := make([]openai.ChatCompletionMessage, 0, len(messages)+1)
+	request = append(request, messages[0])
+
+	// 助手的工具调用与工具结果转为文本，摘要请求中不需要工具定义
+	for _, message := range messages[1:] {
+		content := messageText(message)
+		role := message.Role
+		switch role {
+		case openai.ChatMessageRoleTool:
+			role = openai.ChatMessageRoleUser
+			content = "Tool result:\n" + truncateMiddle(content, maxSummarizedToolChars)
+		case openai.ChatMessageRoleAssistant:
+			var calls []string
+			for _, toolCall := range message.ToolCalls {
+				calls = append(calls, fmt.Sprintf("Called tool %s with %s", toolCall.Function.Name, truncateMiddle(toolCall.Function.Arguments, maxSummarizedToolChars)))
+			}
+			if len(calls) > 0 {
+				content = strings.TrimSpace(content + "\n" + strings.Join(calls, "\n"))
+			}
+		case openai.ChatMessageRoleSystem:
+			role = openai.ChatMessageRoleUser
+		}
+		if content == "" {
+			continue
+		}
+		request = append(request, openai.ChatCompletionMessage{Role: role, Content: content})
+	}
+	request = append(request, openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleUser,
+		Content: summarizePrompt,
+	})
+
+	// 截断后仍然过大时，从最早的对话开始丢弃（保留系统提示词与摘要指令）
+	for len(request) > 3 && estimateMessagesTokens(request) > int(float64(c.contextWindow)*summarizeThreshold) {
+		request = append(request[:1], request[2:]...)
+	}
+
+	streamCtx, cancel := c.streamContext(ctx)
+	defer cancel()
+	limiter := limiterFor(c.baseURL)
+	release, err := limiter.acquire(streamCtx, estimateMessagesTokens(request))
+	if err != nil {
+		if c.isInterrupted() {
+			return "", ErrInterrupted
+		}
+		return "", err
+	}
+	defer release()
+	response, err := c.client.CreateChatCompletion(streamCtx, openai.ChatCompletionRequest{
+		Model:    c.model,
+		Messages: request,
+	})
+	if err != nil {
+		if c.isInterrupted() {
+			return "", ErrInterrupted
+		}
+		return "", c.explainError("failed to summarize the conversation", err)
+	}
+	var summary string
+	if len(response.Choices) > 0 {
+		summary = response.Choices[0].Message.Content
+	}
+	c.addUsage(&response.Usage, request, nil, summary, nil)
+	limiter.record(estimateCompletionTokens(&response.Usage, summary, nil))
+	if strings.TrimSpace(summary) == "" {
+		return "", fmt.Errorf("failed to summarize the conversation: empty response")
+	}
+	return summary, nil
+}
+
+// truncateMiddle 超过 n 个字符时保留开头与结尾，中间以省略说明代替
+func truncateMiddle(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	half := n / 2
+	return string(runes[:half]) + fmt.Sprintf("\n... (%d characters omitted) ...\n", len(runes)-2*half) + string(runes[len(runes)-half:])
+}
\ No newline at end of file
@@ -0,0 +1,263 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DirName 命令文件所在的目录名：项目中为 .opencursor/commands，用户级为 ~/.opencursor/commands
+const DirName = "commands"
+
+// namePattern 合法的命令名，即文件名去掉 .md
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// 提示词中的参数占位符：$ARGUMENTS、$1…$9 与 {{name}}
+var (
+	positionalPattern = regexp.MustCompile(`\$([1-9])`)
+	namedPattern      = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_-]*)\s*\}\}`)
+)
+
+// Command 用户定义的命令（提示词宏）
+type Command struct {
+	Name        string
+	Description string
+	Args        []Arg  // 命名参数，按位置对应调用时的参数
+	Prompt      string // 展开前的提示词
+	Path        string
+}
+
+// Arg 命令的命名参数
+type Arg struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+	Default     string `yaml:"default,omitempty"`
+	Required    bool   `yaml:"required,omitempty"`
+}
+
+// frontMatter 命令文件开头 --- 之间的YAML
+type frontMatter struct {
+	Description string `yaml:"description"`
+	Args        []Arg  `yaml:"args"`
+}
+
+// Dirs 查找命令的目录，按优先级排列：项目目录中的命令覆盖用户级的同名命令
+func Dirs(workDir, configDir string) []string {
+	return []string{
+		filepath.Join(workDir, ".opencursor", DirName),
+		filepath.Join(configDir, DirName),
+	}
+}
+
+// Load 读取各目录中的 *.md 命令，同名时前面的目录优先，按名称排序
+func Load(dirs ...string) ([]*Command, error) {
+	byName := make(map[string]*Command)
+	for _, dir := range dirs {
+		files, err := filepath.Glob(filepath.Join(dir, "*.md"))
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range files {
+			name := strings.TrimSuffix(filepath.Base(path), ".md")
+			if _, exists := byName[name]; exists || !namePattern.MatchString(name) {
+				continue
+			}
+			command, err := Parse(path)
+			if err != nil {
+				return nil, err
+			}
+			byName[name] = command
+		}
+	}
+
+	commands := make([]*Command, 0, len(byName))
+	for _, command := range byName {
+		commands = append(commands, command)
+	}
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].Name < commands[j].Name
+	})
+	return commands, nil
+}
+
+// Find 按名称查找命令，名称可带前导的 /
+func Find(commands []*Command, name string) (*Command, bool) {
+	name = strings.TrimPrefix(name, "/")
+	for _, command := range commands {
+		if command.Name == name {
+			return command, true
+		}
+	}
+	return nil, false
+}
+
+// Parse 读取一个命令文件
+func Parse(path string) (*Command, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	command := &Command{
+		Name: strings.TrimSuffix(filepath.Base(path), ".md"),
+		Path: path,
+	}
+
+	body := bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	if bytes.HasPrefix(body, []byte("---\n")) {
+		rest := body[4:]
+		var header []byte
+		if bytes.HasPrefix(rest, []byte("---")) {
+			rest = rest[3:]
+		} else {
+			end := bytes.Index(rest, []byte("\n---"))
+			if end < 0 {
+				return nil, fmt.Errorf("%s: front matter is not closed with ---", path)
+			}
+			header, rest = rest[:end], rest[end+4:]
+		}
+		var meta frontMatter
+		if err := yaml.Unmarshal(header, &meta); err != nil {
+			return nil, fmt.Errorf("%s: invalid front matter: %w", path, err)
+		}
+		for _, arg := range meta.Args {
+			if arg.Name == "" {
+				return nil, fmt.Errorf("%s: every argument needs a name", path)
+			}
+		}
+		command.Description = meta.Description
+		command.Args = meta.Args
+		body = rest
+	}
+
+	command.Prompt = strings.TrimSpace(string(body))
+	if command.Prompt == "" {
+		return nil, fmt.Errorf("%s: the command has no prompt", path)
+	}
+	if command.Description == "" {
+		// 没有描述时取提示词的第一行
+		line, _, _ := strings.Cut(command.Prompt, "\n")
+		command.Description = strings.TrimSpace(strings.TrimLeft(line, "# "))
+	}
+	return command, nil
+}
+
+// Usage 命令的用法，如 /add-logging <package> [level]
+func (c *Command) Usage() string {
+	usage := "/" + c.Name
+	for _, arg := range c.Args {
+		if arg.Required {
+			usage += " <" + arg.Name + ">"
+		} else {
+			usage += " [" + arg.Name + "]"
+		}
+	}
+	return usage
+}
+
+// Expand 用调用参数展开提示词。参数可以按位置给出，也可以写成 name=value；
+// $ARGUMENTS 为全部参数，$1…$9 为位置参数，{{name}} 为命名参数。
+// 提示词中没有任何占位符时，参数追加在提示词末尾
+func (c *Command) Expand(args []string) (string, error) {
+	named := make(map[string]string)
+	declared := make(map[string]bool)
+	for _, arg := range c.Args {
+		declared[arg.Name] = true
+	}
+
+	var positional []string
+	for _, arg := range args {
+		if key, value, ok := strings.Cut(arg, "="); ok && declared[key] {
+			named[key] = value
+			continue
+		}
+		positional = append(positional, arg)
+	}
+
+	// 位置参数依次对应未以 name=value 给出的命名参数
+	next := 0
+	for _, arg := range c.Args {
+		if _, ok := named[arg.Name]; ok {
+			continue
+		}
+		if next < len(positional) {
+			named[arg.Name] = positional[next]
+			next++
+		} else if arg.Default != "" {
+			named[arg.Name] = arg.Default
+		} else if arg.Required {
+			return "", fmt.Errorf("missing argument %q, usage: %s", arg.Name, c.Usage())
+		}
+	}
+
+	prompt := c.Prompt
+	hasPlaceholders := strings.Contains(prompt, "$ARGUMENTS") || positionalPattern.MatchString(prompt) || namedPattern.MatchString(prompt)
+
+	var unknown string
+	prompt = namedPattern.ReplaceAllStringFunc(prompt, func(match string) string {
+		name := namedPattern.FindStringSubmatch(match)[1]
+		if !declared[name] && unknown == "" {
+			unknown = name
+		}
+		return named[name]
+	})
+	if unknown != "" {
+		return "", fmt.Errorf("%s: {{%s}} is not a declared argument", c.Path, unknown)
+	}
+	prompt = positionalPattern.ReplaceAllStringFunc(prompt, func(match string) string {
+		index, _ := strconv.Atoi(match[1:])
+		if index <= len(args) {
+			return args[index-1]
+		}
+		return ""
+	})
+	prompt = strings.ReplaceAll(prompt, "$ARGUMENTS", strings.Join(args, " "))
+
+	if !hasPlaceholders && len(args) > 0 {
+		prompt += "\n\n" + strings.Join(args, " ")
+	}
+	return prompt, nil
+}
+
+// SplitArgs 按空白拆分参数，支持单引号与双引号
+func SplitArgs(text string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	for _, r := range text {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
\ No newline at end of file
@@ -0,0 +1,195 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"openCursor/internal/client"
+	"openCursor/internal/embeddings"
+	"openCursor/internal/index"
+	"openCursor/internal/lsp"
+	"openCursor/internal/roles"
+	"openCursor/internal/schedule"
+	"openCursor/internal/tools"
+	"openCursor/internal/webhook"
+)
+
+// Config openCursor配置文件定义
+type Config struct {
+	// Model 默认使用的模型（环境变量 MODEL 优先）
+	Model string `yaml:"model,omitempty"`
+
+	// BaseURL 模型服务地址（环境变量 BASE_URL 优先）
+	BaseURL string `yaml:"base_url,omitempty"`
+
+	// Env 注入到每个命令类工具调用中的环境变量
+	Env map[string]string `yaml:"env,omitempty"`
+
+	// EnableTools 需要显式启用的可选工具（如 browser）
+	EnableTools []string `yaml:"enable_tools,omitempty"`
+
+	// BrowserPath headless浏览器可执行文件路径，为空时自动查找
+	BrowserPath string `yaml:"browser_path,omitempty"`
+
+	// Editor open_in_editor工具使用的编辑器命令（如 code 或 subl），为空时使用 $VISUAL、$EDITOR 或 VS Code
+	Editor string `yaml:"editor,omitempty"`
+
+	// Databases query_database工具可以只读访问的数据库：名称到 DSN（postgres://、mysql:// 或 sqlite:路径），
+	// DSN 中可以使用 ${VAR} 引用环境变量。配置后自动启用query_database工具
+	Databases map[string]string `yaml:"databases,omitempty"`
+
+	// RepoMap 是否自动将仓库地图附加到提示词中
+	RepoMap bool `yaml:"repo_map,omitempty"`
+
+	// RepoMapTokens 自动附加的仓库地图token预算
+	RepoMapTokens int `yaml:"repo_map_tokens,omitempty"`
+
+	// GitStatus 是否在会话的第一次查询中附加当前分支与未提交的改动
+	GitStatus bool `yaml:"git_status,omitempty"`
+
+	// ContextWindow 模型的上下文窗口（tokens），对话接近上限时自动摘要较早的轮次
+	ContextWindow int `yaml:"context_window,omitempty"`
+
+	// Pricing 按模型名设置价格（美元每百万tokens），用于 --max-cost 估算费用
+	Pricing map[string]client.Pricing `yaml:"pricing,omitempty"`
+
+	// RateLimits 按模型服务限制请求，键为 BASE_URL、其主机名或 default
+	RateLimits map[string]client.RateLimit `yaml:"rate_limits,omitempty"`
+
+	// Embeddings 嵌入服务，用于语义索引与仓库地图的排名
+	Embeddings embeddings.Config `yaml:"embeddings,omitempty"`
+
+	// Index 语义索引的向量存储
+	Index index.StoreConfig `yaml:"index,omitempty"`
+
+	// Approval 命令确认模式：auto 直接执行，ask 除安全只读命令外需确认，never 拒绝所有命令
+	Approval string `yaml:"approval,omitempty"`
+
+	// ApprovalPolicy 按工具类别（read、write、terminal）或工具名设置的确认策略（auto、ask 或 never），
+	// 工具名优先于类别，terminal 优先于 Approval
+	ApprovalPolicy map[string]string `yaml:"approval_policy,omitempty"`
+
+	// CIAllowCommands ci 命令中除只读命令、测试与构建检查外允许自动执行的命令前缀（如 make lint），其他命令自动拒绝
+	CIAllowCommands []string `yaml:"ci_allow_commands,omitempty"`
+
+	// SyntaxCheck 编辑文件后检查语法（Go、JSON、JavaScript、Python），错误随工具结果返回给模型
+	SyntaxCheck bool `yaml:"syntax_check,omitempty"`
+
+	// MaxReadFileSize read_file 可整个读取的文件大小上限（字节），更大的文件只能按行范围读取，默认 2 MB
+	MaxReadFileSize int64 `yaml:"max_read_file_size,omitempty"`
+
+	// MaxLineSize 逐行读取文件（read_file、search_replace、内置 grep）时单行的长度上限（字节），默认 16 MB
+	MaxLineSize int `yaml:"max_line_size,omitempty"`
+
+	// SecurityPolicy 编辑与删除文件的安全策略：allow_paths、deny_paths（glob）、allow_extensions、deny_extensions，
+	// 拒绝列表追加在内置列表（系统目录、可执行文件）之后，no_defaults 不使用内置列表
+	SecurityPolicy tools.SecurityPolicy `yaml:"security_policy,omitempty"`
+
+	// Workspaces 主工作目录外的工作区根目录（名称到路径，相对路径基于当前目录），
+	// 工具可用 workspace 参数选择，file_search 同时搜索所有根目录
+	Workspaces map[string]string `yaml:"workspaces,omitempty"`
+
+	// AutoCommit 是否将每次修改自动提交到 opencursor/<session> 影子分支
+	AutoCommit bool `yaml:"autocommit,omitempty"`
+
+	// GitHubToken GitHub 访问令牌（环境变量 GITHUB_TOKEN 优先）
+	GitHubToken string `yaml:"github_token,omitempty"`
+
+	// GitHubAPIURL GitHub API 地址，用于 GitHub Enterprise（环境变量 GITHUB_API_URL 优先）
+	GitHubAPIURL string `yaml:"github_api_url,omitempty"`
+
+	// GitLabToken GitLab 访问令牌（环境变量 GITLAB_TOKEN 优先）
+	GitLabToken string `yaml:"gitlab_token,omitempty"`
+
+	// GitLabURL 自建 GitLab 实例地址（环境变量 GITLAB_URL 优先）
+	GitLabURL string `yaml:"gitlab_url,omitempty"`
+
+	// Webhook 非交互运行结束时接收运行摘要的 webhook
+	Webhook webhook.Config `yaml:"webhook,omitempty"`
+
+	// SlackAppToken Slack 应用级令牌（xapp-），openCursor slack 用于建立 Socket Mode 连接（环境变量 SLACK_APP_TOKEN 优先）
+	SlackAppToken string `yaml:"slack_app_token,omitempty"`
+
+	// SlackBotToken Slack 机器人令牌（xoxb-），openCursor slack 用于发送消息（环境变量 SLACK_BOT_TOKEN 优先）
+	SlackBotToken string `yaml:"slack_bot_token,omitempty"`
+
+	// LanguageServers 按语言覆盖或新增语言服务器配置
+	LanguageServers map[string]lsp.ServerConfig `yaml:"language_servers,omitempty"`
+
+	// Role 默认使用的角色预设（--role 优先）
+	Role string `yaml:"role,omitempty"`
+
+	// Roles 按名称覆盖内置角色或新增角色
+	Roles map[string]roles.Role `yaml:"roles,omitempty"`
+
+	// Schedules openCursor schedule 执行的定时任务
+	Schedules []schedule.Job `yaml:"schedules,omitempty"`
+}
+
+// DefaultDir 获取默认配置目录 (~/.opencursor)
+func DefaultDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".opencursor"
+	}
+	return filepath.Join(home, ".opencursor")
+}
+
+// DefaultPath 获取默认配置文件路径
+func DefaultPath() string {
+	return filepath.Join(DefaultDir(), "config.yaml")
+}
+
+// SessionsDir 获取会话保存目录 (~/.opencursor/sessions)
+func SessionsDir() string {
+	return filepath.Join(DefaultDir(), "sessions")
+}
+
+// WorkflowsDir 获取工作流运行记录目录 (~/.opencursor/workflows)
+func WorkflowsDir() string {
+	return filepath.Join(DefaultDir(), "workflows")
+}
+
+// PlansDir 获取试运行修改计划的保存目录 (~/.opencursor/plans)
+func PlansDir() string {
+	return filepath.Join(DefaultDir(), "plans")
+}
+
+// RateLimitFor 查找模型服务地址的请求限制：依次匹配完整地址、主机名与 default
+func (c *Config) RateLimitFor(baseURL string) (client.RateLimit, bool) {
+	keys := []string{strings.TrimRight(baseURL, "/")}
+	if u, err := url.Parse(baseURL); err == nil && u.Host != "" {
+		keys = append(keys, u.Host, u.Hostname())
+	}
+	keys = append(keys, "default")
+	for _, key := range keys {
+		if limit, ok := c.RateLimits[key]; ok {
+			return limit, true
+		}
+	}
+	return client.RateLimit{}, false
+}
+
+// Load 从指定路径加载配置，文件不存在时返回空配置
+func Load(path string) (*Config, error) {
+	cfg := &Config{}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
\ No newline at end of file
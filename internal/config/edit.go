@@ -0,0 +1,430 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"openCursor/internal/embeddings"
+	"openCursor/internal/index"
+	"openCursor/internal/roles"
+	"openCursor/internal/tools"
+)
+
+// Setting 配置文件中的一项设置，键为点分隔的路径（如 rate_limits.default.max_concurrent）
+type Setting struct {
+	Key   string
+	Value string
+}
+
+// Validate 检查配置项的取值
+func (c *Config) Validate() error {
+	switch c.Approval {
+	case "", tools.ApprovalAuto, tools.ApprovalAsk, tools.ApprovalNever:
+	default:
+		return fmt.Errorf("approval must be auto, ask or never, got %q", c.Approval)
+	}
+	if err := tools.ValidateApprovalPolicy(c.ApprovalPolicy); err != nil {
+		return err
+	}
+	if err := tools.ValidateSecurityPolicy(c.SecurityPolicy); err != nil {
+		return err
+	}
+	if c.ContextWindow < 0 {
+		return fmt.Errorf("context_window must be positive")
+	}
+	if c.RepoMapTokens < 0 {
+		return fmt.Errorf("repo_map_tokens must be positive")
+	}
+	if c.BaseURL != "" && !strings.HasPrefix(c.BaseURL, "http://") && !strings.HasPrefix(c.BaseURL, "https://") {
+		return fmt.Errorf("base_url must start with http:// or https://")
+	}
+	for model, pricing := range c.Pricing {
+		if pricing.Input < 0 || pricing.Output < 0 {
+			return fmt.Errorf("pricing for %s must not be negative", model)
+		}
+	}
+	for key, limit := range c.RateLimits {
+		if limit.RequestsPerMinute < 0 || limit.TokensPerMinute < 0 || limit.MaxConcurrent < 0 {
+			return fmt.Errorf("rate_limits.%s must not be negative", key)
+		}
+	}
+	switch c.Embeddings.Provider {
+	case "", embeddings.ProviderOpenAI, embeddings.ProviderOllama, embeddings.ProviderLocal:
+	case embeddings.ProviderCommand:
+		if len(c.Embeddings.Command) == 0 {
+			return fmt.Errorf("embeddings.command is required for the command provider")
+		}
+	default:
+		return fmt.Errorf("embeddings.provider must be openai, ollama, command or local, got %q", c.Embeddings.Provider)
+	}
+	switch c.Index.Store {
+	case "", index.StoreMemory, index.StoreSQLite, index.StoreQdrant, index.StoreChroma:
+	default:
+		return fmt.Errorf("index.store must be memory, sqlite, qdrant or chroma, got %q", c.Index.Store)
+	}
+	if c.Index.URL != "" {
+		if u, err := url.Parse(c.Index.URL); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("index.url must be an absolute URL, got %q", c.Index.URL)
+		}
+	}
+	if c.Webhook.URL != "" {
+		if u, err := url.Parse(c.Webhook.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook.url must be an http:// or https:// URL, got %q", c.Webhook.URL)
+		}
+	}
+	for name, dsn := range c.Databases {
+		if !strings.HasPrefix(dsn, "postgres://") && !strings.HasPrefix(dsn, "postgresql://") &&
+			!strings.HasPrefix(dsn, "mysql://") && !strings.HasPrefix(dsn, "sqlite:") {
+			return fmt.Errorf("databases.%s must be a postgres://, mysql:// or sqlite: DSN", name)
+		}
+	}
+	if c.Role != "" {
+		if _, err := roles.Resolve(c.Role, c.Roles); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List 列出配置文件中设置的所有值，嵌套的项展开为点分隔的键
+func List(path string) ([]Setting, error) {
+	root, err := loadDocument(path)
+	if err != nil {
+		return nil, err
+	}
+	var settings []Setting
+	flatten(root, "", &settings)
+	return settings, nil
+}
+
+// Get 读取一项设置：标量直接返回，映射或列表返回YAML。没有设置时返回错误
+func Get(path, key string) (string, error) {
+	segments, err := splitKey(key)
+	if err != nil {
+		return "", err
+	}
+	root, err := loadDocument(path)
+	if err != nil {
+		return "", err
+	}
+	node := root
+	for _, segment := range segments {
+		node = child(node, segment)
+		if node == nil {
+			return "", fmt.Errorf("%s is not set in %s", key, path)
+		}
+	}
+	return formatValue(node)
+}
+
+// Set 修改一项设置并写回配置文件，其他内容与注释保持不变。
+// 值按YAML解析（如 true、42、[browser]），修改后的配置不合法时不写入
+func Set(path, key, value string) error {
+	segments, err := splitKey(key)
+	if err != nil {
+		return err
+	}
+	var parsed yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &parsed); err != nil {
+		return fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	valueNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: ""}
+	if len(parsed.Content) > 0 {
+		valueNode = parsed.Content[0]
+	}
+	if valueNode.Kind == yaml.MappingNode || valueNode.Kind == yaml.SequenceNode {
+		valueNode.Style = yaml.FlowStyle
+	}
+
+	root, err := loadDocument(path)
+	if err != nil {
+		return err
+	}
+	node := root
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		if node.Kind == yaml.SequenceNode {
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(node.Content) {
+				return fmt.Errorf("%s: index %q is out of range", key, segment)
+			}
+			if last {
+				keepComments(valueNode, node.Content[index])
+				node.Content[index] = valueNode
+				break
+			}
+			node = node.Content[index]
+			continue
+		}
+		if node.Kind != yaml.MappingNode {
+			return fmt.Errorf("%s: %s is not a mapping", key, strings.Join(segments[:i], "."))
+		}
+		next := child(node, segment)
+		if last {
+			if next != nil {
+				replaceChild(node, segment, valueNode)
+			} else {
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: segment}, valueNode)
+			}
+			break
+		}
+		if next == nil || (next.Kind == yaml.ScalarNode && next.Tag == "!!null") {
+			next = &yaml.Node{Kind: yaml.MappingNode}
+			if child(node, segment) != nil {
+				replaceChild(node, segment, next)
+			} else {
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: segment}, next)
+			}
+		}
+		node = next
+	}
+	return saveDocument(path, root)
+}
+
+// Unset 删除一项设置并写回配置文件，没有设置时返回错误
+func Unset(path, key string) error {
+	segments, err := splitKey(key)
+	if err != nil {
+		return err
+	}
+	root, err := loadDocument(path)
+	if err != nil {
+		return err
+	}
+	node := root
+	for _, segment := range segments[:len(segments)-1] {
+		node = child(node, segment)
+		if node == nil {
+			return fmt.Errorf("%s is not set in %s", key, path)
+		}
+	}
+	last := segments[len(segments)-1]
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == last {
+				node.Content = append(node.Content[:i], node.Content[i+2:]...)
+				return saveDocument(path, root)
+			}
+		}
+	case yaml.SequenceNode:
+		if index, err := strconv.Atoi(last); err == nil && index >= 0 && index < len(node.Content) {
+			node.Content = append(node.Content[:index], node.Content[index+1:]...)
+			return saveDocument(path, root)
+		}
+	}
+	return fmt.Errorf("%s is not set in %s", key, path)
+}
+
+// splitKey 拆分点分隔的键，包含点的映射键（如主机名）用双引号括起：rate_limits."api.deepseek.com".max_concurrent
+func splitKey(key string) ([]string, error) {
+	var segments []string
+	var current strings.Builder
+	quoted := false
+	for _, r := range key {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == '.' && !quoted:
+			segments = append(segments, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	segments = append(segments, current.String())
+	if quoted {
+		return nil, fmt.Errorf("invalid key %q: unterminated quote", key)
+	}
+	for _, segment := range segments {
+		if segment == "" {
+			return nil, fmt.Errorf("invalid key %q", key)
+		}
+	}
+	if _, ok := topLevelKeys()[segments[0]]; !ok {
+		return nil, fmt.Errorf("unknown setting %q (known: %s)", segments[0], strings.Join(Keys(), ", "))
+	}
+	return segments, nil
+}
+
+// Keys 配置文件支持的顶层键，按字母顺序
+func Keys() []string {
+	return sortedKeys(topLevelKeys())
+}
+
+// topLevelKeys 配置文件支持的顶层键，取自 Config 的yaml标签
+func topLevelKeys() map[string]bool {
+	keys := make(map[string]bool)
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if name != "" && name != "-" {
+			keys[name] = true
+		}
+	}
+	return keys
+}
+
+// loadDocument 读取配置文件的YAML节点树，文件不存在或为空时返回空映射
+func loadDocument(path string) (*yaml.Node, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &yaml.Node{Kind: yaml.MappingNode}, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return &yaml.Node{Kind: yaml.MappingNode}, nil
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("config file %s is not a YAML mapping", path)
+	}
+	return doc.Content[0], nil
+}
+
+// saveDocument 检查修改后的配置并写回文件。配置中可能有访问令牌，文件仅所有者可读写
+func saveDocument(path string, root *yaml.Node) error {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	data := buf.Bytes()
+	if len(root.Content) == 0 {
+		data = nil
+	}
+
+	cfg := &Config{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && len(data) > 0 {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// child 映射中键对应的值或列表中下标对应的元素
+func child(node *yaml.Node, segment string) *yaml.Node {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == segment {
+				return node.Content[i+1]
+			}
+		}
+	case yaml.SequenceNode:
+		if index, err := strconv.Atoi(segment); err == nil && index >= 0 && index < len(node.Content) {
+			return node.Content[index]
+		}
+	}
+	return nil
+}
+
+// replaceChild 替换映射中键对应的值，保留原值上的注释
+func replaceChild(node *yaml.Node, segment string, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == segment {
+			keepComments(value, node.Content[i+1])
+			node.Content[i+1] = value
+			return
+		}
+	}
+}
+
+// keepComments 将被替换节点的注释移到新节点上
+func keepComments(value, old *yaml.Node) {
+	value.HeadComment = old.HeadComment
+	value.LineComment = old.LineComment
+	value.FootComment = old.FootComment
+}
+
+// flatten 将映射展开为点分隔的键，列表与标量作为值
+func flatten(node *yaml.Node, prefix string, settings *[]Setting) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if strings.Contains(key, ".") {
+				key = `"` + key + `"`
+			}
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			flatten(node.Content[i+1], key, settings)
+		}
+		return
+	}
+	value, err := formatValue(node)
+	if err != nil {
+		return
+	}
+	if node.Kind != yaml.ScalarNode {
+		value = inlineValue(node)
+	}
+	*settings = append(*settings, Setting{Key: prefix, Value: value})
+}
+
+// formatValue 标量返回其值，其他节点返回YAML
+func formatValue(node *yaml.Node) (string, error) {
+	if node.Kind == yaml.ScalarNode {
+		return node.Value, nil
+	}
+	data, err := yaml.Marshal(node)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode value: %w", err)
+	}
+	return strings.TrimRight(string(data), "\n"), nil
+}
+
+// inlineValue 以单行的YAML流式写法表示列表等节点
+func inlineValue(node *yaml.Node) string {
+	copied := *node
+	copied.Style = yaml.FlowStyle
+	data, err := yaml.Marshal(&copied)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// sortedKeys 按字母顺序返回键
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
\ No newline at end of file
@@ -0,0 +1,65 @@
+package credentials
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/zalando/go-keyring"
+)
+
+// service 系统钥匙串中的服务名
+const service = "openCursor"
+
+// ErrNotFound 钥匙串中没有保存该服务地址的密钥
+var ErrNotFound = errors.New("no API key stored in the keychain")
+
+// Account 服务地址在钥匙串中对应的账户名：主机名加路径，不同的模型服务分别保存
+func Account(baseURL string) string {
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if parsed, err := url.Parse(baseURL); err == nil && parsed.Host != "" {
+		return strings.TrimRight(parsed.Host+parsed.Path, "/")
+	}
+	return baseURL
+}
+
+// Save 将API密钥保存到系统钥匙串（macOS Keychain、Linux Secret Service、Windows 凭据管理器）
+func Save(baseURL, apiKey string) error {
+	if err := keyring.Set(service, Account(baseURL), apiKey); err != nil {
+		return fmt.Errorf("failed to store the API key in the keychain: %w", err)
+	}
+	return nil
+}
+
+// Load 从系统钥匙串读取API密钥，没有保存时返回 ErrNotFound
+func Load(baseURL string) (string, error) {
+	apiKey, err := keyring.Get(service, Account(baseURL))
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read the API key from the keychain: %w", err)
+	}
+	return apiKey, nil
+}
+
+// Delete 删除钥匙串中保存的API密钥，没有保存时返回 ErrNotFound
+func Delete(baseURL string) error {
+	err := keyring.Delete(service, Account(baseURL))
+	if errors.Is(err, keyring.ErrNotFound) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete the API key from the keychain: %w", err)
+	}
+	return nil
+}
+
+// Mask 遮盖密钥，只显示开头与结尾用于确认
+func Mask(apiKey string) string {
+	if len(apiKey) <= 8 {
+		return strings.Repeat("*", len(apiKey))
+	}
+	return apiKey[:3] + strings.Repeat("*", 6) + apiKey[len(apiKey)-4:]
+}
\ No newline at end of file
@@ -0,0 +1,244 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"openCursor/internal/schedule"
+)
+
+// DefaultPollInterval 检查队列的默认间隔
+const DefaultPollInterval = 5 * time.Second
+
+// 队列目录中的文件
+const (
+	AuditLogName = "audit.log"  // 审计日志，每行一个JSON事件
+	LockName     = "daemon.pid" // 守护进程运行期间存在，防止两个守护进程处理同一队列
+)
+
+// Options 守护进程的选项
+type Options struct {
+	Workers      int           // 同时运行的任务数
+	PollInterval time.Duration // 检查队列的间隔
+	WorkDir      string        // 任务未指定工作目录时使用
+	Checkpoints  bool          // 为每个任务加上 --autocommit，逐步提交到影子分支
+	Run          schedule.RunOptions
+	Log          io.Writer // 接收守护进程事件
+}
+
+// Daemon 从目录队列中领取任务并以非交互子进程方式运行
+type Daemon struct {
+	queue *Queue
+	opts  Options
+
+	auditMu sync.Mutex
+	audit   *os.File
+}
+
+// New 创建守护进程
+func New(queue *Queue, opts Options) *Daemon {
+	if opts.Workers < 1 {
+		opts.Workers = 1
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+	if opts.Log == nil {
+		opts.Log = io.Discard
+	}
+	return &Daemon{queue: queue, opts: opts}
+}
+
+// Start 处理队列直到ctx取消，并等待正在运行的任务结束（取消时任务进程被终止）
+func (d *Daemon) Start(ctx context.Context) error {
+	if err := d.queue.Init(); err != nil {
+		return err
+	}
+	lock := filepath.Join(d.queue.Dir, LockName)
+	lockFile, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("another daemon is using %s (remove %s if it is no longer running)", d.queue.Dir, lock)
+		}
+		return fmt.Errorf("failed to create lock file: %w", err)
+	}
+	fmt.Fprintf(lockFile, "%d\n", os.Getpid())
+	lockFile.Close()
+	defer os.Remove(lock)
+
+	audit, err := os.OpenFile(filepath.Join(d.queue.Dir, AuditLogName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer audit.Close()
+	d.audit = audit
+
+	d.recoverStale()
+	d.event("daemon_started", "", map[string]interface{}{"pid": os.Getpid(), "workers": d.opts.Workers, "queue": d.queue.Dir})
+	fmt.Fprintf(d.opts.Log, "[daemon] watching %s with %d worker(s)\n", filepath.Join(d.queue.Dir, StatePending), d.opts.Workers)
+
+	slots := make(chan struct{}, d.opts.Workers)
+	var wg sync.WaitGroup
+	defer func() {
+		wg.Wait()
+		d.event("daemon_stopped", "", nil)
+	}()
+
+	for {
+		pending, err := d.queue.List(StatePending)
+		if err != nil {
+			fmt.Fprintf(d.opts.Log, "[daemon] failed to read the queue: %v\n", err)
+		}
+		for _, entry := range pending {
+			// 没有空闲的工作者时等到下一轮（只有这里占用工作者，检查后发送不会阻塞）
+			if len(slots) == cap(slots) {
+				break
+			}
+			claimed, ok := d.queue.Claim(entry)
+			if !ok {
+				continue
+			}
+			slots <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-slots }()
+				d.process(ctx, claimed)
+			}()
+		}
+
+		select {
+		case <-ctx.Done():
+			fmt.Fprintf(d.opts.Log, "[daemon] stopping, waiting for running tasks\n")
+			return nil
+		case <-time.After(d.opts.PollInterval):
+		}
+	}
+}
+
+// recoverStale 上次守护进程退出时仍在 running 中的任务标记为失败，不自动重跑
+func (d *Daemon) recoverStale() {
+	stale, _ := d.queue.List(StateRunning)
+	for _, entry := range stale {
+		task, _ := ReadTask(entry)
+		task.ID = entry.ID
+		message := "interrupted: the daemon stopped while the task was running; move it back to pending to retry"
+		if _, err := d.queue.Finish(entry, Result{Task: task, Error: message}); err == nil {
+			d.event("task_failed", entry.ID, map[string]interface{}{"error": message})
+			fmt.Fprintf(d.opts.Log, "[daemon] %s: %s\n", entry.ID, message)
+		}
+	}
+}
+
+// process 运行一个已领取的任务并记录结果
+func (d *Daemon) process(ctx context.Context, entry Entry) {
+	task, err := ReadTask(entry)
+	if err != nil {
+		d.finish(entry, Result{Task: task, Error: err.Error()})
+		return
+	}
+
+	workDir := task.WorkDir
+	if workDir == "" {
+		workDir = d.opts.WorkDir
+	}
+	if workDir == "~" || strings.HasPrefix(workDir, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			workDir = filepath.Join(home, workDir[1:])
+		}
+	}
+	args := append([]string{}, task.Args...)
+	if d.opts.Checkpoints && !contains(args, "--autocommit") {
+		args = append(args, "--autocommit")
+	}
+	job := schedule.Job{
+		Name:    task.ID,
+		Prompt:  task.Prompt,
+		WorkDir: workDir,
+		Args:    args,
+		Timeout: task.Timeout,
+	}
+
+	d.event("task_started", task.ID, map[string]interface{}{"prompt": task.Prompt, "workdir": workDir, "args": args})
+	fmt.Fprintf(d.opts.Log, "[daemon] %s: started\n", task.ID)
+
+	record, err := schedule.Run(ctx, job, d.opts.Run)
+	result := Result{Task: task, Record: record}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	d.finish(entry, result)
+}
+
+// finish 写入任务结果并记录审计事件
+func (d *Daemon) finish(entry Entry, result Result) {
+	state, err := d.queue.Finish(entry, result)
+	if err != nil {
+		fmt.Fprintf(d.opts.Log, "[daemon] %s: failed to record the result: %v\n", entry.ID, err)
+		return
+	}
+
+	details := map[string]interface{}{"state": state}
+	if result.Error != "" {
+		details["error"] = result.Error
+	}
+	if record := result.Record; record != nil {
+		details["exit_code"] = record.ExitCode
+		details["duration"] = record.FinishedAt.Sub(record.StartedAt).Round(time.Second).String()
+		details["changed_files"] = record.ChangedFiles
+		details["artifacts"] = record.ArtifactsDir
+		if record.Error != "" {
+			details["error"] = record.Error
+		}
+	}
+	event := "task_finished"
+	if state == StateFailed {
+		event = "task_failed"
+	}
+	d.event(event, entry.ID, details)
+
+	if result.Record != nil {
+		fmt.Fprintf(d.opts.Log, "[daemon] %s: %s\n", entry.ID, schedule.Summary(result.Record))
+	} else {
+		fmt.Fprintf(d.opts.Log, "[daemon] %s: failed: %s\n", entry.ID, result.Error)
+	}
+}
+
+// event 向审计日志追加一个事件
+func (d *Daemon) event(name, task string, details map[string]interface{}) {
+	entry := map[string]interface{}{
+		"time":  time.Now().Format(time.RFC3339),
+		"event": name,
+	}
+	if task != "" {
+		entry["task"] = task
+	}
+	for key, value := range details {
+		entry[key] = value
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	d.auditMu.Lock()
+	defer d.auditMu.Unlock()
+	d.audit.Write(append(data, '\n'))
+}
+
+// contains 字符串切片中是否包含 s
+func contains(items []string, s string) bool {
+	for _, item := range items {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
\ No newline at end of file
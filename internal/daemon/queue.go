@@ -0,0 +1,202 @@
+package daemon
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"openCursor/internal/schedule"
+)
+
+// 队列目录中的子目录，任务文件依次在其间移动
+const (
+	StatePending = "pending" // 等待处理
+	StateRunning = "running" // 已被守护进程领取
+	StateDone    = "done"    // 运行成功
+	StateFailed  = "failed"  // 运行失败、超时或被中断
+)
+
+// states 按处理顺序排列的任务状态
+var states = []string{StatePending, StateRunning, StateDone, StateFailed}
+
+// Task 队列中的一个任务。pending 目录中的 .json 文件按此格式解析，
+// .md 与 .txt 文件的全部内容作为提示词
+type Task struct {
+	ID        string    `json:"id"`
+	Prompt    string    `json:"prompt"`
+	WorkDir   string    `json:"workdir,omitempty"`
+	Args      []string  `json:"args,omitempty"`    // 追加给 openCursor 的参数，如 [--repo-map]
+	Timeout   string    `json:"timeout,omitempty"` // 如 "45m"，默认30分钟
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+// Result 处理完的任务，保存在 done 或 failed 目录中
+type Result struct {
+	Task   Task             `json:"task"`
+	Record *schedule.Record `json:"record,omitempty"`
+	Error  string           `json:"error,omitempty"`
+}
+
+// Queue 基于目录的任务队列
+type Queue struct {
+	Dir string
+}
+
+// Init 创建队列的各个子目录
+func (q *Queue) Init() error {
+	for _, state := range states {
+		if err := os.MkdirAll(filepath.Join(q.Dir, state), 0755); err != nil {
+			return fmt.Errorf("failed to create queue directory: %w", err)
+		}
+	}
+	return nil
+}
+
+// Submit 将任务写入 pending 目录，返回任务ID
+func (q *Queue) Submit(task Task) (string, error) {
+	if strings.TrimSpace(task.Prompt) == "" {
+		return "", fmt.Errorf("prompt is required")
+	}
+	if err := q.Init(); err != nil {
+		return "", err
+	}
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = time.Now()
+	}
+	if task.ID == "" {
+		suffix := make([]byte, 3)
+		rand.Read(suffix)
+		task.ID = task.CreatedAt.Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+	}
+	data, err := json.MarshalIndent(task, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	// 先写临时文件再重命名，守护进程不会读到写了一半的任务
+	path := filepath.Join(q.Dir, StatePending, task.ID+".json")
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write task: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return "", fmt.Errorf("failed to write task: %w", err)
+	}
+	return task.ID, nil
+}
+
+// Entry 某个状态目录中的一个任务文件
+type Entry struct {
+	State    string
+	Path     string
+	ID       string
+	Modified time.Time
+}
+
+// List 列出某个状态目录中的任务文件，按修改时间与文件名排序
+func (q *Queue) List(state string) ([]Entry, error) {
+	dir := filepath.Join(q.Dir, state)
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || strings.HasPrefix(name, ".") || !isTaskFile(name) {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, Entry{
+			State:    state,
+			Path:     filepath.Join(dir, name),
+			ID:       strings.TrimSuffix(name, filepath.Ext(name)),
+			Modified: info.ModTime(),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].Modified.Equal(entries[j].Modified) {
+			return entries[i].Modified.Before(entries[j].Modified)
+		}
+		return entries[i].ID < entries[j].ID
+	})
+	return entries, nil
+}
+
+// isTaskFile 是否为任务文件（其他文件，如写入中的 .tmp，忽略）
+func isTaskFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json", ".md", ".txt":
+		return true
+	}
+	return false
+}
+
+// Claim 将任务从 pending 移到 running。另一个进程已领取时返回 false
+func (q *Queue) Claim(entry Entry) (Entry, bool) {
+	claimed := entry
+	claimed.State = StateRunning
+	claimed.Path = filepath.Join(q.Dir, StateRunning, filepath.Base(entry.Path))
+	if err := os.Rename(entry.Path, claimed.Path); err != nil {
+		return entry, false
+	}
+	return claimed, true
+}
+
+// ReadTask 读取任务文件
+func ReadTask(entry Entry) (Task, error) {
+	data, err := os.ReadFile(entry.Path)
+	if err != nil {
+		return Task{}, err
+	}
+
+	var task Task
+	if strings.EqualFold(filepath.Ext(entry.Path), ".json") {
+		if err := json.Unmarshal(data, &task); err != nil {
+			return Task{}, fmt.Errorf("invalid task file %s: %w", filepath.Base(entry.Path), err)
+		}
+	} else {
+		task.Prompt = string(data)
+	}
+	task.ID = entry.ID
+	task.Prompt = strings.TrimSpace(task.Prompt)
+	if task.Prompt == "" {
+		return task, fmt.Errorf("task %s has no prompt", entry.ID)
+	}
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = entry.Modified
+	}
+	return task, nil
+}
+
+// Finish 写入处理结果并移除 running 中的任务文件
+func (q *Queue) Finish(entry Entry, result Result) (string, error) {
+	state := StateDone
+	if result.Error != "" || result.Record == nil || result.Record.Error != "" {
+		state = StateFailed
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(q.Dir, state, entry.ID+".json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write task result: %w", err)
+	}
+	if err := os.Remove(entry.Path); err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	return state, nil
+}
\ No newline at end of file
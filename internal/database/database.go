@@ -0,0 +1,321 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"  // Postgres 驱动
+	_ "modernc.org/sqlite" // 纯Go实现的SQLite驱动，无需cgo
+)
+
+// 支持的数据库
+const (
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql"
+	DriverSQLite   = "sqlite"
+)
+
+// 查询结果的上限
+const (
+	DefaultRowLimit = 100
+	MaxRowLimit     = 1000
+	maxValueLength  = 500 // 单个值的最大长度，超出部分截断
+)
+
+// DB 只读的数据库连接：每个查询都在只读事务中执行并回滚
+type DB struct {
+	Driver string
+	db     *sql.DB
+}
+
+// Open 按 DSN 打开数据库：postgres://…、mysql://…（URL 或 go-sql-driver 格式）、sqlite:路径 或 sqlite://路径。
+// DSN 中的 ${VAR} 替换为环境变量，SQLite 的相对路径基于 workDir
+func Open(dsn, workDir string) (*DB, error) {
+	driver, source, err := parseDSN(os.ExpandEnv(dsn), workDir)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open(driver, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s database: %w", driver, err)
+	}
+	db.SetMaxOpenConns(1)
+	return &DB{Driver: driver, db: db}, nil
+}
+
+// Close 关闭连接
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// parseDSN 识别驱动并转换为驱动使用的数据源
+func parseDSN(dsn, workDir string) (string, string, error) {
+	switch {
+	case strings.HasPrefix(dsn, "postgres://"), strings.HasPrefix(dsn, "postgresql://"):
+		return DriverPostgres, dsn, nil
+
+	case strings.HasPrefix(dsn, "mysql://"):
+		source := strings.TrimPrefix(dsn, "mysql://")
+		if strings.Contains(source, "@tcp(") || strings.Contains(source, "@unix(") {
+			return mysqlSource(source)
+		}
+		// URL 格式转换为 user:pass@tcp(host:port)/db?params
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid mysql DSN: %w", err)
+		}
+		host := u.Host
+		if u.Port() == "" {
+			host += ":3306"
+		}
+		source = "tcp(" + host + ")" + u.Path
+		if u.User != nil {
+			credentials := u.User.Username()
+			if password, ok := u.User.Password(); ok {
+				credentials += ":" + password
+			}
+			source = credentials + "@" + source
+		}
+		if u.RawQuery != "" {
+			source += "?" + u.RawQuery
+		}
+		return mysqlSource(source)
+
+	case strings.HasPrefix(dsn, "sqlite:"):
+		path := strings.TrimPrefix(strings.TrimPrefix(dsn, "sqlite:"), "//")
+		if path == "" {
+			return "", "", fmt.Errorf("sqlite DSN needs a path, e.g. sqlite:data/app.db")
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(workDir, path)
+		}
+		if _, err := os.Stat(path); err != nil {
+			return "", "", fmt.Errorf("sqlite database not found: %s", path)
+		}
+		// 以只读方式打开，query_only 同时拒绝任何写入
+		return DriverSQLite, "file:" + path + "?mode=ro&_pragma=query_only(1)", nil
+	}
+	return "", "", fmt.Errorf("unsupported DSN: expected postgres://, mysql:// or sqlite:")
+}
+
+// mysqlSource 校验 go-sql-driver 格式的数据源，并总是关闭 multiStatements
+func mysqlSource(source string) (string, string, error) {
+	config, err := mysql.ParseDSN(source)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid mysql DSN: %w", err)
+	}
+	config.MultiStatements = false
+	return DriverMySQL, config.FormatDSN(), nil
+}
+
+// ResultSet 查询结果
+type ResultSet struct {
+	Columns   []string        `json:"columns"`
+	Rows      [][]interface{} `json:"rows"`
+	RowCount  int             `json:"row_count"`
+	Truncated bool            `json:"truncated,omitempty"` // 结果超过行数上限
+}
+
+// Query 在只读事务中执行一条只读语句，最多返回 limit 行
+func (d *DB) Query(ctx context.Context, query string, limit int, args ...interface{}) (*ResultSet, error) {
+	if err := CheckReadOnly(d.Driver, query); err != nil {
+		return nil, err
+	}
+	return d.query(ctx, query, limit, args...)
+}
+
+// query 在只读事务中执行语句
+func (d *DB) query(ctx context.Context, query string, limit int, args ...interface{}) (*ResultSet, error) {
+	if limit <= 0 {
+		limit = DefaultRowLimit
+	}
+	if limit > MaxRowLimit {
+		limit = MaxRowLimit
+	}
+	// SQLite 已以只读方式打开，驱动不支持只读事务选项
+	opts := &sql.TxOptions{ReadOnly: d.Driver != DriverSQLite}
+	tx, err := d.db.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the %s database: %w", d.Driver, err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ResultSet{Columns: columns, Rows: [][]interface{}{}}
+	for rows.Next() {
+		if len(result.Rows) == limit {
+			result.Truncated = true
+			break
+		}
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		for i, value := range values {
+			values[i] = jsonValue(value)
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	result.RowCount = len(result.Rows)
+	return result, nil
+}
+
+// jsonValue 转换为可以序列化为JSON的值，过长的文本截断，二进制数据只报告大小
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []byte:
+		if !utf8.Valid(v) {
+			return fmt.Sprintf("<%d bytes>", len(v))
+		}
+		return truncate(string(v))
+	case string:
+		return truncate(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+	return value
+}
+
+// truncate 截断过长的值
+func truncate(s string) string {
+	if len(s) <= maxValueLength {
+		return s
+	}
+	cut := maxValueLength
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + fmt.Sprintf("… (%d bytes)", len(s))
+}
+
+// readOnlyKeywords 允许执行的语句的第一个关键字
+var readOnlyKeywords = map[string]bool{
+	"select": true, "with": true, "explain": true, "show": true, "describe": true, "desc": true,
+	"values": true, "table": true,
+}
+
+// CheckReadOnly 只允许单条查询语句（SELECT、WITH、EXPLAIN、SHOW 等）。写入由只读事务拒绝，
+// 这里拒绝多条语句，避免后面的语句结束只读事务。拆分时宁可多拆：无法确定时按多条语句拒绝
+func CheckReadOnly(driver, query string) error {
+	// 只有 MySQL 的字符串默认支持反斜杠转义
+	statements := splitStatements(query, driver == DriverMySQL)
+	if len(statements) == 0 {
+		return fmt.Errorf("sql is empty")
+	}
+	if len(statements) > 1 {
+		return fmt.Errorf("only a single statement can be run at a time")
+	}
+	words := strings.FieldsFunc(statements[0], func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+	})
+	if len(words) == 0 {
+		return fmt.Errorf("only read-only queries are allowed (SELECT, WITH, EXPLAIN, SHOW, DESCRIBE)")
+	}
+	keyword := strings.ToLower(words[0])
+	if !readOnlyKeywords[keyword] {
+		return fmt.Errorf("only read-only queries are allowed (SELECT, WITH, EXPLAIN, SHOW, DESCRIBE), not %s", strings.ToUpper(keyword))
+	}
+	return nil
+}
+
+// splitStatements 按分号拆分语句，跳过字符串、引号标识符、-- 与 /* */ 注释以及 Postgres 的 $tag$ 字符串；
+// 返回去掉注释后非空的语句
+func splitStatements(query string, backslashEscapes bool) []string {
+	var statements []string
+	var current strings.Builder
+	flush := func() {
+		if s := strings.TrimSpace(current.String()); s != "" {
+			statements = append(statements, s)
+		}
+		current.Reset()
+	}
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end
+			}
+			current.WriteByte(' ')
+			continue
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end + 3
+			}
+			current.WriteByte(' ')
+			continue
+		case c == '\'' || c == '"' || c == '`':
+			end := i + 1
+			for end < len(query) {
+				if query[end] == '\\' && c == '\'' && backslashEscapes {
+					end += 2
+					continue
+				}
+				if query[end] == c {
+					break
+				}
+				end++
+			}
+			current.WriteString(query[i:min(end+1, len(query))])
+			i = end
+			continue
+		case c == '$':
+			// $tag$ ... $tag$
+			if tagEnd := strings.IndexByte(query[i+1:], '$'); tagEnd >= 0 && isDollarTag(query[i+1:i+1+tagEnd]) {
+				tag := query[i : i+tagEnd+2]
+				if end := strings.Index(query[i+len(tag):], tag); end >= 0 {
+					stop := i + len(tag) + end + len(tag)
+					current.WriteString(query[i:stop])
+					i = stop - 1
+					continue
+				}
+			}
+		case c == ';':
+			flush()
+			continue
+		}
+		current.WriteByte(c)
+	}
+	flush()
+	return statements
+}
+
+// isDollarTag $ 之间是否为合法的标签（可以为空）
+func isDollarTag(tag string) bool {
+	for i, r := range tag {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
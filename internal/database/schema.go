@@ -0,0 +1,271 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// TableInfo 表或视图
+type TableInfo struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // table 或 view
+}
+
+// Column 表的列
+type Column struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Nullable   bool   `json:"nullable"`
+	Default    string `json:"default,omitempty"`
+	PrimaryKey bool   `json:"primary_key,omitempty"`
+}
+
+// Index 表的索引
+type Index struct {
+	Name    string   `json:"name"`
+	Unique  bool     `json:"unique,omitempty"`
+	Columns []string `json:"columns"`
+}
+
+// ForeignKey 表的外键
+type ForeignKey struct {
+	Name       string `json:"name,omitempty"`
+	Definition string `json:"definition"` // 如 FOREIGN KEY (user_id) REFERENCES users(id)
+}
+
+// TableSchema 表的结构
+type TableSchema struct {
+	Name        string       `json:"name"`
+	Columns     []Column     `json:"columns"`
+	Indexes     []Index      `json:"indexes,omitempty"`
+	ForeignKeys []ForeignKey `json:"foreign_keys,omitempty"`
+}
+
+// ListTables 列出用户的表与视图：Postgres 为系统 schema 以外的表（public 以外的带 schema 前缀），
+// MySQL 为当前数据库的表
+func (d *DB) ListTables(ctx context.Context) ([]TableInfo, error) {
+	var query string
+	switch d.Driver {
+	case DriverPostgres:
+		query = `SELECT CASE WHEN table_schema = 'public' THEN table_name ELSE table_schema || '.' || table_name END,
+	CASE WHEN table_type = 'VIEW' THEN 'view' ELSE 'table' END
+FROM information_schema.tables
+WHERE table_schema NOT IN ('pg_catalog', 'information_schema')
+ORDER BY table_schema <> 'public', table_schema, table_name`
+	case DriverMySQL:
+		query = `SELECT table_name, CASE WHEN table_type = 'VIEW' THEN 'view' ELSE 'table' END
+FROM information_schema.tables WHERE table_schema = DATABASE() ORDER BY table_name`
+	default:
+		query = `SELECT name, type FROM sqlite_master
+WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%' ORDER BY name`
+	}
+	result, err := d.query(ctx, query, MaxRowLimit)
+	if err != nil {
+		return nil, err
+	}
+	tables := make([]TableInfo, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		tables = append(tables, TableInfo{Name: text(row[0]), Type: text(row[1])})
+	}
+	return tables, nil
+}
+
+// Describe 查询表的列、索引与外键
+func (d *DB) Describe(ctx context.Context, table string) (*TableSchema, error) {
+	schema := &TableSchema{Name: table}
+	var err error
+	switch d.Driver {
+	case DriverPostgres:
+		err = d.describePostgres(ctx, schema)
+	case DriverMySQL:
+		err = d.describeMySQL(ctx, schema)
+	default:
+		err = d.describeSQLite(ctx, schema)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(schema.Columns) == 0 {
+		return nil, fmt.Errorf("table not found: %s", table)
+	}
+	return schema, nil
+}
+
+// describePostgres Postgres 的表结构，表名可以带 schema 前缀，默认为 public
+func (d *DB) describePostgres(ctx context.Context, schema *TableSchema) error {
+	namespace, table := "public", schema.Name
+	if i := strings.LastIndexByte(table, '.'); i >= 0 {
+		namespace, table = table[:i], table[i+1:]
+	}
+
+	columns, err := d.query(ctx, `SELECT c.column_name, c.data_type, c.is_nullable = 'YES', COALESCE(c.column_default, ''),
+	EXISTS (SELECT 1 FROM information_schema.table_constraints tc
+		JOIN information_schema.key_column_usage k ON k.constraint_name = tc.constraint_name AND k.table_schema = tc.table_schema
+		WHERE tc.constraint_type = 'PRIMARY KEY' AND tc.table_schema = c.table_schema
+			AND tc.table_name = c.table_name AND k.column_name = c.column_name)
+FROM information_schema.columns c
+WHERE c.table_schema = $1 AND c.table_name = $2 ORDER BY c.ordinal_position`, MaxRowLimit, namespace, table)
+	if err != nil {
+		return err
+	}
+	for _, row := range columns.Rows {
+		schema.Columns = append(schema.Columns, Column{
+			Name: text(row[0]), Type: text(row[1]), Nullable: truthy(row[2]), Default: text(row[3]), PrimaryKey: truthy(row[4]),
+		})
+	}
+
+	indexes, err := d.query(ctx, `SELECT i.relname, ix.indisunique, a.attname
+FROM pg_index ix
+JOIN pg_class t ON t.oid = ix.indrelid
+JOIN pg_namespace n ON n.oid = t.relnamespace
+JOIN pg_class i ON i.oid = ix.indexrelid
+JOIN LATERAL unnest(ix.indkey) WITH ORDINALITY AS k(attnum, position) ON true
+JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+WHERE n.nspname = $1 AND t.relname = $2 ORDER BY i.relname, k.position`, MaxRowLimit, namespace, table)
+	if err != nil {
+		return err
+	}
+	schema.Indexes = groupIndexes(indexes.Rows)
+
+	foreignKeys, err := d.query(ctx, `SELECT con.conname, pg_get_constraintdef(con.oid)
+FROM pg_constraint con
+JOIN pg_class t ON t.oid = con.conrelid
+JOIN pg_namespace n ON n.oid = t.relnamespace
+WHERE con.contype = 'f' AND n.nspname = $1 AND t.relname = $2 ORDER BY con.conname`, MaxRowLimit, namespace, table)
+	if err != nil {
+		return err
+	}
+	for _, row := range foreignKeys.Rows {
+		schema.ForeignKeys = append(schema.ForeignKeys, ForeignKey{Name: text(row[0]), Definition: text(row[1])})
+	}
+	return nil
+}
+
+// describeMySQL MySQL 当前数据库中的表结构
+func (d *DB) describeMySQL(ctx context.Context, schema *TableSchema) error {
+	columns, err := d.query(ctx, `SELECT column_name, column_type, is_nullable = 'YES', COALESCE(column_default, ''), column_key = 'PRI'
+FROM information_schema.columns
+WHERE table_schema = DATABASE() AND table_name = ? ORDER BY ordinal_position`, MaxRowLimit, schema.Name)
+	if err != nil {
+		return err
+	}
+	for _, row := range columns.Rows {
+		schema.Columns = append(schema.Columns, Column{
+			Name: text(row[0]), Type: text(row[1]), Nullable: truthy(row[2]), Default: text(row[3]), PrimaryKey: truthy(row[4]),
+		})
+	}
+
+	indexes, err := d.query(ctx, `SELECT index_name, non_unique = 0, column_name
+FROM information_schema.statistics
+WHERE table_schema = DATABASE() AND table_name = ? ORDER BY index_name, seq_in_index`, MaxRowLimit, schema.Name)
+	if err != nil {
+		return err
+	}
+	schema.Indexes = groupIndexes(indexes.Rows)
+
+	foreignKeys, err := d.query(ctx, `SELECT constraint_name, column_name, referenced_table_name, referenced_column_name
+FROM information_schema.key_column_usage
+WHERE table_schema = DATABASE() AND table_name = ? AND referenced_table_name IS NOT NULL
+ORDER BY constraint_name, ordinal_position`, MaxRowLimit, schema.Name)
+	if err != nil {
+		return err
+	}
+	schema.ForeignKeys = groupForeignKeys(foreignKeys.Rows)
+	return nil
+}
+
+// describeSQLite SQLite 的表结构
+func (d *DB) describeSQLite(ctx context.Context, schema *TableSchema) error {
+	columns, err := d.query(ctx, `SELECT name, type, "notnull" = 0, COALESCE(dflt_value, ''), pk > 0
+FROM pragma_table_info(?) ORDER BY cid`, MaxRowLimit, schema.Name)
+	if err != nil {
+		return err
+	}
+	for _, row := range columns.Rows {
+		schema.Columns = append(schema.Columns, Column{
+			Name: text(row[0]), Type: text(row[1]), Nullable: truthy(row[2]), Default: text(row[3]), PrimaryKey: truthy(row[4]),
+		})
+	}
+
+	indexes, err := d.query(ctx, `SELECT il.name, il."unique", ii.name
+FROM pragma_index_list(?) il, pragma_index_info(il.name) ii
+ORDER BY il.name, ii.seqno`, MaxRowLimit, schema.Name)
+	if err != nil {
+		return err
+	}
+	schema.Indexes = groupIndexes(indexes.Rows)
+
+	foreignKeys, err := d.query(ctx, `SELECT id, "from", "table", "to"
+FROM pragma_foreign_key_list(?) ORDER BY id, seq`, MaxRowLimit, schema.Name)
+	if err != nil {
+		return err
+	}
+	schema.ForeignKeys = groupForeignKeys(foreignKeys.Rows)
+	for i := range schema.ForeignKeys {
+		// SQLite 的外键没有名称，id 只是序号
+		schema.ForeignKeys[i].Name = ""
+	}
+	return nil
+}
+
+// groupIndexes 把 (索引名, 是否唯一, 列名) 的行按索引合并
+func groupIndexes(rows [][]interface{}) []Index {
+	var indexes []Index
+	for _, row := range rows {
+		name := text(row[0])
+		if len(indexes) == 0 || indexes[len(indexes)-1].Name != name {
+			indexes = append(indexes, Index{Name: name, Unique: truthy(row[1])})
+		}
+		last := &indexes[len(indexes)-1]
+		last.Columns = append(last.Columns, text(row[2]))
+	}
+	return indexes
+}
+
+// groupForeignKeys 把 (约束名, 列, 引用的表, 引用的列) 的行按约束合并为定义
+func groupForeignKeys(rows [][]interface{}) []ForeignKey {
+	type key struct {
+		name, table string
+		from, to    []string
+	}
+	var keys []key
+	for _, row := range rows {
+		name := text(row[0])
+		if len(keys) == 0 || keys[len(keys)-1].name != name {
+			keys = append(keys, key{name: name, table: text(row[2])})
+		}
+		last := &keys[len(keys)-1]
+		last.from = append(last.from, text(row[1]))
+		last.to = append(last.to, text(row[3]))
+	}
+	foreignKeys := make([]ForeignKey, 0, len(keys))
+	for _, k := range keys {
+		foreignKeys = append(foreignKeys, ForeignKey{
+			Name:       k.name,
+			Definition: fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s(%s)", strings.Join(k.from, ", "), k.table, strings.Join(k.to, ", ")),
+		})
+	}
+	return foreignKeys
+}
+
+// text 查询结果中的值转换为文本
+func text(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// truthy 查询结果中的布尔值（各驱动分别返回 bool、整数或文本）
+func truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case int64:
+		return v != 0
+	case string:
+		return v == "1" || strings.EqualFold(v, "true") || v == "t"
+	}
+	return false
+}
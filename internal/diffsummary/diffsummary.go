@@ -0,0 +1,357 @@
+package diffsummary
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 文件改动的类型
+const (
+	StatusAdded    = "A"
+	StatusDeleted  = "D"
+	StatusModified = "M"
+	StatusRenamed  = "R"
+)
+
+// DefaultTokenBudget 摘要的默认token预算
+const DefaultTokenBudget = 2000
+
+// maxExcerptLines 每个文件附加的改动行数上限
+const maxExcerptLines = 40
+
+// declarationPattern 常见语言中声明的开头（函数、类型、类等）
+var declarationPattern = regexp.MustCompile(`^\s*(?:export\s+(?:default\s+)?)?(?:pub(?:\([^)]*\))?\s+)?(?:async\s+)?(?:func|type|class|def|fn|struct|enum|trait|impl|interface|function|message|service|rpc|module)\s+[A-Za-z_(*]`)
+
+// hunkHeaderPattern @@ -a,b +c,d @@ 上下文
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@ ?(.*)$`)
+
+// FileDiff 一个文件的改动
+type FileDiff struct {
+	Path    string `json:"path"`
+	OldPath string `json:"old_path,omitempty"` // 重命名前的路径
+	Status  string `json:"status"`
+	Added   int    `json:"added"`
+	Deleted int    `json:"deleted"`
+	Binary  bool   `json:"binary,omitempty"`
+	Hunks   []Hunk `json:"-"`
+}
+
+// Hunk diff中的一段改动
+type Hunk struct {
+	Header  string   // 完整的 @@ 行
+	Context string   // @@ 之后的上下文（通常是所在的函数）
+	Line    int      // 新文件中的起始行
+	Lines   []string // 以 +、- 或空格开头的行
+}
+
+// Churn 增删的行数之和
+func (f *FileDiff) Churn() int {
+	return f.Added + f.Deleted
+}
+
+// Parse 解析 git diff 格式的统一diff
+func Parse(diff string) []FileDiff {
+	var files []FileDiff
+	var file *FileDiff
+	var hunk *Hunk
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			files = append(files, FileDiff{Status: StatusModified})
+			file = &files[len(files)-1]
+			hunk = nil
+			// diff --git a/x b/y：路径在后面的 ---/+++ 或 rename 行中更可靠，这里作为后备
+			if i := strings.Index(line, " b/"); i >= 0 {
+				file.Path = line[i+3:]
+			}
+		case file == nil:
+			continue
+		case hunk == nil && strings.HasPrefix(line, "new file mode"):
+			file.Status = StatusAdded
+		case hunk == nil && strings.HasPrefix(line, "deleted file mode"):
+			file.Status = StatusDeleted
+		case hunk == nil && strings.HasPrefix(line, "rename from "):
+			file.Status = StatusRenamed
+			file.OldPath = strings.TrimPrefix(line, "rename from ")
+		case hunk == nil && strings.HasPrefix(line, "rename to "):
+			file.Path = strings.TrimPrefix(line, "rename to ")
+		case hunk == nil && strings.HasPrefix(line, "Binary files "):
+			file.Binary = true
+		case hunk == nil && strings.HasPrefix(line, "--- "):
+			if name := strings.TrimPrefix(line, "--- "); name != "/dev/null" && file.Status == StatusDeleted {
+				file.Path = strings.TrimPrefix(name, "a/")
+			}
+		case hunk == nil && strings.HasPrefix(line, "+++ "):
+			if name := strings.TrimPrefix(line, "+++ "); name != "/dev/null" {
+				file.Path = strings.TrimPrefix(name, "b/")
+			}
+		case strings.HasPrefix(line, "@@"):
+			m := hunkHeaderPattern.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			start, _ := strconv.Atoi(m[1])
+			file.Hunks = append(file.Hunks, Hunk{Header: line, Context: strings.TrimSpace(m[2]), Line: start})
+			hunk = &file.Hunks[len(file.Hunks)-1]
+		case hunk != nil && strings.HasPrefix(line, "+"):
+			file.Added++
+			hunk.Lines = append(hunk.Lines, line)
+		case hunk != nil && strings.HasPrefix(line, "-"):
+			file.Deleted++
+			hunk.Lines = append(hunk.Lines, line)
+		case hunk != nil && (strings.HasPrefix(line, " ") || line == ""):
+			hunk.Lines = append(hunk.Lines, line)
+		}
+	}
+	return files
+}
+
+// Symbols 文件中改动涉及的声明：+ 新增、- 删除、~ 修改（改动所在的函数或类型）
+func Symbols(file *FileDiff) []string {
+	var symbols []string
+	seen := make(map[string]bool)
+	add := func(symbol string) {
+		if len(symbol) > 120 {
+			symbol = symbol[:120] + "…"
+		}
+		if !seen[symbol] {
+			seen[symbol] = true
+			symbols = append(symbols, symbol)
+		}
+	}
+	added, removed := make(map[string]bool), make(map[string]bool)
+	for _, hunk := range file.Hunks {
+		for _, line := range hunk.Lines {
+			if line == "" || line[0] == ' ' {
+				continue
+			}
+			text := strings.TrimSpace(line[1:])
+			if !declarationPattern.MatchString(text) {
+				continue
+			}
+			text = strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(text, "{"), ":"))
+			if line[0] == '+' {
+				added[text] = true
+			} else {
+				removed[text] = true
+			}
+		}
+	}
+	for _, hunk := range file.Hunks {
+		for _, line := range hunk.Lines {
+			if line == "" || line[0] == ' ' {
+				continue
+			}
+			text := strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(strings.TrimSpace(line[1:]), "{"), ":"))
+			switch {
+			case line[0] == '+' && added[text] && !removed[text]:
+				add("+ " + text)
+			case line[0] == '-' && removed[text] && !added[text]:
+				add("- " + text)
+			}
+		}
+		if hunk.Context != "" && !added[hunk.Context] {
+			add("~ " + strings.TrimSpace(strings.TrimSuffix(hunk.Context, "{")))
+		}
+	}
+	return symbols
+}
+
+// Summary 分层的diff摘要
+type Summary struct {
+	Text           string `json:"summary"`
+	Files          int    `json:"files"`
+	Added          int    `json:"added"`
+	Deleted        int    `json:"deleted"`
+	EstimateTokens int    `json:"estimate_tokens"`
+	Truncated      bool   `json:"truncated,omitempty"` // 预算内没有放下所有文件的细节
+}
+
+// Summarize 在token预算内生成分层摘要：总计、每个目录（包）、每个文件的增删行数，
+// 预算允许时按改动量从大到小加入文件中改动的声明，再加入改动的代码片段
+func Summarize(files []FileDiff, tokenBudget int) *Summary {
+	if tokenBudget <= 0 {
+		tokenBudget = DefaultTokenBudget
+	}
+	budget := tokenBudget * 4 // 约4个字符一个token
+
+	summary := &Summary{Files: len(files)}
+	packages := make(map[string][]*FileDiff)
+	for i := range files {
+		file := &files[i]
+		summary.Added += file.Added
+		summary.Deleted += file.Deleted
+		dir := path.Dir(file.Path)
+		packages[dir] = append(packages[dir], file)
+	}
+
+	// 目录与文件按改动量排序
+	churn := make(map[string]int)
+	var dirs []string
+	for dir, list := range packages {
+		dirs = append(dirs, dir)
+		sort.SliceStable(list, func(i, j int) bool {
+			if list[i].Churn() != list[j].Churn() {
+				return list[i].Churn() > list[j].Churn()
+			}
+			return list[i].Path < list[j].Path
+		})
+		for _, file := range list {
+			churn[dir] += file.Churn()
+		}
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		if churn[dirs[i]] != churn[dirs[j]] {
+			return churn[dirs[i]] > churn[dirs[j]]
+		}
+		return dirs[i] < dirs[j]
+	})
+
+	header := fmt.Sprintf("%d files changed, +%d -%d, in %d directories\n", len(files), summary.Added, summary.Deleted, len(dirs))
+	used := len(header)
+	dirLines := make(map[string]string)
+	for _, dir := range dirs {
+		dirLines[dir] = fmt.Sprintf("\n%s/ (%d files, +%d -%d)\n", dir, len(packages[dir]), sumAdded(packages[dir]), sumDeleted(packages[dir]))
+		used += len(dirLines[dir])
+	}
+
+	// 文件行按改动量从大到小放入预算，放不下的文件在目录下合计
+	var ordered []*FileDiff
+	for _, dir := range dirs {
+		ordered = append(ordered, packages[dir]...)
+	}
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Churn() > ordered[j].Churn() })
+	fileLines := make(map[*FileDiff]string)
+	for _, file := range ordered {
+		line := fileLine(file)
+		if used+len(line) > budget {
+			summary.Truncated = true
+			break
+		}
+		fileLines[file] = line
+		used += len(line)
+	}
+
+	// 改动的声明与代码片段按同样的顺序放入剩余预算
+	symbolBlocks := make(map[*FileDiff]string)
+	for _, file := range ordered {
+		if _, ok := fileLines[file]; !ok {
+			break
+		}
+		symbols := Symbols(file)
+		if len(symbols) == 0 {
+			continue
+		}
+		text := "      " + strings.Join(symbols, "\n      ") + "\n"
+		if used+len(text) > budget {
+			summary.Truncated = true
+			continue
+		}
+		symbolBlocks[file] = text
+		used += len(text)
+	}
+	excerptBlocks := make(map[*FileDiff]string)
+	for _, file := range ordered {
+		if _, ok := fileLines[file]; !ok {
+			break
+		}
+		text := excerpt(file)
+		if text == "" {
+			continue
+		}
+		if used+len(text) > budget {
+			summary.Truncated = true
+			continue
+		}
+		excerptBlocks[file] = text
+		used += len(text)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(header)
+	for _, dir := range dirs {
+		sb.WriteString(dirLines[dir])
+		omitted, omittedChurn := 0, 0
+		for _, file := range packages[dir] {
+			line, ok := fileLines[file]
+			if !ok {
+				omitted++
+				omittedChurn += file.Churn()
+				continue
+			}
+			sb.WriteString(line)
+			sb.WriteString(symbolBlocks[file])
+			sb.WriteString(excerptBlocks[file])
+		}
+		if omitted > 0 {
+			fmt.Fprintf(&sb, "  ... %d more files (%d lines changed)\n", omitted, omittedChurn)
+		}
+	}
+	summary.Text = sb.String()
+	summary.EstimateTokens = (len(summary.Text) + 3) / 4
+	return summary
+}
+
+// fileLine 文件的一行摘要：状态、路径与增删行数
+func fileLine(file *FileDiff) string {
+	name := path.Base(file.Path)
+	if file.Status == StatusRenamed {
+		name = file.OldPath + " -> " + file.Path
+	}
+	if file.Binary {
+		return fmt.Sprintf("  %s %s (binary)\n", file.Status, name)
+	}
+	return fmt.Sprintf("  %s %s +%d -%d\n", file.Status, name, file.Added, file.Deleted)
+}
+
+// excerpt 文件中改动的行（不含上下文），最多 maxExcerptLines 行
+func excerpt(file *FileDiff) string {
+	var sb strings.Builder
+	count := 0
+	for _, hunk := range file.Hunks {
+		if count == maxExcerptLines {
+			break
+		}
+		fmt.Fprintf(&sb, "      @@ line %d\n", hunk.Line)
+		for _, line := range hunk.Lines {
+			if line == "" || line[0] == ' ' || strings.TrimSpace(line[1:]) == "" {
+				continue
+			}
+			if count == maxExcerptLines {
+				sb.WriteString("      ...\n")
+				break
+			}
+			if len(line) > 160 {
+				line = line[:160] + "…"
+			}
+			sb.WriteString("      " + line + "\n")
+			count++
+		}
+	}
+	if count == 0 {
+		return ""
+	}
+	return sb.String()
+}
+
+// sumAdded 文件新增行数之和
+func sumAdded(files []*FileDiff) int {
+	total := 0
+	for _, file := range files {
+		total += file.Added
+	}
+	return total
+}
+
+// sumDeleted 文件删除行数之和
+func sumDeleted(files []*FileDiff) int {
+	total := 0
+	for _, file := range files {
+		total += file.Deleted
+	}
+	return total
+}
@@ -0,0 +1,54 @@
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// commandEmbedder 执行外部程序计算向量：标准输入为文本的JSON数组，标准输出为向量的JSON数组。
+// 用于 fastembed、sentence-transformers 或 ONNX 模型等本地推理
+type commandEmbedder struct {
+	command   []string
+	model     string
+	batchSize int
+}
+
+// newCommand 创建 command 嵌入服务
+func newCommand(cfg Config, batchSize int) *commandEmbedder {
+	return &commandEmbedder{command: cfg.Command, model: cfg.Model, batchSize: batchSize}
+}
+
+// Name 服务与模型标识，未设置模型名时使用程序名
+func (e *commandEmbedder) Name() string {
+	if e.model != "" {
+		return ProviderCommand + "/" + e.model
+	}
+	return ProviderCommand + "/" + strings.Join(e.command, " ")
+}
+
+// Embed 分批执行程序
+func (e *commandEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return embedBatches(ctx, texts, e.batchSize, func(ctx context.Context, batch []string) ([][]float32, error) {
+		input, err := json.Marshal(batch)
+		if err != nil {
+			return nil, err
+		}
+		cmd := exec.CommandContext(ctx, e.command[0], e.command[1:]...)
+		cmd.Stdin = bytes.NewReader(input)
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("embeddings command %s failed: %w: %s", e.command[0], err, strings.TrimSpace(stderr.String()))
+		}
+		var vectors [][]float32
+		if err := json.Unmarshal(stdout.Bytes(), &vectors); err != nil {
+			return nil, fmt.Errorf("embeddings command %s printed invalid output (expected a JSON array of vectors): %w", e.command[0], err)
+		}
+		return vectors, nil
+	})
+}
\ No newline at end of file
@@ -0,0 +1,120 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// 支持的嵌入服务
+const (
+	ProviderOpenAI  = "openai"  // OpenAI 兼容的 /embeddings 接口
+	ProviderOllama  = "ollama"  // 本地 Ollama 服务
+	ProviderCommand = "command" // 外部程序，如基于 fastembed/ONNX 的脚本
+	ProviderLocal   = "local"   // 内置的特征哈希向量，无需模型与网络
+)
+
+// defaultBatchSize 单次请求嵌入的最多文本数
+const defaultBatchSize = 64
+
+// Embedder 将文本转换为向量
+type Embedder interface {
+	// Embed 按顺序返回每段文本的向量
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	// Name 服务与模型的标识，如 openai/text-embedding-3-small，模型不同的向量不能混用
+	Name() string
+}
+
+// Config 嵌入服务配置（配置文件中的 embeddings:）
+type Config struct {
+	Provider   string   `yaml:"provider"`              // openai、ollama、command 或 local
+	Model      string   `yaml:"model,omitempty"`       // 模型名，各服务有默认值
+	BaseURL    string   `yaml:"base_url,omitempty"`    // 服务地址，openai 默认为 BASE_URL
+	APIKeyEnv  string   `yaml:"api_key_env,omitempty"` // 读取API密钥的环境变量，默认使用对话模型的密钥
+	Dimensions int      `yaml:"dimensions,omitempty"`  // 向量维度：openai 传给支持缩短维度的模型，local 为哈希维度
+	BatchSize  int      `yaml:"batch_size,omitempty"`  // 单次请求的最多文本数
+	Command    []string `yaml:"command,omitempty"`     // command 服务执行的程序及参数
+}
+
+// Enabled 是否配置了嵌入服务
+func (c Config) Enabled() bool {
+	return c.Provider != ""
+}
+
+// New 按配置创建嵌入服务。apiKey 与 baseURL 为对话模型的密钥与地址，openai 服务未单独配置时使用
+func New(cfg Config, apiKey, baseURL string) (Embedder, error) {
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	switch strings.ToLower(cfg.Provider) {
+	case ProviderOpenAI:
+		return newOpenAI(cfg, apiKey, baseURL, batchSize), nil
+	case ProviderOllama:
+		return newOllama(cfg, batchSize), nil
+	case ProviderCommand:
+		if len(cfg.Command) == 0 {
+			return nil, fmt.Errorf("embeddings provider %q requires command", ProviderCommand)
+		}
+		return newCommand(cfg, batchSize), nil
+	case ProviderLocal:
+		return NewLocal(cfg.Dimensions), nil
+	case "":
+		return nil, fmt.Errorf("no embeddings provider configured")
+	}
+	return nil, fmt.Errorf("unknown embeddings provider %q (expected %s, %s, %s or %s)", cfg.Provider, ProviderOpenAI, ProviderOllama, ProviderCommand, ProviderLocal)
+}
+
+// embedBatches 按批次调用 embed，合并结果并检查数量
+func embedBatches(ctx context.Context, texts []string, batchSize int, embed func(context.Context, []string) ([][]float32, error)) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += batchSize {
+		end := start + batchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+		batch, err := embed(ctx, texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) != end-start {
+			return nil, fmt.Errorf("embeddings service returned %d vectors for %d texts", len(batch), end-start)
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
+
+// Cosine 两个向量的余弦相似度，维度不同或为零向量时返回0
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// Normalize 将向量缩放为单位长度（原地修改）
+func Normalize(v []float32) []float32 {
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	if norm == 0 {
+		return v
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i := range v {
+		v[i] *= scale
+	}
+	return v
+}
\ No newline at end of file
@@ -0,0 +1,73 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strings"
+)
+
+// defaultLocalDimensions 内置哈希向量的默认维度
+const defaultLocalDimensions = 512
+
+// localWordPattern 切分文本的词：标识符、数字与非ASCII字符序列
+var localWordPattern = regexp.MustCompile(`[A-Za-z][a-z0-9]*|[A-Z]+(?:[A-Z][a-z]|$)?|[0-9]+|[^\x00-\x7F]+`)
+
+// localEmbedder 内置的特征哈希向量：词与词内的三字母片段哈希到固定维度，完全离线。
+// 只反映用词的相似，不理解语义，适合作为没有嵌入模型时的后备
+type localEmbedder struct {
+	dimensions int
+}
+
+// NewLocal 创建内置的哈希嵌入，dimensions<=0 时使用默认维度
+func NewLocal(dimensions int) Embedder {
+	if dimensions <= 0 {
+		dimensions = defaultLocalDimensions
+	}
+	return &localEmbedder{dimensions: dimensions}
+}
+
+// Name 服务与维度标识
+func (e *localEmbedder) Name() string {
+	return fmt.Sprintf("%s/hash@%d", ProviderLocal, e.dimensions)
+}
+
+// Embed 计算每段文本的向量
+func (e *localEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		vectors[i] = e.embed(text)
+	}
+	return vectors, nil
+}
+
+// embed 将驼峰与下划线拆开的词及其三字母片段累加到哈希桶中
+func (e *localEmbedder) embed(text string) []float32 {
+	vector := make([]float32, e.dimensions)
+	for _, word := range localWordPattern.FindAllString(text, -1) {
+		word = strings.ToLower(word)
+		e.add(vector, "w:"+word, 1)
+		padded := "^" + word + "$"
+		runes := []rune(padded)
+		for i := 0; i+3 <= len(runes); i++ {
+			e.add(vector, "t:"+string(runes[i:i+3]), 0.5)
+		}
+	}
+	return Normalize(vector)
+}
+
+// add 用哈希的一位决定符号，减少不同特征冲突时的相互抵消
+func (e *localEmbedder) add(vector []float32, feature string, weight float32) {
+	h := fnv.New64a()
+	h.Write([]byte(feature))
+	sum := h.Sum64()
+	index := int(sum % uint64(e.dimensions))
+	if sum>>63 == 1 {
+		weight = -weight
+	}
+	vector[index] += weight
+}
\ No newline at end of file
@@ -0,0 +1,83 @@
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Ollama 服务的默认地址与嵌入模型
+const (
+	defaultOllamaURL   = "http://localhost:11434"
+	defaultOllamaModel = "nomic-embed-text"
+)
+
+// ollamaEmbedder 调用 Ollama 的 /api/embed 接口
+type ollamaEmbedder struct {
+	http      *http.Client
+	baseURL   string
+	model     string
+	batchSize int
+}
+
+// newOllama 创建 ollama 嵌入服务
+func newOllama(cfg Config, batchSize int) *ollamaEmbedder {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = defaultOllamaURL
+	}
+	model := cfg.Model
+	if model == "" {
+		model = defaultOllamaModel
+	}
+	return &ollamaEmbedder{
+		http:      &http.Client{Timeout: 5 * time.Minute},
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		model:     model,
+		batchSize: batchSize,
+	}
+}
+
+// Name 服务与模型标识
+func (e *ollamaEmbedder) Name() string {
+	return ProviderOllama + "/" + e.model
+}
+
+// Embed 分批请求文本的向量
+func (e *ollamaEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return embedBatches(ctx, texts, e.batchSize, func(ctx context.Context, batch []string) ([][]float32, error) {
+		body, err := json.Marshal(map[string]interface{}{"model": e.model, "input": batch})
+		if err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL+"/api/embed", bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := e.http.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reach ollama at %s: %w", e.baseURL, err)
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ollama response: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("ollama returned %s: %s", resp.Status, strings.TrimSpace(string(data)))
+		}
+		var result struct {
+			Embeddings [][]float32 `json:"embeddings"`
+		}
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("failed to parse ollama response: %w", err)
+		}
+		return result.Embeddings, nil
+	})
+}
\ No newline at end of file
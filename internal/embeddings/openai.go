@@ -0,0 +1,79 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// defaultOpenAIModel openai 服务的默认嵌入模型
+const defaultOpenAIModel = "text-embedding-3-small"
+
+// openAIEmbedder 调用 OpenAI 兼容的 /embeddings 接口
+type openAIEmbedder struct {
+	client     *openai.Client
+	model      string
+	dimensions int
+	batchSize  int
+}
+
+// newOpenAI 创建 openai 嵌入服务
+func newOpenAI(cfg Config, apiKey, baseURL string, batchSize int) *openAIEmbedder {
+	if cfg.APIKeyEnv != "" {
+		apiKey = os.Getenv(cfg.APIKeyEnv)
+	}
+	if cfg.BaseURL != "" {
+		baseURL = cfg.BaseURL
+	}
+	model := cfg.Model
+	if model == "" {
+		model = defaultOpenAIModel
+	}
+	clientConfig := openai.DefaultConfig(apiKey)
+	if baseURL != "" {
+		clientConfig.BaseURL = baseURL
+	}
+	return &openAIEmbedder{
+		client:     openai.NewClientWithConfig(clientConfig),
+		model:      model,
+		dimensions: cfg.Dimensions,
+		batchSize:  batchSize,
+	}
+}
+
+// Name 服务与模型标识
+func (e *openAIEmbedder) Name() string {
+	if e.dimensions > 0 {
+		return fmt.Sprintf("%s/%s@%d", ProviderOpenAI, e.model, e.dimensions)
+	}
+	return ProviderOpenAI + "/" + e.model
+}
+
+// Embed 分批请求文本的向量
+func (e *openAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return embedBatches(ctx, texts, e.batchSize, func(ctx context.Context, batch []string) ([][]float32, error) {
+		response, err := e.client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+			Input:      batch,
+			Model:      openai.EmbeddingModel(e.model),
+			Dimensions: e.dimensions,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create embeddings with %s: %w", e.model, err)
+		}
+		vectors := make([][]float32, len(batch))
+		for _, item := range response.Data {
+			if item.Index < 0 || item.Index >= len(vectors) {
+				return nil, fmt.Errorf("embeddings service returned an invalid index %d", item.Index)
+			}
+			vectors[item.Index] = item.Embedding
+		}
+		for i, vector := range vectors {
+			if vector == nil {
+				return nil, fmt.Errorf("embeddings service returned no vector for text %d", i)
+			}
+		}
+		return vectors, nil
+	})
+}
\ No newline at end of file
@@ -0,0 +1,327 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL GitHub API 默认地址（GitHub Enterprise 为 https://<host>/api/v3）
+const DefaultAPIURL = "https://api.github.com"
+
+// maxCommentPages 评论最多读取的页数（每页100条）
+const maxCommentPages = 5
+
+// Repo GitHub 仓库
+type Repo struct {
+	Owner string
+	Name  string
+}
+
+// String owner/name 形式
+func (r Repo) String() string {
+	return r.Owner + "/" + r.Name
+}
+
+// remotePattern 匹配 owner/name、https://host/owner/name(.git) 与 git@host:owner/name(.git)
+var remotePattern = regexp.MustCompile(`^(?:(?:https?|ssh|git)://(?:[^@/]+@)?[^/]+/|[^@/]+@[^:/]+:)?([\w.-]+)/([\w.-]+?)(?:\.git)?/?$`)
+
+// ParseRepo 解析 owner/name 或 git 远程地址
+func ParseRepo(s string) (Repo, error) {
+	m := remotePattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return Repo{}, fmt.Errorf("cannot determine the GitHub repository from %q (expected owner/name)", s)
+	}
+	return Repo{Owner: m[1], Name: m[2]}, nil
+}
+
+// Comment issue 或 PR 的评论
+type Comment struct {
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	Path      string    `json:"path,omitempty"` // 代码评审评论所在文件
+	Line      int       `json:"line,omitempty"`
+}
+
+// Issue GitHub issue 或 pull request
+type Issue struct {
+	Number        int       `json:"number"`
+	Title         string    `json:"title"`
+	Body          string    `json:"body"`
+	State         string    `json:"state"`
+	Author        string    `json:"author"`
+	URL           string    `json:"url"`
+	Labels        []string  `json:"labels,omitempty"`
+	IsPullRequest bool      `json:"is_pull_request"`
+	BaseRef       string    `json:"base_ref,omitempty"`
+	HeadRef       string    `json:"head_ref,omitempty"`
+	Comments      []Comment `json:"comments"`
+	Diff          string    `json:"diff,omitempty"`
+}
+
+// Client GitHub REST API 客户端
+type Client struct {
+	apiURL string
+	token  string
+	http   *http.Client
+}
+
+// NewClient 创建客户端，apiURL 为空时使用 api.github.com，token 为空时只能访问公开仓库
+func NewClient(apiURL, token string) *Client {
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	return &Client{
+		apiURL: strings.TrimRight(apiURL, "/"),
+		token:  token,
+		http:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// apiError GitHub API 错误响应
+type apiError struct {
+	Message string `json:"message"`
+}
+
+// do 发送请求，out 为 *string 时返回原始响应正文，否则按JSON解码
+func (c *Client) do(ctx context.Context, method, path, accept string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.apiURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if accept == "" {
+		accept = "application/vnd.github+json"
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("GitHub request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read GitHub response: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		var apiErr apiError
+		json.Unmarshal(data, &apiErr)
+		msg := apiErr.Message
+		if msg == "" {
+			msg = http.StatusText(resp.StatusCode)
+		}
+		switch resp.StatusCode {
+		case http.StatusUnauthorized:
+			msg += " (check GITHUB_TOKEN)"
+		case http.StatusNotFound:
+			if c.token == "" {
+				msg += " (private repositories need GITHUB_TOKEN)"
+			}
+		}
+		return fmt.Errorf("GitHub API %s %s: %s (HTTP %d)", method, path, msg, resp.StatusCode)
+	}
+
+	if raw, ok := out.(*string); ok {
+		*raw = string(data)
+		return nil
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("failed to parse GitHub response: %w", err)
+		}
+	}
+	return nil
+}
+
+// apiUser API 中的用户
+type apiUser struct {
+	Login string `json:"login"`
+}
+
+// apiComment API 中的评论
+type apiComment struct {
+	User      apiUser   `json:"user"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	Path      string    `json:"path"`
+	Line      int       `json:"line"`
+	HTMLURL   string    `json:"html_url"`
+}
+
+// listComments 分页读取评论
+func (c *Client) listComments(ctx context.Context, path string) ([]Comment, error) {
+	var comments []Comment
+	for page := 1; page <= maxCommentPages; page++ {
+		var batch []apiComment
+		if err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s?per_page=100&page=%d", path, page), "", nil, &batch); err != nil {
+			return nil, err
+		}
+		for _, item := range batch {
+			comments = append(comments, Comment{
+				Author:    item.User.Login,
+				Body:      item.Body,
+				CreatedAt: item.CreatedAt,
+				Path:      item.Path,
+				Line:      item.Line,
+			})
+		}
+		if len(batch) < 100 {
+			break
+		}
+	}
+	return comments, nil
+}
+
+// FetchIssue 读取 issue 或 PR 的标题、正文与评论；PR 还包括代码评审评论与diff
+func (c *Client) FetchIssue(ctx context.Context, repo Repo, number int) (*Issue, error) {
+	var raw struct {
+		Number  int     `json:"number"`
+		Title   string  `json:"title"`
+		Body    string  `json:"body"`
+		State   string  `json:"state"`
+		HTMLURL string  `json:"html_url"`
+		User    apiUser `json:"user"`
+		Labels  []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+		PullRequest *struct{} `json:"pull_request"`
+	}
+	base := fmt.Sprintf("/repos/%s/%s", url.PathEscape(repo.Owner), url.PathEscape(repo.Name))
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s/issues/%d", base, number), "", nil, &raw); err != nil {
+		return nil, err
+	}
+
+	issue := &Issue{
+		Number:        raw.Number,
+		Title:         raw.Title,
+		Body:          raw.Body,
+		State:         raw.State,
+		Author:        raw.User.Login,
+		URL:           raw.HTMLURL,
+		IsPullRequest: raw.PullRequest != nil,
+	}
+	for _, label := range raw.Labels {
+		issue.Labels = append(issue.Labels, label.Name)
+	}
+
+	comments, err := c.listComments(ctx, fmt.Sprintf("%s/issues/%d/comments", base, number))
+	if err != nil {
+		return nil, err
+	}
+	issue.Comments = comments
+
+	if issue.IsPullRequest {
+		var pr struct {
+			Base struct {
+				Ref string `json:"ref"`
+			} `json:"base"`
+			Head struct {
+				Ref string `json:"ref"`
+			} `json:"head"`
+		}
+		if err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s/pulls/%d", base, number), "", nil, &pr); err != nil {
+			return nil, err
+		}
+		issue.BaseRef = pr.Base.Ref
+		issue.HeadRef = pr.Head.Ref
+
+		reviewComments, err := c.listComments(ctx, fmt.Sprintf("%s/pulls/%d/comments", base, number))
+		if err != nil {
+			return nil, err
+		}
+		issue.Comments = append(issue.Comments, reviewComments...)
+
+		if err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s/pulls/%d", base, number), "application/vnd.github.diff", nil, &issue.Diff); err != nil {
+			return nil, err
+		}
+	}
+
+	return issue, nil
+}
+
+// PostComment 在 issue 或 PR 下发表评论，返回评论地址
+func (c *Client) PostComment(ctx context.Context, repo Repo, number int, body string) (string, error) {
+	var created apiComment
+	path := fmt.Sprintf("/repos/%s/%s/issues/%d/comments", url.PathEscape(repo.Owner), url.PathEscape(repo.Name), number)
+	if err := c.do(ctx, http.MethodPost, path, "", map[string]string{"body": body}, &created); err != nil {
+		return "", err
+	}
+	return created.HTMLURL, nil
+}
+
+// Context 生成附加到提示词中的内容，diff 超过 maxDiffBytes 时截断
+func (i *Issue) Context(maxDiffBytes int) string {
+	var sb strings.Builder
+	kind := "Issue"
+	if i.IsPullRequest {
+		kind = "Pull request"
+	}
+	fmt.Fprintf(&sb, "%s #%d: %s\n", kind, i.Number, i.Title)
+	fmt.Fprintf(&sb, "State: %s · Author: @%s · %s\n", i.State, i.Author, i.URL)
+	if len(i.Labels) > 0 {
+		fmt.Fprintf(&sb, "Labels: %s\n", strings.Join(i.Labels, ", "))
+	}
+	if i.IsPullRequest {
+		fmt.Fprintf(&sb, "Branch: %s -> %s\n", i.HeadRef, i.BaseRef)
+	}
+
+	body := strings.TrimSpace(i.Body)
+	if body == "" {
+		body = "(no description)"
+	}
+	fmt.Fprintf(&sb, "\n%s\n", body)
+
+	if len(i.Comments) > 0 {
+		fmt.Fprintf(&sb, "\n## Comments (%d)\n", len(i.Comments))
+		for _, comment := range i.Comments {
+			location := ""
+			if comment.Path != "" {
+				location = fmt.Sprintf(" on %s:%d", comment.Path, comment.Line)
+			}
+			fmt.Fprintf(&sb, "\n@%s%s (%s):\n%s\n", comment.Author, location, comment.CreatedAt.Format("2006-01-02"), strings.TrimSpace(comment.Body))
+		}
+	}
+
+	if i.Diff != "" {
+		diff := i.Diff
+		truncated := false
+		if maxDiffBytes > 0 && len(diff) > maxDiffBytes {
+			diff = diff[:maxDiffBytes]
+			if cut := strings.LastIndex(diff, "\n"); cut > 0 {
+				diff = diff[:cut+1]
+			}
+			truncated = true
+		}
+		sb.WriteString("\n## Diff\n")
+		sb.WriteString(strings.TrimRight(diff, "\n") + "\n")
+		if truncated {
+			fmt.Fprintf(&sb, "... diff truncated to %d bytes of %d; check out the branch %s to see the rest\n", maxDiffBytes, len(i.Diff), i.HeadRef)
+		}
+	}
+	return sb.String()
+}
\ No newline at end of file
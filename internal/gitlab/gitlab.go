@@ -0,0 +1,401 @@
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultURL GitLab 默认实例地址，自建实例使用其根地址（API 位于 /api/v4）
+const DefaultURL = "https://gitlab.com"
+
+// maxPages 讨论与diff最多读取的页数（每页100条）
+const maxPages = 5
+
+// Project GitLab 项目，Path 可包含多级群组，如 group/subgroup/name
+type Project struct {
+	Host string // 远程地址中的主机名，owner/name 形式时为空
+	Path string
+}
+
+// String 项目路径
+func (p Project) String() string {
+	return p.Path
+}
+
+// remotePattern 匹配 https://host/group/.../name(.git)、git@host:group/.../name(.git) 与 group/.../name
+var remotePattern = regexp.MustCompile(`^(?:(?:https?|ssh|git)://(?:[^@/]+@)?([^/:]+)(?::\d+)?/|[^@/]+@([^:/]+):)?([\w.-]+(?:/[\w.-]+)+)/?$`)
+
+// ParseProject 解析项目路径或 git 远程地址
+func ParseProject(s string) (Project, error) {
+	m := remotePattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return Project{}, fmt.Errorf("cannot determine the GitLab project from %q (expected group/name)", s)
+	}
+	host := m[1]
+	if host == "" {
+		host = m[2]
+	}
+	return Project{Host: host, Path: strings.TrimSuffix(m[3], ".git")}, nil
+}
+
+// Note MR 讨论中的一条评论
+type Note struct {
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	Path      string    `json:"path,omitempty"` // 代码评审评论所在文件
+	Line      int       `json:"line,omitempty"`
+	Resolved  bool      `json:"resolved,omitempty"`
+	Reply     bool      `json:"reply,omitempty"` // 是否为讨论中的回复
+}
+
+// MergeRequest GitLab 合并请求
+type MergeRequest struct {
+	IID          int      `json:"iid"`
+	Title        string   `json:"title"`
+	Description  string   `json:"description"`
+	State        string   `json:"state"`
+	Author       string   `json:"author"`
+	URL          string   `json:"url"`
+	Labels       []string `json:"labels,omitempty"`
+	SourceBranch string   `json:"source_branch"`
+	TargetBranch string   `json:"target_branch"`
+	Notes        []Note   `json:"notes"`
+	Diff         string   `json:"diff,omitempty"`
+}
+
+// Client GitLab REST API (v4) 客户端
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// NewClient 创建客户端，baseURL 为实例根地址（为空时使用 gitlab.com），token 为空时只能访问公开项目
+func NewClient(baseURL, token string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultURL
+	}
+	baseURL = strings.TrimRight(baseURL, "/")
+	baseURL = strings.TrimSuffix(baseURL, "/api/v4")
+	return &Client{
+		baseURL: baseURL,
+		token:   token,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// apiError GitLab API 错误响应（message 可能是字符串或对象）
+type apiError struct {
+	Message interface{} `json:"message"`
+	Error   string      `json:"error"`
+}
+
+// do 发送请求并按JSON解码响应
+func (c *Client) do(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+"/api/v4"+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("GitLab request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read GitLab response: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		var apiErr apiError
+		json.Unmarshal(data, &apiErr)
+		msg := apiErr.Error
+		if apiErr.Message != nil {
+			msg = fmt.Sprint(apiErr.Message)
+		}
+		if msg == "" {
+			msg = http.StatusText(resp.StatusCode)
+		}
+		switch resp.StatusCode {
+		case http.StatusUnauthorized:
+			msg += " (check GITLAB_TOKEN)"
+		case http.StatusNotFound:
+			if c.token == "" {
+				msg += " (private projects need GITLAB_TOKEN)"
+			}
+		}
+		return &StatusError{StatusCode: resp.StatusCode, Err: fmt.Errorf("GitLab API %s %s: %s (HTTP %d)", method, path, msg, resp.StatusCode)}
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("failed to parse GitLab response: %w", err)
+		}
+	}
+	return nil
+}
+
+// StatusError 非成功的HTTP状态
+type StatusError struct {
+	StatusCode int
+	Err        error
+}
+
+func (e *StatusError) Error() string {
+	return e.Err.Error()
+}
+
+// projectPath 项目的API路径
+func projectPath(project Project) string {
+	return "/projects/" + url.PathEscape(project.Path)
+}
+
+// apiUser API 中的用户
+type apiUser struct {
+	Username string `json:"username"`
+}
+
+// apiDiff API 中单个文件的diff
+type apiDiff struct {
+	OldPath     string `json:"old_path"`
+	NewPath     string `json:"new_path"`
+	Diff        string `json:"diff"`
+	NewFile     bool   `json:"new_file"`
+	RenamedFile bool   `json:"renamed_file"`
+	DeletedFile bool   `json:"deleted_file"`
+}
+
+// FetchMergeRequest 读取 MR 的标题、描述、讨论（包括代码评审评论）与diff
+func (c *Client) FetchMergeRequest(ctx context.Context, project Project, iid int) (*MergeRequest, error) {
+	base := fmt.Sprintf("%s/merge_requests/%d", projectPath(project), iid)
+
+	var raw struct {
+		IID          int      `json:"iid"`
+		Title        string   `json:"title"`
+		Description  string   `json:"description"`
+		State        string   `json:"state"`
+		WebURL       string   `json:"web_url"`
+		Author       apiUser  `json:"author"`
+		Labels       []string `json:"labels"`
+		SourceBranch string   `json:"source_branch"`
+		TargetBranch string   `json:"target_branch"`
+	}
+	if err := c.do(ctx, http.MethodGet, base, nil, &raw); err != nil {
+		return nil, err
+	}
+	mr := &MergeRequest{
+		IID:          raw.IID,
+		Title:        raw.Title,
+		Description:  raw.Description,
+		State:        raw.State,
+		Author:       raw.Author.Username,
+		URL:          raw.WebURL,
+		Labels:       raw.Labels,
+		SourceBranch: raw.SourceBranch,
+		TargetBranch: raw.TargetBranch,
+	}
+
+	notes, err := c.listDiscussions(ctx, base)
+	if err != nil {
+		return nil, err
+	}
+	mr.Notes = notes
+
+	diff, err := c.fetchDiff(ctx, base)
+	if err != nil {
+		return nil, err
+	}
+	mr.Diff = diff
+
+	return mr, nil
+}
+
+// listDiscussions 分页读取讨论，忽略系统生成的评论
+func (c *Client) listDiscussions(ctx context.Context, base string) ([]Note, error) {
+	var notes []Note
+	for page := 1; page <= maxPages; page++ {
+		var discussions []struct {
+			Notes []struct {
+				Author    apiUser   `json:"author"`
+				Body      string    `json:"body"`
+				CreatedAt time.Time `json:"created_at"`
+				System    bool      `json:"system"`
+				Resolved  bool      `json:"resolved"`
+				Position  *struct {
+					NewPath string `json:"new_path"`
+					NewLine int    `json:"new_line"`
+					OldPath string `json:"old_path"`
+					OldLine int    `json:"old_line"`
+				} `json:"position"`
+			} `json:"notes"`
+		}
+		if err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s/discussions?per_page=100&page=%d", base, page), nil, &discussions); err != nil {
+			return nil, err
+		}
+		for _, discussion := range discussions {
+			for i, item := range discussion.Notes {
+				if item.System {
+					continue
+				}
+				note := Note{
+					Author:    item.Author.Username,
+					Body:      item.Body,
+					CreatedAt: item.CreatedAt,
+					Resolved:  item.Resolved,
+					Reply:     i > 0,
+				}
+				if item.Position != nil {
+					note.Path, note.Line = item.Position.NewPath, item.Position.NewLine
+					if note.Line == 0 {
+						note.Path, note.Line = item.Position.OldPath, item.Position.OldLine
+					}
+				}
+				notes = append(notes, note)
+			}
+		}
+		if len(discussions) < 100 {
+			break
+		}
+	}
+	return notes, nil
+}
+
+// fetchDiff 读取 MR 的diff；/diffs 接口（GitLab 15.7+）不可用时回退到 /changes
+func (c *Client) fetchDiff(ctx context.Context, base string) (string, error) {
+	var files []apiDiff
+	for page := 1; page <= maxPages; page++ {
+		var batch []apiDiff
+		err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s/diffs?per_page=100&page=%d", base, page), nil, &batch)
+		if statusErr, ok := err.(*StatusError); ok && statusErr.StatusCode == http.StatusNotFound && page == 1 {
+			var changes struct {
+				Changes []apiDiff `json:"changes"`
+			}
+			if err := c.do(ctx, http.MethodGet, base+"/changes", nil, &changes); err != nil {
+				return "", err
+			}
+			files = changes.Changes
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		files = append(files, batch...)
+		if len(batch) < 100 {
+			break
+		}
+	}
+
+	// 接口只返回各文件的hunk，补上 git diff 格式的文件头
+	var sb strings.Builder
+	for _, file := range files {
+		fmt.Fprintf(&sb, "diff --git a/%s b/%s\n", file.OldPath, file.NewPath)
+		switch {
+		case file.NewFile:
+			fmt.Fprintf(&sb, "new file\n--- /dev/null\n+++ b/%s\n", file.NewPath)
+		case file.DeletedFile:
+			fmt.Fprintf(&sb, "deleted file\n--- a/%s\n+++ /dev/null\n", file.OldPath)
+		default:
+			if file.RenamedFile {
+				fmt.Fprintf(&sb, "rename from %s\nrename to %s\n", file.OldPath, file.NewPath)
+			}
+			fmt.Fprintf(&sb, "--- a/%s\n+++ b/%s\n", file.OldPath, file.NewPath)
+		}
+		sb.WriteString(file.Diff)
+		if file.Diff != "" && !strings.HasSuffix(file.Diff, "\n") {
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String(), nil
+}
+
+// PostNote 在 MR 下发表评论，返回评论地址
+func (c *Client) PostNote(ctx context.Context, project Project, mr *MergeRequest, body string) (string, error) {
+	var created struct {
+		ID int `json:"id"`
+	}
+	path := fmt.Sprintf("%s/merge_requests/%d/notes", projectPath(project), mr.IID)
+	if err := c.do(ctx, http.MethodPost, path, map[string]string{"body": body}, &created); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s#note_%d", mr.URL, created.ID), nil
+}
+
+// Context 生成附加到提示词中的内容，diff 超过 maxDiffBytes 时截断
+func (mr *MergeRequest) Context(maxDiffBytes int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Merge request !%d: %s\n", mr.IID, mr.Title)
+	fmt.Fprintf(&sb, "State: %s · Author: @%s · %s\n", mr.State, mr.Author, mr.URL)
+	if len(mr.Labels) > 0 {
+		fmt.Fprintf(&sb, "Labels: %s\n", strings.Join(mr.Labels, ", "))
+	}
+	fmt.Fprintf(&sb, "Branch: %s -> %s\n", mr.SourceBranch, mr.TargetBranch)
+
+	description := strings.TrimSpace(mr.Description)
+	if description == "" {
+		description = "(no description)"
+	}
+	fmt.Fprintf(&sb, "\n%s\n", description)
+
+	if len(mr.Notes) > 0 {
+		fmt.Fprintf(&sb, "\n## Discussion (%d)\n", len(mr.Notes))
+		for _, note := range mr.Notes {
+			prefix := ""
+			if note.Reply {
+				prefix = "  ↳ "
+			}
+			location := ""
+			if note.Path != "" {
+				location = fmt.Sprintf(" on %s:%d", note.Path, note.Line)
+			}
+			status := ""
+			if note.Resolved {
+				status = " [resolved]"
+			}
+			fmt.Fprintf(&sb, "\n%s@%s%s (%s)%s:\n%s\n", prefix, note.Author, location, note.CreatedAt.Format("2006-01-02"), status, strings.TrimSpace(note.Body))
+		}
+	}
+
+	if mr.Diff != "" {
+		diff := mr.Diff
+		truncated := false
+		if maxDiffBytes > 0 && len(diff) > maxDiffBytes {
+			diff = diff[:maxDiffBytes]
+			if cut := strings.LastIndex(diff, "\n"); cut > 0 {
+				diff = diff[:cut+1]
+			}
+			truncated = true
+		}
+		sb.WriteString("\n## Diff\n")
+		sb.WriteString(strings.TrimRight(diff, "\n") + "\n")
+		if truncated {
+			fmt.Fprintf(&sb, "... diff truncated to %d bytes of %d; check out the branch %s to see the rest\n", maxDiffBytes, len(mr.Diff), mr.SourceBranch)
+		}
+	}
+	return sb.String()
+}
\ No newline at end of file
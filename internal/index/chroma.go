@@ -0,0 +1,159 @@
+package index
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// Chroma 的默认地址与租户、数据库
+const (
+	defaultChromaURL = "http://localhost:8000"
+	chromaDatabase   = "/api/v2/tenants/default_tenant/databases/default_database"
+)
+
+// chromaStore 使用 Chroma 的 v2 REST 接口，集合使用余弦距离
+type chromaStore struct {
+	client     *remoteClient
+	collection string
+
+	mu sync.Mutex
+	id string // 集合ID，第一次使用时获取或创建
+}
+
+// newChromaStore 创建 Chroma 存储
+func newChromaStore(cfg StoreConfig, collection string) *chromaStore {
+	return &chromaStore{
+		client:     newRemoteClient(cfg.URL, defaultChromaURL, cfg.APIKeyEnv, "x-chroma-token"),
+		collection: collection,
+	}
+}
+
+// collectionPath 获取或创建集合，返回其接口路径
+func (s *chromaStore) collectionPath(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.id == "" {
+		var response struct {
+			ID string `json:"id"`
+		}
+		body := map[string]interface{}{
+			"name":          s.collection,
+			"get_or_create": true,
+			"metadata":      map[string]interface{}{"hnsw:space": "cosine"},
+		}
+		if _, err := s.client.do(ctx, http.MethodPost, chromaDatabase+"/collections", body, &response); err != nil {
+			return "", err
+		}
+		s.id = response.ID
+	}
+	return chromaDatabase + "/collections/" + s.id, nil
+}
+
+// Upsert 先删除文件原有的代码段再写入
+func (s *chromaStore) Upsert(ctx context.Context, records []Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+	paths := make(map[string]bool)
+	var pathList []string
+	body := map[string]interface{}{}
+	var ids, documents []string
+	var vectors [][]float32
+	var metadatas []map[string]interface{}
+	for _, record := range records {
+		if !paths[record.Path] {
+			paths[record.Path] = true
+			pathList = append(pathList, record.Path)
+		}
+		metadata := chunkPayload(record.Chunk)
+		delete(metadata, "content")
+		ids = append(ids, record.ID)
+		documents = append(documents, record.Content)
+		vectors = append(vectors, record.Vector)
+		metadatas = append(metadatas, metadata)
+	}
+	if err := s.DeletePaths(ctx, pathList); err != nil {
+		return err
+	}
+	path, err := s.collectionPath(ctx)
+	if err != nil {
+		return err
+	}
+	body["ids"] = ids
+	body["documents"] = documents
+	body["embeddings"] = vectors
+	body["metadatas"] = metadatas
+	_, err = s.client.do(ctx, http.MethodPost, path+"/upsert", body, nil)
+	return err
+}
+
+// DeletePaths 按 path 元数据删除
+func (s *chromaStore) DeletePaths(ctx context.Context, paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	path, err := s.collectionPath(ctx)
+	if err != nil {
+		return err
+	}
+	body := map[string]interface{}{
+		"where": map[string]interface{}{"path": map[string]interface{}{"$in": paths}},
+	}
+	_, err = s.client.do(ctx, http.MethodPost, path+"/delete", body, nil)
+	return err
+}
+
+// Search 向量检索，Chroma 返回余弦距离，转换为相似度
+func (s *chromaStore) Search(ctx context.Context, vector []float32, limit int) ([]Result, error) {
+	path, err := s.collectionPath(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var response struct {
+		Documents [][]string                 `json:"documents"`
+		Metadatas [][]map[string]interface{} `json:"metadatas"`
+		Distances [][]float64                `json:"distances"`
+	}
+	body := map[string]interface{}{
+		"query_embeddings": [][]float32{vector},
+		"n_results":        limit,
+		"include":          []string{"documents", "metadatas", "distances"},
+	}
+	if _, err := s.client.do(ctx, http.MethodPost, path+"/query", body, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Metadatas) == 0 {
+		return nil, nil
+	}
+	results := make([]Result, 0, len(response.Metadatas[0]))
+	for i, metadata := range response.Metadatas[0] {
+		chunk := payloadChunk(metadata)
+		if len(response.Documents) > 0 && i < len(response.Documents[0]) {
+			chunk.Content = response.Documents[0][i]
+		}
+		result := Result{Chunk: chunk}
+		if len(response.Distances) > 0 && i < len(response.Distances[0]) {
+			result.Score = 1 - response.Distances[0][i]
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// Clear 删除集合
+func (s *chromaStore) Clear(ctx context.Context) error {
+	s.mu.Lock()
+	s.id = ""
+	s.mu.Unlock()
+	status, err := s.client.do(ctx, http.MethodDelete, chromaDatabase+"/collections/"+s.collection, nil, nil)
+	if status == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+// Close 无需释放资源
+func (s *chromaStore) Close() error {
+	return nil
+}
\ No newline at end of file
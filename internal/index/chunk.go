@@ -0,0 +1,242 @@
+package index
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// 分段的行数限制
+const (
+	chunkLines    = 60  // 相邻的小声明合并到不超过此行数
+	maxChunkLines = 120 // 超过此行数的声明按窗口拆分
+	windowOverlap = 10  // 拆分或无法识别声明时相邻窗口重叠的行数
+)
+
+// declPattern 声明起始行的匹配规则，maxIndent 为声明允许的最大缩进（列），
+// 大于0时类中的方法也作为边界
+type declPattern struct {
+	maxIndent int
+	patterns  []*regexp.Regexp
+}
+
+// controlKeywords 形如方法调用、但不是声明的行首关键字
+var controlKeywords = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "catch": true, "return": true,
+	"else": true, "do": true, "try": true, "with": true, "new": true, "throw": true, "await": true,
+}
+
+var jsDecls = declPattern{maxIndent: 4, patterns: []*regexp.Regexp{
+	regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:declare\s+)?(?:async\s+)?(?:function\*?\s|(?:abstract\s+)?class\s|interface\s|enum\s|type\s+\w+.*=)`),
+	regexp.MustCompile(`^(?:export\s+)?(?:const|let|var)\s+[\w$]+\s*(?::[^=]+)?=\s*(?:async\s+)?(?:function|\([^)]*\)\s*(?::[^=]+)?=>|[\w$]+\s*=>)`),
+	regexp.MustCompile(`^(?:(?:public|private|protected|static|async|readonly|override|get|set)\s+)*\*?([A-Za-z_$][\w$]*)\s*\([^;]*\)\s*(?::[^{;]+)?\{\s*$`),
+}}
+
+var jvmDecls = declPattern{maxIndent: 4, patterns: []*regexp.Regexp{
+	regexp.MustCompile(`^(?:@\w+\s+)*(?:(?:public|protected|private|internal|static|final|abstract|override|open|sealed|data|partial|virtual|suspend|inline)\s+)*(?:class|interface|enum|record|struct|object|fun)\s+\w`),
+	regexp.MustCompile(`^(?:(?:public|protected|private|internal|static|final|abstract|override|virtual|async|synchronized)\s+)+[\w<>\[\],.?]+(?:\s+[\w<>\[\],.?]+)*\s+(\w+)\s*\(`),
+}}
+
+var cDecls = declPattern{patterns: []*regexp.Regexp{
+	regexp.MustCompile(`^(?:typedef\s+)?(?:struct|class|enum|union|namespace)\s+\w+[^;]*$`),
+	regexp.MustCompile(`^(?:template\s*<.*>\s*)?[A-Za-z_][\w\s\*&:<>,]*?[\s\*&]~?([A-Za-z_][\w:]*)\s*\([^;]*$`),
+}}
+
+// declPatterns 按扩展名的声明规则，Go 使用语法树，其他语言按行匹配
+var declPatterns = map[string]declPattern{
+	".py": {maxIndent: 4, patterns: []*regexp.Regexp{
+		regexp.MustCompile(`^(?:async\s+)?(?:def|class)\s+\w`),
+	}},
+	".js": jsDecls, ".jsx": jsDecls, ".ts": jsDecls, ".tsx": jsDecls, ".mjs": jsDecls, ".cjs": jsDecls,
+	".java": jvmDecls, ".kt": jvmDecls, ".cs": jvmDecls, ".scala": jvmDecls,
+	".rs": {maxIndent: 4, patterns: []*regexp.Regexp{
+		regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?(?:(?:async|unsafe|const|extern(?:\s+"\w+")?)\s+)*(?:fn|struct|enum|trait|type|mod|impl|union|macro_rules!)\b`),
+	}},
+	".rb": {maxIndent: 2, patterns: []*regexp.Regexp{
+		regexp.MustCompile(`^(?:def|class|module)\s`),
+	}},
+	".php": {maxIndent: 4, patterns: []*regexp.Regexp{
+		regexp.MustCompile(`^(?:(?:abstract|final|public|protected|private|static)\s+)*(?:class|interface|trait|enum|function)\s`),
+	}},
+	".c": cDecls, ".h": cDecls, ".cc": cDecls, ".cpp": cDecls, ".hpp": cDecls, ".cxx": cDecls,
+	".swift": {maxIndent: 4, patterns: []*regexp.Regexp{
+		regexp.MustCompile(`^(?:@\w+\s+)*(?:(?:public|private|internal|fileprivate|open|static|final|override|mutating)\s+)*(?:func|class|struct|enum|protocol|extension)\s`),
+	}},
+	".sh":   {patterns: []*regexp.Regexp{regexp.MustCompile(`^(?:function\s+\w+|\w+\s*\(\)\s*\{?)`)}},
+	".bash": {patterns: []*regexp.Regexp{regexp.MustCompile(`^(?:function\s+\w+|\w+\s*\(\)\s*\{?)`)}},
+}
+
+// chunkFile 按函数、类等声明的边界分段：相邻的小声明合并，过长的声明按窗口拆分，
+// 无法识别声明的文件按固定行数分段
+func chunkFile(rel string, content []byte) []Chunk {
+	lines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	var boundaries []int
+	ext := strings.ToLower(filepath.Ext(rel))
+	if ext == ".go" {
+		boundaries = goBoundaries(content)
+	} else if rules, ok := declPatterns[ext]; ok {
+		boundaries = patternBoundaries(lines, rules)
+	}
+
+	var chunks []Chunk
+	add := func(start, end int) {
+		text := strings.Join(lines[start:end], "\n")
+		if strings.TrimSpace(text) == "" {
+			return
+		}
+		chunks = append(chunks, Chunk{
+			ID:        fmt.Sprintf("%s:%d-%d", rel, start+1, end),
+			Path:      rel,
+			StartLine: start + 1,
+			EndLine:   end,
+			Content:   text,
+		})
+	}
+	if len(boundaries) == 0 {
+		for _, window := range windows(0, len(lines), chunkLines) {
+			add(window[0], window[1])
+		}
+		return chunks
+	}
+
+	// 每个声明从其边界延续到下一个边界，第一个边界之前为文件头（包声明、导入等）
+	units := make([][2]int, 0, len(boundaries)+1)
+	start := 0
+	for _, boundary := range append(boundaries, len(lines)) {
+		if boundary > start {
+			units = append(units, [2]int{start, boundary})
+			start = boundary
+		}
+	}
+
+	current := [2]int{-1, -1}
+	flush := func() {
+		if current[0] >= 0 {
+			add(current[0], current[1])
+			current = [2]int{-1, -1}
+		}
+	}
+	for _, unit := range units {
+		if unit[1]-unit[0] > maxChunkLines {
+			flush()
+			for _, window := range windows(unit[0], unit[1], chunkLines) {
+				add(window[0], window[1])
+			}
+			continue
+		}
+		if current[0] >= 0 && unit[1]-current[0] > chunkLines {
+			flush()
+		}
+		if current[0] < 0 {
+			current[0] = unit[0]
+		}
+		current[1] = unit[1]
+	}
+	flush()
+	return chunks
+}
+
+// windows 将 [start, end) 按固定行数拆分，相邻窗口有少量重叠以保留上下文
+func windows(start, end, size int) [][2]int {
+	var result [][2]int
+	for from := start; from < end; from += size - windowOverlap {
+		to := from + size
+		if to > end {
+			to = end
+		}
+		result = append(result, [2]int{from, to})
+		if to == end {
+			break
+		}
+	}
+	return result
+}
+
+// goBoundaries 用语法树找出顶层声明的起始行（含文档注释），解析失败时返回nil
+func goBoundaries(content []byte) []int {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", content, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+	var boundaries []int
+	for _, decl := range file.Decls {
+		pos := decl.Pos()
+		if doc := declDoc(decl); doc != nil {
+			pos = doc.Pos()
+		}
+		line := fset.Position(pos).Line - 1
+		if len(boundaries) == 0 || line > boundaries[len(boundaries)-1] {
+			boundaries = append(boundaries, line)
+		}
+	}
+	return boundaries
+}
+
+// declDoc 声明的文档注释
+func declDoc(decl ast.Decl) *ast.CommentGroup {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		return d.Doc
+	case *ast.GenDecl:
+		return d.Doc
+	}
+	return nil
+}
+
+// patternBoundaries 按声明规则逐行匹配，声明前紧邻的注释与装饰器归入该声明
+func patternBoundaries(lines []string, rules declPattern) []int {
+	var boundaries []int
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" || indentWidth(line[:len(line)-len(trimmed)]) > rules.maxIndent {
+			continue
+		}
+		if !matchesDecl(trimmed, rules.patterns) {
+			continue
+		}
+		start := i
+		for start > 0 && isCommentOrDecorator(lines[start-1]) {
+			start--
+		}
+		if len(boundaries) == 0 || start > boundaries[len(boundaries)-1] {
+			boundaries = append(boundaries, start)
+		}
+	}
+	return boundaries
+}
+
+// matchesDecl 行是否为声明，排除 if (...) { 之类的控制语句
+func matchesDecl(line string, patterns []*regexp.Regexp) bool {
+	for _, pattern := range patterns {
+		match := pattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		if len(match) > 1 && controlKeywords[match[1]] {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// indentWidth 缩进的列数，制表符按4列计算
+func indentWidth(indent string) int {
+	return len(strings.ReplaceAll(indent, "\t", "    "))
+}
+
+// isCommentOrDecorator 是否为注释或装饰器、注解行
+func isCommentOrDecorator(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	for _, prefix := range []string{"//", "#", "/*", "*", "@", "///", "--"} {
+		if strings.HasPrefix(trimmed, prefix) && !strings.HasPrefix(trimmed, "#include") && !strings.HasPrefix(trimmed, "#!") {
+			return true
+		}
+	}
+	return false
+}
\ No newline at end of file
@@ -0,0 +1,81 @@
+package index
+
+import (
+	"regexp"
+	"strings"
+)
+
+// 融合检索的参数
+const (
+	rrfK            = 60 // 倒数排名融合的平滑常数
+	candidateFactor = 4  // 每种检索取 limit 的多少倍作为候选
+)
+
+// identifierPattern 由标识符字符组成的词，可含点号（如 pkg.Func）
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_$][\w$]*(?:\.[A-Za-z_$][\w$]*)*$`)
+
+// fuse 按倒数排名融合语义与词项检索的结果。查询只含标识符时提高词项检索的权重，
+// 原样包含查询中标识符的代码段额外加分，使精确的标识符排在最前
+func fuse(query string, semantic, lexical []Result, limit int) []Result {
+	identifiers := queryIdentifiers(query)
+	lexicalWeight := 1.0
+	if len(identifiers) > 0 && len(identifiers) == len(strings.Fields(query)) {
+		lexicalWeight = 2.0
+	}
+
+	scores := make(map[string]float64)
+	chunks := make(map[string]Chunk)
+	var order []string
+	add := func(results []Result, weight float64) {
+		for rank, result := range results {
+			if _, ok := chunks[result.ID]; !ok {
+				chunks[result.ID] = result.Chunk
+				order = append(order, result.ID)
+			}
+			scores[result.ID] += weight / float64(rrfK+rank+1)
+		}
+	}
+	add(semantic, 1.0)
+	add(lexical, lexicalWeight)
+
+	results := make([]Result, 0, len(order))
+	for _, id := range order {
+		chunk := chunks[id]
+		score := scores[id]
+		for _, ident := range identifiers {
+			if strings.Contains(chunk.Content, ident) {
+				score += 1.0 / rrfK
+			}
+		}
+		results = append(results, Result{Chunk: chunk, Score: score})
+	}
+	return topResults(results, limit)
+}
+
+// queryIdentifiers 查询中看起来像代码标识符的词
+func queryIdentifiers(query string) []string {
+	var identifiers []string
+	for _, word := range strings.Fields(query) {
+		word = strings.Trim(word, "`'\"()[]{},;:")
+		if isIdentifier(word) {
+			identifiers = append(identifiers, word)
+		}
+	}
+	return identifiers
+}
+
+// isIdentifier 词是否看起来像代码标识符而非普通单词：含下划线、点号或内部的大写字母
+func isIdentifier(word string) bool {
+	if !identifierPattern.MatchString(word) {
+		return false
+	}
+	if strings.ContainsAny(word, "_.$") {
+		return true
+	}
+	for i := 1; i < len(word); i++ {
+		if word[i] >= 'A' && word[i] <= 'Z' && word[i-1] >= 'a' && word[i-1] <= 'z' {
+			return true
+		}
+	}
+	return false
+}
\ No newline at end of file
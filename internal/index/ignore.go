@@ -0,0 +1,190 @@
+package index
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignoreFiles 读取忽略规则的文件，.opencursorignore 只影响索引，不影响 git
+var ignoreFiles = []string{".gitignore", ".opencursorignore"}
+
+// ignoreRule 一条 gitignore 语法的规则
+type ignoreRule struct {
+	base    string // 规则文件所在目录（相对仓库根目录，根目录为空）
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreMatcher 仓库内所有忽略规则，后出现的规则优先（与 git 相同）
+type ignoreMatcher struct {
+	rules []ignoreRule
+}
+
+// loadIgnore 读取仓库根目录及各子目录中的 .gitignore 与 .opencursorignore
+func loadIgnore(root string) *ignoreMatcher {
+	m := &ignoreMatcher{}
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			rel = ""
+		} else if ignoredDir(info.Name()) || m.ignored(rel, true) {
+			return filepath.SkipDir
+		}
+		for _, name := range ignoreFiles {
+			m.load(filepath.Join(path, name), rel)
+		}
+		return nil
+	})
+	return m
+}
+
+// load 读取一个规则文件
+func (m *ignoreMatcher) load(path, base string) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreRule(scanner.Text(), base); ok {
+			m.rules = append(m.rules, rule)
+		}
+	}
+}
+
+// parseIgnoreRule 将 gitignore 规则转换为正则表达式
+func parseIgnoreRule(line, base string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+	rule := ignoreRule{base: base}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+	// 含有 / 的规则相对于规则文件所在目录，否则匹配任意层级的名称
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	var sb strings.Builder
+	if anchored {
+		sb.WriteString("^")
+	} else {
+		sb.WriteString("(?:^|/)")
+	}
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case strings.HasPrefix(line[i:], "**/"):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(line[i:], "/**") && i+3 == len(line):
+			sb.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(line[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(line[i:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := line[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end
+		case c == '\\' && i+1 < len(line):
+			i++
+			sb.WriteString(regexp.QuoteMeta(string(line[i])))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return ignoreRule{}, false
+	}
+	rule.re = re
+	return rule, true
+}
+
+// ignored 路径（相对仓库根目录，使用 /）是否被规则忽略
+func (m *ignoreMatcher) ignored(rel string, isDir bool) bool {
+	if m == nil {
+		return false
+	}
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		path := rel
+		if rule.base != "" {
+			if !strings.HasPrefix(rel, rule.base+"/") {
+				continue
+			}
+			path = rel[len(rule.base)+1:]
+		}
+		if rule.re.MatchString(path) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// ignoredPath 路径本身或其所在的任一目录是否被忽略
+func (m *ignoreMatcher) ignoredPath(rel string) bool {
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		if m.ignored(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return m.ignored(rel, false)
+}
+
+// IgnoreFilter 读取仓库的忽略规则，返回判断路径（相对仓库根目录，使用 /）是否被忽略的函数。
+// 与索引相同，默认忽略的目录（如 node_modules）与隐藏文件也视为忽略
+func IgnoreFilter(root string) func(rel string, isDir bool) bool {
+	m := loadIgnore(root)
+	return func(rel string, isDir bool) bool {
+		parts := strings.Split(rel, "/")
+		for _, part := range parts[:len(parts)-1] {
+			if ignoredDir(part) {
+				return true
+			}
+		}
+		name := parts[len(parts)-1]
+		if isDir {
+			return ignoredDir(name) || m.ignored(rel, true) || m.ignoredPath(rel)
+		}
+		return strings.HasPrefix(name, ".") || m.ignoredPath(rel)
+	}
+}
\ No newline at end of file
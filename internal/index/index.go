@@ -0,0 +1,599 @@
+package index
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"openCursor/internal/embeddings"
+)
+
+// 索引的文件与分段限制
+const (
+	maxFileSize = 512 * 1024 // 参与索引的单个文件最大字节数
+	maxFiles    = 20000      // 参与索引的最多文件数
+)
+
+// manifestVersion 清单格式或分段方式变化时递增，旧索引会被重建
+const manifestVersion = 3
+
+// ignoredDirs 不参与索引的目录
+var ignoredDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, "dist": true, "build": true,
+	"target": true, "__pycache__": true, ".venv": true, "venv": true, ".idea": true,
+	".vscode": true, ".opencursor": true,
+}
+
+// FileState 已索引文件的状态
+type FileState struct {
+	Hash    string    `json:"hash"`
+	ModTime time.Time `json:"mod_time"`
+	Size    int64     `json:"size"`
+	Chunks  int       `json:"chunks"`
+}
+
+// manifest 索引清单，记录生成向量的嵌入服务与存储，二者变化时需要重建
+type manifest struct {
+	Version   int                   `json:"version"`
+	Embedder  string                `json:"embedder"`
+	Store     string                `json:"store"`
+	UpdatedAt time.Time             `json:"updated_at"`
+	Files     map[string]*FileState `json:"files"`
+}
+
+// UpdateStats 一次更新的统计
+type UpdateStats struct {
+	Indexed   int `json:"indexed"`   // 新增或修改后重新索引的文件
+	Removed   int `json:"removed"`   // 已删除的文件
+	Unchanged int `json:"unchanged"` // 未变化的文件
+	Chunks    int `json:"chunks"`    // 新写入的代码段
+	Tokens    int `json:"tokens"`    // 新写入代码段的估计token数（嵌入服务的用量）
+	Ignored   int `json:"ignored"`   // 被 .gitignore 或 .opencursorignore 忽略的文件
+	TooLarge  int `json:"too_large"` // 超过大小限制的文件
+	Binary    int `json:"binary"`    // 二进制文件
+}
+
+// Progress 更新进度回调，done 为已处理的文件数
+type Progress func(done, total int)
+
+// Index 仓库的语义索引：文件分段后经嵌入服务写入向量存储
+type Index struct {
+	root     string
+	dir      string
+	embedder embeddings.Embedder
+	store    Store
+	storeCfg StoreConfig
+
+	mu       sync.Mutex
+	manifest *manifest
+	lexical  *lexicalIndex
+	ignore   atomic.Pointer[ignoreMatcher] // 后台监听也会读取
+	watcher  *Watcher
+}
+
+// Dir 仓库的索引目录
+func Dir(root string) string {
+	return filepath.Join(root, ".opencursor", "index")
+}
+
+// Open 打开仓库的索引。嵌入服务或存储与清单记录的不同时，清空旧索引
+func Open(root string, embedder embeddings.Embedder, cfg StoreConfig) (*Index, error) {
+	if embedder == nil {
+		return nil, fmt.Errorf("code index requires an embeddings provider")
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", root, err)
+	}
+	dir := Dir(absRoot)
+	store, err := OpenStore(cfg, dir, absRoot)
+	if err != nil {
+		return nil, err
+	}
+	idx := &Index{root: absRoot, dir: dir, embedder: embedder, store: store, storeCfg: cfg}
+	idx.manifest = idx.loadManifest()
+	idx.lexical = loadLexical(filepath.Join(dir, "lexical.gob"))
+	idx.ignore.Store(loadIgnore(absRoot))
+	if idx.manifest.Embedder != embedder.Name() || idx.manifest.Store != idx.storeKey() || idx.manifest.Version != manifestVersion {
+		if len(idx.manifest.Files) > 0 {
+			if err := store.Clear(context.Background()); err != nil {
+				store.Close()
+				return nil, fmt.Errorf("failed to reset index: %w", err)
+			}
+		}
+		idx.lexical.clear()
+		idx.manifest = idx.newManifest()
+	}
+	return idx, nil
+}
+
+// storeKey 存储的标识，路径、地址或集合变化时视为不同的存储
+func (idx *Index) storeKey() string {
+	store := strings.ToLower(idx.storeCfg.Store)
+	if store == "" {
+		store = StoreMemory
+	}
+	switch store {
+	case StoreQdrant, StoreChroma:
+		return store + ":" + idx.storeCfg.URL + "/" + collectionName(idx.storeCfg, idx.root)
+	}
+	return store + ":" + idx.storeCfg.Path
+}
+
+// newManifest 创建空清单
+func (idx *Index) newManifest() *manifest {
+	return &manifest{
+		Version:  manifestVersion,
+		Embedder: idx.embedder.Name(),
+		Store:    idx.storeKey(),
+		Files:    make(map[string]*FileState),
+	}
+}
+
+// loadManifest 读取清单，不存在或损坏时返回空清单
+func (idx *Index) loadManifest() *manifest {
+	data, err := os.ReadFile(filepath.Join(idx.dir, "manifest.json"))
+	if err != nil {
+		return &manifest{Files: make(map[string]*FileState)}
+	}
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil || m.Files == nil {
+		return &manifest{Files: make(map[string]*FileState)}
+	}
+	return &m
+}
+
+// saveManifest 写入清单（先写临时文件再重命名）
+func (idx *Index) saveManifest() error {
+	if err := os.MkdirAll(idx.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create index directory: %w", err)
+	}
+	data, err := json.MarshalIndent(idx.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(idx.dir, "manifest.json")
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("failed to write index manifest: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to write index manifest: %w", err)
+	}
+	return nil
+}
+
+// Root 索引的仓库根目录
+func (idx *Index) Root() string {
+	return idx.root
+}
+
+// Update 增量更新：重新索引内容变化的文件，删除已不存在的文件
+func (idx *Index) Update(ctx context.Context, progress Progress) (*UpdateStats, error) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.ignore.Store(loadIgnore(idx.root))
+	stats := &UpdateStats{}
+	files, err := idx.collectFiles(idx.root, stats)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(files))
+	for i, rel := range files {
+		seen[rel] = true
+		if progress != nil {
+			progress(i, len(files))
+		}
+		if err := ctx.Err(); err != nil {
+			idx.save()
+			return stats, err
+		}
+		changed, chunks, tokens, err := idx.updateFile(ctx, rel)
+		if err != nil {
+			// 已完成的部分仍然保存，下次从中断处继续
+			idx.save()
+			return stats, fmt.Errorf("failed to index %s: %w", rel, err)
+		}
+		if changed {
+			stats.Indexed++
+			stats.Chunks += chunks
+			stats.Tokens += tokens
+		} else {
+			stats.Unchanged++
+		}
+	}
+	if progress != nil {
+		progress(len(files), len(files))
+	}
+
+	var removed []string
+	for rel := range idx.manifest.Files {
+		if !seen[rel] {
+			removed = append(removed, rel)
+		}
+	}
+	if len(removed) > 0 {
+		sort.Strings(removed)
+		if err := idx.store.DeletePaths(ctx, removed); err != nil {
+			return stats, fmt.Errorf("failed to remove deleted files from index: %w", err)
+		}
+		for _, rel := range removed {
+			delete(idx.manifest.Files, rel)
+		}
+		idx.lexical.remove(removed)
+		stats.Removed = len(removed)
+	}
+
+	idx.manifest.UpdatedAt = time.Now()
+	return stats, idx.save()
+}
+
+// UpdatePaths 只重新索引指定的文件或目录（相对路径），已删除或不再需要索引的文件从索引中移除
+func (idx *Index) UpdatePaths(ctx context.Context, paths []string) (*UpdateStats, error) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	stats := &UpdateStats{}
+	var removed []string
+	for _, rel := range idx.expandPaths(paths) {
+		if err := ctx.Err(); err != nil {
+			idx.save()
+			return stats, err
+		}
+		path := filepath.Join(idx.root, filepath.FromSlash(rel))
+		info, err := os.Stat(path)
+		if err != nil || !idx.indexable(rel, path, info) {
+			if _, ok := idx.manifest.Files[rel]; ok {
+				removed = append(removed, rel)
+			}
+			continue
+		}
+		changed, chunks, tokens, err := idx.updateFile(ctx, rel)
+		if err != nil {
+			idx.save()
+			return stats, fmt.Errorf("failed to index %s: %w", rel, err)
+		}
+		if changed {
+			stats.Indexed++
+			stats.Chunks += chunks
+			stats.Tokens += tokens
+		} else {
+			stats.Unchanged++
+		}
+	}
+	if len(removed) > 0 {
+		if err := idx.store.DeletePaths(ctx, removed); err != nil {
+			return stats, fmt.Errorf("failed to remove deleted files from index: %w", err)
+		}
+		for _, rel := range removed {
+			delete(idx.manifest.Files, rel)
+		}
+		idx.lexical.remove(removed)
+		stats.Removed = len(removed)
+	}
+	if stats.Indexed == 0 && stats.Removed == 0 {
+		return stats, nil
+	}
+	idx.manifest.UpdatedAt = time.Now()
+	return stats, idx.save()
+}
+
+// expandPaths 目录展开为其中的文件：磁盘上的文件与清单中记录的文件（可能已被删除）
+func (idx *Index) expandPaths(paths []string) []string {
+	seen := make(map[string]bool)
+	var files []string
+	add := func(rel string) {
+		if !seen[rel] {
+			seen[rel] = true
+			files = append(files, rel)
+		}
+	}
+	for _, rel := range paths {
+		rel = filepath.ToSlash(filepath.Clean(rel))
+		path := filepath.Join(idx.root, filepath.FromSlash(rel))
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			if under, err := idx.collectFiles(path, &UpdateStats{}); err == nil {
+				for _, file := range under {
+					add(rel + "/" + file)
+				}
+			}
+		} else {
+			add(rel)
+		}
+		for file := range idx.manifest.Files {
+			if strings.HasPrefix(file, rel+"/") {
+				add(file)
+			}
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// Refresh 使索引与工作区一致：监听文件变化时只处理变化的文件，否则按修改时间完整比对
+func (idx *Index) Refresh(ctx context.Context) (*UpdateStats, error) {
+	if idx.watcher != nil {
+		if paths, ok := idx.watcher.take(); ok {
+			return idx.UpdatePaths(ctx, paths)
+		}
+	}
+	return idx.Update(ctx, nil)
+}
+
+// save 保存向量存储与清单
+func (idx *Index) save() error {
+	if flusher, ok := idx.store.(interface{ Flush() error }); ok {
+		if err := flusher.Flush(); err != nil {
+			return err
+		}
+	}
+	if err := idx.lexical.save(); err != nil {
+		return err
+	}
+	return idx.saveManifest()
+}
+
+// updateFile 文件修改时间或大小变化且内容哈希不同时重新索引，返回是否重新索引、代码段数与token数
+func (idx *Index) updateFile(ctx context.Context, rel string) (bool, int, int, error) {
+	path := filepath.Join(idx.root, filepath.FromSlash(rel))
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, 0, 0, nil
+	}
+	state := idx.manifest.Files[rel]
+	if state != nil && state.ModTime.Equal(info.ModTime()) && state.Size == info.Size() {
+		return false, 0, 0, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false, 0, 0, nil
+	}
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+	if state != nil && state.Hash == hash {
+		state.ModTime = info.ModTime()
+		state.Size = info.Size()
+		return false, 0, 0, nil
+	}
+
+	chunks := chunkFile(rel, content)
+	if len(chunks) == 0 {
+		if err := idx.store.DeletePaths(ctx, []string{rel}); err != nil {
+			return false, 0, 0, err
+		}
+	} else {
+		texts := make([]string, len(chunks))
+		for i, chunk := range chunks {
+			texts[i] = chunk.Path + "\n" + chunk.Content
+		}
+		vectors, err := idx.embedder.Embed(ctx, texts)
+		if err != nil {
+			return false, 0, 0, err
+		}
+		records := make([]Record, len(chunks))
+		for i, chunk := range chunks {
+			records[i] = Record{Chunk: chunk, Vector: embeddings.Normalize(vectors[i])}
+		}
+		if err := idx.store.Upsert(ctx, records); err != nil {
+			return false, 0, 0, err
+		}
+	}
+	idx.lexical.set(rel, chunks)
+	tokens := 0
+	for _, chunk := range chunks {
+		tokens += estimateTokens(chunk.Content)
+	}
+	idx.manifest.Files[rel] = &FileState{Hash: hash, ModTime: info.ModTime(), Size: info.Size(), Chunks: len(chunks)}
+	return true, len(chunks), tokens, nil
+}
+
+// Search 返回与查询最相关的代码段：向量相似度与 BM25 的排名经倒数排名融合（RRF）合并，
+// Score 为融合后的分数
+func (idx *Index) Search(ctx context.Context, query string, limit int) ([]Result, error) {
+	candidates := limit * candidateFactor
+	vectors, err := idx.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) == 0 {
+		return nil, fmt.Errorf("embeddings provider returned no vector for the query")
+	}
+	semantic, err := idx.store.Search(ctx, embeddings.Normalize(vectors[0]), candidates)
+	if err != nil {
+		return nil, err
+	}
+	idx.mu.Lock()
+	lexical := idx.lexical.search(query, candidates)
+	idx.mu.Unlock()
+	return fuse(query, semantic, lexical, limit), nil
+}
+
+// Stats 索引的统计
+type Stats struct {
+	Root       string           `json:"root"`
+	Embedder   string           `json:"embedder"`
+	Store      string           `json:"store"`
+	Files      int              `json:"files"`
+	Chunks     int              `json:"chunks"`
+	Tokens     int              `json:"tokens"` // 代码段的估计token数
+	DiskBytes  int64            `json:"disk_bytes"`
+	UpdatedAt  time.Time        `json:"updated_at,omitempty"`
+	Extensions []ExtensionStats `json:"extensions"`
+}
+
+// ExtensionStats 按扩展名统计的文件与代码段
+type ExtensionStats struct {
+	Extension string `json:"extension"`
+	Files     int    `json:"files"`
+	Chunks    int    `json:"chunks"`
+	Tokens    int    `json:"tokens"`
+}
+
+// Stats 返回当前索引的统计，不更新索引
+func (idx *Index) Stats() *Stats {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	stats := &Stats{
+		Root:      idx.root,
+		Embedder:  idx.embedder.Name(),
+		Store:     idx.storeKey(),
+		Files:     len(idx.manifest.Files),
+		UpdatedAt: idx.manifest.UpdatedAt,
+	}
+	byExt := make(map[string]*ExtensionStats)
+	for rel, state := range idx.manifest.Files {
+		ext := strings.ToLower(filepath.Ext(rel))
+		if ext == "" {
+			ext = filepath.Base(rel)
+		}
+		entry := byExt[ext]
+		if entry == nil {
+			entry = &ExtensionStats{Extension: ext}
+			byExt[ext] = entry
+		}
+		entry.Files++
+		entry.Chunks += state.Chunks
+		stats.Chunks += state.Chunks
+		for _, doc := range idx.lexical.docs[rel] {
+			tokens := estimateTokens(doc.Chunk.Content)
+			entry.Tokens += tokens
+			stats.Tokens += tokens
+		}
+	}
+	for _, entry := range byExt {
+		stats.Extensions = append(stats.Extensions, *entry)
+	}
+	sort.Slice(stats.Extensions, func(i, j int) bool {
+		a, b := stats.Extensions[i], stats.Extensions[j]
+		if a.Chunks != b.Chunks {
+			return a.Chunks > b.Chunks
+		}
+		return a.Extension < b.Extension
+	})
+	filepath.Walk(idx.dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			stats.DiskBytes += info.Size()
+		}
+		return nil
+	})
+	return stats
+}
+
+// Clear 清空索引与清单
+func (idx *Index) Clear(ctx context.Context) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if err := idx.store.Clear(ctx); err != nil {
+		return err
+	}
+	if err := idx.lexical.clear(); err != nil {
+		return err
+	}
+	idx.manifest = idx.newManifest()
+	if err := os.Remove(filepath.Join(idx.dir, "manifest.json")); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove index manifest: %w", err)
+	}
+	return nil
+}
+
+// Close 停止监听，保存并关闭存储
+func (idx *Index) Close() error {
+	if idx.watcher != nil {
+		idx.watcher.stop()
+	}
+	return idx.store.Close()
+}
+
+// collectFiles 收集目录（仓库根目录或其子目录）内需要索引的文本文件（相对仓库根目录），
+// 跳过的文件计入 stats
+func (idx *Index) collectFiles(dir string, stats *UpdateStats) ([]string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil // 忽略错误，继续处理其他文件
+		}
+		rel, err := filepath.Rel(idx.root, path)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		name := info.Name()
+		if info.IsDir() {
+			if path != idx.root && (ignoredDir(name) || idx.ignore.Load().ignored(rel, true)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if len(files) >= maxFiles {
+			return filepath.SkipDir
+		}
+		if !info.Mode().IsRegular() || info.Size() == 0 || strings.HasPrefix(name, ".") {
+			return nil
+		}
+		switch {
+		case idx.ignore.Load().ignored(rel, false):
+			stats.Ignored++
+		case info.Size() > maxFileSize:
+			stats.TooLarge++
+		case !isTextFile(path):
+			stats.Binary++
+		default:
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk directory: %w", err)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// indexable 文件需要索引：文本文件，未超过大小限制，且本身及所在目录未被忽略
+func (idx *Index) indexable(rel, path string, info os.FileInfo) bool {
+	if !info.Mode().IsRegular() || info.Size() == 0 || info.Size() > maxFileSize || strings.HasPrefix(info.Name(), ".") {
+		return false
+	}
+	parts := strings.Split(rel, "/")
+	for _, dir := range parts[:len(parts)-1] {
+		if ignoredDir(dir) {
+			return false
+		}
+	}
+	if idx.ignore.Load().ignoredPath(rel) {
+		return false
+	}
+	return isTextFile(path)
+}
+
+// estimateTokens 粗略估算文本的token数量（约4个字符一个token）
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// ignoredDir 目录是否不参与索引
+func ignoredDir(name string) bool {
+	return ignoredDirs[name] || strings.HasPrefix(name, ".")
+}
+
+// isTextFile 文件开头不含NUL字节时视为文本
+func isTextFile(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	buf := make([]byte, 8000)
+	n, _ := file.Read(buf)
+	return !bytes.Contains(buf[:n], []byte{0})
+}
\ No newline at end of file
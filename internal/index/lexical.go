@@ -0,0 +1,218 @@
+package index
+
+import (
+	"encoding/gob"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// BM25 参数
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// lexicalDoc 代码段的词频
+type lexicalDoc struct {
+	Chunk  Chunk
+	Terms  map[string]int
+	Length int
+}
+
+// lexicalIndex 代码段的词项索引，用于 BM25 检索。与向量存储分开保存在索引目录中，
+// 使外部向量服务也能与精确的标识符匹配结合
+type lexicalIndex struct {
+	path  string
+	docs  map[string][]lexicalDoc // 按文件
+	dirty bool
+}
+
+// loadLexical 读取词项索引，不存在或损坏时返回空索引
+func loadLexical(path string) *lexicalIndex {
+	l := &lexicalIndex{path: path, docs: make(map[string][]lexicalDoc)}
+	file, err := os.Open(path)
+	if err != nil {
+		return l
+	}
+	defer file.Close()
+	if err := gob.NewDecoder(file).Decode(&l.docs); err != nil {
+		l.docs = make(map[string][]lexicalDoc)
+	}
+	return l
+}
+
+// set 替换文件的代码段
+func (l *lexicalIndex) set(path string, chunks []Chunk) {
+	if len(chunks) == 0 {
+		delete(l.docs, path)
+		l.dirty = true
+		return
+	}
+	docs := make([]lexicalDoc, 0, len(chunks))
+	for _, chunk := range chunks {
+		terms := make(map[string]int)
+		length := 0
+		for _, term := range tokenize(chunk.Content) {
+			terms[term]++
+			length++
+		}
+		docs = append(docs, lexicalDoc{Chunk: chunk, Terms: terms, Length: length})
+	}
+	l.docs[path] = docs
+	l.dirty = true
+}
+
+// remove 删除文件的代码段
+func (l *lexicalIndex) remove(paths []string) {
+	for _, path := range paths {
+		delete(l.docs, path)
+	}
+	l.dirty = true
+}
+
+// clear 清空并删除索引文件
+func (l *lexicalIndex) clear() error {
+	l.docs = make(map[string][]lexicalDoc)
+	l.dirty = false
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove lexical index: %w", err)
+	}
+	return nil
+}
+
+// save 有修改时写入磁盘（先写临时文件再重命名）
+func (l *lexicalIndex) save() error {
+	if !l.dirty {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create index directory: %w", err)
+	}
+	tmp := l.path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to write lexical index: %w", err)
+	}
+	if err := gob.NewEncoder(file).Encode(l.docs); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write lexical index: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write lexical index: %w", err)
+	}
+	if err := os.Rename(tmp, l.path); err != nil {
+		return fmt.Errorf("failed to write lexical index: %w", err)
+	}
+	l.dirty = false
+	return nil
+}
+
+// search 按 BM25 返回最相关的代码段，Score 为 BM25 分数
+func (l *lexicalIndex) search(query string, limit int) []Result {
+	terms := uniqueTerms(tokenize(query))
+	if len(terms) == 0 {
+		return nil
+	}
+	total, totalLength := 0, 0
+	df := make(map[string]int, len(terms))
+	for _, docs := range l.docs {
+		for _, doc := range docs {
+			total++
+			totalLength += doc.Length
+			for _, term := range terms {
+				if doc.Terms[term] > 0 {
+					df[term]++
+				}
+			}
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	avgLength := float64(totalLength) / float64(total)
+
+	var results []Result
+	for _, docs := range l.docs {
+		for _, doc := range docs {
+			score := 0.0
+			for _, term := range terms {
+				tf := float64(doc.Terms[term])
+				if tf == 0 {
+					continue
+				}
+				idf := math.Log(1 + (float64(total)-float64(df[term])+0.5)/(float64(df[term])+0.5))
+				score += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*float64(doc.Length)/avgLength))
+			}
+			if score > 0 {
+				results = append(results, Result{Chunk: doc.Chunk, Score: score})
+			}
+		}
+	}
+	return topResults(results, limit)
+}
+
+// tokenize 提取小写的词项：完整的标识符及其按驼峰、下划线拆分的各部分，
+// 使 RetryRequest 既能精确匹配，也能被 "retry request" 检索到
+func tokenize(text string) []string {
+	var terms []string
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	for _, field := range fields {
+		if len(field) < 2 {
+			continue
+		}
+		lower := strings.ToLower(field)
+		terms = append(terms, lower)
+		parts := splitIdentifier(field)
+		if len(parts) > 1 {
+			for _, part := range parts {
+				if len(part) >= 2 {
+					terms = append(terms, strings.ToLower(part))
+				}
+			}
+		}
+	}
+	return terms
+}
+
+// splitIdentifier 按下划线与大小写变化拆分标识符，如 parseHTTPRequest → parse HTTP Request
+func splitIdentifier(ident string) []string {
+	var parts []string
+	for _, word := range strings.Split(ident, "_") {
+		runes := []rune(word)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			lowerToUpper := unicode.IsLower(runes[i-1]) && unicode.IsUpper(runes[i])
+			acronymEnd := i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsUpper(runes[i]) && unicode.IsLower(runes[i+1])
+			letterDigit := unicode.IsLetter(runes[i-1]) != unicode.IsLetter(runes[i])
+			if lowerToUpper || acronymEnd || letterDigit {
+				parts = append(parts, string(runes[start:i]))
+				start = i
+			}
+		}
+		if start < len(runes) {
+			parts = append(parts, string(runes[start:]))
+		}
+	}
+	return parts
+}
+
+// uniqueTerms 去重并保持顺序
+func uniqueTerms(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	var unique []string
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			unique = append(unique, term)
+		}
+	}
+	return unique
+}
\ No newline at end of file
@@ -0,0 +1,126 @@
+package index
+
+import (
+	"context"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// memoryStore 所有向量保存在内存中，检索时逐个比较，Close 时写入磁盘快照
+type memoryStore struct {
+	path string
+
+	mu     sync.RWMutex
+	byPath map[string][]Record
+	dirty  bool
+}
+
+// openMemoryStore 读取快照，不存在时创建空存储
+func openMemoryStore(path string) (*memoryStore, error) {
+	store := &memoryStore{path: path, byPath: make(map[string][]Record)}
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, fmt.Errorf("failed to open index snapshot: %w", err)
+	}
+	defer file.Close()
+	if err := gob.NewDecoder(file).Decode(&store.byPath); err != nil {
+		// 快照损坏时从空索引重建
+		store.byPath = make(map[string][]Record)
+		store.dirty = true
+	}
+	return store, nil
+}
+
+// Upsert 按文件替换代码段：同一文件的代码段总是一起写入
+func (s *memoryStore) Upsert(ctx context.Context, records []Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	replaced := make(map[string]bool)
+	for _, record := range records {
+		if !replaced[record.Path] {
+			s.byPath[record.Path] = s.byPath[record.Path][:0:0]
+			replaced[record.Path] = true
+		}
+		s.byPath[record.Path] = append(s.byPath[record.Path], record)
+	}
+	s.dirty = true
+	return nil
+}
+
+// DeletePaths 删除文件的代码段
+func (s *memoryStore) DeletePaths(ctx context.Context, paths []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, path := range paths {
+		delete(s.byPath, path)
+	}
+	s.dirty = true
+	return nil
+}
+
+// Search 逐个计算相似度
+func (s *memoryStore) Search(ctx context.Context, vector []float32, limit int) ([]Result, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var results []Result
+	for _, records := range s.byPath {
+		for _, record := range records {
+			results = append(results, Result{Chunk: record.Chunk, Score: score(vector, record.Vector)})
+		}
+	}
+	return topResults(results, limit), nil
+}
+
+// Clear 清空并删除快照
+func (s *memoryStore) Clear(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.byPath = make(map[string][]Record)
+	s.dirty = false
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove index snapshot: %w", err)
+	}
+	return nil
+}
+
+// Close 写入快照
+func (s *memoryStore) Close() error {
+	return s.Flush()
+}
+
+// Flush 有修改时写入快照（先写临时文件再重命名）
+func (s *memoryStore) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.dirty {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create index directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to write index snapshot: %w", err)
+	}
+	if err := gob.NewEncoder(file).Encode(s.byPath); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write index snapshot: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write index snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write index snapshot: %w", err)
+	}
+	s.dirty = false
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,143 @@
+package index
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// defaultQdrantURL Qdrant 的默认地址
+const defaultQdrantURL = "http://localhost:6333"
+
+// qdrantStore 使用 Qdrant 的 REST 接口，集合在第一次写入时按向量维度创建
+type qdrantStore struct {
+	client     *remoteClient
+	collection string
+
+	mu      sync.Mutex
+	created bool
+}
+
+// newQdrantStore 创建 Qdrant 存储
+func newQdrantStore(cfg StoreConfig, collection string) *qdrantStore {
+	return &qdrantStore{
+		client:     newRemoteClient(cfg.URL, defaultQdrantURL, cfg.APIKeyEnv, "api-key"),
+		collection: collection,
+	}
+}
+
+// ensureCollection 集合不存在时创建，使用余弦距离
+func (s *qdrantStore) ensureCollection(ctx context.Context, dimensions int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.created {
+		return nil
+	}
+	status, err := s.client.do(ctx, http.MethodGet, "/collections/"+s.collection, nil, nil)
+	if err != nil && status != http.StatusNotFound {
+		return err
+	}
+	if status == http.StatusNotFound {
+		body := map[string]interface{}{
+			"vectors": map[string]interface{}{"size": dimensions, "distance": "Cosine"},
+		}
+		if _, err := s.client.do(ctx, http.MethodPut, "/collections/"+s.collection, body, nil); err != nil {
+			return err
+		}
+		// 按文件删除代码段需要 path 的索引
+		index := map[string]interface{}{"field_name": "path", "field_schema": "keyword"}
+		if _, err := s.client.do(ctx, http.MethodPut, "/collections/"+s.collection+"/index?wait=true", index, nil); err != nil {
+			return err
+		}
+	}
+	s.created = true
+	return nil
+}
+
+// Upsert 先删除文件原有的代码段再写入
+func (s *qdrantStore) Upsert(ctx context.Context, records []Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+	if err := s.ensureCollection(ctx, len(records[0].Vector)); err != nil {
+		return err
+	}
+	paths := make(map[string]bool)
+	var pathList []string
+	points := make([]map[string]interface{}, 0, len(records))
+	for _, record := range records {
+		if !paths[record.Path] {
+			paths[record.Path] = true
+			pathList = append(pathList, record.Path)
+		}
+		points = append(points, map[string]interface{}{
+			"id":      pointID(record.ID),
+			"vector":  record.Vector,
+			"payload": chunkPayload(record.Chunk),
+		})
+	}
+	if err := s.DeletePaths(ctx, pathList); err != nil {
+		return err
+	}
+	_, err := s.client.do(ctx, http.MethodPut, "/collections/"+s.collection+"/points?wait=true", map[string]interface{}{"points": points}, nil)
+	return err
+}
+
+// DeletePaths 按 path 过滤删除
+func (s *qdrantStore) DeletePaths(ctx context.Context, paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	body := map[string]interface{}{
+		"filter": map[string]interface{}{
+			"must": []interface{}{
+				map[string]interface{}{"key": "path", "match": map[string]interface{}{"any": paths}},
+			},
+		},
+	}
+	status, err := s.client.do(ctx, http.MethodPost, "/collections/"+s.collection+"/points/delete?wait=true", body, nil)
+	if status == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+// Search 向量检索
+func (s *qdrantStore) Search(ctx context.Context, vector []float32, limit int) ([]Result, error) {
+	var response struct {
+		Result []struct {
+			Score   float64                `json:"score"`
+			Payload map[string]interface{} `json:"payload"`
+		} `json:"result"`
+	}
+	body := map[string]interface{}{"vector": vector, "limit": limit, "with_payload": true}
+	status, err := s.client.do(ctx, http.MethodPost, "/collections/"+s.collection+"/points/search", body, &response)
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	results := make([]Result, 0, len(response.Result))
+	for _, point := range response.Result {
+		results = append(results, Result{Chunk: payloadChunk(point.Payload), Score: point.Score})
+	}
+	return results, nil
+}
+
+// Clear 删除集合
+func (s *qdrantStore) Clear(ctx context.Context) error {
+	s.mu.Lock()
+	s.created = false
+	s.mu.Unlock()
+	status, err := s.client.do(ctx, http.MethodDelete, "/collections/"+s.collection, nil, nil)
+	if status == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+// Close 无需释放资源
+func (s *qdrantStore) Close() error {
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,111 @@
+package index
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// remoteClient 外部向量服务的JSON接口客户端
+type remoteClient struct {
+	http    *http.Client
+	baseURL string
+	headers map[string]string
+}
+
+// newRemoteClient 创建客户端，apiKeyEnv 指定的环境变量存在时以 header 发送密钥
+func newRemoteClient(baseURL, defaultURL, apiKeyEnv, header string) *remoteClient {
+	if baseURL == "" {
+		baseURL = defaultURL
+	}
+	client := &remoteClient{
+		http:    &http.Client{Timeout: 60 * time.Second},
+		baseURL: strings.TrimRight(baseURL, "/"),
+		headers: make(map[string]string),
+	}
+	if apiKeyEnv != "" {
+		if key := os.Getenv(apiKeyEnv); key != "" {
+			client.headers[header] = key
+		}
+	}
+	return client
+}
+
+// do 发送请求并解析JSON响应，result 为nil时忽略响应内容。返回HTTP状态码
+func (c *remoteClient) do(ctx context.Context, method, path string, body, result interface{}) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reach %s: %w", c.baseURL, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to read response from %s: %w", c.baseURL, err)
+	}
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("%s %s returned %s: %s", method, path, resp.Status, strings.TrimSpace(string(data)))
+	}
+	if result != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, result); err != nil {
+			return resp.StatusCode, fmt.Errorf("failed to parse response of %s %s: %w", method, path, err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// pointID 将代码段ID转换为UUID形式（Qdrant 的点ID只能是整数或UUID）
+func pointID(id string) string {
+	sum := sha1.Sum([]byte(id))
+	sum[6] = sum[6]&0x0f | 0x50 // 版本5
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// chunkPayload 代码段以外部服务的元数据保存
+func chunkPayload(chunk Chunk) map[string]interface{} {
+	return map[string]interface{}{
+		"chunk_id":   chunk.ID,
+		"path":       chunk.Path,
+		"start_line": chunk.StartLine,
+		"end_line":   chunk.EndLine,
+		"content":    chunk.Content,
+	}
+}
+
+// payloadChunk 从元数据还原代码段
+func payloadChunk(payload map[string]interface{}) Chunk {
+	chunk := Chunk{}
+	chunk.ID, _ = payload["chunk_id"].(string)
+	chunk.Path, _ = payload["path"].(string)
+	chunk.Content, _ = payload["content"].(string)
+	if line, ok := payload["start_line"].(float64); ok {
+		chunk.StartLine = int(line)
+	}
+	if line, ok := payload["end_line"].(float64); ok {
+		chunk.EndLine = int(line)
+	}
+	return chunk
+}
\ No newline at end of file
@@ -0,0 +1,158 @@
+package index
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite" // 纯Go实现的SQLite驱动，无需cgo
+)
+
+// sqliteSchema 代码段表，向量以小端 float32 数组保存
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS chunks (
+	id         TEXT PRIMARY KEY,
+	path       TEXT NOT NULL,
+	start_line INTEGER NOT NULL,
+	end_line   INTEGER NOT NULL,
+	content    TEXT NOT NULL,
+	vector     BLOB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS chunks_path ON chunks(path);`
+
+// sqliteStore 保存在单个SQLite文件中。检索时按行读取向量比较，内存中只保留前 limit 个结果。
+// 纯Go的驱动不能加载 sqlite-vec 等扩展，相似度在Go中计算
+type sqliteStore struct {
+	db *sql.DB
+}
+
+// openSQLiteStore 打开或创建数据库
+func openSQLiteStore(path string) (*sqliteStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create index directory: %w", err)
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open index database: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec("PRAGMA journal_mode=WAL;" + sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize index database %s: %w", path, err)
+	}
+	return &sqliteStore{db: db}, nil
+}
+
+// Upsert 在一个事务中替换文件的代码段
+func (s *sqliteStore) Upsert(ctx context.Context, records []Record) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to update index: %w", err)
+	}
+	defer tx.Rollback()
+	replaced := make(map[string]bool)
+	for _, record := range records {
+		if !replaced[record.Path] {
+			if _, err := tx.ExecContext(ctx, "DELETE FROM chunks WHERE path = ?", record.Path); err != nil {
+				return fmt.Errorf("failed to update index: %w", err)
+			}
+			replaced[record.Path] = true
+		}
+		if _, err := tx.ExecContext(ctx,
+			"INSERT OR REPLACE INTO chunks (id, path, start_line, end_line, content, vector) VALUES (?, ?, ?, ?, ?, ?)",
+			record.ID, record.Path, record.StartLine, record.EndLine, record.Content, encodeVector(record.Vector)); err != nil {
+			return fmt.Errorf("failed to update index: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to update index: %w", err)
+	}
+	return nil
+}
+
+// DeletePaths 删除文件的代码段
+func (s *sqliteStore) DeletePaths(ctx context.Context, paths []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to update index: %w", err)
+	}
+	defer tx.Rollback()
+	for _, path := range paths {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM chunks WHERE path = ?", path); err != nil {
+			return fmt.Errorf("failed to update index: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to update index: %w", err)
+	}
+	return nil
+}
+
+// Search 先只读取ID与向量计算相似度，再读取前 limit 个代码段的内容
+func (s *sqliteStore) Search(ctx context.Context, vector []float32, limit int) ([]Result, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, vector FROM chunks")
+	if err != nil {
+		return nil, fmt.Errorf("failed to search index: %w", err)
+	}
+	var candidates []Result
+	for rows.Next() {
+		var id string
+		var blob []byte
+		if err := rows.Scan(&id, &blob); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to search index: %w", err)
+		}
+		candidates = append(candidates, Result{Chunk: Chunk{ID: id}, Score: score(vector, decodeVector(blob))})
+		if limit > 0 && len(candidates) >= 4*limit+256 {
+			candidates = topResults(candidates, limit)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to search index: %w", err)
+	}
+
+	candidates = topResults(candidates, limit)
+	for i := range candidates {
+		row := s.db.QueryRowContext(ctx, "SELECT path, start_line, end_line, content FROM chunks WHERE id = ?", candidates[i].ID)
+		if err := row.Scan(&candidates[i].Path, &candidates[i].StartLine, &candidates[i].EndLine, &candidates[i].Content); err != nil {
+			return nil, fmt.Errorf("failed to search index: %w", err)
+		}
+	}
+	return candidates, nil
+}
+
+// Clear 删除所有代码段
+func (s *sqliteStore) Clear(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM chunks"); err != nil {
+		return fmt.Errorf("failed to clear index: %w", err)
+	}
+	return nil
+}
+
+// Close 关闭数据库
+func (s *sqliteStore) Close() error {
+	return s.db.Close()
+}
+
+// encodeVector 向量编码为小端 float32 数组
+func encodeVector(vector []float32) []byte {
+	data := make([]byte, 4*len(vector))
+	for i, x := range vector {
+		binary.LittleEndian.PutUint32(data[4*i:], math.Float32bits(x))
+	}
+	return data
+}
+
+// decodeVector 解码 encodeVector 的结果
+func decodeVector(data []byte) []float32 {
+	vector := make([]float32, len(data)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:]))
+	}
+	return vector
+}
\ No newline at end of file
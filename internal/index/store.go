@@ -0,0 +1,113 @@
+package index
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"openCursor/internal/embeddings"
+)
+
+// 向量存储后端
+const (
+	StoreMemory = "memory" // 内存中检索，保存为磁盘快照，适合中小型仓库
+	StoreSQLite = "sqlite" // 单个SQLite文件，按文件增量读写，适合较大的仓库
+	StoreQdrant = "qdrant" // 外部 Qdrant 服务
+	StoreChroma = "chroma" // 外部 Chroma 服务
+)
+
+// StoreConfig 向量存储配置（配置文件中的 index:）
+type StoreConfig struct {
+	Store      string `yaml:"store,omitempty"`       // memory（默认）、sqlite、qdrant 或 chroma
+	Path       string `yaml:"path,omitempty"`        // memory 快照或 sqlite 文件路径，默认在 <仓库>/.opencursor/index 中
+	URL        string `yaml:"url,omitempty"`         // qdrant 或 chroma 服务地址
+	Collection string `yaml:"collection,omitempty"`  // qdrant 或 chroma 的集合名，默认按仓库路径生成
+	APIKeyEnv  string `yaml:"api_key_env,omitempty"` // 读取服务API密钥的环境变量
+}
+
+// Chunk 索引中的一段代码
+type Chunk struct {
+	ID        string `json:"id"`
+	Path      string `json:"path"` // 相对仓库根目录，使用 /
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Content   string `json:"content"`
+}
+
+// Record 代码段及其向量
+type Record struct {
+	Chunk
+	Vector []float32
+}
+
+// Result 检索结果，Score 越大越相关：向量存储返回余弦相似度，Index.Search 返回融合后的分数
+type Result struct {
+	Chunk
+	Score float64
+}
+
+// Store 向量存储
+type Store interface {
+	// Upsert 写入或覆盖代码段
+	Upsert(ctx context.Context, records []Record) error
+	// DeletePaths 删除文件的所有代码段
+	DeletePaths(ctx context.Context, paths []string) error
+	// Search 返回与向量最相似的代码段，相似度从高到低
+	Search(ctx context.Context, vector []float32, limit int) ([]Result, error)
+	// Clear 删除所有代码段
+	Clear(ctx context.Context) error
+	// Close 保存并释放资源
+	Close() error
+}
+
+// OpenStore 按配置打开向量存储，dir 为默认的索引目录，root 用于生成默认的集合名
+func OpenStore(cfg StoreConfig, dir, root string) (Store, error) {
+	switch strings.ToLower(cfg.Store) {
+	case "", StoreMemory:
+		path := cfg.Path
+		if path == "" {
+			path = filepath.Join(dir, "vectors.gob")
+		}
+		return openMemoryStore(path)
+	case StoreSQLite:
+		path := cfg.Path
+		if path == "" {
+			path = filepath.Join(dir, "index.db")
+		}
+		return openSQLiteStore(path)
+	case StoreQdrant:
+		return newQdrantStore(cfg, collectionName(cfg, root)), nil
+	case StoreChroma:
+		return newChromaStore(cfg, collectionName(cfg, root)), nil
+	}
+	return nil, fmt.Errorf("unknown index store %q (expected %s, %s, %s or %s)", cfg.Store, StoreMemory, StoreSQLite, StoreQdrant, StoreChroma)
+}
+
+// collectionName 外部服务的集合名：配置的名称，或 opencursor_ 加仓库路径的哈希
+func collectionName(cfg StoreConfig, root string) string {
+	if cfg.Collection != "" {
+		return cfg.Collection
+	}
+	sum := sha256.Sum256([]byte(root))
+	return "opencursor_" + hex.EncodeToString(sum[:6])
+}
+
+// topResults 从候选中按相似度取前 limit 个
+func topResults(results []Result, limit int) []Result {
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// score 查询向量与代码段向量的相似度
+func score(query, vector []float32) float64 {
+	return embeddings.Cosine(query, vector)
+}
\ No newline at end of file
@@ -0,0 +1,188 @@
+package index
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce 最后一次文件变化后等待多久在后台更新索引
+const watchDebounce = 2 * time.Second
+
+// Watcher 监听工作区的文件变化，记录需要重新索引的文件，并在变化停止后于后台更新索引。
+// 无法监听（如超出系统的监听数量限制）或丢失事件时，回退为按修改时间完整比对
+type Watcher struct {
+	idx *Index
+	fs  *fsnotify.Watcher
+
+	mu       sync.Mutex
+	dirty    map[string]bool
+	rescan   bool // 下次更新需要完整比对
+	fallback bool // 监听不可用，总是完整比对
+	timer    *time.Timer
+	done     chan struct{}
+}
+
+// Watch 开始监听仓库的文件变化，之后的 Refresh 只重新索引变化的文件。
+// 启动前的变化仍需一次完整比对，因此第一次 Refresh 总是完整比对
+func (idx *Index) Watch() error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if idx.watcher != nil {
+		return nil
+	}
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	w := &Watcher{
+		idx:    idx,
+		fs:     fsWatcher,
+		dirty:  make(map[string]bool),
+		rescan: true,
+		done:   make(chan struct{}),
+	}
+	if err := w.addTree(idx.root); err != nil {
+		fsWatcher.Close()
+		return err
+	}
+	idx.watcher = w
+	go w.loop()
+	return nil
+}
+
+// addTree 监听目录及其中未被忽略的子目录（fsnotify 不支持递归监听）
+func (w *Watcher) addTree(dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return nil
+		}
+		if path != w.idx.root {
+			rel, err := filepath.Rel(w.idx.root, path)
+			if err != nil || ignoredDir(info.Name()) || w.idx.ignore.Load().ignored(filepath.ToSlash(rel), true) {
+				return filepath.SkipDir
+			}
+		}
+		return w.fs.Add(path)
+	})
+}
+
+// loop 处理文件事件直到停止
+func (w *Watcher) loop() {
+	for {
+		select {
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+			w.handle(event)
+		case _, ok := <-w.fs.Errors:
+			if !ok {
+				return
+			}
+			// 事件队列溢出等错误后无法确定哪些文件变化了
+			w.mu.Lock()
+			w.rescan = true
+			w.mu.Unlock()
+			w.schedule()
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// handle 记录变化的文件，新建的目录加入监听
+func (w *Watcher) handle(event fsnotify.Event) {
+	if event.Op == fsnotify.Chmod {
+		return
+	}
+	rel, err := filepath.Rel(w.idx.root, event.Name)
+	if err != nil || rel == "." {
+		return
+	}
+	rel = filepath.ToSlash(rel)
+	parts := strings.Split(rel, "/")
+	for _, dir := range parts[:len(parts)-1] {
+		if ignoredDir(dir) {
+			return
+		}
+	}
+	if strings.HasPrefix(parts[len(parts)-1], ".") {
+		return
+	}
+	if event.Op&fsnotify.Create != 0 {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			if err := w.addTree(event.Name); err != nil {
+				w.disable()
+				return
+			}
+		}
+	}
+	w.mu.Lock()
+	w.dirty[rel] = true
+	w.mu.Unlock()
+	w.schedule()
+}
+
+// schedule 文件变化停止 watchDebounce 后在后台更新索引
+func (w *Watcher) schedule() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.timer = time.AfterFunc(watchDebounce, func() {
+		select {
+		case <-w.done:
+			return
+		default:
+		}
+		// 后台更新失败时保留变化记录，由下次 Refresh 重试并报告错误
+		w.idx.Refresh(context.Background())
+	})
+}
+
+// take 取出变化的文件。需要完整比对时返回false
+func (w *Watcher) take() ([]string, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.fallback || w.rescan {
+		w.rescan = false
+		w.dirty = make(map[string]bool)
+		return nil, false
+	}
+	paths := make([]string, 0, len(w.dirty))
+	for rel := range w.dirty {
+		paths = append(paths, rel)
+	}
+	w.dirty = make(map[string]bool)
+	return paths, true
+}
+
+// disable 监听不可用时停止监听，之后总是完整比对
+func (w *Watcher) disable() {
+	w.mu.Lock()
+	w.fallback = true
+	w.mu.Unlock()
+	w.fs.Close()
+}
+
+// stop 停止监听
+func (w *Watcher) stop() {
+	w.mu.Lock()
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.mu.Unlock()
+	select {
+	case <-w.done:
+	default:
+		close(w.done)
+		w.fs.Close()
+	}
+}
\ No newline at end of file
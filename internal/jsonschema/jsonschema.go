@@ -0,0 +1,476 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxErrors 校验时最多报告的错误数
+const maxErrors = 20
+
+// Schema 解析后的 JSON Schema，支持常用的关键字：type、properties、required、
+// additionalProperties、items、enum、const、数值与长度范围、pattern、allOf/anyOf/oneOf
+// 以及指向 #/$defs 或 #/definitions 的 $ref
+type Schema struct {
+	raw  json.RawMessage
+	root map[string]interface{}
+}
+
+// Load 读取并解析 JSON Schema 文件
+func Load(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+	schema, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema %s: %w", path, err)
+	}
+	return schema, nil
+}
+
+// Parse 解析 JSON Schema，顶层必须是对象
+func Parse(data []byte) (*Schema, error) {
+	var root map[string]interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("not a JSON object: %w", err)
+	}
+	s := &Schema{root: root}
+	if err := s.check(root, "#"); err != nil {
+		return nil, err
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return nil, err
+	}
+	s.raw = compact.Bytes()
+	return s, nil
+}
+
+// Raw 紧凑格式的 schema 原文
+func (s *Schema) Raw() json.RawMessage {
+	return s.raw
+}
+
+// Title schema 的 title，未设置时为空
+func (s *Schema) Title() string {
+	title, _ := s.root["title"].(string)
+	return title
+}
+
+// check 检查 schema 中无法使用的关键字值：未知的类型、无效的正则与无法解析的 $ref
+func (s *Schema) check(node map[string]interface{}, at string) error {
+	for _, name := range typeNames(node["type"]) {
+		switch name {
+		case "object", "array", "string", "number", "integer", "boolean", "null":
+		default:
+			return fmt.Errorf("%s: unknown type %q", at, name)
+		}
+	}
+	if pattern, ok := node["pattern"].(string); ok {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("%s: invalid pattern: %w", at, err)
+		}
+	}
+	if ref, ok := node["$ref"].(string); ok {
+		if _, err := s.resolve(ref); err != nil {
+			return fmt.Errorf("%s: %w", at, err)
+		}
+	}
+	for _, key := range []string{"properties", "$defs", "definitions"} {
+		children, _ := node[key].(map[string]interface{})
+		for name, child := range children {
+			if child, ok := child.(map[string]interface{}); ok {
+				if err := s.check(child, at+"/"+key+"/"+name); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, key := range []string{"items", "additionalProperties", "not"} {
+		if child, ok := node[key].(map[string]interface{}); ok {
+			if err := s.check(child, at+"/"+key); err != nil {
+				return err
+			}
+		}
+	}
+	for _, key := range []string{"allOf", "anyOf", "oneOf"} {
+		children, _ := node[key].([]interface{})
+		for i, child := range children {
+			if child, ok := child.(map[string]interface{}); ok {
+				if err := s.check(child, fmt.Sprintf("%s/%s/%d", at, key, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// resolve 解析文档内的 $ref（#、#/$defs/x、#/definitions/x 等 JSON Pointer）
+func (s *Schema) resolve(ref string) (map[string]interface{}, error) {
+	if ref == "#" {
+		return s.root, nil
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported $ref %q (only references within the schema are supported)", ref)
+	}
+	var node interface{} = s.root
+	for _, part := range strings.Split(ref[2:], "/") {
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		object, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+		if node, ok = object[part]; !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+	}
+	object, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("$ref %q does not point to a schema", ref)
+	}
+	return object, nil
+}
+
+// Validate 校验 JSON 文本，返回不符合 schema 之处（如 "$.items[0].name: required property is missing"），
+// 符合时返回空
+func (s *Schema) Validate(data []byte) []string {
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return []string{fmt.Sprintf("not valid JSON: %v", err)}
+	}
+	if decoder.More() {
+		return []string{"not valid JSON: unexpected content after the JSON value"}
+	}
+	v := &validator{schema: s}
+	v.validate(s.root, value, "$", 0)
+	return v.errors
+}
+
+// validator 一次校验的状态
+type validator struct {
+	schema *Schema
+	errors []string
+}
+
+// fail 记录一处错误
+func (v *validator) fail(at, format string, args ...interface{}) {
+	if len(v.errors) < maxErrors {
+		v.errors = append(v.errors, at+": "+fmt.Sprintf(format, args...))
+	}
+}
+
+// valid 在不记录错误的情况下判断值是否符合子 schema（用于 anyOf/oneOf/not）
+func (v *validator) valid(node map[string]interface{}, value interface{}, at string, depth int) bool {
+	sub := &validator{schema: v.schema}
+	sub.validate(node, value, at, depth)
+	return len(sub.errors) == 0
+}
+
+// validate 按 schema 节点校验值
+func (v *validator) validate(node map[string]interface{}, value interface{}, at string, depth int) {
+	if depth > 64 {
+		v.fail(at, "schema nesting is too deep (recursive $ref?)")
+		return
+	}
+	if ref, ok := node["$ref"].(string); ok {
+		target, err := v.schema.resolve(ref)
+		if err != nil {
+			v.fail(at, "%v", err)
+			return
+		}
+		v.validate(target, value, at, depth+1)
+	}
+
+	if types := typeNames(node["type"]); len(types) > 0 {
+		matched := false
+		for _, name := range types {
+			if hasType(value, name) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.fail(at, "expected %s, got %s", strings.Join(types, " or "), typeOf(value))
+			return
+		}
+	}
+	if options, ok := node["enum"].([]interface{}); ok {
+		found := false
+		for _, option := range options {
+			if equal(value, option) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(at, "must be one of %s", compactJSON(options))
+		}
+	}
+	if constant, ok := node["const"]; ok && !equal(value, constant) {
+		v.fail(at, "must be %s", compactJSON(constant))
+	}
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		v.validateObject(node, value, at, depth)
+	case []interface{}:
+		v.validateArray(node, value, at, depth)
+	case string:
+		length := utf8.RuneCountInString(value)
+		if limit, ok := number(node["minLength"]); ok && float64(length) < limit {
+			v.fail(at, "must be at least %v characters long", limit)
+		}
+		if limit, ok := number(node["maxLength"]); ok && float64(length) > limit {
+			v.fail(at, "must be at most %v characters long", limit)
+		}
+		if pattern, ok := node["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(value) {
+				v.fail(at, "must match the pattern %q", pattern)
+			}
+		}
+	case json.Number:
+		n, _ := value.Float64()
+		if limit, ok := number(node["minimum"]); ok && n < limit {
+			v.fail(at, "must be >= %v", limit)
+		}
+		if limit, ok := number(node["maximum"]); ok && n > limit {
+			v.fail(at, "must be <= %v", limit)
+		}
+		if limit, ok := number(node["exclusiveMinimum"]); ok && n <= limit {
+			v.fail(at, "must be > %v", limit)
+		}
+		if limit, ok := number(node["exclusiveMaximum"]); ok && n >= limit {
+			v.fail(at, "must be < %v", limit)
+		}
+	}
+
+	if all, ok := node["allOf"].([]interface{}); ok {
+		for _, child := range all {
+			if child, ok := child.(map[string]interface{}); ok {
+				v.validate(child, value, at, depth+1)
+			}
+		}
+	}
+	if anyOf, ok := node["anyOf"].([]interface{}); ok {
+		if matches := v.matches(anyOf, value, at, depth); matches == 0 {
+			v.fail(at, "does not match any of the allowed schemas (anyOf)")
+		}
+	}
+	if oneOf, ok := node["oneOf"].([]interface{}); ok {
+		if matches := v.matches(oneOf, value, at, depth); matches != 1 {
+			v.fail(at, "must match exactly one of the allowed schemas (oneOf), matches %d", matches)
+		}
+	}
+	if not, ok := node["not"].(map[string]interface{}); ok && v.valid(not, value, at, depth+1) {
+		v.fail(at, "must not match the schema in \"not\"")
+	}
+}
+
+// matches 值符合的子 schema 数量
+func (v *validator) matches(schemas []interface{}, value interface{}, at string, depth int) int {
+	count := 0
+	for _, child := range schemas {
+		if child, ok := child.(map[string]interface{}); ok && v.valid(child, value, at, depth+1) {
+			count++
+		}
+	}
+	return count
+}
+
+// validateObject 校验对象的属性
+func (v *validator) validateObject(node map[string]interface{}, value map[string]interface{}, at string, depth int) {
+	properties, _ := node["properties"].(map[string]interface{})
+	if required, ok := node["required"].([]interface{}); ok {
+		for _, name := range required {
+			if name, ok := name.(string); ok {
+				if _, present := value[name]; !present {
+					v.fail(propertyPath(at, name), "required property is missing")
+				}
+			}
+		}
+	}
+	if limit, ok := number(node["minProperties"]); ok && float64(len(value)) < limit {
+		v.fail(at, "must have at least %v properties", limit)
+	}
+	if limit, ok := number(node["maxProperties"]); ok && float64(len(value)) > limit {
+		v.fail(at, "must have at most %v properties", limit)
+	}
+
+	names := make([]string, 0, len(value))
+	for name := range value {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if child, ok := properties[name].(map[string]interface{}); ok {
+			v.validate(child, value[name], propertyPath(at, name), depth+1)
+			continue
+		}
+		if _, ok := properties[name]; ok {
+			continue
+		}
+		switch additional := node["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				v.fail(propertyPath(at, name), "additional property is not allowed")
+			}
+		case map[string]interface{}:
+			v.validate(additional, value[name], propertyPath(at, name), depth+1)
+		}
+	}
+}
+
+// validateArray 校验数组的元素
+func (v *validator) validateArray(node map[string]interface{}, value []interface{}, at string, depth int) {
+	if limit, ok := number(node["minItems"]); ok && float64(len(value)) < limit {
+		v.fail(at, "must have at least %v items", limit)
+	}
+	if limit, ok := number(node["maxItems"]); ok && float64(len(value)) > limit {
+		v.fail(at, "must have at most %v items", limit)
+	}
+	if unique, _ := node["uniqueItems"].(bool); unique {
+		for i := range value {
+			for j := 0; j < i; j++ {
+				if equal(value[i], value[j]) {
+					v.fail(fmt.Sprintf("%s[%d]", at, i), "duplicates item %d", j)
+				}
+			}
+		}
+	}
+	if items, ok := node["items"].(map[string]interface{}); ok {
+		for i, item := range value {
+			v.validate(items, item, fmt.Sprintf("%s[%d]", at, i), depth+1)
+		}
+	}
+}
+
+// propertyPath 属性的路径，名称不是标识符时使用 ["name"]
+func propertyPath(at, name string) string {
+	if identifierPattern.MatchString(name) {
+		return at + "." + name
+	}
+	return fmt.Sprintf("%s[%q]", at, name)
+}
+
+// identifierPattern 可以用 . 访问的属性名
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// typeNames type 关键字的值（字符串或字符串数组）
+func typeNames(value interface{}) []string {
+	switch value := value.(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		var names []string
+		for _, name := range value {
+			if name, ok := name.(string); ok {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+// hasType 值是否为 JSON Schema 中的类型
+func hasType(value interface{}, name string) bool {
+	switch name {
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		f, err := n.Float64()
+		return err == nil && f == math.Trunc(f)
+	case "number":
+		_, ok := value.(json.Number)
+		return ok
+	default:
+		return typeOf(value) == name
+	}
+}
+
+// typeOf 值的 JSON 类型名
+func typeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number, float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// number 取出 schema 中的数值关键字（schema 用 float64 解析，实例用 json.Number）
+func number(value interface{}) (float64, bool) {
+	switch value := value.(type) {
+	case float64:
+		return value, true
+	case json.Number:
+		f, err := value.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// equal 比较两个 JSON 值，数字按数值比较
+func equal(a, b interface{}) bool {
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		return ok && x == y
+	}
+	switch a := a.(type) {
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for key, value := range a {
+			other, ok := b[key]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
+
+// compactJSON 错误信息中显示的 JSON 值
+func compactJSON(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
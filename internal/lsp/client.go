@@ -0,0 +1,666 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// requestTimeout 单个LSP请求的超时时间
+const requestTimeout = 60 * time.Second
+
+// diagnosticsSettle 收到首个诊断后等待后续诊断的时间
+const diagnosticsSettle = 700 * time.Millisecond
+
+// Client 语言服务器客户端
+type Client struct {
+	name       string
+	languageID string
+	rootDir    string
+
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	reader *bufio.Reader
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	nextID  int
+	pending map[string]chan *jsonrpcMessage
+	closed  bool
+
+	// 已打开的文档及其版本号与内容
+	documents map[string]*openDocument
+
+	// 服务器推送的诊断信息
+	diagnostics   map[string][]Diagnostic
+	diagnosticsCh map[string][]chan struct{}
+}
+
+// openDocument 已同步到服务器的文档
+type openDocument struct {
+	version int
+	content string
+}
+
+// Start 启动语言服务器并完成初始化握手
+func Start(server ServerConfig, rootDir string) (*Client, error) {
+	absRoot, err := filepath.Abs(rootDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve root directory: %w", err)
+	}
+
+	cmd := exec.Command(server.Command, server.Args...)
+	cmd.Dir = absRoot
+	cmd.Stderr = io.Discard
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", server.Command, err)
+	}
+
+	client := &Client{
+		name:          server.Name,
+		languageID:    server.LanguageID,
+		rootDir:       absRoot,
+		cmd:           cmd,
+		stdin:         stdin,
+		reader:        bufio.NewReader(stdout),
+		pending:       make(map[string]chan *jsonrpcMessage),
+		documents:     make(map[string]*openDocument),
+		diagnostics:   make(map[string][]Diagnostic),
+		diagnosticsCh: make(map[string][]chan struct{}),
+	}
+	go client.readLoop()
+
+	if err := client.initialize(); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// Name 语言服务器名称
+func (c *Client) Name() string {
+	return c.name
+}
+
+// initialize 发送initialize请求与initialized通知
+func (c *Client) initialize() error {
+	rootURI := PathToURI(c.rootDir)
+	params := map[string]interface{}{
+		"processId": os.Getpid(),
+		"rootUri":   rootURI,
+		"workspaceFolders": []map[string]string{
+			{"uri": rootURI, "name": filepath.Base(c.rootDir)},
+		},
+		"capabilities": map[string]interface{}{
+			"textDocument": map[string]interface{}{
+				"synchronization":    map[string]interface{}{"didSave": true},
+				"definition":         map[string]interface{}{"linkSupport": true},
+				"references":         map[string]interface{}{},
+				"hover":              map[string]interface{}{"contentFormat": []string{"markdown", "plaintext"}},
+				"rename":             map[string]interface{}{"prepareSupport": false},
+				"publishDiagnostics": map[string]interface{}{"relatedInformation": false},
+			},
+			"workspace": map[string]interface{}{
+				"workspaceFolders": true,
+				"configuration":    true,
+				"symbol":           map[string]interface{}{},
+			},
+		},
+	}
+
+	if err := c.call("initialize", params, nil); err != nil {
+		return fmt.Errorf("failed to initialize %s: %w", c.name, err)
+	}
+	return c.notify("initialized", map[string]interface{}{})
+}
+
+// Close 关闭语言服务器
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.mu.Unlock()
+
+	// 尽力而为的优雅关闭
+	done := make(chan struct{})
+	go func() {
+		c.call("shutdown", nil, nil)
+		c.notify("exit", nil)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+	}
+
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+
+	c.stdin.Close()
+	if c.cmd.Process != nil {
+		c.cmd.Process.Kill()
+	}
+	c.cmd.Wait()
+	return nil
+}
+
+// writeMessage 按LSP的Content-Length分帧写入消息
+func (c *Client) writeMessage(msg *jsonrpcMessage) error {
+	msg.JSONRPC = "2.0"
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if _, err := fmt.Fprintf(c.stdin, "Content-Length: %d\r\n\r\n", len(data)); err != nil {
+		return fmt.Errorf("failed to write to language server: %w", err)
+	}
+	if _, err := c.stdin.Write(data); err != nil {
+		return fmt.Errorf("failed to write to language server: %w", err)
+	}
+	return nil
+}
+
+// readMessage 读取一条LSP消息
+func (c *Client) readMessage() (*jsonrpcMessage, error) {
+	contentLength := -1
+	for {
+		line, err := c.reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		if name, value, ok := strings.Cut(line, ":"); ok && strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			contentLength, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return nil, fmt.Errorf("invalid Content-Length: %s", value)
+			}
+		}
+	}
+	if contentLength < 0 {
+		return nil, fmt.Errorf("missing Content-Length header")
+	}
+
+	body := make([]byte, contentLength)
+	if _, err := io.ReadFull(c.reader, body); err != nil {
+		return nil, err
+	}
+
+	var msg jsonrpcMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, fmt.Errorf("failed to decode message: %w", err)
+	}
+	return &msg, nil
+}
+
+// readLoop 持续读取服务器消息并分发
+func (c *Client) readLoop() {
+	for {
+		msg, err := c.readMessage()
+		if err != nil {
+			c.mu.Lock()
+			c.closed = true
+			for id, ch := range c.pending {
+				close(ch)
+				delete(c.pending, id)
+			}
+			c.mu.Unlock()
+			return
+		}
+
+		switch {
+		case msg.ID != nil && msg.Method == "":
+			// 响应
+			c.mu.Lock()
+			ch, ok := c.pending[string(*msg.ID)]
+			delete(c.pending, string(*msg.ID))
+			c.mu.Unlock()
+			if ok {
+				ch <- msg
+			}
+		case msg.ID != nil:
+			// 服务器发起的请求
+			c.handleServerRequest(msg)
+		default:
+			// 通知
+			c.handleNotification(msg)
+		}
+	}
+}
+
+// handleServerRequest 响应服务器发起的请求（配置、进度、注册能力等）
+func (c *Client) handleServerRequest(msg *jsonrpcMessage) {
+	var result interface{}
+	if msg.Method == "workspace/configuration" {
+		var params struct {
+			Items []interface{} `json:"items"`
+		}
+		json.Unmarshal(msg.Params, &params)
+		result = make([]interface{}, len(params.Items))
+	}
+
+	data, _ := json.Marshal(result)
+	c.writeMessage(&jsonrpcMessage{ID: msg.ID, Result: data})
+}
+
+// handleNotification 处理服务器通知
+func (c *Client) handleNotification(msg *jsonrpcMessage) {
+	if msg.Method != "textDocument/publishDiagnostics" {
+		return
+	}
+
+	var params struct {
+		URI         string       `json:"uri"`
+		Diagnostics []Diagnostic `json:"diagnostics"`
+	}
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	c.diagnostics[params.URI] = params.Diagnostics
+	waiters := c.diagnosticsCh[params.URI]
+	delete(c.diagnosticsCh, params.URI)
+	c.mu.Unlock()
+
+	for _, ch := range waiters {
+		close(ch)
+	}
+}
+
+// call 发送请求并等待响应
+func (c *Client) call(method string, params interface{}, result interface{}) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return fmt.Errorf("language server %s is not running", c.name)
+	}
+	c.nextID++
+	id := json.RawMessage(strconv.Itoa(c.nextID))
+	ch := make(chan *jsonrpcMessage, 1)
+	c.pending[string(id)] = ch
+	c.mu.Unlock()
+
+	var rawParams json.RawMessage
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("failed to encode params: %w", err)
+		}
+		rawParams = data
+	}
+
+	if err := c.writeMessage(&jsonrpcMessage{ID: &id, Method: method, Params: rawParams}); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			return fmt.Errorf("language server %s exited", c.name)
+		}
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result != nil && len(resp.Result) > 0 {
+			if err := json.Unmarshal(resp.Result, result); err != nil {
+				return fmt.Errorf("failed to decode %s result: %w", method, err)
+			}
+		}
+		return nil
+	case <-ctx.Done():
+		c.mu.Lock()
+		delete(c.pending, string(id))
+		c.mu.Unlock()
+		return fmt.Errorf("%s timed out after %s", method, requestTimeout)
+	}
+}
+
+// notify 发送通知
+func (c *Client) notify(method string, params interface{}) error {
+	var rawParams json.RawMessage
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("failed to encode params: %w", err)
+		}
+		rawParams = data
+	}
+	return c.writeMessage(&jsonrpcMessage{Method: method, Params: rawParams})
+}
+
+// SyncDocument 将磁盘上的文件内容同步到服务器（首次打开或内容变化时）
+func (c *Client) SyncDocument(path string) (string, error) {
+	uri, _, err := c.syncDocument(path)
+	return uri, err
+}
+
+// syncDocument 同步文档，并返回是否向服务器发送了新内容
+func (c *Client) syncDocument(path string) (string, bool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read file: %w", err)
+	}
+	if !isValidUTF8(content) {
+		return "", false, fmt.Errorf("file is not valid UTF-8 text: %s", path)
+	}
+
+	uri := PathToURI(path)
+	text := string(content)
+
+	c.mu.Lock()
+	doc, opened := c.documents[uri]
+	if opened && doc.content == text {
+		c.mu.Unlock()
+		return uri, false, nil
+	}
+	if !opened {
+		doc = &openDocument{}
+		c.documents[uri] = doc
+	}
+	doc.version++
+	doc.content = text
+	version := doc.version
+	c.mu.Unlock()
+
+	if !opened {
+		err = c.notify("textDocument/didOpen", map[string]interface{}{
+			"textDocument": map[string]interface{}{
+				"uri":        uri,
+				"languageId": languageIDForFile(path, c.languageID),
+				"version":    version,
+				"text":       text,
+			},
+		})
+	} else {
+		err = c.notify("textDocument/didChange", map[string]interface{}{
+			"textDocument":   map[string]interface{}{"uri": uri, "version": version},
+			"contentChanges": []map[string]string{{"text": text}},
+		})
+	}
+	return uri, true, err
+}
+
+// positionParams 构建TextDocumentPositionParams
+func positionParams(uri string, pos Position) map[string]interface{} {
+	return map[string]interface{}{
+		"textDocument": map[string]string{"uri": uri},
+		"position":     pos,
+	}
+}
+
+// Definition 查找符号定义位置
+func (c *Client) Definition(path string, pos Position) ([]Location, error) {
+	uri, err := c.SyncDocument(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw json.RawMessage
+	if err := c.call("textDocument/definition", positionParams(uri, pos), &raw); err != nil {
+		return nil, err
+	}
+	return decodeLocations(raw)
+}
+
+// References 查找符号的所有引用
+func (c *Client) References(path string, pos Position, includeDeclaration bool) ([]Location, error) {
+	uri, err := c.SyncDocument(path)
+	if err != nil {
+		return nil, err
+	}
+
+	params := positionParams(uri, pos)
+	params["context"] = map[string]bool{"includeDeclaration": includeDeclaration}
+
+	var locations []Location
+	if err := c.call("textDocument/references", params, &locations); err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
+
+// Hover 获取符号的悬停信息（类型签名与文档）
+func (c *Client) Hover(path string, pos Position) (string, error) {
+	uri, err := c.SyncDocument(path)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		Contents json.RawMessage `json:"contents"`
+	}
+	if err := c.call("textDocument/hover", positionParams(uri, pos), &result); err != nil {
+		return "", err
+	}
+	return decodeHoverContents(result.Contents), nil
+}
+
+// Rename 计算重命名所需的工作区编辑
+func (c *Client) Rename(path string, pos Position, newName string) (*WorkspaceEdit, error) {
+	uri, err := c.SyncDocument(path)
+	if err != nil {
+		return nil, err
+	}
+
+	params := positionParams(uri, pos)
+	params["newName"] = newName
+
+	var edit WorkspaceEdit
+	if err := c.call("textDocument/rename", params, &edit); err != nil {
+		return nil, err
+	}
+	return &edit, nil
+}
+
+// WorkspaceSymbols 在工作区中按名称查找符号
+func (c *Client) WorkspaceSymbols(query string) ([]SymbolInformation, error) {
+	var symbols []SymbolInformation
+	if err := c.call("workspace/symbol", map[string]string{"query": query}, &symbols); err != nil {
+		return nil, err
+	}
+	return symbols, nil
+}
+
+// Diagnostics 获取文件的诊断信息，等待服务器推送
+func (c *Client) Diagnostics(path string, timeout time.Duration) ([]Diagnostic, error) {
+	uri := PathToURI(path)
+
+	// 先注册等待，再同步文档，避免错过通知
+	ch := make(chan struct{})
+	c.mu.Lock()
+	c.diagnosticsCh[uri] = append(c.diagnosticsCh[uri], ch)
+	c.mu.Unlock()
+
+	_, changed, err := c.syncDocument(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// 内容未变化且已有诊断结果时，服务器不会再次推送
+	c.mu.Lock()
+	_, cached := c.diagnostics[uri]
+	c.mu.Unlock()
+	if changed || !cached {
+		select {
+		case <-ch:
+			// 服务器可能分多次推送（如先语法后类型检查），稍等片刻取最新结果
+			time.Sleep(diagnosticsSettle)
+		case <-time.After(timeout):
+		}
+	}
+
+	c.mu.Lock()
+	diagnostics := c.diagnostics[uri]
+	c.mu.Unlock()
+
+	sorted := append([]Diagnostic(nil), diagnostics...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Range.Start.Line < sorted[j].Range.Start.Line
+	})
+	return sorted, nil
+}
+
+// decodeLocations 解析Location、[]Location或[]LocationLink
+func decodeLocations(raw json.RawMessage) ([]Location, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var single Location
+	if err := json.Unmarshal(raw, &single); err == nil && single.URI != "" {
+		return []Location{single}, nil
+	}
+
+	var links []locationLink
+	if err := json.Unmarshal(raw, &links); err == nil && len(links) > 0 && links[0].TargetURI != "" {
+		locations := make([]Location, 0, len(links))
+		for _, link := range links {
+			locations = append(locations, Location{URI: link.TargetURI, Range: link.TargetSelectionRange})
+		}
+		return locations, nil
+	}
+
+	var locations []Location
+	if err := json.Unmarshal(raw, &locations); err != nil {
+		return nil, fmt.Errorf("failed to decode locations: %w", err)
+	}
+	return locations, nil
+}
+
+// decodeHoverContents 解析MarkupContent、MarkedString或其数组
+func decodeHoverContents(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+
+	var markup struct {
+		Kind     string `json:"kind"`
+		Language string `json:"language"`
+		Value    string `json:"value"`
+	}
+	if err := json.Unmarshal(raw, &markup); err == nil && markup.Value != "" {
+		if markup.Language != "" {
+			return "```" + markup.Language + "\n" + markup.Value + "\n```"
+		}
+		return markup.Value
+	}
+
+	var list []json.RawMessage
+	if err := json.Unmarshal(raw, &list); err == nil {
+		var parts []string
+		for _, item := range list {
+			if part := decodeHoverContents(item); part != "" {
+				parts = append(parts, part)
+			}
+		}
+		return strings.Join(parts, "\n\n")
+	}
+
+	return ""
+}
+
+// WorkspaceEditPaths 工作区编辑涉及的文件，写入前用于检查
+func WorkspaceEditPaths(edit *WorkspaceEdit) []string {
+	seen := make(map[string]bool)
+	var paths []string
+	add := func(uri string) {
+		if path := URIToPath(uri); !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	for uri := range edit.Changes {
+		add(uri)
+	}
+	for _, change := range edit.DocumentChanges {
+		add(change.TextDocument.URI)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// ApplyWorkspaceEdit 将工作区编辑写入磁盘，返回修改过的文件及编辑数量
+func ApplyWorkspaceEdit(edit *WorkspaceEdit) (map[string]int, error) {
+	byURI := make(map[string][]TextEdit)
+	for uri, edits := range edit.Changes {
+		byURI[uri] = append(byURI[uri], edits...)
+	}
+	for _, change := range edit.DocumentChanges {
+		byURI[change.TextDocument.URI] = append(byURI[change.TextDocument.URI], change.Edits...)
+	}
+
+	applied := make(map[string]int)
+	for uri, edits := range byURI {
+		path := URIToPath(uri)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return applied, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		text := string(content)
+
+		// 从后往前应用，避免偏移失效
+		sort.SliceStable(edits, func(i, j int) bool {
+			a, b := edits[i].Range.Start, edits[j].Range.Start
+			if a.Line != b.Line {
+				return a.Line > b.Line
+			}
+			return a.Character > b.Character
+		})
+		for _, e := range edits {
+			start := OffsetOf(text, e.Range.Start)
+			end := OffsetOf(text, e.Range.End)
+			if start > end {
+				return applied, fmt.Errorf("invalid edit range in %s", path)
+			}
+			text = text[:start] + e.NewText + text[end:]
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return applied, fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+		if err := os.WriteFile(path, []byte(text), info.Mode().Perm()); err != nil {
+			return applied, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		applied[path] = len(edits)
+	}
+
+	return applied, nil
+}
\ No newline at end of file
@@ -0,0 +1,195 @@
+package lsp
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ServerConfig 语言服务器配置
+type ServerConfig struct {
+	Name       string   `yaml:"-"`
+	Command    string   `yaml:"command"`
+	Args       []string `yaml:"args,omitempty"`
+	LanguageID string   `yaml:"language_id,omitempty"`
+	Extensions []string `yaml:"extensions"`
+}
+
+// DefaultServers 内置的语言服务器配置
+func DefaultServers() map[string]ServerConfig {
+	return map[string]ServerConfig{
+		"go": {
+			Name:       "go",
+			Command:    "gopls",
+			LanguageID: "go",
+			Extensions: []string{".go"},
+		},
+		"python": {
+			Name:       "python",
+			Command:    "pyright-langserver",
+			Args:       []string{"--stdio"},
+			LanguageID: "python",
+			Extensions: []string{".py", ".pyi"},
+		},
+		"typescript": {
+			Name:       "typescript",
+			Command:    "typescript-language-server",
+			Args:       []string{"--stdio"},
+			LanguageID: "typescript",
+			Extensions: []string{".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs"},
+		},
+	}
+}
+
+// languageIDForFile 根据扩展名细化languageId（同一服务器处理多种语言时）
+func languageIDForFile(path, fallback string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ts":
+		return "typescript"
+	case ".tsx":
+		return "typescriptreact"
+	case ".js", ".mjs", ".cjs":
+		return "javascript"
+	case ".jsx":
+		return "javascriptreact"
+	}
+	return fallback
+}
+
+// Manager 按语言管理语言服务器实例（懒启动，进程内复用）
+type Manager struct {
+	mu      sync.Mutex
+	servers map[string]ServerConfig
+	clients map[string]*Client
+	rootDir string
+}
+
+// NewManager 创建语言服务器管理器
+func NewManager(servers map[string]ServerConfig) *Manager {
+	if servers == nil {
+		servers = DefaultServers()
+	}
+	for name, server := range servers {
+		server.Name = name
+		if server.LanguageID == "" {
+			server.LanguageID = name
+		}
+		servers[name] = server
+	}
+	return &Manager{
+		servers: servers,
+		clients: make(map[string]*Client),
+	}
+}
+
+// SetRootDir 设置工作区根目录，变更时关闭已启动的服务器
+func (m *Manager) SetRootDir(dir string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.rootDir == dir {
+		return
+	}
+	for name, client := range m.clients {
+		client.Close()
+		delete(m.clients, name)
+	}
+	m.rootDir = dir
+}
+
+// ServerForFile 查找处理该文件的语言服务器配置
+func (m *Manager) ServerForFile(path string) (ServerConfig, bool) {
+	ext := strings.ToLower(filepath.Ext(path))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, name := range sortedServerNames(m.servers) {
+		server := m.servers[name]
+		for _, serverExt := range server.Extensions {
+			if strings.EqualFold(serverExt, ext) {
+				return server, true
+			}
+		}
+	}
+	return ServerConfig{}, false
+}
+
+// Available 列出已安装（可执行文件在PATH中）的语言服务器
+func (m *Manager) Available() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var names []string
+	for _, name := range sortedServerNames(m.servers) {
+		if _, err := exec.LookPath(m.servers[name].Command); err == nil {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ClientForFile 获取（必要时启动）处理该文件的语言服务器客户端
+func (m *Manager) ClientForFile(path string) (*Client, error) {
+	server, ok := m.ServerForFile(path)
+	if !ok {
+		return nil, fmt.Errorf("no language server configured for %s files", filepath.Ext(path))
+	}
+	return m.Client(server.Name)
+}
+
+// Client 获取（必要时启动）指定语言的服务器客户端
+func (m *Manager) Client(name string) (*Client, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if client, ok := m.clients[name]; ok {
+		client.mu.Lock()
+		closed := client.closed
+		client.mu.Unlock()
+		if !closed {
+			return client, nil
+		}
+		delete(m.clients, name)
+	}
+
+	server, ok := m.servers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown language server '%s'", name)
+	}
+	if _, err := exec.LookPath(server.Command); err != nil {
+		return nil, fmt.Errorf("language server '%s' is not installed (%s not found in PATH)", name, server.Command)
+	}
+
+	rootDir := m.rootDir
+	if rootDir == "" {
+		rootDir = "."
+	}
+	client, err := Start(server, rootDir)
+	if err != nil {
+		return nil, err
+	}
+	m.clients[name] = client
+	return client, nil
+}
+
+// Shutdown 关闭所有已启动的语言服务器
+func (m *Manager) Shutdown() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for name, client := range m.clients {
+		client.Close()
+		delete(m.clients, name)
+	}
+}
+
+// sortedServerNames 返回排序后的服务器名称，保证查找顺序稳定
+func sortedServerNames(servers map[string]ServerConfig) []string {
+	names := make([]string, 0, len(servers))
+	for name := range servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
\ No newline at end of file
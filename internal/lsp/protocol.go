@@ -0,0 +1,187 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Position LSP位置（0-based行号，UTF-16字符偏移）
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range LSP范围
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Location 文件中的一个范围
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// locationLink textDocument/definition 可能返回的LocationLink
+type locationLink struct {
+	TargetURI            string `json:"targetUri"`
+	TargetRange          Range  `json:"targetRange"`
+	TargetSelectionRange Range  `json:"targetSelectionRange"`
+}
+
+// Diagnostic 诊断信息
+type Diagnostic struct {
+	Range    Range           `json:"range"`
+	Severity int             `json:"severity,omitempty"`
+	Code     json.RawMessage `json:"code,omitempty"`
+	Source   string          `json:"source,omitempty"`
+	Message  string          `json:"message"`
+}
+
+// TextEdit 文本编辑
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+// WorkspaceEdit 工作区编辑（rename的结果）
+type WorkspaceEdit struct {
+	Changes         map[string][]TextEdit `json:"changes,omitempty"`
+	DocumentChanges []struct {
+		TextDocument struct {
+			URI string `json:"uri"`
+		} `json:"textDocument"`
+		Edits []TextEdit `json:"edits"`
+	} `json:"documentChanges,omitempty"`
+}
+
+// SymbolInformation workspace/symbol 的返回项
+type SymbolInformation struct {
+	Name          string   `json:"name"`
+	Kind          int      `json:"kind"`
+	Location      Location `json:"location"`
+	ContainerName string   `json:"containerName,omitempty"`
+}
+
+// 诊断严重级别
+const (
+	SeverityError       = 1
+	SeverityWarning     = 2
+	SeverityInformation = 3
+	SeverityHint        = 4
+)
+
+// SeverityName 诊断严重级别名称
+func SeverityName(severity int) string {
+	switch severity {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityInformation:
+		return "info"
+	case SeverityHint:
+		return "hint"
+	}
+	return "unknown"
+}
+
+// jsonrpcMessage JSON-RPC 2.0 消息（请求、响应与通知共用）
+type jsonrpcMessage struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *jsonrpcError    `json:"error,omitempty"`
+}
+
+// jsonrpcError JSON-RPC错误
+type jsonrpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *jsonrpcError) Error() string {
+	return fmt.Sprintf("language server error %d: %s", e.Code, e.Message)
+}
+
+// PathToURI 将文件路径转换为file:// URI
+func PathToURI(path string) string {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		absPath = path
+	}
+	slashed := filepath.ToSlash(absPath)
+	if runtime.GOOS == "windows" {
+		slashed = "/" + slashed
+	}
+	return (&url.URL{Scheme: "file", Path: slashed}).String()
+}
+
+// URIToPath 将file:// URI转换为文件路径
+func URIToPath(uri string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme != "file" {
+		return uri
+	}
+	path := parsed.Path
+	if runtime.GOOS == "windows" {
+		path = strings.TrimPrefix(path, "/")
+	}
+	return filepath.FromSlash(path)
+}
+
+// UTF16Column 将行内的字节偏移转换为UTF-16字符偏移
+func UTF16Column(line string, byteOffset int) int {
+	if byteOffset > len(line) {
+		byteOffset = len(line)
+	}
+	return len(utf16.Encode([]rune(line[:byteOffset])))
+}
+
+// ByteColumn 将行内的UTF-16字符偏移转换为字节偏移
+func ByteColumn(line string, utf16Offset int) int {
+	units := 0
+	for i, r := range line {
+		if units >= utf16Offset {
+			return i
+		}
+		if r >= 0x10000 {
+			units += 2
+		} else {
+			units++
+		}
+	}
+	return len(line)
+}
+
+// OffsetOf 将LSP位置转换为文本中的字节偏移
+func OffsetOf(text string, pos Position) int {
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		idx := strings.IndexByte(text[offset:], '\n')
+		if idx < 0 {
+			return len(text)
+		}
+		offset += idx + 1
+	}
+	end := strings.IndexByte(text[offset:], '\n')
+	lineText := text[offset:]
+	if end >= 0 {
+		lineText = text[offset : offset+end]
+	}
+	return offset + ByteColumn(lineText, pos.Character)
+}
+
+// isValidUTF8 检查内容是否为文本
+func isValidUTF8(content []byte) bool {
+	return utf8.Valid(content)
+}
\ No newline at end of file
@@ -0,0 +1,432 @@
+package repomap
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"openCursor/internal/embeddings"
+)
+
+// DefaultTokenBudget 默认的仓库地图token预算
+const DefaultTokenBudget = 1024
+
+// maxFileSize 参与分析的单个文件最大字节数
+const maxFileSize = 512 * 1024
+
+// maxFiles 最多分析的文件数量
+const maxFiles = 5000
+
+// Options 仓库地图生成选项
+type Options struct {
+	Root        string   // 仓库根目录
+	TokenBudget int      // token预算，<=0时使用默认值
+	Mentions    []string // 查询中提到的词，用于提升相关文件与符号的排名
+
+	// Query 与 Embedder 都设置时，按查询与文件的向量相似度调整排名
+	Query    string
+	Embedder embeddings.Embedder
+}
+
+// Symbol 顶层符号
+type Symbol struct {
+	Name       string `json:"name"`
+	Signature  string `json:"signature"`
+	Line       int    `json:"line"`
+	References int    `json:"references"`
+}
+
+// FileEntry 文件及其符号
+type FileEntry struct {
+	Path    string   `json:"path"`
+	Score   float64  `json:"score"`
+	Symbols []Symbol `json:"symbols"`
+}
+
+// Map 生成的仓库地图
+type Map struct {
+	Files          []FileEntry `json:"files"`
+	TotalFiles     int         `json:"total_files"`
+	IncludedFiles  int         `json:"included_files"`
+	EstimateTokens int         `json:"estimated_tokens"`
+	Text           string      `json:"text"`
+	Warning        string      `json:"warning,omitempty"` // 语义排名失败等不影响生成的问题
+}
+
+// ignoredDirs 不参与分析的目录
+var ignoredDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, "dist": true, "build": true,
+	"target": true, "__pycache__": true, ".venv": true, "venv": true, ".idea": true,
+	".vscode": true, ".opencursor": true,
+}
+
+// identPattern 标识符匹配模式
+var identPattern = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+
+// symbolPatterns 非Go语言的顶层符号匹配规则（按扩展名）
+var symbolPatterns = map[string][]*regexp.Regexp{
+	".py": {
+		regexp.MustCompile(`^(?:async\s+)?def\s+([A-Za-z_]\w*)\s*\(.*`),
+		regexp.MustCompile(`^class\s+([A-Za-z_]\w*).*`),
+	},
+	".js":  jsPatterns,
+	".jsx": jsPatterns,
+	".ts":  jsPatterns,
+	".tsx": jsPatterns,
+	".mjs": jsPatterns,
+	".java": {
+		regexp.MustCompile(`^\s*(?:public\s+|protected\s+|private\s+|abstract\s+|final\s+|static\s+)*(?:class|interface|enum|record)\s+([A-Za-z_]\w*).*`),
+	},
+	".kt": {
+		regexp.MustCompile(`^\s*(?:(?:data|sealed|abstract|open|private|internal)\s+)*(?:class|interface|object|fun)\s+([A-Za-z_]\w*).*`),
+	},
+	".cs": {
+		regexp.MustCompile(`^\s*(?:public\s+|internal\s+|private\s+|abstract\s+|sealed\s+|static\s+|partial\s+)*(?:class|interface|enum|struct|record)\s+([A-Za-z_]\w*).*`),
+	},
+	".rs": {
+		regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:async\s+)?(?:fn|struct|enum|trait|type|mod)\s+([A-Za-z_]\w*).*`),
+	},
+	".rb": {
+		regexp.MustCompile(`^\s*(?:def|class|module)\s+([A-Za-z_][\w.]*).*`),
+	},
+	".php": {
+		regexp.MustCompile(`^\s*(?:abstract\s+|final\s+)?(?:class|interface|trait|function)\s+([A-Za-z_]\w*).*`),
+	},
+	".c":   cPatterns,
+	".h":   cPatterns,
+	".cc":  cPatterns,
+	".cpp": cPatterns,
+	".hpp": cPatterns,
+}
+
+var jsPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:async\s+)?function\*?\s+([A-Za-z_$][\w$]*).*`),
+	regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+([A-Za-z_$][\w$]*).*`),
+	regexp.MustCompile(`^(?:export\s+)?(?:interface|type|enum)\s+([A-Za-z_$][\w$]*).*`),
+	regexp.MustCompile(`^(?:export\s+)?const\s+([A-Za-z_$][\w$]*)\s*=\s*(?:async\s+)?(?:\([^)]*\)|[A-Za-z_$][\w$]*)\s*=>.*`),
+}
+
+var cPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^(?:struct|class|enum|union)\s+([A-Za-z_]\w*)\s*\{?\s*$`),
+	regexp.MustCompile(`^[A-Za-z_][\w\s\*&:<>,]*?[\s\*&]([A-Za-z_]\w*)\s*\([^;]*$`),
+}
+
+// fileInfo 单个文件的分析结果
+type fileInfo struct {
+	path    string
+	symbols []Symbol
+	idents  map[string]bool
+}
+
+// Generate 生成排名后的仓库地图
+func Generate(opts Options) (*Map, error) {
+	root := opts.Root
+	if root == "" {
+		root = "."
+	}
+	budget := opts.TokenBudget
+	if budget <= 0 {
+		budget = DefaultTokenBudget
+	}
+
+	files, err := collectFiles(root)
+	if err != nil {
+		return nil, err
+	}
+
+	// 解析每个文件的符号与引用的标识符
+	var infos []*fileInfo
+	for _, path := range files {
+		info := analyzeFile(root, path)
+		if info != nil {
+			infos = append(infos, info)
+		}
+	}
+
+	// 统计每个标识符被多少个文件引用
+	refCount := make(map[string]int)
+	for _, info := range infos {
+		for ident := range info.idents {
+			refCount[ident]++
+		}
+	}
+
+	mentioned := make(map[string]bool)
+	for _, word := range opts.Mentions {
+		mentioned[strings.ToLower(word)] = true
+	}
+
+	// 计算文件分数：被其他文件引用越多的符号越重要
+	entries := make([]FileEntry, 0, len(infos))
+	for _, info := range infos {
+		score := 0.0
+		for i := range info.symbols {
+			sym := &info.symbols[i]
+			refs := refCount[sym.Name]
+			if info.idents[sym.Name] {
+				refs-- // 不计算自身文件
+			}
+			sym.References = refs
+
+			weight := math.Log1p(float64(refs))
+			if mentioned[strings.ToLower(sym.Name)] {
+				weight = weight*10 + 10
+			}
+			score += weight
+		}
+
+		base := strings.ToLower(filepath.Base(info.path))
+		stem := strings.TrimSuffix(base, filepath.Ext(base))
+		if mentioned[base] || mentioned[stem] {
+			score = score*10 + 10
+		}
+		if stem == "main" || stem == "index" || stem == "app" {
+			score += 1
+		}
+
+		sort.SliceStable(info.symbols, func(i, j int) bool {
+			return info.symbols[i].References > info.symbols[j].References
+		})
+
+		entries = append(entries, FileEntry{
+			Path:    info.path,
+			Score:   score,
+			Symbols: info.symbols,
+		})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Score != entries[j].Score {
+			return entries[i].Score > entries[j].Score
+		}
+		return entries[i].Path < entries[j].Path
+	})
+
+	result := &Map{TotalFiles: len(entries)}
+	if opts.Embedder != nil && strings.TrimSpace(opts.Query) != "" {
+		if err := rankSemantically(context.Background(), opts.Embedder, opts.Query, entries); err != nil {
+			result.Warning = err.Error()
+		}
+	}
+	result.Text, result.Files = render(entries, budget)
+	result.IncludedFiles = len(result.Files)
+	result.EstimateTokens = EstimateTokens(result.Text)
+
+	return result, nil
+}
+
+// EstimateTokens 粗略估算文本的token数量（约4个字符一个token）
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// render 按排名渲染文件与符号，直到用完token预算
+func render(entries []FileEntry, budget int) (string, []FileEntry) {
+	var sb strings.Builder
+	var included []FileEntry
+
+	for _, entry := range entries {
+		if len(entry.Symbols) == 0 {
+			continue
+		}
+
+		var block strings.Builder
+		block.WriteString(entry.Path + ":\n")
+		header := block.Len()
+
+		var shown []Symbol
+		for _, sym := range entry.Symbols {
+			line := "│ " + sym.Signature + "\n"
+			if EstimateTokens(sb.String()+block.String()+line) > budget {
+				break
+			}
+			block.WriteString(line)
+			shown = append(shown, sym)
+		}
+
+		// 一个符号都放不下时停止
+		if block.Len() == header {
+			break
+		}
+
+		sb.WriteString(block.String())
+		entry.Symbols = shown
+		included = append(included, entry)
+	}
+
+	return sb.String(), included
+}
+
+// collectFiles 收集仓库内需要分析的源码文件（相对路径）
+func collectFiles(root string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil // 忽略错误，继续处理其他文件
+		}
+
+		name := info.Name()
+		if info.IsDir() {
+			if path != root && (ignoredDirs[name] || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if len(files) >= maxFiles {
+			return filepath.SkipDir
+		}
+		if info.Size() > maxFileSize || strings.HasPrefix(name, ".") {
+			return nil
+		}
+
+		ext := strings.ToLower(filepath.Ext(name))
+		if ext != ".go" && symbolPatterns[ext] == nil {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk directory: %w", err)
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// analyzeFile 分析单个文件，提取顶层符号与标识符
+func analyzeFile(root, relPath string) *fileInfo {
+	content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(relPath)))
+	if err != nil {
+		return nil
+	}
+
+	info := &fileInfo{
+		path:   relPath,
+		idents: make(map[string]bool),
+	}
+	for _, ident := range identPattern.FindAllString(string(content), -1) {
+		info.idents[ident] = true
+	}
+
+	if strings.HasSuffix(relPath, ".go") {
+		info.symbols = goSymbols(relPath, content)
+	} else {
+		info.symbols = patternSymbols(strings.ToLower(filepath.Ext(relPath)), content)
+	}
+
+	return info
+}
+
+// goSymbols 使用go/parser提取Go文件的顶层声明
+func goSymbols(path string, content []byte) []Symbol {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, content, parser.SkipObjectResolution)
+	if err != nil {
+		return patternSymbols(".go", content)
+	}
+
+	var symbols []Symbol
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			// 只打印函数签名，不包括函数体与注释
+			sig := *d
+			sig.Body = nil
+			sig.Doc = nil
+			var buf bytes.Buffer
+			if err := printer.Fprint(&buf, fset, &sig); err != nil {
+				continue
+			}
+			symbols = append(symbols, Symbol{
+				Name:      d.Name.Name,
+				Signature: strings.Join(strings.Fields(buf.String()), " "),
+				Line:      fset.Position(d.Pos()).Line,
+			})
+
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					kind := "type"
+					switch s.Type.(type) {
+					case *ast.StructType:
+						kind = "struct"
+					case *ast.InterfaceType:
+						kind = "interface"
+					}
+					sig := fmt.Sprintf("type %s", s.Name.Name)
+					if kind != "type" {
+						sig += " " + kind
+					}
+					symbols = append(symbols, Symbol{
+						Name:      s.Name.Name,
+						Signature: sig,
+						Line:      fset.Position(s.Pos()).Line,
+					})
+				case *ast.ValueSpec:
+					// 只收录导出的常量与变量
+					for _, name := range s.Names {
+						if !name.IsExported() {
+							continue
+						}
+						symbols = append(symbols, Symbol{
+							Name:      name.Name,
+							Signature: fmt.Sprintf("%s %s", d.Tok.String(), name.Name),
+							Line:      fset.Position(name.Pos()).Line,
+						})
+					}
+				}
+			}
+		}
+	}
+
+	return symbols
+}
+
+// patternSymbols 使用正则提取其他语言的顶层符号
+func patternSymbols(ext string, content []byte) []Symbol {
+	patterns := symbolPatterns[ext]
+	if ext == ".go" {
+		patterns = []*regexp.Regexp{regexp.MustCompile(`^(?:func|type)\s+(?:\([^)]*\)\s*)?([A-Za-z_]\w*).*`)}
+	}
+
+	var symbols []Symbol
+	for i, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimRight(line, " \t\r{")
+		for _, pattern := range patterns {
+			match := pattern.FindStringSubmatch(trimmed)
+			if match == nil {
+				continue
+			}
+			signature := strings.TrimSpace(match[0])
+			if len(signature) > 120 {
+				signature = signature[:120] + "..."
+			}
+			symbols = append(symbols, Symbol{
+				Name:      match[1],
+				Signature: signature,
+				Line:      i + 1,
+			})
+			break
+		}
+	}
+
+	return symbols
+}
\ No newline at end of file
@@ -0,0 +1,93 @@
+package repomap
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"openCursor/internal/embeddings"
+)
+
+// 语义排名的参数
+const (
+	semanticWeight   = 1.0  // 语义相似度相对于归一化后的引用分数的权重
+	maxEmbeddedFiles = 1000 // 最多计算向量的文件数（按引用分数取前面的文件）
+	maxSummaryChars  = 1000 // 每个文件用于计算向量的摘要长度
+)
+
+// rankSemantically 按查询与文件摘要（路径与符号签名）的向量相似度调整排名。
+// 引用分数归一化到0~1后加上归一化的相似度，entries 需已按引用分数排序
+func rankSemantically(ctx context.Context, embedder embeddings.Embedder, query string, entries []FileEntry) error {
+	var candidates []int
+	for i, entry := range entries {
+		if len(entry.Symbols) > 0 {
+			candidates = append(candidates, i)
+		}
+		if len(candidates) >= maxEmbeddedFiles {
+			break
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	texts := []string{query}
+	for _, i := range candidates {
+		texts = append(texts, fileSummary(entries[i]))
+	}
+	vectors, err := embedder.Embed(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("semantic ranking disabled: %w", err)
+	}
+
+	similarities := make([]float64, len(candidates))
+	minSim, maxSim := 1.0, -1.0
+	for j := range candidates {
+		similarities[j] = embeddings.Cosine(vectors[0], vectors[j+1])
+		if similarities[j] < minSim {
+			minSim = similarities[j]
+		}
+		if similarities[j] > maxSim {
+			maxSim = similarities[j]
+		}
+	}
+
+	maxScore := 0.0
+	for _, entry := range entries {
+		if entry.Score > maxScore {
+			maxScore = entry.Score
+		}
+	}
+	for i := range entries {
+		if maxScore > 0 {
+			entries[i].Score /= maxScore
+		}
+	}
+	if maxSim > minSim {
+		for j, i := range candidates {
+			entries[i].Score += semanticWeight * (similarities[j] - minSim) / (maxSim - minSim)
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Score != entries[j].Score {
+			return entries[i].Score > entries[j].Score
+		}
+		return entries[i].Path < entries[j].Path
+	})
+	return nil
+}
+
+// fileSummary 用于计算向量的文件摘要：路径与符号签名
+func fileSummary(entry FileEntry) string {
+	var sb strings.Builder
+	sb.WriteString(entry.Path)
+	for _, sym := range entry.Symbols {
+		if sb.Len()+len(sym.Signature) > maxSummaryChars {
+			break
+		}
+		sb.WriteString("\n" + sym.Signature)
+	}
+	return sb.String()
+}
\ No newline at end of file
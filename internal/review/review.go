@@ -0,0 +1,170 @@
+package review
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 发现的严重程度，从高到低
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityNote    = "note"
+)
+
+// severityRank 严重程度的排序值，越大越严重
+var severityRank = map[string]int{
+	SeverityNote:    1,
+	SeverityWarning: 2,
+	SeverityError:   3,
+}
+
+// Finding 审查中发现的一个问题
+type Finding struct {
+	File       string `json:"file"`
+	Line       int    `json:"line,omitempty"`
+	EndLine    int    `json:"end_line,omitempty"`
+	Severity   string `json:"severity"`
+	Title      string `json:"title"`
+	Message    string `json:"message,omitempty"`
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// Report 一次审查的结果
+type Report struct {
+	Summary  string    `json:"summary"`
+	Findings []Finding `json:"findings"`
+}
+
+// NormalizeSeverity 将模型给出的严重程度归一为 error、warning 或 note
+func NormalizeSeverity(severity string) string {
+	switch strings.ToLower(strings.TrimSpace(severity)) {
+	case "error", "critical", "blocker", "high", "major", "bug":
+		return SeverityError
+	case "warning", "warn", "medium", "moderate":
+		return SeverityWarning
+	default:
+		return SeverityNote
+	}
+}
+
+// ValidSeverity 是否为可识别的严重程度（用于 --fail-on 之类的参数）
+func ValidSeverity(severity string) bool {
+	_, ok := severityRank[severity]
+	return ok
+}
+
+// AtLeast 严重程度 severity 是否不低于 threshold
+func AtLeast(severity, threshold string) bool {
+	return severityRank[severity] >= severityRank[threshold]
+}
+
+// Parse 从模型的最终回答中解析审查结果。回答可能包裹在代码块中或带有前后说明，
+// 取第一个 { 到最后一个 } 之间的JSON对象
+func Parse(text string) (*Report, error) {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("the review does not contain a JSON object")
+	}
+
+	var report Report
+	if err := json.Unmarshal([]byte(text[start:end+1]), &report); err != nil {
+		return nil, fmt.Errorf("failed to parse the review: %w", err)
+	}
+
+	findings := make([]Finding, 0, len(report.Findings))
+	for _, f := range report.Findings {
+		f.File = strings.TrimPrefix(strings.TrimSpace(f.File), "./")
+		f.File = strings.TrimPrefix(strings.TrimPrefix(f.File, "a/"), "b/")
+		f.Severity = NormalizeSeverity(f.Severity)
+		f.Title = strings.TrimSpace(f.Title)
+		if f.Title == "" && f.Message == "" {
+			continue
+		}
+		if f.Line < 0 {
+			f.Line = 0
+		}
+		if f.EndLine < f.Line {
+			f.EndLine = 0
+		}
+		findings = append(findings, f)
+	}
+	report.Findings = findings
+	report.Summary = strings.TrimSpace(report.Summary)
+	report.Sort()
+	return &report, nil
+}
+
+// Sort 按严重程度、文件与行号排序
+func (r *Report) Sort() {
+	sort.SliceStable(r.Findings, func(i, j int) bool {
+		a, b := r.Findings[i], r.Findings[j]
+		if severityRank[a.Severity] != severityRank[b.Severity] {
+			return severityRank[a.Severity] > severityRank[b.Severity]
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+}
+
+// Count 严重程度不低于 threshold 的发现数
+func (r *Report) Count(threshold string) int {
+	n := 0
+	for _, f := range r.Findings {
+		if AtLeast(f.Severity, threshold) {
+			n++
+		}
+	}
+	return n
+}
+
+// Text 供终端阅读的文本格式
+func (r *Report) Text() string {
+	var b strings.Builder
+	if r.Summary != "" {
+		b.WriteString(r.Summary)
+		b.WriteString("\n\n")
+	}
+	if len(r.Findings) == 0 {
+		b.WriteString("No findings.\n")
+		return b.String()
+	}
+
+	icons := map[string]string{SeverityError: "❌", SeverityWarning: "⚠️ ", SeverityNote: "💡"}
+	for _, f := range r.Findings {
+		location := f.File
+		if f.Line > 0 {
+			location = fmt.Sprintf("%s:%d", f.File, f.Line)
+			if f.EndLine > f.Line {
+				location = fmt.Sprintf("%s-%d", location, f.EndLine)
+			}
+		}
+		fmt.Fprintf(&b, "%s %s %s: %s\n", icons[f.Severity], strings.ToUpper(f.Severity), location, f.Title)
+		if f.Message != "" {
+			b.WriteString(indent(f.Message, "   "))
+		}
+		if f.Suggestion != "" {
+			b.WriteString(indent("Suggestion: "+f.Suggestion, "   "))
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "%d error(s), %d warning(s), %d note(s)\n",
+		r.Count(SeverityError), r.Count(SeverityWarning)-r.Count(SeverityError), len(r.Findings)-r.Count(SeverityWarning))
+	return b.String()
+}
+
+// indent 为多行文本的每一行加上前缀
+func indent(text, prefix string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		b.WriteString(prefix)
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
\ No newline at end of file
@@ -0,0 +1,113 @@
+package review
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// SARIF 2.1.0 的最小子集，足够被 GitHub code scanning 等CI工具读取
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name    string      `json:"name"`
+	Version string      `json:"version,omitempty"`
+	Rules   []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine,omitempty"`
+}
+
+// sarifRuleID 所有发现共用的规则，按严重程度区分
+const sarifRuleID = "opencursor/review"
+
+// SARIF 将审查结果转换为SARIF 2.1.0 日志
+func (r *Report) SARIF(toolVersion string) ([]byte, error) {
+	results := make([]sarifResult, 0, len(r.Findings))
+	for _, f := range r.Findings {
+		text := f.Title
+		if f.Message != "" {
+			text = strings.TrimSpace(text + "\n\n" + f.Message)
+		}
+		if f.Suggestion != "" {
+			text += "\n\nSuggestion: " + f.Suggestion
+		}
+
+		result := sarifResult{
+			RuleID:  sarifRuleID,
+			Level:   f.Severity, // error、warning、note 与SARIF的级别一致
+			Message: sarifMessage{Text: text},
+		}
+		if f.File != "" {
+			location := sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: f.File, URIBaseID: "%SRCROOT%"},
+			}
+			if f.Line > 0 {
+				location.Region = &sarifRegion{StartLine: f.Line, EndLine: f.EndLine}
+			}
+			result.Locations = []sarifLocation{{PhysicalLocation: location}}
+		}
+		results = append(results, result)
+	}
+
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:    "openCursor",
+				Version: toolVersion,
+				Rules: []sarifRule{{
+					ID:               sarifRuleID,
+					ShortDescription: sarifMessage{Text: "Issue found by openCursor code review"},
+				}},
+			}},
+			Results: results,
+		}},
+	}
+	return json.MarshalIndent(log, "", "  ")
+}
\ No newline at end of file
@@ -0,0 +1,115 @@
+package roles
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 角色可用的工具集，与 spawn_task 的工具集一致
+const (
+	ToolsReadOnly = "read_only" // 只读工具
+	ToolsEdit     = "edit"      // 只读工具与文件编辑工具，不能执行命令
+	ToolsAll      = "all"       // 所有工具
+)
+
+// Role 代理的角色预设：追加到系统提示词中的说明与默认工具集
+type Role struct {
+	Name        string `yaml:"-"`
+	Description string `yaml:"description,omitempty"`
+	Prompt      string `yaml:"prompt,omitempty"`
+	Tools       string `yaml:"tools,omitempty"` // read_only、edit 或 all，默认 all
+}
+
+// Builtin 内置的角色
+func Builtin() map[string]Role {
+	return map[string]Role{
+		"architect": {
+			Description: "Analyzes the codebase and designs changes without modifying files",
+			Tools:       ToolsReadOnly,
+			Prompt: `You are acting as a software architect. Study how the codebase is structured before answering: its modules, their responsibilities and the dependencies between them.
+Do not modify files. Instead produce a design: the components involved, the changes each one needs, the interfaces between them, the trade-offs of the alternatives you considered and a step-by-step implementation plan.
+Reference concrete files and symbols, and call out risks, migration concerns and anything that needs a decision from the user.`,
+		},
+		"reviewer": {
+			Description: "Reviews code for bugs, security issues and maintainability without modifying files",
+			Tools:       ToolsReadOnly,
+			Prompt: `You are acting as a meticulous code reviewer. Read the relevant code and its callers before judging it.
+Do not modify files. Report concrete problems ordered by severity: correctness bugs, security issues, race conditions, error handling gaps, performance problems, then maintainability and style.
+For each finding give the file and line, explain why it is a problem and suggest a fix. Say so explicitly when you find nothing significant.`,
+		},
+		"debugger": {
+			Description: "Reproduces, diagnoses and fixes bugs",
+			Tools:       ToolsAll,
+			Prompt: `You are acting as a debugger. Reproduce the problem first, by running the failing command or test or by writing a minimal reproduction.
+Form hypotheses about the root cause and verify each one with evidence such as logs, added diagnostics or narrowed-down inputs before changing code.
+Fix the root cause rather than the symptom, keep the fix minimal, remove temporary diagnostics and confirm the fix by re-running the reproduction and the related tests.`,
+		},
+		"doc-writer": {
+			Description: "Writes and updates documentation and code comments",
+			Tools:       ToolsEdit,
+			Prompt: `You are acting as a technical writer. Read the code you document so that every statement is accurate; never describe behavior you have not verified in the source.
+Write documentation and comments in the style, language and format the project already uses. Prefer concise explanations with examples of real usage.
+Only change documentation and comments, never the behavior of the code.`,
+		},
+	}
+}
+
+// Resolve 按名称查找角色。custom 为配置文件中的角色：与内置角色同名时覆盖其非空字段，否则新增角色
+func Resolve(name string, custom map[string]Role) (Role, error) {
+	all := Merge(custom)
+	role, ok := all[name]
+	if !ok {
+		return Role{}, fmt.Errorf("unknown role %q (available: %s)", name, strings.Join(Names(all), ", "))
+	}
+	if err := role.Validate(); err != nil {
+		return Role{}, err
+	}
+	return role, nil
+}
+
+// Merge 合并内置角色与配置文件中的角色，未指定工具集的角色使用全部工具
+func Merge(custom map[string]Role) map[string]Role {
+	all := Builtin()
+	for name, role := range custom {
+		if base, ok := all[name]; ok {
+			if role.Description == "" {
+				role.Description = base.Description
+			}
+			if role.Prompt == "" {
+				role.Prompt = base.Prompt
+			}
+			if role.Tools == "" {
+				role.Tools = base.Tools
+			}
+		}
+		all[name] = role
+	}
+	for name, role := range all {
+		role.Name = name
+		if role.Tools == "" {
+			role.Tools = ToolsAll
+		}
+		all[name] = role
+	}
+	return all
+}
+
+// Validate 检查角色的工具集是否有效
+func (r Role) Validate() error {
+	switch r.Tools {
+	case ToolsReadOnly, ToolsEdit, ToolsAll:
+		return nil
+	}
+	return fmt.Errorf("role %s: unknown tools %q (expected %s, %s or %s)", r.Name, r.Tools, ToolsReadOnly, ToolsEdit, ToolsAll)
+}
+
+// Names 按名称排序的角色名
+func Names(all map[string]Role) []string {
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
\ No newline at end of file
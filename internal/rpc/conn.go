@@ -0,0 +1,169 @@
+// Package rpc 实现 JSON-RPC 2.0 的消息收发，消息使用与 LSP 相同的 Content-Length 头分帧或 WebSocket 传输，
+// 用作编辑器插件（VS Code、Neovim 等）与浏览器界面和 openCursor 之间的后端协议
+package rpc
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 标准错误码
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	CodeRequestFailed  = -32803 // 请求合法但执行失败（与 LSP 的 RequestFailed 相同）
+)
+
+// maxContentLength 单条消息的最大长度，防止错误的头部导致分配过大的内存
+const maxContentLength = 64 << 20
+
+// Message 一条 JSON-RPC 消息：带 ID 与 Method 的为请求，只有 Method 的为通知，只有 ID 的为响应
+type Message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// IsNotification 是否为通知（不需要响应）
+func (m *Message) IsNotification() bool {
+	return len(m.ID) == 0
+}
+
+// Error JSON-RPC 错误对象
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// Errorf 创建指定错误码的错误
+func Errorf(code int, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Stream 收发完整消息体的传输方式，如 Content-Length 分帧的字节流或 WebSocket
+type Stream interface {
+	ReadMessage() ([]byte, error) // 连接关闭时返回 io.EOF
+	WriteMessage(data []byte) error
+}
+
+// headerStream 使用与 LSP 相同的 Content-Length 头分帧的字节流
+type headerStream struct {
+	reader *bufio.Reader
+	writer io.Writer
+}
+
+// NewHeaderStream 创建 Content-Length 分帧的字节流，如标准输入输出
+func NewHeaderStream(r io.Reader, w io.Writer) Stream {
+	return &headerStream{reader: bufio.NewReader(r), writer: w}
+}
+
+func (s *headerStream) ReadMessage() ([]byte, error) {
+	header, err := textproto.NewReader(s.reader).ReadMIMEHeader()
+	if err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("failed to read message header: %w", err)
+	}
+	value := strings.TrimSpace(header.Get("Content-Length"))
+	if value == "" {
+		return nil, fmt.Errorf("message without Content-Length header")
+	}
+	length, err := strconv.Atoi(value)
+	if err != nil || length < 0 || length > maxContentLength {
+		return nil, fmt.Errorf("invalid Content-Length %q", value)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(s.reader, body); err != nil {
+		return nil, fmt.Errorf("failed to read message body: %w", err)
+	}
+	return body, nil
+}
+
+func (s *headerStream) WriteMessage(data []byte) error {
+	if _, err := fmt.Fprintf(s.writer, "Content-Length: %d\r\n\r\n", len(data)); err != nil {
+		return err
+	}
+	_, err := s.writer.Write(data)
+	return err
+}
+
+// Conn JSON-RPC 连接。Read 只能在一个协程中调用，Reply、Notify 等写入方法可以并发调用
+type Conn struct {
+	stream Stream
+	mu     sync.Mutex
+}
+
+// NewConn 在传输方式上创建连接
+func NewConn(stream Stream) *Conn {
+	return &Conn{stream: stream}
+}
+
+// Read 读取下一条消息，连接关闭时返回 io.EOF。
+// 消息体不是合法的 JSON 时返回 *Error（CodeParseError），连接仍可继续使用
+func (c *Conn) Read() (*Message, error) {
+	body, err := c.stream.ReadMessage()
+	if err != nil {
+		return nil, err
+	}
+	var msg Message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, Errorf(CodeParseError, "invalid JSON: %v", err)
+	}
+	return &msg, nil
+}
+
+// Reply 发送请求的成功响应
+func (c *Conn) Reply(id json.RawMessage, result interface{}) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return c.ReplyError(id, Errorf(CodeInternalError, "failed to encode result: %v", err))
+	}
+	return c.write(&Message{ID: id, Result: data})
+}
+
+// ReplyError 发送请求的错误响应，id 为空（无法解析请求）时使用 null
+func (c *Conn) ReplyError(id json.RawMessage, rpcErr *Error) error {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return c.write(&Message{ID: id, Error: rpcErr})
+}
+
+// Notify 发送通知
+func (c *Conn) Notify(method string, params interface{}) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s params: %w", method, err)
+	}
+	return c.write(&Message{Method: method, Params: data})
+}
+
+// write 编码并发送一条消息
+func (c *Conn) write(msg *Message) error {
+	msg.JSONRPC = "2.0"
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stream.WriteMessage(body)
+}
\ No newline at end of file
@@ -0,0 +1,33 @@
+package rpc
+
+import (
+	"io"
+
+	"golang.org/x/net/websocket"
+)
+
+// websocketStream 每条消息为一个 WebSocket 文本帧
+type websocketStream struct {
+	ws *websocket.Conn
+}
+
+// NewWebSocketStream 在 WebSocket 连接上创建传输方式
+func NewWebSocketStream(ws *websocket.Conn) Stream {
+	ws.MaxPayloadBytes = maxContentLength
+	return &websocketStream{ws: ws}
+}
+
+func (s *websocketStream) ReadMessage() ([]byte, error) {
+	var data []byte
+	if err := websocket.Message.Receive(s.ws, &data); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *websocketStream) WriteMessage(data []byte) error {
+	return websocket.Message.Send(s.ws, string(data))
+}
\ No newline at end of file
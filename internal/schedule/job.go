@@ -0,0 +1,223 @@
+package schedule
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout 单次定时任务的默认超时时间
+const DefaultTimeout = 30 * time.Minute
+
+// Job 配置文件中的一个定时任务
+type Job struct {
+	Name         string   `yaml:"name"`
+	Cron         string   `yaml:"cron"`
+	Prompt       string   `yaml:"prompt"`
+	WorkDir      string   `yaml:"workdir,omitempty"`
+	Args         []string `yaml:"args,omitempty"`    // 追加给 openCursor 的参数，如 [--repo-map]
+	Timeout      string   `yaml:"timeout,omitempty"` // 如 "45m"，默认30分钟
+	ArtifactsDir string   `yaml:"artifacts_dir,omitempty"`
+}
+
+// Validate 校验任务配置
+func (j *Job) Validate() error {
+	if j.Name == "" {
+		return fmt.Errorf("schedule entry is missing a name")
+	}
+	if strings.ContainsAny(j.Name, `/\`) {
+		return fmt.Errorf("schedule %q: name must not contain path separators", j.Name)
+	}
+	if j.Prompt == "" {
+		return fmt.Errorf("schedule %q: prompt is required", j.Name)
+	}
+	if _, err := ParseCron(j.Cron); err != nil {
+		return fmt.Errorf("schedule %q: %w", j.Name, err)
+	}
+	if _, err := j.timeout(); err != nil {
+		return fmt.Errorf("schedule %q: %w", j.Name, err)
+	}
+	return nil
+}
+
+// timeout 解析超时时间
+func (j *Job) timeout() (time.Duration, error) {
+	if j.Timeout == "" {
+		return DefaultTimeout, nil
+	}
+	d, err := time.ParseDuration(j.Timeout)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid timeout %q", j.Timeout)
+	}
+	return d, nil
+}
+
+// RunOptions 执行定时任务的选项
+type RunOptions struct {
+	Executable   string   // openCursor 可执行文件
+	BaseArgs     []string // 每次运行都带上的参数（如 --config）
+	ArtifactsDir string   // 默认产物目录
+	Output       io.Writer
+}
+
+// Record 一次运行的记录，保存在产物目录的 run.json 中
+type Record struct {
+	Job          string    `json:"job"`
+	Prompt       string    `json:"prompt"`
+	WorkDir      string    `json:"workdir"`
+	StartedAt    time.Time `json:"started_at"`
+	FinishedAt   time.Time `json:"finished_at"`
+	ExitCode     int       `json:"exit_code"`
+	Error        string    `json:"error,omitempty"`
+	ChangedFiles []string  `json:"changed_files,omitempty"`
+	PatchFile    string    `json:"patch_file,omitempty"`
+	LogFile      string    `json:"log_file"`
+	ArtifactsDir string    `json:"artifacts_dir"`
+}
+
+// Run 以非交互子进程方式执行一次任务，并把输出、补丁与运行记录写入产物目录
+func Run(ctx context.Context, job Job, opts RunOptions) (*Record, error) {
+	timeout, err := job.timeout()
+	if err != nil {
+		return nil, err
+	}
+
+	workDir := job.WorkDir
+	if workDir == "" {
+		workDir, _ = os.Getwd()
+	}
+	workDir, err = filepath.Abs(workDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workdir: %w", err)
+	}
+
+	baseDir := job.ArtifactsDir
+	if baseDir == "" {
+		baseDir = filepath.Join(opts.ArtifactsDir, job.Name)
+	}
+	record := &Record{
+		Job:       job.Name,
+		Prompt:    job.Prompt,
+		WorkDir:   workDir,
+		StartedAt: time.Now(),
+	}
+	record.ArtifactsDir = filepath.Join(baseDir, record.StartedAt.Format("20060102-150405"))
+	if err := os.MkdirAll(record.ArtifactsDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create artifacts directory: %w", err)
+	}
+
+	record.LogFile = filepath.Join(record.ArtifactsDir, "output.log")
+	logFile, err := os.Create(record.LogFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create log file: %w", err)
+	}
+	defer logFile.Close()
+
+	// 记录运行前的提交，用于生成本次运行的补丁
+	baseline := gitOutput(workDir, "rev-parse", "HEAD")
+	preexisting := make(map[string]bool)
+	if baseline != "" {
+		for _, file := range strings.Split(gitOutput(workDir, "ls-files", "--others", "--exclude-standard"), "\n") {
+			preexisting[file] = true
+		}
+		if gitOutput(workDir, "status", "--porcelain", "--untracked-files=no") != "" {
+			fmt.Fprintf(logFile, "[schedule] warning: %s has uncommitted changes, they will be included in changes.patch\n", workDir)
+		}
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	args := append(append(append([]string{}, opts.BaseArgs...), job.Args...), job.Prompt)
+	cmd := exec.CommandContext(runCtx, opts.Executable, args...)
+	cmd.Dir = workDir
+	cmd.Env = append(os.Environ(), "OPENCURSOR_SCHEDULE_JOB="+job.Name)
+	// 无标准输入：任何需要交互确认的操作都不会得到批准
+	cmd.Stdin = nil
+	var out io.Writer = logFile
+	if opts.Output != nil {
+		out = io.MultiWriter(logFile, opts.Output)
+	}
+	cmd.Stdout = out
+	cmd.Stderr = out
+
+	runErr := cmd.Run()
+	record.FinishedAt = time.Now()
+	if cmd.ProcessState != nil {
+		record.ExitCode = cmd.ProcessState.ExitCode()
+	}
+	if runCtx.Err() == context.DeadlineExceeded {
+		record.Error = fmt.Sprintf("timed out after %s", timeout)
+	} else if runErr != nil {
+		record.Error = runErr.Error()
+	}
+
+	if baseline != "" {
+		patch, files := collectPatch(workDir, baseline, preexisting)
+		record.ChangedFiles = files
+		if len(patch) > 0 {
+			record.PatchFile = filepath.Join(record.ArtifactsDir, "changes.patch")
+			if err := os.WriteFile(record.PatchFile, patch, 0644); err != nil {
+				return nil, fmt.Errorf("failed to write patch: %w", err)
+			}
+		}
+	}
+
+	data, _ := json.MarshalIndent(record, "", "  ")
+	if err := os.WriteFile(filepath.Join(record.ArtifactsDir, "run.json"), data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write run record: %w", err)
+	}
+
+	return record, nil
+}
+
+// gitOutput 执行git命令并返回去除空白的输出，失败时返回空字符串
+func gitOutput(dir string, args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// collectPatch 生成相对于基线提交的补丁（包含新提交、工作区修改与本次新增的未跟踪文件）
+func collectPatch(dir, baseline string, preexisting map[string]bool) ([]byte, []string) {
+	var patch bytes.Buffer
+
+	diff := exec.Command("git", "diff", "--binary", baseline)
+	diff.Dir = dir
+	if output, err := diff.Output(); err == nil {
+		patch.Write(output)
+	}
+
+	var files []string
+	if names := gitOutput(dir, "diff", "--name-only", baseline); names != "" {
+		files = strings.Split(names, "\n")
+	}
+
+	untracked := gitOutput(dir, "ls-files", "--others", "--exclude-standard")
+	if untracked != "" {
+		for _, file := range strings.Split(untracked, "\n") {
+			if preexisting[file] {
+				continue
+			}
+			// --no-index 在有差异时以退出码1结束，输出仍然有效
+			cmd := exec.Command("git", "diff", "--binary", "--no-index", os.DevNull, file)
+			cmd.Dir = dir
+			output, _ := cmd.Output()
+			patch.Write(output)
+			files = append(files, file)
+		}
+	}
+
+	return patch.Bytes(), files
+}
\ No newline at end of file
@@ -0,0 +1,165 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Entry 已解析的定时任务
+type Entry struct {
+	Job  Job
+	Cron *Cron
+}
+
+// NextRun 计算任务在给定时间之后的下一次运行时间
+func (e Entry) NextRun(after time.Time) time.Time {
+	return e.Cron.Next(after)
+}
+
+// Load 校验并解析任务列表，任务名必须唯一
+func Load(jobs []Job) ([]Entry, error) {
+	seen := make(map[string]bool)
+	entries := make([]Entry, 0, len(jobs))
+	for _, job := range jobs {
+		if err := job.Validate(); err != nil {
+			return nil, err
+		}
+		if seen[job.Name] {
+			return nil, fmt.Errorf("duplicate schedule name %q", job.Name)
+		}
+		seen[job.Name] = true
+
+		cron, _ := ParseCron(job.Cron)
+		entries = append(entries, Entry{Job: job, Cron: cron})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Job.Name < entries[j].Job.Name
+	})
+	return entries, nil
+}
+
+// Find 按名称查找任务
+func Find(entries []Entry, name string) (Entry, bool) {
+	for _, entry := range entries {
+		if entry.Job.Name == name {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Scheduler 在前台按cron表达式循环执行任务
+type Scheduler struct {
+	entries []Entry
+	opts    RunOptions
+	log     io.Writer
+
+	mu      sync.Mutex
+	running map[string]bool
+	wg      sync.WaitGroup
+}
+
+// NewScheduler 创建调度器，log 接收调度事件
+func NewScheduler(entries []Entry, opts RunOptions, log io.Writer) *Scheduler {
+	return &Scheduler{
+		entries: entries,
+		opts:    opts,
+		log:     log,
+		running: make(map[string]bool),
+	}
+}
+
+// Start 运行调度循环直到ctx取消，并等待正在运行的任务结束
+func (s *Scheduler) Start(ctx context.Context) error {
+	if len(s.entries) == 0 {
+		return fmt.Errorf("no schedules configured")
+	}
+
+	next := make(map[string]time.Time, len(s.entries))
+	now := time.Now()
+	for _, entry := range s.entries {
+		next[entry.Job.Name] = entry.NextRun(now)
+		fmt.Fprintf(s.log, "[schedule] %s: next run at %s\n", entry.Job.Name, next[entry.Job.Name].Format(time.RFC3339))
+	}
+
+	for {
+		// 睡眠到最早的下一次触发
+		var wake time.Time
+		for _, t := range next {
+			if !t.IsZero() && (wake.IsZero() || t.Before(wake)) {
+				wake = t
+			}
+		}
+		if wake.IsZero() {
+			s.wg.Wait()
+			return fmt.Errorf("no schedule will ever fire again")
+		}
+
+		timer := time.NewTimer(time.Until(wake))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			fmt.Fprintf(s.log, "[schedule] stopping, waiting for running jobs\n")
+			s.wg.Wait()
+			return nil
+		case <-timer.C:
+		}
+
+		now := time.Now()
+		for _, entry := range s.entries {
+			name := entry.Job.Name
+			if next[name].IsZero() || next[name].After(now) {
+				continue
+			}
+			next[name] = entry.NextRun(now)
+			s.dispatch(ctx, entry)
+		}
+	}
+}
+
+// dispatch 在后台运行任务，同一任务的上一次运行未结束时跳过
+func (s *Scheduler) dispatch(ctx context.Context, entry Entry) {
+	name := entry.Job.Name
+
+	s.mu.Lock()
+	if s.running[name] {
+		s.mu.Unlock()
+		fmt.Fprintf(s.log, "[schedule] %s: previous run still in progress, skipping\n", name)
+		return
+	}
+	s.running[name] = true
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			delete(s.running, name)
+			s.mu.Unlock()
+		}()
+
+		fmt.Fprintf(s.log, "[schedule] %s: started\n", name)
+		record, err := Run(ctx, entry.Job, s.opts)
+		if err != nil {
+			fmt.Fprintf(s.log, "[schedule] %s: failed to run: %v\n", name, err)
+			return
+		}
+		fmt.Fprintf(s.log, "[schedule] %s: %s\n", name, Summary(record))
+	}()
+}
+
+// Summary 一次运行的单行摘要
+func Summary(record *Record) string {
+	status := "succeeded"
+	if record.Error != "" {
+		status = "failed: " + record.Error
+	}
+	summary := fmt.Sprintf("%s in %s, %d files changed, artifacts in %s",
+		status, record.FinishedAt.Sub(record.StartedAt).Round(time.Second), len(record.ChangedFiles), record.ArtifactsDir)
+	return summary
+}
\ No newline at end of file
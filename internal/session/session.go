@@ -0,0 +1,269 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Session 一次对话的持久化记录，用于中断后继续
+type Session struct {
+	ID          string                         `json:"id"`
+	WorkDir     string                         `json:"workdir"`
+	Model       string                         `json:"model"`
+	Query       string                         `json:"query"`
+	CreatedAt   time.Time                      `json:"created_at"`
+	UpdatedAt   time.Time                      `json:"updated_at"`
+	Interrupted bool                           `json:"interrupted,omitempty"`
+	ForkedFrom  string                         `json:"forked_from,omitempty"` // 分叉自的会话ID
+	ForkedAt    int                            `json:"forked_at,omitempty"`   // 分叉时保留的轮数
+	Turns       []Turn                         `json:"turns,omitempty"`
+	EditedFiles []EditedFile                   `json:"edited_files,omitempty"` // 会话中修改过的文件，最近修改的在前
+	Messages    []openai.ChatCompletionMessage `json:"messages"`
+}
+
+// Turn 会话中的一次查询及其用量
+type Turn struct {
+	Query            string    `json:"query"`
+	Model            string    `json:"model"`
+	StartedAt        time.Time `json:"started_at"`
+	PromptTokens     int       `json:"prompt_tokens,omitempty"`
+	CompletionTokens int       `json:"completion_tokens,omitempty"`
+	Estimated        bool      `json:"estimated,omitempty"` // 用量至少有一部分为估计值
+	Cost             float64   `json:"cost,omitempty"`      // 估计费用（美元），模型价格未知时为0
+	ToolCalls        int       `json:"tool_calls,omitempty"`
+	Edits            int       `json:"edits,omitempty"` // 成功修改文件的工具调用
+	End              int       `json:"end,omitempty"`   // 本次查询结束时 Messages 的长度，0表示未知（之前的对话被摘要或记录轮次之前保存）
+}
+
+// EditedFile 会话中修改过的文件及累计的增删行数
+type EditedFile struct {
+	Path    string `json:"path"`
+	Added   int    `json:"added"`
+	Deleted int    `json:"deleted"`
+	Created bool   `json:"created,omitempty"`
+	Removed bool   `json:"removed,omitempty"`
+	Turn    int    `json:"turn"` // 最近一次修改该文件的查询（从1开始）
+}
+
+// AddTurn 记录一次查询，需在更新 Messages 之前调用。
+// 继续记录查询之前保存的会话时，先补上会话的第一次查询
+func (s *Session) AddTurn(turn Turn) {
+	if len(s.Turns) == 0 && len(s.Messages) > 0 {
+		s.Turns = s.History()
+		s.Turns[0].End = len(s.Messages)
+	}
+	s.Turns = append(s.Turns, turn)
+}
+
+// RecordEdits 记录本次查询修改过的文件（最近修改的在前），需在 AddTurn 之后调用。
+// 之前修改过的文件累计增删行数并移到最前
+func (s *Session) RecordEdits(files []EditedFile) {
+	if len(files) == 0 {
+		return
+	}
+	turn := len(s.Turns)
+	merged := make([]EditedFile, 0, len(files)+len(s.EditedFiles))
+	index := make(map[string]int, len(files))
+	for _, file := range files {
+		file.Turn = turn
+		index[file.Path] = len(merged)
+		merged = append(merged, file)
+	}
+	for _, previous := range s.EditedFiles {
+		i, ok := index[previous.Path]
+		if !ok {
+			merged = append(merged, previous)
+			continue
+		}
+		merged[i].Added += previous.Added
+		merged[i].Deleted += previous.Deleted
+		merged[i].Created = merged[i].Created || previous.Created
+	}
+	s.EditedFiles = merged
+}
+
+// EditSummary 会话中修改过的文件的简要列表，附加到之后的查询中；没有修改时为空
+func (s *Session) EditSummary(limit int) string {
+	if len(s.EditedFiles) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("Files edited earlier in this session, most recent first (lines added/deleted in the session). They may have changed since; read them again before editing.\n")
+	for i, file := range s.EditedFiles {
+		if limit > 0 && i == limit {
+			fmt.Fprintf(&sb, "... and %d more\n", len(s.EditedFiles)-limit)
+			break
+		}
+		var status string
+		switch {
+		case file.Removed:
+			status = "deleted"
+		case file.Created:
+			status = fmt.Sprintf("new, +%d", file.Added)
+		default:
+			status = fmt.Sprintf("+%d -%d", file.Added, file.Deleted)
+		}
+		fmt.Fprintf(&sb, "%s (%s, turn %d)\n", file.Path, status, file.Turn)
+	}
+	return sb.String()
+}
+
+// SetMessages 记录本次查询之后的对话，需在 AddTurn 之后调用。
+// 对话被自动摘要时之前的消息不再是新对话的前缀，之前各轮的结束位置随之失效
+func (s *Session) SetMessages(messages []openai.ChatCompletionMessage) {
+	if len(messages) < len(s.Messages) || !reflect.DeepEqual(messages[:len(s.Messages)], s.Messages) {
+		for i := range s.Turns {
+			s.Turns[i].End = 0
+		}
+	}
+	s.Messages = messages
+	if len(s.Turns) > 0 {
+		s.Turns[len(s.Turns)-1].End = len(messages)
+	}
+}
+
+// Fork 复制会话的前 turns 轮为新会话，之后可以从该处换一种方式继续
+func (s *Session) Fork(turns int) (*Session, error) {
+	history := s.History()
+	if turns < 1 || turns > len(history) {
+		return nil, fmt.Errorf("turn %d out of range: session %s has %d turns", turns, s.ID, len(history))
+	}
+	end := len(s.Messages)
+	if turns < len(history) {
+		end = history[turns-1].End
+		if end == 0 || end > len(s.Messages) {
+			return nil, fmt.Errorf("cannot fork session %s at turn %d: the conversation up to that turn was summarized or saved before turns were recorded", s.ID, turns)
+		}
+	}
+
+	fork := New(s.WorkDir, history[turns-1].Model, s.Query)
+	if fork.Model == "" {
+		fork.Model = s.Model
+	}
+	fork.ForkedFrom = s.ID
+	fork.ForkedAt = turns
+	fork.Interrupted = turns == len(history) && s.Interrupted
+	fork.Turns = append([]Turn(nil), history[:turns]...)
+	fork.Messages = append([]openai.ChatCompletionMessage(nil), s.Messages[:end]...)
+	for _, file := range s.EditedFiles {
+		if file.Turn <= turns {
+			fork.EditedFiles = append(fork.EditedFiles, file)
+		}
+	}
+	return fork, nil
+}
+
+// History 会话中的所有查询。记录查询之前保存的会话只有第一次查询
+func (s *Session) History() []Turn {
+	if len(s.Turns) > 0 {
+		return s.Turns
+	}
+	return []Turn{{Query: s.Query, Model: s.Model, StartedAt: s.CreatedAt}}
+}
+
+// New 创建新会话，ID由时间戳和随机后缀组成
+func New(workDir, model, query string) *Session {
+	suffix := make([]byte, 3)
+	rand.Read(suffix)
+	now := time.Now()
+	return &Session{
+		ID:        now.Format("20060102-150405") + "-" + hex.EncodeToString(suffix),
+		WorkDir:   workDir,
+		Model:     model,
+		Query:     query,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Path 会话文件路径
+func Path(dir, id string) string {
+	return filepath.Join(dir, id+".json")
+}
+
+// Save 将会话写入 dir/<id>.json
+func (s *Session) Save(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create session directory: %w", err)
+	}
+	s.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+	// 先写临时文件再重命名，避免中断时留下损坏的会话
+	tmp := Path(dir, s.ID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	if err := os.Rename(tmp, Path(dir, s.ID)); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	return nil
+}
+
+// Load 读取指定ID的会话
+func Load(dir, id string) (*Session, error) {
+	data, err := os.ReadFile(Path(dir, id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("session %q not found in %s", id, dir)
+		}
+		return nil, fmt.Errorf("failed to read session: %w", err)
+	}
+	s := &Session{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse session %s: %w", id, err)
+	}
+	return s, nil
+}
+
+// List 读取目录中的所有会话，最近更新的在前。无法解析的文件被跳过
+func List(dir string) ([]*Session, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read session directory: %w", err)
+	}
+	var sessions []*Session
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		s, err := Load(dir, strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+		sessions = append(sessions, s)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt)
+	})
+	return sessions, nil
+}
+
+// Latest 在目录中查找工作目录为 workDir 的最近一次会话
+func Latest(dir, workDir string) (*Session, error) {
+	sessions, err := List(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range sessions {
+		if s.WorkDir == workDir {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("no saved session for %s", workDir)
+}
\ No newline at end of file
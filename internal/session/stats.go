@@ -0,0 +1,94 @@
+package session
+
+import (
+	"sort"
+	"time"
+)
+
+// Totals 一组查询的累计用量与活动
+type Totals struct {
+	Queries          int     `json:"queries"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+	ToolCalls        int     `json:"tool_calls"`
+	Edits            int     `json:"edits"`
+	Estimated        bool    `json:"estimated"` // 至少有一部分用量为估计值
+	Unpriced         bool    `json:"unpriced"`  // 至少有一次查询的模型价格未知，费用偏低
+}
+
+// Tokens 输入与输出tokens之和
+func (t Totals) Tokens() int {
+	return t.PromptTokens + t.CompletionTokens
+}
+
+// add 累计一次查询
+func (t *Totals) add(turn Turn) {
+	t.Queries++
+	t.PromptTokens += turn.PromptTokens
+	t.CompletionTokens += turn.CompletionTokens
+	t.Cost += turn.Cost
+	t.ToolCalls += turn.ToolCalls
+	t.Edits += turn.Edits
+	if turn.Estimated {
+		t.Estimated = true
+	}
+	if turn.Cost == 0 && turn.PromptTokens+turn.CompletionTokens > 0 {
+		t.Unpriced = true
+	}
+}
+
+// Group 按日期或模型分组的累计值
+type Group struct {
+	Key string `json:"key"`
+	Totals
+}
+
+// Stats 会话记录的统计
+type Stats struct {
+	Total   Totals  `json:"total"`
+	ByDay   []Group `json:"by_day"`   // 按日期（本地时间 YYYY-MM-DD）升序
+	ByModel []Group `json:"by_model"` // 按token用量降序
+}
+
+// Aggregate 统计 since 之后（为零时不限）的查询，workDir 不为空时只统计该目录的会话
+func Aggregate(sessions []*Session, since time.Time, workDir string) Stats {
+	days := make(map[string]*Totals)
+	models := make(map[string]*Totals)
+	var stats Stats
+	for _, s := range sessions {
+		if workDir != "" && s.WorkDir != workDir {
+			continue
+		}
+		for _, turn := range s.History() {
+			if !since.IsZero() && turn.StartedAt.Before(since) {
+				continue
+			}
+			day := turn.StartedAt.Local().Format("2006-01-02")
+			if days[day] == nil {
+				days[day] = &Totals{}
+			}
+			if models[turn.Model] == nil {
+				models[turn.Model] = &Totals{}
+			}
+			days[day].add(turn)
+			models[turn.Model].add(turn)
+			stats.Total.add(turn)
+		}
+	}
+
+	for day, totals := range days {
+		stats.ByDay = append(stats.ByDay, Group{Key: day, Totals: *totals})
+	}
+	sort.Slice(stats.ByDay, func(i, j int) bool { return stats.ByDay[i].Key < stats.ByDay[j].Key })
+	for model, totals := range models {
+		stats.ByModel = append(stats.ByModel, Group{Key: model, Totals: *totals})
+	}
+	sort.Slice(stats.ByModel, func(i, j int) bool {
+		if stats.ByModel[i].Tokens() != stats.ByModel[j].Tokens() {
+			return stats.ByModel[i].Tokens() > stats.ByModel[j].Tokens()
+		}
+		return stats.ByModel[i].Key < stats.ByModel[j].Key
+	})
+	return stats
+}
\ No newline at end of file
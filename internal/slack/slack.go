@@ -0,0 +1,125 @@
+// Package slack 实现 openCursor slack 使用的 Slack Web API 与 Socket Mode 客户端
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL Slack Web API 地址
+const DefaultAPIURL = "https://slack.com/api"
+
+// MaxMessageLength 单条消息文本的最大长度（Slack 限制为 40000 个字符）
+const MaxMessageLength = 39000
+
+// Client Slack Web API 客户端。appToken（xapp-）用于建立 Socket Mode 连接，botToken（xoxb-）用于发送消息
+type Client struct {
+	apiURL   string
+	appToken string
+	botToken string
+	http     *http.Client
+}
+
+// NewClient 创建客户端
+func NewClient(appToken, botToken string) *Client {
+	return &Client{
+		apiURL:   DefaultAPIURL,
+		appToken: appToken,
+		botToken: botToken,
+		http:     &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// apiResponse 所有 Web API 响应共有的字段
+type apiResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+// call 以 JSON 调用 Web API 方法，并将响应解码到 out
+func (c *Client) call(ctx context.Context, method, token string, body interface{}, out interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL+"/"+method, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("Slack request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read Slack response: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("Slack API %s: HTTP %d", method, resp.StatusCode)
+	}
+
+	var result apiResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("failed to parse Slack response: %w", err)
+	}
+	if !result.OK {
+		msg := result.Error
+		switch msg {
+		case "invalid_auth", "not_authed":
+			msg += " (check SLACK_APP_TOKEN and SLACK_BOT_TOKEN)"
+		case "not_in_channel":
+			msg += " (invite the app to the channel)"
+		}
+		return fmt.Errorf("Slack API %s: %s", method, msg)
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("failed to parse Slack response: %w", err)
+		}
+	}
+	return nil
+}
+
+// AuthTest 返回机器人的用户ID，用于识别提及与忽略自己的消息
+func (c *Client) AuthTest(ctx context.Context) (string, error) {
+	var result struct {
+		UserID string `json:"user_id"`
+	}
+	if err := c.call(ctx, "auth.test", c.botToken, struct{}{}, &result); err != nil {
+		return "", err
+	}
+	return result.UserID, nil
+}
+
+// PostMessage 在频道中发送消息，threadTS 不为空时回复到该消息的会话串中。过长的文本被截断
+func (c *Client) PostMessage(ctx context.Context, channel, threadTS, text string) error {
+	if len(text) > MaxMessageLength {
+		text = strings.ToValidUTF8(text[:MaxMessageLength], "") + "\n…（已截断）"
+	}
+	return c.call(ctx, "chat.postMessage", c.botToken, map[string]string{
+		"channel":   channel,
+		"thread_ts": threadTS,
+		"text":      text,
+	}, nil)
+}
+
+// openConnection 获取 Socket Mode 的 WebSocket 地址
+func (c *Client) openConnection(ctx context.Context) (string, error) {
+	var result struct {
+		URL string `json:"url"`
+	}
+	if err := c.call(ctx, "apps.connections.open", c.appToken, struct{}{}, &result); err != nil {
+		return "", err
+	}
+	return result.URL, nil
+}
\ No newline at end of file
@@ -0,0 +1,124 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// Event app_mention 或 message 事件
+type Event struct {
+	Type        string `json:"type"`
+	Subtype     string `json:"subtype,omitempty"` // 编辑、删除等消息的子类型，普通消息为空
+	Channel     string `json:"channel"`
+	ChannelType string `json:"channel_type,omitempty"` // im 为私信
+	User        string `json:"user"`
+	BotID       string `json:"bot_id,omitempty"`
+	Text        string `json:"text"`
+	TS          string `json:"ts"`
+	ThreadTS    string `json:"thread_ts,omitempty"` // 会话串中的回复为串的第一条消息
+}
+
+// Thread 事件所在会话串的第一条消息，不在会话串中时为事件本身
+func (e Event) Thread() string {
+	if e.ThreadTS != "" {
+		return e.ThreadTS
+	}
+	return e.TS
+}
+
+// envelope Socket Mode 推送的消息
+type envelope struct {
+	Type       string `json:"type"` // hello、events_api、disconnect 等
+	EnvelopeID string `json:"envelope_id"`
+	Payload    struct {
+		Event Event `json:"event"`
+	} `json:"payload"`
+}
+
+// 重连的等待时间
+const (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = time.Minute
+)
+
+// Listen 通过 Socket Mode 接收事件直到 ctx 取消，连接断开时自动重连。第一次连接失败（如令牌无效）时返回错误。
+// handler 在接收协程中同步调用，耗时的处理应交给其他协程。onConnect 在每次连接成功后调用，可以为nil
+func (c *Client) Listen(ctx context.Context, handler func(Event), onConnect func()) error {
+	delay := minReconnectDelay
+	everConnected := false
+	for {
+		connected, err := c.listenOnce(ctx, handler, onConnect)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil && !everConnected {
+			return err
+		}
+		if connected {
+			everConnected = true
+			delay = minReconnectDelay
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil
+		}
+		delay *= 2
+		if delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
+
+// listenOnce 建立一次连接并接收事件，直到连接断开。返回是否成功连接
+func (c *Client) listenOnce(ctx context.Context, handler func(Event), onConnect func()) (bool, error) {
+	url, err := c.openConnection(ctx)
+	if err != nil {
+		return false, err
+	}
+	ws, err := websocket.Dial(url, "", "https://slack.com")
+	if err != nil {
+		return false, fmt.Errorf("failed to connect to Slack: %w", err)
+	}
+	defer ws.Close()
+	stop := context.AfterFunc(ctx, func() { ws.Close() })
+	defer stop()
+
+	connected := false
+	for {
+		var data []byte
+		if err := websocket.Message.Receive(ws, &data); err != nil {
+			if err == io.EOF {
+				return connected, nil
+			}
+			return connected, fmt.Errorf("Slack connection lost: %w", err)
+		}
+		var env envelope
+		if err := json.Unmarshal(data, &env); err != nil {
+			continue
+		}
+		// 需要确认的消息先确认，否则 Slack 会重发
+		if env.EnvelopeID != "" {
+			ack, _ := json.Marshal(map[string]string{"envelope_id": env.EnvelopeID})
+			if err := websocket.Message.Send(ws, string(ack)); err != nil {
+				return connected, fmt.Errorf("Slack connection lost: %w", err)
+			}
+		}
+		switch env.Type {
+		case "hello":
+			connected = true
+			if onConnect != nil {
+				onConnect()
+			}
+		case "disconnect":
+			return connected, nil
+		case "events_api":
+			handler(env.Payload.Event)
+		}
+	}
+}
\ No newline at end of file
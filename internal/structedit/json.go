@@ -0,0 +1,482 @@
+package structedit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// jsonNode JSON值及其在源文本中的位置
+type jsonNode struct {
+	start   int
+	end     int
+	kind    byte // '{' 对象, '[' 数组, 'v' 标量
+	members []jsonMember
+	elems   []*jsonNode
+}
+
+// jsonMember 对象成员
+type jsonMember struct {
+	key      string
+	keyStart int
+	value    *jsonNode
+}
+
+// jsonParser 记录位置的JSON解析器，允许 // 与 /* */ 注释及尾随逗号（JSONC）
+type jsonParser struct {
+	src string
+	pos int
+}
+
+// parseJSON 解析JSON文本，返回根节点
+func parseJSON(src string) (*jsonNode, error) {
+	p := &jsonParser{src: src}
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return nil, nil
+	}
+	root, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected content after top-level value")
+	}
+	return root, nil
+}
+
+func (p *jsonParser) errorf(format string, args ...interface{}) error {
+	line := strings.Count(p.src[:p.pos], "\n") + 1
+	return fmt.Errorf("invalid JSON at line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// skipSpace 跳过空白与注释
+func (p *jsonParser) skipSpace() {
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			p.pos++
+		case strings.HasPrefix(p.src[p.pos:], "//"):
+			if idx := strings.IndexByte(p.src[p.pos:], '\n'); idx >= 0 {
+				p.pos += idx + 1
+			} else {
+				p.pos = len(p.src)
+			}
+		case strings.HasPrefix(p.src[p.pos:], "/*"):
+			if idx := strings.Index(p.src[p.pos+2:], "*/"); idx >= 0 {
+				p.pos += idx + 4
+			} else {
+				p.pos = len(p.src)
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *jsonParser) parseValue() (*jsonNode, error) {
+	if p.pos >= len(p.src) {
+		return nil, p.errorf("unexpected end of input")
+	}
+	switch p.src[p.pos] {
+	case '{':
+		return p.parseObject()
+	case '[':
+		return p.parseArray()
+	case '"':
+		start := p.pos
+		if _, err := p.parseString(); err != nil {
+			return nil, err
+		}
+		return &jsonNode{start: start, end: p.pos, kind: 'v'}, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.src) && !strings.ContainsRune(",:]} \t\r\n/", rune(p.src[p.pos])) {
+		p.pos++
+	}
+	if !json.Valid([]byte(p.src[start:p.pos])) {
+		p.pos = start
+		return nil, p.errorf("invalid literal %q", p.src[start:min(start+20, len(p.src))])
+	}
+	return &jsonNode{start: start, end: p.pos, kind: 'v'}, nil
+}
+
+func (p *jsonParser) parseString() (string, error) {
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.src) && p.src[p.pos] != '"' {
+		if p.src[p.pos] == '\\' {
+			p.pos++
+		}
+		p.pos++
+	}
+	if p.pos >= len(p.src) {
+		p.pos = start
+		return "", p.errorf("unterminated string")
+	}
+	p.pos++
+
+	var value string
+	if err := json.Unmarshal([]byte(p.src[start:p.pos]), &value); err != nil {
+		p.pos = start
+		return "", p.errorf("invalid string: %v", err)
+	}
+	return value, nil
+}
+
+func (p *jsonParser) parseObject() (*jsonNode, error) {
+	node := &jsonNode{start: p.pos, kind: '{'}
+	p.pos++
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			return nil, p.errorf("unterminated object")
+		}
+		if p.src[p.pos] == '}' {
+			p.pos++
+			node.end = p.pos
+			return node, nil
+		}
+		if p.src[p.pos] != '"' {
+			return nil, p.errorf("expected object key")
+		}
+		keyStart := p.pos
+		key, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.pos >= len(p.src) || p.src[p.pos] != ':' {
+			return nil, p.errorf("expected ':' after key %q", key)
+		}
+		p.pos++
+		p.skipSpace()
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		node.members = append(node.members, jsonMember{key: key, keyStart: keyStart, value: value})
+
+		p.skipSpace()
+		if p.pos < len(p.src) && p.src[p.pos] == ',' {
+			p.pos++
+		} else if p.pos < len(p.src) && p.src[p.pos] != '}' {
+			return nil, p.errorf("expected ',' or '}'")
+		}
+	}
+}
+
+func (p *jsonParser) parseArray() (*jsonNode, error) {
+	node := &jsonNode{start: p.pos, kind: '['}
+	p.pos++
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			return nil, p.errorf("unterminated array")
+		}
+		if p.src[p.pos] == ']' {
+			p.pos++
+			node.end = p.pos
+			return node, nil
+		}
+		elem, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		node.elems = append(node.elems, elem)
+
+		p.skipSpace()
+		if p.pos < len(p.src) && p.src[p.pos] == ',' {
+			p.pos++
+		} else if p.pos < len(p.src) && p.src[p.pos] != ']' {
+			return nil, p.errorf("expected ',' or ']'")
+		}
+	}
+}
+
+// jsonStyle 原文件的缩进风格
+type jsonStyle struct {
+	unit    string // 单级缩进
+	compact bool   // 单行（压缩）JSON
+}
+
+// detectJSONStyle 检测缩进单位与是否为压缩格式
+func detectJSONStyle(src string) jsonStyle {
+	style := jsonStyle{unit: "  "}
+	if !strings.Contains(strings.TrimSpace(src), "\n") {
+		style.compact = true
+		return style
+	}
+	for _, line := range strings.Split(src, "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed != "" && len(trimmed) < len(line) {
+			style.unit = line[:len(line)-len(trimmed)]
+			break
+		}
+	}
+	return style
+}
+
+// lineIndent 返回pos所在行的行首缩进
+func lineIndent(src string, pos int) string {
+	lineStart := strings.LastIndexByte(src[:pos], '\n') + 1
+	end := lineStart
+	for end < pos && (src[end] == ' ' || src[end] == '\t') {
+		end++
+	}
+	return src[lineStart:end]
+}
+
+// encodeJSON 按原文件风格编码值，indent为值所在行的缩进
+func encodeJSON(value interface{}, indent string, style jsonStyle) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if !style.compact {
+		encoder.SetIndent(indent, style.unit)
+	}
+	if err := encoder.Encode(value); err != nil {
+		return "", fmt.Errorf("failed to encode value: %w", err)
+	}
+	return strings.TrimRight(buf.String(), "\n"), nil
+}
+
+// jsonEditor 对JSON源文本做原位修改
+type jsonEditor struct {
+	src   string
+	style jsonStyle
+}
+
+// splice 替换源文本中的 [start, end) 区间
+func (e *jsonEditor) splice(start, end int, text string) {
+	e.src = e.src[:start] + text + e.src[end:]
+}
+
+// insertEntry 在对象或数组末尾插入一个条目；entry根据条目所在行缩进与风格生成文本
+func (e *jsonEditor) insertEntry(container *jsonNode, entry func(indent string, style jsonStyle) (string, error)) error {
+	count := len(container.elems)
+	lastEnd, lastStart := 0, 0
+	if container.kind == '{' {
+		count = len(container.members)
+		if count > 0 {
+			lastStart = container.members[count-1].keyStart
+			lastEnd = container.members[count-1].value.end
+		}
+	} else if count > 0 {
+		lastStart = container.elems[count-1].start
+		lastEnd = container.elems[count-1].end
+	}
+
+	singleLine := !strings.Contains(e.src[container.start:container.end], "\n")
+	switch {
+	case count > 0 && (e.style.compact || singleLine):
+		separator := ", "
+		if e.style.compact {
+			separator = ","
+		}
+		// 单行容器内保持单行
+		text, err := entry("", jsonStyle{unit: e.style.unit, compact: true})
+		if err != nil {
+			return err
+		}
+		e.splice(lastEnd, lastEnd, separator+text)
+	case count > 0:
+		indent := lineIndent(e.src, lastStart)
+		text, err := entry(indent, e.style)
+		if err != nil {
+			return err
+		}
+		e.splice(lastEnd, lastEnd, ",\n"+indent+text)
+	case e.style.compact:
+		text, err := entry("", e.style)
+		if err != nil {
+			return err
+		}
+		e.splice(container.start+1, container.start+1, text)
+	default:
+		outer := lineIndent(e.src, container.start)
+		inner := outer + e.style.unit
+		text, err := entry(inner, e.style)
+		if err != nil {
+			return err
+		}
+		// 空容器内若有注释则保留，仅替换纯空白
+		body := e.src[container.start+1 : container.end-1]
+		if strings.TrimSpace(body) == "" {
+			e.splice(container.start+1, container.end-1, "\n"+inner+text+"\n"+outer)
+		} else {
+			e.splice(container.end-1, container.end-1, "\n"+inner+text+"\n"+outer)
+		}
+	}
+	return nil
+}
+
+// removeEntry 删除对象或数组中的第i个条目，连同相邻的逗号
+func (e *jsonEditor) removeEntry(container *jsonNode, i int) {
+	var starts, ends []int
+	if container.kind == '{' {
+		for _, member := range container.members {
+			starts = append(starts, member.keyStart)
+			ends = append(ends, member.value.end)
+		}
+	} else {
+		for _, elem := range container.elems {
+			starts = append(starts, elem.start)
+			ends = append(ends, elem.end)
+		}
+	}
+
+	switch {
+	case len(starts) == 1:
+		e.splice(container.start+1, container.end-1, "")
+	case i < len(starts)-1:
+		e.splice(starts[i], starts[i+1], "")
+	default:
+		e.splice(ends[i-1], ends[i], "")
+	}
+}
+
+// applyJSON 对JSON内容执行编辑
+func applyJSON(content []byte, req Request) (*Result, error) {
+	src := string(content)
+	root, err := parseJSON(src)
+	if err != nil {
+		return nil, err
+	}
+
+	editor := &jsonEditor{src: src, style: detectJSONStyle(src)}
+	result := &Result{}
+
+	// 空文件：set/append 时直接生成新文档
+	if root == nil {
+		if req.Operation == OpGet || req.Operation == OpDelete {
+			return nil, fmt.Errorf("path %s not found: document is empty", FormatPath(req.Path))
+		}
+		value := req.Value
+		if req.Operation == OpAppend {
+			value = []interface{}{value}
+		}
+		nested, err := nestedValue(req.Path, value)
+		if err != nil {
+			return nil, err
+		}
+		text, err := encodeJSON(nested, "", jsonStyle{unit: "  "})
+		if err != nil {
+			return nil, err
+		}
+		result.Content = []byte(text + "\n")
+		result.Created = true
+		result.Value, _ = encodeJSON(value, "", jsonStyle{compact: true})
+		return result, nil
+	}
+
+	// 沿路径查找，parent为当前节点的容器
+	node := root
+	var parent *jsonNode
+	parentIndex := -1
+	for depth, segment := range req.Path {
+		var next *jsonNode
+		switch {
+		case segment.IsIndex && node.kind == '[':
+			index, ok := resolveIndex(segment.Index, len(node.elems))
+			if !ok {
+				return nil, fmt.Errorf("index %s out of range (array length %d)", FormatPath(req.Path[:depth+1]), len(node.elems))
+			}
+			next, parentIndex = node.elems[index], index
+		case !segment.IsIndex && node.kind == '{':
+			for i, member := range node.members {
+				if member.key == segment.Key {
+					next, parentIndex = member.value, i
+				}
+			}
+		default:
+			return nil, fmt.Errorf("cannot index %s with %s: value is not %s", FormatPath(req.Path[:depth]), segment.String(), expectedContainer(segment))
+		}
+
+		if next == nil {
+			// 缺失的键：set/append 时连同剩余路径一起创建
+			if req.Operation == OpGet || req.Operation == OpDelete {
+				return nil, fmt.Errorf("path %s not found", FormatPath(req.Path[:depth+1]))
+			}
+			value := req.Value
+			if req.Operation == OpAppend {
+				value = []interface{}{value}
+			}
+			nested, err := nestedValue(req.Path[depth+1:], value)
+			if err != nil {
+				return nil, err
+			}
+			key := segment.Key
+			err = editor.insertEntry(node, func(indent string, style jsonStyle) (string, error) {
+				text, err := encodeJSON(nested, indent, style)
+				if err != nil {
+					return "", err
+				}
+				keyText, _ := encodeJSON(key, "", style)
+				if editor.style.compact {
+					return keyText + ":" + text, nil
+				}
+				return keyText + ": " + text, nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			result.Value, _ = encodeJSON(value, "", jsonStyle{unit: editor.style.unit, compact: true})
+			result.Created = true
+			return finishJSON(editor, result)
+		}
+		parent, node = node, next
+	}
+
+	result.Previous = src[node.start:node.end]
+
+	switch req.Operation {
+	case OpGet:
+		result.Content = content
+		result.Value = result.Previous
+		return result, nil
+	case OpSet:
+		text, err := encodeJSON(req.Value, lineIndent(src, node.start), editor.style)
+		if err != nil {
+			return nil, err
+		}
+		editor.splice(node.start, node.end, text)
+		result.Value = text
+	case OpDelete:
+		editor.removeEntry(parent, parentIndex)
+	case OpAppend:
+		if node.kind != '[' {
+			return nil, fmt.Errorf("cannot append to %s: value is not an array", FormatPath(req.Path))
+		}
+		err := editor.insertEntry(node, func(indent string, style jsonStyle) (string, error) {
+			text, err := encodeJSON(req.Value, indent, style)
+			result.Value = text
+			return text, err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return finishJSON(editor, result)
+}
+
+// finishJSON 校验编辑后的内容仍是合法JSON
+func finishJSON(editor *jsonEditor, result *Result) (*Result, error) {
+	if _, err := parseJSON(editor.src); err != nil {
+		return nil, fmt.Errorf("edit produced invalid JSON, file left unchanged: %w", err)
+	}
+	result.Content = []byte(editor.src)
+	return result, nil
+}
+
+// expectedContainer 片段期望的容器类型描述
+func expectedContainer(segment Segment) string {
+	if segment.IsIndex {
+		return "an array"
+	}
+	return "an object"
+}
\ No newline at end of file
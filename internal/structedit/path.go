@@ -0,0 +1,146 @@
+package structedit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Segment 路径表达式中的一段：对象键或数组下标
+type Segment struct {
+	Key     string
+	Index   int
+	IsIndex bool
+}
+
+// String 返回片段的路径表示
+func (s Segment) String() string {
+	if s.IsIndex {
+		return fmt.Sprintf("[%d]", s.Index)
+	}
+	if isPlainKey(s.Key) {
+		return "." + s.Key
+	}
+	return "[" + strconv.Quote(s.Key) + "]"
+}
+
+// FormatPath 将片段列表格式化为路径表达式
+func FormatPath(path []Segment) string {
+	if len(path) == 0 {
+		return "."
+	}
+	var b strings.Builder
+	for _, segment := range path {
+		b.WriteString(segment.String())
+	}
+	return b.String()
+}
+
+// isPlainKey 键是否可以用 .key 形式书写
+func isPlainKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, r := range key {
+		if !(r == '_' || r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// ParsePath 解析路径表达式，支持 .spec.replicas、.items[0].name、.labels["app.kubernetes.io/name"]
+func ParsePath(expr string) ([]Segment, error) {
+	expr = strings.TrimSpace(expr)
+	expr = strings.TrimPrefix(expr, "$")
+	if expr == "" || expr == "." {
+		return nil, nil
+	}
+
+	var path []Segment
+	i := 0
+	for i < len(expr) {
+		switch expr[i] {
+		case '.':
+			i++
+			start := i
+			for i < len(expr) && expr[i] != '.' && expr[i] != '[' {
+				i++
+			}
+			if start == i {
+				// 允许 .[0] 这种写法
+				if i < len(expr) && expr[i] == '[' {
+					continue
+				}
+				return nil, fmt.Errorf("invalid path %q: empty key at offset %d", expr, start)
+			}
+			path = append(path, Segment{Key: expr[start:i]})
+		case '[':
+			end := i + 1
+			if end < len(expr) && (expr[end] == '"' || expr[end] == '\'') {
+				quote := expr[end]
+				end++
+				for end < len(expr) && expr[end] != quote {
+					if expr[end] == '\\' {
+						end++
+					}
+					end++
+				}
+				if end+1 >= len(expr) || expr[end+1] != ']' {
+					return nil, fmt.Errorf("invalid path %q: unterminated quoted key", expr)
+				}
+				raw := expr[i+1 : end+1]
+				key := raw[1 : len(raw)-1]
+				if quote == '"' {
+					unquoted, err := strconv.Unquote(raw)
+					if err != nil {
+						return nil, fmt.Errorf("invalid path %q: %w", expr, err)
+					}
+					key = unquoted
+				}
+				path = append(path, Segment{Key: key})
+				i = end + 2
+				continue
+			}
+			for end < len(expr) && expr[end] != ']' {
+				end++
+			}
+			if end >= len(expr) {
+				return nil, fmt.Errorf("invalid path %q: missing ']'", expr)
+			}
+			index, err := strconv.Atoi(strings.TrimSpace(expr[i+1 : end]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid path %q: array index must be an integer, got %q", expr, expr[i+1:end])
+			}
+			path = append(path, Segment{Index: index, IsIndex: true})
+			i = end + 1
+		default:
+			// 允许省略开头的点，如 spec.replicas
+			if len(path) == 0 && i == 0 {
+				expr = "." + expr
+				continue
+			}
+			return nil, fmt.Errorf("invalid path %q: unexpected character %q at offset %d", expr, expr[i], i)
+		}
+	}
+	return path, nil
+}
+
+// resolveIndex 将可能为负数的下标转换为实际位置
+func resolveIndex(index, length int) (int, bool) {
+	if index < 0 {
+		index += length
+	}
+	return index, index >= 0 && index < length
+}
+
+// nestedValue 为缺失的路径片段构建嵌套对象，仅支持键片段
+func nestedValue(rest []Segment, value interface{}) (interface{}, error) {
+	for i := len(rest) - 1; i >= 0; i-- {
+		if rest[i].IsIndex {
+			return nil, fmt.Errorf("cannot create missing array element %s", FormatPath(rest[:i+1]))
+		}
+		value = map[string]interface{}{rest[i].Key: value}
+	}
+	return value, nil
+}
\ No newline at end of file
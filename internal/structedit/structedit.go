@@ -0,0 +1,72 @@
+package structedit
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Format 结构化文件格式
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+)
+
+// Operation 编辑操作
+type Operation string
+
+const (
+	OpGet    Operation = "get"    // 读取路径上的值
+	OpSet    Operation = "set"    // 设置值，缺失的中间对象会自动创建
+	OpDelete Operation = "delete" // 删除键或数组元素
+	OpAppend Operation = "append" // 向数组末尾追加元素
+)
+
+// Request 一次结构化编辑请求
+type Request struct {
+	Operation Operation
+	Path      []Segment
+	Value     interface{}
+	Document  int // YAML多文档时的文档下标
+}
+
+// Result 编辑结果
+type Result struct {
+	Content  []byte // 编辑后的完整内容（get操作为原内容）
+	Previous string // 编辑前路径上的值（不存在时为空）
+	Value    string // 编辑后（或get读取到的）路径上的值
+	Created  bool   // set操作是否新建了键
+}
+
+// DetectFormat 根据文件扩展名判断格式
+func DetectFormat(filePath string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".json", ".jsonc":
+		return FormatJSON, nil
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	}
+	return "", fmt.Errorf("cannot detect structured format of %s (expected .json, .jsonc, .yaml or .yml)", filePath)
+}
+
+// Apply 对文件内容执行一次结构化编辑
+func Apply(content []byte, format Format, req Request) (*Result, error) {
+	switch req.Operation {
+	case OpGet, OpSet, OpDelete, OpAppend:
+	default:
+		return nil, fmt.Errorf("unknown operation '%s' (expected get, set, delete or append)", req.Operation)
+	}
+	if req.Operation == OpDelete && len(req.Path) == 0 {
+		return nil, fmt.Errorf("cannot delete the document root")
+	}
+
+	switch format {
+	case FormatJSON:
+		return applyJSON(content, req)
+	case FormatYAML:
+		return applyYAML(content, req)
+	}
+	return nil, fmt.Errorf("unsupported format '%s'", format)
+}
\ No newline at end of file
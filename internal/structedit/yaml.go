@@ -0,0 +1,298 @@
+package structedit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// parseYAMLDocuments 解析（可能包含多个文档的）YAML内容
+func parseYAMLDocuments(content []byte) ([]*yaml.Node, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	var docs []*yaml.Node
+	for {
+		doc := &yaml.Node{}
+		if err := decoder.Decode(doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("invalid YAML: %w", err)
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// detectYAMLIndent 检测YAML缩进宽度（最小的非零行首空格数）
+func detectYAMLIndent(content []byte) int {
+	indent := 0
+	for _, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if spaces := len(line) - len(trimmed); spaces > 0 && (indent == 0 || spaces < indent) {
+			indent = spaces
+		}
+	}
+	if indent < 2 || indent > 8 {
+		return 2
+	}
+	return indent
+}
+
+// valueNode 将值编码为YAML节点
+func valueNode(value interface{}) (*yaml.Node, error) {
+	node := &yaml.Node{}
+	if err := node.Encode(value); err != nil {
+		return nil, fmt.Errorf("failed to encode value: %w", err)
+	}
+	return node, nil
+}
+
+// renderYAMLNode 将节点渲染为文本（用于结果展示）
+func renderYAMLNode(node *yaml.Node) string {
+	if node.Kind == yaml.ScalarNode {
+		return node.Value
+	}
+	data, err := yaml.Marshal(node)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimRight(string(data), "\n")
+}
+
+// replaceYAMLNode 用新值替换节点内容，保留原节点的注释与字符串引号风格
+func replaceYAMLNode(target, replacement *yaml.Node) {
+	head, line, foot := target.HeadComment, target.LineComment, target.FootComment
+	style := target.Style
+	wasString := target.Kind == yaml.ScalarNode && target.Tag == "!!str"
+
+	*target = *replacement
+	target.HeadComment, target.LineComment, target.FootComment = head, line, foot
+	if wasString && target.Kind == yaml.ScalarNode && target.Tag == "!!str" {
+		target.Style = style
+	}
+}
+
+// spliceYAMLScalar 在原文中直接替换单行标量的文本，失败时返回false以回退到整体重新编码
+func spliceYAMLScalar(content []byte, original, replacement *yaml.Node) ([]byte, bool) {
+	if original.Kind != yaml.ScalarNode || replacement.Kind != yaml.ScalarNode || original.Line < 1 {
+		return nil, false
+	}
+
+	lines := strings.SplitAfter(string(content), "\n")
+	if original.Line > len(lines) {
+		return nil, false
+	}
+	line := lines[original.Line-1]
+	start := 0
+	for i := 1; i < original.Column && start < len(line); i++ {
+		_, size := utf8.DecodeRuneInString(line[start:])
+		start += size
+	}
+
+	// 确定原标量在该行中的结束位置
+	end := -1
+	switch original.Style {
+	case 0, yaml.TaggedStyle:
+		if strings.HasPrefix(line[start:], original.Value) {
+			end = start + len(original.Value)
+		}
+	case yaml.DoubleQuotedStyle:
+		for i := start + 1; i < len(line); i++ {
+			if line[i] == '\\' {
+				i++
+			} else if line[i] == '"' {
+				end = i + 1
+				break
+			}
+		}
+	case yaml.SingleQuotedStyle:
+		for i := start + 1; i < len(line); i++ {
+			if line[i] == '\'' {
+				if i+1 < len(line) && line[i+1] == '\'' {
+					i++
+					continue
+				}
+				end = i + 1
+				break
+			}
+		}
+	}
+	if end < 0 {
+		return nil, false
+	}
+
+	// 只渲染标量本身，不含注释
+	bare := *replacement
+	bare.HeadComment, bare.LineComment, bare.FootComment = "", "", ""
+	data, err := yaml.Marshal(&bare)
+	if err != nil {
+		return nil, false
+	}
+	text := strings.TrimSuffix(string(data), "\n")
+	if strings.Contains(text, "\n") {
+		return nil, false
+	}
+
+	lines[original.Line-1] = line[:start] + text + line[end:]
+	spliced := []byte(strings.Join(lines, ""))
+	if _, err := parseYAMLDocuments(spliced); err != nil {
+		return nil, false
+	}
+	return spliced, true
+}
+
+// applyYAML 对YAML内容执行编辑
+func applyYAML(content []byte, req Request) (*Result, error) {
+	docs, err := parseYAMLDocuments(content)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	if len(docs) == 0 {
+		if req.Operation == OpGet || req.Operation == OpDelete {
+			return nil, fmt.Errorf("path %s not found: document is empty", FormatPath(req.Path))
+		}
+		docs = []*yaml.Node{{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}}
+	}
+	if req.Document < 0 || req.Document >= len(docs) {
+		return nil, fmt.Errorf("document index %d out of range (file has %d documents)", req.Document, len(docs))
+	}
+
+	node := docs[req.Document].Content[0]
+	var parent *yaml.Node
+	parentIndex := -1
+	for depth, segment := range req.Path {
+		if node.Kind == yaml.AliasNode {
+			node = node.Alias
+		}
+
+		var next *yaml.Node
+		switch {
+		case segment.IsIndex && node.Kind == yaml.SequenceNode:
+			index, ok := resolveIndex(segment.Index, len(node.Content))
+			if !ok {
+				return nil, fmt.Errorf("index %s out of range (array length %d)", FormatPath(req.Path[:depth+1]), len(node.Content))
+			}
+			next, parentIndex = node.Content[index], index
+		case !segment.IsIndex && node.Kind == yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == segment.Key {
+					next, parentIndex = node.Content[i+1], i
+				}
+			}
+		default:
+			return nil, fmt.Errorf("cannot index %s with %s: value is not %s", FormatPath(req.Path[:depth]), segment.String(), expectedContainer(segment))
+		}
+
+		if next == nil {
+			// 缺失的键：set/append 时连同剩余路径一起创建
+			if req.Operation == OpGet || req.Operation == OpDelete {
+				return nil, fmt.Errorf("path %s not found", FormatPath(req.Path[:depth+1]))
+			}
+			value := req.Value
+			if req.Operation == OpAppend {
+				value = []interface{}{value}
+			}
+			nested, err := nestedValue(req.Path[depth+1:], value)
+			if err != nil {
+				return nil, err
+			}
+			valNode, err := valueNode(nested)
+			if err != nil {
+				return nil, err
+			}
+			keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: segment.Key}
+			// 空的流式映射 {} 改为块格式，避免生成单行大对象
+			if len(node.Content) == 0 {
+				node.Style &^= yaml.FlowStyle
+			}
+			node.Content = append(node.Content, keyNode, valNode)
+			if requested, err := valueNode(value); err == nil {
+				result.Value = renderYAMLNode(requested)
+			}
+			result.Created = true
+			return finishYAML(docs, content, result)
+		}
+		parent, node = node, next
+	}
+
+	result.Previous = renderYAMLNode(node)
+
+	switch req.Operation {
+	case OpGet:
+		result.Content = content
+		result.Value = result.Previous
+		return result, nil
+	case OpSet:
+		valNode, err := valueNode(req.Value)
+		if err != nil {
+			return nil, err
+		}
+		original := *node
+		replaceYAMLNode(node, valNode)
+		result.Value = renderYAMLNode(node)
+
+		// 标量替换标量时直接改写原文，完整保留空行与格式
+		if spliced, ok := spliceYAMLScalar(content, &original, node); ok {
+			result.Content = spliced
+			return result, nil
+		}
+	case OpDelete:
+		if parent.Kind == yaml.MappingNode {
+			// 被删除键的头部注释通常属于该键，随之删除
+			parent.Content = append(parent.Content[:parentIndex], parent.Content[parentIndex+2:]...)
+		} else {
+			parent.Content = append(parent.Content[:parentIndex], parent.Content[parentIndex+1:]...)
+		}
+	case OpAppend:
+		if node.Kind == yaml.AliasNode {
+			node = node.Alias
+		}
+		if node.Kind != yaml.SequenceNode {
+			return nil, fmt.Errorf("cannot append to %s: value is not an array", FormatPath(req.Path))
+		}
+		valNode, err := valueNode(req.Value)
+		if err != nil {
+			return nil, err
+		}
+		if len(node.Content) == 0 {
+			node.Style &^= yaml.FlowStyle
+		}
+		node.Content = append(node.Content, valNode)
+		result.Value = renderYAMLNode(valNode)
+	}
+
+	return finishYAML(docs, content, result)
+}
+
+// finishYAML 以原文件的缩进重新编码所有文档
+func finishYAML(docs []*yaml.Node, original []byte, result *Result) (*Result, error) {
+	var buf bytes.Buffer
+	trimmed := bytes.TrimLeft(original, " \t\r\n")
+	if bytes.HasPrefix(trimmed, []byte("---")) {
+		buf.WriteString("---\n")
+	}
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(detectYAMLIndent(original))
+	for _, doc := range docs {
+		if err := encoder.Encode(doc); err != nil {
+			return nil, fmt.Errorf("failed to encode YAML: %w", err)
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML: %w", err)
+	}
+
+	result.Content = buf.Bytes()
+	return result, nil
+}
\ No newline at end of file
@@ -0,0 +1,87 @@
+package symbols
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ctagsExtensions 交给 ctags 解析的扩展名（Go 文件总是使用语法树）
+var ctagsExtensions = map[string]bool{
+	".py": true, ".js": true, ".jsx": true, ".ts": true, ".tsx": true, ".mjs": true, ".cjs": true,
+	".java": true, ".kt": true, ".cs": true, ".scala": true, ".rs": true, ".rb": true, ".php": true,
+	".c": true, ".h": true, ".cc": true, ".cpp": true, ".hpp": true, ".cxx": true, ".swift": true,
+	".m": true, ".lua": true, ".pl": true, ".sh": true, ".ex": true, ".exs": true, ".erl": true,
+	".hs": true, ".ml": true, ".clj": true, ".dart": true, ".r": true, ".jl": true, ".zig": true,
+	".sql": true, ".proto": true, ".vue": true, ".svelte": true,
+}
+
+// findCtags 查找支持JSON输出的 universal-ctags（exuberant ctags 不支持）
+func findCtags() string {
+	for _, name := range []string{"ctags", "universal-ctags", "uctags"} {
+		path, err := exec.LookPath(name)
+		if err != nil {
+			continue
+		}
+		output, err := exec.Command(path, "--version").Output()
+		if err == nil && strings.Contains(string(output), "Universal Ctags") {
+			return path
+		}
+	}
+	return ""
+}
+
+// ctagsTag ctags 的JSON输出
+type ctagsTag struct {
+	Type      string `json:"_type"`
+	Name      string `json:"name"`
+	Path      string `json:"path"`
+	Pattern   string `json:"pattern"`
+	Line      int    `json:"line"`
+	Kind      string `json:"kind"`
+	Signature string `json:"signature"`
+	Scope     string `json:"scope"`
+}
+
+// runCtags 用 ctags 解析文件（相对路径，从标准输入传入文件列表），按文件返回符号
+func runCtags(ctags, root string, files []string) (map[string][]Symbol, error) {
+	cmd := exec.Command(ctags, "--output-format=json", "--fields=+nKSs", "--extras=-F", "-L", "-", "-f", "-")
+	cmd.Dir = root
+	cmd.Stdin = strings.NewReader(strings.Join(files, "\n") + "\n")
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][]Symbol, len(files))
+	scanner := bufio.NewScanner(&stdout)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var tag ctagsTag
+		if err := json.Unmarshal(scanner.Bytes(), &tag); err != nil || tag.Type != "tag" {
+			continue
+		}
+		rel := filepath.ToSlash(tag.Path)
+		signature := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(strings.TrimSuffix(strings.TrimPrefix(tag.Pattern, "/"), "/"), "^"), "$"))
+		if signature == "" {
+			signature = tag.Name + tag.Signature
+		}
+		scope := tag.Scope
+		if i := strings.LastIndexAny(scope, ".:"); i >= 0 {
+			scope = scope[i+1:]
+		}
+		result[rel] = append(result[rel], Symbol{
+			Name:      tag.Name,
+			Kind:      tag.Kind,
+			Path:      rel,
+			Line:      tag.Line,
+			Container: scope,
+			Signature: signature,
+		})
+	}
+	return result, scanner.Err()
+}
\ No newline at end of file
@@ -0,0 +1,106 @@
+package symbols
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// parseGo 用语法树提取Go文件的顶层声明与方法，解析失败时按行模式匹配
+func parseGo(root, rel string) []Symbol {
+	content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(rel)))
+	if err != nil {
+		return nil
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, rel, content, parser.SkipObjectResolution)
+	if err != nil {
+		return parsePatterns(root, rel)
+	}
+	lines := strings.Split(string(content), "\n")
+	signature := func(pos token.Pos) string {
+		line := fset.Position(pos).Line
+		if line >= 1 && line <= len(lines) {
+			return strings.TrimSpace(lines[line-1])
+		}
+		return ""
+	}
+	add := func(symbols []Symbol, ident *ast.Ident, kind, container string) []Symbol {
+		if ident == nil || ident.Name == "_" {
+			return symbols
+		}
+		pos := fset.Position(ident.Pos())
+		return append(symbols, Symbol{
+			Name:      ident.Name,
+			Kind:      kind,
+			Path:      rel,
+			Line:      pos.Line,
+			Column:    pos.Column,
+			Container: container,
+			Signature: signature(ident.Pos()),
+		})
+	}
+
+	var symbols []Symbol
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				symbols = add(symbols, d.Name, "method", receiverType(d.Recv.List[0].Type))
+			} else {
+				symbols = add(symbols, d.Name, "function", "")
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					kind := "type"
+					switch t := s.Type.(type) {
+					case *ast.StructType:
+						kind = "struct"
+						for _, field := range t.Fields.List {
+							for _, name := range field.Names {
+								symbols = add(symbols, name, "field", s.Name.Name)
+							}
+						}
+					case *ast.InterfaceType:
+						kind = "interface"
+						for _, method := range t.Methods.List {
+							for _, name := range method.Names {
+								symbols = add(symbols, name, "method", s.Name.Name)
+							}
+						}
+					}
+					symbols = add(symbols, s.Name, kind, "")
+				case *ast.ValueSpec:
+					kind := "variable"
+					if d.Tok == token.CONST {
+						kind = "constant"
+					}
+					for _, name := range s.Names {
+						symbols = add(symbols, name, kind, "")
+					}
+				}
+			}
+		}
+	}
+	return symbols
+}
+
+// receiverType 方法接收者的类型名，去掉指针与类型参数
+func receiverType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverType(t.X)
+	case *ast.IndexExpr:
+		return receiverType(t.X)
+	case *ast.IndexListExpr:
+		return receiverType(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
\ No newline at end of file
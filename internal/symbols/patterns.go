@@ -0,0 +1,160 @@
+package symbols
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// pattern 声明的匹配规则，第一个分组为符号名。nested 的规则只匹配类等内部的声明
+type pattern struct {
+	re     *regexp.Regexp
+	kind   string
+	nested bool
+}
+
+// containerKinds 可以包含方法的符号类型，其后缩进更深的声明归属于它
+var containerKinds = map[string]bool{
+	"class": true, "interface": true, "struct": true, "trait": true, "impl": true,
+	"module": true, "enum": true, "object": true, "namespace": true,
+}
+
+var jsPatterns = []pattern{
+	{regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:async\s+)?function\*?\s+([A-Za-z_$][\w$]*)`), "function", false},
+	{regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+([A-Za-z_$][\w$]*)`), "class", false},
+	{regexp.MustCompile(`^(?:export\s+)?(?:declare\s+)?interface\s+([A-Za-z_$][\w$]*)`), "interface", false},
+	{regexp.MustCompile(`^(?:export\s+)?(?:declare\s+)?type\s+([A-Za-z_$][\w$]*)\s*(?:<[^=]*>)?\s*=`), "type", false},
+	{regexp.MustCompile(`^(?:export\s+)?(?:declare\s+)?(?:const\s+)?enum\s+([A-Za-z_$][\w$]*)`), "enum", false},
+	{regexp.MustCompile(`^(?:export\s+)?(?:const|let|var)\s+([A-Za-z_$][\w$]*)\s*(?::[^=]+)?=\s*(?:async\s+)?(?:function|\([^)]*\)\s*(?::[^=]+)?=>|[A-Za-z_$][\w$]*\s*=>)`), "function", false},
+	{regexp.MustCompile(`^(?:export\s+)?const\s+([A-Z][A-Z0-9_]*)\s*=`), "constant", false},
+	{regexp.MustCompile(`^(?:(?:public|private|protected|static|async|readonly|override|get|set)\s+)*\*?([A-Za-z_$][\w$]*)\s*\([^;]*\)\s*(?::[^{;]+)?\{\s*$`), "method", true},
+}
+
+var cPatterns = []pattern{
+	{regexp.MustCompile(`^(?:typedef\s+)?(?:struct|union)\s+([A-Za-z_]\w*)\s*\{?\s*$`), "struct", false},
+	{regexp.MustCompile(`^(?:enum)\s+(?:class\s+)?([A-Za-z_]\w*)`), "enum", false},
+	{regexp.MustCompile(`^class\s+([A-Za-z_]\w*)[^;]*$`), "class", false},
+	{regexp.MustCompile(`^namespace\s+([A-Za-z_]\w*)`), "namespace", false},
+	{regexp.MustCompile(`^#define\s+([A-Za-z_]\w*)`), "macro", false},
+	{regexp.MustCompile(`^[A-Za-z_][\w\s\*&:<>,]*?[\s\*&](?:[A-Za-z_]\w*::)?~?([A-Za-z_]\w*)\s*\([^;]*$`), "function", false},
+}
+
+var jvmPatterns = []pattern{
+	{regexp.MustCompile(`^(?:@\w+\s+)*(?:(?:public|protected|private|internal|static|final|abstract|sealed|open|data|partial)\s+)*class\s+([A-Za-z_]\w*)`), "class", false},
+	{regexp.MustCompile(`^(?:@\w+\s+)*(?:(?:public|protected|private|internal|static|sealed|partial)\s+)*interface\s+([A-Za-z_]\w*)`), "interface", false},
+	{regexp.MustCompile(`^(?:(?:public|protected|private|internal|static)\s+)*(?:enum|record|struct|object)\s+(?:class\s+)?([A-Za-z_]\w*)`), "struct", false},
+	{regexp.MustCompile(`^(?:(?:public|protected|private|internal|override|open|suspend|inline)\s+)*fun\s+(?:<[^>]*>\s*)?(?:[\w.]+\.)?([A-Za-z_]\w*)`), "function", false},
+	{regexp.MustCompile(`^(?:(?:public|protected|private|internal|static|final|abstract|override|virtual|async|synchronized)\s+)+[\w<>\[\],.?]+(?:\s+[\w<>\[\],.?]+)*\s+([A-Za-z_]\w*)\s*\(`), "method", true},
+}
+
+// patterns 无法使用 ctags 时按扩展名的声明规则（不含Go，Go 使用语法树）
+var patterns = map[string][]pattern{
+	".py": {
+		{regexp.MustCompile(`^(?:async\s+)?def\s+([A-Za-z_]\w*)`), "function", false},
+		{regexp.MustCompile(`^class\s+([A-Za-z_]\w*)`), "class", false},
+		{regexp.MustCompile(`^([A-Z][A-Z0-9_]*)\s*(?::[^=]+)?=`), "constant", false},
+	},
+	".js": jsPatterns, ".jsx": jsPatterns, ".ts": jsPatterns, ".tsx": jsPatterns, ".mjs": jsPatterns, ".cjs": jsPatterns,
+	".java": jvmPatterns, ".kt": jvmPatterns, ".cs": jvmPatterns, ".scala": jvmPatterns,
+	".rs": {
+		{regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?(?:(?:async|unsafe|const|extern(?:\s+"\w+")?)\s+)*fn\s+([A-Za-z_]\w*)`), "function", false},
+		{regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?(?:struct|union)\s+([A-Za-z_]\w*)`), "struct", false},
+		{regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?enum\s+([A-Za-z_]\w*)`), "enum", false},
+		{regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?(?:unsafe\s+)?trait\s+([A-Za-z_]\w*)`), "trait", false},
+		{regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?type\s+([A-Za-z_]\w*)`), "type", false},
+		{regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?(?:const|static)\s+(?:mut\s+)?([A-Za-z_]\w*)`), "constant", false},
+		{regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?mod\s+([A-Za-z_]\w*)`), "module", false},
+		{regexp.MustCompile(`^impl(?:<[^>]*>)?\s+(?:[\w:<>, ]+\s+for\s+)?([A-Za-z_]\w*)`), "impl", false},
+		{regexp.MustCompile(`^macro_rules!\s*([A-Za-z_]\w*)`), "macro", false},
+	},
+	".rb": {
+		{regexp.MustCompile(`^def\s+(?:self\.)?([A-Za-z_]\w*[?!=]?)`), "method", false},
+		{regexp.MustCompile(`^class\s+([A-Z]\w*)`), "class", false},
+		{regexp.MustCompile(`^module\s+([A-Z]\w*)`), "module", false},
+	},
+	".php": {
+		{regexp.MustCompile(`^(?:(?:abstract|final|public|protected|private|static)\s+)*function\s+&?([A-Za-z_]\w*)`), "function", false},
+		{regexp.MustCompile(`^(?:(?:abstract|final)\s+)*class\s+([A-Za-z_]\w*)`), "class", false},
+		{regexp.MustCompile(`^interface\s+([A-Za-z_]\w*)`), "interface", false},
+		{regexp.MustCompile(`^trait\s+([A-Za-z_]\w*)`), "trait", false},
+	},
+	".c": cPatterns, ".h": cPatterns, ".cc": cPatterns, ".cpp": cPatterns, ".hpp": cPatterns, ".cxx": cPatterns,
+	".go": {
+		{regexp.MustCompile(`^func\s+(?:\([^)]*\)\s*)?([A-Za-z_]\w*)`), "function", false},
+		{regexp.MustCompile(`^type\s+([A-Za-z_]\w*)`), "type", false},
+	},
+}
+
+// controlKeywords 形如方法调用、但不是声明的行首关键字
+var controlKeywords = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "catch": true, "return": true,
+	"else": true, "do": true, "try": true, "with": true, "new": true, "throw": true, "await": true,
+	"function": true, "sizeof": true,
+}
+
+// parsePatterns 按声明的行模式提取符号，缩进更深的声明归属于之前的类、结构体等
+func parsePatterns(root, rel string) []Symbol {
+	rules := patterns[strings.ToLower(filepath.Ext(rel))]
+	if rules == nil {
+		return nil
+	}
+	file, err := os.Open(filepath.Join(root, filepath.FromSlash(rel)))
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	type container struct {
+		indent int
+		name   string
+	}
+	var stack []container
+	var symbols []Symbol
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxFileSize)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			continue
+		}
+		indent := len(strings.ReplaceAll(line[:len(line)-len(trimmed)], "\t", "    "))
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		for _, rule := range rules {
+			match := rule.re.FindStringSubmatchIndex(trimmed)
+			if match == nil {
+				continue
+			}
+			name := trimmed[match[2]:match[3]]
+			if controlKeywords[name] || (rule.nested && len(stack) == 0) {
+				continue
+			}
+			symbol := Symbol{
+				Name:      name,
+				Kind:      rule.kind,
+				Path:      rel,
+				Line:      lineNum,
+				Column:    len(line) - len(trimmed) + match[2] + 1,
+				Signature: strings.TrimSpace(trimmed),
+			}
+			if len(stack) > 0 {
+				symbol.Container = stack[len(stack)-1].name
+				if symbol.Kind == "function" {
+					symbol.Kind = "method"
+				}
+			}
+			symbols = append(symbols, symbol)
+			if containerKinds[rule.kind] {
+				stack = append(stack, container{indent: indent, name: name})
+			}
+			break
+		}
+	}
+	return symbols
+}
\ No newline at end of file
@@ -0,0 +1,253 @@
+package symbols
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 符号索引的文件限制
+const (
+	maxFileSize = 1024 * 1024 // 参与索引的单个文件最大字节数
+	maxFiles    = 20000       // 参与索引的最多文件数
+)
+
+// ignoredDirs 不参与索引的目录
+var ignoredDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, "dist": true, "build": true,
+	"target": true, "__pycache__": true, ".venv": true, "venv": true, ".idea": true,
+	".vscode": true, ".opencursor": true,
+}
+
+// Symbol 一个符号定义
+type Symbol struct {
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`                // function、method、type、class、constant 等
+	Path      string `json:"file"`                // 相对仓库根目录，使用 /
+	Line      int    `json:"line"`                // 1-based
+	Column    int    `json:"column,omitempty"`    // 1-based，未知时为0
+	Container string `json:"container,omitempty"` // 所属的类型或类，如方法的接收者
+	Signature string `json:"signature,omitempty"` // 定义所在行或函数签名
+}
+
+// fileEntry 已解析文件的符号
+type fileEntry struct {
+	modTime time.Time
+	size    int64
+	symbols []Symbol
+}
+
+// Index 仓库的符号索引：Go 文件用语法树解析，其他语言安装了 universal-ctags 时用 ctags，
+// 否则按声明的行模式匹配。只重新解析修改时间或大小变化的文件
+type Index struct {
+	root  string
+	ctags string // universal-ctags 路径，未安装时为空
+
+	mu    sync.Mutex
+	files map[string]*fileEntry
+}
+
+// New 创建仓库的符号索引，第一次 Refresh 时解析文件
+func New(root string) *Index {
+	return &Index{root: root, ctags: findCtags(), files: make(map[string]*fileEntry)}
+}
+
+// Backend 解析非Go文件使用的方式
+func (x *Index) Backend() string {
+	if x.ctags != "" {
+		return "ctags"
+	}
+	return "builtin"
+}
+
+// Refresh 解析新增或修改的文件，移除已删除的文件
+func (x *Index) Refresh() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	seen := make(map[string]bool)
+	var changed []string
+	changedInfo := make(map[string]os.FileInfo)
+	err := filepath.Walk(x.root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil // 忽略错误，继续处理其他文件
+		}
+		name := info.Name()
+		if info.IsDir() {
+			if path != x.root && (ignoredDirs[name] || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if len(seen) >= maxFiles {
+			return filepath.SkipDir
+		}
+		if !info.Mode().IsRegular() || info.Size() > maxFileSize || strings.HasPrefix(name, ".") || !x.supported(name) {
+			return nil
+		}
+		rel, err := filepath.Rel(x.root, path)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		seen[rel] = true
+		if entry, ok := x.files[rel]; ok && entry.modTime.Equal(info.ModTime()) && entry.size == info.Size() {
+			return nil
+		}
+		changed = append(changed, rel)
+		changedInfo[rel] = info
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to walk directory: %w", err)
+	}
+
+	for rel := range x.files {
+		if !seen[rel] {
+			delete(x.files, rel)
+		}
+	}
+
+	parsed := make(map[string][]Symbol, len(changed))
+	var ctagsFiles []string
+	for _, rel := range changed {
+		ext := strings.ToLower(filepath.Ext(rel))
+		if ext == ".go" {
+			parsed[rel] = parseGo(x.root, rel)
+		} else if x.ctags != "" {
+			ctagsFiles = append(ctagsFiles, rel)
+		} else {
+			parsed[rel] = parsePatterns(x.root, rel)
+		}
+	}
+	if len(ctagsFiles) > 0 {
+		tags, err := runCtags(x.ctags, x.root, ctagsFiles)
+		if err != nil {
+			// ctags 失败时回退为行模式匹配
+			for _, rel := range ctagsFiles {
+				parsed[rel] = parsePatterns(x.root, rel)
+			}
+		} else {
+			for _, rel := range ctagsFiles {
+				parsed[rel] = tags[rel]
+			}
+		}
+	}
+	for rel, symbols := range parsed {
+		info := changedInfo[rel]
+		x.files[rel] = &fileEntry{modTime: info.ModTime(), size: info.Size(), symbols: symbols}
+	}
+	return nil
+}
+
+// supported 文件是否可能包含可解析的符号
+func (x *Index) supported(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext == ".go" || patterns[ext] != nil {
+		return true
+	}
+	return x.ctags != "" && ctagsExtensions[ext]
+}
+
+// Count 索引中的文件数与符号数
+func (x *Index) Count() (int, int) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	total := 0
+	for _, entry := range x.files {
+		total += len(entry.symbols)
+	}
+	return len(x.files), total
+}
+
+// Find 按名称查找符号：完全匹配优先，其次忽略大小写匹配、前缀匹配与包含匹配。
+// query 可以是 Container.Name 形式，kind 非空时只返回该类型的符号
+func (x *Index) Find(query, kind string, limit int) []Symbol {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	container := ""
+	name := query
+	if i := strings.LastIndex(query, "."); i > 0 && i < len(query)-1 {
+		container, name = query[:i], query[i+1:]
+	}
+	lowerName := strings.ToLower(name)
+
+	type candidate struct {
+		symbol Symbol
+		rank   int
+	}
+	var candidates []candidate
+	for _, entry := range x.files {
+		for _, symbol := range entry.symbols {
+			if kind != "" && !strings.EqualFold(symbol.Kind, kind) {
+				continue
+			}
+			if container != "" && !strings.EqualFold(symbol.Container, container) {
+				continue
+			}
+			lower := strings.ToLower(symbol.Name)
+			rank := -1
+			switch {
+			case symbol.Name == name:
+				rank = 0
+			case lower == lowerName:
+				rank = 1
+			case strings.HasPrefix(lower, lowerName):
+				rank = 2
+			case strings.Contains(lower, lowerName):
+				rank = 3
+			}
+			if rank >= 0 {
+				candidates = append(candidates, candidate{symbol, rank})
+			}
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.rank != b.rank {
+			return a.rank < b.rank
+		}
+		if a.symbol.Path != b.symbol.Path {
+			return a.symbol.Path < b.symbol.Path
+		}
+		return a.symbol.Line < b.symbol.Line
+	})
+	if limit > 0 && len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	results := make([]Symbol, len(candidates))
+	for i, c := range candidates {
+		results[i] = c.symbol
+	}
+	return results
+}
+
+// Definitions 查找名称完全相同的定义，靠近 fromPath 的排在前面：同一文件、同一目录、其他
+func (x *Index) Definitions(name, fromPath string) []Symbol {
+	definitions := x.Find(name, "", 0)
+	exact := definitions[:0]
+	for _, symbol := range definitions {
+		if symbol.Name == name || (strings.Contains(name, ".") && strings.HasSuffix(name, "."+symbol.Name)) {
+			exact = append(exact, symbol)
+		}
+	}
+	fromDir := filepath.ToSlash(filepath.Dir(fromPath))
+	distance := func(symbol Symbol) int {
+		switch {
+		case symbol.Path == fromPath:
+			return 0
+		case filepath.ToSlash(filepath.Dir(symbol.Path)) == fromDir:
+			return 1
+		}
+		return 2
+	}
+	sort.SliceStable(exact, func(i, j int) bool {
+		return distance(exact[i]) < distance(exact[j])
+	})
+	return exact
+}
\ No newline at end of file
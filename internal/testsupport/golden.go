@@ -0,0 +1,59 @@
+package testsupport
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// goldenFS 每个工具一个用例文件 golden/<tool>.json
+//
+//go:embed golden/*.json
+var goldenFS embed.FS
+
+// LoadGolden 读取内置的全部工具用例，按工具名排序
+func LoadGolden() ([]Case, error) {
+	entries, err := goldenFS.ReadDir("golden")
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	var cases []Case
+	for _, entry := range entries {
+		data, err := goldenFS.ReadFile(path.Join("golden", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var fileCases []Case
+		if err := json.Unmarshal(data, &fileCases); err != nil {
+			return nil, fmt.Errorf("failed to parse golden/%s: %w", entry.Name(), err)
+		}
+		tool := strings.TrimSuffix(entry.Name(), ".json")
+		for _, c := range fileCases {
+			c.Tool = tool
+			cases = append(cases, c)
+		}
+	}
+	return cases, nil
+}
+
+// Filter 选出工具名或用例标识（<tool>/<name>）包含任一关键字的用例，关键字为空时返回全部
+func Filter(cases []Case, keywords []string) []Case {
+	if len(keywords) == 0 {
+		return cases
+	}
+	var selected []Case
+	for _, c := range cases {
+		for _, keyword := range keywords {
+			if c.Tool == keyword || strings.Contains(c.ID(), keyword) {
+				selected = append(selected, c)
+				break
+			}
+		}
+	}
+	return selected
+}
\ No newline at end of file
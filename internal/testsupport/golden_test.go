@@ -0,0 +1,22 @@
+package testsupport
+
+import "testing"
+
+// TestGolden 执行 golden/ 中的全部工具用例
+func TestGolden(t *testing.T) {
+	cases, err := LoadGolden()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range cases {
+		t.Run(c.ID(), func(t *testing.T) {
+			outcome := Run(c)
+			if outcome.Skipped != "" {
+				t.Skip(outcome.Skipped)
+			}
+			for _, failure := range outcome.Failures {
+				t.Error(failure)
+			}
+		})
+	}
+}
@@ -0,0 +1,449 @@
+package testsupport
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"openCursor/internal/tools"
+)
+
+// 期望值中的特殊字符串
+const (
+	anyValue      = "<any>"       // 匹配任意值（字段必须存在）
+	regexpPrefix  = "re:"         // 以 re: 开头的字符串按正则匹配
+	workDirMarker = "{{workdir}}" // 参数与期望值中替换为临时工作区路径
+)
+
+// planTool 应用修改计划（openCursor apply-plan）的用例使用的工具名，params.files 为计划中的文件
+const planTool = "apply_plan"
+
+// Commit 用例工作区中的一次提交
+type Commit struct {
+	Message string            `json:"message"`
+	Files   map[string]string `json:"files"`
+}
+
+// Expect 用例的期望结果
+type Expect struct {
+	// Error 工具应失败，且错误包含该子串
+	Error string `json:"error,omitempty"`
+	// Result 结果的JSON子集：对象只比较列出的字段，数组长度必须一致
+	Result interface{} `json:"result,omitempty"`
+	// Files 执行后工作区中的文件内容
+	Files map[string]string `json:"files,omitempty"`
+	// Absent 执行后不应存在的文件
+	Absent []string `json:"absent,omitempty"`
+}
+
+// Case 一个工具用例：准备临时工作区，按脚本参数调用工具，并与期望结果比较
+type Case struct {
+	Tool     string                 `json:"-"`
+	Name     string                 `json:"name"`
+	Requires []string               `json:"requires,omitempty"` // 需要的可执行文件，缺少时跳过
+	Commits  []Commit               `json:"commits,omitempty"`  // 非空时工作区为git仓库
+	Files    map[string]string      `json:"files,omitempty"`    // 提交之后写入的文件
+	Params   map[string]interface{} `json:"params"`
+	Expect   Expect                 `json:"expect"`
+
+	BinaryFiles    map[string]string     `json:"binary_files,omitempty"`    // 提交之后写入的二进制文件（base64）
+	SecurityPolicy *tools.SecurityPolicy `json:"security_policy,omitempty"` // 用例中生效的安全策略，为空时为内置策略
+	Unattended     bool                  `json:"unattended,omitempty"`      // 像 openCursor ci 一样确认命令：只执行只读命令与 allow_commands 中的命令
+	AllowCommands  []string              `json:"allow_commands,omitempty"`
+}
+
+// ID 用例标识 <tool>/<name>
+func (c Case) ID() string {
+	return c.Tool + "/" + c.Name
+}
+
+// Outcome 用例执行结果
+type Outcome struct {
+	Case     Case
+	Skipped  string   // 跳过原因，为空表示已执行
+	Failures []string // 与期望不一致之处，为空表示通过
+	Duration time.Duration
+}
+
+// Passed 用例是否通过（跳过的用例不算失败）
+func (o Outcome) Passed() bool {
+	return len(o.Failures) == 0
+}
+
+// writeFiles 将文件写入工作区
+func writeFiles(dir string, files map[string]string) error {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(files[name]), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewWorkspace 创建临时工作区：依次写入并提交 commits（固定作者与时间，提交哈希可复现），
+// 再写入未提交的 files。返回工作区路径与清理函数
+func NewWorkspace(commits []Commit, files map[string]string) (string, func(), error) {
+	dir, err := os.MkdirTemp("", "opencursor-selftest-*")
+	if err != nil {
+		return "", nil, err
+	}
+	// macOS 的临时目录是符号链接，统一使用真实路径
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	if len(commits) > 0 {
+		// 仓库级身份与签名设置，使工具自己执行的git提交不依赖用户配置
+		setup := [][]string{
+			{"init", "--quiet", "--initial-branch=main"},
+			{"config", "user.name", "Test Author"},
+			{"config", "user.email", "author@example.com"},
+			{"config", "commit.gpgsign", "false"},
+		}
+		for _, args := range setup {
+			if err := git(dir, time.Time{}, args...); err != nil {
+				cleanup()
+				return "", nil, err
+			}
+		}
+		date := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		for i, commit := range commits {
+			if err := writeFiles(dir, commit.Files); err != nil {
+				cleanup()
+				return "", nil, err
+			}
+			if err := git(dir, date, "add", "--all"); err != nil {
+				cleanup()
+				return "", nil, err
+			}
+			if err := git(dir, date.Add(time.Duration(i)*time.Hour), "commit", "--quiet", "--allow-empty", "--message", commit.Message); err != nil {
+				cleanup()
+				return "", nil, err
+			}
+		}
+	}
+
+	if err := writeFiles(dir, files); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return dir, cleanup, nil
+}
+
+// git 在工作区中执行git命令，使用固定的身份与时间，忽略用户的全局配置
+func git(dir string, date time.Time, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_CONFIG_GLOBAL="+os.DevNull,
+		"GIT_CONFIG_NOSYSTEM=1",
+		"GIT_AUTHOR_NAME=Test Author",
+		"GIT_AUTHOR_EMAIL=author@example.com",
+		"GIT_COMMITTER_NAME=Test Author",
+		"GIT_COMMITTER_EMAIL=author@example.com",
+	)
+	if !date.IsZero() {
+		stamp := date.Format(time.RFC3339)
+		cmd.Env = append(cmd.Env, "GIT_AUTHOR_DATE="+stamp, "GIT_COMMITTER_DATE="+stamp)
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git %s failed: %s", args[0], strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// substitute 将值中的 {{workdir}} 替换为工作区路径
+func substitute(value interface{}, workDir string) interface{} {
+	switch v := value.(type) {
+	case string:
+		return strings.ReplaceAll(v, workDirMarker, workDir)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = substitute(item, workDir)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = substitute(item, workDir)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// Run 在新的临时工作区中执行用例
+func Run(c Case) (outcome Outcome) {
+	start := time.Now()
+	outcome.Case = c
+	defer func() { outcome.Duration = time.Since(start) }()
+
+	requires := c.Requires
+	if len(c.Commits) > 0 {
+		requires = append([]string{"git"}, requires...)
+	}
+	for _, name := range requires {
+		if _, err := exec.LookPath(name); err != nil {
+			outcome.Skipped = name + " not found in PATH"
+			return outcome
+		}
+	}
+
+	registry := tools.NewRegistry()
+	if err := registry.RegisterAllTools(); err != nil {
+		outcome.Failures = append(outcome.Failures, err.Error())
+		return outcome
+	}
+	// 可选工具（如 view_image）只为自己的用例注册
+	for _, name := range tools.OptionalToolNames() {
+		if name == c.Tool {
+			if err := registry.RegisterOptionalTools([]string{name}); err != nil {
+				outcome.Failures = append(outcome.Failures, err.Error())
+				return outcome
+			}
+		}
+	}
+	if _, ok := registry.GetManager().GetTool(c.Tool); !ok && c.Tool != planTool {
+		outcome.Skipped = "tool not available"
+		return outcome
+	}
+
+	workDir, cleanup, err := NewWorkspace(c.Commits, c.Files)
+	if err != nil {
+		outcome.Failures = append(outcome.Failures, "workspace setup: "+err.Error())
+		return outcome
+	}
+	defer cleanup()
+	if err := writeBinaryFiles(workDir, c.BinaryFiles); err != nil {
+		outcome.Failures = append(outcome.Failures, "workspace setup: "+err.Error())
+		return outcome
+	}
+	registry.SetWorkDirectory(workDir)
+
+	if c.SecurityPolicy != nil {
+		if err := tools.SetSecurityPolicy(*c.SecurityPolicy); err != nil {
+			outcome.Failures = append(outcome.Failures, "security_policy: "+err.Error())
+			return outcome
+		}
+		defer tools.SetSecurityPolicy(tools.SecurityPolicy{})
+	}
+	if c.Unattended {
+		tools.SetUnattendedApproval(c.AllowCommands, nil)
+		defer func() {
+			tools.SetApprovalHandler(nil)
+			tools.SetApprovalPolicy(nil)
+			tools.SetCommandApproval(tools.ApprovalAuto)
+		}()
+	}
+
+	params, _ := substitute(c.Params, workDir).(map[string]interface{})
+	var result *tools.ToolResult
+	if c.Tool == planTool {
+		result = applyPlan(params, workDir)
+	} else {
+		result, err = registry.GetManager().ExecuteTool(c.Tool, params)
+		if err != nil {
+			outcome.Failures = append(outcome.Failures, err.Error())
+			return outcome
+		}
+	}
+	outcome.Failures = Check(c.Expect, result, workDir)
+	return outcome
+}
+
+// writeBinaryFiles 将 base64 编码的文件写入工作区
+func writeBinaryFiles(dir string, files map[string]string) error {
+	decoded := make(map[string]string, len(files))
+	for name, data := range files {
+		content, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return fmt.Errorf("binary file %s: %w", name, err)
+		}
+		decoded[name] = string(content)
+	}
+	return writeFiles(dir, decoded)
+}
+
+// applyPlan 用 params.files 构建工作区的修改计划并应用，结果与工具结果的形式相同
+func applyPlan(params map[string]interface{}, workDir string) *tools.ToolResult {
+	plan := &tools.ChangePlan{Version: 1, WorkDir: workDir}
+	data, _ := json.Marshal(params["files"])
+	if err := json.Unmarshal(data, &plan.Files); err != nil {
+		return &tools.ToolResult{Name: planTool, Error: "invalid plan files: " + err.Error()}
+	}
+	if err := plan.Apply(); err != nil {
+		return &tools.ToolResult{Name: planTool, Error: err.Error()}
+	}
+	return &tools.ToolResult{Name: planTool, Success: true, Result: map[string]interface{}{"applied": len(plan.Files)}}
+}
+
+// Check 将工具结果与工作区状态与期望比较，返回不一致之处
+func Check(expect Expect, result *tools.ToolResult, workDir string) []string {
+	var failures []string
+
+	if expect.Error != "" {
+		if result.Success {
+			failures = append(failures, fmt.Sprintf("expected an error containing %q, the tool succeeded", expect.Error))
+		} else if !strings.Contains(result.Error, expect.Error) {
+			failures = append(failures, fmt.Sprintf("expected an error containing %q, got %q", expect.Error, result.Error))
+		}
+	} else if !result.Success {
+		failures = append(failures, "unexpected error: "+result.Error)
+	}
+
+	if expect.Result != nil && result.Success {
+		// 经过一次JSON编解码，与模型看到的结果一致
+		data, err := json.Marshal(result.Result)
+		if err != nil {
+			failures = append(failures, "failed to encode result: "+err.Error())
+		} else {
+			var actual interface{}
+			json.Unmarshal(data, &actual)
+			failures = append(failures, Match(substitute(expect.Result, workDir), actual, "result")...)
+		}
+	}
+
+	names := make([]string, 0, len(expect.Files))
+	for name := range expect.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(workDir, filepath.FromSlash(name)))
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("file %s: %v", name, err))
+			continue
+		}
+		if string(data) != expect.Files[name] {
+			failures = append(failures, fmt.Sprintf("file %s: expected %q, got %q", name, expect.Files[name], string(data)))
+		}
+	}
+	for _, name := range expect.Absent {
+		if _, err := os.Stat(filepath.Join(workDir, filepath.FromSlash(name))); err == nil {
+			failures = append(failures, fmt.Sprintf("file %s should not exist", name))
+		}
+	}
+	return failures
+}
+
+// Match 比较期望的JSON子集与实际值，返回不一致之处
+func Match(expected, actual interface{}, path string) []string {
+	if s, ok := expected.(string); ok {
+		if s == anyValue {
+			if actual == nil {
+				return []string{fmt.Sprintf("%s: expected a value, got nothing", path)}
+			}
+			return nil
+		}
+		if strings.HasPrefix(s, regexpPrefix) {
+			pattern, err := regexp.Compile(strings.TrimPrefix(s, regexpPrefix))
+			if err != nil {
+				return []string{fmt.Sprintf("%s: invalid pattern %q: %v", path, s, err)}
+			}
+			text, ok := actual.(string)
+			if !ok || !pattern.MatchString(text) {
+				return []string{fmt.Sprintf("%s: expected to match %s, got %s", path, pattern, describe(actual))}
+			}
+			return nil
+		}
+	}
+
+	switch want := expected.(type) {
+	case map[string]interface{}:
+		got, ok := actual.(map[string]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: expected an object, got %s", path, describe(actual))}
+		}
+		keys := make([]string, 0, len(want))
+		for key := range want {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var failures []string
+		for _, key := range keys {
+			value, exists := got[key]
+			if !exists {
+				// 省略的零值字段（omitempty）视为零值
+				if isZero(want[key]) {
+					continue
+				}
+				failures = append(failures, fmt.Sprintf("%s.%s: missing", path, key))
+				continue
+			}
+			failures = append(failures, Match(want[key], value, path+"."+key)...)
+		}
+		return failures
+
+	case []interface{}:
+		got, ok := actual.([]interface{})
+		if !ok {
+			if actual == nil && len(want) == 0 {
+				return nil
+			}
+			return []string{fmt.Sprintf("%s: expected an array, got %s", path, describe(actual))}
+		}
+		if len(got) != len(want) {
+			return []string{fmt.Sprintf("%s: expected %d items, got %d: %s", path, len(want), len(got), describe(actual))}
+		}
+		var failures []string
+		for i := range want {
+			failures = append(failures, Match(want[i], got[i], fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return failures
+
+	default:
+		if fmt.Sprint(expected) != fmt.Sprint(actual) {
+			return []string{fmt.Sprintf("%s: expected %s, got %s", path, describe(expected), describe(actual))}
+		}
+		return nil
+	}
+}
+
+// isZero 期望值是否为JSON零值
+func isZero(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case bool:
+		return !v
+	case float64:
+		return v == 0
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// describe 失败信息中的值，过长时截断
+func describe(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	text := string(data)
+	if len(text) > 300 {
+		text = text[:297] + "..."
+	}
+	return text
+}
\ No newline at end of file
@@ -0,0 +1,170 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"openCursor/internal/apischema"
+)
+
+// APISchemaDiffParams api_schema_diff工具的参数
+type APISchemaDiffParams struct {
+	FilePath    string `json:"file_path"`
+	BaseRef     string `json:"base_ref,omitempty"`
+	CompareRef  string `json:"compare_ref,omitempty"`
+	Explanation string `json:"explanation,omitempty"`
+}
+
+// APISchemaDiffResult api_schema_diff工具的返回结果
+type APISchemaDiffResult struct {
+	FilePath           string             `json:"file_path"`
+	Format             string             `json:"format"`
+	BaseRef            string             `json:"base_ref"`
+	CompareRef         string             `json:"compare_ref"`
+	HasBreakingChanges bool               `json:"has_breaking_changes"`
+	BreakingChanges    []apischema.Change `json:"breaking_changes"`
+	NonBreakingChanges []apischema.Change `json:"non_breaking_changes"`
+	Message            string             `json:"message"`
+}
+
+// gitShowFile 读取文件在指定git引用中的内容
+func gitShowFile(filePath, ref string) ([]byte, error) {
+	cmd := exec.Command("git", "show", ref+":./"+filepath.Base(filePath))
+	cmd.Dir = filepath.Dir(filePath)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return nil, fmt.Errorf("git show %s failed: %s", ref, message)
+	}
+	return output, nil
+}
+
+// apiSchemaDiffFunction API schema差异比较工具函数
+func apiSchemaDiffFunction(params map[string]interface{}) (interface{}, error) {
+	// 解析参数
+	filePath, ok := params["file_path"].(string)
+	if !ok || filePath == "" {
+		return nil, fmt.Errorf("file_path is required")
+	}
+
+	baseRef, _ := params["base_ref"].(string)
+	if baseRef == "" {
+		baseRef = "HEAD"
+	}
+	compareRef, _ := params["compare_ref"].(string)
+	workDir, _ := params["__work_dir__"].(string)
+
+	// 解析文件路径
+	targetPath := filePath
+	if !filepath.IsAbs(targetPath) && workDir != "" {
+		targetPath = filepath.Join(workDir, filePath)
+	}
+
+	// 读取新版本（工作区或指定引用）
+	var newContent []byte
+	var err error
+	if compareRef == "" {
+		newContent, err = os.ReadFile(targetPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+	} else {
+		newContent, err = gitShowFile(targetPath, compareRef)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	format, err := apischema.DetectFormat(targetPath, newContent)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &APISchemaDiffResult{
+		FilePath:           targetPath,
+		Format:             format,
+		BaseRef:            baseRef,
+		CompareRef:         compareRef,
+		BreakingChanges:    []apischema.Change{},
+		NonBreakingChanges: []apischema.Change{},
+	}
+	if compareRef == "" {
+		result.CompareRef = "working tree"
+	}
+
+	// 读取旧版本
+	oldContent, err := gitShowFile(targetPath, baseRef)
+	if err != nil {
+		result.Message = fmt.Sprintf("File does not exist at %s, so it is a new API definition: %v", baseRef, err)
+		return result, nil
+	}
+
+	changes, err := apischema.Diff(format, oldContent, newContent)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, change := range changes {
+		if change.Severity == apischema.SeverityBreaking {
+			result.BreakingChanges = append(result.BreakingChanges, change)
+		} else {
+			result.NonBreakingChanges = append(result.NonBreakingChanges, change)
+		}
+	}
+	result.HasBreakingChanges = len(result.BreakingChanges) > 0
+
+	if result.HasBreakingChanges {
+		result.Message = fmt.Sprintf("Found %d breaking and %d non-breaking changes compared to %s", len(result.BreakingChanges), len(result.NonBreakingChanges), baseRef)
+	} else if len(result.NonBreakingChanges) > 0 {
+		result.Message = fmt.Sprintf("No breaking changes; %d backward-compatible changes compared to %s", len(result.NonBreakingChanges), baseRef)
+	} else {
+		result.Message = fmt.Sprintf("No API changes compared to %s", baseRef)
+	}
+
+	return result, nil
+}
+
+// NewAPISchemaDiffTool 创建api_schema_diff工具
+func NewAPISchemaDiffTool() Tool {
+	schema := ToolSchema{
+		Name:        "api_schema_diff",
+		Description: "Compare two versions of an API definition file (OpenAPI/Swagger YAML or JSON, or a protobuf .proto file) and report breaking and non-breaking changes. By default the working tree version is compared against HEAD. Use this after editing API definitions to warn the USER about compatibility issues such as removed endpoints, new required parameters, changed field types or reused protobuf field numbers.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"file_path": map[string]interface{}{
+					"type":        "string",
+					"description": "The path of the OpenAPI or .proto file, relative to the workspace root.",
+				},
+				"base_ref": map[string]interface{}{
+					"type":        "string",
+					"description": "The git ref of the old version (branch, tag or commit). Defaults to HEAD.",
+				},
+				"compare_ref": map[string]interface{}{
+					"type":        "string",
+					"description": "The git ref of the new version. Defaults to the working tree.",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+			"required": []string{"file_path"},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: apiSchemaDiffFunction,
+	}
+}
\ No newline at end of file
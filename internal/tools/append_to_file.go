@@ -0,0 +1,135 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// AppendToFileParams append_to_file工具的参数
+type AppendToFileParams struct {
+	TargetFile      string `json:"target_file"`
+	Content         string `json:"content"`
+	CreateIfMissing *bool  `json:"create_if_missing,omitempty"`
+	Explanation     string `json:"explanation,omitempty"`
+}
+
+// AppendToFileResult append_to_file工具的返回结果
+type AppendToFileResult struct {
+	TargetFile    string `json:"target_file"`
+	Appended      bool   `json:"appended"`
+	Created       bool   `json:"created"`
+	BytesAppended int    `json:"bytes_appended"`
+	StartLine     int    `json:"start_line"`
+	TotalLines    int    `json:"total_lines"`
+	Message       string `json:"message"`
+}
+
+// appendToFileFunction 追加内容到文件末尾工具函数
+func appendToFileFunction(params map[string]interface{}) (interface{}, error) {
+	// 解析参数
+	targetFile, ok := params["target_file"].(string)
+	if !ok || targetFile == "" {
+		return nil, fmt.Errorf("target_file is required")
+	}
+
+	content, ok := params["content"].(string)
+	if !ok || content == "" {
+		return nil, fmt.Errorf("content is required")
+	}
+
+	createIfMissing := true
+	if val, ok := params["create_if_missing"].(bool); ok {
+		createIfMissing = val
+	}
+
+	workDir, _ := params["__work_dir__"].(string)
+
+	// 解析文件路径
+	filePath := targetFile
+	if !filepath.IsAbs(filePath) && workDir != "" {
+		filePath = filepath.Join(workDir, targetFile)
+	}
+
+	result := &AppendToFileResult{
+		TargetFile: filePath,
+	}
+
+	existing, err := readWorkspaceFile(filePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		if !createIfMissing {
+			return nil, fmt.Errorf("file not found: %s", targetFile)
+		}
+		if err := makeWorkspaceDir(filepath.Dir(filePath)); err != nil {
+			return nil, fmt.Errorf("failed to create directory: %w", err)
+		}
+		result.Created = true
+	}
+
+	// 沿用文件原有的换行符，并保证追加内容另起一行、以换行结尾
+	lineEnding := detectLineEnding(string(existing))
+	text := strings.Join(splitContentLines(content), lineEnding) + lineEnding
+	if len(existing) > 0 && !strings.HasSuffix(string(existing), "\n") {
+		text = lineEnding + text
+	}
+
+	if err := appendWorkspaceFile(filePath, []byte(text)); err != nil {
+		return nil, fmt.Errorf("failed to write file: %w", err)
+	}
+
+	existingLines := strings.Count(string(existing), "\n")
+	if len(existing) > 0 && !strings.HasSuffix(string(existing), "\n") {
+		existingLines++
+	}
+
+	result.Appended = true
+	result.BytesAppended = len(text)
+	result.StartLine = existingLines + 1
+	result.TotalLines = existingLines + len(splitContentLines(content))
+	if result.Created {
+		result.Message = fmt.Sprintf("Created %s with %d lines", targetFile, result.TotalLines)
+	} else {
+		result.Message = fmt.Sprintf("Appended %d lines to %s starting at line %d", result.TotalLines-existingLines, targetFile, result.StartLine)
+	}
+
+	return result, nil
+}
+
+// NewAppendToFileTool 创建append_to_file工具
+func NewAppendToFileTool() Tool {
+	schema := ToolSchema{
+		Name:        "append_to_file",
+		Description: "Append content to the end of a file without reading or rewriting it, e.g. adding an entry to a log, a changelog, a .gitignore or a list of registrations. The content always starts on a new line and the file keeps its line endings. Creates the file (and parent directories) if it does not exist unless create_if_missing is false.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"target_file": map[string]interface{}{
+					"type":        "string",
+					"description": "The file to append to, relative to the workspace root or absolute.",
+				},
+				"content": map[string]interface{}{
+					"type":        "string",
+					"description": "The lines to append. A trailing newline is added if missing.",
+				},
+				"create_if_missing": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Create the file if it does not exist. Defaults to true.",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+			"required": []string{"target_file", "content"},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: appendToFileFunction,
+	}
+}
\ No newline at end of file
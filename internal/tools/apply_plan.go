@@ -0,0 +1,242 @@
+package tools
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// changePlanVersion 计划文件的格式版本
+const changePlanVersion = 1
+
+// contentHash 文件内容的 SHA-256（十六进制）
+func contentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// SaveChangePlan 将修改计划写入 JSON 文件
+func SaveChangePlan(path string, plan *ChangePlan) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create plan directory: %w", err)
+	}
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write plan: %w", err)
+	}
+	return nil
+}
+
+// LoadChangePlan 读取修改计划文件
+func LoadChangePlan(path string) (*ChangePlan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan: %w", err)
+	}
+	plan := &ChangePlan{}
+	if err := json.Unmarshal(data, plan); err != nil {
+		return nil, fmt.Errorf("failed to parse plan %s: %w", path, err)
+	}
+	if plan.Version != changePlanVersion {
+		return nil, fmt.Errorf("unsupported plan version %d in %s (expected %d)", plan.Version, path, changePlanVersion)
+	}
+	if plan.WorkDir == "" {
+		return nil, fmt.Errorf("plan %s has no workdir", path)
+	}
+	for _, file := range plan.Files {
+		switch file.Action {
+		case PlanCreate, PlanModify, PlanDelete:
+		default:
+			return nil, fmt.Errorf("plan %s: unknown action %q for %s", path, file.Action, file.Path)
+		}
+		if file.Action != PlanCreate && file.OriginalHash == "" {
+			return nil, fmt.Errorf("plan %s: %s has no original_hash", path, file.Path)
+		}
+	}
+	return plan, nil
+}
+
+// planPath 计划中文件的绝对路径
+func (p *ChangePlan) planPath(file PlannedFile) string {
+	path := filepath.FromSlash(file.Path)
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(p.WorkDir, path)
+}
+
+// checkPaths 应用前检查计划中的每个文件：相对路径不能通过 .. 离开工作目录，所有路径按安全策略检查
+func (p *ChangePlan) checkPaths() error {
+	for _, file := range p.Files {
+		path := filepath.FromSlash(file.Path)
+		if !filepath.IsAbs(path) {
+			if _, ok := relSecurityPath(filepath.Clean(p.WorkDir), filepath.Join(p.WorkDir, path)); !ok {
+				return fmt.Errorf("plan path %s is outside the workdir %s", file.Path, p.WorkDir)
+			}
+		}
+		action := policyWrite
+		if file.Action == PlanDelete {
+			action = policyDelete
+		}
+		if err := checkSecurityPolicy(action, p.planPath(file), p.WorkDir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Drift 工作区相对于试运行时的变化：要修改或删除的文件内容已改变或不存在，要新建的文件已存在
+func (p *ChangePlan) Drift() []string {
+	var drift []string
+	for _, file := range p.Files {
+		path := p.planPath(file)
+		content, err := os.ReadFile(path)
+		switch {
+		case file.Action == PlanCreate:
+			if err == nil {
+				drift = append(drift, fmt.Sprintf("%s: should be created but already exists", file.Path))
+			}
+		case os.IsNotExist(err):
+			drift = append(drift, fmt.Sprintf("%s: no longer exists", file.Path))
+		case err != nil:
+			drift = append(drift, fmt.Sprintf("%s: %v", file.Path, err))
+		case contentHash(content) != file.OriginalHash:
+			drift = append(drift, fmt.Sprintf("%s: changed since the dry run", file.Path))
+		}
+	}
+	return drift
+}
+
+// appliedFile 应用计划时一个文件的中间状态，用于失败时回滚
+type appliedFile struct {
+	path    string
+	staged  string // 写好新内容的临时文件
+	backup  string // 原文件移开后的位置
+	placed  bool   // 新内容已就位
+	created []string
+}
+
+// Apply 检查工作区没有变化后应用计划中的所有文件修改：先把新内容写入同目录的临时文件，
+// 再依次替换；任何一步失败时恢复已替换的文件，工作区保持不变。计划中的命令不执行
+func (p *ChangePlan) Apply() error {
+	if err := p.checkPaths(); err != nil {
+		return err
+	}
+	if drift := p.Drift(); len(drift) > 0 {
+		return fmt.Errorf("the workspace changed since the dry run:\n  %s", strings.Join(drift, "\n  "))
+	}
+
+	files := make([]*appliedFile, 0, len(p.Files))
+	rollback := func() {
+		for i := len(files) - 1; i >= 0; i-- {
+			file := files[i]
+			if file.placed {
+				os.Remove(file.path)
+			}
+			if file.backup != "" {
+				os.Rename(file.backup, file.path)
+			}
+			if file.staged != "" {
+				os.Remove(file.staged)
+			}
+			for j := len(file.created) - 1; j >= 0; j-- {
+				os.Remove(file.created[j])
+			}
+		}
+	}
+
+	// 写入临时文件
+	for _, planned := range p.Files {
+		file := &appliedFile{path: p.planPath(planned)}
+		files = append(files, file)
+		if planned.Action == PlanDelete {
+			continue
+		}
+		created, err := makeDirs(filepath.Dir(file.path))
+		file.created = created
+		if err != nil {
+			rollback()
+			return fmt.Errorf("failed to create directory for %s: %w", planned.Path, err)
+		}
+		mode := planned.Mode.Perm()
+		if mode == 0 {
+			mode = 0644
+		}
+		staged, err := os.CreateTemp(filepath.Dir(file.path), "."+filepath.Base(file.path)+".opencursor-*")
+		if err != nil {
+			rollback()
+			return fmt.Errorf("failed to stage %s: %w", planned.Path, err)
+		}
+		file.staged = staged.Name()
+		_, err = staged.WriteString(planned.Content)
+		if closeErr := staged.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Chmod(file.staged, mode)
+		}
+		if err != nil {
+			rollback()
+			return fmt.Errorf("failed to stage %s: %w", planned.Path, err)
+		}
+	}
+
+	// 移开原文件并替换为新内容
+	for i, planned := range p.Files {
+		file := files[i]
+		if planned.Action != PlanCreate {
+			backup, err := os.CreateTemp(filepath.Dir(file.path), "."+filepath.Base(file.path)+".opencursor-backup-*")
+			if err != nil {
+				rollback()
+				return fmt.Errorf("failed to replace %s: %w", planned.Path, err)
+			}
+			backup.Close()
+			if err := os.Rename(file.path, backup.Name()); err != nil {
+				os.Remove(backup.Name())
+				rollback()
+				return fmt.Errorf("failed to replace %s: %w", planned.Path, err)
+			}
+			file.backup = backup.Name()
+		}
+		if planned.Action != PlanDelete {
+			if err := os.Rename(file.staged, file.path); err != nil {
+				rollback()
+				return fmt.Errorf("failed to replace %s: %w", planned.Path, err)
+			}
+			file.staged = ""
+			file.placed = true
+		}
+	}
+
+	for _, file := range files {
+		if file.backup != "" {
+			os.Remove(file.backup)
+		}
+	}
+	return nil
+}
+
+// makeDirs 创建目录及缺少的上级目录，返回新建的目录（从外到内）
+func makeDirs(dir string) ([]string, error) {
+	var missing []string
+	for current := dir; ; current = filepath.Dir(current) {
+		if _, err := os.Stat(current); err == nil {
+			break
+		}
+		missing = append([]string{current}, missing...)
+		if filepath.Dir(current) == current {
+			break
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return missing, err
+	}
+	return missing, nil
+}
@@ -0,0 +1,275 @@
+package tools
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 确认策略
+const (
+	ApprovalAuto  = "auto"  // 直接执行
+	ApprovalAsk   = "ask"   // 执行前需要用户确认（内置的安全只读命令除外）
+	ApprovalNever = "never" // 总是拒绝
+)
+
+// 工具类别，确认策略可以按类别或工具名设置
+const (
+	CategoryRead     = "read"     // 只读工具
+	CategoryWrite    = "write"    // 修改文件的工具
+	CategoryTerminal = "terminal" // 执行命令或外部程序的工具
+)
+
+// commandTools 按执行的每条命令确认的工具
+var commandTools = []string{"run_terminal_cmd", "run_tests", "coverage_report", "run_benchmarks", "get_build_errors", "audit_dependencies", "git", "docker"}
+
+// terminalTools 属于 terminal 类别的其他工具，整个工具调用前确认
+var terminalTools = []string{"browser", "open_in_editor"}
+
+// ToolCategory 工具的类别：read、write 或 terminal。spawn_task 视为只读，子代理的工具调用各自确认
+func ToolCategory(name string) string {
+	switch {
+	case isReadOnlyTool(name) || name == "spawn_task":
+		return CategoryRead
+	case containsString(commandTools, name) || containsString(terminalTools, name):
+		return CategoryTerminal
+	default:
+		return CategoryWrite
+	}
+}
+
+// ValidateApprovalPolicy 检查确认策略：键为类别或工具名，值为 auto、ask 或 never
+func ValidateApprovalPolicy(policy map[string]string) error {
+	keys := make([]string, 0, len(policy))
+	for key := range policy {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := validateApprovalMode(policy[key]); err != nil {
+			return fmt.Errorf("approval_policy.%s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// validateApprovalMode 检查单个确认策略
+func validateApprovalMode(mode string) error {
+	switch mode {
+	case ApprovalAuto, ApprovalAsk, ApprovalNever:
+		return nil
+	}
+	return fmt.Errorf("invalid approval mode %q (expected %s, %s or %s)", mode, ApprovalAuto, ApprovalAsk, ApprovalNever)
+}
+
+// stdinReader 默认从标准输入读取确认的回答
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// approvalState 命令确认状态。询问用户时不持有锁，其他工具调用（如并行的子代理）的确认不会因此阻塞
+var approvalState = struct {
+	sync.Mutex
+	prompt   sync.Mutex        // 终端上的询问逐个进行
+	mode     string            // 命令的默认策略（terminal 类别）
+	policy   map[string]string // 按类别或工具名设置的策略，优先于 mode
+	readLine func() (string, error)
+	output   io.Writer
+	handler  func(command, explanation string) bool
+	denied   int // 被拒绝的命令数
+}{
+	mode:     ApprovalAuto,
+	readLine: func() (string, error) { return stdinReader.ReadString('\n') },
+	output:   os.Stdout,
+}
+
+// SetCommandApproval 设置命令的默认确认策略（auto、ask 或 never），确认策略中设置了 terminal 时以其为准
+func SetCommandApproval(mode string) error {
+	if mode == "" {
+		mode = ApprovalAuto
+	}
+	if err := validateApprovalMode(mode); err != nil {
+		return err
+	}
+	approvalState.Lock()
+	defer approvalState.Unlock()
+	approvalState.mode = mode
+	return nil
+}
+
+// SetApprovalPolicy 设置按类别（read、write、terminal）或工具名的确认策略，工具名优先于类别。
+// 未设置的只读与修改类工具直接执行，命令按 SetCommandApproval 的策略确认
+func SetApprovalPolicy(policy map[string]string) error {
+	if err := ValidateApprovalPolicy(policy); err != nil {
+		return err
+	}
+	approvalState.Lock()
+	defer approvalState.Unlock()
+	approvalState.policy = policy
+	return nil
+}
+
+// approvalPolicyFor 工具的确认策略。调用时需持有锁
+func approvalPolicyFor(name string) string {
+	if mode, ok := approvalState.policy[name]; ok {
+		return mode
+	}
+	category := ToolCategory(name)
+	if mode, ok := approvalState.policy[category]; ok {
+		return mode
+	}
+	if category == CategoryTerminal {
+		return approvalState.mode
+	}
+	return ApprovalAuto
+}
+
+// SetApprovalInput 设置读取确认回答的函数，用于与其他读取标准输入的功能（如运行中的补充指令）共享输入
+func SetApprovalInput(readLine func() (string, error)) {
+	approvalState.Lock()
+	defer approvalState.Unlock()
+	approvalState.readLine = readLine
+}
+
+// SetApprovalOutput 设置终端上询问确认的输出位置，默认为标准输出
+func SetApprovalOutput(out io.Writer) {
+	approvalState.Lock()
+	defer approvalState.Unlock()
+	approvalState.output = out
+}
+
+// SetApprovalHandler 设置确认命令的函数，代替在终端上询问（如编辑器插件中的确认对话框），为nil时恢复终端询问
+func SetApprovalHandler(handler func(command, explanation string) bool) {
+	approvalState.Lock()
+	defer approvalState.Unlock()
+	approvalState.handler = handler
+}
+
+// SetUnattendedApproval 无人值守运行（openCursor ci）的确认设置：run_tests 与 get_build_errors 自动执行，
+// 其他命令只在匹配允许列表（IsAllowedCommand）时执行，否则自动拒绝并调用 onDeny（可为nil）
+func SetUnattendedApproval(allowlist []string, onDeny func(command, explanation string)) {
+	SetCommandApproval(ApprovalAsk)
+	SetApprovalPolicy(map[string]string{"run_tests": ApprovalAuto, "get_build_errors": ApprovalAuto})
+	SetApprovalHandler(func(command, explanation string) bool {
+		if IsAllowedCommand(command, allowlist) {
+			return true
+		}
+		if onDeny != nil {
+			onDeny(command, explanation)
+		}
+		return false
+	})
+}
+
+// DeniedCommands 进程启动以来被拒绝执行的命令与工具调用数
+func DeniedCommands() int {
+	approvalState.Lock()
+	defer approvalState.Unlock()
+	return approvalState.denied
+}
+
+// approvalMode 工具的确认策略，never 时计入被拒绝的次数
+func approvalMode(name string) string {
+	approvalState.Lock()
+	defer approvalState.Unlock()
+	mode := approvalPolicyFor(name)
+	if mode == ApprovalNever {
+		approvalState.denied++
+	}
+	return mode
+}
+
+// approveCommand 按工具的确认策略确认要执行的命令，ask 策略下安全只读命令直接放行
+func approveCommand(tool, command, explanation string) bool {
+	switch approvalMode(tool) {
+	case ApprovalAuto:
+		return true
+	case ApprovalNever:
+		return false
+	}
+	if IsSafeCommand(command) {
+		return true
+	}
+	return askApproval("命令", command, explanation)
+}
+
+// approveToolCall 按确认策略确认整个工具调用，按命令确认的工具在执行命令时确认
+func approveToolCall(name string, params map[string]interface{}) bool {
+	if containsString(commandTools, name) {
+		return true
+	}
+	switch approvalMode(name) {
+	case ApprovalAuto:
+		return true
+	case ApprovalNever:
+		return false
+	}
+	explanation, _ := params["explanation"].(string)
+	return askApproval("工具", describeToolCall(name, params), explanation)
+}
+
+// confirmRecursiveDelete 递归删除目录前总是询问（auto 策略也不例外），never 策略直接拒绝
+func confirmRecursiveDelete(dir string, files int, explanation string) bool {
+	if approvalMode("delete_file") == ApprovalNever {
+		return false
+	}
+	return askApproval("递归删除", fmt.Sprintf("%s（%d 个文件）", dir, files), explanation)
+}
+
+// describeToolCall 确认时展示的工具调用：工具名与目标
+func describeToolCall(name string, params map[string]interface{}) string {
+	for _, key := range append(append([]string{}, changeTargetKeys...), "path", "url") {
+		if value, ok := params[key].(string); ok && value != "" {
+			return name + " " + value
+		}
+	}
+	return name
+}
+
+// askApproval 通过确认函数或在终端上询问，无法读取输入（如标准输入已关闭）时视为拒绝。调用时不能持有锁
+func askApproval(kind, command, explanation string) bool {
+	approved := promptApproval(kind, command, explanation)
+	if !approved {
+		approvalState.Lock()
+		approvalState.denied++
+		approvalState.Unlock()
+	}
+	return approved
+}
+
+// promptApproval 调用确认函数或在终端上询问
+func promptApproval(kind, command, explanation string) bool {
+	approvalState.Lock()
+	handler, out, readLine := approvalState.handler, approvalState.output, approvalState.readLine
+	approvalState.Unlock()
+	if handler != nil {
+		return handler(command, explanation)
+	}
+
+	approvalState.prompt.Lock()
+	defer approvalState.prompt.Unlock()
+	fmt.Fprintf(out, "\n❓ 需要确认执行%s: %s\n", kind, command)
+	if explanation != "" {
+		fmt.Fprintf(out, "   原因: %s\n", explanation)
+	}
+	fmt.Fprint(out, "   是否执行? [y/N] ")
+
+	answer, err := readLine()
+	if err != nil && answer == "" {
+		fmt.Fprintln(out)
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+// containsString 列表中是否包含字符串
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
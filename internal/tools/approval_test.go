@@ -0,0 +1,42 @@
+package tools
+
+import (
+	"testing"
+	"time"
+)
+
+// TestApprovalPromptDoesNotBlock 等待用户回答时，其他工具调用的确认照常进行
+func TestApprovalPromptDoesNotBlock(t *testing.T) {
+	entered, release := make(chan struct{}), make(chan struct{})
+	SetApprovalPolicy(map[string]string{"run_tests": ApprovalAuto})
+	SetCommandApproval(ApprovalAsk)
+	SetApprovalHandler(func(command, explanation string) bool {
+		close(entered)
+		<-release
+		return true
+	})
+	defer func() {
+		SetApprovalHandler(nil)
+		SetApprovalPolicy(nil)
+		SetCommandApproval(ApprovalAuto)
+	}()
+
+	asked := make(chan bool)
+	go func() { asked <- approveCommand("run_terminal_cmd", "make deploy", "") }()
+	<-entered
+
+	decided := make(chan bool)
+	go func() { decided <- approveCommand("run_tests", "go test ./...", "") }()
+	select {
+	case approved := <-decided:
+		if !approved {
+			t.Errorf("run_tests with the auto policy was denied")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("approval of another tool call blocked while the user was being asked")
+	}
+	close(release)
+	if !<-asked {
+		t.Errorf("approved prompt returned false")
+	}
+}
@@ -0,0 +1,424 @@
+package tools
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// 压缩包列出与解压的上限，防止压缩炸弹
+const (
+	maxArchiveListEntries = 1000
+	maxExtractFiles       = 10000
+	maxExtractBytes       = 512 << 20
+)
+
+// ArchiveEntry 压缩包中的一项
+type ArchiveEntry struct {
+	Name  string `json:"name"`
+	Size  int64  `json:"size"`
+	IsDir bool   `json:"is_dir,omitempty"`
+	Link  string `json:"link,omitempty"` // 符号链接或硬链接的目标
+}
+
+// ListArchiveResult list_archive工具的返回结果
+type ListArchiveResult struct {
+	Archive    string         `json:"archive"`
+	Format     string         `json:"format"`
+	Entries    []ArchiveEntry `json:"entries"`
+	TotalFiles int            `json:"total_files"`
+	TotalSize  int64          `json:"total_size"`
+	Truncated  bool           `json:"truncated,omitempty"`
+}
+
+// ExtractArchiveResult extract_archive工具的返回结果
+type ExtractArchiveResult struct {
+	Archive     string   `json:"archive"`
+	Destination string   `json:"destination"`
+	Extracted   []string `json:"extracted"`
+	Skipped     []string `json:"skipped,omitempty"` // 链接与特殊文件不解压
+	BytesTotal  int64    `json:"bytes_total"`
+}
+
+// archiveFormat 根据扩展名判断压缩包格式：zip、tar 或 tar.gz
+func archiveFormat(name string) (string, error) {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"), strings.HasSuffix(lower, ".jar"), strings.HasSuffix(lower, ".whl"):
+		return "zip", nil
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return "tar.gz", nil
+	case strings.HasSuffix(lower, ".tar"):
+		return "tar", nil
+	}
+	return "", fmt.Errorf("unsupported archive format: %s (expected .zip, .tar, .tar.gz or .tgz)", name)
+}
+
+// archiveStem 去掉压缩包扩展名后的文件名，作为默认的解压目录
+func archiveStem(name string) string {
+	base := filepath.Base(name)
+	lower := strings.ToLower(base)
+	for _, ext := range []string{".tar.gz", ".tgz", ".tar", ".zip", ".jar", ".whl"} {
+		if strings.HasSuffix(lower, ext) {
+			return base[:len(base)-len(ext)]
+		}
+	}
+	return base
+}
+
+// archiveFile 压缩包中的一个文件，open 只在遍历到该项时有效
+type archiveFile struct {
+	ArchiveEntry
+	mode    os.FileMode
+	regular bool
+	open    func() (io.ReadCloser, error)
+}
+
+// walkArchive 依次访问压缩包中的每一项，visit 返回 false 时停止
+func walkArchive(archivePath, format string, visit func(archiveFile) (bool, error)) error {
+	if format == "zip" {
+		reader, err := zip.OpenReader(archivePath)
+		if err != nil {
+			return fmt.Errorf("failed to open archive: %w", err)
+		}
+		defer reader.Close()
+		for _, f := range reader.File {
+			f := f
+			mode := f.Mode()
+			entry := archiveFile{
+				ArchiveEntry: ArchiveEntry{Name: f.Name, Size: int64(f.UncompressedSize64), IsDir: mode.IsDir()},
+				mode:         mode.Perm(),
+				regular:      mode.IsRegular(),
+				open:         f.Open,
+			}
+			if mode&os.ModeSymlink != 0 {
+				entry.Link = zipLinkTarget(f)
+			}
+			if ok, err := visit(entry); err != nil || !ok {
+				return err
+			}
+		}
+		return nil
+	}
+
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer file.Close()
+	var stream io.Reader = file
+	if format == "tar.gz" {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return fmt.Errorf("failed to open archive: %w", err)
+		}
+		defer gz.Close()
+		stream = gz
+	}
+	reader := tar.NewReader(stream)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+		entry := archiveFile{
+			ArchiveEntry: ArchiveEntry{Name: header.Name, Size: header.Size, IsDir: header.Typeflag == tar.TypeDir},
+			mode:         os.FileMode(header.Mode).Perm(),
+			regular:      header.Typeflag == tar.TypeReg || header.Typeflag == tar.TypeRegA,
+			open:         func() (io.ReadCloser, error) { return io.NopCloser(reader), nil },
+		}
+		if header.Typeflag == tar.TypeSymlink || header.Typeflag == tar.TypeLink {
+			entry.Link = header.Linkname
+		}
+		if ok, err := visit(entry); err != nil || !ok {
+			return err
+		}
+	}
+}
+
+// zipLinkTarget zip 中符号链接的目标保存在文件内容中
+func zipLinkTarget(f *zip.File) string {
+	reader, err := f.Open()
+	if err != nil {
+		return ""
+	}
+	defer reader.Close()
+	target, _ := io.ReadAll(io.LimitReader(reader, 4096))
+	return string(target)
+}
+
+// archiveEntryPath 压缩包内路径在解压目录下的位置，拒绝绝对路径与跳出解压目录的路径（zip slip）
+func archiveEntryPath(dest, name string) (string, error) {
+	slashed := strings.ReplaceAll(name, `\`, "/")
+	if strings.HasPrefix(slashed, "/") || filepath.VolumeName(name) != "" || (len(slashed) > 1 && slashed[1] == ':') {
+		return "", fmt.Errorf("archive entry has an absolute path: %s", name)
+	}
+	cleaned := path.Clean(slashed)
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("archive entry escapes the destination directory: %s", name)
+	}
+	target := filepath.Join(dest, filepath.FromSlash(cleaned))
+	if rel, err := filepath.Rel(dest, target); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("archive entry escapes the destination directory: %s", name)
+	}
+	return target, nil
+}
+
+// archivePathParam 解析压缩包路径参数
+func archivePathParam(params map[string]interface{}) (string, string, string, error) {
+	archive, ok := params["path"].(string)
+	if !ok || archive == "" {
+		return "", "", "", fmt.Errorf("path parameter is required and must be a string")
+	}
+	workDir, _ := params["__work_dir__"].(string)
+	archivePath := archive
+	if !filepath.IsAbs(archivePath) {
+		archivePath = filepath.Join(workDir, archive)
+	}
+	format, err := archiveFormat(archive)
+	if err != nil {
+		return "", "", "", err
+	}
+	if _, err := os.Stat(archivePath); err != nil {
+		return "", "", "", fmt.Errorf("archive not found: %s", archive)
+	}
+	return archive, archivePath, format, nil
+}
+
+// listArchiveFunction 列出压缩包内容工具函数
+func listArchiveFunction(params map[string]interface{}) (interface{}, error) {
+	archive, archivePath, format, err := archivePathParam(params)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ListArchiveResult{Archive: archive, Format: format, Entries: []ArchiveEntry{}}
+	err = walkArchive(archivePath, format, func(f archiveFile) (bool, error) {
+		if !f.IsDir {
+			result.TotalFiles++
+			result.TotalSize += f.Size
+		}
+		if len(result.Entries) < maxArchiveListEntries {
+			result.Entries = append(result.Entries, f.ArchiveEntry)
+		} else {
+			result.Truncated = true
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// extractArchiveFunction 解压压缩包工具函数
+func extractArchiveFunction(params map[string]interface{}) (interface{}, error) {
+	archive, archivePath, format, err := archivePathParam(params)
+	if err != nil {
+		return nil, err
+	}
+	workDir, _ := params["__work_dir__"].(string)
+	destination, _ := params["destination"].(string)
+	if destination == "" {
+		destination = filepath.Join(filepath.Dir(archive), archiveStem(archive))
+	}
+	dest := destination
+	if !filepath.IsAbs(dest) {
+		dest = filepath.Join(workDir, destination)
+	}
+	overwrite := false
+	if v, ok := params["overwrite"].(bool); ok {
+		overwrite = v
+	}
+	list, _ := params["files"].([]interface{})
+	var only []string
+	for _, item := range list {
+		if name, ok := item.(string); ok && name != "" {
+			only = append(only, name)
+		}
+	}
+	selected := func(name string) bool {
+		if len(only) == 0 {
+			return true
+		}
+		name = strings.TrimSuffix(path.Clean(strings.ReplaceAll(name, `\`, "/")), "/")
+		for _, prefix := range only {
+			prefix = strings.TrimSuffix(path.Clean(prefix), "/")
+			if name == prefix || strings.HasPrefix(name, prefix+"/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	// 第一遍只检查：路径、安全策略、已存在的文件与大小上限，任何一项不通过都不写入
+	result := &ExtractArchiveResult{Archive: archive, Destination: destination, Extracted: []string{}}
+	var files int
+	var total int64
+	err = walkArchive(archivePath, format, func(f archiveFile) (bool, error) {
+		if !selected(f.Name) {
+			return true, nil
+		}
+		target, err := archiveEntryPath(dest, f.Name)
+		if err != nil {
+			return false, err
+		}
+		if f.IsDir || !f.regular {
+			return true, nil
+		}
+		if err := checkSecurityPolicy(policyWrite, target, workDir); err != nil {
+			return false, err
+		}
+		if _, err := statWorkspaceFile(target); err == nil && !overwrite {
+			return false, fmt.Errorf("%s already exists, set overwrite to true to replace it", displayArchivePath(workDir, target))
+		}
+		files++
+		total += f.Size
+		if files > maxExtractFiles {
+			return false, fmt.Errorf("archive has more than %d files", maxExtractFiles)
+		}
+		if total > maxExtractBytes {
+			return false, fmt.Errorf("archive expands to more than %s", formatFileSize(maxExtractBytes))
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if files == 0 && len(only) > 0 {
+		return nil, fmt.Errorf("no archive entries match %s", strings.Join(only, ", "))
+	}
+
+	// 第二遍写入；实际大小以读取的字节数为准，不信任压缩包中记录的大小
+	err = walkArchive(archivePath, format, func(f archiveFile) (bool, error) {
+		if !selected(f.Name) {
+			return true, nil
+		}
+		target, err := archiveEntryPath(dest, f.Name)
+		if err != nil {
+			return false, err
+		}
+		switch {
+		case f.IsDir:
+			return true, makeWorkspaceDir(target)
+		case !f.regular:
+			result.Skipped = append(result.Skipped, f.Name)
+			return true, nil
+		}
+
+		reader, err := f.open()
+		if err != nil {
+			return false, fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		data, err := io.ReadAll(io.LimitReader(reader, maxExtractBytes-result.BytesTotal+1))
+		reader.Close()
+		if err != nil {
+			return false, fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		result.BytesTotal += int64(len(data))
+		if result.BytesTotal > maxExtractBytes {
+			return false, fmt.Errorf("archive expands to more than %s", formatFileSize(maxExtractBytes))
+		}
+
+		if err := makeWorkspaceDir(filepath.Dir(target)); err != nil {
+			return false, fmt.Errorf("failed to create directory: %w", err)
+		}
+		mode := f.mode
+		if mode&0600 != 0600 {
+			mode |= 0600
+		}
+		if err := writeWorkspaceFile(target, data, mode); err != nil {
+			return false, fmt.Errorf("failed to write %s: %w", target, err)
+		}
+		result.Extracted = append(result.Extracted, displayArchivePath(workDir, target))
+		return true, nil
+	})
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// displayArchivePath 工作目录下的文件显示为相对路径
+func displayArchivePath(workDir, path string) string {
+	if rel, err := filepath.Rel(workDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+// NewListArchiveTool 创建list_archive工具
+func NewListArchiveTool() Tool {
+	schema := ToolSchema{
+		Name:        "list_archive",
+		Description: "List the contents of a .zip, .tar, .tar.gz or .tgz archive (names, sizes, directories and links) without extracting it. At most 1000 entries are returned.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Path of the archive, relative to the workspace root or absolute",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+			"required": []string{"path"},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: listArchiveFunction,
+	}
+}
+
+// NewExtractArchiveTool 创建extract_archive工具
+func NewExtractArchiveTool() Tool {
+	schema := ToolSchema{
+		Name:        "extract_archive",
+		Description: "Extract a .zip, .tar, .tar.gz or .tgz archive into the workspace. Entries with absolute paths or paths leading outside the destination are refused, symbolic links and special files are skipped, and nothing is written if any file already exists (unless overwrite is true) or the archive expands to more than 10000 files or 512MB. Use list_archive first to see what it contains.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Path of the archive, relative to the workspace root or absolute",
+				},
+				"destination": map[string]interface{}{
+					"type":        "string",
+					"description": "Directory to extract into, relative to the workspace root. Defaults to a directory named after the archive next to it.",
+				},
+				"files": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Only extract these entries or directories (names as shown by list_archive). Defaults to everything.",
+				},
+				"overwrite": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Replace files that already exist. Defaults to false.",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+			"required": []string{"path"},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: extractArchiveFunction,
+	}
+}
@@ -0,0 +1,449 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 依赖审计的默认值与上限
+const (
+	defaultAuditTimeout = 300 // 秒
+	maxAuditTimeout     = 1800
+	maxAuditOutputBytes = 10000
+)
+
+// dependencyAuditors 支持的审计工具
+var dependencyAuditors = []string{"govulncheck", "npm", "pip-audit"}
+
+// Vulnerability 一条依赖漏洞
+type Vulnerability struct {
+	ID           string   `json:"id"`
+	Aliases      []string `json:"aliases,omitempty"`
+	Package      string   `json:"package"`
+	Version      string   `json:"version,omitempty"`
+	Affected     string   `json:"affected,omitempty"` // 受影响的版本范围（npm 不报告安装的版本）
+	FixedVersion string   `json:"fixed_version,omitempty"`
+	Severity     string   `json:"severity,omitempty"`
+	Summary      string   `json:"summary,omitempty"`
+	URL          string   `json:"url,omitempty"`
+	Reachable    bool     `json:"reachable,omitempty"` // govulncheck：代码调用了有漏洞的函数
+}
+
+// AuditDependenciesResult audit_dependencies工具的返回结果
+type AuditDependenciesResult struct {
+	Auditor         string          `json:"auditor"`
+	Command         string          `json:"command"`
+	Directory       string          `json:"directory"`
+	Count           int             `json:"count"`
+	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+	Duration        string          `json:"duration"`
+	ExitCode        int             `json:"exit_code"`
+	Output          string          `json:"output,omitempty"` // 无法解析审计结果时的原始输出
+}
+
+// auditDependenciesFunction 依赖审计工具函数
+func auditDependenciesFunction(params map[string]interface{}) (interface{}, error) {
+	target, _ := params["target"].(string)
+	auditor, _ := params["auditor"].(string)
+	workDir, _ := params["__work_dir__"].(string)
+	if workDir == "" {
+		workDir = "."
+	}
+	workDir, _ = filepath.Abs(workDir)
+
+	timeout := defaultAuditTimeout
+	if t, ok := intParam(params, "timeout_seconds"); ok && t > 0 {
+		timeout = t
+	}
+	if timeout > maxAuditTimeout {
+		timeout = maxAuditTimeout
+	}
+
+	projectDir := workDir
+	if target != "" {
+		projectDir = target
+		if !filepath.IsAbs(projectDir) {
+			projectDir = filepath.Join(workDir, target)
+		}
+		info, err := os.Stat(projectDir)
+		if err != nil {
+			return nil, fmt.Errorf("audit target not found: %s", target)
+		}
+		if !info.IsDir() {
+			projectDir = filepath.Dir(projectDir)
+		}
+	}
+	if auditor == "" {
+		auditor = detectAuditor(projectDir)
+	}
+	if auditor == "" {
+		return nil, fmt.Errorf("cannot detect the dependency auditor for %q (no go.mod, package.json, requirements.txt or pyproject.toml): pass auditor (one of %s)", projectDir, strings.Join(dependencyAuditors, ", "))
+	}
+
+	args, err := auditCommand(auditor, projectDir)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := exec.LookPath(args[0]); err != nil {
+		return nil, fmt.Errorf("%s is not installed: %s", args[0], auditorInstallHint(auditor))
+	}
+	command := strings.Join(quoteArgs(args), " ")
+
+	// 试运行时只记录命令；审计需要联网查询漏洞数据库
+	explanation, _ := params["explanation"].(string)
+	if planCommand("audit_dependencies", command, explanation) {
+		return nil, errNotRunInDryRun(command)
+	}
+	if !approveCommand("audit_dependencies", command, explanation) {
+		return nil, fmt.Errorf("the user rejected the command: %s", command)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = projectDir
+	cmd.Env = buildCommandEnv(params)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	start := time.Now()
+	runErr := cmd.Run()
+	result := &AuditDependenciesResult{
+		Auditor:   auditor,
+		Command:   command,
+		Directory: projectDir,
+		Duration:  time.Since(start).Round(time.Millisecond).String(),
+	}
+	if rel, err := filepath.Rel(workDir, projectDir); err == nil && !strings.HasPrefix(rel, "..") {
+		result.Directory = rel
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("%s timed out after %ds", command, timeout)
+	}
+	if runErr != nil {
+		exitError, ok := runErr.(*exec.ExitError)
+		if !ok {
+			return nil, fmt.Errorf("failed to run %s: %w", command, runErr)
+		}
+		// 发现漏洞时审计工具以非零退出码结束
+		result.ExitCode = exitError.ExitCode()
+	}
+
+	vulns, parseErr := parseAuditOutput(auditor, stdout.Bytes())
+	if parseErr != nil {
+		output := strings.TrimSpace(stderr.String() + "\n" + stdout.String())
+		if len(output) > maxAuditOutputBytes {
+			output = "...\n" + output[len(output)-maxAuditOutputBytes:]
+		}
+		if result.ExitCode != 0 {
+			return nil, fmt.Errorf("%s failed with exit code %d:\n%s", command, result.ExitCode, output)
+		}
+		result.Output = output
+	}
+	sort.SliceStable(vulns, func(i, j int) bool {
+		if vulns[i].Package != vulns[j].Package {
+			return vulns[i].Package < vulns[j].Package
+		}
+		return vulns[i].ID < vulns[j].ID
+	})
+	result.Vulnerabilities = vulns
+	result.Count = len(vulns)
+	return result, nil
+}
+
+// detectAuditor 根据项目文件选择审计工具
+func detectAuditor(dir string) string {
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(dir, name))
+		return err == nil
+	}
+	switch {
+	case exists("go.mod"):
+		return "govulncheck"
+	case exists("package.json"):
+		return "npm"
+	case exists("requirements.txt"), exists("pyproject.toml"), exists("setup.py"):
+		return "pip-audit"
+	}
+	return ""
+}
+
+// auditCommand 构建输出 JSON 的审计命令
+func auditCommand(auditor, projectDir string) ([]string, error) {
+	switch auditor {
+	case "govulncheck":
+		return []string{"govulncheck", "-json", "./..."}, nil
+	case "npm":
+		return []string{"npm", "audit", "--json"}, nil
+	case "pip-audit":
+		if _, err := os.Stat(filepath.Join(projectDir, "requirements.txt")); err == nil {
+			return []string{"pip-audit", "-f", "json", "-r", "requirements.txt"}, nil
+		}
+		return []string{"pip-audit", "-f", "json", "."}, nil
+	}
+	return nil, fmt.Errorf("unknown dependency auditor %q (expected one of %s)", auditor, strings.Join(dependencyAuditors, ", "))
+}
+
+// auditorInstallHint 审计工具的安装方式
+func auditorInstallHint(auditor string) string {
+	switch auditor {
+	case "govulncheck":
+		return "install it with `go install golang.org/x/vuln/cmd/govulncheck@latest`"
+	case "pip-audit":
+		return "install it with `pip install pip-audit`"
+	}
+	return "install Node.js and npm"
+}
+
+// parseAuditOutput 解析审计工具的 JSON 输出
+func parseAuditOutput(auditor string, output []byte) ([]Vulnerability, error) {
+	switch auditor {
+	case "govulncheck":
+		return parseGovulncheck(output)
+	case "npm":
+		return parseNpmAudit(output)
+	case "pip-audit":
+		return parsePipAudit(output)
+	}
+	return nil, fmt.Errorf("unknown dependency auditor %q", auditor)
+}
+
+// parseGovulncheck 解析 govulncheck -json 的消息流：osv 消息描述漏洞，finding 消息给出受影响的模块与调用路径
+func parseGovulncheck(output []byte) ([]Vulnerability, error) {
+	type osvEntry struct {
+		ID       string   `json:"id"`
+		Aliases  []string `json:"aliases"`
+		Summary  string   `json:"summary"`
+		Details  string   `json:"details"`
+		Database struct {
+			URL string `json:"url"`
+		} `json:"database_specific"`
+	}
+	type message struct {
+		OSV     *osvEntry `json:"osv"`
+		Finding *struct {
+			OSV          string `json:"osv"`
+			FixedVersion string `json:"fixed_version"`
+			Trace        []struct {
+				Module   string `json:"module"`
+				Version  string `json:"version"`
+				Function string `json:"function"`
+			} `json:"trace"`
+		} `json:"finding"`
+	}
+
+	entries := make(map[string]*osvEntry)
+	found := make(map[string]*Vulnerability)
+	var order []string
+	decoder := json.NewDecoder(bytes.NewReader(output))
+	for {
+		var msg message
+		if err := decoder.Decode(&msg); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse govulncheck output: %w", err)
+		}
+		if msg.OSV != nil {
+			entries[msg.OSV.ID] = msg.OSV
+		}
+		if msg.Finding == nil || len(msg.Finding.Trace) == 0 {
+			continue
+		}
+		finding := msg.Finding
+		key := finding.OSV + "\x00" + finding.Trace[0].Module
+		vuln := found[key]
+		if vuln == nil {
+			vuln = &Vulnerability{
+				ID:           finding.OSV,
+				Package:      finding.Trace[0].Module,
+				Version:      finding.Trace[0].Version,
+				FixedVersion: finding.FixedVersion,
+			}
+			found[key] = vuln
+			order = append(order, key)
+		}
+		// 调用路径的第一帧是有漏洞的符号，只有代码调用了它时才带函数名
+		if finding.Trace[0].Function != "" {
+			vuln.Reachable = true
+		}
+	}
+
+	vulns := make([]Vulnerability, 0, len(order))
+	for _, key := range order {
+		vuln := *found[key]
+		if entry := entries[vuln.ID]; entry != nil {
+			vuln.Aliases = entry.Aliases
+			vuln.Summary = entry.Summary
+			if vuln.Summary == "" {
+				vuln.Summary = firstLine(entry.Details)
+			}
+			vuln.URL = entry.Database.URL
+		}
+		if vuln.URL == "" {
+			vuln.URL = "https://pkg.go.dev/vuln/" + vuln.ID
+		}
+		vulns = append(vulns, vuln)
+	}
+	return vulns, nil
+}
+
+// parseNpmAudit 解析 npm audit --json（npm 7 及以上）的输出
+func parseNpmAudit(output []byte) ([]Vulnerability, error) {
+	var report struct {
+		Error *struct {
+			Summary string `json:"summary"`
+		} `json:"error"`
+		Vulnerabilities map[string]struct {
+			Name         string            `json:"name"`
+			Severity     string            `json:"severity"`
+			Range        string            `json:"range"`
+			Via          []json.RawMessage `json:"via"`
+			FixAvailable json.RawMessage   `json:"fixAvailable"`
+		} `json:"vulnerabilities"`
+	}
+	if err := json.Unmarshal(output, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse npm audit output: %w", err)
+	}
+	if report.Error != nil {
+		return nil, fmt.Errorf("npm audit failed: %s", report.Error.Summary)
+	}
+
+	var vulns []Vulnerability
+	for name, pkg := range report.Vulnerabilities {
+		fixed := ""
+		var fix struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		}
+		if json.Unmarshal(pkg.FixAvailable, &fix) == nil && fix.Name != "" {
+			fixed = fix.Name + "@" + fix.Version
+		}
+		for _, raw := range pkg.Via {
+			// via 为字符串时表示漏洞来自依赖的另一个包，在那个包的条目中报告
+			var advisory struct {
+				Source   json.Number `json:"source"`
+				Title    string      `json:"title"`
+				URL      string      `json:"url"`
+				Severity string      `json:"severity"`
+				Range    string      `json:"range"`
+			}
+			if json.Unmarshal(raw, &advisory) != nil {
+				continue
+			}
+			id := advisory.URL[strings.LastIndex(advisory.URL, "/")+1:]
+			if id == "" {
+				id = advisory.Source.String()
+			}
+			vulns = append(vulns, Vulnerability{
+				ID:           id,
+				Package:      name,
+				Affected:     advisory.Range,
+				FixedVersion: fixed,
+				Severity:     advisory.Severity,
+				Summary:      advisory.Title,
+				URL:          advisory.URL,
+			})
+		}
+	}
+	return vulns, nil
+}
+
+// parsePipAudit 解析 pip-audit -f json 的输出（新版本为对象，旧版本为依赖列表）
+func parsePipAudit(output []byte) ([]Vulnerability, error) {
+	type dependency struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+		Vulns   []struct {
+			ID          string   `json:"id"`
+			FixVersions []string `json:"fix_versions"`
+			Aliases     []string `json:"aliases"`
+			Description string   `json:"description"`
+		} `json:"vulns"`
+	}
+	var report struct {
+		Dependencies []dependency `json:"dependencies"`
+	}
+	trimmed := bytes.TrimSpace(output)
+	var err error
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		err = json.Unmarshal(trimmed, &report.Dependencies)
+	} else {
+		err = json.Unmarshal(trimmed, &report)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse pip-audit output: %w", err)
+	}
+
+	var vulns []Vulnerability
+	for _, dep := range report.Dependencies {
+		for _, v := range dep.Vulns {
+			vuln := Vulnerability{
+				ID:      v.ID,
+				Aliases: v.Aliases,
+				Package: dep.Name,
+				Version: dep.Version,
+				Summary: firstLine(v.Description),
+				URL:     "https://osv.dev/vulnerability/" + v.ID,
+			}
+			if len(v.FixVersions) > 0 {
+				vuln.FixedVersion = v.FixVersions[0]
+			}
+			vulns = append(vulns, vuln)
+		}
+	}
+	return vulns, nil
+}
+
+// firstLine 返回文本的第一行
+func firstLine(text string) string {
+	text = strings.TrimSpace(text)
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		return strings.TrimSpace(text[:i])
+	}
+	return text
+}
+
+// NewAuditDependenciesTool 创建audit_dependencies工具
+func NewAuditDependenciesTool() Tool {
+	schema := ToolSchema{
+		Name:        "audit_dependencies",
+		Description: "Check the project's dependencies for known vulnerabilities with govulncheck (Go), npm audit (Node.js) or pip-audit (Python), detected from the project files. Returns one entry per vulnerability with the affected package and version, the fixed version, severity and summary; for Go, reachable tells whether the code actually calls the vulnerable function. Use it to find dependencies to upgrade, then re-run it to verify the fix.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"target": map[string]interface{}{
+					"type":        "string",
+					"description": "Project directory to audit, relative to the workspace root. Defaults to the workspace root.",
+				},
+				"auditor": map[string]interface{}{
+					"type":        "string",
+					"description": "Audit tool, only needed when detection fails",
+					"enum":        dependencyAuditors,
+				},
+				"timeout_seconds": map[string]interface{}{
+					"type":        "integer",
+					"description": "Timeout for the audit in seconds (default 300, max 1800)",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: auditDependenciesFunction,
+	}
+}
@@ -0,0 +1,207 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// shadowAuthor 影子分支提交的作者与提交者
+const (
+	shadowAuthorName  = "openCursor"
+	shadowAuthorEmail = "opencursor@localhost"
+)
+
+// ShadowBranch 将每次修改工作区的工具调用自动提交到独立的 opencursor/<session> 分支。
+// 提交使用单独的索引文件，不会改动当前分支、HEAD、用户的暂存区或工作区
+type ShadowBranch struct {
+	mu      sync.Mutex
+	workDir string
+	ref     string // refs/heads/opencursor/<session>
+	index   string // 影子分支专用的索引文件
+	parent  string // 影子分支当前的提交（仓库还没有提交时为空）
+	commits int    // 本次会话新增的提交数
+}
+
+// NewShadowBranch 为会话创建（或继续）影子分支，并先提交一次会话开始前工作区的状态，
+// 之后每一步的提交都只包含该步的改动
+func NewShadowBranch(workDir, sessionID string) (*ShadowBranch, error) {
+	if _, err := runGit(workDir, "rev-parse", "--is-inside-work-tree"); err != nil {
+		return nil, fmt.Errorf("auto-commit requires a git repository: %w", err)
+	}
+	branch := "opencursor/" + sessionID
+	if _, err := runGit(workDir, "check-ref-format", "--branch", branch); err != nil {
+		return nil, fmt.Errorf("invalid shadow branch name: %s", branch)
+	}
+	indexPath, err := runGit(workDir, "rev-parse", "--path-format=absolute", "--git-path", "opencursor-"+sessionID+".index")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &ShadowBranch{
+		workDir: workDir,
+		ref:     "refs/heads/" + branch,
+		index:   strings.TrimSpace(indexPath),
+	}
+
+	// 继续已有的影子分支，否则从 HEAD 开始
+	if commit, err := runGit(workDir, "rev-parse", "--verify", "--quiet", s.ref); err == nil {
+		s.parent = strings.TrimSpace(commit)
+	} else if commit, err := runGit(workDir, "rev-parse", "--verify", "--quiet", "HEAD"); err == nil {
+		s.parent = strings.TrimSpace(commit)
+	}
+
+	// 索引从父提交开始，git add -A 之后只记录差异
+	os.Remove(s.index)
+	if s.parent != "" {
+		if _, err := s.git("read-tree", s.parent); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := s.commit("Session start: working tree before agent edits"); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Branch 影子分支名
+func (s *ShadowBranch) Branch() string {
+	return strings.TrimPrefix(s.ref, "refs/heads/")
+}
+
+// Commits 本次会话在影子分支上新增的提交数
+func (s *ShadowBranch) Commits() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.commits
+}
+
+// Record 工具调用后提交工作区的改动，没有改动时不提交
+func (s *ShadowBranch) Record(toolName string, params map[string]interface{}) error {
+	subject := toolName
+	for _, key := range []string{"target_file", "file_path", "path", "command"} {
+		if value, ok := params[key].(string); ok && value != "" {
+			subject += ": " + value
+			break
+		}
+	}
+	subject = strings.Join(strings.Fields(subject), " ")
+	if len(subject) > 72 {
+		subject = subject[:69] + "..."
+	}
+
+	message := subject
+	if explanation, _ := params["explanation"].(string); explanation != "" {
+		message += "\n\n" + explanation
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.commit(message)
+	return err
+}
+
+// Checkpoint 将工作区当前状态提交为检查点，返回影子分支的当前提交（没有改动时为上一个提交）
+func (s *ShadowBranch) Checkpoint(message string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.commit(message); err != nil {
+		return "", err
+	}
+	return s.parent, nil
+}
+
+// commit 将工作区快照提交到影子分支（调用方持有锁，或在构造期间调用）
+func (s *ShadowBranch) commit(message string) (bool, error) {
+	// 遵循 .gitignore，与 git add -A 的范围一致
+	if _, err := s.git("add", "--all", "--", "."); err != nil {
+		return false, err
+	}
+	tree, err := s.git("write-tree")
+	if err != nil {
+		return false, err
+	}
+	tree = strings.TrimSpace(tree)
+
+	args := []string{"commit-tree", tree, "-m", message}
+	if s.parent != "" {
+		parentTree, err := s.git("rev-parse", s.parent+"^{tree}")
+		if err != nil {
+			return false, err
+		}
+		if strings.TrimSpace(parentTree) == tree {
+			return false, nil
+		}
+		args = append(args, "-p", s.parent)
+	}
+	commit, err := s.git(args...)
+	if err != nil {
+		return false, err
+	}
+	commit = strings.TrimSpace(commit)
+
+	if _, err := s.git("update-ref", "-m", "opencursor: auto-commit", s.ref, commit); err != nil {
+		return false, err
+	}
+	s.parent = commit
+	s.commits++
+	return true, nil
+}
+
+// git 使用影子索引执行git命令
+func (s *ShadowBranch) git(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = s.workDir
+	cmd.Env = append(os.Environ(),
+		"GIT_INDEX_FILE="+s.index,
+		"GIT_AUTHOR_NAME="+shadowAuthorName,
+		"GIT_AUTHOR_EMAIL="+shadowAuthorEmail,
+		"GIT_COMMITTER_NAME="+shadowAuthorName,
+		"GIT_COMMITTER_EMAIL="+shadowAuthorEmail,
+	)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s failed: %s", args[0], msg)
+	}
+	return stdout.String(), nil
+}
+
+// Close 删除影子索引文件，分支保留
+func (s *ShadowBranch) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	os.Remove(s.index)
+}
+
+// isReadOnlyTool 工具是否为只读工具
+func isReadOnlyTool(name string) bool {
+	for _, readOnly := range readOnlyTools {
+		if readOnly == name {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEditTool 工具是否为修改文件的编辑工具（如 search_replace、write_file）
+func IsEditTool(name string) bool {
+	if isReadOnlyTool(name) {
+		return false
+	}
+	for _, edit := range editTools {
+		if edit == name {
+			return true
+		}
+	}
+	return false
+}
\ No newline at end of file
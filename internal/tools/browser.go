@@ -0,0 +1,543 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// browserTimeout 单次浏览器操作的超时时间
+const browserTimeout = 30 * time.Second
+
+// maxSnapshotChars 快照文本的最大长度，防止页面内容撑爆上下文
+const maxSnapshotChars = 20000
+
+// BrowserParams browser工具的参数
+type BrowserParams struct {
+	Action      string `json:"action"` // "navigate", "snapshot" or "screenshot"
+	URL         string `json:"url,omitempty"`
+	Format      string `json:"format,omitempty"` // "text" or "accessibility"
+	OutputFile  string `json:"output_file,omitempty"`
+	Explanation string `json:"explanation,omitempty"`
+}
+
+// BrowserResult browser工具的返回结果
+type BrowserResult struct {
+	Action     string `json:"action"`
+	URL        string `json:"url"`
+	Title      string `json:"title,omitempty"`
+	Format     string `json:"format,omitempty"`
+	Snapshot   string `json:"snapshot,omitempty"`
+	Truncated  bool   `json:"truncated,omitempty"`
+	OutputFile string `json:"output_file,omitempty"`
+	Message    string `json:"message"`
+}
+
+// browserState 记录最近一次导航的页面，snapshot/screenshot可省略url
+var browserState struct {
+	mu         sync.Mutex
+	currentURL string
+}
+
+// browserPath 浏览器可执行文件路径，为空时自动查找
+var browserPath string
+
+// SetBrowserPath 设置headless浏览器可执行文件路径
+func SetBrowserPath(path string) {
+	browserPath = path
+}
+
+// findBrowser 查找可用的Chromium/Chrome可执行文件
+func findBrowser() (string, error) {
+	if browserPath != "" {
+		return browserPath, nil
+	}
+	if env := os.Getenv("CHROME_PATH"); env != "" {
+		return env, nil
+	}
+
+	candidates := []string{"chromium", "chromium-browser", "google-chrome", "google-chrome-stable", "chrome"}
+	for _, name := range candidates {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+
+	// 常见的固定安装位置
+	var knownPaths []string
+	switch runtime.GOOS {
+	case "darwin":
+		knownPaths = []string{
+			"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+			"/Applications/Chromium.app/Contents/MacOS/Chromium",
+		}
+	case "windows":
+		knownPaths = []string{
+			"C:\\Program Files\\Google\\Chrome\\Application\\chrome.exe",
+			"C:\\Program Files (x86)\\Google\\Chrome\\Application\\chrome.exe",
+		}
+	}
+	for _, path := range knownPaths {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+
+	return "", fmt.Errorf("no Chromium/Chrome executable found; install one or set CHROME_PATH")
+}
+
+// resolveBrowserURL 将工作区内的文件路径转换为file:// URL
+func resolveBrowserURL(target, workDir string) (string, error) {
+	if strings.Contains(target, "://") || strings.HasPrefix(target, "about:") || strings.HasPrefix(target, "data:") {
+		return target, nil
+	}
+
+	path := target
+	if !filepath.IsAbs(path) && workDir != "" {
+		path = filepath.Join(workDir, path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("url is neither a URL nor an existing file: %s", target)
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(absPath)}).String(), nil
+}
+
+// runBrowser 以headless模式运行浏览器
+func runBrowser(args ...string) ([]byte, error) {
+	browser, err := findBrowser()
+	if err != nil {
+		return nil, err
+	}
+
+	baseArgs := []string{"--headless=new", "--disable-gpu", "--hide-scrollbars", "--virtual-time-budget=5000"}
+	if runtime.GOOS == "linux" && os.Geteuid() == 0 {
+		// 以root运行时Chromium需要关闭沙箱
+		baseArgs = append(baseArgs, "--no-sandbox")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), browserTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, browser, append(baseArgs, args...)...)
+	output, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("browser timed out after %s", browserTimeout)
+	}
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("browser failed: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("browser failed: %w", err)
+	}
+	return output, nil
+}
+
+// browserFunction headless浏览器工具函数
+func browserFunction(params map[string]interface{}) (interface{}, error) {
+	// 试运行时不启动浏览器
+	if IsDryRun() {
+		return nil, fmt.Errorf("the browser tool is not available in a dry run")
+	}
+
+	// 解析参数
+	action, ok := params["action"].(string)
+	if !ok || action == "" {
+		return nil, fmt.Errorf("action is required")
+	}
+
+	target, _ := params["url"].(string)
+	format, _ := params["format"].(string)
+	outputFile, _ := params["output_file"].(string)
+	workDir, _ := params["__work_dir__"].(string)
+
+	browserState.mu.Lock()
+	defer browserState.mu.Unlock()
+
+	// 确定目标页面
+	pageURL := browserState.currentURL
+	if target != "" {
+		resolved, err := resolveBrowserURL(target, workDir)
+		if err != nil {
+			return nil, err
+		}
+		pageURL = resolved
+	}
+	if pageURL == "" {
+		return nil, fmt.Errorf("url is required (no page has been navigated to yet)")
+	}
+
+	result := &BrowserResult{
+		Action: action,
+		URL:    pageURL,
+	}
+
+	switch action {
+	case "navigate", "snapshot":
+		if format == "" {
+			format = "text"
+		}
+		if format != "text" && format != "accessibility" {
+			return nil, fmt.Errorf("invalid format: %s (expected 'text' or 'accessibility')", format)
+		}
+
+		output, err := runBrowser("--dump-dom", pageURL)
+		if err != nil {
+			return nil, err
+		}
+		browserState.currentURL = pageURL
+
+		doc, err := html.Parse(strings.NewReader(string(output)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse rendered page: %w", err)
+		}
+
+		var snapshot string
+		if format == "accessibility" {
+			snapshot = accessibilitySnapshot(doc)
+		} else {
+			snapshot = textSnapshot(doc)
+		}
+		if len(snapshot) > maxSnapshotChars {
+			snapshot = snapshot[:maxSnapshotChars]
+			result.Truncated = true
+		}
+
+		result.Title = pageTitle(doc)
+		result.Format = format
+		result.Snapshot = snapshot
+		result.Message = fmt.Sprintf("Rendered %s", pageURL)
+
+	case "screenshot":
+		if outputFile == "" {
+			outputFile = "screenshot.png"
+		}
+		if !filepath.IsAbs(outputFile) && workDir != "" {
+			outputFile = filepath.Join(workDir, outputFile)
+		}
+		if err := checkSecurityPolicy(policyWrite, outputFile, workDir); err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(outputFile), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create directory: %w", err)
+		}
+
+		if _, err := runBrowser("--window-size=1280,800", "--screenshot="+outputFile, pageURL); err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(outputFile); err != nil {
+			return nil, fmt.Errorf("browser did not produce a screenshot: %w", err)
+		}
+		browserState.currentURL = pageURL
+
+		result.OutputFile = outputFile
+		result.Message = fmt.Sprintf("Screenshot saved to %s", outputFile)
+
+	default:
+		return nil, fmt.Errorf("invalid action: %s (expected 'navigate', 'snapshot' or 'screenshot')", action)
+	}
+
+	return result, nil
+}
+
+// pageTitle 提取页面标题
+func pageTitle(doc *html.Node) string {
+	var title string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if title != "" {
+			return
+		}
+		if n.Type == html.ElementNode && n.Data == "title" {
+			title = strings.TrimSpace(nodeText(n))
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return title
+}
+
+// isHiddenElement 判断元素是否不参与页面可见内容
+func isHiddenElement(n *html.Node) bool {
+	switch n.Data {
+	case "script", "style", "noscript", "template", "head":
+		return true
+	}
+	for _, attr := range n.Attr {
+		if attr.Key == "hidden" || (attr.Key == "aria-hidden" && attr.Val == "true") {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeText 获取节点下所有可见文本
+func nodeText(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+			return
+		}
+		if n.Type == html.ElementNode && n.Data != "title" && isHiddenElement(n) {
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// textSnapshot 生成页面可见文本快照（块级元素分行）
+func textSnapshot(doc *html.Node) string {
+	blockElements := map[string]bool{
+		"p": true, "div": true, "section": true, "article": true, "header": true, "footer": true,
+		"li": true, "tr": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+		"br": true, "pre": true, "blockquote": true, "form": true, "nav": true, "main": true, "table": true,
+	}
+	// 这些行内元素前后补空格，避免相邻控件文本粘连
+	separatedElements := map[string]bool{
+		"a": true, "button": true, "label": true, "select": true, "td": true, "th": true, "span": true,
+	}
+
+	var sb strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+			return
+		}
+		if n.Type == html.ElementNode {
+			if isHiddenElement(n) {
+				return
+			}
+			if blockElements[n.Data] {
+				sb.WriteString("\n")
+			} else if separatedElements[n.Data] {
+				sb.WriteString(" ")
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if n.Type == html.ElementNode && blockElements[n.Data] {
+			sb.WriteString("\n")
+		} else if n.Type == html.ElementNode && separatedElements[n.Data] {
+			sb.WriteString(" ")
+		}
+	}
+	walk(doc)
+
+	var lines []string
+	for _, line := range strings.Split(sb.String(), "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// getAttr 获取元素属性
+func getAttr(n *html.Node, key string) (string, bool) {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
+
+// elementRole 推断元素的可访问性角色
+func elementRole(n *html.Node) string {
+	if role, ok := getAttr(n, "role"); ok && role != "" {
+		return role
+	}
+
+	switch n.Data {
+	case "a":
+		if _, ok := getAttr(n, "href"); ok {
+			return "link"
+		}
+	case "button":
+		return "button"
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		return "heading"
+	case "img":
+		return "img"
+	case "nav":
+		return "navigation"
+	case "main":
+		return "main"
+	case "header":
+		return "banner"
+	case "footer":
+		return "contentinfo"
+	case "form":
+		return "form"
+	case "ul", "ol":
+		return "list"
+	case "li":
+		return "listitem"
+	case "table":
+		return "table"
+	case "tr":
+		return "row"
+	case "td", "th":
+		return "cell"
+	case "select":
+		return "combobox"
+	case "textarea":
+		return "textbox"
+	case "canvas":
+		return "canvas"
+	case "dialog":
+		return "dialog"
+	case "input":
+		inputType, _ := getAttr(n, "type")
+		switch inputType {
+		case "checkbox":
+			return "checkbox"
+		case "radio":
+			return "radio"
+		case "submit", "button", "reset":
+			return "button"
+		case "range":
+			return "slider"
+		case "hidden":
+			return ""
+		default:
+			return "textbox"
+		}
+	}
+	return ""
+}
+
+// accessibleName 计算元素的可访问名称
+func accessibleName(n *html.Node) string {
+	for _, key := range []string{"aria-label", "alt", "title", "placeholder"} {
+		if value, ok := getAttr(n, key); ok && strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	if n.Data == "input" {
+		if value, ok := getAttr(n, "value"); ok {
+			return value
+		}
+		return ""
+	}
+	return nodeText(n)
+}
+
+// accessibilitySnapshot 生成近似的可访问性树快照
+func accessibilitySnapshot(doc *html.Node) string {
+	// 这些角色的名称来自子节点文本，无需再展开子节点
+	leafRoles := map[string]bool{
+		"link": true, "button": true, "heading": true, "img": true, "textbox": true,
+		"checkbox": true, "radio": true, "slider": true, "combobox": true, "cell": true,
+	}
+
+	var lines []string
+	var walk func(n *html.Node, depth int)
+	walk = func(n *html.Node, depth int) {
+		if n.Type == html.ElementNode {
+			if isHiddenElement(n) {
+				return
+			}
+			if role := elementRole(n); role != "" {
+				line := strings.Repeat("  ", depth) + "- " + role
+				name := accessibleName(n)
+				if leafRoles[role] && name != "" {
+					line += fmt.Sprintf(" %q", name)
+				}
+				if role == "heading" && len(n.Data) == 2 {
+					line += fmt.Sprintf(" [level=%s]", n.Data[1:])
+				}
+				if _, ok := getAttr(n, "disabled"); ok {
+					line += " [disabled]"
+				}
+				if _, ok := getAttr(n, "checked"); ok {
+					line += " [checked]"
+				}
+				lines = append(lines, line)
+				if leafRoles[role] {
+					return
+				}
+				depth++
+			}
+		} else if n.Type == html.TextNode && n.Parent != nil && n.Parent.Type == html.ElementNode {
+			// 顶层的段落文本以text节点展示
+			if text := strings.Join(strings.Fields(n.Data), " "); text != "" && n.Parent.Data != "title" {
+				lines = append(lines, strings.Repeat("  ", depth)+"- text "+fmt.Sprintf("%q", text))
+			}
+			return
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, depth)
+		}
+	}
+	walk(doc, 0)
+
+	return strings.Join(lines, "\n")
+}
+
+// NewBrowserTool 创建browser工具
+func NewBrowserTool() Tool {
+	schema := ToolSchema{
+		Name:        "browser",
+		Description: "Drive a headless Chromium browser to verify that a web page actually renders. Use 'navigate' to load a URL or a workspace HTML file and get a snapshot of the rendered page (after JavaScript runs), 'snapshot' to re-read the current page as visible text or as an accessibility tree (roles and names of links, buttons, headings, inputs), and 'screenshot' to save a PNG of the page to a file. The browser cannot be interacted with (no clicking or typing), and each call reloads the page.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"action": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"navigate", "snapshot", "screenshot"},
+					"description": "The browser action to perform.",
+				},
+				"url": map[string]interface{}{
+					"type":        "string",
+					"description": "The URL to load, or a path to an HTML file in the workspace. Optional for 'snapshot' and 'screenshot', which default to the last navigated page.",
+				},
+				"format": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"text", "accessibility"},
+					"description": "Snapshot format for 'navigate' and 'snapshot'. Defaults to 'text'.",
+				},
+				"output_file": map[string]interface{}{
+					"type":        "string",
+					"description": "Where to save the PNG for 'screenshot', relative to the workspace root. Defaults to 'screenshot.png'.",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+			"required": []string{"action"},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: browserFunction,
+	}
+}
\ No newline at end of file
@@ -0,0 +1,152 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// FileChange 修改类工具对一个文件的改动
+type FileChange struct {
+	Tool    string `json:"tool"`
+	Path    string `json:"path"` // 工作目录内为相对路径，使用 /
+	Before  string `json:"before"`
+	After   string `json:"after"`
+	Created bool   `json:"created,omitempty"`
+	Deleted bool   `json:"deleted,omitempty"`
+}
+
+// changeTargetKeys 修改类工具中指定目标文件的参数
+var changeTargetKeys = []string{"target_file", "file_path"}
+
+// SetChangeObserver 设置文件改动的回调：修改类工具执行后，对目标文件及工具写入的每个内容有变化的文件调用，为nil时不记录
+func (tm *DefaultToolManager) SetChangeObserver(observer func(FileChange)) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.observer = observer
+}
+
+// SetDefaultChangeObserver 设置默认工具管理器的文件改动回调
+func SetDefaultChangeObserver(observer func(FileChange)) {
+	if tm, ok := DefaultRegistry.manager.(*DefaultToolManager); ok {
+		tm.SetChangeObserver(observer)
+	}
+}
+
+// fileSnapshot 工具执行前目标文件的内容
+type fileSnapshot struct {
+	path    string // 绝对路径
+	rel     string
+	content string
+	existed bool
+}
+
+// snapshotTarget 读取修改类工具目标文件的当前内容，没有目标文件参数时返回nil
+func snapshotTarget(name string, params map[string]interface{}, workDir string) *fileSnapshot {
+	if isReadOnlyTool(name) {
+		return nil
+	}
+	target, path := changeTarget(params, workDir)
+	if target == "" {
+		return nil
+	}
+	return newFileSnapshot(path, target, workDir)
+}
+
+// newFileSnapshot 读取文件的当前内容，rel 在文件位于工作目录内时替换为相对路径
+func newFileSnapshot(path, rel, workDir string) *fileSnapshot {
+	snapshot := &fileSnapshot{path: path, rel: rel}
+	if rel, err := filepath.Rel(workDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		snapshot.rel = filepath.ToSlash(rel)
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		snapshot.content = string(data)
+		snapshot.existed = true
+	}
+	return snapshot
+}
+
+// changeTarget 修改类工具的目标文件参数及其绝对路径，没有目标文件参数时为空
+func changeTarget(params map[string]interface{}, workDir string) (string, string) {
+	for _, key := range changeTargetKeys {
+		if value, ok := params[key].(string); ok && value != "" {
+			if filepath.IsAbs(value) {
+				return value, value
+			}
+			return value, filepath.Join(workDir, value)
+		}
+	}
+	return "", ""
+}
+
+// changed 与执行后的内容比较，有变化时返回改动
+func (s *fileSnapshot) changed(tool string) (FileChange, bool) {
+	change := FileChange{Tool: tool, Path: s.rel, Before: s.content}
+	data, err := os.ReadFile(s.path)
+	exists := err == nil
+	change.After = string(data)
+	switch {
+	case !s.existed && !exists:
+		return change, false
+	case !s.existed:
+		change.Created = true
+	case !exists:
+		change.Deleted = true
+	case change.After == s.content:
+		return change, false
+	}
+	return change, true
+}
+
+// changeSet 一次修改类工具调用写入或删除的所有文件及第一次写入前的内容，
+// 覆盖目标文件参数之外的文件（replace_all、rename_symbol、extract_archive、env_file 等）
+type changeSet struct {
+	workDir   string
+	order     []string
+	snapshots map[string]*fileSnapshot // 绝对路径 -> 第一次写入前的内容
+}
+
+// activeChanges 正在执行的修改类工具调用，文件写入前记录到其中每一个
+var activeChanges = struct {
+	sync.Mutex
+	sets map[*changeSet]bool
+}{sets: make(map[*changeSet]bool)}
+
+// trackChanges 开始记录修改类工具调用写入的文件
+func trackChanges(workDir string) *changeSet {
+	set := &changeSet{workDir: workDir, snapshots: make(map[string]*fileSnapshot)}
+	activeChanges.Lock()
+	activeChanges.sets[set] = true
+	activeChanges.Unlock()
+	return set
+}
+
+// stop 停止记录，返回写入过的文件（按第一次写入的顺序）
+func (c *changeSet) stop() []*fileSnapshot {
+	if c == nil {
+		return nil
+	}
+	activeChanges.Lock()
+	delete(activeChanges.sets, c)
+	activeChanges.Unlock()
+	snapshots := make([]*fileSnapshot, 0, len(c.order))
+	for _, path := range c.order {
+		snapshots = append(snapshots, c.snapshots[path])
+	}
+	return snapshots
+}
+
+// noteWorkspaceChange 文件即将被写入或删除，记录它写入前的内容。没有修改类工具在执行时不做任何事
+func noteWorkspaceChange(path string) {
+	activeChanges.Lock()
+	defer activeChanges.Unlock()
+	path = filepath.Clean(path)
+	for set := range activeChanges.sets {
+		if _, ok := set.snapshots[path]; ok {
+			continue
+		}
+		set.snapshots[path] = newFileSnapshot(path, path, set.workDir)
+		set.order = append(set.order, path)
+	}
+}
@@ -0,0 +1,322 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 代码度量的默认值与上限
+const (
+	defaultMetricsLimit = 30
+	maxMetricsLimit     = 200
+	metricsLargestFiles = 10
+)
+
+// FunctionMetrics 一个函数的度量
+type FunctionMetrics struct {
+	Function   string `json:"function"` // 方法为 Type.Method
+	File       string `json:"file"`
+	Line       int    `json:"line"`
+	Complexity int    `json:"complexity"` // 圈复杂度
+	Lines      int    `json:"lines"`
+	Params     int    `json:"params"`
+	MaxNesting int    `json:"max_nesting"` // 控制结构的最大嵌套深度
+}
+
+// FileMetrics 一个文件的度量
+type FileMetrics struct {
+	File      string `json:"file"`
+	Lines     int    `json:"lines"`
+	Size      int    `json:"size"`
+	Functions int    `json:"functions"`
+}
+
+// CodeMetricsResult code_metrics工具的返回结果
+type CodeMetricsResult struct {
+	Path              string            `json:"path"`
+	Files             int               `json:"files"`
+	TotalLines        int               `json:"total_lines"`
+	TotalFunctions    int               `json:"total_functions"`
+	AverageComplexity float64           `json:"average_complexity"`
+	Functions         []FunctionMetrics `json:"functions"`
+	LargestFiles      []FileMetrics     `json:"largest_files"`
+	Truncated         bool              `json:"truncated,omitempty"` // 只列出了排序靠前的函数
+	Errors            []string          `json:"errors,omitempty"`    // 无法解析的文件
+}
+
+// codeMetricsFunction 代码度量工具函数
+func codeMetricsFunction(params map[string]interface{}) (interface{}, error) {
+	target, _ := params["path"].(string)
+	if target == "" {
+		target = "."
+	}
+	sortBy, _ := params["sort_by"].(string)
+	if sortBy == "" {
+		sortBy = "complexity"
+	}
+	if sortBy != "complexity" && sortBy != "lines" {
+		return nil, fmt.Errorf("sort_by must be complexity or lines")
+	}
+	includeTests, _ := params["include_tests"].(bool)
+	minComplexity, _ := intParam(params, "min_complexity")
+	limit := defaultMetricsLimit
+	if l, ok := intParam(params, "limit"); ok && l > 0 {
+		limit = min(l, maxMetricsLimit)
+	}
+	workDir, _ := params["__work_dir__"].(string)
+
+	// 以 /... 结尾时包括子目录，否则只统计这一个包（目录）
+	recursive := strings.HasSuffix(target, "/...") || target == "..."
+	root := strings.TrimSuffix(strings.TrimSuffix(target, "..."), "/")
+	if root == "" {
+		root = "."
+	}
+	if !filepath.IsAbs(root) {
+		root = filepath.Join(workDir, root)
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("path not found: %s", target)
+	}
+
+	var files []string
+	if !info.IsDir() {
+		files = []string{root}
+	} else {
+		var mu sync.Mutex
+		skipDir := func(p string, d fs.DirEntry) bool {
+			name := d.Name()
+			return !recursive || strings.HasPrefix(name, ".") || name == "testdata" || replaceSkipDirs[name]
+		}
+		parallelWalk([]string{root}, skipDir, func(p string, d fs.DirEntry) {
+			mu.Lock()
+			files = append(files, p)
+			mu.Unlock()
+		})
+	}
+	var goFiles []string
+	for _, file := range files {
+		if strings.HasSuffix(file, ".go") && (includeTests || !strings.HasSuffix(file, "_test.go")) {
+			goFiles = append(goFiles, file)
+		}
+	}
+	if len(goFiles) == 0 {
+		return nil, fmt.Errorf("no Go files in %s (code_metrics supports Go; append /... to include subdirectories)", target)
+	}
+	sort.Strings(goFiles)
+
+	result := &CodeMetricsResult{Path: target, Files: len(goFiles)}
+	var functions []FunctionMetrics
+	var fileMetrics []FileMetrics
+	totalComplexity := 0
+	for _, file := range goFiles {
+		rel := file
+		if r, err := filepath.Rel(workDir, file); err == nil && !strings.HasPrefix(r, "..") {
+			rel = filepath.ToSlash(r)
+		}
+		content, err := readWorkspaceFile(file)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", rel, err))
+			continue
+		}
+		fm := FileMetrics{File: rel, Lines: bytes.Count(content, []byte("\n")), Size: len(content)}
+		if len(content) > 0 && content[len(content)-1] != '\n' {
+			fm.Lines++
+		}
+		result.TotalLines += fm.Lines
+
+		fset := token.NewFileSet()
+		parsed, err := parser.ParseFile(fset, file, content, parser.SkipObjectResolution)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", rel, err))
+			fileMetrics = append(fileMetrics, fm)
+			continue
+		}
+		for _, decl := range parsed.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			metrics := functionMetrics(fset, fn)
+			metrics.File = rel
+			fm.Functions++
+			totalComplexity += metrics.Complexity
+			functions = append(functions, metrics)
+		}
+		fileMetrics = append(fileMetrics, fm)
+	}
+
+	result.TotalFunctions = len(functions)
+	if len(functions) > 0 {
+		result.AverageComplexity = roundTo(float64(totalComplexity)/float64(len(functions)), 2)
+	}
+	sort.SliceStable(functions, func(i, j int) bool {
+		a, b := functions[i], functions[j]
+		if sortBy == "lines" && a.Lines != b.Lines {
+			return a.Lines > b.Lines
+		}
+		if a.Complexity != b.Complexity {
+			return a.Complexity > b.Complexity
+		}
+		return a.Lines > b.Lines
+	})
+	result.Functions = []FunctionMetrics{}
+	for _, fn := range functions {
+		if fn.Complexity < minComplexity {
+			continue
+		}
+		if len(result.Functions) == limit {
+			result.Truncated = true
+			break
+		}
+		result.Functions = append(result.Functions, fn)
+	}
+
+	sort.SliceStable(fileMetrics, func(i, j int) bool { return fileMetrics[i].Lines > fileMetrics[j].Lines })
+	result.LargestFiles = fileMetrics[:min(len(fileMetrics), metricsLargestFiles)]
+	return result, nil
+}
+
+// functionMetrics 计算函数的度量。圈复杂度与 gocyclo 相同：1 加上 if、for、range、
+// 非 default 的 case 与 &&、|| 的个数，函数字面量计入所在的函数
+func functionMetrics(fset *token.FileSet, fn *ast.FuncDecl) FunctionMetrics {
+	metrics := FunctionMetrics{
+		Function:   fn.Name.Name,
+		Line:       fset.Position(fn.Pos()).Line,
+		Lines:      fset.Position(fn.End()).Line - fset.Position(fn.Pos()).Line + 1,
+		Complexity: 1,
+	}
+	if fn.Recv != nil && len(fn.Recv.List) > 0 {
+		metrics.Function = receiverName(fn.Recv.List[0].Type) + "." + fn.Name.Name
+	}
+	for _, field := range fn.Type.Params.List {
+		metrics.Params += max(len(field.Names), 1)
+	}
+
+	// 控制结构内部的代码嵌套深度加一，else if 与 if 处于同一层
+	var walk, children func(node ast.Node, depth int)
+	nest := func(node ast.Node, depth int) {
+		metrics.MaxNesting = max(metrics.MaxNesting, depth+1)
+		children(node, depth+1)
+	}
+	children = func(node ast.Node, depth int) {
+		ast.Inspect(node, func(n ast.Node) bool {
+			if n == node {
+				return true
+			}
+			if n != nil {
+				walk(n, depth)
+			}
+			return false
+		})
+	}
+	walk = func(node ast.Node, depth int) {
+		ast.Inspect(node, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.IfStmt:
+				metrics.Complexity++
+				metrics.MaxNesting = max(metrics.MaxNesting, depth+1)
+				if n.Init != nil {
+					walk(n.Init, depth+1)
+				}
+				walk(n.Cond, depth+1)
+				walk(n.Body, depth+1)
+				if elseIf, ok := n.Else.(*ast.IfStmt); ok {
+					walk(elseIf, depth)
+				} else if n.Else != nil {
+					walk(n.Else, depth+1)
+				}
+				return false
+			case *ast.ForStmt, *ast.RangeStmt:
+				metrics.Complexity++
+				nest(n, depth)
+				return false
+			case *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt:
+				nest(n, depth)
+				return false
+			case *ast.CaseClause:
+				if n.List != nil {
+					metrics.Complexity++
+				}
+			case *ast.CommClause:
+				if n.Comm != nil {
+					metrics.Complexity++
+				}
+			case *ast.BinaryExpr:
+				if n.Op == token.LAND || n.Op == token.LOR {
+					metrics.Complexity++
+				}
+			}
+			return true
+		})
+	}
+	walk(fn.Body, 0)
+	return metrics
+}
+
+// receiverName 方法接收者的类型名
+func receiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(t.X)
+	case *ast.IndexExpr:
+		return receiverName(t.X)
+	case *ast.IndexListExpr:
+		return receiverName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+// NewCodeMetricsTool 创建code_metrics工具
+func NewCodeMetricsTool() Tool {
+	schema := ToolSchema{
+		Name:        "code_metrics",
+		Description: "Report code metrics for a Go package: the cyclomatic complexity (gocyclo-style), length, parameter count and nesting depth of its functions, sorted with the most complex first, plus the largest files. Use it to find refactoring candidates without reading the whole codebase.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Package directory or Go file, relative to the workspace root. Append /... to include subdirectories (e.g. internal/... or ./...). Defaults to the workspace root package.",
+				},
+				"sort_by": map[string]interface{}{
+					"type":        "string",
+					"description": "Order of the functions: complexity (default) or lines",
+					"enum":        []string{"complexity", "lines"},
+				},
+				"min_complexity": map[string]interface{}{
+					"type":        "integer",
+					"description": "Only list functions with at least this complexity (gocyclo's -over, commonly 10 or 15)",
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": "Maximum number of functions to list (default 30, max 200)",
+				},
+				"include_tests": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Include _test.go files (default false)",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: codeMetricsFunction,
+	}
+}
@@ -0,0 +1,142 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"openCursor/internal/index"
+)
+
+// CodebaseSearchResult codebase_search工具的返回结果
+type CodebaseSearchResult struct {
+	Query   string              `json:"query"`
+	Results []CodebaseSearchHit `json:"results"`
+	Indexed int                 `json:"indexed_files,omitempty"` // 本次调用前更新索引的文件数
+}
+
+// CodebaseSearchHit 一条检索结果
+type CodebaseSearchHit struct {
+	Path      string  `json:"path"`
+	StartLine int     `json:"start_line"`
+	EndLine   int     `json:"end_line"`
+	Score     float64 `json:"score"`
+	Content   string  `json:"content"`
+}
+
+// indexStore 语义索引的向量存储配置
+var indexStore index.StoreConfig
+
+// codeIndexes 按工作目录缓存打开的索引，会话期间监听文件变化，只重新索引变化的文件
+var (
+	codeIndexes   = make(map[string]*index.Index)
+	codeIndexesMu sync.Mutex
+)
+
+// SetIndexStore 设置语义索引使用的向量存储
+func SetIndexStore(cfg index.StoreConfig) {
+	indexStore = cfg
+}
+
+// codebaseSearchFunction 语义检索工具函数：先增量更新索引，再检索
+func codebaseSearchFunction(params map[string]interface{}) (interface{}, error) {
+	query, _ := params["query"].(string)
+	if query == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+	limit := 10
+	if val, ok := params["limit"].(float64); ok {
+		limit = int(val)
+	}
+	if limit <= 0 || limit > 50 {
+		return nil, fmt.Errorf("limit must be between 1 and 50 (got %d)", limit)
+	}
+	workDir, _ := params["__work_dir__"].(string)
+	if workDir == "" {
+		workDir = "."
+	}
+
+	idx, err := openCodeIndex(workDir)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	stats, err := idx.Refresh(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update code index: %w", err)
+	}
+	results, err := idx.Search(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search code index: %w", err)
+	}
+
+	hits := make([]CodebaseSearchHit, 0, len(results))
+	for _, result := range results {
+		hits = append(hits, CodebaseSearchHit{
+			Path:      result.Path,
+			StartLine: result.StartLine,
+			EndLine:   result.EndLine,
+			Score:     result.Score,
+			Content:   result.Content,
+		})
+	}
+	return &CodebaseSearchResult{Query: query, Results: hits, Indexed: stats.Indexed}, nil
+}
+
+// openCodeIndex 打开工作目录的索引并开始监听文件变化，无法监听时每次检索前按修改时间比对
+func openCodeIndex(workDir string) (*index.Index, error) {
+	codeIndexesMu.Lock()
+	defer codeIndexesMu.Unlock()
+	if idx, ok := codeIndexes[workDir]; ok {
+		return idx, nil
+	}
+	idx, err := index.Open(workDir, embedder, indexStore)
+	if err != nil {
+		return nil, err
+	}
+	idx.Watch()
+	codeIndexes[workDir] = idx
+	return idx, nil
+}
+
+// CloseCodeIndexes 停止监听并关闭打开的索引
+func CloseCodeIndexes() {
+	codeIndexesMu.Lock()
+	defer codeIndexesMu.Unlock()
+	for workDir, idx := range codeIndexes {
+		idx.Close()
+		delete(codeIndexes, workDir)
+	}
+}
+
+// NewCodebaseSearchTool 创建codebase_search工具
+func NewCodebaseSearchTool() Tool {
+	schema := ToolSchema{
+		Name:        "codebase_search",
+		Description: "Semantic search over the codebase: finds the code most relevant to a natural-language query, returned as whole functions, types or classes where possible, even when they share no keywords with it. The index is kept up to date incrementally as files change. Results combine semantic similarity with keyword (BM25) matching, so exact identifiers in the query rank first; use grep_search to find every occurrence of a string.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "What you are looking for, described in natural language, e.g. 'where are API retries configured'.",
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": "Maximum number of snippets to return (1-50). Defaults to 10.",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+			"required": []string{"query"},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: codebaseSearchFunction,
+	}
+}
\ No newline at end of file
@@ -0,0 +1,478 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 覆盖率报告的上限
+const (
+	maxCoverageFiles     = 300
+	maxCoverageFunctions = 500
+)
+
+// coverageFrameworks 支持覆盖率报告的测试框架
+var coverageFrameworks = []string{"go", "pytest"}
+
+// goCoverFuncPattern go tool cover -func 的一行：文件:行号: 函数名 覆盖率
+var goCoverFuncPattern = regexp.MustCompile(`^(.+?):(\d+):\s+(\S+)\s+([\d.]+)%$`)
+
+// FileCoverage 一个文件的语句覆盖率
+type FileCoverage struct {
+	File         string  `json:"file"`
+	Statements   int     `json:"statements"`
+	Covered      int     `json:"covered"`
+	Percent      float64 `json:"percent"`
+	MissingLines string  `json:"missing_lines,omitempty"` // 未覆盖的行，如 "12-15, 20"
+}
+
+// FunctionCoverage 一个函数的语句覆盖率
+type FunctionCoverage struct {
+	File     string  `json:"file"`
+	Function string  `json:"function"`
+	Line     int     `json:"line,omitempty"`
+	Percent  float64 `json:"percent"`
+}
+
+// CoverageReportResult coverage_report工具的返回结果
+type CoverageReportResult struct {
+	Framework string             `json:"framework"`
+	Command   string             `json:"command"`
+	Directory string             `json:"directory"`
+	Passed    bool               `json:"passed"`
+	ExitCode  int                `json:"exit_code"`
+	Failed    []string           `json:"failed,omitempty"`
+	Total     float64            `json:"total_percent"`
+	Files     []FileCoverage     `json:"files"`
+	Functions []FunctionCoverage `json:"functions,omitempty"`
+	Truncated bool               `json:"truncated,omitempty"` // 文件或函数超出上限，只保留覆盖率最低的部分
+	Duration  string             `json:"duration"`
+	Output    string             `json:"output,omitempty"` // 测试失败时输出的尾部
+}
+
+// coverageReportFunction 运行测试并报告覆盖率工具函数
+func coverageReportFunction(params map[string]interface{}) (interface{}, error) {
+	target, _ := params["target"].(string)
+	filter, _ := params["run"].(string)
+	framework, _ := params["framework"].(string)
+	source, _ := params["source"].(string)
+	workDir, _ := params["__work_dir__"].(string)
+	if workDir == "" {
+		workDir = "."
+	}
+	workDir, _ = filepath.Abs(workDir)
+
+	timeout := defaultTestTimeout
+	if t, ok := intParam(params, "timeout_seconds"); ok && t > 0 {
+		timeout = t
+	}
+	if timeout > maxTestTimeout {
+		timeout = maxTestTimeout
+	}
+
+	targetPath := ""
+	if target != "" {
+		targetPath = target
+		if !filepath.IsAbs(targetPath) {
+			targetPath = filepath.Join(workDir, strings.TrimSuffix(target, "/..."))
+		}
+		if _, err := os.Stat(targetPath); err != nil {
+			return nil, fmt.Errorf("test target not found: %s", target)
+		}
+	}
+
+	projectDir, detected := detectTestFramework(targetPath, workDir)
+	if framework == "" {
+		framework = detected
+	}
+	if !containsString(coverageFrameworks, framework) {
+		if framework == "" {
+			return nil, fmt.Errorf("cannot detect the test framework for %q: pass framework (one of %s)", target, strings.Join(coverageFrameworks, ", "))
+		}
+		return nil, fmt.Errorf("coverage reports are not supported for %s (supported: %s); use run_terminal_cmd with the framework's coverage option", framework, strings.Join(coverageFrameworks, ", "))
+	}
+
+	args, err := testCommand(framework, projectDir, target, targetPath, filter)
+	if err != nil {
+		return nil, err
+	}
+	report, err := os.CreateTemp("", "opencursor-coverage-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the coverage report file: %w", err)
+	}
+	report.Close()
+	defer os.Remove(report.Name())
+
+	// 在测试命令中加入覆盖率参数，报告写到临时文件
+	if framework == "go" {
+		args = append(args[:2:2], append([]string{"-coverprofile=" + report.Name()}, args[2:]...)...)
+	} else {
+		cov := "--cov"
+		if source != "" {
+			cov += "=" + source
+		}
+		args = append(args, cov, "--cov-report=json:"+report.Name())
+	}
+	command := strings.Join(quoteArgs(args), " ")
+
+	// 试运行时只记录命令（测试会执行项目代码）
+	explanation, _ := params["explanation"].(string)
+	if planCommand("coverage_report", command, explanation) {
+		return nil, errNotRunInDryRun(command)
+	}
+	if !approveCommand("coverage_report", command, explanation) {
+		return nil, fmt.Errorf("the user rejected the command: %s", command)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = projectDir
+	cmd.Env = buildCommandEnv(params)
+
+	start := time.Now()
+	output, runErr := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("%s timed out after %ds", command, timeout)
+	}
+	result := &CoverageReportResult{
+		Framework: framework,
+		Command:   command,
+		Directory: projectDir,
+		Passed:    runErr == nil,
+		Failed:    failedTests(framework, string(output)),
+		Duration:  time.Since(start).Round(time.Millisecond).String(),
+	}
+	if rel, err := filepath.Rel(workDir, projectDir); err == nil && !strings.HasPrefix(rel, "..") {
+		result.Directory = rel
+	}
+	if runErr != nil {
+		exitError, ok := runErr.(*exec.ExitError)
+		if !ok {
+			return nil, fmt.Errorf("failed to run %s: %w", command, runErr)
+		}
+		result.ExitCode = exitError.ExitCode()
+		text := string(output)
+		if len(text) > maxTestOutputBytes {
+			text = "...\n" + text[len(text)-maxTestOutputBytes:]
+		}
+		result.Output = text
+	}
+
+	// 测试失败时通常仍会写出覆盖率，没有报告才算失败
+	if info, err := os.Stat(report.Name()); err != nil || info.Size() == 0 {
+		if runErr == nil {
+			return nil, fmt.Errorf("%s wrote no coverage report", command)
+		}
+		if framework == "pytest" && bytes.Contains(output, []byte("unrecognized arguments: --cov")) {
+			return nil, fmt.Errorf("pytest-cov is not installed: install it with `pip install pytest-cov`")
+		}
+		return nil, fmt.Errorf("%s failed with exit code %d and wrote no coverage report:\n%s", command, result.ExitCode, result.Output)
+	}
+
+	if framework == "go" {
+		err = parseGoCoverage(ctx, report.Name(), projectDir, result)
+	} else {
+		err = parsePytestCoverage(report.Name(), result)
+	}
+	if err != nil {
+		return nil, err
+	}
+	limitCoverage(result)
+	return result, nil
+}
+
+// coverBlock Go 覆盖率文件中的一个语句块
+type coverBlock struct {
+	file       string
+	startLine  int
+	endLine    int
+	statements int
+	covered    bool
+}
+
+// parseGoCoverage 解析 go test -coverprofile 的结果，函数覆盖率来自 go tool cover -func
+func parseGoCoverage(ctx context.Context, profile, projectDir string, result *CoverageReportResult) error {
+	data, err := os.ReadFile(profile)
+	if err != nil {
+		return fmt.Errorf("failed to read the coverage profile: %w", err)
+	}
+	module := goModulePath(projectDir)
+	relFile := func(file string) string {
+		if module != "" && strings.HasPrefix(file, module+"/") {
+			return strings.TrimPrefix(file, module+"/")
+		}
+		return file
+	}
+
+	// 同一语句块可能出现多次（多个包的测试都覆盖到），任一次执行过即为覆盖
+	blocks := make(map[string]*coverBlock)
+	var order []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		// 格式：file:startLine.startCol,endLine.endCol numStmts count
+		colon := strings.LastIndex(line, ":")
+		if colon < 0 {
+			return fmt.Errorf("malformed coverage profile line: %s", line)
+		}
+		fields := strings.Fields(line[colon+1:])
+		if len(fields) != 3 {
+			return fmt.Errorf("malformed coverage profile line: %s", line)
+		}
+		var startLine, startCol, endLine, endCol int
+		if _, err := fmt.Sscanf(fields[0], "%d.%d,%d.%d", &startLine, &startCol, &endLine, &endCol); err != nil {
+			return fmt.Errorf("malformed coverage profile line: %s", line)
+		}
+		statements, _ := strconv.Atoi(fields[1])
+		count, _ := strconv.Atoi(fields[2])
+		key := line[:colon] + ":" + fields[0]
+		block := blocks[key]
+		if block == nil {
+			block = &coverBlock{file: relFile(line[:colon]), startLine: startLine, endLine: endLine, statements: statements}
+			blocks[key] = block
+			order = append(order, key)
+		}
+		block.covered = block.covered || count > 0
+	}
+
+	files := make(map[string]*FileCoverage)
+	missing := make(map[string][]int)
+	var total, covered int
+	for _, key := range order {
+		block := blocks[key]
+		file := files[block.file]
+		if file == nil {
+			file = &FileCoverage{File: block.file}
+			files[block.file] = file
+		}
+		file.Statements += block.statements
+		total += block.statements
+		if block.covered {
+			file.Covered += block.statements
+			covered += block.statements
+		} else {
+			for line := block.startLine; line <= block.endLine; line++ {
+				missing[block.file] = append(missing[block.file], line)
+			}
+		}
+	}
+	for name, file := range files {
+		file.Percent = coveragePercent(file.Covered, file.Statements)
+		file.MissingLines = lineRanges(missing[name])
+		result.Files = append(result.Files, *file)
+	}
+	result.Total = coveragePercent(covered, total)
+
+	// 函数覆盖率需要 go tool cover 解析源文件，失败时只报告文件覆盖率
+	cmd := exec.CommandContext(ctx, "go", "tool", "cover", "-func="+profile)
+	cmd.Dir = projectDir
+	cmd.Env = os.Environ()
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		match := goCoverFuncPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		lineNumber, _ := strconv.Atoi(match[2])
+		percent, _ := strconv.ParseFloat(match[4], 64)
+		result.Functions = append(result.Functions, FunctionCoverage{
+			File:     relFile(match[1]),
+			Function: match[3],
+			Line:     lineNumber,
+			Percent:  percent,
+		})
+	}
+	return nil
+}
+
+// goModulePath 读取 go.mod 中的模块路径
+func goModulePath(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if fields := strings.Fields(line); len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
+		}
+	}
+	return ""
+}
+
+// parsePytestCoverage 解析 pytest-cov（coverage.py）的 JSON 报告；函数覆盖率需要 coverage 7.5 及以上
+func parsePytestCoverage(path string, result *CoverageReportResult) error {
+	type summary struct {
+		Statements int     `json:"num_statements"`
+		Covered    int     `json:"covered_lines"`
+		Percent    float64 `json:"percent_covered"`
+	}
+	type region struct {
+		Summary       summary `json:"summary"`
+		ExecutedLines []int   `json:"executed_lines"`
+		MissingLines  []int   `json:"missing_lines"`
+	}
+	var report struct {
+		Files map[string]struct {
+			region
+			Functions map[string]region `json:"functions"`
+		} `json:"files"`
+		Totals summary `json:"totals"`
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read the coverage report: %w", err)
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return fmt.Errorf("failed to parse the coverage report: %w", err)
+	}
+
+	for name, file := range report.Files {
+		name = filepath.ToSlash(name)
+		result.Files = append(result.Files, FileCoverage{
+			File:         name,
+			Statements:   file.Summary.Statements,
+			Covered:      file.Summary.Covered,
+			Percent:      math.Round(file.Summary.Percent*10) / 10,
+			MissingLines: lineRanges(file.MissingLines),
+		})
+		for function, fn := range file.Functions {
+			// 空名称是模块级代码
+			if function == "" {
+				continue
+			}
+			line := 0
+			for _, l := range append(fn.ExecutedLines, fn.MissingLines...) {
+				if line == 0 || l < line {
+					line = l
+				}
+			}
+			result.Functions = append(result.Functions, FunctionCoverage{
+				File:     name,
+				Function: function,
+				Line:     line,
+				Percent:  math.Round(fn.Summary.Percent*10) / 10,
+			})
+		}
+	}
+	result.Total = math.Round(report.Totals.Percent*10) / 10
+	return nil
+}
+
+// coveragePercent 覆盖率百分比，保留一位小数；没有语句时为 100
+func coveragePercent(covered, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return math.Round(float64(covered)*1000/float64(total)) / 10
+}
+
+// lineRanges 将行号列表格式化为 "3-5, 9" 形式的区间
+func lineRanges(lines []int) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	sort.Ints(lines)
+	var ranges []string
+	start, end := lines[0], lines[0]
+	flush := func() {
+		if start == end {
+			ranges = append(ranges, strconv.Itoa(start))
+		} else {
+			ranges = append(ranges, fmt.Sprintf("%d-%d", start, end))
+		}
+	}
+	for _, line := range lines[1:] {
+		if line <= end+1 {
+			end = max(end, line)
+			continue
+		}
+		flush()
+		start, end = line, line
+	}
+	flush()
+	return strings.Join(ranges, ", ")
+}
+
+// limitCoverage 按路径排序；超出上限时保留覆盖率最低的文件与函数
+func limitCoverage(result *CoverageReportResult) {
+	if len(result.Files) > maxCoverageFiles {
+		sort.SliceStable(result.Files, func(i, j int) bool { return result.Files[i].Percent < result.Files[j].Percent })
+		result.Files = result.Files[:maxCoverageFiles]
+		result.Truncated = true
+	}
+	if len(result.Functions) > maxCoverageFunctions {
+		sort.SliceStable(result.Functions, func(i, j int) bool { return result.Functions[i].Percent < result.Functions[j].Percent })
+		result.Functions = result.Functions[:maxCoverageFunctions]
+		result.Truncated = true
+	}
+	sort.Slice(result.Files, func(i, j int) bool { return result.Files[i].File < result.Files[j].File })
+	sort.Slice(result.Functions, func(i, j int) bool {
+		a, b := result.Functions[i], result.Functions[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+}
+
+// NewCoverageReportTool 创建coverage_report工具
+func NewCoverageReportTool() Tool {
+	schema := ToolSchema{
+		Name:        "coverage_report",
+		Description: "Run the tests with coverage (go test -coverprofile, or pytest --cov which needs pytest-cov) and report the total, per-file (with the uncovered line ranges) and per-function statement coverage. Use it before and after adding tests to find untested code and to measure progress. The framework is detected like run_tests.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"target": map[string]interface{}{
+					"type":        "string",
+					"description": "Test file, directory or Go package to run, relative to the workspace root (e.g. internal/parser, internal/parser/..., tests/test_api.py). Defaults to the whole project.",
+				},
+				"run": map[string]interface{}{
+					"type":        "string",
+					"description": "Only run tests matching this name or pattern (go test -run, pytest -k)",
+				},
+				"source": map[string]interface{}{
+					"type":        "string",
+					"description": "pytest only: the package or directory to measure (--cov=source). Defaults to the coverage configuration of the project.",
+				},
+				"framework": map[string]interface{}{
+					"type":        "string",
+					"description": "Test framework, only needed when detection fails",
+					"enum":        coverageFrameworks,
+				},
+				"timeout_seconds": map[string]interface{}{
+					"type":        "integer",
+					"description": "Timeout for the test run in seconds (default 600, max 3600)",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: coverageReportFunction,
+	}
+}
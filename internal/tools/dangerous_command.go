@@ -0,0 +1,314 @@
+package tools
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// dangerousCommands 可能造成破坏或影响工作区以外系统的命令。子命令集合为nil表示命令本身即危险，
+// 否则只有这些子命令危险
+var dangerousCommands = map[string]map[string]bool{
+	"sudo": nil, "su": nil, "doas": nil, "eval": nil,
+	"mkfs": nil, "dd": nil, "shred": nil, "wipefs": nil, "fdisk": nil, "parted": nil,
+	"shutdown": nil, "reboot": nil, "halt": nil, "poweroff": nil,
+	"kill": nil, "killall": nil, "pkill": nil,
+	"ssh": nil, "scp": nil, "sftp": nil,
+	"crontab":   setOf("-r", "-e"),
+	"git":       setOf("push", "filter-branch", "filter-repo", "update-ref"),
+	"npm":       setOf("publish", "unpublish", "deprecate"),
+	"yarn":      setOf("publish"),
+	"pnpm":      setOf("publish"),
+	"cargo":     setOf("publish", "yank"),
+	"gem":       setOf("push", "yank"),
+	"twine":     setOf("upload"),
+	"docker":    setOf("push", "login", "rm", "rmi", "system", "volume", "network"),
+	"kubectl":   setOf("apply", "create", "delete", "replace", "patch", "scale", "drain", "cordon", "rollout", "edit"),
+	"helm":      setOf("install", "upgrade", "uninstall", "delete", "rollback"),
+	"terraform": setOf("apply", "destroy", "import", "taint"),
+	"gh":        setOf("release", "repo", "secret", "workflow"),
+	"aws":       nil, "gcloud": nil, "az": nil,
+}
+
+// shellInterpreters 执行下载内容（如 curl ... | sh）时视为危险的解释器
+var shellInterpreters = setOf("sh", "bash", "zsh", "dash", "ksh", "fish", "python", "python3", "node", "perl", "ruby")
+
+// shells 用 -c 执行命令字符串的shell，命令字符串按同样的规则检查
+var shells = setOf("sh", "bash", "zsh", "dash", "ksh", "fish")
+
+// inlineCodeFlags 解释器执行命令行中代码的选项，代码无法检查，视为危险
+var inlineCodeFlags = map[string][]string{
+	"python": {"-c"}, "node": {"-e", "--eval", "-p", "--print"},
+	"perl": {"-e", "-E"}, "ruby": {"-e"}, "php": {"-r"},
+}
+
+// findModifiers find -exec 对找到的每个文件执行时视为删除或改动文件的命令
+var findModifiers = setOf("rm", "shred", "unlink", "mv", "chmod", "chown", "chgrp", "truncate")
+
+// xargsValueFlags 带值的 xargs 选项（值可以是下一个参数）
+var xargsValueFlags = setOf("-a", "-d", "-E", "-I", "-L", "-n", "-P", "-s")
+
+// IsDangerousCommand 判断命令是否可能造成破坏或影响工作区以外的系统：提权、删除工作区以外或整个目录树、
+// 推送与发布、部署、远程登录、下载后执行，以及 sh -c、python -c、find -exec、xargs 中的这些命令等，
+// 用于在无人值守运行中额外拒绝。不在规则中的命令不代表安全；无法解析的写法视为危险
+func IsDangerousCommand(command string) bool {
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return false
+	}
+	// 分段时 & 只允许 &&，先去掉常见的重定向写法
+	for _, redirect := range []string{"2>&1", ">&2", "&>"} {
+		command = strings.ReplaceAll(command, redirect, " ")
+	}
+	segments, ok := splitCommandSegments(strings.ReplaceAll(command, "\n", ";"))
+	if !ok {
+		return true
+	}
+
+	downloads, interprets := false, false
+	for _, args := range segments {
+		args = stripCommandPrefix(args)
+		if len(args) == 0 {
+			continue
+		}
+		name := commandName(args[0])
+		switch {
+		case name == "curl" || name == "wget":
+			downloads = true
+		case shellInterpreters[name]:
+			interprets = true
+		}
+		if isDangerousArgs(args) {
+			return true
+		}
+	}
+	return downloads && interprets
+}
+
+// commandName 可执行文件的名称，去掉路径与 .exe
+func commandName(arg string) string {
+	return strings.TrimSuffix(filepath.Base(arg), ".exe")
+}
+
+// isDangerousArgs 判断单条命令（不含管道与连接符）是否危险
+func isDangerousArgs(args []string) bool {
+	args = stripCommandPrefix(args)
+	if len(args) == 0 {
+		return false
+	}
+	name := commandName(args[0])
+	if strings.HasPrefix(name, "mkfs.") {
+		return true
+	}
+	switch name {
+	case "rm":
+		if isDangerousRemove(args[1:]) {
+			return true
+		}
+	case "git":
+		if isDangerousGit(args[1:]) {
+			return true
+		}
+	case "chmod", "chown", "chgrp":
+		if hasRecursiveFlag(args[1:]) && touchesOutside(args[1:]) {
+			return true
+		}
+	case "find":
+		if isDangerousFind(args[1:]) {
+			return true
+		}
+	case "xargs":
+		// 参数来自输入，无法确定，按可能指向任何路径检查
+		if rest := xargsCommand(args[1:]); len(rest) > 0 && isDangerousArgs(append(append([]string{}, rest...), "*")) {
+			return true
+		}
+	}
+	if shells[name] {
+		if script, ok := shellScript(args[1:]); ok && (script == "" || IsDangerousCommand(script)) {
+			return true
+		}
+	}
+	// python3.12 等带版本号的名称按 python 检查
+	for _, arg := range args[1:] {
+		for _, flag := range inlineCodeFlags[strings.TrimRight(name, "0123456789.")] {
+			if arg == flag || (len(flag) == 2 && strings.HasPrefix(arg, flag)) {
+				return true
+			}
+		}
+	}
+	subcommands, ok := dangerousCommands[name]
+	if !ok {
+		return false
+	}
+	return subcommands == nil || (len(args) > 1 && subcommands[args[1]])
+}
+
+// shellScript sh -c 等执行的命令字符串：-c 可以与其他短选项合并（如 -lc、-ec），
+// 命令字符串为之后第一个不是选项的参数。没有 -c 时返回 false
+func shellScript(args []string) (string, bool) {
+	for i, arg := range args {
+		if arg == "--" || !strings.HasPrefix(arg, "-") || strings.HasPrefix(arg, "--") {
+			continue
+		}
+		if !strings.Contains(arg[1:], "c") {
+			continue
+		}
+		for _, rest := range args[i+1:] {
+			if !strings.HasPrefix(rest, "-") {
+				return rest, true
+			}
+		}
+		return "", true
+	}
+	return "", false
+}
+
+// isDangerousFind find 删除工作区以外或整个工作区的文件，或用 -exec 等执行危险命令时为危险
+func isDangerousFind(args []string) bool {
+	for i, arg := range args {
+		switch arg {
+		case "-delete":
+			if touchesOutside(findRoots(args)) {
+				return true
+			}
+		case "-exec", "-execdir", "-ok", "-okdir":
+			var command []string
+			for _, rest := range args[i+1:] {
+				if rest == ";" || rest == "+" {
+					break
+				}
+				// {} 可以是找到的任何文件
+				if rest == "{}" {
+					rest = "*"
+				}
+				command = append(command, rest)
+			}
+			if len(command) == 0 || isDangerousArgs(command) {
+				return true
+			}
+			// 对工作区以外或整个工作区的每个文件执行删除、移动或修改权限
+			if findModifiers[commandName(command[0])] && touchesOutside(findRoots(args)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findRoots find 的起始路径（第一个表达式之前的参数），没有时为当前目录
+func findRoots(args []string) []string {
+	var roots []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") || arg == "(" || arg == "!" {
+			break
+		}
+		roots = append(roots, arg)
+	}
+	if len(roots) == 0 {
+		roots = []string{"."}
+	}
+	return roots
+}
+
+// xargsCommand xargs 执行的命令（去掉 xargs 的选项）
+func xargsCommand(args []string) []string {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			return args[i:]
+		}
+		if xargsValueFlags[arg] {
+			i++
+		}
+	}
+	return nil
+}
+
+// stripCommandPrefix 去掉 VAR=value 前缀与 env、nohup、time 等包装命令
+func stripCommandPrefix(args []string) []string {
+	for len(args) > 0 {
+		switch {
+		case strings.Contains(args[0], "=") && !strings.HasPrefix(args[0], "-"):
+			args = args[1:]
+		case args[0] == "env" || args[0] == "nohup" || args[0] == "time" || args[0] == "command" || args[0] == "exec":
+			args = args[1:]
+		default:
+			return args
+		}
+	}
+	return args
+}
+
+// isDangerousRemove rm 递归删除工作区以外的路径、根目录、主目录或整个当前目录时为危险
+func isDangerousRemove(args []string) bool {
+	if !hasRecursiveFlag(args) {
+		return false
+	}
+	return touchesOutside(args)
+}
+
+// hasRecursiveFlag 参数中是否有 -r、-R 或 --recursive
+func hasRecursiveFlag(args []string) bool {
+	for _, arg := range args {
+		if arg == "--recursive" || (strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && strings.ContainsAny(arg, "rR")) {
+			return true
+		}
+	}
+	return false
+}
+
+// touchesOutside 路径参数是否指向工作区以外或整个工作区（绝对路径、~、..、.、*）
+func touchesOutside(args []string) bool {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		path := strings.Trim(arg, `"'`)
+		clean := filepath.ToSlash(filepath.Clean(path))
+		if filepath.IsAbs(path) || strings.HasPrefix(path, "/") || strings.HasPrefix(path, "~") || strings.HasPrefix(path, "$") ||
+			clean == "." || clean == ".." || strings.HasPrefix(clean, "../") || clean == "*" || clean == ".*" {
+			return true
+		}
+	}
+	return false
+}
+
+// isDangerousGit 丢弃修改或删除未跟踪文件的 git 命令（推送等在 dangerousCommands 中）
+func isDangerousGit(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	rest := args[1:]
+	switch args[0] {
+	case "reset":
+		return containsArg(rest, "--hard")
+	case "clean":
+		return hasShortFlag(rest, 'f') || containsArg(rest, "--force")
+	case "checkout", "restore":
+		return containsArg(rest, ".") || containsArg(rest, "--force") || hasShortFlag(rest, 'f')
+	case "branch":
+		return hasShortFlag(rest, 'D')
+	case "config":
+		return containsArg(rest, "--global") || containsArg(rest, "--system")
+	}
+	return false
+}
+
+// containsArg 参数中是否有指定的值
+func containsArg(args []string, value string) bool {
+	for _, arg := range args {
+		if arg == value {
+			return true
+		}
+	}
+	return false
+}
+
+// hasShortFlag 参数中是否有指定的单字符短选项（包括合并写法，如 -fd）
+func hasShortFlag(args []string, flag byte) bool {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && strings.IndexByte(arg[1:], flag) >= 0 {
+			return true
+		}
+	}
+	return false
+}
\ No newline at end of file
@@ -2,14 +2,15 @@ package tools
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
-	"strings"
 )
 
 // DeleteFileParams delete_file工具的参数
 type DeleteFileParams struct {
 	TargetFile  string `json:"target_file"`
+	Recursive   bool   `json:"recursive,omitempty"`
 	Explanation string `json:"explanation,omitempty"`
 }
 
@@ -19,6 +20,7 @@ type DeleteFileResult struct {
 	Deleted    bool   `json:"deleted"`
 	Message    string `json:"message"`
 	FileInfo   string `json:"file_info,omitempty"`
+	TrashPath  string `json:"trash_path,omitempty"` // 移入回收站后的位置
 }
 
 // deleteFileFunction 删除文件工具函数
@@ -29,7 +31,13 @@ func deleteFileFunction(params map[string]interface{}) (interface{}, error) {
 		return nil, fmt.Errorf("target_file is required")
 	}
 
+	recursive, _ := params["recursive"].(bool)
+	explanation, _ := params["explanation"].(string)
 	workDir, _ := params["__work_dir__"].(string)
+	// 先确定工作目录，回收站位置与不能删除回收站的检查都以它为准
+	if workDir == "" {
+		workDir, _ = os.Getwd()
+	}
 
 	// 解析文件路径
 	var filePath string
@@ -49,7 +57,7 @@ func deleteFileFunction(params map[string]interface{}) (interface{}, error) {
 	}
 
 	// 检查文件是否存在
-	info, err := os.Stat(filePath)
+	info, err := statWorkspaceFile(filePath)
 	if os.IsNotExist(err) {
 		result.Message = "File does not exist"
 		return result, nil
@@ -60,96 +68,93 @@ func deleteFileFunction(params map[string]interface{}) (interface{}, error) {
 		return result, nil
 	}
 
-	// 记录文件信息
-	if info.IsDir() {
-		result.FileInfo = fmt.Sprintf("Directory with %d bytes", info.Size())
-		result.Message = "Cannot delete directories with this tool"
+	// 回收站中的文件、回收站本身以及包含它的目录（如工作区根目录）都不能删除
+	if workDir != "" && inTrashPath(filePath, workDir) {
+		result.Message = "Cannot delete the trash, anything in it, or a directory containing it such as the workspace root"
 		return result, nil
+	}
+
+	// 记录文件信息，目录只在 recursive 为 true 并经用户确认后删除
+	var files []string
+	if info.IsDir() {
+		files, err = directoryFiles(filePath)
+		if err != nil {
+			result.Message = fmt.Sprintf("Failed to list directory: %v", err)
+			return result, nil
+		}
+		result.FileInfo = fmt.Sprintf("Directory with %d files", len(files))
+		if !recursive {
+			result.Message = "Cannot delete a directory without recursive set to true"
+			return result, nil
+		}
 	} else {
 		result.FileInfo = fmt.Sprintf("File with %d bytes", info.Size())
 	}
 
-	// 执行安全检查
-	if err := performSecurityChecks(filePath); err != nil {
-		result.Message = fmt.Sprintf("Security check failed: %v", err)
+	// 按安全策略检查（目录中的每个文件都要通过）
+	for _, path := range append([]string{filePath}, files...) {
+		if err := checkSecurityPolicy(policyDelete, path, workDir); err != nil {
+			result.Message = fmt.Sprintf("Security check failed: %v", err)
+			return result, nil
+		}
+	}
+	if info.IsDir() && !confirmRecursiveDelete(filePath, len(files), explanation) {
+		result.Message = "Recursive deletion of the directory was not confirmed"
 		return result, nil
 	}
 
-	// 尝试删除文件
-	err = os.Remove(filePath)
+	// 试运行时只记录删除，否则移入回收站
+	if IsDryRun() {
+		targets := []string{filePath}
+		if info.IsDir() {
+			targets = files
+		}
+		for _, path := range targets {
+			if err := removeWorkspaceFile(path); err != nil {
+				result.Message = fmt.Sprintf("Failed to delete file: %v", err)
+				return result, nil
+			}
+		}
+		result.Deleted = true
+		result.Message = "File successfully deleted"
+		return result, nil
+	}
+	session, trashPath, err := moveToTrash(workDir, filePath, info.IsDir())
 	if err != nil {
 		result.Message = fmt.Sprintf("Failed to delete file: %v", err)
 		return result, nil
 	}
 
 	result.Deleted = true
-	result.Message = "File successfully deleted"
+	result.TrashPath = trashPath
+	result.Message = fmt.Sprintf("File moved to the trash, the user can restore it with: openCursor restore %s", session)
+	if info.IsDir() {
+		result.Message = fmt.Sprintf("Directory with %d files moved to the trash, the user can restore it with: openCursor restore %s", len(files), session)
+	}
 
 	return result, nil
 }
 
-// performSecurityChecks 执行安全检查
-func performSecurityChecks(filePath string) error {
-	// 检查是否为系统重要文件
-	dangerousPaths := []string{
-		"/etc",
-		"/bin",
-		"/sbin",
-		"/usr/bin",
-		"/usr/sbin",
-		"/boot",
-		"/sys",
-		"/proc",
-		"C:\\Windows",
-		"C:\\Program Files",
-		"C:\\Program Files (x86)",
-	}
-
-	absPath, err := filepath.Abs(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to get absolute path: %w", err)
-	}
-
-	for _, dangerousPath := range dangerousPaths {
-		if strings.HasPrefix(absPath, dangerousPath) {
-			return fmt.Errorf("cannot delete files in system directory: %s", dangerousPath)
-		}
-	}
-
-	// 检查文件扩展名
-	dangerousExtensions := []string{
-		".exe", ".dll", ".sys", ".bat", ".cmd", ".com", ".scr",
-		".pif", ".application", ".gadget", ".msi", ".msp", ".msc",
-	}
-
-	ext := filepath.Ext(filePath)
-	for _, dangerousExt := range dangerousExtensions {
-		if ext == dangerousExt {
-			return fmt.Errorf("cannot delete potentially dangerous file type: %s", ext)
+// directoryFiles 目录下的所有文件（不含目录本身，不跟随符号链接）
+func directoryFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
 		}
-	}
-
-	// 检查是否为隐藏的系统文件
-	fileName := filepath.Base(filePath)
-	systemFiles := []string{
-		"boot.ini", "ntldr", "bootmgr", "pagefile.sys", "hiberfil.sys",
-		".DS_Store", "Thumbs.db", "desktop.ini",
-	}
-
-	for _, systemFile := range systemFiles {
-		if fileName == systemFile {
-			return fmt.Errorf("cannot delete system file: %s", systemFile)
+		if !d.IsDir() {
+			files = append(files, path)
 		}
-	}
-
-	return nil
+		return nil
+	})
+	return files, err
 }
 
 // NewDeleteFileTool 创建delete_file工具
 func NewDeleteFileTool() Tool {
 	schema := ToolSchema{
 		Name:        "delete_file",
-		Description: "Deletes a file at the specified path. The operation will fail gracefully if:\n    - The file doesn't exist\n    - The operation is rejected for security reasons\n    - The file cannot be deleted",
+		Description: "Deletes a file at the specified path by moving it to the session's trash (.opencursor/trash/<session>/), from where the user can restore it. Directories are only deleted with recursive set to true and after the user confirms. The operation will fail gracefully if:\n    - The file doesn't exist\n    - The operation is rejected for security reasons\n    - The file cannot be deleted",
 		InputSchema: map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
@@ -157,6 +162,10 @@ func NewDeleteFileTool() Tool {
 					"type":        "string",
 					"description": "The path of the file to delete, relative to the workspace root.",
 				},
+				"recursive": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Delete a directory with everything in it. The user is asked to confirm. Defaults to false.",
+				},
 				"explanation": map[string]interface{}{
 					"type":        "string",
 					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
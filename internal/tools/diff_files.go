@@ -0,0 +1,262 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxDiffFilesBytes diff_files结果中diff的大小上限，超出部分截断
+const maxDiffFilesBytes = 64 * 1024
+
+// DiffFilesResult diff_files工具的返回结果
+type DiffFilesResult struct {
+	PathA     string   `json:"path_a"`
+	PathB     string   `json:"path_b"`
+	Identical bool     `json:"identical"`
+	Changed   []string `json:"changed,omitempty"`
+	OnlyInA   []string `json:"only_in_a,omitempty"`
+	OnlyInB   []string `json:"only_in_b,omitempty"`
+	Binary    []string `json:"binary,omitempty"` // 内容不同的二进制文件，不生成diff
+	Diff      string   `json:"diff"`
+	Truncated bool     `json:"truncated,omitempty"`
+}
+
+// diffIgnore 忽略规则：不含 / 的模式匹配任意一级的文件名或目录名，否则匹配相对路径
+type diffIgnore []struct {
+	re       *regexp.Regexp
+	basename bool
+}
+
+// compileDiffIgnore 编译忽略模式，.git 目录总是忽略
+func compileDiffIgnore(patterns []string) (diffIgnore, error) {
+	var ignore diffIgnore
+	for _, pattern := range append([]string{".git"}, patterns...) {
+		pattern = strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+		if pattern == "" {
+			continue
+		}
+		re, err := globToRegexp(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignore pattern %q: %w", pattern, err)
+		}
+		ignore = append(ignore, struct {
+			re       *regexp.Regexp
+			basename bool
+		}{re, !strings.Contains(pattern, "/")})
+	}
+	return ignore, nil
+}
+
+// match 相对路径是否被忽略
+func (ignore diffIgnore) match(rel string) bool {
+	for _, rule := range ignore {
+		if rule.basename && rule.re.MatchString(path.Base(rel)) || !rule.basename && rule.re.MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// collectDiffFiles 目录下未被忽略的文件，键为相对路径
+func collectDiffFiles(root string, ignore diffIgnore) map[string]string {
+	var mu sync.Mutex
+	files := make(map[string]string)
+	relOf := func(p string) string {
+		rel, _ := filepath.Rel(root, p)
+		return filepath.ToSlash(rel)
+	}
+	skipDir := func(p string, d fs.DirEntry) bool {
+		return ignore.match(relOf(p))
+	}
+	parallelWalk([]string{root}, skipDir, func(p string, d fs.DirEntry) {
+		if !d.Type().IsRegular() {
+			return
+		}
+		rel := relOf(p)
+		if ignore.match(rel) {
+			return
+		}
+		mu.Lock()
+		files[rel] = p
+		mu.Unlock()
+	})
+	return files
+}
+
+// diffFilesFunction 比较两个文件或目录工具函数
+func diffFilesFunction(params map[string]interface{}) (interface{}, error) {
+	pathA, _ := params["path_a"].(string)
+	pathB, _ := params["path_b"].(string)
+	if pathA == "" || pathB == "" {
+		return nil, fmt.Errorf("path_a and path_b are required")
+	}
+	workDir, _ := params["__work_dir__"].(string)
+	resolve := func(p string) string {
+		if filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(workDir, p)
+	}
+	absA, absB := resolve(pathA), resolve(pathB)
+
+	list, _ := params["ignore"].([]interface{})
+	var patterns []string
+	for _, item := range list {
+		if pattern, ok := item.(string); ok {
+			patterns = append(patterns, pattern)
+		}
+	}
+	ignore, err := compileDiffIgnore(patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	infoA, err := statWorkspaceFile(absA)
+	if err != nil {
+		return nil, fmt.Errorf("path_a not found: %s", pathA)
+	}
+	infoB, err := statWorkspaceFile(absB)
+	if err != nil {
+		return nil, fmt.Errorf("path_b not found: %s", pathB)
+	}
+	if infoA.IsDir() != infoB.IsDir() {
+		return nil, fmt.Errorf("cannot compare a file with a directory: %s and %s", pathA, pathB)
+	}
+
+	result := &DiffFilesResult{PathA: pathA, PathB: pathB}
+	var diff strings.Builder
+	compare := func(rel, fileA, fileB, nameA, nameB string, existsA, existsB bool) error {
+		var a, b []byte
+		var err error
+		if existsA {
+			if a, err = readWorkspaceFile(fileA); err != nil {
+				return fmt.Errorf("failed to read %s: %w", fileA, err)
+			}
+		}
+		if existsB {
+			if b, err = readWorkspaceFile(fileB); err != nil {
+				return fmt.Errorf("failed to read %s: %w", fileB, err)
+			}
+		}
+		if existsA && existsB && bytes.Equal(a, b) {
+			return nil
+		}
+		switch {
+		case !existsB:
+			result.OnlyInA = append(result.OnlyInA, rel)
+		case !existsA:
+			result.OnlyInB = append(result.OnlyInB, rel)
+		default:
+			result.Changed = append(result.Changed, rel)
+		}
+		if isBinaryContent(a) || isBinaryContent(b) {
+			if existsA && existsB {
+				result.Binary = append(result.Binary, rel)
+			}
+			fmt.Fprintf(&diff, "Binary files %s and %s differ\n", nameA, nameB)
+			return nil
+		}
+		diff.WriteString(renameDiffHeader(unifiedDiff(rel, string(a), string(b), existsA, existsB), nameA, nameB, existsA, existsB))
+		return nil
+	}
+
+	if !infoA.IsDir() {
+		if err := compare(filepath.Base(pathB), absA, absB, pathA, pathB, true, true); err != nil {
+			return nil, err
+		}
+	} else {
+		filesA := collectDiffFiles(absA, ignore)
+		filesB := collectDiffFiles(absB, ignore)
+		names := make([]string, 0, len(filesA)+len(filesB))
+		for rel := range filesA {
+			names = append(names, rel)
+		}
+		for rel := range filesB {
+			if _, ok := filesA[rel]; !ok {
+				names = append(names, rel)
+			}
+		}
+		sort.Strings(names)
+		for _, rel := range names {
+			fileA, existsA := filesA[rel]
+			fileB, existsB := filesB[rel]
+			nameA := filepath.ToSlash(filepath.Join(pathA, rel))
+			nameB := filepath.ToSlash(filepath.Join(pathB, rel))
+			if err := compare(rel, fileA, fileB, nameA, nameB, existsA, existsB); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	result.Identical = len(result.Changed) == 0 && len(result.OnlyInA) == 0 && len(result.OnlyInB) == 0
+	result.Diff = diff.String()
+	if len(result.Diff) > maxDiffFilesBytes {
+		cut := strings.LastIndex(result.Diff[:maxDiffFilesBytes], "\n") + 1
+		result.Diff = result.Diff[:cut] + fmt.Sprintf("... diff truncated (%d more bytes), narrow the comparison or add ignore patterns\n", len(result.Diff)-cut)
+		result.Truncated = true
+	}
+	return result, nil
+}
+
+// renameDiffHeader 将 unifiedDiff 的文件头替换为两侧各自的路径
+func renameDiffHeader(diff, nameA, nameB string, existsA, existsB bool) string {
+	lines := strings.SplitN(diff, "\n", 3)
+	if len(lines) < 3 {
+		return diff
+	}
+	if existsA {
+		lines[0] = "--- " + nameA
+	}
+	if existsB {
+		lines[1] = "+++ " + nameB
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isBinaryContent 开头包含 NUL 字节的内容视为二进制
+func isBinaryContent(content []byte) bool {
+	return bytes.IndexByte(content[:min(len(content), 8000)], 0) >= 0
+}
+
+// NewDiffFilesTool 创建diff_files工具
+func NewDiffFilesTool() Tool {
+	schema := ToolSchema{
+		Name:        "diff_files",
+		Description: "Show a unified diff between two files or two directories, e.g. to compare an old and a new implementation or a vendored copy with upstream. For directories, files are paired by relative path and files present on only one side are listed; binary files are only reported as differing. The .git directory is always ignored.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path_a": map[string]interface{}{
+					"type":        "string",
+					"description": "The old file or directory, relative to the workspace root or absolute",
+				},
+				"path_b": map[string]interface{}{
+					"type":        "string",
+					"description": "The new file or directory, relative to the workspace root or absolute",
+				},
+				"ignore": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Glob patterns to skip when comparing directories. Patterns without a slash match file or directory names at any depth (e.g. node_modules, *.lock); others match paths relative to each directory (e.g. build/**).",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+			"required": []string{"path_a", "path_b"},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: diffFilesFunction,
+	}
+}
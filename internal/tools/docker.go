@@ -0,0 +1,455 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// docker工具的默认值与上限
+const (
+	defaultDockerBuildTimeout = 1800 // 秒
+	defaultDockerRunTimeout   = 600  // 前台运行容器的超时（秒）
+	maxDockerTimeout          = 3600
+	defaultDockerLogLines     = 200
+	maxDockerOutputBytes      = 20000
+)
+
+// dockerAgentLabel 标记由代理启动的容器与构建的镜像，list 与 stop 只处理带这个标签的容器
+const dockerAgentLabel = "opencursor.agent"
+
+// dockerNamePattern 镜像名中不允许的字符
+var dockerNamePattern = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// dockerActions docker工具支持的操作
+var dockerActions = []string{"build", "run", "logs", "list", "stop"}
+
+// DockerContainer 一个由代理启动的容器
+type DockerContainer struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Image   string `json:"image"`
+	Status  string `json:"status"`
+	Ports   string `json:"ports,omitempty"`
+	Created string `json:"created"`
+	WorkDir string `json:"workdir,omitempty"` // 启动容器时的工作区
+}
+
+// DockerResult docker工具的返回结果
+type DockerResult struct {
+	Action     string            `json:"action"`
+	Command    string            `json:"command,omitempty"`
+	Image      string            `json:"image,omitempty"`
+	Container  string            `json:"container,omitempty"`
+	Containers []DockerContainer `json:"containers,omitempty"`
+	ExitCode   int               `json:"exit_code"`
+	Output     string            `json:"output,omitempty"`
+	Truncated  bool              `json:"truncated,omitempty"`
+	Message    string            `json:"message"`
+}
+
+// dockerStringList 读取字符串数组参数
+func dockerStringList(params map[string]interface{}, key string) []string {
+	list, _ := params[key].([]interface{})
+	var values []string
+	for _, item := range list {
+		if value, ok := item.(string); ok && value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// dockerStringMap 读取字符串映射参数，按键排序后格式化为 KEY=VALUE
+func dockerStringMap(params map[string]interface{}, key string) ([]string, error) {
+	values, _ := params[key].(map[string]interface{})
+	pairs := make([]string, 0, len(values))
+	for name, value := range values {
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s.%s must be a string", key, name)
+		}
+		pairs = append(pairs, name+"="+s)
+	}
+	sort.Strings(pairs)
+	return pairs, nil
+}
+
+// runDocker 执行 docker 命令，返回合并的输出与退出码
+func runDocker(params map[string]interface{}, dir string, timeout int, args ...string) (string, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Dir = dir
+	cmd.Env = buildCommandEnv(params)
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return string(output), -1, fmt.Errorf("docker %s timed out after %ds", args[0], timeout)
+	}
+	if err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			return string(output), exitError.ExitCode(), nil
+		}
+		return "", 0, fmt.Errorf("failed to run docker: %w", err)
+	}
+	return string(output), 0, nil
+}
+
+// runApprovedDocker 试运行时只记录，否则经确认后执行会创建或修改镜像、容器的 docker 命令
+func runApprovedDocker(params map[string]interface{}, dir string, timeout int, args ...string) (string, int, error) {
+	display := "docker " + strings.Join(quoteArgs(args), " ")
+	explanation, _ := params["explanation"].(string)
+	if planCommand("docker", display, explanation) {
+		return "", 0, errNotRunInDryRun(display)
+	}
+	if !approveCommand("docker", display, explanation) {
+		return "", 0, fmt.Errorf("the user rejected the command: %s", display)
+	}
+	return runDocker(params, dir, timeout, args...)
+}
+
+// dockerOutput 截取输出，构建与运行日志保留尾部
+func dockerOutput(result *DockerResult, output string) {
+	output = strings.TrimRight(output, "\n")
+	if len(output) > maxDockerOutputBytes {
+		output = "...\n" + output[len(output)-maxDockerOutputBytes:]
+		result.Truncated = true
+	}
+	result.Output = output
+}
+
+// agentContainer 确认容器是由代理启动的
+func agentContainer(params map[string]interface{}, workDir, container string) error {
+	output, exitCode, err := runDocker(params, workDir, 60, "inspect", "--type", "container", "--format", fmt.Sprintf(`{{index .Config.Labels %q}}`, dockerAgentLabel), container)
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("no such container: %s", container)
+	}
+	if strings.TrimSpace(output) != "true" {
+		return fmt.Errorf("container %s was not started by the agent; only containers started with the docker tool can be stopped", container)
+	}
+	return nil
+}
+
+// dockerFunction Docker操作工具函数
+func dockerFunction(params map[string]interface{}) (interface{}, error) {
+	action, _ := params["action"].(string)
+	if action == "" {
+		return nil, fmt.Errorf("action is required (one of: %s)", strings.Join(dockerActions, ", "))
+	}
+	if !containsString(dockerActions, action) {
+		return nil, fmt.Errorf("unknown action %q (expected one of: %s)", action, strings.Join(dockerActions, ", "))
+	}
+	if _, err := exec.LookPath("docker"); err != nil {
+		return nil, fmt.Errorf("docker is not installed or not in PATH")
+	}
+	workDir, _ := params["__work_dir__"].(string)
+	if workDir == "" {
+		workDir = "."
+	}
+	workDir, _ = filepath.Abs(workDir)
+	image, _ := params["image"].(string)
+	container, _ := params["container"].(string)
+	if strings.HasPrefix(image, "-") || strings.HasPrefix(container, "-") {
+		return nil, fmt.Errorf("invalid image or container name")
+	}
+
+	result := &DockerResult{Action: action, Image: image, Container: container}
+	switch action {
+	case "build":
+		contextDir, _ := params["context"].(string)
+		if contextDir == "" {
+			contextDir = "."
+		}
+		if image == "" {
+			image = "opencursor-" + dockerNamePattern.ReplaceAllString(strings.ToLower(filepath.Base(workDir)), "-") + ":latest"
+		}
+		args := []string{"build", "--tag", image, "--label", dockerAgentLabel + "=true"}
+		if dockerfile, _ := params["dockerfile"].(string); dockerfile != "" {
+			args = append(args, "--file", dockerfile)
+		}
+		if target, _ := params["target"].(string); target != "" {
+			args = append(args, "--target", target)
+		}
+		buildArgs, err := dockerStringMap(params, "build_args")
+		if err != nil {
+			return nil, err
+		}
+		for _, arg := range buildArgs {
+			args = append(args, "--build-arg", arg)
+		}
+		args = append(args, contextDir)
+
+		timeout := defaultDockerBuildTimeout
+		if t, ok := intParam(params, "timeout_seconds"); ok && t > 0 {
+			timeout = min(t, maxDockerTimeout)
+		}
+		output, exitCode, err := runApprovedDocker(params, workDir, timeout, args...)
+		if err != nil {
+			return nil, err
+		}
+		result.Command = "docker " + strings.Join(quoteArgs(args), " ")
+		result.Image = image
+		result.ExitCode = exitCode
+		dockerOutput(result, output)
+		if exitCode != 0 {
+			result.Message = fmt.Sprintf("build of %s failed with exit code %d", image, exitCode)
+		} else {
+			result.Message = fmt.Sprintf("built image %s", image)
+		}
+
+	case "run":
+		if image == "" {
+			return nil, fmt.Errorf("image is required for run")
+		}
+		detach := true
+		if d, ok := params["detach"].(bool); ok {
+			detach = d
+		}
+		args := []string{"run", "--label", dockerAgentLabel + "=true", "--label", "opencursor.workdir=" + workDir}
+		if detach {
+			args = append(args, "--detach")
+		} else {
+			args = append(args, "--rm")
+		}
+		if container != "" {
+			args = append(args, "--name", container)
+		}
+		for _, port := range dockerStringList(params, "ports") {
+			args = append(args, "--publish", port)
+		}
+		env, err := dockerStringMap(params, "env")
+		if err != nil {
+			return nil, err
+		}
+		for _, pair := range env {
+			args = append(args, "--env", pair)
+		}
+		if mount, _ := params["mount_workspace"].(bool); mount {
+			args = append(args, "--volume", workDir+":/workspace", "--workdir", "/workspace")
+		}
+		args = append(args, image)
+		args = append(args, dockerStringList(params, "command")...)
+
+		timeout := 120
+		if !detach {
+			timeout = defaultDockerRunTimeout
+			if t, ok := intParam(params, "timeout_seconds"); ok && t > 0 {
+				timeout = min(t, maxDockerTimeout)
+			}
+		}
+		output, exitCode, err := runApprovedDocker(params, workDir, timeout, args...)
+		if err != nil {
+			return nil, err
+		}
+		result.Command = "docker " + strings.Join(quoteArgs(args), " ")
+		result.ExitCode = exitCode
+		switch {
+		case exitCode != 0:
+			dockerOutput(result, output)
+			result.Message = fmt.Sprintf("docker run failed with exit code %d", exitCode)
+		case detach:
+			// 后台运行时输出的最后一行是容器 ID
+			lines := strings.Split(strings.TrimSpace(output), "\n")
+			id := lines[len(lines)-1]
+			result.Container = id[:min(len(id), 12)]
+			if container != "" {
+				result.Container = container
+			}
+			result.Message = fmt.Sprintf("started container %s from %s; use logs to follow its output and stop when done", result.Container, image)
+		default:
+			dockerOutput(result, output)
+			result.Message = "container exited with code 0"
+		}
+
+	case "logs":
+		if container == "" {
+			return nil, fmt.Errorf("container is required for logs")
+		}
+		lines := defaultDockerLogLines
+		if n, ok := intParam(params, "tail"); ok && n > 0 {
+			lines = n
+		}
+		args := []string{"logs", "--tail", strconv.Itoa(lines), "--timestamps"}
+		if since, _ := params["since"].(string); since != "" {
+			args = append(args, "--since", since)
+		}
+		args = append(args, container)
+		output, exitCode, err := runDocker(params, workDir, 60, args...)
+		if err != nil {
+			return nil, err
+		}
+		if exitCode != 0 {
+			return nil, fmt.Errorf("docker logs failed: %s", strings.TrimSpace(output))
+		}
+		result.Command = "docker " + strings.Join(quoteArgs(args), " ")
+		dockerOutput(result, output)
+		result.Message = fmt.Sprintf("last %d log lines of %s", lines, container)
+
+	case "list":
+		output, exitCode, err := runDocker(params, workDir, 60, "ps", "--all", "--no-trunc", "--filter", "label="+dockerAgentLabel, "--format", "{{json .}}")
+		if err != nil {
+			return nil, err
+		}
+		if exitCode != 0 {
+			return nil, fmt.Errorf("docker ps failed: %s", strings.TrimSpace(output))
+		}
+		for _, line := range strings.Split(output, "\n") {
+			var entry struct {
+				ID        string `json:"ID"`
+				Names     string `json:"Names"`
+				Image     string `json:"Image"`
+				Status    string `json:"Status"`
+				Ports     string `json:"Ports"`
+				CreatedAt string `json:"CreatedAt"`
+				Labels    string `json:"Labels"`
+			}
+			if json.Unmarshal([]byte(line), &entry) != nil {
+				continue
+			}
+			c := DockerContainer{
+				ID:      entry.ID[:min(len(entry.ID), 12)],
+				Name:    entry.Names,
+				Image:   entry.Image,
+				Status:  entry.Status,
+				Ports:   entry.Ports,
+				Created: entry.CreatedAt,
+			}
+			for _, label := range strings.Split(entry.Labels, ",") {
+				if dir, ok := strings.CutPrefix(label, "opencursor.workdir="); ok {
+					c.WorkDir = dir
+				}
+			}
+			result.Containers = append(result.Containers, c)
+		}
+		result.Message = fmt.Sprintf("%d containers started by the agent", len(result.Containers))
+
+	case "stop":
+		if container == "" {
+			return nil, fmt.Errorf("container is required for stop")
+		}
+		if err := agentContainer(params, workDir, container); err != nil {
+			return nil, err
+		}
+		args := []string{"stop", container}
+		if remove, _ := params["remove"].(bool); remove {
+			args = []string{"rm", "--force", container}
+		}
+		output, exitCode, err := runApprovedDocker(params, workDir, 120, args...)
+		if err != nil {
+			return nil, err
+		}
+		result.Command = "docker " + strings.Join(quoteArgs(args), " ")
+		result.ExitCode = exitCode
+		if exitCode != 0 {
+			return nil, fmt.Errorf("%s failed: %s", result.Command, strings.TrimSpace(output))
+		}
+		if args[0] == "rm" {
+			result.Message = fmt.Sprintf("stopped and removed container %s", container)
+		} else {
+			result.Message = fmt.Sprintf("stopped container %s", container)
+		}
+	}
+	return result, nil
+}
+
+// NewDockerTool 创建docker工具
+func NewDockerTool() Tool {
+	schema := ToolSchema{
+		Name:        "docker",
+		Description: "Work with Docker for containerized development: build an image from the workspace, run a container (in the background, e.g. a database or the app under test, or in the foreground to run a command and get its output), read a container's logs, and list or stop the containers you started. Containers started by this tool are labeled, and only those can be stopped. Building and running need the user's approval. Stop background containers when you no longer need them.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"action": map[string]interface{}{
+					"type":        "string",
+					"description": "build, run, logs, list or stop",
+					"enum":        dockerActions,
+				},
+				"image": map[string]interface{}{
+					"type":        "string",
+					"description": "For build: the tag of the image (default opencursor-<workspace>:latest). For run: the image to run.",
+				},
+				"container": map[string]interface{}{
+					"type":        "string",
+					"description": "For run: an optional container name. For logs and stop: the container name or ID.",
+				},
+				"context": map[string]interface{}{
+					"type":        "string",
+					"description": "For build: the build context directory relative to the workspace root (default .)",
+				},
+				"dockerfile": map[string]interface{}{
+					"type":        "string",
+					"description": "For build: the Dockerfile, if not <context>/Dockerfile",
+				},
+				"target": map[string]interface{}{
+					"type":        "string",
+					"description": "For build: the stage of a multi-stage Dockerfile to build",
+				},
+				"build_args": map[string]interface{}{
+					"type":                 "object",
+					"description":          "For build: build arguments",
+					"additionalProperties": map[string]interface{}{"type": "string"},
+				},
+				"command": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "For run: the command and arguments to run instead of the image's default",
+				},
+				"detach": map[string]interface{}{
+					"type":        "boolean",
+					"description": "For run: run in the background (default true). With false the container is removed when it exits and its output is returned.",
+				},
+				"ports": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "For run: published ports, e.g. 8080:80 or 127.0.0.1:5432:5432",
+				},
+				"env": map[string]interface{}{
+					"type":                 "object",
+					"description":          "For run: environment variables of the container",
+					"additionalProperties": map[string]interface{}{"type": "string"},
+				},
+				"mount_workspace": map[string]interface{}{
+					"type":        "boolean",
+					"description": "For run: mount the workspace at /workspace and use it as the working directory",
+				},
+				"tail": map[string]interface{}{
+					"type":        "integer",
+					"description": "For logs: number of lines from the end (default 200)",
+				},
+				"since": map[string]interface{}{
+					"type":        "string",
+					"description": "For logs: only logs newer than this, e.g. 10m or a timestamp",
+				},
+				"remove": map[string]interface{}{
+					"type":        "boolean",
+					"description": "For stop: also remove the container",
+				},
+				"timeout_seconds": map[string]interface{}{
+					"type":        "integer",
+					"description": "For build and foreground run: timeout in seconds (default 1800 for build, 600 for run, max 3600)",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+			"required": []string{"action"},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: dockerFunction,
+	}
+}
@@ -0,0 +1,438 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 计划中文件修改的类型
+const (
+	PlanCreate = "create"
+	PlanModify = "modify"
+	PlanDelete = "delete"
+)
+
+// PlannedFile 试运行中对一个文件的修改
+type PlannedFile struct {
+	Path         string      `json:"path"`                    // 相对工作目录的路径（工作目录外的文件为绝对路径）
+	Action       string      `json:"action"`                  // create、modify 或 delete
+	OriginalHash string      `json:"original_hash,omitempty"` // 试运行时文件内容的 SHA-256，新建的文件为空
+	Content      string      `json:"content,omitempty"`
+	Mode         os.FileMode `json:"mode,omitempty"`
+	Diff         string      `json:"diff,omitempty"` // 统一diff
+}
+
+// PlannedCommand 试运行中没有执行的命令
+type PlannedCommand struct {
+	Tool        string `json:"tool"`
+	Command     string `json:"command"`
+	Explanation string `json:"explanation,omitempty"`
+}
+
+// ChangePlan 试运行记录的修改计划
+type ChangePlan struct {
+	Version   int              `json:"version"`
+	Query     string           `json:"query,omitempty"`
+	SessionID string           `json:"session_id,omitempty"`
+	CreatedAt time.Time        `json:"created_at"`
+	WorkDir   string           `json:"workdir"`
+	Files     []PlannedFile    `json:"files"`
+	Commands  []PlannedCommand `json:"commands"`
+}
+
+// Empty 计划中是否没有任何修改与命令
+func (p *ChangePlan) Empty() bool {
+	return len(p.Files) == 0 && len(p.Commands) == 0
+}
+
+// overlayFile 试运行中修改过的文件：原始内容与计划后的内容
+type overlayFile struct {
+	original []byte
+	existed  bool
+	content  []byte
+	deleted  bool
+	mode     os.FileMode
+}
+
+// dryRunState 试运行状态：修改只记录在内存中，读取文件的工具看到计划后的内容
+var dryRunState = struct {
+	sync.Mutex
+	enabled  bool
+	files    map[string]*overlayFile // 键为绝对路径
+	commands []PlannedCommand
+}{}
+
+// SetDryRun 开启或关闭试运行：修改类工具只计算并记录要做的修改，不写磁盘也不执行命令
+func SetDryRun(enabled bool) {
+	dryRunState.Lock()
+	defer dryRunState.Unlock()
+	dryRunState.enabled = enabled
+	dryRunState.files = make(map[string]*overlayFile)
+	dryRunState.commands = nil
+}
+
+// IsDryRun 是否处于试运行
+func IsDryRun() bool {
+	dryRunState.Lock()
+	defer dryRunState.Unlock()
+	return dryRunState.enabled
+}
+
+// DryRunPlan 试运行到目前为止记录的修改计划，路径相对于 workDir
+func DryRunPlan(workDir string) *ChangePlan {
+	dryRunState.Lock()
+	defer dryRunState.Unlock()
+
+	plan := &ChangePlan{
+		Version:   changePlanVersion,
+		CreatedAt: time.Now(),
+		WorkDir:   workDir,
+		Files:     []PlannedFile{},
+		Commands:  append([]PlannedCommand{}, dryRunState.commands...),
+	}
+	paths := make([]string, 0, len(dryRunState.files))
+	for path := range dryRunState.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		file := dryRunState.files[path]
+		rel := path
+		if r, err := filepath.Rel(workDir, path); err == nil && !strings.HasPrefix(r, "..") {
+			rel = filepath.ToSlash(r)
+		}
+		planned := PlannedFile{Path: rel, Mode: file.mode}
+		switch {
+		case file.deleted && !file.existed:
+			continue // 新建后又删除
+		case file.deleted:
+			planned.Action = PlanDelete
+		case !file.existed:
+			planned.Action = PlanCreate
+			planned.Content = string(file.content)
+		case bytes.Equal(file.original, file.content):
+			continue // 修改后又改回
+		default:
+			planned.Action = PlanModify
+			planned.Content = string(file.content)
+		}
+		var oldContent, newContent string
+		if file.existed {
+			oldContent = string(file.original)
+			planned.OriginalHash = contentHash(file.original)
+		}
+		if !file.deleted {
+			newContent = string(file.content)
+		}
+		planned.Diff = unifiedDiff(rel, oldContent, newContent, file.existed, !file.deleted)
+		plan.Files = append(plan.Files, planned)
+	}
+	return plan
+}
+
+// errNotRunInDryRun 试运行时记录而没有执行的命令返回的错误
+func errNotRunInDryRun(command string) error {
+	return fmt.Errorf("dry run: %s was not executed, it is recorded in the change plan", command)
+}
+
+// planCommand 试运行时记录命令而不执行，返回是否处于试运行
+func planCommand(tool, command, explanation string) bool {
+	dryRunState.Lock()
+	defer dryRunState.Unlock()
+	if !dryRunState.enabled {
+		return false
+	}
+	dryRunState.commands = append(dryRunState.commands, PlannedCommand{Tool: tool, Command: command, Explanation: explanation})
+	return true
+}
+
+// overlayEntry 试运行中路径对应的记录，第一次修改时读取原始内容。调用时需持有锁
+func overlayEntry(path string) *overlayFile {
+	path = filepath.Clean(path)
+	if file, ok := dryRunState.files[path]; ok {
+		return file
+	}
+	file := &overlayFile{mode: 0644}
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		if data, err := os.ReadFile(path); err == nil {
+			file.original = data
+			file.content = data
+			file.existed = true
+			file.mode = info.Mode().Perm()
+		}
+	} else {
+		file.deleted = true
+	}
+	dryRunState.files[path] = file
+	return file
+}
+
+// overlayLookup 试运行中修改过的文件，没有修改或不在试运行时返回nil。调用时需持有锁
+func overlayLookup(path string) *overlayFile {
+	if !dryRunState.enabled {
+		return nil
+	}
+	return dryRunState.files[filepath.Clean(path)]
+}
+
+// readWorkspaceFile 读取文件，试运行时返回计划后的内容
+func readWorkspaceFile(path string) ([]byte, error) {
+	dryRunState.Lock()
+	file := overlayLookup(path)
+	dryRunState.Unlock()
+	if file == nil {
+		return os.ReadFile(path)
+	}
+	if file.deleted {
+		return nil, &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+	}
+	return append([]byte(nil), file.content...), nil
+}
+
+// openWorkspaceFile 打开文件读取，试运行时读取计划后的内容
+func openWorkspaceFile(path string) (io.ReadCloser, error) {
+	dryRunState.Lock()
+	file := overlayLookup(path)
+	dryRunState.Unlock()
+	if file == nil {
+		return os.Open(path)
+	}
+	if file.deleted {
+		return nil, &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+	}
+	return io.NopCloser(bytes.NewReader(append([]byte(nil), file.content...))), nil
+}
+
+// statWorkspaceFile 文件信息，试运行时反映计划后的状态
+func statWorkspaceFile(path string) (os.FileInfo, error) {
+	dryRunState.Lock()
+	file := overlayLookup(path)
+	dryRunState.Unlock()
+	if file == nil {
+		return os.Stat(path)
+	}
+	if file.deleted {
+		return nil, &fs.PathError{Op: "stat", Path: path, Err: fs.ErrNotExist}
+	}
+	return plannedFileInfo{name: filepath.Base(path), size: int64(len(file.content)), mode: file.mode}, nil
+}
+
+// makeWorkspaceDir 创建目录，试运行时不创建
+func makeWorkspaceDir(dir string) error {
+	if IsDryRun() {
+		return nil
+	}
+	return os.MkdirAll(dir, 0755)
+}
+
+// writeWorkspaceFile 写入文件，试运行时只记录计划后的内容
+func writeWorkspaceFile(path string, data []byte, perm os.FileMode) error {
+	noteWorkspaceChange(path)
+	dryRunState.Lock()
+	defer dryRunState.Unlock()
+	if !dryRunState.enabled {
+		return os.WriteFile(path, data, perm)
+	}
+	file := overlayEntry(path)
+	if !file.existed && file.deleted {
+		file.mode = perm
+	}
+	file.content = append([]byte(nil), data...)
+	file.deleted = false
+	return nil
+}
+
+// appendWorkspaceFile 追加内容（文件不存在时创建），试运行时只记录计划后的内容
+func appendWorkspaceFile(path string, data []byte) error {
+	noteWorkspaceChange(path)
+	dryRunState.Lock()
+	defer dryRunState.Unlock()
+	if !dryRunState.enabled {
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = file.Write(data)
+		return err
+	}
+	file := overlayEntry(path)
+	if file.deleted {
+		file.content = nil
+		file.deleted = false
+	}
+	file.content = append(append([]byte(nil), file.content...), data...)
+	return nil
+}
+
+// removeWorkspaceFile 删除文件，试运行时只记录删除
+func removeWorkspaceFile(path string) error {
+	noteWorkspaceChange(path)
+	dryRunState.Lock()
+	defer dryRunState.Unlock()
+	if !dryRunState.enabled {
+		return os.Remove(path)
+	}
+	file := overlayEntry(path)
+	if file.deleted {
+		return &fs.PathError{Op: "remove", Path: path, Err: fs.ErrNotExist}
+	}
+	file.deleted = true
+	file.content = nil
+	return nil
+}
+
+// plannedFileInfo 试运行中计划后的文件信息
+type plannedFileInfo struct {
+	name string
+	size int64
+	mode os.FileMode
+}
+
+func (i plannedFileInfo) Name() string       { return i.name }
+func (i plannedFileInfo) Size() int64        { return i.size }
+func (i plannedFileInfo) Mode() os.FileMode  { return i.mode }
+func (i plannedFileInfo) ModTime() time.Time { return time.Now() }
+func (i plannedFileInfo) IsDir() bool        { return false }
+func (i plannedFileInfo) Sys() interface{}   { return nil }
+
+// maxDiffCells 逐行比较的规模上限（行数之积），超出时整个文件作为一个修改块
+const maxDiffCells = 4_000_000
+
+// diffContext 修改块前后的上下文行数
+const diffContext = 3
+
+// unifiedDiff 两个版本的统一diff，oldExists 或 newExists 为 false 时对应 /dev/null
+func unifiedDiff(path, oldContent, newContent string, oldExists, newExists bool) string {
+	oldLines := splitDiffLines(oldContent)
+	newLines := splitDiffLines(newContent)
+
+	var sb strings.Builder
+	oldName, newName := "a/"+path, "b/"+path
+	if !oldExists {
+		oldName = "/dev/null"
+	}
+	if !newExists {
+		newName = "/dev/null"
+	}
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+
+	// ops: ' ' 相同，'-' 删除，'+' 新增
+	type op struct {
+		kind byte
+		line string
+	}
+	var ops []op
+	if len(oldLines)*len(newLines) > maxDiffCells {
+		for _, line := range oldLines {
+			ops = append(ops, op{'-', line})
+		}
+		for _, line := range newLines {
+			ops = append(ops, op{'+', line})
+		}
+	} else {
+		// 最长公共子序列
+		n, m := len(oldLines), len(newLines)
+		lcs := make([][]int, n+1)
+		for i := range lcs {
+			lcs[i] = make([]int, m+1)
+		}
+		for i := n - 1; i >= 0; i-- {
+			for j := m - 1; j >= 0; j-- {
+				if oldLines[i] == newLines[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else if lcs[i+1][j] >= lcs[i][j+1] {
+					lcs[i][j] = lcs[i+1][j]
+				} else {
+					lcs[i][j] = lcs[i][j+1]
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < n || j < m {
+			switch {
+			case i < n && j < m && oldLines[i] == newLines[j]:
+				ops = append(ops, op{' ', oldLines[i]})
+				i++
+				j++
+			case i < n && (j == m || lcs[i+1][j] >= lcs[i][j+1]):
+				ops = append(ops, op{'-', oldLines[i]})
+				i++
+			default:
+				ops = append(ops, op{'+', newLines[j]})
+				j++
+			}
+		}
+	}
+
+	// 相距不超过两倍上下文的修改合并为一个修改块
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		last := i
+		for k := i + 1; k < len(ops) && k-last <= 2*diffContext; k++ {
+			if ops[k].kind != ' ' {
+				last = k
+			}
+		}
+		from := i - diffContext
+		if from < 0 {
+			from = 0
+		}
+		end := last + diffContext + 1
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		oldStart, newStart := 1, 1
+		for _, o := range ops[:from] {
+			if o.kind != '+' {
+				oldStart++
+			}
+			if o.kind != '-' {
+				newStart++
+			}
+		}
+		oldCount, newCount := 0, 0
+		for _, o := range ops[from:end] {
+			if o.kind != '+' {
+				oldCount++
+			}
+			if o.kind != '-' {
+				newCount++
+			}
+		}
+		if oldCount == 0 {
+			oldStart--
+		}
+		if newCount == 0 {
+			newStart--
+		}
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+		for _, o := range ops[from:end] {
+			sb.WriteByte(o.kind)
+			sb.WriteString(o.line)
+			sb.WriteByte('\n')
+		}
+		i = end
+	}
+	return sb.String()
+}
+
+// splitDiffLines 按行拆分，忽略末尾的换行
+func splitDiffLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
@@ -0,0 +1,24 @@
+package tools
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// maxEditDiffBytes 编辑工具结果中diff的大小上限，超出部分截断
+const maxEditDiffBytes = 16 * 1024
+
+// editDiff 编辑工具结果中的统一diff：工作目录内的文件使用相对路径，过长时在行边界截断
+func editDiff(workDir, path, before, after string, existed bool) string {
+	rel := path
+	if r, err := filepath.Rel(workDir, path); workDir != "" && err == nil && !strings.HasPrefix(r, "..") {
+		rel = filepath.ToSlash(r)
+	}
+	diff := unifiedDiff(rel, before, after, existed, true)
+	if len(diff) <= maxEditDiffBytes {
+		return diff
+	}
+	cut := strings.LastIndex(diff[:maxEditDiffBytes], "\n") + 1
+	return diff[:cut] + fmt.Sprintf("... diff truncated (%d more bytes), read the file to see the rest\n", len(diff)-cut)
+}
@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"os"
+	"strings"
+	"sync"
+)
+
+// EditedFile 修改类工具改动过的文件，增删行数相对于第一次改动之前的内容
+type EditedFile struct {
+	Path    string `json:"path"` // 工作目录内为相对路径，使用 /
+	Added   int    `json:"added"`
+	Deleted int    `json:"deleted"`
+	Created bool   `json:"created,omitempty"`
+	Removed bool   `json:"removed,omitempty"` // 文件已被删除
+}
+
+// editHistory 修改类工具改动过的文件及第一次改动前的内容
+type editHistory struct {
+	mu    sync.Mutex
+	order []string                 // 绝对路径，最近改动的在后
+	files map[string]*fileSnapshot // 绝对路径 -> 第一次改动前的内容
+}
+
+// record 记录一次改动，同一文件只保留第一次改动前的内容
+func (h *editHistory) record(snapshot *fileSnapshot) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.files == nil {
+		h.files = make(map[string]*fileSnapshot)
+	}
+	if _, ok := h.files[snapshot.path]; !ok {
+		h.files[snapshot.path] = snapshot
+	}
+	for i, path := range h.order {
+		if path == snapshot.path {
+			h.order = append(h.order[:i], h.order[i+1:]...)
+			break
+		}
+	}
+	h.order = append(h.order, snapshot.path)
+}
+
+// take 取出改动过的文件（最近改动的在前）并清空记录。改回原内容的文件不列出
+func (h *editHistory) take() []EditedFile {
+	h.mu.Lock()
+	order, files := h.order, h.files
+	h.order, h.files = nil, nil
+	h.mu.Unlock()
+
+	var edited []EditedFile
+	for i := len(order) - 1; i >= 0; i-- {
+		original := files[order[i]]
+		data, err := os.ReadFile(original.path)
+		exists := err == nil
+		if exists == original.existed && string(data) == original.content {
+			continue
+		}
+		file := EditedFile{Path: original.rel, Created: !original.existed, Removed: !exists}
+		file.Added, file.Deleted = diffStats(original.content, string(data))
+		edited = append(edited, file)
+	}
+	return edited
+}
+
+// diffStats 两个版本之间新增与删除的行数
+func diffStats(before, after string) (int, int) {
+	added, deleted := 0, 0
+	lines := strings.Split(unifiedDiff("", before, after, true, true), "\n")
+	for _, line := range lines[2:] { // 跳过 ---/+++ 两行文件头
+		switch {
+		case strings.HasPrefix(line, "+"):
+			added++
+		case strings.HasPrefix(line, "-"):
+			deleted++
+		}
+	}
+	return added, deleted
+}
+
+// TakeEditedFiles 取出修改类工具自上次调用以来改动过的文件并清空记录
+func (tm *DefaultToolManager) TakeEditedFiles() []EditedFile {
+	return tm.edits.take()
+}
+
+// TakeDefaultEditedFiles 取出默认工具管理器记录的改动过的文件
+func TakeDefaultEditedFiles() []EditedFile {
+	if tm, ok := DefaultRegistry.manager.(*DefaultToolManager); ok {
+		return tm.TakeEditedFiles()
+	}
+	return nil
+}
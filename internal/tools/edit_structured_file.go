@@ -0,0 +1,187 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"openCursor/internal/structedit"
+)
+
+// EditStructuredFileParams edit_structured_file工具的参数
+type EditStructuredFileParams struct {
+	TargetFile  string      `json:"target_file"`
+	Operation   string      `json:"operation"`
+	Path        string      `json:"path"`
+	Value       interface{} `json:"value,omitempty"`
+	Format      string      `json:"format,omitempty"`
+	Document    int         `json:"document,omitempty"`
+	Explanation string      `json:"explanation,omitempty"`
+}
+
+// EditStructuredFileResult edit_structured_file工具的返回结果
+type EditStructuredFileResult struct {
+	TargetFile string `json:"target_file"`
+	Format     string `json:"format"`
+	Operation  string `json:"operation"`
+	Path       string `json:"path"`
+	Previous   string `json:"previous,omitempty"`
+	Value      string `json:"value,omitempty"`
+	Created    bool   `json:"created,omitempty"`
+	Modified   bool   `json:"modified"`
+	Message    string `json:"message"`
+}
+
+// editStructuredFileFunction 结构化编辑工具函数
+func editStructuredFileFunction(params map[string]interface{}) (interface{}, error) {
+	// 解析参数
+	targetFile, ok := params["target_file"].(string)
+	if !ok || targetFile == "" {
+		return nil, fmt.Errorf("target_file is required")
+	}
+
+	operation, ok := params["operation"].(string)
+	if !ok || operation == "" {
+		return nil, fmt.Errorf("operation is required")
+	}
+
+	pathExpr, _ := params["path"].(string)
+	path, err := structedit.ParsePath(pathExpr)
+	if err != nil {
+		return nil, err
+	}
+
+	value, hasValue := params["value"]
+	op := structedit.Operation(operation)
+	if (op == structedit.OpSet || op == structedit.OpAppend) && !hasValue {
+		return nil, fmt.Errorf("value is required for %s", operation)
+	}
+
+	document, _ := intParam(params, "document")
+	formatName, _ := params["format"].(string)
+	workDir, _ := params["__work_dir__"].(string)
+
+	// 解析文件路径
+	filePath := targetFile
+	if !filepath.IsAbs(filePath) && workDir != "" {
+		filePath = filepath.Join(workDir, targetFile)
+	}
+
+	format := structedit.Format(formatName)
+	if format == "" {
+		format, err = structedit.DetectFormat(filePath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 文件不存在时，set/append 会创建新文件
+	content, err := readWorkspaceFile(filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if err != nil && op != structedit.OpSet && op != structedit.OpAppend {
+		return nil, fmt.Errorf("file not found: %s", filePath)
+	}
+
+	edit, err := structedit.Apply(content, format, structedit.Request{
+		Operation: op,
+		Path:      path,
+		Value:     value,
+		Document:  document,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &EditStructuredFileResult{
+		TargetFile: filePath,
+		Format:     string(format),
+		Operation:  operation,
+		Path:       structedit.FormatPath(path),
+		Previous:   edit.Previous,
+		Value:      edit.Value,
+		Created:    edit.Created,
+	}
+
+	if op == structedit.OpGet {
+		result.Message = fmt.Sprintf("Read %s from %s", result.Path, targetFile)
+		return result, nil
+	}
+
+	if string(edit.Content) == string(content) {
+		result.Message = fmt.Sprintf("%s already has the requested value, file unchanged", result.Path)
+		return result, nil
+	}
+
+	if err := makeWorkspaceDir(filepath.Dir(filePath)); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := writeWorkspaceFile(filePath, edit.Content, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write file: %w", err)
+	}
+	result.Modified = true
+
+	switch op {
+	case structedit.OpSet:
+		if edit.Created {
+			result.Message = fmt.Sprintf("Created %s in %s", result.Path, targetFile)
+		} else {
+			result.Message = fmt.Sprintf("Updated %s in %s", result.Path, targetFile)
+		}
+	case structedit.OpDelete:
+		result.Message = fmt.Sprintf("Deleted %s from %s", result.Path, targetFile)
+	case structedit.OpAppend:
+		result.Message = fmt.Sprintf("Appended to %s in %s", result.Path, targetFile)
+	}
+
+	return result, nil
+}
+
+// NewEditStructuredFileTool 创建edit_structured_file工具
+func NewEditStructuredFileTool() Tool {
+	schema := ToolSchema{
+		Name:        "edit_structured_file",
+		Description: "Read or edit a YAML or JSON file by path expression instead of by text, e.g. set `.spec.replicas` to 3 in a Kubernetes manifest or add a dependency in package.json. Much more reliable than search_replace for config files. Comments and formatting are preserved: JSON/JSONC files are edited in place, YAML comments are kept (a YAML file may be re-indented when a non-scalar value changes). Path syntax: `.a.b`, `.items[0].name`, `.items[-1]` for the last element, `.labels[\"app.kubernetes.io/name\"]` for keys containing dots. Missing intermediate objects are created on set.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"target_file": map[string]interface{}{
+					"type":        "string",
+					"description": "The YAML or JSON file to edit, relative to the workspace root or absolute. Created if it does not exist (set/append only).",
+				},
+				"operation": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"get", "set", "delete", "append"},
+					"description": "get: read the value at path. set: set the value at path. delete: remove the key or array element at path. append: add value to the end of the array at path.",
+				},
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Path expression to the value, e.g. '.spec.replicas' or '.dependencies[\"left-pad\"]'. Use '.' for the document root.",
+				},
+				"value": map[string]interface{}{
+					"description": "The new value for set/append, as a JSON value (number, string, boolean, null, array or object).",
+				},
+				"format": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"yaml", "json"},
+					"description": "Optional file format; detected from the file extension by default.",
+				},
+				"document": map[string]interface{}{
+					"type":        "integer",
+					"description": "Zero-based document index for multi-document YAML files (separated by ---). Defaults to 0.",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+			"required": []string{"target_file", "operation", "path"},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: editStructuredFileFunction,
+	}
+}
\ No newline at end of file
@@ -0,0 +1,356 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// envFileActions env_file工具支持的操作
+var envFileActions = []string{"list", "set", "unset", "example"}
+
+// envKeyPattern .env 中合法的变量名
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// EnvFileKey .env 中的一个变量，不包含值
+type EnvFileKey struct {
+	Key   string `json:"key"`
+	Line  int    `json:"line"`
+	Empty bool   `json:"empty,omitempty"`
+}
+
+// EnvFileResult env_file工具的返回结果，任何操作都不返回变量的值
+type EnvFileResult struct {
+	Path    string       `json:"path"`
+	Keys    []EnvFileKey `json:"keys,omitempty"`
+	Added   []string     `json:"added,omitempty"`
+	Updated []string     `json:"updated,omitempty"`
+	Removed []string     `json:"removed,omitempty"`
+	Created bool         `json:"created,omitempty"`
+	Message string       `json:"message"`
+}
+
+// envLine .env 的一行；不是变量的行（注释、空行）key 为空，原样保留
+type envLine struct {
+	text   string
+	key    string
+	export bool
+	value  string
+}
+
+// parseEnvFile 按行解析 .env 内容
+func parseEnvFile(content string) []envLine {
+	if content == "" {
+		return nil
+	}
+	var lines []envLine
+	for _, text := range strings.Split(strings.TrimSuffix(content, "\n"), "\n") {
+		line := envLine{text: strings.TrimSuffix(text, "\r")}
+		trimmed := strings.TrimSpace(line.text)
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			if rest := strings.TrimPrefix(trimmed, "export "); rest != trimmed {
+				line.export = true
+				trimmed = strings.TrimSpace(rest)
+			}
+			if i := strings.IndexByte(trimmed, '='); i > 0 {
+				if key := strings.TrimSpace(trimmed[:i]); envKeyPattern.MatchString(key) {
+					line.key = key
+					line.value = unquoteEnvValue(strings.TrimSpace(trimmed[i+1:]))
+				}
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// unquoteEnvValue 去掉值两侧的引号，未加引号时去掉行尾注释
+func unquoteEnvValue(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value
+}
+
+// formatEnvValue 需要时给值加上双引号
+func formatEnvValue(value string) string {
+	if value == "" || !strings.ContainsAny(value, " \t#\"'$\\\n=") {
+		return value
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// formatEnvLine 格式化一行变量定义
+func formatEnvLine(key, value string, export bool) string {
+	line := key + "=" + formatEnvValue(value)
+	if export {
+		line = "export " + line
+	}
+	return line
+}
+
+// joinEnvLines 将各行拼接为文件内容，沿用原有的换行符
+func joinEnvLines(lines []envLine, lineEnding string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	texts := make([]string, len(lines))
+	for i, line := range lines {
+		texts[i] = line.text
+	}
+	return strings.Join(texts, lineEnding) + lineEnding
+}
+
+// envFileFunction 管理 .env 文件工具函数
+func envFileFunction(params map[string]interface{}) (interface{}, error) {
+	action, _ := params["action"].(string)
+	if action == "" {
+		action = "list"
+	}
+	target, _ := params["path"].(string)
+	if target == "" {
+		target = ".env"
+	}
+	workDir, _ := params["__work_dir__"].(string)
+	filePath := target
+	if !filepath.IsAbs(filePath) {
+		filePath = filepath.Join(workDir, target)
+	}
+
+	data, err := readWorkspaceFile(filePath)
+	exists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", target, err)
+	}
+	content := string(data)
+	lines := parseEnvFile(content)
+	result := &EnvFileResult{Path: target}
+
+	switch action {
+	case "list":
+		if !exists {
+			return nil, fmt.Errorf("file not found: %s", target)
+		}
+		for i, line := range lines {
+			if line.key != "" {
+				result.Keys = append(result.Keys, EnvFileKey{Key: line.key, Line: i + 1, Empty: line.value == ""})
+			}
+		}
+		result.Message = fmt.Sprintf("%d keys in %s (values are not shown)", len(result.Keys), target)
+		return result, nil
+
+	case "set":
+		values, ok := params["values"].(map[string]interface{})
+		if !ok || len(values) == 0 {
+			return nil, fmt.Errorf("values is required for set and must map keys to values")
+		}
+		keys := make([]string, 0, len(values))
+		for key, value := range values {
+			if !envKeyPattern.MatchString(key) {
+				return nil, fmt.Errorf("invalid key %q", key)
+			}
+			if _, ok := value.(string); !ok {
+				return nil, fmt.Errorf("value of %s must be a string", key)
+			}
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value := values[key].(string)
+			found := false
+			for i := range lines {
+				if lines[i].key == key {
+					// 重复定义的变量都更新，避免后面的定义覆盖新值
+					lines[i].text = formatEnvLine(key, value, lines[i].export)
+					lines[i].value = value
+					found = true
+				}
+			}
+			if found {
+				result.Updated = append(result.Updated, key)
+			} else {
+				lines = append(lines, envLine{text: formatEnvLine(key, value, false), key: key, value: value})
+				result.Added = append(result.Added, key)
+			}
+		}
+
+	case "unset":
+		list, _ := params["keys"].([]interface{})
+		if len(list) == 0 {
+			return nil, fmt.Errorf("keys is required for unset")
+		}
+		if !exists {
+			return nil, fmt.Errorf("file not found: %s", target)
+		}
+		remove := make(map[string]bool)
+		for _, item := range list {
+			if key, ok := item.(string); ok && key != "" {
+				remove[key] = true
+			}
+		}
+		kept := lines[:0]
+		seen := make(map[string]bool)
+		for _, line := range lines {
+			if line.key != "" && remove[line.key] {
+				if !seen[line.key] {
+					seen[line.key] = true
+					result.Removed = append(result.Removed, line.key)
+				}
+				continue
+			}
+			kept = append(kept, line)
+		}
+		lines = kept
+		if len(result.Removed) == 0 {
+			result.Message = fmt.Sprintf("none of the keys are in %s", target)
+			return result, nil
+		}
+
+	case "example":
+		if !exists {
+			return nil, fmt.Errorf("file not found: %s", target)
+		}
+		return writeEnvExample(params, workDir, target, filePath, lines)
+
+	default:
+		return nil, fmt.Errorf("unknown action %q (expected one of %s)", action, strings.Join(envFileActions, ", "))
+	}
+
+	if err := checkSecurityPolicy(policyWrite, filePath, workDir); err != nil {
+		return nil, err
+	}
+	if !exists {
+		if err := makeWorkspaceDir(filepath.Dir(filePath)); err != nil {
+			return nil, fmt.Errorf("failed to create directory: %w", err)
+		}
+		result.Created = true
+	}
+	if err := writeWorkspaceFile(filePath, []byte(joinEnvLines(lines, detectLineEnding(content))), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	result.Message = fmt.Sprintf("updated %s: %d added, %d updated, %d removed", target, len(result.Added), len(result.Updated), len(result.Removed))
+	return result, nil
+}
+
+// writeEnvExample 生成或补全 .env.example：已有的示例文件保留原内容（包括示例值），只追加缺少的变量；
+// 新建时沿用 .env 的注释与顺序，值全部留空
+func writeEnvExample(params map[string]interface{}, workDir, target, filePath string, lines []envLine) (interface{}, error) {
+	example, _ := params["example_path"].(string)
+	if example == "" {
+		example = filepath.Join(filepath.Dir(target), ".env.example")
+	}
+	examplePath := example
+	if !filepath.IsAbs(examplePath) {
+		examplePath = filepath.Join(workDir, example)
+	}
+	if examplePath == filePath {
+		return nil, fmt.Errorf("example_path must differ from path")
+	}
+	if err := checkSecurityPolicy(policyWrite, examplePath, workDir); err != nil {
+		return nil, err
+	}
+
+	data, err := readWorkspaceFile(examplePath)
+	exists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", example, err)
+	}
+	result := &EnvFileResult{Path: example, Created: !exists}
+
+	var output []envLine
+	if exists {
+		output = parseEnvFile(string(data))
+		present := make(map[string]bool)
+		for _, line := range output {
+			present[line.key] = true
+		}
+		for _, line := range lines {
+			if line.key != "" && !present[line.key] {
+				present[line.key] = true
+				output = append(output, envLine{text: formatEnvLine(line.key, "", line.export), key: line.key})
+				result.Added = append(result.Added, line.key)
+			}
+		}
+	} else {
+		seen := make(map[string]bool)
+		for _, line := range lines {
+			if line.key == "" {
+				output = append(output, line)
+				continue
+			}
+			if seen[line.key] {
+				continue
+			}
+			seen[line.key] = true
+			output = append(output, envLine{text: formatEnvLine(line.key, "", line.export), key: line.key})
+			result.Added = append(result.Added, line.key)
+		}
+	}
+	if exists && len(result.Added) == 0 {
+		result.Message = fmt.Sprintf("%s already lists every key in %s", example, target)
+		return result, nil
+	}
+
+	if !exists {
+		if err := makeWorkspaceDir(filepath.Dir(examplePath)); err != nil {
+			return nil, fmt.Errorf("failed to create directory: %w", err)
+		}
+	}
+	if err := writeWorkspaceFile(examplePath, []byte(joinEnvLines(output, detectLineEnding(string(data)))), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", example, err)
+	}
+	result.Message = fmt.Sprintf("wrote %s with %d new keys (values left empty)", example, len(result.Added))
+	return result, nil
+}
+
+// NewEnvFileTool 创建env_file工具
+func NewEnvFileTool() Tool {
+	schema := ToolSchema{
+		Name:        "env_file",
+		Description: "Manage .env files without seeing secret values: list the keys (values are never returned, only whether they are empty), set keys (adding or updating them in place, keeping comments and order), unset keys, or generate/complete .env.example with the keys and empty values. Use this instead of read_file or write_file for .env files, and never ask for or echo secret values.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"action": map[string]interface{}{
+					"type":        "string",
+					"description": "list (default), set, unset or example",
+					"enum":        envFileActions,
+				},
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "The .env file, relative to the workspace root. Defaults to .env.",
+				},
+				"values": map[string]interface{}{
+					"type":                 "object",
+					"description":          "For set: keys and their new values. Only use values the user provided or non-secret defaults.",
+					"additionalProperties": map[string]interface{}{"type": "string"},
+				},
+				"keys": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "For unset: keys to remove",
+				},
+				"example_path": map[string]interface{}{
+					"type":        "string",
+					"description": "For example: the example file to write. Defaults to .env.example next to path.",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: envFileFunction,
+	}
+}
@@ -2,10 +2,11 @@ package tools
 
 import (
 	"fmt"
-	"os"
+	"io/fs"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 )
 
 // FileSearchParams file_search工具的参数
@@ -28,6 +29,14 @@ type FileSearchResult struct {
 	Count   int         `json:"count"`
 }
 
+// fileSearchSkipExtensions 文件搜索跳过的二进制、压缩包与媒体文件扩展名
+var fileSearchSkipExtensions = map[string]bool{
+	".exe": true, ".dll": true, ".so": true, ".dylib": true, ".o": true, ".a": true,
+	".jar": true, ".war": true, ".zip": true, ".tar": true, ".gz": true, ".7z": true, ".rar": true,
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".bmp": true, ".svg": true, ".ico": true,
+	".mp3": true, ".mp4": true, ".avi": true, ".mov": true, ".wav": true, ".pdf": true,
+}
+
 // calculateFuzzyScore 计算模糊匹配分数
 func calculateFuzzyScore(query, path string) float64 {
 	query = strings.ToLower(query)
@@ -98,59 +107,54 @@ func fileSearchFunction(params map[string]interface{}) (interface{}, error) {
 		Matches: []FileMatch{},
 	}
 
-	var allFiles []string
-	
-	// 遍历目录收集所有文件
-	err := filepath.Walk(searchPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil // 忽略错误，继续处理其他文件
+	// 未指定 workspace 时同时搜索所有工作区根目录
+	searchPaths := []string{searchPath}
+	if workspaces, ok := params["__workspaces__"].([]Workspace); ok {
+		for _, ws := range workspaces {
+			searchPaths = append(searchPaths, ws.Path)
 		}
-		
-		// 跳过目录和隐藏文件
-		if info.IsDir() || strings.HasPrefix(filepath.Base(path), ".") {
-			return nil
+	}
+	
+	// 并行遍历目录，计算匹配分数并过滤
+	var (
+		mu      sync.Mutex
+		matches []FileMatch
+	)
+	err := parallelWalk(searchPaths, nil, func(path string, d fs.DirEntry) {
+		// 跳过隐藏文件
+		if strings.HasPrefix(d.Name(), ".") {
+			return
 		}
 		
 		// 跳过一些常见的不需要搜索的文件类型
-		ext := strings.ToLower(filepath.Ext(path))
-		skipExtensions := []string{".exe", ".dll", ".so", ".dylib", ".o", ".a", 
-			".jar", ".war", ".zip", ".tar", ".gz", ".7z", ".rar",
-			".jpg", ".jpeg", ".png", ".gif", ".bmp", ".svg", ".ico",
-			".mp3", ".mp4", ".avi", ".mov", ".wav", ".pdf"}
-		
-		for _, skipExt := range skipExtensions {
-			if ext == skipExt {
-				return nil
-			}
+		if fileSearchSkipExtensions[strings.ToLower(filepath.Ext(path))] {
+			return
 		}
 		
-		allFiles = append(allFiles, path)
-		return nil
+		score := calculateFuzzyScore(query, path)
+		if score <= 0 {
+			return
+		}
+		// 生成匹配描述
+		match := FileMatch{
+			Path:  path,
+			Score: score,
+			Match: generateMatchDescription(query, path),
+		}
+		mu.Lock()
+		matches = append(matches, match)
+		mu.Unlock()
 	})
-	
 	if err != nil {
-		return nil, fmt.Errorf("failed to walk directory: %w", err)
-	}
-
-	// 计算匹配分数并过滤
-	var matches []FileMatch
-	for _, file := range allFiles {
-		score := calculateFuzzyScore(query, file)
-		if score > 0 {
-			// 生成匹配描述
-			match := generateMatchDescription(query, file)
-			
-			matches = append(matches, FileMatch{
-				Path:  file,
-				Score: score,
-				Match: match,
-			})
-		}
+		return nil, err
 	}
 
-	// 按分数排序
+	// 按分数排序，分数相同时按路径（遍历顺序不确定）
 	sort.Slice(matches, func(i, j int) bool {
-		return matches[i].Score > matches[j].Score
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Path < matches[j].Path
 	})
 
 	// 限制结果数量为10个
@@ -0,0 +1,151 @@
+package tools
+
+import (
+	"fmt"
+	"sync"
+
+	"openCursor/internal/symbols"
+)
+
+// maxSymbolResults 最多返回的符号数量
+const maxSymbolResults = 50
+
+// symbolIndexes 按工作目录缓存的符号索引，每次查询前只重新解析修改过的文件
+var (
+	symbolIndexes   = make(map[string]*symbols.Index)
+	symbolIndexesMu sync.Mutex
+)
+
+// symbolIndex 获取并刷新工作目录的符号索引
+func symbolIndex(workDir string) (*symbols.Index, error) {
+	if workDir == "" {
+		workDir = "."
+	}
+	symbolIndexesMu.Lock()
+	idx, ok := symbolIndexes[workDir]
+	if !ok {
+		idx = symbols.New(workDir)
+		symbolIndexes[workDir] = idx
+	}
+	symbolIndexesMu.Unlock()
+	if err := idx.Refresh(); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// FindSymbolResult find_symbol工具的返回结果
+type FindSymbolResult struct {
+	Query     string           `json:"query"`
+	Backend   string           `json:"backend"` // ctags 或 builtin（Go 文件总是使用语法树）
+	Symbols   []symbols.Symbol `json:"symbols"`
+	Count     int              `json:"count"`
+	Truncated bool             `json:"truncated,omitempty"`
+}
+
+// findSymbolFunction 查找符号定义工具函数
+func findSymbolFunction(params map[string]interface{}) (interface{}, error) {
+	query, _ := params["name"].(string)
+	if query == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	kind, _ := params["kind"].(string)
+	workDir, _ := params["__work_dir__"].(string)
+
+	idx, err := symbolIndex(workDir)
+	if err != nil {
+		return nil, err
+	}
+	found := idx.Find(query, kind, maxSymbolResults+1)
+	result := &FindSymbolResult{Query: query, Backend: idx.Backend(), Symbols: found}
+	if len(found) > maxSymbolResults {
+		result.Symbols = found[:maxSymbolResults]
+		result.Truncated = true
+	}
+	if result.Symbols == nil {
+		result.Symbols = []symbols.Symbol{}
+	}
+	result.Count = len(result.Symbols)
+	return result, nil
+}
+
+// NewFindSymbolTool 创建find_symbol工具
+func NewFindSymbolTool() Tool {
+	schema := ToolSchema{
+		Name:        "find_symbol",
+		Description: "Find where functions, methods, types, classes and constants are defined by name, using an offline symbol index (universal-ctags when installed, otherwise built-in parsers). Exact matches come first, then case-insensitive, prefix and substring matches. Use Type.method to look up a method of a specific type.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"name": map[string]interface{}{
+					"type":        "string",
+					"description": "The symbol name or part of it, optionally qualified with its type or class, e.g. 'Client.Send'.",
+				},
+				"kind": map[string]interface{}{
+					"type":        "string",
+					"description": "Only return symbols of this kind, e.g. function, method, struct, class, interface, constant.",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+			"required": []string{"name"},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: findSymbolFunction,
+	}
+}
+
+// symbolDefinitionFunction 没有语言服务器时按符号名在符号索引中查找定义
+func symbolDefinitionFunction(params map[string]interface{}) (interface{}, error) {
+	filePath, _ := params["file_path"].(string)
+	symbol, _ := params["symbol"].(string)
+	if symbol == "" {
+		return nil, fmt.Errorf("symbol is required")
+	}
+	workDir, _ := params["__work_dir__"].(string)
+
+	idx, err := symbolIndex(workDir)
+	if err != nil {
+		return nil, err
+	}
+	result := &GoToDefinitionResult{
+		Server:      "symbol-index:" + idx.Backend(),
+		Definitions: []SymbolLocation{},
+	}
+	for _, definition := range idx.Definitions(symbol, filePath) {
+		result.Definitions = append(result.Definitions, SymbolLocation{
+			File:    definition.Path,
+			Line:    definition.Line,
+			Column:  definition.Column,
+			Snippet: definition.Signature,
+		})
+		if len(result.Definitions) >= maxSymbolResults {
+			break
+		}
+	}
+	result.Count = len(result.Definitions)
+	return result, nil
+}
+
+// NewSymbolDefinitionTool 创建基于符号索引的go_to_definition工具，用于没有安装语言服务器时
+func NewSymbolDefinitionTool() Tool {
+	schema := ToolSchema{
+		Name:        "go_to_definition",
+		Description: "Find where a symbol is defined using an offline symbol index (no language server is installed). Give the file and line where the symbol is used, plus the symbol name. Matches by name only, so several candidates may be returned; those in the same file and directory come first.",
+		InputSchema: map[string]interface{}{
+			"type":       "object",
+			"properties": lspPositionProperties(),
+			"required":   []string{"file_path", "line", "symbol"},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: symbolDefinitionFunction,
+	}
+}
\ No newline at end of file
@@ -0,0 +1,348 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxBuildDiagnostics 最多返回的诊断数
+const maxBuildDiagnostics = 200
+
+// builders 支持的构建工具
+var builders = []string{"go", "tsc", "cargo"}
+
+// 各构建工具的诊断格式
+var (
+	goBuildPattern    = regexp.MustCompile(`^(\S+\.go):(\d+)(?::(\d+))?: (.*)$`)
+	tscBuildPattern   = regexp.MustCompile(`^(.+?)\((\d+),(\d+)\): (error|warning) (TS\d+): (.*)$`)
+	cargoBuildPattern = regexp.MustCompile(`^(.+?):(\d+):(\d+): (error|warning)(?:\[(\w+)\])?: (.*)$`)
+)
+
+// BuildDiagnostic 一条编译诊断
+type BuildDiagnostic struct {
+	Line     int    `json:"line"`
+	Column   int    `json:"column,omitempty"`
+	Severity string `json:"severity"` // error 或 warning
+	Code     string `json:"code,omitempty"`
+	Message  string `json:"message"`
+}
+
+// BuildFileDiagnostics 一个文件的诊断
+type BuildFileDiagnostics struct {
+	File        string            `json:"file"`
+	Errors      int               `json:"errors"`
+	Warnings    int               `json:"warnings,omitempty"`
+	Diagnostics []BuildDiagnostic `json:"diagnostics"`
+}
+
+// GetBuildErrorsResult get_build_errors工具的返回结果
+type GetBuildErrorsResult struct {
+	Builder   string                 `json:"builder"`
+	Command   string                 `json:"command"`
+	Directory string                 `json:"directory"`
+	Success   bool                   `json:"success"`
+	ExitCode  int                    `json:"exit_code"`
+	TimedOut  bool                   `json:"timed_out,omitempty"`
+	Errors    int                    `json:"errors"`
+	Warnings  int                    `json:"warnings"`
+	Files     []BuildFileDiagnostics `json:"files"`
+	Other     []string               `json:"other,omitempty"`     // 没有位置的错误（如依赖或配置问题）
+	Truncated bool                   `json:"truncated,omitempty"` // 诊断超过上限
+	Output    string                 `json:"output,omitempty"`    // 构建失败而没有解析出诊断时的原始输出
+	Duration  string                 `json:"duration"`
+}
+
+// getBuildErrorsFunction 获取编译错误工具函数
+func getBuildErrorsFunction(params map[string]interface{}) (interface{}, error) {
+	target, _ := params["path"].(string)
+	builder, _ := params["builder"].(string)
+	workDir, _ := params["__work_dir__"].(string)
+	if workDir == "" {
+		workDir = "."
+	}
+	workDir, _ = filepath.Abs(workDir)
+	timeout := defaultTestTimeout
+	if t, ok := intParam(params, "timeout_seconds"); ok && t > 0 {
+		timeout = min(t, maxTestTimeout)
+	}
+
+	targetPath := ""
+	if target != "" {
+		targetPath = target
+		if !filepath.IsAbs(targetPath) {
+			targetPath = filepath.Join(workDir, target)
+		}
+		if _, err := os.Stat(targetPath); err != nil {
+			return nil, fmt.Errorf("path not found: %s", target)
+		}
+	}
+	projectDir, detected := detectBuilder(targetPath, workDir)
+	if builder == "" {
+		builder = detected
+	}
+	if builder == "" {
+		return nil, fmt.Errorf("cannot detect how to build %q (no go.mod, tsconfig.json or Cargo.toml): pass builder (one of %s) or use run_terminal_cmd", target, strings.Join(builders, ", "))
+	}
+
+	var args []string
+	switch builder {
+	case "go":
+		args = []string{"go", "build", "./..."}
+	case "tsc":
+		args = []string{"npx", "--no-install", "tsc", "--noEmit", "--pretty", "false"}
+		if _, err := os.Stat(filepath.Join(projectDir, "node_modules", ".bin", "tsc")); err == nil {
+			args = []string{filepath.Join("node_modules", ".bin", "tsc"), "--noEmit", "--pretty", "false"}
+		}
+	case "cargo":
+		args = []string{"cargo", "check", "--message-format=short"}
+	default:
+		return nil, fmt.Errorf("unknown builder %q (expected one of %s)", builder, strings.Join(builders, ", "))
+	}
+	command := strings.Join(quoteArgs(args), " ")
+
+	// 试运行时只记录命令（构建脚本会执行项目代码）
+	explanation, _ := params["explanation"].(string)
+	if planCommand("get_build_errors", command, explanation) {
+		return nil, errNotRunInDryRun(command)
+	}
+	if !approveCommand("get_build_errors", command, explanation) {
+		return nil, fmt.Errorf("the user rejected the command: %s", command)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+	name := args[0]
+	if builder == "tsc" && !strings.HasPrefix(name, "npx") {
+		name = filepath.Join(projectDir, name)
+	}
+	cmd := exec.CommandContext(ctx, name, args[1:]...)
+	cmd.Dir = projectDir
+	cmd.Env = buildCommandEnv(params)
+
+	start := time.Now()
+	output, runErr := cmd.CombinedOutput()
+	result := &GetBuildErrorsResult{
+		Builder:   builder,
+		Command:   command,
+		Directory: projectDir,
+		Files:     []BuildFileDiagnostics{},
+		Duration:  time.Since(start).Round(time.Millisecond).String(),
+	}
+	if rel, err := filepath.Rel(workDir, projectDir); err == nil && !strings.HasPrefix(rel, "..") {
+		result.Directory = rel
+	}
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		result.TimedOut = true
+		result.ExitCode = -1
+	case runErr != nil:
+		if exitError, ok := runErr.(*exec.ExitError); ok {
+			result.ExitCode = exitError.ExitCode()
+		} else {
+			return nil, fmt.Errorf("failed to run %s: %w", command, runErr)
+		}
+	}
+	result.Success = runErr == nil
+
+	parseBuildOutput(result, string(output), projectDir, workDir)
+	if !result.Success && result.Errors == 0 && len(result.Other) == 0 {
+		result.Output = tailOutput(string(output))
+	}
+	return result, nil
+}
+
+// detectBuilder 从目标所在目录向上（不超出工作目录）查找项目文件，返回项目目录与构建工具
+func detectBuilder(targetPath, workDir string) (string, string) {
+	dir := workDir
+	if targetPath != "" {
+		dir = targetPath
+		if info, err := os.Stat(targetPath); err == nil && !info.IsDir() {
+			dir = filepath.Dir(targetPath)
+		}
+	}
+	for {
+		for _, marker := range []struct{ file, builder string }{
+			{"go.mod", "go"}, {"Cargo.toml", "cargo"}, {"tsconfig.json", "tsc"},
+		} {
+			if _, err := os.Stat(filepath.Join(dir, marker.file)); err == nil {
+				return dir, marker.builder
+			}
+		}
+		if dir == workDir || !strings.HasPrefix(dir, workDir) {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return workDir, ""
+}
+
+// parseBuildOutput 解析构建输出中的诊断，按文件分组，文件路径相对工作目录
+func parseBuildOutput(result *GetBuildErrorsResult, output, projectDir, workDir string) {
+	byFile := make(map[string]*BuildFileDiagnostics)
+	var last *BuildDiagnostic
+	total := 0
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		file, diag, ok := parseBuildLine(result.Builder, line)
+		if !ok {
+			// 多行消息的后续行（Go 以 tab、tsc 以空格缩进）
+			if last != nil && strings.TrimSpace(line) != "" && (line[0] == '\t' || line[0] == ' ') && result.Builder != "cargo" {
+				last.Message += "\n" + strings.TrimSpace(line)
+				continue
+			}
+			last = nil
+			if other := buildErrorWithoutLocation(result.Builder, line); other != "" {
+				result.Other = append(result.Other, other)
+			}
+			continue
+		}
+		if diag.Severity == "error" {
+			result.Errors++
+		} else {
+			result.Warnings++
+		}
+		if total == maxBuildDiagnostics {
+			result.Truncated = true
+			last = nil
+			continue
+		}
+		total++
+
+		path := file
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(projectDir, path)
+		}
+		if rel, err := filepath.Rel(workDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+			file = filepath.ToSlash(rel)
+		}
+		entry := byFile[file]
+		if entry == nil {
+			entry = &BuildFileDiagnostics{File: file}
+			byFile[file] = entry
+		}
+		if diag.Severity == "error" {
+			entry.Errors++
+		} else {
+			entry.Warnings++
+		}
+		entry.Diagnostics = append(entry.Diagnostics, diag)
+		last = &entry.Diagnostics[len(entry.Diagnostics)-1]
+	}
+
+	// 错误多的文件在前
+	for _, entry := range byFile {
+		sort.SliceStable(entry.Diagnostics, func(i, j int) bool { return entry.Diagnostics[i].Line < entry.Diagnostics[j].Line })
+		result.Files = append(result.Files, *entry)
+	}
+	sort.Slice(result.Files, func(i, j int) bool {
+		a, b := result.Files[i], result.Files[j]
+		if a.Errors != b.Errors {
+			return a.Errors > b.Errors
+		}
+		return a.File < b.File
+	})
+}
+
+// parseBuildLine 解析一行诊断，返回文件与诊断
+func parseBuildLine(builder, line string) (string, BuildDiagnostic, bool) {
+	var diag BuildDiagnostic
+	switch builder {
+	case "go":
+		m := goBuildPattern.FindStringSubmatch(line)
+		if m == nil {
+			return "", diag, false
+		}
+		diag.Line, _ = strconv.Atoi(m[2])
+		diag.Column, _ = strconv.Atoi(m[3])
+		diag.Severity = "error"
+		diag.Message = m[4]
+		return strings.TrimPrefix(m[1], "./"), diag, true
+	case "tsc":
+		m := tscBuildPattern.FindStringSubmatch(line)
+		if m == nil {
+			return "", diag, false
+		}
+		diag.Line, _ = strconv.Atoi(m[2])
+		diag.Column, _ = strconv.Atoi(m[3])
+		diag.Severity, diag.Code, diag.Message = m[4], m[5], m[6]
+		return m[1], diag, true
+	case "cargo":
+		m := cargoBuildPattern.FindStringSubmatch(line)
+		if m == nil {
+			return "", diag, false
+		}
+		diag.Line, _ = strconv.Atoi(m[2])
+		diag.Column, _ = strconv.Atoi(m[3])
+		diag.Severity, diag.Code, diag.Message = m[4], m[5], m[6]
+		return m[1], diag, true
+	}
+	return "", diag, false
+}
+
+// buildErrorWithoutLocation 没有源码位置的错误行，其他输出返回空
+func buildErrorWithoutLocation(builder, line string) string {
+	switch builder {
+	case "go":
+		// go: 开头的模块错误与找不到包等
+		if strings.HasPrefix(line, "go: ") || strings.HasPrefix(line, "package ") || strings.Contains(line, "cannot find package") {
+			return line
+		}
+	case "tsc":
+		if strings.HasPrefix(line, "error TS") {
+			return line
+		}
+	case "cargo":
+		// 跳过 "could not compile" 与 "aborting" 等汇总行
+		if strings.HasPrefix(line, "error") && !strings.Contains(line, "could not compile") && !strings.Contains(line, "aborting due to") {
+			return line
+		}
+	}
+	return ""
+}
+
+// NewGetBuildErrorsTool 创建get_build_errors工具
+func NewGetBuildErrorsTool() Tool {
+	schema := ToolSchema{
+		Name:        "get_build_errors",
+		Description: "Build the project without running it (go build ./..., tsc --noEmit or cargo check, detected from go.mod, tsconfig.json or Cargo.toml) and return the compiler errors and warnings parsed into file, line, column and message, grouped by file with the files with the most errors first. Use it after editing code to get the same feedback an IDE shows, instead of reading raw build output.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "A file or directory inside the project to build, relative to the workspace root; the project is found by looking upwards for go.mod, Cargo.toml or tsconfig.json. Defaults to the workspace root.",
+				},
+				"builder": map[string]interface{}{
+					"type":        "string",
+					"description": "Override the detected builder",
+					"enum":        builders,
+				},
+				"timeout_seconds": map[string]interface{}{
+					"type":        "integer",
+					"description": "Timeout in seconds (default 600, max 3600)",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: getBuildErrorsFunction,
+	}
+}
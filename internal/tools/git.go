@@ -0,0 +1,363 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GitParams git工具的参数
+type GitParams struct {
+	Action      string   `json:"action"`
+	Files       []string `json:"files,omitempty"`
+	All         bool     `json:"all,omitempty"`
+	Message     string   `json:"message,omitempty"`
+	Branch      string   `json:"branch,omitempty"`
+	StartPoint  string   `json:"start_point,omitempty"`
+	Explanation string   `json:"explanation,omitempty"`
+}
+
+// GitStatus 工作区状态
+type GitStatus struct {
+	Branch    string   `json:"branch"`
+	Upstream  string   `json:"upstream,omitempty"`
+	Ahead     int      `json:"ahead,omitempty"`
+	Behind    int      `json:"behind,omitempty"`
+	Staged    []string `json:"staged"`
+	Unstaged  []string `json:"unstaged"`
+	Untracked []string `json:"untracked"`
+	Clean     bool     `json:"clean"`
+}
+
+// GitResult git工具的返回结果
+type GitResult struct {
+	Action   string     `json:"action"`
+	Status   *GitStatus `json:"status,omitempty"`
+	Commit   *GitCommit `json:"commit,omitempty"`
+	Branches []string   `json:"branches,omitempty"`
+	Current  string     `json:"current_branch,omitempty"`
+	Message  string     `json:"message"`
+}
+
+// gitActions git工具支持的操作
+var gitActions = []string{"status", "stage", "unstage", "commit", "create_branch", "switch_branch", "list_branches"}
+
+// readGitStatus 解析 git status --porcelain=v1 -z --branch 输出（-z 避免路径被转义）
+func readGitStatus(workDir string) (*GitStatus, error) {
+	output, err := runGit(workDir, "status", "--porcelain=v1", "-z", "--branch", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+
+	status := &GitStatus{Staged: []string{}, Unstaged: []string{}, Untracked: []string{}}
+	entries := strings.Split(output, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 3 {
+			continue
+		}
+		if strings.HasPrefix(entry, "## ") {
+			// ## main...origin/main [ahead 1, behind 2]
+			head := strings.TrimPrefix(entry, "## ")
+			head, tracking, _ := strings.Cut(head, " [")
+			branch, upstream, _ := strings.Cut(head, "...")
+			status.Branch = strings.TrimPrefix(branch, "No commits yet on ")
+			status.Upstream = upstream
+			for _, part := range strings.Split(strings.TrimSuffix(tracking, "]"), ", ") {
+				fmt.Sscanf(part, "ahead %d", &status.Ahead)
+				fmt.Sscanf(part, "behind %d", &status.Behind)
+			}
+			continue
+		}
+		x, y, path := entry[0], entry[1], entry[3:]
+		// 重命名与复制的下一项是原路径
+		if x == 'R' || x == 'C' {
+			i++
+		}
+		switch {
+		case x == '?' && y == '?':
+			status.Untracked = append(status.Untracked, path)
+		default:
+			if x != ' ' {
+				status.Staged = append(status.Staged, fmt.Sprintf("%c %s", x, path))
+			}
+			if y != ' ' {
+				status.Unstaged = append(status.Unstaged, fmt.Sprintf("%c %s", y, path))
+			}
+		}
+	}
+	status.Clean = len(status.Staged) == 0 && len(status.Unstaged) == 0 && len(status.Untracked) == 0
+	return status, nil
+}
+
+// gitPathArgs 解析files参数并转换为相对路径
+func gitPathArgs(workDir string, params map[string]interface{}) ([]string, error) {
+	list, _ := params["files"].([]interface{})
+	var paths []string
+	for _, item := range list {
+		path, ok := item.(string)
+		if !ok || path == "" {
+			continue
+		}
+		rel, err := gitRelativePath(workDir, path)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, rel)
+	}
+	return paths, nil
+}
+
+// validateBranchName 校验分支名
+func validateBranchName(workDir, name string) error {
+	if name == "" {
+		return fmt.Errorf("branch is required")
+	}
+	if strings.HasPrefix(name, "-") {
+		return fmt.Errorf("invalid branch name: %s", name)
+	}
+	if _, err := runGit(workDir, "check-ref-format", "--branch", name); err != nil {
+		return fmt.Errorf("invalid branch name: %s", name)
+	}
+	return nil
+}
+
+// runApprovedGit 经确认后执行会修改仓库的git命令
+func runApprovedGit(workDir, explanation string, args ...string) (string, error) {
+	display := "git " + strings.Join(quoteArgs(args), " ")
+	if !approveCommand("git", display, explanation) {
+		return "", fmt.Errorf("the user rejected the command: %s", display)
+	}
+	return runGit(workDir, args...)
+}
+
+// quoteArgs 为展示给用户的命令给含空白的参数加引号
+func quoteArgs(args []string) []string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"") {
+			quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		} else {
+			quoted[i] = arg
+		}
+	}
+	return quoted
+}
+
+// gitFunction git仓库操作工具函数
+func gitFunction(params map[string]interface{}) (interface{}, error) {
+	// 解析参数
+	action, _ := params["action"].(string)
+	if action == "" {
+		return nil, fmt.Errorf("action is required (one of: %s)", strings.Join(gitActions, ", "))
+	}
+	all, _ := params["all"].(bool)
+	message, _ := params["message"].(string)
+	branch, _ := params["branch"].(string)
+	startPoint, _ := params["start_point"].(string)
+	explanation, _ := params["explanation"].(string)
+
+	workDir, _ := params["__work_dir__"].(string)
+	if workDir == "" {
+		workDir = "."
+	}
+
+	files, err := gitPathArgs(workDir, params)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &GitResult{Action: action}
+
+	// 试运行时只记录会修改仓库的操作
+	if action != "status" && action != "list_branches" && containsString(gitActions, action) {
+		planned := "git " + action
+		if all {
+			planned += " --all"
+		}
+		if len(files) > 0 {
+			planned += " " + strings.Join(quoteArgs(files), " ")
+		}
+		if message != "" {
+			planned += " --message " + quoteArgs([]string{message})[0]
+		}
+		if branch != "" {
+			planned += " " + strings.TrimSpace(branch+" "+startPoint)
+		}
+		if planCommand("git", planned, explanation) {
+			return nil, errNotRunInDryRun(planned)
+		}
+	}
+
+	switch action {
+	case "status":
+		// 只读操作，直接返回状态
+
+	case "stage":
+		if len(files) == 0 && !all {
+			return nil, fmt.Errorf("files is required for stage (or set all to true to stage every change)")
+		}
+		args := append([]string{"add", "--"}, files...)
+		if all {
+			args = []string{"add", "--all"}
+		}
+		if _, err := runApprovedGit(workDir, explanation, args...); err != nil {
+			return nil, err
+		}
+
+	case "unstage":
+		if len(files) == 0 && !all {
+			return nil, fmt.Errorf("files is required for unstage (or set all to true to unstage everything)")
+		}
+		args := append([]string{"restore", "--staged", "--"}, files...)
+		if all {
+			args = []string{"restore", "--staged", "--", "."}
+		}
+		if _, err := runApprovedGit(workDir, explanation, args...); err != nil {
+			return nil, err
+		}
+
+	case "commit":
+		if strings.TrimSpace(message) == "" {
+			return nil, fmt.Errorf("message is required for commit")
+		}
+		// 提交前先暂存指定的文件
+		if all {
+			if _, err := runApprovedGit(workDir, explanation, "add", "--all"); err != nil {
+				return nil, err
+			}
+		} else if len(files) > 0 {
+			if _, err := runApprovedGit(workDir, explanation, append([]string{"add", "--"}, files...)...); err != nil {
+				return nil, err
+			}
+		}
+		status, err := readGitStatus(workDir)
+		if err != nil {
+			return nil, err
+		}
+		if len(status.Staged) == 0 {
+			return nil, fmt.Errorf("nothing staged to commit; pass files or all=true, or stage changes first")
+		}
+		if _, err := runApprovedGit(workDir, explanation, "commit", "--message", message); err != nil {
+			return nil, err
+		}
+		output, err := runGit(workDir, "log", "-1", "--name-only", "--format=%x1e%H%x1f%h%x1f%an%x1f%ae%x1f%aI%x1f%s%x1f%b%x1d")
+		if err != nil {
+			return nil, err
+		}
+		if commits := parseGitLog(output, false); len(commits) > 0 {
+			result.Commit = &commits[0]
+		}
+
+	case "create_branch", "switch_branch":
+		if err := validateBranchName(workDir, branch); err != nil {
+			return nil, err
+		}
+		args := []string{"switch", branch}
+		if action == "create_branch" {
+			if strings.HasPrefix(startPoint, "-") {
+				return nil, fmt.Errorf("invalid start_point: %s", startPoint)
+			}
+			args = []string{"switch", "--create", branch}
+			if startPoint != "" {
+				args = append(args, startPoint)
+			}
+		}
+		if _, err := runApprovedGit(workDir, explanation, args...); err != nil {
+			return nil, err
+		}
+
+	case "list_branches":
+		output, err := runGit(workDir, "branch", "--format=%(refname:short)")
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range strings.Split(strings.TrimSpace(output), "\n") {
+			if name != "" {
+				result.Branches = append(result.Branches, name)
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown action %q (expected one of: %s)", action, strings.Join(gitActions, ", "))
+	}
+
+	// 每个操作之后都返回最新状态
+	status, err := readGitStatus(workDir)
+	if err != nil {
+		return nil, err
+	}
+	result.Status = status
+	result.Current = status.Branch
+
+	switch action {
+	case "status":
+		if status.Clean {
+			result.Message = fmt.Sprintf("On branch %s, working tree clean", status.Branch)
+		} else {
+			result.Message = fmt.Sprintf("On branch %s: %d staged, %d unstaged, %d untracked", status.Branch, len(status.Staged), len(status.Unstaged), len(status.Untracked))
+		}
+	case "stage", "unstage":
+		result.Message = fmt.Sprintf("%d files staged", len(status.Staged))
+	case "commit":
+		if result.Commit != nil {
+			result.Message = fmt.Sprintf("Committed %s on %s: %s (%d files)", result.Commit.ShortHash, status.Branch, result.Commit.Subject, len(result.Commit.Files))
+		}
+	case "create_branch":
+		result.Message = fmt.Sprintf("Created and switched to branch %s", branch)
+	case "switch_branch":
+		result.Message = fmt.Sprintf("Switched to branch %s", branch)
+	case "list_branches":
+		result.Message = fmt.Sprintf("%d branches, current: %s", len(result.Branches), status.Branch)
+	}
+
+	return result, nil
+}
+
+// NewGitTool 创建git工具
+func NewGitTool() Tool {
+	schema := ToolSchema{
+		Name:        "git",
+		Description: "Inspect and manage the git repository: show the status, stage or unstage files, commit with a message, and create, switch or list branches. Use this to commit your changes as small, self-contained, revertable units with a descriptive message, ideally on a dedicated branch. Check the status before committing so you only commit files you changed. Every action returns the resulting status. Actions that change the repository may need the user's approval.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"action": map[string]interface{}{
+					"type":        "string",
+					"enum":        gitActions,
+					"description": "The operation to perform.",
+				},
+				"files": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Paths relative to the workspace root for stage, unstage and commit (staged before committing).",
+				},
+				"all": map[string]interface{}{
+					"type":        "boolean",
+					"description": "For stage and commit: include every change, including untracked files. For unstage: unstage everything.",
+				},
+				"message": map[string]interface{}{
+					"type":        "string",
+					"description": "Commit message for commit: a short summary line, optionally followed by a blank line and details.",
+				},
+				"branch": map[string]interface{}{
+					"type":        "string",
+					"description": "Branch name for create_branch and switch_branch.",
+				},
+				"start_point": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional commit or branch to create the new branch from. Defaults to HEAD.",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+			"required": []string{"action"},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: gitFunction,
+	}
+}
\ No newline at end of file
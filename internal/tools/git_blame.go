@@ -0,0 +1,270 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxBlameLines 单次blame最多的行数
+const maxBlameLines = 400
+
+// GitBlameParams git_blame工具的参数
+type GitBlameParams struct {
+	TargetFile       string `json:"target_file"`
+	StartLine        int    `json:"start_line,omitempty"`
+	EndLine          int    `json:"end_line,omitempty"`
+	Revision         string `json:"revision,omitempty"`
+	IgnoreWhitespace bool   `json:"ignore_whitespace,omitempty"`
+	Explanation      string `json:"explanation,omitempty"`
+}
+
+// BlameHunk 连续由同一提交最后修改的若干行
+type BlameHunk struct {
+	StartLine int      `json:"start_line"`
+	EndLine   int      `json:"end_line"`
+	Commit    string   `json:"commit"`
+	Author    string   `json:"author"`
+	Date      string   `json:"date"`
+	Summary   string   `json:"summary"`
+	Lines     []string `json:"lines"`
+}
+
+// GitBlameResult git_blame工具的返回结果
+type GitBlameResult struct {
+	TargetFile string      `json:"target_file"`
+	StartLine  int         `json:"start_line"`
+	EndLine    int         `json:"end_line"`
+	Hunks      []BlameHunk `json:"hunks"`
+	Commits    int         `json:"commits"`
+	Truncated  bool        `json:"truncated,omitempty"`
+	Message    string      `json:"message"`
+}
+
+// blameCommit --line-porcelain 输出中的提交信息
+type blameCommit struct {
+	author  string
+	date    string
+	summary string
+}
+
+// parseBlamePorcelain 解析 git blame --line-porcelain 输出，相邻且属于同一提交的行合并为一段
+func parseBlamePorcelain(output string) []BlameHunk {
+	var hunks []BlameHunk
+	commits := make(map[string]*blameCommit)
+
+	var hash string
+	var finalLine int
+	var current *blameCommit
+	for _, line := range strings.Split(output, "\n") {
+		switch {
+		case strings.HasPrefix(line, "\t"):
+			// 内容行，结束一条记录
+			content := line[1:]
+			shortHash := hash
+			if len(shortHash) > 8 {
+				shortHash = shortHash[:8]
+			}
+			if strings.Trim(hash, "0") == "" {
+				shortHash = "uncommitted"
+			}
+			if n := len(hunks); n > 0 && hunks[n-1].Commit == shortHash && hunks[n-1].EndLine == finalLine-1 {
+				hunks[n-1].EndLine = finalLine
+				hunks[n-1].Lines = append(hunks[n-1].Lines, content)
+				continue
+			}
+			hunks = append(hunks, BlameHunk{
+				StartLine: finalLine,
+				EndLine:   finalLine,
+				Commit:    shortHash,
+				Author:    current.author,
+				Date:      current.date,
+				Summary:   current.summary,
+				Lines:     []string{content},
+			})
+		case strings.HasPrefix(line, "author "):
+			current.author = strings.TrimPrefix(line, "author ")
+		case strings.HasPrefix(line, "author-time "):
+			if sec, err := strconv.ParseInt(strings.TrimPrefix(line, "author-time "), 10, 64); err == nil {
+				current.date = time.Unix(sec, 0).UTC().Format("2006-01-02")
+			}
+		case strings.HasPrefix(line, "summary "):
+			current.summary = strings.TrimPrefix(line, "summary ")
+		default:
+			// 记录头：<hash> <原行号> <最终行号> [<行数>]
+			fields := strings.Fields(line)
+			if len(fields) >= 3 && len(fields[0]) == 40 {
+				hash = fields[0]
+				finalLine, _ = strconv.Atoi(fields[2])
+				if commits[hash] == nil {
+					commits[hash] = &blameCommit{}
+				}
+				current = commits[hash]
+			}
+		}
+	}
+	return hunks
+}
+
+// gitBlameFunction 逐行追溯修改来源工具函数
+func gitBlameFunction(params map[string]interface{}) (interface{}, error) {
+	// 解析参数
+	targetFile, ok := params["target_file"].(string)
+	if !ok || targetFile == "" {
+		return nil, fmt.Errorf("target_file is required")
+	}
+
+	revision, _ := params["revision"].(string)
+	if strings.HasPrefix(revision, "-") {
+		return nil, fmt.Errorf("invalid revision: %s", revision)
+	}
+	ignoreWhitespace, _ := params["ignore_whitespace"].(bool)
+
+	startLine, _ := intParam(params, "start_line")
+	endLine, _ := intParam(params, "end_line")
+	if startLine < 1 {
+		startLine = 1
+	}
+	if endLine > 0 && endLine < startLine {
+		return nil, fmt.Errorf("end_line (%d) must be >= start_line (%d)", endLine, startLine)
+	}
+
+	workDir, _ := params["__work_dir__"].(string)
+	if workDir == "" {
+		workDir = "."
+	}
+
+	relPath, err := gitRelativePath(workDir, targetFile)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := countBlameLines(workDir, relPath, revision)
+	if err != nil {
+		return nil, err
+	}
+	if startLine > total {
+		return nil, fmt.Errorf("start_line (%d) exceeds total lines (%d)", startLine, total)
+	}
+
+	// 限制行数，避免整份大文件的blame
+	if endLine == 0 || endLine > total {
+		endLine = total
+	}
+	truncated := false
+	if endLine-startLine+1 > maxBlameLines {
+		endLine = startLine + maxBlameLines - 1
+		truncated = true
+	}
+
+	args := []string{"blame", "--line-porcelain", fmt.Sprintf("-L%d,%d", startLine, endLine)}
+	if ignoreWhitespace {
+		args = append(args, "-w")
+	}
+	if revision != "" {
+		args = append(args, revision)
+	}
+	args = append(args, "--", relPath)
+
+	output, err := runGit(workDir, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	hunks := parseBlamePorcelain(output)
+	result := &GitBlameResult{
+		TargetFile: relPath,
+		StartLine:  startLine,
+		Hunks:      hunks,
+		Truncated:  truncated,
+	}
+	if result.Hunks == nil {
+		result.Hunks = []BlameHunk{}
+	}
+
+	seen := make(map[string]bool)
+	for _, hunk := range hunks {
+		seen[hunk.Commit] = true
+		result.EndLine = hunk.EndLine
+	}
+	result.Commits = len(seen)
+
+	result.Message = fmt.Sprintf("Lines %d-%d of %s were last changed by %d commits", result.StartLine, result.EndLine, relPath, result.Commits)
+	if truncated {
+		result.Message += fmt.Sprintf("; output limited to %d lines, the file has %d lines, request further ranges separately", maxBlameLines, total)
+	}
+
+	return result, nil
+}
+
+// countBlameLines 获取文件（在指定版本中）的行数
+func countBlameLines(workDir, relPath, revision string) (int, error) {
+	var content string
+	if revision != "" {
+		output, err := runGit(workDir, "show", revision+":"+relPath)
+		if err != nil {
+			return 0, err
+		}
+		content = output
+	} else {
+		data, err := os.ReadFile(filepath.Join(workDir, relPath))
+		if err != nil {
+			if os.IsNotExist(err) {
+				return 0, fmt.Errorf("file not found: %s", relPath)
+			}
+			return 0, fmt.Errorf("failed to read file: %w", err)
+		}
+		content = string(data)
+	}
+	count := strings.Count(content, "\n")
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		count++
+	}
+	return count, nil
+}
+
+// NewGitBlameTool 创建git_blame工具
+func NewGitBlameTool() Tool {
+	schema := ToolSchema{
+		Name:        "git_blame",
+		Description: "Show which commit last changed each line of a file, grouped into runs of lines from the same commit, with author, date and commit summary. Use this to find out why a specific piece of code looks the way it does, then call git_log with revision set to the commit, max_count 1 and include_patch to read the full change.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"target_file": map[string]interface{}{
+					"type":        "string",
+					"description": "The file to blame, relative to the workspace root.",
+				},
+				"start_line": map[string]interface{}{
+					"type":        "integer",
+					"description": "First line (one-indexed) to blame. Defaults to 1.",
+				},
+				"end_line": map[string]interface{}{
+					"type":        "integer",
+					"description": fmt.Sprintf("Last line (inclusive) to blame. At most %d lines are returned per call.", maxBlameLines),
+				},
+				"revision": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional revision to blame at, e.g. HEAD~5 or a commit hash. Defaults to the working tree.",
+				},
+				"ignore_whitespace": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Ignore whitespace-only changes when attributing lines. Defaults to false.",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+			"required": []string{"target_file"},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: gitBlameFunction,
+	}
+}
\ No newline at end of file
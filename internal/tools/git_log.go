@@ -0,0 +1,286 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// git历史查询的限制
+const (
+	defaultGitLogCount  = 20
+	maxGitLogCount      = 100
+	maxGitLogPatchBytes = 4 * 1024
+)
+
+// GitLogParams git_log工具的参数
+type GitLogParams struct {
+	Path         string `json:"path,omitempty"`
+	StartLine    int    `json:"start_line,omitempty"`
+	EndLine      int    `json:"end_line,omitempty"`
+	MaxCount     int    `json:"max_count,omitempty"`
+	Since        string `json:"since,omitempty"`
+	Author       string `json:"author,omitempty"`
+	Grep         string `json:"grep,omitempty"`
+	Revision     string `json:"revision,omitempty"`
+	IncludePatch bool   `json:"include_patch,omitempty"`
+	Explanation  string `json:"explanation,omitempty"`
+}
+
+// GitCommit 一次提交的信息
+type GitCommit struct {
+	Hash      string   `json:"hash"`
+	ShortHash string   `json:"short_hash"`
+	Author    string   `json:"author"`
+	Email     string   `json:"email"`
+	Date      string   `json:"date"`
+	Subject   string   `json:"subject"`
+	Body      string   `json:"body,omitempty"`
+	Files     []string `json:"files,omitempty"`
+	Patch     string   `json:"patch,omitempty"`
+}
+
+// GitLogResult git_log工具的返回结果
+type GitLogResult struct {
+	Path    string      `json:"path,omitempty"`
+	Commits []GitCommit `json:"commits"`
+	Count   int         `json:"count"`
+	Message string      `json:"message"`
+}
+
+// runGit 在指定目录执行git命令并返回标准输出
+func runGit(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s failed: %s", args[0], msg)
+	}
+	return stdout.String(), nil
+}
+
+// gitRelativePath 将路径转换为相对于工作目录的路径，并确认在仓库中
+func gitRelativePath(workDir, path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		return filepath.ToSlash(filepath.Clean(path)), nil
+	}
+	rel, err := filepath.Rel(workDir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("path %s is outside the workspace", path)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// parseGitLog 解析 --format 输出：每条记录以\x1e开头，字段以\x1f分隔，正文以\x1d结束，之后为文件列表或补丁
+func parseGitLog(output string, includePatch bool) []GitCommit {
+	var commits []GitCommit
+	for _, record := range strings.Split(output, "\x1e") {
+		if strings.TrimSpace(record) == "" {
+			continue
+		}
+		header, rest, _ := strings.Cut(record, "\x1d")
+		fields := strings.SplitN(header, "\x1f", 7)
+		if len(fields) < 7 {
+			continue
+		}
+		commit := GitCommit{
+			Hash:      fields[0],
+			ShortHash: fields[1],
+			Author:    fields[2],
+			Email:     fields[3],
+			Date:      fields[4],
+			Subject:   fields[5],
+			Body:      strings.TrimSpace(fields[6]),
+		}
+		rest = strings.Trim(rest, "\n")
+		if includePatch {
+			if len(rest) > maxGitLogPatchBytes {
+				rest = rest[:maxGitLogPatchBytes] + "\n... (patch truncated)"
+			}
+			commit.Patch = rest
+		} else if rest != "" {
+			for _, file := range strings.Split(rest, "\n") {
+				if file = strings.TrimSpace(file); file != "" {
+					commit.Files = append(commit.Files, file)
+				}
+			}
+		}
+		commits = append(commits, commit)
+	}
+	return commits
+}
+
+// gitLogFunction 查询提交历史工具函数
+func gitLogFunction(params map[string]interface{}) (interface{}, error) {
+	// 解析参数
+	path, _ := params["path"].(string)
+	since, _ := params["since"].(string)
+	author, _ := params["author"].(string)
+	grep, _ := params["grep"].(string)
+	revision, _ := params["revision"].(string)
+	includePatch, _ := params["include_patch"].(bool)
+	startLine, _ := intParam(params, "start_line")
+	endLine, _ := intParam(params, "end_line")
+
+	maxCount := defaultGitLogCount
+	if val, ok := intParam(params, "max_count"); ok && val > 0 {
+		maxCount = min(val, maxGitLogCount)
+	}
+
+	workDir, _ := params["__work_dir__"].(string)
+	if workDir == "" {
+		workDir = "."
+	}
+
+	if strings.HasPrefix(revision, "-") {
+		return nil, fmt.Errorf("invalid revision: %s", revision)
+	}
+	if (startLine > 0 || endLine > 0) && path == "" {
+		return nil, fmt.Errorf("path is required when start_line/end_line are given")
+	}
+
+	args := []string{"log", "--no-color", fmt.Sprintf("--max-count=%d", maxCount),
+		"--format=%x1e%H%x1f%h%x1f%an%x1f%ae%x1f%aI%x1f%s%x1f%b%x1d"}
+	if since != "" {
+		args = append(args, "--since="+since)
+	}
+	if author != "" {
+		args = append(args, "--author="+author)
+	}
+	if grep != "" {
+		args = append(args, "--grep="+grep, "--regexp-ignore-case")
+	}
+
+	var relPath string
+	if path != "" {
+		var err error
+		if relPath, err = gitRelativePath(workDir, path); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case relPath != "" && (startLine > 0 || endLine > 0):
+		// 行范围的历史：git log -L 总是输出补丁
+		if startLine < 1 {
+			startLine = 1
+		}
+		if endLine == 0 {
+			endLine = startLine
+		}
+		if endLine < startLine {
+			return nil, fmt.Errorf("end_line (%d) must be >= start_line (%d)", endLine, startLine)
+		}
+		args = append(args, fmt.Sprintf("-L%d,%d:%s", startLine, endLine, relPath))
+		includePatch = true
+	case includePatch:
+		args = append(args, "--patch", "--stat")
+	default:
+		args = append(args, "--name-only")
+	}
+	if revision != "" {
+		args = append(args, revision)
+	}
+	if relPath != "" && startLine == 0 && endLine == 0 {
+		// --follow 只适用于单个文件
+		if info, err := os.Stat(filepath.Join(workDir, relPath)); err == nil && !info.IsDir() {
+			args = append(args, "--follow")
+		}
+		args = append(args, "--", relPath)
+	}
+
+	output, err := runGit(workDir, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &GitLogResult{
+		Path:    relPath,
+		Commits: parseGitLog(output, includePatch),
+	}
+	if result.Commits == nil {
+		result.Commits = []GitCommit{}
+	}
+	result.Count = len(result.Commits)
+
+	switch {
+	case result.Count == 0:
+		result.Message = "No matching commits"
+	case relPath != "":
+		result.Message = fmt.Sprintf("Found %d commits touching %s", result.Count, relPath)
+	default:
+		result.Message = fmt.Sprintf("Found %d commits", result.Count)
+	}
+	if result.Count == maxCount {
+		result.Message += fmt.Sprintf(" (limited to %d, narrow with since/author/grep or raise max_count)", maxCount)
+	}
+
+	return result, nil
+}
+
+// NewGitLogTool 创建git_log工具
+func NewGitLogTool() Tool {
+	schema := ToolSchema{
+		Name:        "git_log",
+		Description: "Show the commit history of the repository, a file or a range of lines, newest first, with author, date, full commit message and changed files. Use this to answer when and why code was changed, to find the commit that introduced or fixed a bug, and to check for reverted changes (grep=revert) before re-applying an old fix. Set include_patch to see the diffs; line-range history (start_line/end_line) always includes them.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional file or directory to limit the history to, relative to the workspace root. Renames of a single file are followed.",
+				},
+				"start_line": map[string]interface{}{
+					"type":        "integer",
+					"description": "Optional first line (one-indexed) of a range in path whose history to show.",
+				},
+				"end_line": map[string]interface{}{
+					"type":        "integer",
+					"description": "Optional last line (inclusive) of the range in path.",
+				},
+				"max_count": map[string]interface{}{
+					"type":        "integer",
+					"description": fmt.Sprintf("Maximum number of commits. Defaults to %d, maximum %d.", defaultGitLogCount, maxGitLogCount),
+				},
+				"since": map[string]interface{}{
+					"type":        "string",
+					"description": "Only commits after this date, e.g. 2024-01-31 or \"2 weeks ago\".",
+				},
+				"author": map[string]interface{}{
+					"type":        "string",
+					"description": "Only commits whose author name or email matches this pattern.",
+				},
+				"grep": map[string]interface{}{
+					"type":        "string",
+					"description": "Only commits whose message matches this pattern (case-insensitive), e.g. revert or an issue number.",
+				},
+				"revision": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional revision or range, e.g. main..HEAD or v1.2.0. Defaults to HEAD.",
+				},
+				"include_patch": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Include the diff of each commit (truncated to 4KB per commit). Defaults to false.",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: gitLogFunction,
+	}
+}
\ No newline at end of file
@@ -0,0 +1,235 @@
+package tools
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// glob搜索的结果数量限制
+const (
+	defaultGlobResults = 200
+	maxGlobResults     = 1000
+)
+
+// GlobSearchParams glob_search工具的参数
+type GlobSearchParams struct {
+	Pattern       string `json:"pattern"`
+	Directory     string `json:"directory,omitempty"`
+	SortBy        string `json:"sort_by,omitempty"`
+	IncludeHidden bool   `json:"include_hidden,omitempty"`
+	MaxResults    int    `json:"max_results,omitempty"`
+	Explanation   string `json:"explanation,omitempty"`
+}
+
+// GlobMatch glob匹配到的文件
+type GlobMatch struct {
+	Path     string `json:"path"`
+	Size     int64  `json:"size"`
+	Modified string `json:"modified"`
+
+	modTime time.Time
+}
+
+// GlobSearchResult glob_search工具的返回结果
+type GlobSearchResult struct {
+	Pattern   string      `json:"pattern"`
+	Directory string      `json:"directory"`
+	Matches   []GlobMatch `json:"matches"`
+	Count     int         `json:"count"`
+	Truncated bool        `json:"truncated,omitempty"`
+	Message   string      `json:"message"`
+}
+
+// globBaseDir 返回模式中不含通配符的前导目录，遍历可以从这里开始
+func globBaseDir(pattern string) string {
+	segments := strings.Split(pattern, "/")
+	i := 0
+	for ; i < len(segments)-1; i++ {
+		if strings.ContainsAny(segments[i], "*?[{") {
+			break
+		}
+	}
+	return strings.Join(segments[:i], "/")
+}
+
+// globSearchFunction 按glob模式查找文件工具函数
+func globSearchFunction(params map[string]interface{}) (interface{}, error) {
+	// 解析参数
+	pattern, ok := params["pattern"].(string)
+	if !ok || strings.TrimSpace(pattern) == "" {
+		return nil, fmt.Errorf("pattern is required")
+	}
+	pattern = strings.TrimPrefix(filepath.ToSlash(strings.TrimSpace(pattern)), "./")
+	if strings.HasPrefix(pattern, "/") || strings.Contains("/"+pattern+"/", "/../") {
+		return nil, fmt.Errorf("pattern must be relative to the search directory and must not contain '..'")
+	}
+
+	directory, _ := params["directory"].(string)
+	sortBy, _ := params["sort_by"].(string)
+	if sortBy == "" {
+		sortBy = "path"
+	}
+	if sortBy != "path" && sortBy != "modified" && sortBy != "size" {
+		return nil, fmt.Errorf("sort_by must be one of path, modified, size (got %q)", sortBy)
+	}
+	includeHidden, _ := params["include_hidden"].(bool)
+
+	maxResults := defaultGlobResults
+	if val, ok := intParam(params, "max_results"); ok && val > 0 {
+		maxResults = min(val, maxGlobResults)
+	}
+
+	workDir, _ := params["__work_dir__"].(string)
+	root := workDir
+	if directory != "" {
+		root = directory
+		if !filepath.IsAbs(root) && workDir != "" {
+			root = filepath.Join(workDir, directory)
+		}
+	}
+	if root == "" {
+		root = "."
+	}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("directory not found: %s", root)
+	}
+
+	re, err := globToRegexp(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+	}
+
+	// 从模式的固定前缀目录开始遍历，如 internal/**/*.go 只遍历 internal
+	start := filepath.Join(root, filepath.FromSlash(globBaseDir(pattern)))
+
+	var matches []GlobMatch
+	err = filepath.WalkDir(start, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // 忽略无法访问的路径
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path == start {
+				return nil
+			}
+			if replaceSkipDirs[name] || name == ".git" || (!includeHidden && strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !includeHidden && strings.HasPrefix(name, ".") {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		relPath = filepath.ToSlash(relPath)
+		if !re.MatchString(relPath) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		matches = append(matches, GlobMatch{
+			Path:     relPath,
+			Size:     info.Size(),
+			Modified: info.ModTime().Format(time.RFC3339),
+			modTime:  info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to walk directory: %w", err)
+	}
+
+	// 排序：修改时间和大小按从新到旧、从大到小
+	sort.SliceStable(matches, func(i, j int) bool {
+		switch sortBy {
+		case "modified":
+			if !matches[i].modTime.Equal(matches[j].modTime) {
+				return matches[i].modTime.After(matches[j].modTime)
+			}
+		case "size":
+			if matches[i].Size != matches[j].Size {
+				return matches[i].Size > matches[j].Size
+			}
+		}
+		return matches[i].Path < matches[j].Path
+	})
+
+	result := &GlobSearchResult{
+		Pattern:   pattern,
+		Directory: root,
+		Matches:   []GlobMatch{},
+		Count:     len(matches),
+	}
+	if len(matches) > maxResults {
+		matches = matches[:maxResults]
+		result.Truncated = true
+	}
+	result.Matches = append(result.Matches, matches...)
+
+	switch {
+	case result.Count == 0:
+		result.Message = fmt.Sprintf("No files match %s", pattern)
+	case result.Truncated:
+		result.Message = fmt.Sprintf("Found %d files matching %s, showing the first %d sorted by %s", result.Count, pattern, maxResults, sortBy)
+	default:
+		result.Message = fmt.Sprintf("Found %d files matching %s", result.Count, pattern)
+	}
+
+	return result, nil
+}
+
+// NewGlobSearchTool 创建glob_search工具
+func NewGlobSearchTool() Tool {
+	schema := ToolSchema{
+		Name:        "glob_search",
+		Description: "List files whose path matches a glob pattern, with size and modification time. Use this instead of file_search when you know the exact pattern, e.g. all tests (**/*_test.go), all migrations (db/migrations/*.sql) or every config file (**/*.{yaml,yml}). Patterns are matched against the path relative to the search directory: * and ? do not cross directories, ** matches any number of directories, and [abc] and {a,b} are supported. Hidden files and dependency directories (node_modules, vendor, ...) are skipped.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"pattern": map[string]interface{}{
+					"type":        "string",
+					"description": "Glob pattern relative to the search directory, e.g. **/*_test.go or src/**/*.ts. Note that *.go only matches files directly in the search directory.",
+				},
+				"directory": map[string]interface{}{
+					"type":        "string",
+					"description": "Directory to search in, relative to the workspace root or absolute. Defaults to the workspace root.",
+				},
+				"sort_by": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"path", "modified", "size"},
+					"description": "Sort order: path (default, alphabetical), modified (most recently modified first) or size (largest first).",
+				},
+				"include_hidden": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Also match hidden files and directories (names starting with a dot). Defaults to false.",
+				},
+				"max_results": map[string]interface{}{
+					"type":        "integer",
+					"description": fmt.Sprintf("Maximum number of files to return. Defaults to %d, maximum %d.", defaultGlobResults, maxGlobResults),
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+			"required": []string{"pattern"},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: globSearchFunction,
+	}
+}
\ No newline at end of file
@@ -2,12 +2,16 @@ package tools
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
 )
 
 // GrepSearchParams grep_search工具的参数
@@ -37,6 +41,7 @@ type GrepSearchResult struct {
 	CaseSensitive  bool        `json:"case_sensitive"`
 	IncludePattern string      `json:"include_pattern,omitempty"`
 	ExcludePattern string      `json:"exclude_pattern,omitempty"`
+	Skipped        []string    `json:"skipped,omitempty"` // 内置搜索无法读取的文件（如行过长）及原因
 }
 
 // grepSearchFunction grep搜索工具函数
@@ -192,40 +197,47 @@ func fallbackGrepSearch(query string, caseSensitive bool, includePattern, exclud
 		searchPath = workDir
 	}
 
-	// 使用filepath.Walk遍历文件
-	err = filepath.Walk(searchPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil // 忽略错误，继续处理其他文件
-		}
-
-		// 跳过目录
-		if info.IsDir() {
-			return nil
-		}
-
+	// 并行遍历文件，各文件的匹配合并后按文件与行号排序
+	var mu sync.Mutex
+	err = parallelWalk([]string{searchPath}, nil, func(path string, d fs.DirEntry) {
 		// 检查包含模式
 		if includePattern != "" {
-			matched, _ := filepath.Match(includePattern, filepath.Base(path))
+			matched, _ := filepath.Match(includePattern, d.Name())
 			if !matched {
-				return nil
+				return
 			}
 		}
 
 		// 检查排除模式
 		if excludePattern != "" {
-			matched, _ := filepath.Match(excludePattern, filepath.Base(path))
+			matched, _ := filepath.Match(excludePattern, d.Name())
 			if matched {
-				return nil
+				return
 			}
 		}
 
 		// 读取并搜索文件内容
-		return searchInFile(path, regex, caseSensitive, result)
+		matches, err := searchInFile(path, regex, query, caseSensitive)
+		if len(matches) == 0 && err == nil {
+			return
+		}
+		mu.Lock()
+		result.Matches = append(result.Matches, matches...)
+		if err != nil {
+			result.Skipped = append(result.Skipped, err.Error())
+		}
+		mu.Unlock()
 	})
-
 	if err != nil {
 		return nil, err
 	}
+	sort.Strings(result.Skipped)
+	sort.Slice(result.Matches, func(i, j int) bool {
+		if result.Matches[i].File != result.Matches[j].File {
+			return result.Matches[i].File < result.Matches[j].File
+		}
+		return result.Matches[i].Line < result.Matches[j].Line
+	})
 
 	// 计算文件数量
 	fileSet := make(map[string]bool)
@@ -261,36 +273,37 @@ func extractMatch(content, query string, caseSensitive bool) string {
 }
 
 // searchInFile 在文件中搜索匹配项
-func searchInFile(filePath string, regex *regexp.Regexp, caseSensitive bool, result *GrepSearchResult) error {
+func searchInFile(filePath string, regex *regexp.Regexp, query string, caseSensitive bool) ([]GrepMatch, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
-		return nil // 忽略无法打开的文件
+		return nil, nil // 忽略无法打开的文件
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
+	scanner := newLineScanner(file)
 	lineNumber := 0
-	matchCount := 0
+	var matches []GrepMatch
 
-	for scanner.Scan() && matchCount < 50 { // 限制匹配数量
+	for scanner.Scan() && len(matches) < 50 { // 限制匹配数量
 		lineNumber++
 		line := scanner.Text()
 		
 		if regex.MatchString(line) {
 			// 找到匹配项
-			match := GrepMatch{
+			matches = append(matches, GrepMatch{
 				File:     filePath,
 				Line:     lineNumber,
 				Content:  line,
-				Match:    extractMatch(line, result.Query, caseSensitive),
-			}
-			
-			result.Matches = append(result.Matches, match)
-			matchCount++
+				Match:    extractMatch(line, query, caseSensitive),
+			})
 		}
 	}
 
-	return scanner.Err()
+	// 行过长时报告从该行起未搜索，其他读取错误忽略
+	if err := scanner.Err(); errors.Is(err, bufio.ErrTooLong) {
+		return matches, scanError(err, filePath, lineNumber)
+	}
+	return matches, nil
 }
 
 // NewGrepSearchTool 创建grep_search工具
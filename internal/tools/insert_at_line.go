@@ -0,0 +1,173 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// insertContextLines 插入结果中展示的上下文行数
+const insertContextLines = 2
+
+// InsertAtLineParams insert_at_line工具的参数
+type InsertAtLineParams struct {
+	TargetFile  string `json:"target_file"`
+	LineNumber  int    `json:"line_number"`
+	Content     string `json:"content"`
+	Explanation string `json:"explanation,omitempty"`
+}
+
+// InsertAtLineResult insert_at_line工具的返回结果
+type InsertAtLineResult struct {
+	TargetFile string `json:"target_file"`
+	Inserted   bool   `json:"inserted"`
+	StartLine  int    `json:"start_line"`
+	EndLine    int    `json:"end_line"`
+	TotalLines int    `json:"total_lines"`
+	Context    string `json:"context"`
+	Message    string `json:"message"`
+}
+
+// detectLineEnding 检测文件使用的换行符
+func detectLineEnding(content string) string {
+	if strings.Contains(content, "\r\n") {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// splitContentLines 将要插入的内容按行拆分，统一换行符
+func splitContentLines(content string) []string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = strings.TrimSuffix(content, "\n")
+	return strings.Split(content, "\n")
+}
+
+// insertAtLineFunction 在指定行插入内容工具函数
+func insertAtLineFunction(params map[string]interface{}) (interface{}, error) {
+	// 解析参数
+	targetFile, ok := params["target_file"].(string)
+	if !ok || targetFile == "" {
+		return nil, fmt.Errorf("target_file is required")
+	}
+
+	lineNumber, ok := intParam(params, "line_number")
+	if !ok {
+		return nil, fmt.Errorf("line_number is required")
+	}
+
+	content, ok := params["content"].(string)
+	if !ok {
+		return nil, fmt.Errorf("content is required")
+	}
+
+	workDir, _ := params["__work_dir__"].(string)
+
+	// 解析文件路径
+	filePath := targetFile
+	if !filepath.IsAbs(filePath) && workDir != "" {
+		filePath = filepath.Join(workDir, targetFile)
+	}
+
+	info, err := statWorkspaceFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("file not found: %s (use write_file or append_to_file to create it)", targetFile)
+		}
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	data, err := readWorkspaceFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	original := string(data)
+	lineEnding := detectLineEnding(original)
+	hasTrailingNewline := strings.HasSuffix(original, "\n")
+
+	var lines []string
+	if original != "" {
+		lines = strings.Split(strings.TrimSuffix(strings.ReplaceAll(original, "\r\n", "\n"), "\n"), "\n")
+	}
+
+	// line_number 为插入后新内容的首行行号；total+1 表示追加到末尾，负数从末尾倒数
+	if lineNumber < 0 {
+		lineNumber = len(lines) + 2 + lineNumber
+	}
+	if lineNumber < 1 || lineNumber > len(lines)+1 {
+		return nil, fmt.Errorf("line_number %d out of range: file has %d lines, valid values are 1 to %d", lineNumber, len(lines), len(lines)+1)
+	}
+
+	inserted := splitContentLines(content)
+	newLines := make([]string, 0, len(lines)+len(inserted))
+	newLines = append(newLines, lines[:lineNumber-1]...)
+	newLines = append(newLines, inserted...)
+	newLines = append(newLines, lines[lineNumber-1:]...)
+
+	output := strings.Join(newLines, lineEnding)
+	// 原文件为空或以换行结尾时保持以换行结尾；在末尾追加时也补上换行
+	if hasTrailingNewline || original == "" || lineNumber > len(lines) {
+		output += lineEnding
+	}
+
+	if err := writeWorkspaceFile(filePath, []byte(output), info.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("failed to write file: %w", err)
+	}
+
+	result := &InsertAtLineResult{
+		TargetFile: filePath,
+		Inserted:   true,
+		StartLine:  lineNumber,
+		EndLine:    lineNumber + len(inserted) - 1,
+		TotalLines: len(newLines),
+	}
+
+	// 展示插入位置附近的内容，便于确认缩进与位置
+	from := max(result.StartLine-insertContextLines, 1)
+	to := min(result.EndLine+insertContextLines, len(newLines))
+	var context strings.Builder
+	for i := from; i <= to; i++ {
+		fmt.Fprintf(&context, "%6d\t%s\n", i, newLines[i-1])
+	}
+	result.Context = context.String()
+	result.Message = fmt.Sprintf("Inserted %d lines at line %d", len(inserted), lineNumber)
+
+	return result, nil
+}
+
+// NewInsertAtLineTool 创建insert_at_line工具
+func NewInsertAtLineTool() Tool {
+	schema := ToolSchema{
+		Name:        "insert_at_line",
+		Description: "Insert content into an existing file at a specific line, shifting the existing lines down. Use this to add an import, a registry entry or a new function at a known position when search_replace would need awkward unique context. Read the file first to pick the line; the result shows the surrounding lines so you can check the placement and indentation.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"target_file": map[string]interface{}{
+					"type":        "string",
+					"description": "The file to insert into, relative to the workspace root or absolute.",
+				},
+				"line_number": map[string]interface{}{
+					"type":        "integer",
+					"description": "One-indexed line number the inserted content will start at: 1 inserts at the top, N inserts before the current line N, total lines + 1 (or -1) appends at the end.",
+				},
+				"content": map[string]interface{}{
+					"type":        "string",
+					"description": "The lines to insert, including their indentation. A trailing newline is optional.",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+			"required": []string{"target_file", "line_number", "content"},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: insertAtLineFunction,
+	}
+}
\ No newline at end of file
@@ -0,0 +1,49 @@
+package tools
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// DefaultMaxLineSize 默认的单行长度上限
+const DefaultMaxLineSize = 16 << 20
+
+// maxLineSize 逐行读取文件时单行的长度上限，超过时报告而不是截断
+var maxLineSize = DefaultMaxLineSize
+
+// SetMaxLineSize 设置单行长度上限，不大于0时使用默认值
+func SetMaxLineSize(size int) {
+	if size <= 0 {
+		size = DefaultMaxLineSize
+	}
+	maxLineSize = size
+}
+
+// LineTooLongError 文件中有超过长度上限的行（压缩的 JS、单行 JSON 等）
+type LineTooLongError struct {
+	Path  string
+	Line  int // 过长的行号
+	Limit int
+}
+
+func (e *LineTooLongError) Error() string {
+	return fmt.Sprintf("line %d of %s is longer than the %s line limit (max_line_size in the config file), the file cannot be read line by line from there",
+		e.Line, e.Path, formatFileSize(int64(e.Limit)))
+}
+
+// newLineScanner 按行读取，行长度上限为 maxLineSize
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	return scanner
+}
+
+// scanError 逐行读取的错误，lines 为已读取的行数；行过长时返回 LineTooLongError
+func scanError(err error, path string, lines int) error {
+	if errors.Is(err, bufio.ErrTooLong) {
+		return &LineTooLongError{Path: path, Line: lines + 1, Limit: maxLineSize}
+	}
+	return fmt.Errorf("failed to read file: %w", err)
+}
@@ -0,0 +1,312 @@
+package tools
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"openCursor/internal/lsp"
+)
+
+// maxCodeUsages 最多返回的引用数量
+const maxCodeUsages = 100
+
+// ListCodeUsagesParams list_code_usages工具的参数
+type ListCodeUsagesParams struct {
+	SymbolName     string `json:"symbol_name"`
+	FilePath       string `json:"file_path,omitempty"`
+	IncludePattern string `json:"include_pattern,omitempty"`
+	Explanation    string `json:"explanation,omitempty"`
+}
+
+// CodeUsage 一处符号引用
+type CodeUsage struct {
+	File         string `json:"file"`
+	Line         int    `json:"line"`
+	Column       int    `json:"column,omitempty"`
+	Snippet      string `json:"snippet"`
+	IsDefinition bool   `json:"is_definition,omitempty"`
+}
+
+// ListCodeUsagesResult list_code_usages工具的返回结果
+type ListCodeUsagesResult struct {
+	SymbolName string      `json:"symbol_name"`
+	Method     string      `json:"method"` // "lsp:<server>" or "grep"
+	Usages     []CodeUsage `json:"usages"`
+	Count      int         `json:"count"`
+	Truncated  bool        `json:"truncated,omitempty"`
+	Message    string      `json:"message"`
+}
+
+// lspUsages 通过语言服务器查找符号的所有引用（先workspace/symbol定位定义，再查references）
+func lspUsages(client *lsp.Client, symbolName, filePath, workDir string) ([]CodeUsage, error) {
+	symbols, err := client.WorkspaceSymbols(symbolName)
+	if err != nil {
+		return nil, err
+	}
+
+	var definitions []lsp.Location
+	for _, symbol := range symbols {
+		// 方法名可能显示为 Type.Method
+		name := symbol.Name
+		if idx := strings.LastIndex(name, "."); idx >= 0 {
+			name = name[idx+1:]
+		}
+		if name != symbolName {
+			continue
+		}
+		defPath := lsp.URIToPath(symbol.Location.URI)
+		if filePath != "" && !strings.HasSuffix(filepath.ToSlash(defPath), filepath.ToSlash(filePath)) {
+			continue
+		}
+		definitions = append(definitions, symbol.Location)
+	}
+	if len(definitions) == 0 {
+		return nil, fmt.Errorf("symbol '%s' not found by language server", symbolName)
+	}
+
+	// 同名符号过多时只取前几个定义
+	if len(definitions) > 5 {
+		definitions = definitions[:5]
+	}
+
+	var usages []CodeUsage
+	seen := make(map[string]bool)
+	for _, def := range definitions {
+		defPath := lsp.URIToPath(def.URI)
+		refs, err := client.References(defPath, def.Range.Start, true)
+		if err != nil {
+			return nil, err
+		}
+		for _, ref := range refs {
+			location := toSymbolLocation(ref, workDir)
+			key := fmt.Sprintf("%s:%d:%d", location.File, location.Line, location.Column)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			usages = append(usages, CodeUsage{
+				File:         location.File,
+				Line:         location.Line,
+				Column:       location.Column,
+				Snippet:      location.Snippet,
+				IsDefinition: ref.URI == def.URI && ref.Range.Start == def.Range.Start,
+			})
+		}
+	}
+
+	return usages, nil
+}
+
+// grepUsages 使用单词边界正则查找符号引用（语言服务器不可用时的回退方案）
+func grepUsages(symbolName, includePattern, workDir string) ([]CodeUsage, error) {
+	result, err := grepSearchFunction(map[string]interface{}{
+		"query":           `\b` + regexp.QuoteMeta(symbolName) + `\b`,
+		"case_sensitive":  true,
+		"include_pattern": includePattern,
+		"__work_dir__":    workDir,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	grepResult, ok := result.(*GrepSearchResult)
+	if !ok {
+		return nil, fmt.Errorf("unexpected grep result")
+	}
+
+	// 简单的定义识别：常见的声明关键字紧跟符号名
+	definitionPattern := regexp.MustCompile(`\b(func|type|class|def|interface|struct|enum|trait|fn|const|var|let)\s+(\([^)]*\)\s*)?` + regexp.QuoteMeta(symbolName) + `\b`)
+
+	usages := make([]CodeUsage, 0, len(grepResult.Matches))
+	for _, match := range grepResult.Matches {
+		usages = append(usages, CodeUsage{
+			File:         match.File,
+			Line:         match.Line,
+			Column:       match.Column,
+			Snippet:      strings.TrimSpace(match.Content),
+			IsDefinition: definitionPattern.MatchString(match.Content),
+		})
+	}
+	return usages, nil
+}
+
+// fillSnippets 为缺少内容的引用补充源码行
+func fillSnippets(usages []CodeUsage, workDir string) {
+	byFile := make(map[string][]int)
+	for i, usage := range usages {
+		if usage.Snippet == "" && usage.Line > 0 {
+			byFile[usage.File] = append(byFile[usage.File], i)
+		}
+	}
+
+	for file, indexes := range byFile {
+		path := file
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(workDir, path)
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		wanted := make(map[int][]int)
+		for _, idx := range indexes {
+			wanted[usages[idx].Line] = append(wanted[usages[idx].Line], idx)
+		}
+		scanner := bufio.NewScanner(f)
+		lineNumber := 0
+		for scanner.Scan() {
+			lineNumber++
+			for _, idx := range wanted[lineNumber] {
+				usages[idx].Snippet = strings.TrimSpace(scanner.Text())
+			}
+		}
+		f.Close()
+	}
+}
+
+// usagesClient 选择语言服务器：优先按file_path，其次按工作区中的项目标记文件
+func usagesClient(filePath, workDir string) (*lsp.Client, error) {
+	languageServers.SetRootDir(workDir)
+	if filePath != "" {
+		return languageServers.ClientForFile(filePath)
+	}
+	markers := []struct {
+		file   string
+		server string
+	}{
+		{"go.mod", "go"},
+		{"tsconfig.json", "typescript"},
+		{"package.json", "typescript"},
+		{"pyproject.toml", "python"},
+		{"setup.py", "python"},
+	}
+	for _, marker := range markers {
+		if _, err := os.Stat(filepath.Join(workDir, marker.file)); err == nil {
+			return languageServers.Client(marker.server)
+		}
+	}
+	return nil, fmt.Errorf("no language server matches this workspace")
+}
+
+// listCodeUsagesFunction 查找符号引用工具函数
+func listCodeUsagesFunction(params map[string]interface{}) (interface{}, error) {
+	// 解析参数
+	symbolName, ok := params["symbol_name"].(string)
+	if !ok || symbolName == "" {
+		return nil, fmt.Errorf("symbol_name is required")
+	}
+	if !regexp.MustCompile(`^[A-Za-z_$][\w$]*$`).MatchString(symbolName) {
+		return nil, fmt.Errorf("symbol_name must be a plain identifier (got '%s')", symbolName)
+	}
+
+	filePath, _ := params["file_path"].(string)
+	includePattern, _ := params["include_pattern"].(string)
+	workDir, _ := params["__work_dir__"].(string)
+	if workDir == "" {
+		workDir = "."
+	}
+
+	result := &ListCodeUsagesResult{
+		SymbolName: symbolName,
+	}
+
+	// 有可用的语言服务器时使用语义查找
+	var usages []CodeUsage
+	var lspErr error
+	if includePattern == "" {
+		if client, err := usagesClient(filePath, workDir); err == nil {
+			usages, lspErr = lspUsages(client, symbolName, filePath, workDir)
+			if lspErr == nil {
+				result.Method = "lsp:" + client.Name()
+			}
+		}
+	}
+
+	if result.Method == "" {
+		var err error
+		usages, err = grepUsages(symbolName, includePattern, workDir)
+		if err != nil {
+			return nil, err
+		}
+		result.Method = "grep"
+	}
+
+	// 相对工作区路径，定义排在前面
+	for i := range usages {
+		if rel, err := filepath.Rel(workDir, usages[i].File); err == nil && !strings.HasPrefix(rel, "..") {
+			usages[i].File = rel
+		}
+	}
+	sort.SliceStable(usages, func(i, j int) bool {
+		if usages[i].IsDefinition != usages[j].IsDefinition {
+			return usages[i].IsDefinition
+		}
+		if usages[i].File != usages[j].File {
+			return usages[i].File < usages[j].File
+		}
+		return usages[i].Line < usages[j].Line
+	})
+
+	if len(usages) > maxCodeUsages {
+		usages = usages[:maxCodeUsages]
+		result.Truncated = true
+	}
+	fillSnippets(usages, workDir)
+
+	result.Usages = usages
+	result.Count = len(usages)
+
+	switch {
+	case result.Count == 0:
+		result.Message = fmt.Sprintf("No usages of '%s' found", symbolName)
+	case result.Method == "grep":
+		result.Message = fmt.Sprintf("Found %d textual matches of '%s' (word-boundary search; may include unrelated identifiers with the same name)", result.Count, symbolName)
+	default:
+		result.Message = fmt.Sprintf("Found %d references to '%s'", result.Count, symbolName)
+	}
+	if lspErr != nil {
+		result.Message += fmt.Sprintf(". Language server lookup failed, fell back to grep: %v", lspErr)
+	}
+
+	return result, nil
+}
+
+// NewListCodeUsagesTool 创建list_code_usages工具
+func NewListCodeUsagesTool() Tool {
+	schema := ToolSchema{
+		Name:        "list_code_usages",
+		Description: "Find all references to a symbol (function, type, method, variable, class) across the codebase, returning the file, line and code snippet of every usage with definitions listed first. When a language server (gopls, pyright, typescript-language-server) is installed it returns precise, type-aware results; otherwise it falls back to a word-boundary text search. Use this before renaming a symbol or changing a function signature so that no call site is missed.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"symbol_name": map[string]interface{}{
+					"type":        "string",
+					"description": "The plain identifier to find usages of, e.g. 'NewClient' (not a regex, no package qualifier).",
+				},
+				"file_path": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional path of the file that defines the symbol, used to disambiguate symbols with the same name.",
+				},
+				"include_pattern": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional glob pattern to restrict the text search to certain files (e.g. '*.ts').",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+			"required": []string{"symbol_name"},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: listCodeUsagesFunction,
+	}
+}
\ No newline at end of file
@@ -0,0 +1,120 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"openCursor/internal/buildtasks"
+)
+
+// ListProjectTasksParams list_project_tasks工具的参数
+type ListProjectTasksParams struct {
+	Directory   string `json:"directory,omitempty"`
+	File        string `json:"file,omitempty"`
+	Explanation string `json:"explanation,omitempty"`
+}
+
+// ListProjectTasksResult list_project_tasks工具的返回结果
+type ListProjectTasksResult struct {
+	Files   []buildtasks.File `json:"files"`
+	Count   int               `json:"count"`
+	Message string            `json:"message"`
+}
+
+// listProjectTasksFunction 列出项目任务工具函数
+func listProjectTasksFunction(params map[string]interface{}) (interface{}, error) {
+	// 解析参数
+	directory, _ := params["directory"].(string)
+	file, _ := params["file"].(string)
+	workDir, _ := params["__work_dir__"].(string)
+
+	resolve := func(path string) string {
+		if !filepath.IsAbs(path) && workDir != "" {
+			return filepath.Join(workDir, path)
+		}
+		return path
+	}
+
+	var paths []string
+	if file != "" {
+		path := resolve(file)
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("file not found: %s", file)
+		}
+		paths = []string{path}
+	} else {
+		dir := workDir
+		if directory != "" {
+			dir = resolve(directory)
+		}
+		if dir == "" {
+			dir = "."
+		}
+		paths = buildtasks.Discover(dir)
+	}
+
+	result := &ListProjectTasksResult{
+		Files: []buildtasks.File{},
+	}
+
+	var runners []string
+	for _, path := range paths {
+		parsed, err := buildtasks.Parse(path)
+		if err != nil {
+			return nil, err
+		}
+		if rel, err := filepath.Rel(workDir, parsed.Path); err == nil && workDir != "" && !strings.HasPrefix(rel, "..") {
+			parsed.Path = rel
+		}
+		result.Files = append(result.Files, *parsed)
+		result.Count += len(parsed.Tasks)
+
+		switch parsed.Kind {
+		case buildtasks.KindMakefile:
+			runners = append(runners, fmt.Sprintf("`make -f %s <target>`", parsed.Path))
+		case buildtasks.KindTaskfile:
+			runners = append(runners, fmt.Sprintf("`task -t %s <task>`", parsed.Path))
+		}
+	}
+
+	if len(result.Files) == 0 {
+		result.Message = "No Makefile or Taskfile found"
+	} else {
+		result.Message = fmt.Sprintf("Found %d tasks in %d files. Run them with %s", result.Count, len(result.Files), strings.Join(runners, " or "))
+	}
+
+	return result, nil
+}
+
+// NewListProjectTasksTool 创建list_project_tasks工具
+func NewListProjectTasksTool() Tool {
+	schema := ToolSchema{
+		Name:        "list_project_tasks",
+		Description: "List the targets of the project's Makefile and the tasks of its Taskfile (go-task), with their descriptions, dependencies and the commands they run. Use this to discover the project's canonical build, test, lint and run commands instead of guessing them; prefer running these tasks over inventing equivalent commands.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"directory": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional directory to look for Makefile/Taskfile in, relative to the workspace root. Defaults to the workspace root.",
+				},
+				"file": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional path of a specific Makefile (e.g. 'build/rules.mk') or Taskfile to parse.",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+			"required": []string{},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: listProjectTasksFunction,
+	}
+}
\ No newline at end of file
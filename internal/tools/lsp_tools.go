@@ -0,0 +1,470 @@
+package tools
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"openCursor/internal/lsp"
+)
+
+// diagnosticsTimeout 等待语言服务器推送诊断的最长时间
+const diagnosticsTimeout = 15 * time.Second
+
+// languageServers 全局语言服务器管理器
+var languageServers = lsp.NewManager(nil)
+
+// SetLanguageServers 使用配置覆盖或新增语言服务器（需在注册工具前调用）
+func SetLanguageServers(servers map[string]lsp.ServerConfig) {
+	merged := lsp.DefaultServers()
+	for name, server := range servers {
+		merged[name] = server
+	}
+	languageServers.Shutdown()
+	languageServers = lsp.NewManager(merged)
+}
+
+// ShutdownLanguageServers 关闭所有已启动的语言服务器
+func ShutdownLanguageServers() {
+	languageServers.Shutdown()
+}
+
+// SymbolLocation 符号位置（1-based行列）
+type SymbolLocation struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// lspTarget 解析后的LSP工具目标
+type lspTarget struct {
+	path     string
+	position lsp.Position
+	client   *lsp.Client
+}
+
+// resolveLSPTarget 解析 file_path/line/symbol/column 参数并获取语言服务器
+func resolveLSPTarget(params map[string]interface{}) (*lspTarget, error) {
+	filePath, ok := params["file_path"].(string)
+	if !ok || filePath == "" {
+		return nil, fmt.Errorf("file_path is required")
+	}
+	line, ok := intParam(params, "line")
+	if !ok || line < 1 {
+		return nil, fmt.Errorf("line is required and must be >= 1")
+	}
+	symbol, _ := params["symbol"].(string)
+	column, _ := intParam(params, "column")
+	workDir, _ := params["__work_dir__"].(string)
+
+	path := filePath
+	if !filepath.IsAbs(path) && workDir != "" {
+		path = filepath.Join(workDir, filePath)
+	}
+
+	lineText, err := readLine(path, line)
+	if err != nil {
+		return nil, err
+	}
+
+	// 优先通过符号名定位列，其次使用给定列，默认取行首第一个非空白字符
+	byteCol := -1
+	if symbol != "" {
+		pattern := regexp.MustCompile(`(^|[^\w$])(` + regexp.QuoteMeta(symbol) + `)($|[^\w$])`)
+		loc := pattern.FindStringSubmatchIndex(lineText)
+		if loc == nil {
+			return nil, fmt.Errorf("symbol '%s' not found on line %d of %s: %s", symbol, line, filePath, strings.TrimSpace(lineText))
+		}
+		byteCol = loc[4]
+	} else if column >= 1 {
+		byteCol = runeOffsetToByte(lineText, column-1)
+	} else {
+		byteCol = len(lineText) - len(strings.TrimLeft(lineText, " \t"))
+	}
+
+	if workDir != "" {
+		languageServers.SetRootDir(workDir)
+	}
+	client, err := languageServers.ClientForFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lspTarget{
+		path:     path,
+		position: lsp.Position{Line: line - 1, Character: lsp.UTF16Column(lineText, byteCol)},
+		client:   client,
+	}, nil
+}
+
+// readLine 读取文件的指定行（1-based）
+func readLine(path string, line int) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	current := 0
+	for scanner.Scan() {
+		current++
+		if current == line {
+			return scanner.Text(), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	return "", fmt.Errorf("line %d exceeds total lines (%d) of %s", line, current, path)
+}
+
+// runeOffsetToByte 将字符偏移转换为字节偏移
+func runeOffsetToByte(text string, runeOffset int) int {
+	count := 0
+	for i := range text {
+		if count == runeOffset {
+			return i
+		}
+		count++
+	}
+	return len(text)
+}
+
+// toSymbolLocation 将LSP位置转换为工作区相对的1-based位置并附带源码行
+func toSymbolLocation(location lsp.Location, workDir string) SymbolLocation {
+	path := lsp.URIToPath(location.URI)
+	result := SymbolLocation{
+		File: path,
+		Line: location.Range.Start.Line + 1,
+	}
+
+	if lineText, err := readLine(path, result.Line); err == nil {
+		byteCol := lsp.ByteColumn(lineText, location.Range.Start.Character)
+		result.Column = utf8.RuneCountInString(lineText[:byteCol]) + 1
+		result.Snippet = strings.TrimSpace(lineText)
+	} else {
+		result.Column = location.Range.Start.Character + 1
+	}
+
+	if workDir != "" {
+		if rel, err := filepath.Rel(workDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+			result.File = rel
+		}
+	}
+	return result
+}
+
+// lspPositionProperties LSP工具共用的位置参数定义
+func lspPositionProperties() map[string]interface{} {
+	return map[string]interface{}{
+		"file_path": map[string]interface{}{
+			"type":        "string",
+			"description": "The path of the source file, relative to the workspace root.",
+		},
+		"line": map[string]interface{}{
+			"type":        "integer",
+			"description": "The one-indexed line number where the symbol appears.",
+		},
+		"symbol": map[string]interface{}{
+			"type":        "string",
+			"description": "The symbol name as it appears on that line; used to find the exact column. Preferred over column.",
+		},
+		"column": map[string]interface{}{
+			"type":        "integer",
+			"description": "Optional one-indexed column of the symbol, used when symbol is not given.",
+		},
+		"explanation": map[string]interface{}{
+			"type":        "string",
+			"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+		},
+	}
+}
+
+// GoToDefinitionResult go_to_definition工具的返回结果
+type GoToDefinitionResult struct {
+	Server      string           `json:"server"`
+	Definitions []SymbolLocation `json:"definitions"`
+	Count       int              `json:"count"`
+}
+
+// goToDefinitionFunction 跳转到定义工具函数
+func goToDefinitionFunction(params map[string]interface{}) (interface{}, error) {
+	target, err := resolveLSPTarget(params)
+	if err != nil {
+		return nil, err
+	}
+	workDir, _ := params["__work_dir__"].(string)
+
+	locations, err := target.client.Definition(target.path, target.position)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &GoToDefinitionResult{
+		Server:      target.client.Name(),
+		Definitions: []SymbolLocation{},
+	}
+	for _, location := range locations {
+		result.Definitions = append(result.Definitions, toSymbolLocation(location, workDir))
+	}
+	result.Count = len(result.Definitions)
+
+	return result, nil
+}
+
+// NewGoToDefinitionTool 创建go_to_definition工具
+func NewGoToDefinitionTool() Tool {
+	schema := ToolSchema{
+		Name:        "go_to_definition",
+		Description: "Find where a symbol is defined using the language server (gopls, pyright, typescript-language-server). Give the file and line where the symbol is used, plus the symbol name. Returns precise, type-aware definition locations, including definitions in dependencies and the standard library.",
+		InputSchema: map[string]interface{}{
+			"type":       "object",
+			"properties": lspPositionProperties(),
+			"required":   []string{"file_path", "line", "symbol"},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: goToDefinitionFunction,
+	}
+}
+
+// HoverSymbolResult hover_symbol工具的返回结果
+type HoverSymbolResult struct {
+	Server string `json:"server"`
+	Hover  string `json:"hover"`
+}
+
+// hoverSymbolFunction 悬停信息工具函数
+func hoverSymbolFunction(params map[string]interface{}) (interface{}, error) {
+	target, err := resolveLSPTarget(params)
+	if err != nil {
+		return nil, err
+	}
+
+	hover, err := target.client.Hover(target.path, target.position)
+	if err != nil {
+		return nil, err
+	}
+	if hover == "" {
+		hover = "No hover information available at this position"
+	}
+
+	return &HoverSymbolResult{
+		Server: target.client.Name(),
+		Hover:  hover,
+	}, nil
+}
+
+// NewHoverSymbolTool 创建hover_symbol工具
+func NewHoverSymbolTool() Tool {
+	schema := ToolSchema{
+		Name:        "hover_symbol",
+		Description: "Get the type signature and documentation of a symbol from the language server, exactly as an IDE shows on hover. Use this to check a function's parameters, a variable's type or an API's docs without reading its source.",
+		InputSchema: map[string]interface{}{
+			"type":       "object",
+			"properties": lspPositionProperties(),
+			"required":   []string{"file_path", "line", "symbol"},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: hoverSymbolFunction,
+	}
+}
+
+// DiagnosticItem 单条诊断信息
+type DiagnosticItem struct {
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Severity string `json:"severity"`
+	Source   string `json:"source,omitempty"`
+	Message  string `json:"message"`
+}
+
+// GetDiagnosticsResult get_diagnostics工具的返回结果
+type GetDiagnosticsResult struct {
+	FilePath    string           `json:"file_path"`
+	Server      string           `json:"server"`
+	Diagnostics []DiagnosticItem `json:"diagnostics"`
+	Errors      int              `json:"errors"`
+	Warnings    int              `json:"warnings"`
+}
+
+// getDiagnosticsFunction 获取诊断信息工具函数
+func getDiagnosticsFunction(params map[string]interface{}) (interface{}, error) {
+	filePath, ok := params["file_path"].(string)
+	if !ok || filePath == "" {
+		return nil, fmt.Errorf("file_path is required")
+	}
+	workDir, _ := params["__work_dir__"].(string)
+
+	path := filePath
+	if !filepath.IsAbs(path) && workDir != "" {
+		path = filepath.Join(workDir, filePath)
+	}
+
+	if workDir != "" {
+		languageServers.SetRootDir(workDir)
+	}
+	client, err := languageServers.ClientForFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	diagnostics, err := client.Diagnostics(path, diagnosticsTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &GetDiagnosticsResult{
+		FilePath:    filePath,
+		Server:      client.Name(),
+		Diagnostics: []DiagnosticItem{},
+	}
+	for _, diagnostic := range diagnostics {
+		item := DiagnosticItem{
+			Line:     diagnostic.Range.Start.Line + 1,
+			Column:   diagnostic.Range.Start.Character + 1,
+			Severity: lsp.SeverityName(diagnostic.Severity),
+			Source:   diagnostic.Source,
+			Message:  diagnostic.Message,
+		}
+		switch diagnostic.Severity {
+		case lsp.SeverityError:
+			result.Errors++
+		case lsp.SeverityWarning:
+			result.Warnings++
+		}
+		result.Diagnostics = append(result.Diagnostics, item)
+	}
+
+	return result, nil
+}
+
+// NewGetDiagnosticsTool 创建get_diagnostics工具
+func NewGetDiagnosticsTool() Tool {
+	schema := ToolSchema{
+		Name:        "get_diagnostics",
+		Description: "Get compiler and linter diagnostics (errors, warnings) for a file from the language server, reflecting the file's current content on disk. Use this after editing a file to check for type errors or unresolved references before moving on.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"file_path": map[string]interface{}{
+					"type":        "string",
+					"description": "The path of the source file, relative to the workspace root.",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+			"required": []string{"file_path"},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: getDiagnosticsFunction,
+	}
+}
+
+// RenameSymbolResult rename_symbol工具的返回结果
+type RenameSymbolResult struct {
+	Server       string         `json:"server"`
+	NewName      string         `json:"new_name"`
+	FilesChanged map[string]int `json:"files_changed"` // 文件 -> 编辑数量
+	TotalEdits   int            `json:"total_edits"`
+	Message      string         `json:"message"`
+}
+
+// renameSymbolFunction 重命名符号工具函数
+func renameSymbolFunction(params map[string]interface{}) (interface{}, error) {
+	newName, ok := params["new_name"].(string)
+	if !ok || newName == "" {
+		return nil, fmt.Errorf("new_name is required")
+	}
+
+	// 语言服务器只看到磁盘上的内容，无法在试运行的修改上计算重命名
+	if IsDryRun() {
+		return nil, fmt.Errorf("rename_symbol is not available in a dry run, use replace_all or search_replace instead")
+	}
+
+	target, err := resolveLSPTarget(params)
+	if err != nil {
+		return nil, err
+	}
+	workDir, _ := params["__work_dir__"].(string)
+
+	edit, err := target.client.Rename(target.path, target.position, newName)
+	if err != nil {
+		return nil, err
+	}
+
+	// 语言服务器可能修改目标文件之外的文件，写入任何文件前逐一按安全策略检查
+	paths := lsp.WorkspaceEditPaths(edit)
+	for _, path := range paths {
+		if err := checkSecurityPolicy(policyWrite, path, workDir); err != nil {
+			return nil, err
+		}
+	}
+	for _, path := range paths {
+		noteWorkspaceChange(path)
+	}
+
+	applied, err := lsp.ApplyWorkspaceEdit(edit)
+	if err != nil {
+		return nil, fmt.Errorf("rename partially applied: %w", err)
+	}
+
+	result := &RenameSymbolResult{
+		Server:       target.client.Name(),
+		NewName:      newName,
+		FilesChanged: make(map[string]int),
+	}
+	for path, count := range applied {
+		display := path
+		if rel, err := filepath.Rel(workDir, path); err == nil && workDir != "" && !strings.HasPrefix(rel, "..") {
+			display = rel
+		}
+		result.FilesChanged[display] = count
+		result.TotalEdits += count
+	}
+	result.Message = fmt.Sprintf("Renamed to '%s' with %d edits in %d files", newName, result.TotalEdits, len(result.FilesChanged))
+
+	return result, nil
+}
+
+// NewRenameSymbolTool 创建rename_symbol工具
+func NewRenameSymbolTool() Tool {
+	properties := lspPositionProperties()
+	properties["new_name"] = map[string]interface{}{
+		"type":        "string",
+		"description": "The new name for the symbol.",
+	}
+
+	schema := ToolSchema{
+		Name:        "rename_symbol",
+		Description: "Rename a symbol and update every reference to it across the workspace using the language server's semantic rename. This is safer than search and replace because it only touches real references to that symbol. The edits are written to disk immediately.",
+		InputSchema: map[string]interface{}{
+			"type":       "object",
+			"properties": properties,
+			"required":   []string{"file_path", "line", "symbol", "new_name"},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: renameSymbolFunction,
+	}
+}
\ No newline at end of file
@@ -11,7 +11,13 @@ import (
 type DefaultToolManager struct {
 	tools   map[string]Tool
 	mu      sync.RWMutex
-	workDir string // 工作目录，用于解析相对路径
+	workDir string            // 工作目录，用于解析相对路径
+	env     map[string]string // 会话级环境变量，注入到命令类工具中
+	shadow  *ShadowBranch     // 自动提交修改的影子分支，为空时不自动提交
+	observer func(FileChange) // 文件改动回调，为空时不记录
+	workspaces []Workspace   // 主工作目录外的工作区根目录，工具可用 workspace 参数选择
+	reads    readHashes       // 读取时的文件内容哈希，用于发现读取后的外部修改
+	edits    editHistory      // 修改类工具改动过的文件，附加到之后的查询中
 }
 
 // NewDefaultToolManager 创建新的工具管理器
@@ -37,6 +43,34 @@ func (tm *DefaultToolManager) GetWorkDirectory() string {
 	return tm.workDir
 }
 
+// SetWorkspaces 设置主工作目录外的工作区根目录
+func (tm *DefaultToolManager) SetWorkspaces(workspaces []Workspace) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.workspaces = workspaces
+}
+
+// Workspaces 获取主工作目录外的工作区根目录
+func (tm *DefaultToolManager) Workspaces() []Workspace {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	return tm.workspaces
+}
+
+// SetEnvironment 设置会话级环境变量覆盖
+func (tm *DefaultToolManager) SetEnvironment(env map[string]string) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.env = env
+}
+
+// SetShadowBranch 设置自动提交修改的影子分支
+func (tm *DefaultToolManager) SetShadowBranch(shadow *ShadowBranch) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.shadow = shadow
+}
+
 // RegisterTool 注册工具
 func (tm *DefaultToolManager) RegisterTool(name string, tool Tool) error {
 	tm.mu.Lock()
@@ -66,6 +100,10 @@ func (tm *DefaultToolManager) ListTools() []ToolSchema {
 	
 	schemas := make([]ToolSchema, 0, len(tm.tools))
 	for _, tool := range tm.tools {
+		if len(tm.workspaces) > 0 {
+			schemas = append(schemas, withWorkspaceParam(tool.Schema, tm.workspaces))
+			continue
+		}
 		schemas = append(schemas, tool.Schema)
 	}
 	
@@ -77,6 +115,10 @@ func (tm *DefaultToolManager) ExecuteTool(name string, params map[string]interfa
 	tm.mu.RLock()
 	tool, exists := tm.tools[name]
 	workDir := tm.workDir
+	env := tm.env
+	shadow := tm.shadow
+	observer := tm.observer
+	workspaces := tm.workspaces
 	tm.mu.RUnlock()
 	
 	if !exists {
@@ -91,9 +133,130 @@ func (tm *DefaultToolManager) ExecuteTool(name string, params map[string]interfa
 	if params == nil {
 		params = make(map[string]interface{})
 	}
+	
+	// 按 workspace 参数选择工作区根目录
+	_, qualified := params[workspaceParam]
+	mainDir := workDir
+	workDir, err := workspaceDir(params, workDir, workspaces)
+	if err != nil {
+		return &ToolResult{
+			Name:    name,
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	
+	// 参数来自模型输出，执行前检查；路径参数只能指向工作区根目录（主工作目录与其他工作区）之内
+	var roots []string
+	for _, dir := range []string{workDir, mainDir} {
+		if dir != "" {
+			roots = append(roots, dir)
+		}
+	}
+	for _, ws := range workspaces {
+		roots = append(roots, ws.Path)
+	}
+	if err := validateParams(tool.Schema, params, roots); err != nil {
+		return &ToolResult{
+			Name:    name,
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	
+	// 修改前按安全策略检查目标文件（所有非只读工具），编辑工具还检查它在上次读取后是否被修改
+	var target string
+	if !isReadOnlyTool(name) {
+		if _, path := changeTarget(params, workDir); path != "" {
+			action := policyWrite
+			if name == "delete_file" {
+				action = policyDelete
+			}
+			err := checkSecurityPolicy(action, path, workDir)
+			if err == nil && checksStaleness(name) {
+				target = path
+				err = tm.reads.check(target)
+			}
+			if err != nil {
+				return &ToolResult{
+					Name:    name,
+					Success: false,
+					Error:   err.Error(),
+				}, nil
+			}
+		}
+	}
+	
+	// 按确认策略确认（按命令确认的工具在执行命令时确认）
+	if !approveToolCall(name, params) {
+		return &ToolResult{
+			Name:    name,
+			Success: false,
+			Error:   fmt.Sprintf("the approval policy or the user denied the %s tool call", name),
+		}, nil
+	}
 	params["__work_dir__"] = workDir
+	if !qualified && len(workspaces) > 0 {
+		params["__workspaces__"] = workspaces
+	}
+	if len(env) > 0 {
+		params["__env__"] = env
+	}
+	
+	snapshot := snapshotTarget(name, params, workDir)
+	var changes *changeSet
+	if !isReadOnlyTool(name) {
+		changes = trackChanges(workDir)
+	}
 	
 	result, err := tool.Function(params)
+	written := changes.stop()
+	
+	// 记录读取和写入后的内容，之后的编辑以此为准；工具写入的每个文件都是代理自己的修改（失败的工具也可能已写入）
+	if err == nil {
+		if target != "" {
+			tm.reads.record(target)
+		}
+		tm.reads.record(readTargets(name, params, workDir)...)
+	}
+	for _, file := range written {
+		tm.reads.record(file.path)
+	}
+	
+	// 编辑后检查目标文件的语法，错误随结果返回给模型
+	if err == nil && syntaxCheck && IsEditTool(name) {
+		if _, path := changeTarget(params, workDir); path != "" {
+			if content, readErr := readWorkspaceFile(path); readErr == nil {
+				if message := checkSyntax(path, content); message != "" {
+					result = withSyntaxError(result, message)
+				}
+			}
+		}
+	}
+	
+	// 记录并通知目标文件及工具写入的其他文件的改动（失败的工具也可能已改动文件）
+	if snapshot != nil {
+		written = append([]*fileSnapshot{snapshot}, written...)
+	}
+	for _, file := range written {
+		if snapshot != nil && file != snapshot && file.path == filepath.Clean(snapshot.path) {
+			continue
+		}
+		if change, ok := file.changed(name); ok {
+			tm.edits.record(file)
+			if observer != nil {
+				observer(change)
+			}
+		}
+	}
+	
+	// 修改类工具执行后（即使失败也可能已改动文件）自动提交到影子分支
+	if shadow != nil && !isReadOnlyTool(name) {
+		if recordErr := shadow.Record(name, params); recordErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to auto-commit %s: %v\n", name, recordErr)
+		}
+	}
+	
 	if err != nil {
 		return &ToolResult{
 			Name:    name,
@@ -0,0 +1,578 @@
+package tools
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// 读取笔记本时每个单元格输出的上限
+const maxNotebookOutputChars = 2000
+
+// notebookOperations edit_notebook工具支持的操作
+var notebookOperations = []string{"replace_source", "insert", "delete", "move", "change_type", "clear_outputs"}
+
+// notebookCellTypes 单元格类型
+var notebookCellTypes = []string{"code", "markdown", "raw"}
+
+// notebook 笔记本 JSON。未解析的字段保留原始内容，写回时不丢失
+type notebook struct {
+	fields map[string]json.RawMessage
+	cells  []map[string]json.RawMessage
+	indent string
+}
+
+// NotebookCell read_notebook返回的单元格
+type NotebookCell struct {
+	Index          int      `json:"index"`
+	ID             string   `json:"id,omitempty"`
+	CellType       string   `json:"cell_type"`
+	Source         string   `json:"source"`
+	ExecutionCount *int     `json:"execution_count,omitempty"`
+	Outputs        []string `json:"outputs,omitempty"`
+}
+
+// ReadNotebookResult read_notebook工具的返回结果
+type ReadNotebookResult struct {
+	TargetFile string         `json:"target_file"`
+	Language   string         `json:"language,omitempty"`
+	CellCount  int            `json:"cell_count"`
+	Cells      []NotebookCell `json:"cells"`
+}
+
+// EditNotebookResult edit_notebook工具的返回结果
+type EditNotebookResult struct {
+	TargetFile string `json:"target_file"`
+	Operation  string `json:"operation"`
+	CellIndex  *int   `json:"cell_index,omitempty"`
+	CellID     string `json:"cell_id,omitempty"`
+	CellCount  int    `json:"cell_count"`
+	Created    bool   `json:"created,omitempty"`
+	Message    string `json:"message"`
+}
+
+// parseNotebook 解析笔记本，沿用原文件的缩进
+func parseNotebook(data []byte) (*notebook, error) {
+	nb := &notebook{indent: " "}
+	if err := json.Unmarshal(data, &nb.fields); err != nil {
+		return nil, fmt.Errorf("invalid notebook JSON: %w", err)
+	}
+	if raw, ok := nb.fields["cells"]; ok {
+		if err := json.Unmarshal(raw, &nb.cells); err != nil {
+			return nil, fmt.Errorf("invalid notebook cells: %w", err)
+		}
+	} else if _, ok := nb.fields["nbformat"]; !ok {
+		return nil, fmt.Errorf("not a Jupyter notebook (no cells or nbformat)")
+	}
+	if lines := bytes.SplitN(data, []byte("\n"), 3); len(lines) > 1 {
+		indent := lines[1][:len(lines[1])-len(bytes.TrimLeft(lines[1], " \t"))]
+		if len(indent) > 0 {
+			nb.indent = string(indent)
+		}
+	}
+	return nb, nil
+}
+
+// newNotebook 新建空笔记本（nbformat 4.5）
+func newNotebook() *notebook {
+	return &notebook{
+		fields: map[string]json.RawMessage{
+			"metadata":       json.RawMessage(`{}`),
+			"nbformat":       json.RawMessage(`4`),
+			"nbformat_minor": json.RawMessage(`5`),
+		},
+		indent: " ",
+	}
+}
+
+// encode 按 nbformat 的写法编码：键排序、不转义 HTML 字符、以换行结尾
+func (nb *notebook) encode() ([]byte, error) {
+	cells := nb.cells
+	if cells == nil {
+		cells = []map[string]json.RawMessage{}
+	}
+	data, err := encodeNotebookJSON(cells, "")
+	if err != nil {
+		return nil, err
+	}
+	nb.fields["cells"] = bytes.TrimSuffix(data, []byte("\n"))
+	return encodeNotebookJSON(nb.fields, nb.indent)
+}
+
+// encodeNotebookJSON 不转义 HTML 字符的 JSON 编码
+func encodeNotebookJSON(value interface{}, indent string) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if indent != "" {
+		encoder.SetIndent("", indent)
+	}
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// hasCellIDs nbformat 4.5 起单元格需要 id
+func (nb *notebook) hasCellIDs() bool {
+	var major, minor int
+	json.Unmarshal(nb.fields["nbformat"], &major)
+	json.Unmarshal(nb.fields["nbformat_minor"], &minor)
+	return major > 4 || (major == 4 && minor >= 5)
+}
+
+// language 笔记本内核的语言
+func (nb *notebook) language() string {
+	var metadata struct {
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+		Kernelspec struct {
+			Language string `json:"language"`
+		} `json:"kernelspec"`
+	}
+	json.Unmarshal(nb.fields["metadata"], &metadata)
+	if metadata.LanguageInfo.Name != "" {
+		return metadata.LanguageInfo.Name
+	}
+	return metadata.Kernelspec.Language
+}
+
+// cellString 读取单元格的字符串字段
+func cellString(cell map[string]json.RawMessage, key string) string {
+	var value string
+	json.Unmarshal(cell[key], &value)
+	return value
+}
+
+// multilineText nbformat 的多行文本：字符串或字符串列表
+func multilineText(raw json.RawMessage) string {
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		return text
+	}
+	var lines []string
+	json.Unmarshal(raw, &lines)
+	return strings.Join(lines, "")
+}
+
+// sourceLines 按 nbformat 的写法把源码拆为保留换行符的行列表
+func sourceLines(source string) json.RawMessage {
+	lines := []string{}
+	for source != "" {
+		i := strings.IndexByte(source, '\n')
+		if i < 0 {
+			lines = append(lines, source)
+			break
+		}
+		lines = append(lines, source[:i+1])
+		source = source[i+1:]
+	}
+	data, _ := encodeNotebookJSON(lines, "")
+	return bytes.TrimSuffix(data, []byte("\n"))
+}
+
+// summarizeOutputs 单元格输出的文本摘要：文本原样（截断），图片等只给出类型
+func summarizeOutputs(raw json.RawMessage) []string {
+	var outputs []struct {
+		OutputType string                     `json:"output_type"`
+		Name       string                     `json:"name"`
+		Text       json.RawMessage            `json:"text"`
+		Data       map[string]json.RawMessage `json:"data"`
+		Ename      string                     `json:"ename"`
+		Evalue     string                     `json:"evalue"`
+	}
+	json.Unmarshal(raw, &outputs)
+
+	var summaries []string
+	for _, output := range outputs {
+		var text string
+		switch output.OutputType {
+		case "stream":
+			text = multilineText(output.Text)
+		case "error":
+			text = fmt.Sprintf("%s: %s", output.Ename, output.Evalue)
+		default:
+			if plain, ok := output.Data["text/plain"]; ok {
+				text = multilineText(plain)
+			}
+			var mimes []string
+			for mime := range output.Data {
+				if mime != "text/plain" {
+					mimes = append(mimes, "["+mime+"]")
+				}
+			}
+			sort.Strings(mimes)
+			text = strings.TrimSpace(text + "\n" + strings.Join(mimes, "\n"))
+		}
+		if len(text) > maxNotebookOutputChars {
+			text = text[:maxNotebookOutputChars] + "\n... (truncated)"
+		}
+		summaries = append(summaries, fmt.Sprintf("%s: %s", output.OutputType, text))
+	}
+	return summaries
+}
+
+// notebookPath 解析笔记本路径参数
+func notebookPath(params map[string]interface{}) (string, string, error) {
+	targetFile, ok := params["target_file"].(string)
+	if !ok || targetFile == "" {
+		return "", "", fmt.Errorf("target_file is required")
+	}
+	if !strings.EqualFold(filepath.Ext(targetFile), ".ipynb") {
+		return "", "", fmt.Errorf("target_file must be a .ipynb notebook")
+	}
+	workDir, _ := params["__work_dir__"].(string)
+	filePath := targetFile
+	if !filepath.IsAbs(filePath) && workDir != "" {
+		filePath = filepath.Join(workDir, targetFile)
+	}
+	return targetFile, filePath, nil
+}
+
+// readNotebookFunction 读取笔记本工具函数
+func readNotebookFunction(params map[string]interface{}) (interface{}, error) {
+	targetFile, filePath, err := notebookPath(params)
+	if err != nil {
+		return nil, err
+	}
+	includeOutputs := true
+	if v, ok := params["include_outputs"].(bool); ok {
+		includeOutputs = v
+	}
+
+	data, err := readWorkspaceFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("file not found: %s", targetFile)
+		}
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	nb, err := parseNotebook(data)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ReadNotebookResult{
+		TargetFile: targetFile,
+		Language:   nb.language(),
+		CellCount:  len(nb.cells),
+		Cells:      make([]NotebookCell, 0, len(nb.cells)),
+	}
+	for i, raw := range nb.cells {
+		cell := NotebookCell{
+			Index:    i,
+			ID:       cellString(raw, "id"),
+			CellType: cellString(raw, "cell_type"),
+			Source:   multilineText(raw["source"]),
+		}
+		var count int
+		if json.Unmarshal(raw["execution_count"], &count) == nil && raw["execution_count"] != nil {
+			cell.ExecutionCount = &count
+		}
+		if includeOutputs {
+			cell.Outputs = summarizeOutputs(raw["outputs"])
+		}
+		result.Cells = append(result.Cells, cell)
+	}
+	return result, nil
+}
+
+// newCellID nbformat 4.5 的单元格 id
+func newCellID() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// newNotebookCell 新建单元格，代码单元格带空的输出
+func newNotebookCell(cellType, source string, withID bool) map[string]json.RawMessage {
+	cellTypeJSON, _ := json.Marshal(cellType)
+	cell := map[string]json.RawMessage{
+		"cell_type": cellTypeJSON,
+		"metadata":  json.RawMessage(`{}`),
+		"source":    sourceLines(source),
+	}
+	if cellType == "code" {
+		cell["execution_count"] = json.RawMessage(`null`)
+		cell["outputs"] = json.RawMessage(`[]`)
+	}
+	if withID {
+		id, _ := json.Marshal(newCellID())
+		cell["id"] = id
+	}
+	return cell
+}
+
+// setCellType 修改单元格类型，并按 nbformat 增删代码单元格特有的字段
+func setCellType(cell map[string]json.RawMessage, cellType string) {
+	cell["cell_type"], _ = json.Marshal(cellType)
+	if cellType == "code" {
+		if _, ok := cell["outputs"]; !ok {
+			cell["outputs"] = json.RawMessage(`[]`)
+		}
+		if _, ok := cell["execution_count"]; !ok {
+			cell["execution_count"] = json.RawMessage(`null`)
+		}
+		return
+	}
+	delete(cell, "outputs")
+	delete(cell, "execution_count")
+}
+
+// findCell 按 cell_id 或 cell_index 定位单元格
+func (nb *notebook) findCell(params map[string]interface{}) (int, error) {
+	if id, ok := params["cell_id"].(string); ok && id != "" {
+		for i, cell := range nb.cells {
+			if cellString(cell, "id") == id {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("no cell with id %q", id)
+	}
+	index, ok := intParam(params, "cell_index")
+	if !ok {
+		return 0, fmt.Errorf("cell_index or cell_id is required")
+	}
+	if index < 0 || index >= len(nb.cells) {
+		return 0, fmt.Errorf("cell_index %d is out of range (the notebook has %d cells)", index, len(nb.cells))
+	}
+	return index, nil
+}
+
+// editNotebookFunction 编辑笔记本工具函数
+func editNotebookFunction(params map[string]interface{}) (interface{}, error) {
+	targetFile, filePath, err := notebookPath(params)
+	if err != nil {
+		return nil, err
+	}
+	operation, _ := params["operation"].(string)
+	source, hasSource := params["source"].(string)
+	cellType, _ := params["cell_type"].(string)
+	if cellType != "" && !containsString(notebookCellTypes, cellType) {
+		return nil, fmt.Errorf("invalid cell_type %q (expected one of %s)", cellType, strings.Join(notebookCellTypes, ", "))
+	}
+
+	result := &EditNotebookResult{TargetFile: targetFile, Operation: operation}
+	var nb *notebook
+	data, err := readWorkspaceFile(filePath)
+	switch {
+	case err == nil:
+		if nb, err = parseNotebook(data); err != nil {
+			return nil, err
+		}
+	case os.IsNotExist(err) && operation == "insert":
+		// 插入时可以新建笔记本
+		nb = newNotebook()
+		result.Created = true
+		if err := makeWorkspaceDir(filepath.Dir(filePath)); err != nil {
+			return nil, fmt.Errorf("failed to create directory: %w", err)
+		}
+	case os.IsNotExist(err):
+		return nil, fmt.Errorf("file not found: %s", targetFile)
+	default:
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	cellIndex := -1
+	switch operation {
+	case "replace_source":
+		if !hasSource {
+			return nil, fmt.Errorf("source is required for replace_source")
+		}
+		index, err := nb.findCell(params)
+		if err != nil {
+			return nil, err
+		}
+		nb.cells[index]["source"] = sourceLines(source)
+		if cellType != "" {
+			setCellType(nb.cells[index], cellType)
+		}
+		cellIndex = index
+
+	case "insert":
+		if !hasSource {
+			return nil, fmt.Errorf("source is required for insert")
+		}
+		if cellType == "" {
+			cellType = "code"
+		}
+		// cell_index 为插入位置，省略时追加到末尾
+		index := len(nb.cells)
+		if i, ok := intParam(params, "cell_index"); ok {
+			if i < 0 || i > len(nb.cells) {
+				return nil, fmt.Errorf("cell_index %d is out of range (the notebook has %d cells)", i, len(nb.cells))
+			}
+			index = i
+		}
+		cell := newNotebookCell(cellType, source, nb.hasCellIDs())
+		nb.cells = append(nb.cells, nil)
+		copy(nb.cells[index+1:], nb.cells[index:])
+		nb.cells[index] = cell
+		cellIndex = index
+
+	case "delete":
+		index, err := nb.findCell(params)
+		if err != nil {
+			return nil, err
+		}
+		result.CellID = cellString(nb.cells[index], "id")
+		nb.cells = append(nb.cells[:index], nb.cells[index+1:]...)
+		cellIndex = index
+
+	case "move":
+		index, err := nb.findCell(params)
+		if err != nil {
+			return nil, err
+		}
+		to, ok := intParam(params, "to_index")
+		if !ok || to < 0 || to >= len(nb.cells) {
+			return nil, fmt.Errorf("to_index is required for move and must be between 0 and %d", len(nb.cells)-1)
+		}
+		cell := nb.cells[index]
+		nb.cells = append(nb.cells[:index], nb.cells[index+1:]...)
+		nb.cells = append(nb.cells[:to], append([]map[string]json.RawMessage{cell}, nb.cells[to:]...)...)
+		cellIndex = to
+
+	case "change_type":
+		if cellType == "" {
+			return nil, fmt.Errorf("cell_type is required for change_type")
+		}
+		index, err := nb.findCell(params)
+		if err != nil {
+			return nil, err
+		}
+		setCellType(nb.cells[index], cellType)
+		cellIndex = index
+
+	case "clear_outputs":
+		// 省略 cell_index 与 cell_id 时清除所有单元格的输出
+		_, hasIndex := intParam(params, "cell_index")
+		id, _ := params["cell_id"].(string)
+		first, last := 0, len(nb.cells)-1
+		if hasIndex || id != "" {
+			index, err := nb.findCell(params)
+			if err != nil {
+				return nil, err
+			}
+			first, last = index, index
+			cellIndex = index
+		}
+		for i := first; i <= last; i++ {
+			if cellString(nb.cells[i], "cell_type") == "code" {
+				nb.cells[i]["outputs"] = json.RawMessage(`[]`)
+				nb.cells[i]["execution_count"] = json.RawMessage(`null`)
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown operation %q (expected one of %s)", operation, strings.Join(notebookOperations, ", "))
+	}
+
+	if cellIndex >= 0 {
+		result.CellIndex = &cellIndex
+		if operation != "delete" {
+			result.CellID = cellString(nb.cells[cellIndex], "id")
+		}
+	}
+	output, err := nb.encode()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode notebook: %w", err)
+	}
+	if err := writeWorkspaceFile(filePath, output, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write file: %w", err)
+	}
+	result.CellCount = len(nb.cells)
+	result.Message = fmt.Sprintf("%s applied, the notebook has %d cells", operation, result.CellCount)
+	return result, nil
+}
+
+// NewReadNotebookTool 创建read_notebook工具
+func NewReadNotebookTool() Tool {
+	schema := ToolSchema{
+		Name:        "read_notebook",
+		Description: "Read a Jupyter notebook (.ipynb) as a list of cells with their index, id, type, source and a text summary of their outputs (images and other rich outputs are only named). Use this instead of read_file for notebooks, and edit_notebook to change them.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"target_file": map[string]interface{}{
+					"type":        "string",
+					"description": "The path of the notebook, relative to the workspace root or absolute",
+				},
+				"include_outputs": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Whether to include cell outputs. Defaults to true.",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+			"required": []string{"target_file"},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: readNotebookFunction,
+	}
+}
+
+// NewEditNotebookTool 创建edit_notebook工具
+func NewEditNotebookTool() Tool {
+	schema := ToolSchema{
+		Name:        "edit_notebook",
+		Description: "Edit a Jupyter notebook (.ipynb) cell by cell while keeping the notebook JSON valid: replace a cell's source, insert a new cell (creating the notebook if it does not exist), delete, move or change the type of a cell, or clear outputs. Cells are addressed by the 0-based cell_index or the cell_id shown by read_notebook. Never edit notebooks with search_replace or write_file.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"target_file": map[string]interface{}{
+					"type":        "string",
+					"description": "The path of the notebook, relative to the workspace root or absolute",
+				},
+				"operation": map[string]interface{}{
+					"type":        "string",
+					"description": "replace_source, insert, delete, move, change_type or clear_outputs",
+					"enum":        notebookOperations,
+				},
+				"cell_index": map[string]interface{}{
+					"type":        "integer",
+					"description": "0-based index of the cell. For insert, the position of the new cell (defaults to the end). For clear_outputs, omit to clear every cell.",
+				},
+				"cell_id": map[string]interface{}{
+					"type":        "string",
+					"description": "Id of the cell, instead of cell_index",
+				},
+				"source": map[string]interface{}{
+					"type":        "string",
+					"description": "The full new source of the cell, for replace_source and insert",
+				},
+				"cell_type": map[string]interface{}{
+					"type":        "string",
+					"description": "Cell type for insert (defaults to code) and change_type, or to also change the type on replace_source",
+					"enum":        notebookCellTypes,
+				},
+				"to_index": map[string]interface{}{
+					"type":        "integer",
+					"description": "For move: the new 0-based index of the cell",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+			"required": []string{"target_file", "operation"},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: editNotebookFunction,
+	}
+}
@@ -0,0 +1,228 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// editorCommand 配置中的编辑器命令，为空时依次使用 $VISUAL、$EDITOR 与 VS Code
+var editorCommand string
+
+// SetEditor 设置open_in_editor工具使用的编辑器命令（可以带参数）
+func SetEditor(command string) {
+	editorCommand = command
+}
+
+// terminalEditors 在终端中运行的编辑器：代理运行时终端被占用，不启动，只告诉用户打开的命令
+var terminalEditors = map[string]bool{
+	"vi": true, "vim": true, "nvim": true, "nano": true, "emacs": true, "micro": true,
+	"kak": true, "hx": true, "helix": true, "joe": true, "ne": true, "mg": true,
+}
+
+// OpenInEditorResult open_in_editor工具的返回结果
+type OpenInEditorResult struct {
+	FilePath string `json:"file_path"`
+	Line     int    `json:"line,omitempty"`
+	Editor   string `json:"editor,omitempty"`
+	Command  string `json:"command,omitempty"`
+	Opened   bool   `json:"opened"`             // 编辑器已启动
+	Waited   bool   `json:"waited,omitempty"`   // 等到了用户处理完成
+	Modified bool   `json:"modified,omitempty"` // 用户修改了文件
+	UserNote string `json:"user_note,omitempty"`
+	Message  string `json:"message"`
+}
+
+// findEditor 要使用的编辑器命令
+func findEditor() []string {
+	for _, command := range []string{editorCommand, os.Getenv("VISUAL"), os.Getenv("EDITOR")} {
+		if fields := strings.Fields(command); len(fields) > 0 {
+			return fields
+		}
+	}
+	for _, name := range []string{"code", "cursor", "codium"} {
+		if _, err := exec.LookPath(name); err == nil {
+			return []string{name}
+		}
+	}
+	return nil
+}
+
+// editorArgs 在指定行列打开文件的命令行，按编辑器的惯例传入位置
+func editorArgs(editor []string, file string, line, column int) []string {
+	args := append([]string{}, editor...)
+	position := file
+	if line > 0 {
+		position += ":" + strconv.Itoa(line)
+		if column > 0 {
+			position += ":" + strconv.Itoa(column)
+		}
+	}
+	name := strings.TrimSuffix(strings.ToLower(filepath.Base(editor[0])), ".exe")
+	switch {
+	case name == "code" || name == "code-insiders" || name == "codium" || name == "cursor" || name == "windsurf":
+		return append(args, "-g", position)
+	case name == "subl" || name == "zed" || name == "hx" || name == "helix":
+		return append(args, position)
+	case name == "idea" || name == "goland" || name == "pycharm" || name == "webstorm" || name == "clion":
+		if line > 0 {
+			args = append(args, "--line", strconv.Itoa(line))
+		}
+		return append(args, file)
+	}
+	// vim、nano、emacs 等使用 +行号
+	if line > 0 {
+		args = append(args, "+"+strconv.Itoa(line))
+	}
+	return append(args, file)
+}
+
+// openInEditorFunction 在编辑器中打开文件的工具函数
+func openInEditorFunction(params map[string]interface{}) (interface{}, error) {
+	if IsDryRun() {
+		return nil, fmt.Errorf("the open_in_editor tool is not available in a dry run")
+	}
+	filePath, _ := params["file_path"].(string)
+	if filePath == "" {
+		return nil, fmt.Errorf("file_path is required")
+	}
+	line, _ := intParam(params, "line")
+	column, _ := intParam(params, "column")
+	message, _ := params["message"].(string)
+	wait, _ := params["wait"].(bool)
+	workDir, _ := params["__work_dir__"].(string)
+
+	target := filePath
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(workDir, target)
+	}
+	before, err := os.ReadFile(target)
+	if err != nil {
+		return nil, fmt.Errorf("file not found: %s", filePath)
+	}
+
+	result := &OpenInEditorResult{FilePath: filePath, Line: line}
+	location := filePath
+	if line > 0 {
+		location += ":" + strconv.Itoa(line)
+	}
+	editor := findEditor()
+	if editor == nil {
+		result.Message = "No editor found (set editor in the config file, $VISUAL or $EDITOR, or install VS Code)"
+	} else {
+		args := editorArgs(editor, target, line, column)
+		result.Editor = editor[0]
+		result.Command = strings.Join(quoteArgs(args), " ")
+		if terminalEditors[strings.ToLower(filepath.Base(editor[0]))] {
+			result.Message = fmt.Sprintf("%s is a terminal editor and the terminal is in use by the agent; the user can open the file with: %s", editor[0], result.Command)
+		} else {
+			cmd := exec.Command(args[0], args[1:]...)
+			cmd.Dir = workDir
+			if err := cmd.Start(); err != nil {
+				return nil, fmt.Errorf("failed to start %s: %w", editor[0], err)
+			}
+			// 不等待编辑器退出（GUI 编辑器通常把文件交给已经打开的窗口后立即退出）
+			go cmd.Wait()
+			result.Opened = true
+			result.Message = fmt.Sprintf("Opened %s in %s", location, editor[0])
+		}
+	}
+
+	if !wait {
+		return result, nil
+	}
+	// 没有打开编辑器时告诉用户打开的命令
+	command := ""
+	if !result.Opened {
+		command = result.Command
+	}
+	note, ok := awaitHandoff(location, message, command)
+	if !ok {
+		result.Message += "; could not wait for the user (no interactive input)"
+		return result, nil
+	}
+	result.Waited = true
+	result.UserNote = note
+	if after, err := os.ReadFile(target); err == nil && !bytes.Equal(before, after) {
+		result.Modified = true
+	}
+	if result.Modified {
+		result.Message += "; the user modified the file, read it again before continuing"
+	} else {
+		result.Message += "; the user finished without modifying the file"
+	}
+	return result, nil
+}
+
+// awaitHandoff 请用户处理指定位置并等待完成，返回用户输入的说明。与命令确认共用输入与确认函数，
+// 设置了确认函数时（如编辑器插件）由确认函数等待用户
+func awaitHandoff(location, message, command string) (string, bool) {
+	approvalState.Lock()
+	defer approvalState.Unlock()
+
+	if approvalState.handler != nil {
+		return "", approvalState.handler("处理 "+location, message)
+	}
+	out := approvalState.output
+	fmt.Fprintf(out, "\n✋ 需要你处理: %s\n", location)
+	if message != "" {
+		fmt.Fprintf(out, "   %s\n", message)
+	}
+	if command != "" {
+		fmt.Fprintf(out, "   打开命令: %s\n", command)
+	}
+	fmt.Fprint(out, "   完成后按回车继续（可以输入给代理的说明）: ")
+	answer, err := approvalState.readLine()
+	if err != nil && answer == "" {
+		fmt.Fprintln(out)
+		return "", false
+	}
+	return strings.TrimSpace(answer), true
+}
+
+// NewOpenInEditorTool 创建open_in_editor工具
+func NewOpenInEditorTool() Tool {
+	schema := ToolSchema{
+		Name:        "open_in_editor",
+		Description: "Open a file at a specific line in the user's editor (the configured editor, $VISUAL, $EDITOR or VS Code) to hand a decision over to the USER, e.g. an ambiguous merge conflict, a product choice or a secret only they know. With wait, the agent pauses until the user confirms they are done and reports whether they modified the file and any note they typed. Use it sparingly, only for decisions you cannot make yourself; explain in message exactly what the user should decide.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"file_path": map[string]interface{}{
+					"type":        "string",
+					"description": "The file to open, relative to the workspace root.",
+				},
+				"line": map[string]interface{}{
+					"type":        "integer",
+					"description": "The 1-based line to place the cursor on",
+				},
+				"column": map[string]interface{}{
+					"type":        "integer",
+					"description": "The 1-based column, for editors that support it",
+				},
+				"message": map[string]interface{}{
+					"type":        "string",
+					"description": "What the user should look at or decide, shown to them in the terminal",
+				},
+				"wait": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Wait until the user is done before continuing (default false)",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+			"required": []string{"file_path"},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: openInEditorFunction,
+	}
+}
@@ -0,0 +1,327 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// 工具参数的上限。参数来自模型输出，按不可信输入处理
+const (
+	MaxParamBytes       = 16 << 20 // 全部参数（编码后）的总大小
+	maxParamStringBytes = 8 << 20  // 单个字符串参数，如写入文件的内容
+	maxParamDepth       = 16       // 对象与数组的嵌套深度
+	maxParamItems       = 10000    // 单个对象的字段数或数组的元素数
+	maxPathLength       = 4096     // 路径参数的长度
+	maxIntParam         = math.MaxInt32
+)
+
+// reservedParamPrefix 管理器注入的内部参数前缀（__work_dir__、__env__），模型不能传入
+const reservedParamPrefix = "__"
+
+// pathParams 表示文件系统路径的参数名，"files" 为路径数组或带 target_file 的对象数组
+var pathParams = map[string]bool{
+	"target_file":             true,
+	"file_path":               true,
+	"relative_workspace_path": true,
+	"path":                    true,
+	"directory":               true,
+	"file":                    true,
+	"output_file":             true,
+	"files":                   true,
+	"target":                  true,
+	"context":                 true, // docker 构建上下文
+	"dockerfile":              true,
+	"destination":             true, // extract_archive 的解压目录
+	"path_a":                  true, // diff_files 比较的两侧
+	"path_b":                  true,
+	"example_path":            true, // env_file 生成的示例文件
+	"diff_file":               true, // summarize_diff 读取的补丁文件
+	"source":                  true, // coverage_report 统计覆盖率的目录
+	"save_as":                 true, // run_benchmarks 的结果标签，按路径检查以免以后落盘时越界
+}
+
+// nonPathParams 与路径参数同名、但不是文件系统路径的参数
+var nonPathParams = map[string]map[string]bool{
+	"edit_structured_file": {"path": true}, // 文档内的键路径，如 server.port
+}
+
+// ParamError 工具参数不合法
+type ParamError struct {
+	Param   string // 参数路径，如 files[2].target_file
+	Problem string
+}
+
+func (e *ParamError) Error() string {
+	if e.Param == "" {
+		return "invalid parameters: " + e.Problem
+	}
+	return fmt.Sprintf("invalid parameter %q: %s", e.Param, e.Problem)
+}
+
+// paramValidator 遍历参数时的状态
+type paramValidator struct {
+	tool  string
+	roots []string // 工作区根目录，第一个为相对路径的基准
+	total int      // 已检查的字符串总字节数
+}
+
+// validateParams 检查模型传入的参数：保留字段、嵌套深度、元素数、字符串大小与编码、数值、路径，
+// 以及与工具输入模式中声明的类型是否一致。roots 为工作区根目录，第一个为相对路径的基准，为空时不检查路径的位置
+func validateParams(schema ToolSchema, params map[string]interface{}, roots []string) error {
+	for key := range params {
+		if strings.HasPrefix(key, reservedParamPrefix) {
+			return &ParamError{Param: key, Problem: "reserved parameter name"}
+		}
+	}
+	v := &paramValidator{tool: schema.Name, roots: roots}
+	if err := v.check("", "", params, 0); err != nil {
+		return err
+	}
+	inputSchema, _ := schema.InputSchema.(map[string]interface{})
+	return checkProperties("", inputSchema, params)
+}
+
+// checkProperties 按模式中的 properties 检查对象各字段的类型
+func checkProperties(name string, schema map[string]interface{}, object map[string]interface{}) error {
+	properties, _ := schema["properties"].(map[string]interface{})
+	for field, value := range object {
+		property, _ := properties[field].(map[string]interface{})
+		if property == nil || value == nil {
+			continue
+		}
+		childName := field
+		if name != "" {
+			childName = name + "." + field
+		}
+		if err := checkType(childName, property, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkType 检查值是否符合模式声明的类型，整数也接受数字字符串
+func checkType(name string, property map[string]interface{}, value interface{}) error {
+	expected, _ := property["type"].(string)
+	mismatch := func() error {
+		return &ParamError{Param: name, Problem: fmt.Sprintf("expected %s, got %s", expected, jsonTypeName(value))}
+	}
+
+	switch expected {
+	case "string":
+		if _, ok := value.(string); !ok {
+			return mismatch()
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return mismatch()
+		}
+	case "integer":
+		switch value.(type) {
+		case float64, int, int64, string:
+		default:
+			return mismatch()
+		}
+		if _, ok := intParam(map[string]interface{}{"value": value}, "value"); !ok {
+			return &ParamError{Param: name, Problem: fmt.Sprintf("expected an integer between %d and %d, got %v", -maxIntParam, maxIntParam, value)}
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return mismatch()
+		}
+		itemSchema, _ := property["items"].(map[string]interface{})
+		if itemSchema == nil {
+			return nil
+		}
+		for i, item := range items {
+			if err := checkType(fmt.Sprintf("%s[%d]", name, i), itemSchema, item); err != nil {
+				return err
+			}
+		}
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return mismatch()
+		}
+		return checkProperties(name, property, object)
+	}
+	return nil
+}
+
+// jsonTypeName 值的JSON类型名
+func jsonTypeName(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case int, int64:
+		return "integer"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// check 递归检查一个值，name 为参数路径，key 为所在字段名（用于识别路径参数）
+func (v *paramValidator) check(name, key string, value interface{}, depth int) error {
+	if depth > maxParamDepth {
+		return &ParamError{Param: name, Problem: fmt.Sprintf("nested deeper than %d levels", maxParamDepth)}
+	}
+
+	switch val := value.(type) {
+	case string:
+		if len(val) > maxParamStringBytes {
+			return &ParamError{Param: name, Problem: fmt.Sprintf("string of %d bytes exceeds the limit of %d bytes", len(val), maxParamStringBytes)}
+		}
+		v.total += len(val)
+		if v.total > MaxParamBytes {
+			return &ParamError{Problem: fmt.Sprintf("parameters exceed the limit of %d bytes in total", MaxParamBytes)}
+		}
+		if !utf8.ValidString(val) {
+			return &ParamError{Param: name, Problem: "not valid UTF-8"}
+		}
+		if v.isPathParam(key) {
+			if err := checkPath(val, v.roots); err != nil {
+				return &ParamError{Param: name, Problem: err.Error()}
+			}
+		}
+
+	case float64:
+		if math.IsNaN(val) || math.IsInf(val, 0) {
+			return &ParamError{Param: name, Problem: "not a finite number"}
+		}
+
+	case []interface{}:
+		if len(val) > maxParamItems {
+			return &ParamError{Param: name, Problem: fmt.Sprintf("%d items exceed the limit of %d", len(val), maxParamItems)}
+		}
+		for i, item := range val {
+			if err := v.check(fmt.Sprintf("%s[%d]", name, i), key, item, depth+1); err != nil {
+				return err
+			}
+		}
+
+	case map[string]interface{}:
+		if len(val) > maxParamItems {
+			return &ParamError{Param: name, Problem: fmt.Sprintf("%d fields exceed the limit of %d", len(val), maxParamItems)}
+		}
+		for field, item := range val {
+			childName := field
+			if name != "" {
+				childName = name + "." + field
+			}
+			if err := v.check(childName, field, item, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isPathParam 字段是否为该工具的路径参数
+func (v *paramValidator) isPathParam(key string) bool {
+	return pathParams[key] && !nonPathParams[v.tool][key]
+}
+
+// checkPath 检查路径参数：长度、控制字符，以及路径（解析符号链接后）不能离开工作区根目录，
+// 安全策略 allow_paths 允许的路径除外
+func checkPath(path string, roots []string) error {
+	if len(path) > maxPathLength {
+		return fmt.Errorf("path of %d bytes exceeds the limit of %d bytes", len(path), maxPathLength)
+	}
+	for _, r := range path {
+		if r == 0 || (r < 0x20 && r != '\t') {
+			return fmt.Errorf("path contains control characters")
+		}
+	}
+	if path == "" {
+		return nil
+	}
+	absolute := filepath.IsAbs(path) || filepath.VolumeName(path) != ""
+	if !absolute {
+		cleaned := filepath.ToSlash(filepath.Clean(path))
+		if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+			return fmt.Errorf("path %q is outside the workspace; use a path relative to the workspace root", path)
+		}
+	}
+	if len(roots) == 0 {
+		return nil
+	}
+	full := path
+	if !absolute {
+		full = filepath.Join(roots[0], path)
+	}
+	if insideRoots(full, roots) || policyAllowsPath(full, roots[0]) {
+		return nil
+	}
+	if absolute {
+		return fmt.Errorf("path %q is outside the workspace; use a path inside the workspace or allow it with security_policy.allow_paths in the config file", path)
+	}
+	return fmt.Errorf("path %q leads outside the workspace through a symbolic link; allow the target with security_policy.allow_paths in the config file", path)
+}
+
+// insideRoots 路径及其解析符号链接后的路径是否都在某个工作区根目录下
+func insideRoots(path string, roots []string) bool {
+	absPath, err := absSecurityPath(path)
+	if err != nil {
+		return false
+	}
+	var dirs []string
+	for _, root := range roots {
+		if absRoot, err := absSecurityPath(root); err == nil {
+			dirs = append(dirs, absRoot, resolveSymlinks(absRoot))
+		}
+	}
+	for _, p := range []string{absPath, resolveSymlinks(absPath)} {
+		inside := false
+		for _, dir := range dirs {
+			if _, ok := relSecurityPath(dir, p); ok {
+				inside = true
+				break
+			}
+		}
+		if !inside {
+			return false
+		}
+	}
+	return true
+}
+
+// intParam 读取整数参数，兼容JSON解码得到的float64与数字字符串。
+// 非有限值、带小数部分或超出范围的数值视为未提供
+func intParam(params map[string]interface{}, key string) (int, bool) {
+	var f float64
+	switch v := params[key].(type) {
+	case float64:
+		f = v
+	case int:
+		f = float64(v)
+	case int64:
+		f = float64(v)
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return 0, false
+		}
+		f = float64(n)
+	default:
+		return 0, false
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) || f != math.Trunc(f) || f > maxIntParam || f < -maxIntParam {
+		return 0, false
+	}
+	return int(f), true
+}
\ No newline at end of file
@@ -0,0 +1,54 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestPathParamsStayInWorkspace 对每个已注册工具的每个路径参数传入 ../ 路径，都应在执行前被拒绝
+func TestPathParamsStayInWorkspace(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.RegisterAllTools(); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.RegisterOptionalTools(OptionalToolNames()); err != nil {
+		t.Fatal(err)
+	}
+	registry.SetWorkDirectory(t.TempDir())
+	manager := registry.GetManager()
+
+	for _, schema := range manager.ListTools() {
+		inputSchema, _ := schema.InputSchema.(map[string]interface{})
+		properties, _ := inputSchema["properties"].(map[string]interface{})
+		v := &paramValidator{tool: schema.Name}
+		for key, property := range properties {
+			prop, _ := property.(map[string]interface{})
+			typ, _ := prop["type"].(string)
+			description, _ := prop["description"].(string)
+			if !v.isPathParam(key) {
+				// 描述为工作区内路径的参数（glob 模式除外）必须登记为路径参数
+				if strings.Contains(description, "relative to the workspace root") && !strings.HasSuffix(key, "_pattern") {
+					t.Errorf("%s.%s looks like a path but is not in pathParams", schema.Name, key)
+				}
+				continue
+			}
+
+			var value interface{} = "../outside/x"
+			switch typ {
+			case "string":
+			case "array":
+				value = []interface{}{"../outside/x"}
+			default:
+				continue
+			}
+			result, err := manager.ExecuteTool(schema.Name, map[string]interface{}{key: value})
+			if err != nil {
+				t.Errorf("%s.%s: %v", schema.Name, key, err)
+				continue
+			}
+			if result.Success || !strings.Contains(result.Error, "outside the workspace") {
+				t.Errorf("%s.%s = ../outside/x: got success=%v error=%q, want an outside-the-workspace error", schema.Name, key, result.Success, result.Error)
+			}
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"openCursor/internal/database"
+)
+
+// queryDatabaseTimeout 单次数据库操作的超时
+const queryDatabaseTimeout = 60 * time.Second
+
+// databaseActions query_database工具支持的操作
+var databaseActions = []string{"list_tables", "describe", "query"}
+
+// databases 配置中的数据库：名称到 DSN
+var databases map[string]string
+
+// SetDatabases 设置query_database工具可以访问的数据库（名称到 DSN）
+func SetDatabases(dsns map[string]string) {
+	databases = dsns
+}
+
+// databaseNames 已配置的数据库名称
+func databaseNames() []string {
+	names := make([]string, 0, len(databases))
+	for name := range databases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// QueryDatabaseResult query_database工具的返回结果
+type QueryDatabaseResult struct {
+	Database string                `json:"database"`
+	Driver   string                `json:"driver"`
+	Action   string                `json:"action"`
+	Tables   []database.TableInfo  `json:"tables,omitempty"`
+	Schema   *database.TableSchema `json:"schema,omitempty"`
+	Result   *database.ResultSet   `json:"result,omitempty"`
+}
+
+// queryDatabaseFunction 数据库查询工具函数
+func queryDatabaseFunction(params map[string]interface{}) (interface{}, error) {
+	if len(databases) == 0 {
+		return nil, fmt.Errorf("no databases are configured (add them under databases in the config file)")
+	}
+	action, _ := params["action"].(string)
+	if !containsString(databaseActions, action) {
+		return nil, fmt.Errorf("action must be one of: %s", strings.Join(databaseActions, ", "))
+	}
+	name, _ := params["database"].(string)
+	if name == "" {
+		if len(databases) > 1 {
+			return nil, fmt.Errorf("database is required (configured: %s)", strings.Join(databaseNames(), ", "))
+		}
+		name = databaseNames()[0]
+	}
+	dsn, ok := databases[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown database %q (configured: %s)", name, strings.Join(databaseNames(), ", "))
+	}
+	workDir, _ := params["__work_dir__"].(string)
+
+	db, err := database.Open(dsn, workDir)
+	if err != nil {
+		return nil, fmt.Errorf("database %s: %w", name, err)
+	}
+	defer db.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), queryDatabaseTimeout)
+	defer cancel()
+
+	result := &QueryDatabaseResult{Database: name, Driver: db.Driver, Action: action}
+	switch action {
+	case "list_tables":
+		result.Tables, err = db.ListTables(ctx)
+	case "describe":
+		table, _ := params["table"].(string)
+		if table == "" {
+			return nil, fmt.Errorf("table is required for describe")
+		}
+		result.Schema, err = db.Describe(ctx, table)
+	case "query":
+		query, _ := params["sql"].(string)
+		limit, _ := intParam(params, "limit")
+		result.Result, err = db.Query(ctx, query, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// NewQueryDatabaseTool 创建query_database工具
+func NewQueryDatabaseTool() Tool {
+	schema := ToolSchema{
+		Name:        "query_database",
+		Description: "Inspect the project's databases configured by the user (Postgres, MySQL or SQLite), read-only: list the tables, describe a table's columns, indexes and foreign keys, or run a single SELECT (also WITH, EXPLAIN, SHOW) with a row limit. Statements run in a read-only transaction that is always rolled back, so nothing can be modified. Use it to write queries and migrations against the real schema instead of guessing it.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"action": map[string]interface{}{
+					"type":        "string",
+					"description": "list_tables, describe or query",
+					"enum":        databaseActions,
+				},
+				"database": map[string]interface{}{
+					"type":        "string",
+					"description": "Name of the configured database; may be omitted when only one is configured",
+				},
+				"table": map[string]interface{}{
+					"type":        "string",
+					"description": "For describe: the table name (schema.table for Postgres schemas other than public)",
+				},
+				"sql": map[string]interface{}{
+					"type":        "string",
+					"description": "For query: a single read-only statement",
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": "For query: maximum number of rows to return (default 100, max 1000)",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+			"required": []string{"action"},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: queryDatabaseFunction,
+	}
+}
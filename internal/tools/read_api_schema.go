@@ -0,0 +1,148 @@
+package tools
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"openCursor/internal/apischema"
+)
+
+// maxAPISchemaSummary 摘要的最大长度，超出时截断并提示使用 filter
+const maxAPISchemaSummary = 40000
+
+// APISchemaFile 一个schema文件的摘要
+type APISchemaFile struct {
+	FilePath string `json:"file_path"`
+	apischema.Summary
+}
+
+// ReadAPISchemaResult read_api_schema工具的返回结果
+type ReadAPISchemaResult struct {
+	Path      string          `json:"path"`
+	Files     []APISchemaFile `json:"files"`
+	Truncated bool            `json:"truncated,omitempty"`
+	Errors    []string        `json:"errors,omitempty"` // 无法解析的文件
+}
+
+// readAPISchemaFunction API schema摘要工具函数
+func readAPISchemaFunction(params map[string]interface{}) (interface{}, error) {
+	target, _ := params["path"].(string)
+	if target == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+	filter, _ := params["filter"].(string)
+	workDir, _ := params["__work_dir__"].(string)
+	root := target
+	if !filepath.IsAbs(root) {
+		root = filepath.Join(workDir, root)
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("path not found: %s", target)
+	}
+
+	// 目录中查找所有 .proto 文件与 OpenAPI 文档
+	files := []string{root}
+	if info.IsDir() {
+		files = nil
+		var mu sync.Mutex
+		skipDir := func(p string, d fs.DirEntry) bool {
+			return strings.HasPrefix(d.Name(), ".") || replaceSkipDirs[d.Name()]
+		}
+		parallelWalk([]string{root}, skipDir, func(p string, d fs.DirEntry) {
+			switch strings.ToLower(filepath.Ext(p)) {
+			case ".proto", ".yaml", ".yml", ".json":
+				mu.Lock()
+				files = append(files, p)
+				mu.Unlock()
+			}
+		})
+		sort.Strings(files)
+	}
+
+	result := &ReadAPISchemaResult{Path: target, Files: []APISchemaFile{}}
+	size := 0
+	for _, file := range files {
+		rel := file
+		if r, err := filepath.Rel(workDir, file); err == nil && !strings.HasPrefix(r, "..") {
+			rel = filepath.ToSlash(r)
+		}
+		content, err := readWorkspaceFile(file)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", rel, err))
+			continue
+		}
+		format, err := apischema.DetectFormat(file, content)
+		if err != nil {
+			// 目录中其他的 YAML/JSON 文件不是 schema
+			if !info.IsDir() {
+				return nil, err
+			}
+			continue
+		}
+		summary, err := apischema.Summarize(format, content, filter)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", rel, err))
+			continue
+		}
+		if filter != "" && summary.Endpoints == 0 && summary.Types == 0 {
+			continue
+		}
+		if size+len(summary.Text) > maxAPISchemaSummary {
+			result.Truncated = true
+			summary.Text = summary.Text[:max(maxAPISchemaSummary-size, 0)]
+			if cut := strings.LastIndexByte(summary.Text, '\n'); cut >= 0 {
+				summary.Text = summary.Text[:cut+1]
+			}
+			summary.Text += "... (truncated; use filter to narrow the summary)\n"
+		}
+		size += len(summary.Text)
+		result.Files = append(result.Files, APISchemaFile{FilePath: rel, Summary: *summary})
+		if result.Truncated {
+			break
+		}
+	}
+	if len(result.Files) == 0 && len(result.Errors) == 0 {
+		if filter != "" {
+			return nil, fmt.Errorf("nothing in %s matches %q", target, filter)
+		}
+		return nil, fmt.Errorf("no OpenAPI/Swagger documents or .proto files found in %s", target)
+	}
+	return result, nil
+}
+
+// NewReadAPISchemaTool 创建read_api_schema工具
+func NewReadAPISchemaTool() Tool {
+	schema := ToolSchema{
+		Name:        "read_api_schema",
+		Description: "Read a condensed summary of an API definition instead of the whole file: for OpenAPI/Swagger documents (YAML or JSON) one entry per endpoint with its parameters, request body and responses, plus the component schemas with their properties; for protobuf .proto files the services' RPC methods and the messages and enums with their fields. Required parameters and properties are marked with *. A directory summarizes every schema file in it. Use this before generating clients or handlers from large specs, and read_file only for the details the summary leaves out.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "The OpenAPI document, .proto file or directory of them, relative to the workspace root.",
+				},
+				"filter": map[string]interface{}{
+					"type":        "string",
+					"description": "Only list endpoints, RPC methods and types whose path, operationId or name contains this text (case-insensitive), e.g. /pets or User",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+			"required": []string{"path"},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: readAPISchemaFunction,
+	}
+}
@@ -1,7 +1,6 @@
 package tools
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -21,6 +20,7 @@ type ReadFileParams struct {
 type ReadFileResult struct {
 	Content           string `json:"content"`
 	TotalLines        int    `json:"total_lines"`
+	TotalLinesApprox  bool   `json:"total_lines_approximate,omitempty"` // 按行范围读取时没有读到文件末尾，总行数为估算值
 	StartLine         int    `json:"start_line,omitempty"`
 	EndLine           int    `json:"end_line,omitempty"`
 	FilePath          string `json:"file_path"`
@@ -28,6 +28,26 @@ type ReadFileResult struct {
 	ReadEntireFile    bool   `json:"read_entire_file"`
 }
 
+// 读取文件的行数窗口
+const (
+	maxReadLines = 250 // 单次最多读取的行数
+	minReadLines = 200 // 文件足够长时单次最少读取的行数
+)
+
+// DefaultMaxReadFileSize 默认可整个读取的文件大小上限
+const DefaultMaxReadFileSize int64 = 2 << 20
+
+// maxReadFileSize 整个读取文件的大小上限，超过时只能按行范围读取
+var maxReadFileSize = DefaultMaxReadFileSize
+
+// SetMaxReadFileSize 设置整个读取文件的大小上限，不大于0时使用默认值
+func SetMaxReadFileSize(size int64) {
+	if size <= 0 {
+		size = DefaultMaxReadFileSize
+	}
+	maxReadFileSize = size
+}
+
 // readFileFunction 读取文件工具函数
 func readFileFunction(params map[string]interface{}) (interface{}, error) {
 	// 解析参数
@@ -38,31 +58,9 @@ func readFileFunction(params map[string]interface{}) (interface{}, error) {
 
 	shouldReadEntireFile, _ := params["should_read_entire_file"].(bool)
 	
-	// 处理startLine参数，支持多种数值类型
-	var startLine int
-	if val, ok := params["start_line_one_indexed"]; ok {
-		switch v := val.(type) {
-		case float64:
-			startLine = int(v)
-		case int:
-			startLine = v
-		case int64:
-			startLine = int(v)
-		}
-	}
-	
-	// 处理endLine参数，支持多种数值类型
-	var endLine int
-	if val, ok := params["end_line_one_indexed_inclusive"]; ok {
-		switch v := val.(type) {
-		case float64:
-			endLine = int(v)
-		case int:
-			endLine = v
-		case int64:
-			endLine = int(v)
-		}
-	}
+	// 行号参数兼容多种数值类型
+	startLine, _ := intParam(params, "start_line_one_indexed")
+	endLine, _ := intParam(params, "end_line_one_indexed_inclusive")
 	
 	workDir, _ := params["__work_dir__"].(string)
 
@@ -78,36 +76,76 @@ func readFileFunction(params map[string]interface{}) (interface{}, error) {
 		}
 	}
 
-	// 检查文件是否存在
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+	// 检查文件是否存在，过大的文件不能整个读取
+	info, err := statWorkspaceFile(filePath)
+	if os.IsNotExist(err) {
 		return nil, fmt.Errorf("file not found: %s", filePath)
 	}
+	if err == nil && shouldReadEntireFile && info.Size() > maxReadFileSize {
+		return nil, fmt.Errorf("file is too large to read entirely: %s is %s (limit %s); read it in line ranges instead",
+			filePath, formatFileSize(info.Size()), formatFileSize(maxReadFileSize))
+	}
+
+	// 验证行号范围
+	if !shouldReadEntireFile {
+		if startLine < 1 {
+			startLine = 1
+		}
+		if endLine < startLine {
+			return nil, fmt.Errorf("end_line (%d) must be >= start_line (%d)", endLine, startLine)
+		}
+	}
 
 	// 打开文件
-	file, err := os.Open(filePath)
+	file, err := openWorkspaceFile(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file: %w", err)
 	}
 	defer file.Close()
 
-	// 读取所有行
+	// 逐行读取，只保留请求范围内的行（超出单次上限时不再保留，之后会报错）。按行范围读取时
+	// 读到请求范围与最少行数的建议所需的行之后再多读一行就停止，不再扫描到文件末尾
+	stopAfter := 0
+	if !shouldReadEntireFile {
+		stopAfter = endLine
+		if maxEnd := startLine + maxReadLines; stopAfter > maxEnd {
+			stopAfter = maxEnd
+		}
+		if minEnd := startLine + minReadLines - 1; stopAfter < minEnd {
+			stopAfter = minEnd
+		}
+		stopAfter++
+	}
 	var lines []string
-	scanner := bufio.NewScanner(file)
+	totalLines := 0
+	more := false // 在 stopAfter 行之后停止，文件还有更多行
+	var readBytes int64
+	scanner := newLineScanner(file)
 	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+		if stopAfter > 0 && totalLines == stopAfter {
+			more = true
+			break
+		}
+		totalLines++
+		readBytes += int64(len(scanner.Bytes())) + 1
+		if shouldReadEntireFile || (totalLines >= startLine && totalLines <= endLine && len(lines) <= maxReadLines) {
+			lines = append(lines, scanner.Text())
+		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("failed to read file: %w", err)
+		return nil, scanError(err, filePath, totalLines)
 	}
 
-	totalLines := len(lines)
-
 	result := &ReadFileResult{
 		FilePath:       filePath,
 		TotalLines:     totalLines,
 		ReadEntireFile: shouldReadEntireFile,
 	}
+	if more {
+		result.TotalLines = estimateTotalLines(totalLines, readBytes, info)
+		result.TotalLinesApprox = true
+	}
 
 	if shouldReadEntireFile {
 		// 读取整个文件
@@ -119,42 +157,31 @@ func readFileFunction(params map[string]interface{}) (interface{}, error) {
 		startLineInt := startLine
 		endLineInt := endLine
 
-		// 验证行号范围
-		if startLineInt < 1 {
-			startLineInt = 1
-		}
-		if endLineInt < startLineInt {
-			return nil, fmt.Errorf("end_line (%d) must be >= start_line (%d)", endLineInt, startLineInt)
-		}
 		if startLineInt > totalLines {
 			return nil, fmt.Errorf("start_line (%d) exceeds total lines (%d)", startLineInt, totalLines)
 		}
 
-		// 调整结束行号
-		if endLineInt > totalLines {
+		// 调整结束行号（没有读到文件末尾时，请求的结束行之后还有内容）
+		if endLineInt > totalLines && !more {
 			endLineInt = totalLines
 		}
 
 		// 验证行数限制（最多250行，最少200行）
 		lineCount := endLineInt - startLineInt + 1
-		if lineCount > 250 {
-			return nil, fmt.Errorf("cannot read more than 250 lines at once (requested: %d)", lineCount)
+		if lineCount > maxReadLines {
+			return nil, fmt.Errorf("cannot read more than %d lines at once (requested: %d)", maxReadLines, lineCount)
 		}
-		if lineCount < 200 && totalLines >= 200 && endLineInt < totalLines {
+		if lineCount < minReadLines && (more || (totalLines >= minReadLines && endLineInt < totalLines)) {
 			// 如果请求的行数少于200行且文件总行数>=200，建议读取更多行
-			suggestedEnd := startLineInt + 199
-			if suggestedEnd > totalLines {
+			suggestedEnd := startLineInt + minReadLines - 1
+			if suggestedEnd > totalLines && !more {
 				suggestedEnd = totalLines
 			}
-			return nil, fmt.Errorf("minimum 200 lines required when file has >= 200 lines. Consider reading lines %d-%d", startLineInt, suggestedEnd)
+			return nil, fmt.Errorf("minimum %d lines required when file has >= %d lines. Consider reading lines %d-%d", minReadLines, minReadLines, startLineInt, suggestedEnd)
 		}
 
-		// 提取指定行范围 (转换为0-based索引)
-		startIdx := startLineInt - 1
-		endIdx := endLineInt - 1
-
-		selectedLines := lines[startIdx : endIdx+1]
-		result.Content = strings.Join(selectedLines, "\n")
+		// 读取时只保留了指定行范围
+		result.Content = strings.Join(lines, "\n")
 		result.StartLine = startLineInt
 		result.EndLine = endLineInt
 
@@ -163,7 +190,9 @@ func readFileFunction(params map[string]interface{}) (interface{}, error) {
 		if startLineInt > 1 {
 			notShownParts = append(notShownParts, fmt.Sprintf("Lines 1-%d not shown", startLineInt-1))
 		}
-		if endLineInt < totalLines {
+		if more {
+			notShownParts = append(notShownParts, fmt.Sprintf("Lines %d to the end not shown (about %d lines in total)", endLineInt+1, result.TotalLines))
+		} else if endLineInt < totalLines {
 			notShownParts = append(notShownParts, fmt.Sprintf("Lines %d-%d not shown", endLineInt+1, totalLines))
 		}
 		if len(notShownParts) > 0 {
@@ -174,6 +203,30 @@ func readFileFunction(params map[string]interface{}) (interface{}, error) {
 	return result, nil
 }
 
+// estimateTotalLines 按已读行的平均长度与文件大小估算总行数，至少比已读的行数多一行
+func estimateTotalLines(readLines int, readBytes int64, info os.FileInfo) int {
+	estimate := readLines + 1
+	if info != nil && readBytes > 0 {
+		if byAverage := int(info.Size() * int64(readLines) / readBytes); byAverage > estimate {
+			estimate = byAverage
+		}
+	}
+	return estimate
+}
+
+// formatFileSize 以 KB/MB/GB 显示文件大小
+func formatFileSize(size int64) string {
+	switch {
+	case size >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(size)/(1<<30))
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", size)
+}
+
 // NewReadFileTool 创建read_file工具
 func NewReadFileTool() Tool {
 	schema := ToolSchema{
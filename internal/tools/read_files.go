@@ -0,0 +1,257 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 批量读取的限制
+const (
+	maxReadFilesEntries     = 20
+	defaultReadFilesBytes   = 100 * 1024
+	maxReadFilesBytes       = 256 * 1024
+)
+
+// ReadFilesParams read_files工具的参数
+type ReadFilesParams struct {
+	Files         []ReadFilesEntry `json:"files"`
+	MaxTotalBytes int              `json:"max_total_bytes,omitempty"`
+	Explanation   string           `json:"explanation,omitempty"`
+}
+
+// ReadFilesEntry 要读取的一个文件（或文件中的一段）
+type ReadFilesEntry struct {
+	TargetFile string `json:"target_file"`
+	StartLine  int    `json:"start_line_one_indexed,omitempty"`
+	EndLine    int    `json:"end_line_one_indexed_inclusive,omitempty"`
+}
+
+// ReadFilesItem 单个文件的读取结果
+type ReadFilesItem struct {
+	FilePath      string `json:"file_path"`
+	Content       string `json:"content,omitempty"`
+	StartLine     int    `json:"start_line,omitempty"`
+	EndLine       int    `json:"end_line,omitempty"`
+	TotalLines    int    `json:"total_lines,omitempty"`
+	LinesNotShown string `json:"lines_not_shown,omitempty"`
+	Truncated     bool   `json:"truncated,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// ReadFilesResult read_files工具的返回结果
+type ReadFilesResult struct {
+	Files      []ReadFilesItem `json:"files"`
+	TotalBytes int             `json:"total_bytes"`
+	Truncated  bool            `json:"truncated,omitempty"`
+	Message    string          `json:"message"`
+}
+
+// parseReadFilesEntries 解析files参数，元素可以是路径字符串或带行范围的对象
+func parseReadFilesEntries(value interface{}) ([]ReadFilesEntry, error) {
+	list, ok := value.([]interface{})
+	if !ok || len(list) == 0 {
+		return nil, fmt.Errorf("files is required and must be a non-empty array")
+	}
+	if len(list) > maxReadFilesEntries {
+		return nil, fmt.Errorf("cannot read more than %d files at once (requested: %d)", maxReadFilesEntries, len(list))
+	}
+
+	entries := make([]ReadFilesEntry, 0, len(list))
+	for i, item := range list {
+		switch v := item.(type) {
+		case string:
+			entries = append(entries, ReadFilesEntry{TargetFile: v})
+		case map[string]interface{}:
+			entry := ReadFilesEntry{}
+			entry.TargetFile, _ = v["target_file"].(string)
+			entry.StartLine, _ = intParam(v, "start_line_one_indexed")
+			entry.EndLine, _ = intParam(v, "end_line_one_indexed_inclusive")
+			entries = append(entries, entry)
+		default:
+			return nil, fmt.Errorf("files[%d] must be a path or an object with target_file", i)
+		}
+		if entries[i].TargetFile == "" {
+			return nil, fmt.Errorf("files[%d] is missing target_file", i)
+		}
+	}
+	return entries, nil
+}
+
+// readFileRange 读取文件的指定行范围，budget为剩余可用字节数
+func readFileRange(path string, startLine, endLine, budget int) ReadFilesItem {
+	item := ReadFilesItem{FilePath: path}
+
+	file, err := openWorkspaceFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			item.Error = "file not found"
+		} else {
+			item.Error = err.Error()
+		}
+		return item
+	}
+	defer file.Close()
+
+	if startLine < 1 {
+		startLine = 1
+	}
+	item.EndLine = startLine - 1
+
+	var content strings.Builder
+	scanner := newLineScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		if lineNumber < startLine || (endLine > 0 && lineNumber > endLine) || item.Truncated {
+			continue
+		}
+		line := scanner.Text()
+		// 超出总大小限制时在行边界截断
+		if content.Len()+len(line)+1 > budget {
+			item.Truncated = true
+			continue
+		}
+		if content.Len() > 0 || lineNumber > startLine {
+			content.WriteString("\n")
+		}
+		content.WriteString(line)
+		item.EndLine = lineNumber
+	}
+	if err := scanner.Err(); err != nil {
+		item.Error = scanError(err, path, lineNumber).Error()
+		return item
+	}
+
+	item.TotalLines = lineNumber
+	if startLine > lineNumber && lineNumber > 0 {
+		item.Error = fmt.Sprintf("start_line (%d) exceeds total lines (%d)", startLine, lineNumber)
+		return item
+	}
+	item.Content = content.String()
+	if item.EndLine >= startLine {
+		item.StartLine = startLine
+	} else {
+		item.EndLine = 0
+	}
+
+	var notShown []string
+	if startLine > 1 {
+		notShown = append(notShown, fmt.Sprintf("Lines 1-%d not shown", startLine-1))
+	}
+	if shownEnd := max(item.EndLine, startLine-1); shownEnd < lineNumber {
+		notShown = append(notShown, fmt.Sprintf("Lines %d-%d not shown", shownEnd+1, lineNumber))
+	}
+	item.LinesNotShown = strings.Join(notShown, "; ")
+	return item
+}
+
+// readFilesFunction 批量读取文件工具函数
+func readFilesFunction(params map[string]interface{}) (interface{}, error) {
+	// 解析参数
+	entries, err := parseReadFilesEntries(params["files"])
+	if err != nil {
+		return nil, err
+	}
+
+	maxBytes := defaultReadFilesBytes
+	if val, ok := intParam(params, "max_total_bytes"); ok && val > 0 {
+		maxBytes = min(val, maxReadFilesBytes)
+	}
+
+	workDir, _ := params["__work_dir__"].(string)
+
+	result := &ReadFilesResult{
+		Files: make([]ReadFilesItem, 0, len(entries)),
+	}
+
+	remaining := maxBytes
+	for _, entry := range entries {
+		path := entry.TargetFile
+		if !filepath.IsAbs(path) && workDir != "" {
+			path = filepath.Join(workDir, entry.TargetFile)
+		}
+
+		if entry.EndLine > 0 && entry.EndLine < entry.StartLine {
+			result.Files = append(result.Files, ReadFilesItem{
+				FilePath: path,
+				Error:    fmt.Sprintf("end_line (%d) must be >= start_line (%d)", entry.EndLine, entry.StartLine),
+			})
+			continue
+		}
+		if remaining <= 0 {
+			result.Files = append(result.Files, ReadFilesItem{
+				FilePath:  path,
+				Truncated: true,
+				Error:     "skipped: combined size limit reached, read this file in another call",
+			})
+			result.Truncated = true
+			continue
+		}
+
+		item := readFileRange(path, entry.StartLine, entry.EndLine, remaining)
+		remaining -= len(item.Content)
+		result.TotalBytes += len(item.Content)
+		if item.Truncated {
+			result.Truncated = true
+		}
+		result.Files = append(result.Files, item)
+	}
+
+	result.Message = fmt.Sprintf("Read %d files (%d bytes)", len(result.Files), result.TotalBytes)
+	if result.Truncated {
+		result.Message += fmt.Sprintf("; output was truncated at the combined limit of %d bytes, request the remaining lines separately", maxBytes)
+	}
+
+	return result, nil
+}
+
+// NewReadFilesTool 创建read_files工具
+func NewReadFilesTool() Tool {
+	schema := ToolSchema{
+		Name:        "read_files",
+		Description: "Read several files, or several line ranges, in a single call. Prefer this over repeated read_file calls when exploring: e.g. read a handler, its tests and its interface together. Each entry is either a path (whole file) or an object with target_file and an optional one-indexed line range. The combined output is capped (100KB by default); entries beyond the cap are cut at a line boundary or skipped, and the result says which lines were not shown.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"files": map[string]interface{}{
+					"type":        "array",
+					"description": fmt.Sprintf("Up to %d files to read, in order.", maxReadFilesEntries),
+					"items": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"target_file": map[string]interface{}{
+								"type":        "string",
+								"description": "The path of the file, relative to the workspace root or absolute.",
+							},
+							"start_line_one_indexed": map[string]interface{}{
+								"type":        "integer",
+								"description": "Optional first line to read (inclusive). Defaults to 1.",
+							},
+							"end_line_one_indexed_inclusive": map[string]interface{}{
+								"type":        "integer",
+								"description": "Optional last line to read (inclusive). Defaults to the end of the file.",
+							},
+						},
+						"required": []string{"target_file"},
+					},
+				},
+				"max_total_bytes": map[string]interface{}{
+					"type":        "integer",
+					"description": fmt.Sprintf("Optional cap on the combined size of all returned content. Defaults to %d, maximum %d.", defaultReadFilesBytes, maxReadFilesBytes),
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+			"required": []string{"files"},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: readFilesFunction,
+	}
+}
\ No newline at end of file
@@ -2,11 +2,13 @@ package tools
 
 import (
 	"fmt"
+	"sort"
 )
 
 // Registry 工具注册器
 type Registry struct {
 	manager ToolManager
+	subtask bool // 子代理的注册器，不提供 spawn_task 以免递归
 }
 
 // NewRegistry 创建新的工具注册器
@@ -28,6 +30,27 @@ func (r *Registry) SetWorkDirectory(dir string) {
 	}
 }
 
+// SetEnvironment 设置会话级环境变量覆盖
+func (r *Registry) SetEnvironment(env map[string]string) {
+	if tm, ok := r.manager.(*DefaultToolManager); ok {
+		tm.SetEnvironment(env)
+	}
+}
+
+// SetWorkspaces 设置主工作目录外的工作区根目录
+func (r *Registry) SetWorkspaces(workspaces []Workspace) {
+	if tm, ok := r.manager.(*DefaultToolManager); ok {
+		tm.SetWorkspaces(workspaces)
+	}
+}
+
+// SetShadowBranch 设置自动提交修改的影子分支
+func (r *Registry) SetShadowBranch(shadow *ShadowBranch) {
+	if tm, ok := r.manager.(*DefaultToolManager); ok {
+		tm.SetShadowBranch(shadow)
+	}
+}
+
 // RegisterAllTools 注册所有工具
 func (r *Registry) RegisterAllTools() error {
 	// 注册 read_file 工具
@@ -35,6 +58,11 @@ func (r *Registry) RegisterAllTools() error {
 		return fmt.Errorf("failed to register read_file tool: %w", err)
 	}
 
+	// 注册 read_files 工具
+	if err := r.manager.RegisterTool("read_files", NewReadFilesTool()); err != nil {
+		return fmt.Errorf("failed to register read_files tool: %w", err)
+	}
+
 	// 注册 run_terminal_cmd 工具
 	if err := r.manager.RegisterTool("run_terminal_cmd", NewRunTerminalCmdTool()); err != nil {
 		return fmt.Errorf("failed to register run_terminal_cmd tool: %w", err)
@@ -55,11 +83,21 @@ func (r *Registry) RegisterAllTools() error {
 		return fmt.Errorf("failed to register search_replace tool: %w", err)
 	}
 
+	// 注册 replace_all 工具
+	if err := r.manager.RegisterTool("replace_all", NewReplaceAllTool()); err != nil {
+		return fmt.Errorf("failed to register replace_all tool: %w", err)
+	}
+
 	// 注册 file_search 工具
 	if err := r.manager.RegisterTool("file_search", NewFileSearchTool()); err != nil {
 		return fmt.Errorf("failed to register file_search tool: %w", err)
 	}
 
+	// 注册 glob_search 工具
+	if err := r.manager.RegisterTool("glob_search", NewGlobSearchTool()); err != nil {
+		return fmt.Errorf("failed to register glob_search tool: %w", err)
+	}
+
 	// 注册 delete_file 工具
 	if err := r.manager.RegisterTool("delete_file", NewDeleteFileTool()); err != nil {
 		return fmt.Errorf("failed to register delete_file tool: %w", err)
@@ -70,6 +108,277 @@ func (r *Registry) RegisterAllTools() error {
 		return fmt.Errorf("failed to register write_file tool: %w", err)
 	}
 
+	// 注册 insert_at_line 工具
+	if err := r.manager.RegisterTool("insert_at_line", NewInsertAtLineTool()); err != nil {
+		return fmt.Errorf("failed to register insert_at_line tool: %w", err)
+	}
+
+	// 注册 append_to_file 工具
+	if err := r.manager.RegisterTool("append_to_file", NewAppendToFileTool()); err != nil {
+		return fmt.Errorf("failed to register append_to_file tool: %w", err)
+	}
+
+	// 注册 edit_structured_file 工具
+	if err := r.manager.RegisterTool("edit_structured_file", NewEditStructuredFileTool()); err != nil {
+		return fmt.Errorf("failed to register edit_structured_file tool: %w", err)
+	}
+
+	// 注册 repo_map 工具
+	if err := r.manager.RegisterTool("repo_map", NewRepoMapTool()); err != nil {
+		return fmt.Errorf("failed to register repo_map tool: %w", err)
+	}
+
+	// 仅在配置了嵌入服务时注册 codebase_search 工具
+	if embedder != nil {
+		if err := r.manager.RegisterTool("codebase_search", NewCodebaseSearchTool()); err != nil {
+			return fmt.Errorf("failed to register codebase_search tool: %w", err)
+		}
+	}
+
+	// 注册 find_symbol 工具
+	if err := r.manager.RegisterTool("find_symbol", NewFindSymbolTool()); err != nil {
+		return fmt.Errorf("failed to register find_symbol tool: %w", err)
+	}
+
+	// 注册 api_schema_diff 工具
+	if err := r.manager.RegisterTool("api_schema_diff", NewAPISchemaDiffTool()); err != nil {
+		return fmt.Errorf("failed to register api_schema_diff tool: %w", err)
+	}
+
+	// 注册 read_api_schema 工具
+	if err := r.manager.RegisterTool("read_api_schema", NewReadAPISchemaTool()); err != nil {
+		return fmt.Errorf("failed to register read_api_schema tool: %w", err)
+	}
+
+	// 注册 list_code_usages 工具
+	if err := r.manager.RegisterTool("list_code_usages", NewListCodeUsagesTool()); err != nil {
+		return fmt.Errorf("failed to register list_code_usages tool: %w", err)
+	}
+
+	// 注册 list_project_tasks 工具
+	if err := r.manager.RegisterTool("list_project_tasks", NewListProjectTasksTool()); err != nil {
+		return fmt.Errorf("failed to register list_project_tasks tool: %w", err)
+	}
+
+	// 注册 git_log 工具
+	if err := r.manager.RegisterTool("git_log", NewGitLogTool()); err != nil {
+		return fmt.Errorf("failed to register git_log tool: %w", err)
+	}
+
+	// 注册 summarize_diff 工具
+	if err := r.manager.RegisterTool("summarize_diff", NewSummarizeDiffTool()); err != nil {
+		return fmt.Errorf("failed to register summarize_diff tool: %w", err)
+	}
+
+	// 注册 git_blame 工具
+	if err := r.manager.RegisterTool("git_blame", NewGitBlameTool()); err != nil {
+		return fmt.Errorf("failed to register git_blame tool: %w", err)
+	}
+
+	// 注册 git 工具
+	if err := r.manager.RegisterTool("git", NewGitTool()); err != nil {
+		return fmt.Errorf("failed to register git tool: %w", err)
+	}
+
+	// 注册 run_tests 工具
+	if err := r.manager.RegisterTool("run_tests", NewRunTestsTool()); err != nil {
+		return fmt.Errorf("failed to register run_tests tool: %w", err)
+	}
+
+	// 注册 coverage_report 工具
+	if err := r.manager.RegisterTool("coverage_report", NewCoverageReportTool()); err != nil {
+		return fmt.Errorf("failed to register coverage_report tool: %w", err)
+	}
+
+	// 注册 run_benchmarks 工具
+	if err := r.manager.RegisterTool("run_benchmarks", NewRunBenchmarksTool()); err != nil {
+		return fmt.Errorf("failed to register run_benchmarks tool: %w", err)
+	}
+
+	// 注册 get_build_errors 工具
+	if err := r.manager.RegisterTool("get_build_errors", NewGetBuildErrorsTool()); err != nil {
+		return fmt.Errorf("failed to register get_build_errors tool: %w", err)
+	}
+
+	// 注册 audit_dependencies 工具
+	if err := r.manager.RegisterTool("audit_dependencies", NewAuditDependenciesTool()); err != nil {
+		return fmt.Errorf("failed to register audit_dependencies tool: %w", err)
+	}
+
+	// 注册 code_metrics 工具
+	if err := r.manager.RegisterTool("code_metrics", NewCodeMetricsTool()); err != nil {
+		return fmt.Errorf("failed to register code_metrics tool: %w", err)
+	}
+
+	// 注册压缩包工具
+	if err := r.manager.RegisterTool("list_archive", NewListArchiveTool()); err != nil {
+		return fmt.Errorf("failed to register list_archive tool: %w", err)
+	}
+	if err := r.manager.RegisterTool("extract_archive", NewExtractArchiveTool()); err != nil {
+		return fmt.Errorf("failed to register extract_archive tool: %w", err)
+	}
+
+	// 注册 env_file 工具
+	if err := r.manager.RegisterTool("env_file", NewEnvFileTool()); err != nil {
+		return fmt.Errorf("failed to register env_file tool: %w", err)
+	}
+
+	// 注册笔记本工具
+	if err := r.manager.RegisterTool("read_notebook", NewReadNotebookTool()); err != nil {
+		return fmt.Errorf("failed to register read_notebook tool: %w", err)
+	}
+	if err := r.manager.RegisterTool("edit_notebook", NewEditNotebookTool()); err != nil {
+		return fmt.Errorf("failed to register edit_notebook tool: %w", err)
+	}
+
+	// 注册 diff_files 工具
+	if err := r.manager.RegisterTool("diff_files", NewDiffFilesTool()); err != nil {
+		return fmt.Errorf("failed to register diff_files tool: %w", err)
+	}
+
+	// 仅在设置了子代理运行器时注册 spawn_task 工具
+	if getSubtaskRunner() != nil && !r.subtask {
+		if err := r.manager.RegisterTool("spawn_task", NewSpawnTaskTool()); err != nil {
+			return fmt.Errorf("failed to register spawn_task tool: %w", err)
+		}
+	}
+
+	// 仅在安装了语言服务器时注册LSP工具
+	if len(languageServers.Available()) > 0 {
+		// 注册 go_to_definition 工具
+		if err := r.manager.RegisterTool("go_to_definition", NewGoToDefinitionTool()); err != nil {
+			return fmt.Errorf("failed to register go_to_definition tool: %w", err)
+		}
+
+		// 注册 hover_symbol 工具
+		if err := r.manager.RegisterTool("hover_symbol", NewHoverSymbolTool()); err != nil {
+			return fmt.Errorf("failed to register hover_symbol tool: %w", err)
+		}
+
+		// 注册 get_diagnostics 工具
+		if err := r.manager.RegisterTool("get_diagnostics", NewGetDiagnosticsTool()); err != nil {
+			return fmt.Errorf("failed to register get_diagnostics tool: %w", err)
+		}
+
+		// 注册 rename_symbol 工具
+		if err := r.manager.RegisterTool("rename_symbol", NewRenameSymbolTool()); err != nil {
+			return fmt.Errorf("failed to register rename_symbol tool: %w", err)
+		}
+		} else {
+		// 没有语言服务器时，go_to_definition 使用符号索引
+		if err := r.manager.RegisterTool("go_to_definition", NewSymbolDefinitionTool()); err != nil {
+			return fmt.Errorf("failed to register go_to_definition tool: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// optionalTools 需要显式启用的可选工具
+var optionalTools = map[string]func() Tool{
+	"browser":        NewBrowserTool,
+	"docker":         NewDockerTool,
+	"open_in_editor": NewOpenInEditorTool,
+	"query_database": NewQueryDatabaseTool,
+	"view_image":     NewViewImageTool,
+}
+
+// OptionalToolNames 列出所有可选工具名称
+func OptionalToolNames() []string {
+	names := make([]string, 0, len(optionalTools))
+	for name := range optionalTools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RegisterOptionalTools 注册显式启用的可选工具
+func (r *Registry) RegisterOptionalTools(names []string) error {
+	for _, name := range names {
+		newTool, ok := optionalTools[name]
+		if !ok {
+			return fmt.Errorf("unknown optional tool '%s' (available: %v)", name, OptionalToolNames())
+		}
+		if _, exists := r.manager.GetTool(name); exists {
+			continue
+		}
+		if err := r.manager.RegisterTool(name, newTool()); err != nil {
+			return fmt.Errorf("failed to register %s tool: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// readOnlyTools 不修改工作区、不执行命令的只读工具
+var readOnlyTools = []string{
+	"read_file", "read_files", "list_dir", "grep_search", "file_search", "glob_search",
+	"repo_map", "codebase_search", "find_symbol", "api_schema_diff", "read_api_schema", "list_code_usages", "list_project_tasks", "git_log", "summarize_diff", "git_blame",
+	"list_archive", "read_notebook", "diff_files", "view_image", "code_metrics", "query_database",
+	"go_to_definition", "hover_symbol", "get_diagnostics",
+}
+
+// editTools 单文件编辑时提供的工具：读取与修改文件，不能执行命令
+var editTools = []string{
+	"read_file", "search_replace", "write_file", "insert_at_line", "append_to_file", "edit_structured_file",
+	"read_notebook", "edit_notebook",
+}
+
+// RegisterReadOnlyTools 只注册只读工具，用于只需分析代码库的命令
+func (r *Registry) RegisterReadOnlyTools() error {
+	return r.registerSubset(readOnlyTools)
+}
+
+// RegisterEditTools 只注册读取与修改文件的工具，用于限定在单个文件上的编辑
+func (r *Registry) RegisterEditTools() error {
+	return r.registerSubset(editTools)
+}
+
+// RegisterToolset 注册工具集：read_only 只读工具，edit 只读工具与文件编辑工具，all 全部工具
+func (r *Registry) RegisterToolset(toolset string) error {
+	switch toolset {
+	case ToolsetReadOnly:
+		return r.registerSubset(readOnlyTools)
+	case ToolsetEdit:
+		return r.registerSubset(append(append([]string{}, readOnlyTools...), editTools...))
+	case ToolsetAll:
+		return r.RegisterAllTools()
+	}
+	return fmt.Errorf("unknown toolset %q (expected %s, %s or %s)", toolset, ToolsetReadOnly, ToolsetEdit, ToolsetAll)
+}
+
+// RegisterTools 注册指定名称的工具，工具不存在或不可用时返回错误
+func (r *Registry) RegisterTools(names []string) error {
+	all := NewRegistry()
+	if err := all.RegisterAllTools(); err != nil {
+		return err
+	}
+	for _, name := range names {
+		if _, ok := all.manager.GetTool(name); !ok {
+			return fmt.Errorf("tool %q is not available", name)
+		}
+	}
+	return r.registerSubset(names)
+}
+
+// registerSubset 从全部工具中注册指定的工具，不可用（如未安装语言服务器）或已注册的工具跳过
+func (r *Registry) registerSubset(names []string) error {
+	all := NewRegistry()
+	if err := all.RegisterAllTools(); err != nil {
+		return err
+	}
+	for _, name := range names {
+		tool, ok := all.manager.GetTool(name)
+		if !ok {
+			continue
+		}
+		if _, exists := r.manager.GetTool(name); exists {
+			continue
+		}
+		if err := r.manager.RegisterTool(name, tool); err != nil {
+			return fmt.Errorf("failed to register %s tool: %w", name, err)
+		}
+	}
 	return nil
 }
 
@@ -81,6 +390,26 @@ func RegisterDefaultTools() error {
 	return DefaultRegistry.RegisterAllTools()
 }
 
+// RegisterDefaultReadOnlyTools 只注册只读工具到全局注册器
+func RegisterDefaultReadOnlyTools() error {
+	return DefaultRegistry.RegisterReadOnlyTools()
+}
+
+// RegisterDefaultEditTools 只注册编辑工具到全局注册器
+func RegisterDefaultEditTools() error {
+	return DefaultRegistry.RegisterEditTools()
+}
+
+// RegisterDefaultToolset 注册工具集到全局注册器
+func RegisterDefaultToolset(toolset string) error {
+	return DefaultRegistry.RegisterToolset(toolset)
+}
+
+// RegisterDefaultOptionalTools 注册可选工具到全局注册器
+func RegisterDefaultOptionalTools(names []string) error {
+	return DefaultRegistry.RegisterOptionalTools(names)
+}
+
 // GetDefaultManager 获取默认工具管理器
 func GetDefaultManager() ToolManager {
 	return DefaultRegistry.GetManager()
@@ -89,4 +418,19 @@ func GetDefaultManager() ToolManager {
 // SetDefaultWorkDirectory 设置默认工作目录
 func SetDefaultWorkDirectory(dir string) {
 	DefaultRegistry.SetWorkDirectory(dir)
-} 
\ No newline at end of file
+}
+
+// SetDefaultEnvironment 设置默认会话级环境变量
+func SetDefaultEnvironment(env map[string]string) {
+	DefaultRegistry.SetEnvironment(env)
+}
+
+// SetDefaultWorkspaces 设置默认工具管理器的额外工作区根目录
+func SetDefaultWorkspaces(workspaces []Workspace) {
+	DefaultRegistry.SetWorkspaces(workspaces)
+}
+
+// SetDefaultShadowBranch 设置默认工具管理器的影子分支
+func SetDefaultShadowBranch(shadow *ShadowBranch) {
+	DefaultRegistry.SetShadowBranch(shadow)
+}
\ No newline at end of file
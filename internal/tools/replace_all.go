@@ -0,0 +1,457 @@
+package tools
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// 批量替换的限制
+const (
+	maxReplaceFileSize    = 2 * 1024 * 1024 // 跳过大于2MB的文件
+	maxReplacePreviewHits = 200             // 预览中最多列出的命中数
+)
+
+// replaceSkipDirs 批量替换时跳过的目录
+var replaceSkipDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"dist":         true,
+	"build":        true,
+	"target":       true,
+	"__pycache__":  true,
+}
+
+// ReplaceAllParams replace_all工具的参数
+type ReplaceAllParams struct {
+	Pattern        string `json:"pattern"`
+	Replacement    string `json:"replacement"`
+	IsRegex        bool   `json:"is_regex,omitempty"`
+	CaseSensitive  *bool  `json:"case_sensitive,omitempty"`
+	IncludePattern string `json:"include_pattern"`
+	ExcludePattern string `json:"exclude_pattern,omitempty"`
+	DryRun         *bool  `json:"dry_run,omitempty"`
+	ConfirmToken   string `json:"confirm_token,omitempty"`
+	Explanation    string `json:"explanation,omitempty"`
+}
+
+// ReplaceHit 预览中的一处命中（同一行的多处命中合并显示）
+type ReplaceHit struct {
+	Line   int    `json:"line"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// ReplaceFilePreview 单个文件的替换预览
+type ReplaceFilePreview struct {
+	File         string       `json:"file"`
+	Replacements int          `json:"replacements"`
+	Hits         []ReplaceHit `json:"hits,omitempty"`
+}
+
+// ReplaceAllResult replace_all工具的返回结果
+type ReplaceAllResult struct {
+	Pattern           string               `json:"pattern"`
+	Replacement       string               `json:"replacement"`
+	DryRun            bool                 `json:"dry_run"`
+	Files             []ReplaceFilePreview `json:"files"`
+	FilesMatched      int                  `json:"files_matched"`
+	TotalReplacements int                  `json:"total_replacements"`
+	Truncated         bool                 `json:"truncated,omitempty"`
+	ConfirmToken      string               `json:"confirm_token,omitempty"`
+	Message           string               `json:"message"`
+}
+
+// replacePlan 一次批量替换的计划（对每个命中文件计算出的新内容）
+type replacePlan struct {
+	files []plannedFile
+	token string
+}
+
+// plannedFile 单个文件的替换计划
+type plannedFile struct {
+	path       string
+	relPath    string
+	newContent []byte
+	mode       os.FileMode
+	preview    ReplaceFilePreview
+}
+
+// globToRegexp 将glob模式转换为正则，支持 ** 匹配任意层目录
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				// **/ 匹配零或多层目录
+				if i+2 < len(pattern) && pattern[i+2] == '/' {
+					b.WriteString("(?:.*/)?")
+					i += 2
+				} else {
+					b.WriteString(".*")
+					i++
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			// [abc]、[a-z]、[!abc] 字符类
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				b.WriteString(regexp.QuoteMeta(string(c)))
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case '{':
+			// {a,b} 选择
+			end := strings.IndexByte(pattern[i:], '}')
+			if end < 0 {
+				b.WriteString(regexp.QuoteMeta(string(c)))
+				continue
+			}
+			options := strings.Split(pattern[i+1:i+end], ",")
+			for j, option := range options {
+				options[j] = regexp.QuoteMeta(option)
+			}
+			b.WriteString("(?:" + strings.Join(options, "|") + ")")
+			i += end
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// GlobMatcher 不含 / 的模式匹配文件名，否则匹配相对路径
+func GlobMatcher(pattern string) (func(relPath string) bool, error) {
+	re, err := globToRegexp(filepath.ToSlash(pattern))
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+	}
+	if !strings.Contains(pattern, "/") {
+		return func(relPath string) bool {
+			return re.MatchString(filepath.Base(relPath))
+		}, nil
+	}
+	return func(relPath string) bool {
+		return re.MatchString(filepath.ToSlash(relPath))
+	}, nil
+}
+
+// buildReplacePlan 遍历工作区，计算每个匹配文件的替换结果
+func buildReplacePlan(root string, re *regexp.Regexp, replacement string, literal bool, include, exclude func(string) bool, fingerprint string) (*replacePlan, error) {
+	plan := &replacePlan{}
+	hash := sha256.New()
+	hash.Write([]byte(fingerprint))
+
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		name := info.Name()
+		if info.IsDir() {
+			if path != root && (strings.HasPrefix(name, ".") || replaceSkipDirs[name]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() || info.Size() > maxReplaceFileSize {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		if !include(relPath) || (exclude != nil && exclude(relPath)) {
+			return nil
+		}
+		if checkSecurityPolicy(policyWrite, path, root) != nil {
+			return nil // 安全策略不允许修改的文件
+		}
+
+		content, err := readWorkspaceFile(path)
+		if err != nil {
+			return nil
+		}
+		// 跳过二进制文件
+		if isBinaryContent(content) {
+			return nil
+		}
+
+		matches := re.FindAllSubmatchIndex(content, -1)
+		if len(matches) == 0 {
+			return nil
+		}
+
+		file := plannedFile{
+			path:    path,
+			relPath: filepath.ToSlash(relPath),
+			mode:    info.Mode().Perm(),
+		}
+		file.newContent, file.preview = applyReplacements(content, re, matches, replacement, literal)
+		file.preview.File = file.relPath
+		plan.files = append(plan.files, file)
+
+		// 令牌绑定到参数与每个命中文件的当前内容
+		hash.Write([]byte(file.relPath))
+		sum := sha256.Sum256(content)
+		hash.Write(sum[:])
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	plan.token = hex.EncodeToString(hash.Sum(nil))[:16]
+	return plan, nil
+}
+
+// applyReplacements 执行替换并生成按行合并的预览
+func applyReplacements(content []byte, re *regexp.Regexp, matches [][]int, replacement string, literal bool) ([]byte, ReplaceFilePreview) {
+	// 每处命中的替换文本
+	replacements := make([][]byte, len(matches))
+	for i, match := range matches {
+		if literal {
+			replacements[i] = []byte(replacement)
+		} else {
+			replacements[i] = re.Expand(nil, []byte(replacement), content, match)
+		}
+	}
+
+	var out bytes.Buffer
+	last := 0
+	for i, match := range matches {
+		out.Write(content[last:match[0]])
+		out.Write(replacements[i])
+		last = match[1]
+	}
+	out.Write(content[last:])
+
+	preview := ReplaceFilePreview{Replacements: len(matches)}
+	lineStartOf := func(pos int) int {
+		return bytes.LastIndexByte(content[:pos], '\n') + 1
+	}
+	lineEndOf := func(pos int) int {
+		if idx := bytes.IndexByte(content[pos:], '\n'); idx >= 0 {
+			return pos + idx
+		}
+		return len(content)
+	}
+
+	// 起始于同一行（或被前一处命中跨越的行）的命中合并为一条预览
+	for i := 0; i < len(matches); {
+		start := lineStartOf(matches[i][0])
+		end := lineEndOf(matches[i][1])
+		j := i + 1
+		for j < len(matches) && matches[j][0] <= end {
+			end = lineEndOf(matches[j][1])
+			j++
+		}
+
+		var after bytes.Buffer
+		pos := start
+		for k := i; k < j; k++ {
+			after.Write(content[pos:matches[k][0]])
+			after.Write(replacements[k])
+			pos = matches[k][1]
+		}
+		after.Write(content[pos:end])
+
+		preview.Hits = append(preview.Hits, ReplaceHit{
+			Line:   bytes.Count(content[:start], []byte("\n")) + 1,
+			Before: strings.TrimRight(string(content[start:end]), "\r"),
+			After:  strings.TrimRight(after.String(), "\r"),
+		})
+		i = j
+	}
+
+	return out.Bytes(), preview
+}
+
+// replaceAllFunction 批量搜索替换工具函数
+func replaceAllFunction(params map[string]interface{}) (interface{}, error) {
+	// 解析参数
+	pattern, ok := params["pattern"].(string)
+	if !ok || pattern == "" {
+		return nil, fmt.Errorf("pattern is required")
+	}
+
+	replacement, ok := params["replacement"].(string)
+	if !ok {
+		return nil, fmt.Errorf("replacement is required (use an empty string to delete matches)")
+	}
+
+	includePattern, ok := params["include_pattern"].(string)
+	if !ok || includePattern == "" {
+		return nil, fmt.Errorf("include_pattern is required (e.g. '**/*.go')")
+	}
+
+	isRegex, _ := params["is_regex"].(bool)
+	caseSensitive := true
+	if val, ok := params["case_sensitive"].(bool); ok {
+		caseSensitive = val
+	}
+	dryRun := true
+	if val, ok := params["dry_run"].(bool); ok {
+		dryRun = val
+	}
+	confirmToken, _ := params["confirm_token"].(string)
+	excludePattern, _ := params["exclude_pattern"].(string)
+	workDir, _ := params["__work_dir__"].(string)
+	if workDir == "" {
+		workDir = "."
+	}
+
+	// 编译搜索模式
+	expr := pattern
+	if !isRegex {
+		expr = regexp.QuoteMeta(pattern)
+	}
+	if !caseSensitive {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex pattern: %w", err)
+	}
+
+	include, err := GlobMatcher(includePattern)
+	if err != nil {
+		return nil, err
+	}
+	var exclude func(string) bool
+	if excludePattern != "" {
+		if exclude, err = GlobMatcher(excludePattern); err != nil {
+			return nil, err
+		}
+	}
+
+	fingerprint := fmt.Sprintf("%q|%q|%t|%t|%q|%q", pattern, replacement, isRegex, caseSensitive, includePattern, excludePattern)
+	plan, err := buildReplacePlan(workDir, re, replacement, !isRegex, include, exclude, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ReplaceAllResult{
+		Pattern:      pattern,
+		Replacement:  replacement,
+		DryRun:       dryRun,
+		Files:        []ReplaceFilePreview{},
+		FilesMatched: len(plan.files),
+	}
+
+	listed := 0
+	for _, file := range plan.files {
+		preview := file.preview
+		result.TotalReplacements += preview.Replacements
+		if listed >= maxReplacePreviewHits {
+			preview.Hits = nil
+			result.Truncated = true
+		} else if listed+len(preview.Hits) > maxReplacePreviewHits {
+			preview.Hits = preview.Hits[:maxReplacePreviewHits-listed]
+			result.Truncated = true
+		}
+		listed += len(preview.Hits)
+		result.Files = append(result.Files, preview)
+	}
+
+	if len(plan.files) == 0 {
+		result.Message = fmt.Sprintf("No matches for '%s' in files matching '%s'", pattern, includePattern)
+		return result, nil
+	}
+
+	if dryRun {
+		result.ConfirmToken = plan.token
+		result.Message = fmt.Sprintf("Dry run: %d replacements in %d files, nothing written. Review every hit, then call replace_all again with the same arguments, dry_run=false and confirm_token=%s to apply.", result.TotalReplacements, result.FilesMatched, plan.token)
+		return result, nil
+	}
+
+	// 写入前必须先预览，且预览后参数与文件内容都未变化
+	if confirmToken == "" {
+		return nil, fmt.Errorf("confirm_token is required when dry_run is false: run a dry run first and review the preview")
+	}
+	if confirmToken != plan.token {
+		return nil, fmt.Errorf("confirm_token does not match: the arguments or the matched files changed since the dry run, run a new dry run")
+	}
+
+	for i, file := range plan.files {
+		if err := writeWorkspaceFile(file.path, file.newContent, file.mode); err != nil {
+			return nil, fmt.Errorf("failed to write %s after updating %d of %d files: %w", file.relPath, i, len(plan.files), err)
+		}
+	}
+
+	result.Message = fmt.Sprintf("Replaced %d occurrences in %d files", result.TotalReplacements, result.FilesMatched)
+	return result, nil
+}
+
+// NewReplaceAllTool 创建replace_all工具
+func NewReplaceAllTool() Tool {
+	schema := ToolSchema{
+		Name:        "replace_all",
+		Description: "Search and replace across every file matching a glob in one step, for mechanical refactors such as renaming an identifier or updating an import path everywhere. Works in two passes: the first call (dry_run defaults to true) writes nothing and returns a preview of every hit with the line before and after, plus a confirm_token. Review the preview, then repeat the call with identical arguments, dry_run=false and that confirm_token to write the changes. The write is refused if any argument or matched file changed since the preview.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"pattern": map[string]interface{}{
+					"type":        "string",
+					"description": "The text to search for. Treated literally unless is_regex is true.",
+				},
+				"replacement": map[string]interface{}{
+					"type":        "string",
+					"description": "The replacement text. With is_regex, $1 or ${name} refer to capture groups. Use an empty string to delete matches.",
+				},
+				"is_regex": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Treat pattern as a Go (RE2) regular expression. Defaults to false.",
+				},
+				"case_sensitive": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Whether the search is case sensitive. Defaults to true.",
+				},
+				"include_pattern": map[string]interface{}{
+					"type":        "string",
+					"description": "Glob of files to change, e.g. '*.go' (matches file names anywhere) or 'src/**/*.{ts,tsx}' (matches paths relative to the workspace root).",
+				},
+				"exclude_pattern": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional glob of files to leave untouched, e.g. '*_test.go'.",
+				},
+				"dry_run": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Preview only (default true). Set to false together with confirm_token to apply.",
+				},
+				"confirm_token": map[string]interface{}{
+					"type":        "string",
+					"description": "The confirm_token returned by the dry run; required when dry_run is false.",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+			"required": []string{"pattern", "replacement", "include_pattern"},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: replaceAllFunction,
+	}
+}
\ No newline at end of file
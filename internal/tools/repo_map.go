@@ -0,0 +1,117 @@
+package tools
+
+import (
+	"fmt"
+
+	"openCursor/internal/embeddings"
+	"openCursor/internal/repomap"
+)
+
+// RepoMapParams repo_map工具的参数
+type RepoMapParams struct {
+	TokenBudget int      `json:"token_budget,omitempty"`
+	Mentions    []string `json:"mentions,omitempty"`
+	Query       string   `json:"query,omitempty"`
+	Explanation string   `json:"explanation,omitempty"`
+}
+
+// RepoMapResult repo_map工具的返回结果
+type RepoMapResult struct {
+	Map             string `json:"map"`
+	TotalFiles      int    `json:"total_files"`
+	IncludedFiles   int    `json:"included_files"`
+	EstimatedTokens int    `json:"estimated_tokens"`
+	Warning         string `json:"warning,omitempty"`
+}
+
+// embedder 配置的嵌入服务，为空时 repo_map 不做语义排名
+var embedder embeddings.Embedder
+
+// SetEmbedder 设置嵌入服务，用于 repo_map 的语义排名
+func SetEmbedder(e embeddings.Embedder) {
+	embedder = e
+}
+
+// repoMapFunction 仓库地图工具函数
+func repoMapFunction(params map[string]interface{}) (interface{}, error) {
+	// 解析参数
+	tokenBudget := repomap.DefaultTokenBudget
+	if val, ok := params["token_budget"]; ok {
+		switch v := val.(type) {
+		case float64:
+			tokenBudget = int(v)
+		case int:
+			tokenBudget = v
+		}
+	}
+	if tokenBudget <= 0 || tokenBudget > 8192 {
+		return nil, fmt.Errorf("token_budget must be between 1 and 8192 (got %d)", tokenBudget)
+	}
+
+	var mentions []string
+	if list, ok := params["mentions"].([]interface{}); ok {
+		for _, item := range list {
+			if word, ok := item.(string); ok && word != "" {
+				mentions = append(mentions, word)
+			}
+		}
+	}
+
+	query, _ := params["query"].(string)
+	workDir, _ := params["__work_dir__"].(string)
+
+	repoMap, err := repomap.Generate(repomap.Options{
+		Root:        workDir,
+		TokenBudget: tokenBudget,
+		Mentions:    mentions,
+		Query:       query,
+		Embedder:    embedder,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &RepoMapResult{
+		Map:             repoMap.Text,
+		TotalFiles:      repoMap.TotalFiles,
+		IncludedFiles:   repoMap.IncludedFiles,
+		EstimatedTokens: repoMap.EstimateTokens,
+		Warning:         repoMap.Warning,
+	}, nil
+}
+
+// NewRepoMapTool 创建repo_map工具
+func NewRepoMapTool() Tool {
+	schema := ToolSchema{
+		Name:        "repo_map",
+		Description: "Get a ranked map of the repository: the most important source files and their top-level symbols (functions, types, classes) with signatures, ranked by how often other files reference them and trimmed to fit a token budget. Use this first to orient yourself in an unfamiliar or large codebase before reading individual files. Pass identifiers or file names relevant to the task as mentions to rank related code higher.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"token_budget": map[string]interface{}{
+					"type":        "integer",
+					"description": "Approximate maximum size of the map in tokens. Defaults to 1024.",
+				},
+				"mentions": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Identifiers or file names relevant to the current task, used to rank related files higher.",
+				},
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "Natural-language description of what you are looking for. When embeddings are configured, files whose contents are semantically related rank higher.",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+			"required": []string{},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: repoMapFunction,
+	}
+}
\ No newline at end of file
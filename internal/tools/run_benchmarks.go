@@ -0,0 +1,649 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// 基准测试的默认值与上限
+const (
+	defaultBenchCount = 6
+	maxBenchCount     = 20
+	benchAlpha        = 0.05 // 显著性水平，p 值不低于它时认为没有差异
+)
+
+// benchFrameworks 支持的基准测试框架
+var benchFrameworks = []string{"go", "pytest"}
+
+// goBenchPattern go test -bench 的结果行：名称、迭代次数与若干“数值 单位”
+var goBenchPattern = regexp.MustCompile(`^(Benchmark\S+)\s+\d+\s+(.+)$`)
+
+// benchRun 一次基准测试的样本，按名称与单位分组
+type benchRun struct {
+	names   []string
+	samples map[string]map[string][]float64
+}
+
+// add 记录一个样本
+func (r *benchRun) add(name, unit string, value float64) {
+	if r.samples == nil {
+		r.samples = make(map[string]map[string][]float64)
+	}
+	if r.samples[name] == nil {
+		r.samples[name] = make(map[string][]float64)
+		r.names = append(r.names, name)
+	}
+	r.samples[name][unit] = append(r.samples[name][unit], value)
+}
+
+// benchBaselines 用 save_as 保存的基准测试结果，供之后的调用比较
+var benchBaselines = struct {
+	sync.Mutex
+	runs map[string]*benchRun
+}{runs: make(map[string]*benchRun)}
+
+// BenchmarkComparison 一个基准测试在一个单位上的结果与比较
+type BenchmarkComparison struct {
+	Name            string  `json:"name"`
+	Unit            string  `json:"unit"`
+	Before          float64 `json:"before,omitempty"` // 基线的中位数
+	BeforeVariation float64 `json:"before_variation_percent,omitempty"`
+	After           float64 `json:"after"` // 本次运行的中位数
+	AfterVariation  float64 `json:"after_variation_percent"`
+	Samples         string  `json:"samples"`         // 样本数，如 "6+6"
+	Delta           string  `json:"delta,omitempty"` // 变化百分比，差异不显著时为 "~"
+	PValue          float64 `json:"p_value,omitempty"`
+}
+
+// RunBenchmarksResult run_benchmarks工具的返回结果
+type RunBenchmarksResult struct {
+	Framework  string                `json:"framework"`
+	Command    string                `json:"command"`
+	Directory  string                `json:"directory"`
+	Baseline   string                `json:"baseline,omitempty"`
+	SavedAs    string                `json:"saved_as,omitempty"`
+	Benchmarks []BenchmarkComparison `json:"benchmarks"`
+	Table      string                `json:"table"`
+	Duration   string                `json:"duration"`
+	Output     string                `json:"output,omitempty"` // 运行失败或没有结果时输出的尾部
+}
+
+// runBenchmarksFunction 运行基准测试工具函数
+func runBenchmarksFunction(params map[string]interface{}) (interface{}, error) {
+	target, _ := params["target"].(string)
+	bench, _ := params["bench"].(string)
+	framework, _ := params["framework"].(string)
+	benchtime, _ := params["benchtime"].(string)
+	baselineRef, _ := params["baseline_ref"].(string)
+	compareTo, _ := params["compare_to"].(string)
+	saveAs, _ := params["save_as"].(string)
+	workDir, _ := params["__work_dir__"].(string)
+	if workDir == "" {
+		workDir = "."
+	}
+	workDir, _ = filepath.Abs(workDir)
+	if baselineRef != "" && compareTo != "" {
+		return nil, fmt.Errorf("use either baseline_ref or compare_to, not both")
+	}
+
+	count := defaultBenchCount
+	if c, ok := intParam(params, "count"); ok && c > 0 {
+		count = min(c, maxBenchCount)
+	}
+	timeout := defaultTestTimeout
+	if t, ok := intParam(params, "timeout_seconds"); ok && t > 0 {
+		timeout = min(t, maxTestTimeout)
+	}
+
+	var baseline *benchRun
+	if compareTo != "" {
+		benchBaselines.Lock()
+		baseline = benchBaselines.runs[compareTo]
+		benchBaselines.Unlock()
+		if baseline == nil {
+			return nil, fmt.Errorf("no benchmark results saved as %q: run run_benchmarks with save_as first", compareTo)
+		}
+	}
+
+	targetPath := ""
+	if target != "" {
+		targetPath = target
+		if !filepath.IsAbs(targetPath) {
+			targetPath = filepath.Join(workDir, strings.TrimSuffix(target, "/..."))
+		}
+		if _, err := os.Stat(targetPath); err != nil {
+			return nil, fmt.Errorf("benchmark target not found: %s", target)
+		}
+	}
+	projectDir, detected := detectTestFramework(targetPath, workDir)
+	if framework == "" {
+		framework = detected
+	}
+	if !containsString(benchFrameworks, framework) {
+		if framework == "" {
+			return nil, fmt.Errorf("cannot detect the benchmark framework for %q: pass framework (one of %s)", target, strings.Join(benchFrameworks, ", "))
+		}
+		return nil, fmt.Errorf("benchmarks are not supported for %s (supported: %s); use run_terminal_cmd", framework, strings.Join(benchFrameworks, ", "))
+	}
+
+	report, err := os.CreateTemp("", "opencursor-bench-*.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the benchmark report file: %w", err)
+	}
+	report.Close()
+	defer os.Remove(report.Name())
+	args, err := benchCommand(framework, projectDir, target, targetPath, bench, benchtime, count, report.Name())
+	if err != nil {
+		return nil, err
+	}
+	command := strings.Join(quoteArgs(args), " ")
+
+	// 试运行时只记录命令（基准测试会执行项目代码）
+	explanation, _ := params["explanation"].(string)
+	approval := command
+	if baselineRef != "" {
+		approval = fmt.Sprintf("%s (also in a temporary git worktree of %s)", command, baselineRef)
+	}
+	if planCommand("run_benchmarks", approval, explanation) {
+		return nil, errNotRunInDryRun(approval)
+	}
+	if !approveCommand("run_benchmarks", approval, explanation) {
+		return nil, fmt.Errorf("the user rejected the command: %s", approval)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+	start := time.Now()
+	result := &RunBenchmarksResult{
+		Framework: framework,
+		Command:   command,
+		Directory: projectDir,
+		Baseline:  compareTo,
+	}
+	if rel, err := filepath.Rel(workDir, projectDir); err == nil && !strings.HasPrefix(rel, "..") {
+		result.Directory = rel
+	}
+
+	// 基线：在指定提交的临时工作树中运行同样的命令
+	if baselineRef != "" {
+		baseline, err = benchAtRef(ctx, params, baselineRef, projectDir, framework, args, report.Name())
+		if err != nil {
+			return nil, err
+		}
+		result.Baseline = baselineRef
+	}
+
+	current, output, err := runBench(ctx, params, framework, args, projectDir, report.Name())
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("%s timed out after %ds", command, timeout)
+	}
+	if len(current.names) == 0 {
+		result.Output = tailOutput(output)
+		result.Table = "no benchmarks ran"
+		result.Duration = time.Since(start).Round(time.Millisecond).String()
+		return result, nil
+	}
+
+	if saveAs != "" {
+		benchBaselines.Lock()
+		benchBaselines.runs[saveAs] = current
+		benchBaselines.Unlock()
+		result.SavedAs = saveAs
+	}
+	result.Benchmarks = compareBenchmarks(baseline, current)
+	result.Table = formatBenchTable(result.Benchmarks, baseline != nil)
+	result.Duration = time.Since(start).Round(time.Millisecond).String()
+	return result, nil
+}
+
+// benchCommand 构建基准测试命令；pytest-benchmark 的结果写到 report
+func benchCommand(framework, projectDir, target, targetPath, bench, benchtime string, count int, report string) ([]string, error) {
+	args, err := testCommand(framework, projectDir, target, targetPath, "")
+	if err != nil {
+		return nil, err
+	}
+	switch framework {
+	case "go":
+		if bench == "" {
+			bench = "."
+		}
+		// 只运行基准测试，不运行普通测试
+		args = []string{"go", "test", "-run", "^$", "-bench", bench, "-benchmem", "-count", strconv.Itoa(count), args[len(args)-1]}
+		if benchtime != "" {
+			args = append(args, "-benchtime", benchtime)
+		}
+	case "pytest":
+		args = append(args, "--benchmark-only", "--benchmark-json="+report, "--benchmark-min-rounds="+strconv.Itoa(count))
+		if bench != "" {
+			args = append(args, "-k", bench)
+		}
+		if benchtime != "" {
+			args = append(args, "--benchmark-max-time="+strings.TrimSuffix(benchtime, "s"))
+		}
+	}
+	return args, nil
+}
+
+// runBench 运行基准测试并解析结果
+func runBench(ctx context.Context, params map[string]interface{}, framework string, args []string, dir, report string) (*benchRun, string, error) {
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = dir
+	cmd.Env = buildCommandEnv(params)
+	output, runErr := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return &benchRun{}, string(output), nil
+	}
+	if runErr != nil {
+		if _, ok := runErr.(*exec.ExitError); !ok {
+			return nil, "", fmt.Errorf("failed to run %s: %w", args[0], runErr)
+		}
+		if framework == "pytest" && bytes.Contains(output, []byte("unrecognized arguments: --benchmark")) {
+			return nil, "", fmt.Errorf("pytest-benchmark is not installed: install it with `pip install pytest-benchmark`")
+		}
+		return nil, "", fmt.Errorf("%s failed in %s:\n%s", strings.Join(quoteArgs(args), " "), dir, tailOutput(string(output)))
+	}
+
+	if framework == "go" {
+		return parseGoBench(output), string(output), nil
+	}
+	data, err := os.ReadFile(report)
+	if err != nil || len(data) == 0 {
+		return &benchRun{}, string(output), nil
+	}
+	run, err := parsePytestBench(data)
+	return run, string(output), err
+}
+
+// benchAtRef 在 ref 的临时 git 工作树中运行基准测试，目录与当前项目目录对应
+func benchAtRef(ctx context.Context, params map[string]interface{}, ref, projectDir, framework string, args []string, report string) (*benchRun, error) {
+	top, err := exec.Command("git", "-C", projectDir, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return nil, fmt.Errorf("baseline_ref needs a git repository")
+	}
+	root := strings.TrimSpace(string(top))
+	rel, err := filepath.Rel(root, projectDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := exec.Command("git", "-C", root, "rev-parse", "--verify", "--quiet", ref+"^{commit}").Run(); err != nil {
+		return nil, fmt.Errorf("unknown git ref %q", ref)
+	}
+
+	tree, err := os.MkdirTemp("", "opencursor-bench-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tree)
+	if out, err := exec.Command("git", "-C", root, "worktree", "add", "--detach", tree, ref).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("failed to check out %s: %s", ref, strings.TrimSpace(string(out)))
+	}
+	defer exec.Command("git", "-C", root, "worktree", "remove", "--force", tree).Run()
+
+	run, output, err := runBench(ctx, params, framework, args, filepath.Join(tree, rel), report)
+	if err != nil {
+		return nil, fmt.Errorf("baseline at %s: %w", ref, err)
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("the baseline benchmarks at %s timed out", ref)
+	}
+	if len(run.names) == 0 {
+		return nil, fmt.Errorf("no benchmarks ran at %s:\n%s", ref, tailOutput(output))
+	}
+	os.Truncate(report, 0)
+	return run, nil
+}
+
+// parseGoBench 解析 go test -bench 的输出；测试多个包时名称前加上包路径
+func parseGoBench(output []byte) *benchRun {
+	type sample struct {
+		pkg, name, unit string
+		value           float64
+	}
+	var samples []sample
+	pkgs := make(map[string]bool)
+	pkg := ""
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "pkg: ") {
+			pkg = strings.TrimPrefix(line, "pkg: ")
+			continue
+		}
+		match := goBenchPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		pkgs[pkg] = true
+		fields := strings.Fields(match[2])
+		for i := 0; i+1 < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				break
+			}
+			samples = append(samples, sample{pkg, strings.TrimPrefix(match[1], "Benchmark"), fields[i+1], value})
+		}
+	}
+
+	run := &benchRun{}
+	for _, s := range samples {
+		name := s.name
+		if len(pkgs) > 1 {
+			name = s.pkg + "." + name
+		}
+		run.add(name, s.unit, s.value)
+	}
+	return run
+}
+
+// parsePytestBench 解析 pytest-benchmark 的 JSON 报告，每轮的耗时为一个样本（秒）
+func parsePytestBench(data []byte) (*benchRun, error) {
+	var report struct {
+		Benchmarks []struct {
+			Name  string `json:"name"`
+			Stats struct {
+				Data   []float64 `json:"data"`
+				Median float64   `json:"median"`
+			} `json:"stats"`
+		} `json:"benchmarks"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse the pytest-benchmark report: %w", err)
+	}
+	run := &benchRun{}
+	for _, b := range report.Benchmarks {
+		values := b.Stats.Data
+		if len(values) == 0 {
+			values = []float64{b.Stats.Median}
+		}
+		for _, value := range values {
+			run.add(b.Name, "sec/op", value)
+		}
+	}
+	return run, nil
+}
+
+// compareBenchmarks 汇总本次运行，有基线时与基线比较
+func compareBenchmarks(baseline, current *benchRun) []BenchmarkComparison {
+	var comparisons []BenchmarkComparison
+	for _, name := range current.names {
+		units := make([]string, 0, len(current.samples[name]))
+		for unit := range current.samples[name] {
+			units = append(units, unit)
+		}
+		sort.Slice(units, func(i, j int) bool { return benchUnitOrder(units[i]) < benchUnitOrder(units[j]) })
+		for _, unit := range units {
+			after := current.samples[name][unit]
+			c := BenchmarkComparison{Name: name, Unit: unit, Samples: strconv.Itoa(len(after))}
+			c.After, c.AfterVariation = medianVariation(after)
+			if baseline != nil {
+				if before := baseline.samples[name][unit]; len(before) > 0 {
+					c.Before, c.BeforeVariation = medianVariation(before)
+					c.Samples = fmt.Sprintf("%d+%d", len(before), len(after))
+					c.PValue = roundTo(mannWhitneyU(before, after), 3)
+					c.Delta = "~"
+					if c.PValue < benchAlpha && c.Before != 0 {
+						c.Delta = fmt.Sprintf("%+.2f%%", (c.After-c.Before)/c.Before*100)
+					}
+				} else {
+					c.Delta = "new"
+				}
+			}
+			comparisons = append(comparisons, c)
+		}
+	}
+	return comparisons
+}
+
+// benchUnitOrder 耗时在前，其次是内存与分配次数，其他单位最后
+func benchUnitOrder(unit string) string {
+	switch unit {
+	case "ns/op", "sec/op":
+		return "0"
+	case "B/op":
+		return "1"
+	case "allocs/op":
+		return "2"
+	}
+	return "3" + unit
+}
+
+// medianVariation 中位数，以及样本偏离中位数的最大百分比
+func medianVariation(values []float64) (float64, float64) {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	median := sorted[n/2]
+	if n%2 == 0 {
+		median = (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+	if median == 0 {
+		return 0, 0
+	}
+	deviation := math.Max(median-sorted[0], sorted[n-1]-median)
+	return median, roundTo(deviation/math.Abs(median)*100, 1)
+}
+
+// mannWhitneyU Mann-Whitney U 检验的双侧 p 值；样本少且无并列值时精确计算，否则用正态近似
+func mannWhitneyU(a, b []float64) float64 {
+	n1, n2 := len(a), len(b)
+	type value struct {
+		v     float64
+		first bool
+	}
+	all := make([]value, 0, n1+n2)
+	for _, v := range a {
+		all = append(all, value{v, true})
+	}
+	for _, v := range b {
+		all = append(all, value{v, false})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].v < all[j].v })
+
+	// 秩和，并列值取平均秩
+	rankSum, tieTerm, ties := 0.0, 0.0, false
+	for i := 0; i < len(all); {
+		j := i + 1
+		for j < len(all) && all[j].v == all[i].v {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if all[k].first {
+				rankSum += rank
+			}
+		}
+		if t := float64(j - i); t > 1 {
+			ties = true
+			tieTerm += t*t*t - t
+		}
+		i = j
+	}
+	u := rankSum - float64(n1*(n1+1))/2
+
+	if !ties && n1+n2 <= 40 {
+		// counts[k] 为 U 等于 k 的排列数
+		counts := uDistribution(n1, n2)
+		total, below := 0.0, 0.0
+		for k, c := range counts {
+			total += c
+			if float64(k) <= u {
+				below += c
+			}
+		}
+		above := 0.0
+		for k, c := range counts {
+			if float64(k) >= u {
+				above += c
+			}
+		}
+		return math.Min(1, 2*math.Min(below, above)/total)
+	}
+
+	n := float64(n1 + n2)
+	mean := float64(n1*n2) / 2
+	variance := float64(n1*n2) / 12 * (n + 1 - tieTerm/(n*(n-1)))
+	if variance <= 0 {
+		return 1
+	}
+	z := (math.Abs(u-mean) - 0.5) / math.Sqrt(variance)
+	return math.Min(1, math.Erfc(math.Max(z, 0)/math.Sqrt2))
+}
+
+// uDistribution 样本量为 n1、n2 时 U 统计量各取值的排列数
+func uDistribution(n1, n2 int) []float64 {
+	// f[i][j][k]：i 个与 j 个样本时 U=k 的排列数，f(i,j,k) = f(i-1,j,k-j) + f(i,j-1,k)
+	f := make([][][]float64, n1+1)
+	for i := range f {
+		f[i] = make([][]float64, n2+1)
+		for j := range f[i] {
+			f[i][j] = make([]float64, i*j+1)
+			if i == 0 || j == 0 {
+				f[i][j][0] = 1
+				continue
+			}
+			for k := range f[i][j] {
+				if k-j >= 0 && k-j < len(f[i-1][j]) {
+					f[i][j][k] += f[i-1][j][k-j]
+				}
+				if k < len(f[i][j-1]) {
+					f[i][j][k] += f[i][j-1][k]
+				}
+			}
+		}
+	}
+	return f[n1][n2]
+}
+
+// roundTo 保留 digits 位小数
+func roundTo(value float64, digits int) float64 {
+	scale := math.Pow(10, float64(digits))
+	return math.Round(value*scale) / scale
+}
+
+// formatBenchTable 生成 benchstat 风格的文本表格
+func formatBenchTable(comparisons []BenchmarkComparison, compared bool) string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 3, ' ', 0)
+	// 按单位分段，段内保持基准测试的顺序
+	sorted := append([]BenchmarkComparison(nil), comparisons...)
+	sort.SliceStable(sorted, func(i, j int) bool { return benchUnitOrder(sorted[i].Unit) < benchUnitOrder(sorted[j].Unit) })
+	unit := ""
+	for _, c := range sorted {
+		if c.Unit != unit {
+			if unit != "" {
+				fmt.Fprintln(w)
+			}
+			unit = c.Unit
+			if compared {
+				fmt.Fprintf(w, "name\told %s\tnew %s\tdelta\n", unit, unit)
+			} else {
+				fmt.Fprintf(w, "name\t%s\n", unit)
+			}
+		}
+		after := fmt.Sprintf("%s ± %.0f%%", formatBenchValue(c.After), c.AfterVariation)
+		if !compared {
+			fmt.Fprintf(w, "%s\t%s\n", c.Name, after)
+			continue
+		}
+		before := ""
+		if c.Delta != "new" {
+			before = fmt.Sprintf("%s ± %.0f%%", formatBenchValue(c.Before), c.BeforeVariation)
+		}
+		delta := c.Delta
+		if delta != "new" {
+			delta = fmt.Sprintf("%s (p=%.3f n=%s)", c.Delta, c.PValue, c.Samples)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Name, before, after, delta)
+	}
+	w.Flush()
+	return sb.String()
+}
+
+// formatBenchValue 以 4 位有效数字显示数值
+func formatBenchValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', 4, 64)
+}
+
+// tailOutput 输出的尾部
+func tailOutput(text string) string {
+	if len(text) > maxTestOutputBytes {
+		text = "...\n" + text[len(text)-maxTestOutputBytes:]
+	}
+	return text
+}
+
+// NewRunBenchmarksTool 创建run_benchmarks工具
+func NewRunBenchmarksTool() Tool {
+	schema := ToolSchema{
+		Name:        "run_benchmarks",
+		Description: "Run benchmarks (Go benchmarks with go test -bench -benchmem, or pytest-benchmark) and report the median and variation of every metric. To verify a performance change, either pass baseline_ref to also run the same benchmarks at a git commit in a temporary worktree, or run once with save_as before changing the code and again with compare_to afterwards. Comparisons are benchstat-style: the delta is only reported when the difference is statistically significant (Mann-Whitney U test, p < 0.05), otherwise it is ~.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"target": map[string]interface{}{
+					"type":        "string",
+					"description": "Go package or test file/directory with the benchmarks, relative to the workspace root (e.g. internal/parser, ./pkg/..., tests/bench_parse.py). Defaults to the whole project.",
+				},
+				"bench": map[string]interface{}{
+					"type":        "string",
+					"description": "Only run benchmarks matching this pattern (go test -bench regexp, default all; pytest -k)",
+				},
+				"count": map[string]interface{}{
+					"type":        "integer",
+					"description": "Number of samples per benchmark (default 6, max 20); at least 5 are needed to detect significant differences",
+				},
+				"benchtime": map[string]interface{}{
+					"type":        "string",
+					"description": "Time per sample, e.g. 500ms or 2s (Go) or seconds per benchmark (pytest)",
+				},
+				"baseline_ref": map[string]interface{}{
+					"type":        "string",
+					"description": "Git commit, branch or tag (e.g. HEAD) to compare against; the benchmarks are also run there in a temporary worktree",
+				},
+				"save_as": map[string]interface{}{
+					"type":        "string",
+					"description": "Save the results under this label (e.g. before) for a later compare_to",
+				},
+				"compare_to": map[string]interface{}{
+					"type":        "string",
+					"description": "Compare with results saved earlier in this session with save_as",
+				},
+				"framework": map[string]interface{}{
+					"type":        "string",
+					"description": "Benchmark framework, only needed when detection fails",
+					"enum":        benchFrameworks,
+				},
+				"timeout_seconds": map[string]interface{}{
+					"type":        "integer",
+					"description": "Timeout for the whole run in seconds (default 600, max 3600)",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: runBenchmarksFunction,
+	}
+}
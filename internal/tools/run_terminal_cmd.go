@@ -2,9 +2,11 @@ package tools
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 )
 
@@ -40,6 +42,17 @@ func runTerminalCmdFunction(params map[string]interface{}) (interface{}, error)
 	command = strings.ReplaceAll(command, "\n", " ")
 	command = strings.TrimSpace(command)
 
+	// 试运行时只记录命令
+	explanation, _ := params["explanation"].(string)
+	if planCommand("run_terminal_cmd", command, explanation) {
+		return nil, errNotRunInDryRun(command)
+	}
+
+	// ask 模式下非只读命令需要用户确认
+	if !approveCommand("run_terminal_cmd", command, explanation) {
+		return nil, fmt.Errorf("the user rejected the command: %s", command)
+	}
+
 	result := &RunTerminalCmdResult{
 		Command:      command,
 		IsBackground: isBackground,
@@ -65,6 +78,9 @@ func runTerminalCmdFunction(params map[string]interface{}) (interface{}, error)
 		}
 	}
 
+	// 注入会话级环境变量
+	cmd.Env = buildCommandEnv(params)
+
 	if isBackground {
 		// 后台运行
 		err := cmd.Start()
@@ -102,6 +118,26 @@ func runTerminalCmdFunction(params map[string]interface{}) (interface{}, error)
 	return result, nil
 }
 
+// buildCommandEnv 根据会话级环境变量构建命令环境，没有覆盖时返回nil（继承父进程环境）
+func buildCommandEnv(params map[string]interface{}) []string {
+	env, _ := params["__env__"].(map[string]string)
+	if len(env) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	cmdEnv := os.Environ()
+	for _, key := range keys {
+		cmdEnv = append(cmdEnv, key+"="+env[key])
+	}
+	return cmdEnv
+}
+
 // NewRunTerminalCmdTool 创建run_terminal_cmd工具
 func NewRunTerminalCmdTool() Tool {
 	schema := ToolSchema{
@@ -0,0 +1,371 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 测试运行的默认值与上限
+const (
+	defaultTestTimeout = 600  // 秒
+	maxTestTimeout     = 3600 // 秒
+	maxTestOutputBytes = 20000
+)
+
+// RunTestsParams run_tests工具的参数
+type RunTestsParams struct {
+	Target         string `json:"target,omitempty"`
+	Run            string `json:"run,omitempty"`
+	Framework      string `json:"framework,omitempty"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty"`
+	Explanation    string `json:"explanation,omitempty"`
+}
+
+// RunTestsResult run_tests工具的返回结果
+type RunTestsResult struct {
+	Framework string   `json:"framework"`
+	Command   string   `json:"command"`
+	Directory string   `json:"directory"`
+	Passed    bool     `json:"passed"`
+	ExitCode  int      `json:"exit_code"`
+	TimedOut  bool     `json:"timed_out,omitempty"`
+	Failed    []string `json:"failed,omitempty"`
+	Duration  string   `json:"duration"`
+	Output    string   `json:"output"`
+	Truncated bool     `json:"truncated,omitempty"`
+}
+
+// testFrameworks 支持的测试框架
+var testFrameworks = []string{"go", "pytest", "jest", "vitest", "cargo", "npm"}
+
+// 各框架失败测试的输出格式
+var (
+	goFailPattern     = regexp.MustCompile(`(?m)^\s*--- FAIL: (\S+)`)
+	pytestFailPattern = regexp.MustCompile(`(?m)^(?:FAILED|ERROR) (\S+)`)
+	jestFailPattern   = regexp.MustCompile(`(?m)^\s*(?:●|×|✕) (.+?)\s*$`)
+	cargoFailPattern  = regexp.MustCompile(`(?m)^test (\S+) \.\.\. FAILED`)
+)
+
+// runTestsFunction 运行测试工具函数
+func runTestsFunction(params map[string]interface{}) (interface{}, error) {
+	// 解析参数
+	target, _ := params["target"].(string)
+	filter, _ := params["run"].(string)
+	framework, _ := params["framework"].(string)
+	workDir, _ := params["__work_dir__"].(string)
+	if workDir == "" {
+		workDir = "."
+	}
+	workDir, _ = filepath.Abs(workDir)
+
+	timeout := defaultTestTimeout
+	if t, ok := intParam(params, "timeout_seconds"); ok && t > 0 {
+		timeout = t
+	}
+	if timeout > maxTestTimeout {
+		timeout = maxTestTimeout
+	}
+
+	targetPath := ""
+	if target != "" {
+		targetPath = target
+		if !filepath.IsAbs(targetPath) {
+			targetPath = filepath.Join(workDir, strings.TrimSuffix(target, "/..."))
+		}
+		if _, err := os.Stat(targetPath); err != nil {
+			return nil, fmt.Errorf("test target not found: %s", target)
+		}
+	}
+
+	// 从目标向上查找项目根目录与测试框架
+	projectDir, detected := detectTestFramework(targetPath, workDir)
+	if framework == "" {
+		framework = detected
+	}
+	if framework == "" {
+		return nil, fmt.Errorf("cannot detect the test framework for %q: pass framework (one of %s) or use run_terminal_cmd", target, strings.Join(testFrameworks, ", "))
+	}
+
+	args, err := testCommand(framework, projectDir, target, targetPath, filter)
+	if err != nil {
+		return nil, err
+	}
+	command := strings.Join(quoteArgs(args), " ")
+
+	// 试运行时只记录命令（测试会执行项目代码）
+	explanation, _ := params["explanation"].(string)
+	if planCommand("run_tests", command, explanation) {
+		return nil, errNotRunInDryRun(command)
+	}
+
+	// ask 模式下需要用户确认
+	if !approveCommand("run_tests", command, explanation) {
+		return nil, fmt.Errorf("the user rejected the command: %s", command)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = projectDir
+	cmd.Env = buildCommandEnv(params)
+
+	start := time.Now()
+	output, runErr := cmd.CombinedOutput()
+	result := &RunTestsResult{
+		Framework: framework,
+		Command:   command,
+		Directory: projectDir,
+		Duration:  time.Since(start).Round(time.Millisecond).String(),
+	}
+	if rel, err := filepath.Rel(workDir, projectDir); err == nil && !strings.HasPrefix(rel, "..") {
+		result.Directory = rel
+	}
+
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		result.TimedOut = true
+		result.ExitCode = -1
+	case runErr != nil:
+		if exitError, ok := runErr.(*exec.ExitError); ok {
+			result.ExitCode = exitError.ExitCode()
+		} else {
+			return nil, fmt.Errorf("failed to run %s: %w", command, runErr)
+		}
+	}
+	result.Passed = runErr == nil
+	result.Failed = failedTests(framework, string(output))
+
+	// 失败信息通常在输出末尾，超长时保留尾部
+	text := string(output)
+	if len(text) > maxTestOutputBytes {
+		text = "...\n" + text[len(text)-maxTestOutputBytes:]
+		result.Truncated = true
+	}
+	result.Output = text
+	return result, nil
+}
+
+// detectTestFramework 从目标所在目录向上（不超出工作目录）查找项目标记文件，
+// 返回项目目录与测试框架；找不到时返回工作目录与空框架
+func detectTestFramework(targetPath, workDir string) (string, string) {
+	dir := workDir
+	if targetPath != "" {
+		dir = targetPath
+		if info, err := os.Stat(targetPath); err == nil && !info.IsDir() {
+			dir = filepath.Dir(targetPath)
+		}
+	}
+
+	for {
+		if framework := frameworkInDir(dir); framework != "" {
+			return dir, framework
+		}
+		if dir == workDir || !strings.HasPrefix(dir, workDir) {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	if strings.HasSuffix(targetPath, ".py") {
+		return workDir, "pytest"
+	}
+	return workDir, ""
+}
+
+// frameworkInDir 根据目录中的项目文件判断测试框架
+func frameworkInDir(dir string) string {
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(dir, name))
+		return err == nil
+	}
+
+	switch {
+	case exists("go.mod"):
+		return "go"
+	case exists("Cargo.toml"):
+		return "cargo"
+	case exists("package.json"):
+		return packageJSONFramework(filepath.Join(dir, "package.json"))
+	case exists("pytest.ini"), exists("conftest.py"), exists("pyproject.toml"),
+		exists("setup.cfg"), exists("setup.py"), exists("tox.ini"):
+		return "pytest"
+	}
+	return ""
+}
+
+// packageJSONFramework 根据 package.json 的依赖判断使用 vitest、jest 还是 npm test
+func packageJSONFramework(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "npm"
+	}
+	var pkg struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if json.Unmarshal(data, &pkg) != nil {
+		return "npm"
+	}
+	for _, name := range []string{"vitest", "jest"} {
+		if _, ok := pkg.DevDependencies[name]; ok {
+			return name
+		}
+		if _, ok := pkg.Dependencies[name]; ok {
+			return name
+		}
+	}
+	return "npm"
+}
+
+// testCommand 构建测试命令，target 为相对项目目录的文件、目录或（Go）包
+func testCommand(framework, projectDir, target, targetPath, filter string) ([]string, error) {
+	// 目标改为相对项目目录的路径
+	relTarget := ""
+	if targetPath != "" {
+		if rel, err := filepath.Rel(projectDir, targetPath); err == nil && rel != "." {
+			relTarget = filepath.ToSlash(rel)
+		}
+	}
+
+	switch framework {
+	case "go":
+		pkg := "./..."
+		if targetPath != "" {
+			dir := targetPath
+			if info, err := os.Stat(targetPath); err == nil && !info.IsDir() {
+				dir = filepath.Dir(targetPath)
+			}
+			pkg = "."
+			if rel, err := filepath.Rel(projectDir, dir); err == nil && rel != "." {
+				pkg = "./" + filepath.ToSlash(rel)
+			}
+			if strings.HasSuffix(target, "/...") {
+				pkg = strings.TrimSuffix(pkg, "/.") + "/..."
+			}
+		}
+		args := []string{"go", "test", "-count=1", pkg}
+		if filter != "" {
+			args = append(args, "-run", filter)
+		}
+		return args, nil
+
+	case "pytest":
+		args := []string{"python3", "-m", "pytest", "-q"}
+		if _, err := exec.LookPath("pytest"); err == nil {
+			args = []string{"pytest", "-q"}
+		}
+		if relTarget != "" {
+			args = append(args, relTarget)
+		}
+		if filter != "" {
+			args = append(args, "-k", filter)
+		}
+		return args, nil
+
+	case "jest", "vitest":
+		args := []string{"npx", "--no-install", framework}
+		if framework == "vitest" {
+			args = append(args, "run")
+		}
+		if relTarget != "" {
+			args = append(args, relTarget)
+		}
+		if filter != "" {
+			args = append(args, "-t", filter)
+		}
+		return args, nil
+
+	case "cargo":
+		args := []string{"cargo", "test"}
+		if filter != "" {
+			args = append(args, filter)
+		}
+		return args, nil
+
+	case "npm":
+		args := []string{"npm", "test", "--"}
+		if relTarget != "" {
+			args = append(args, relTarget)
+		}
+		return args, nil
+	}
+	return nil, fmt.Errorf("unknown test framework %q (expected one of %s)", framework, strings.Join(testFrameworks, ", "))
+}
+
+// failedTests 从测试输出中提取失败的测试名
+func failedTests(framework, output string) []string {
+	var pattern *regexp.Regexp
+	switch framework {
+	case "go":
+		pattern = goFailPattern
+	case "pytest":
+		pattern = pytestFailPattern
+	case "jest", "vitest", "npm":
+		pattern = jestFailPattern
+	case "cargo":
+		pattern = cargoFailPattern
+	default:
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var failed []string
+	for _, match := range pattern.FindAllStringSubmatch(output, -1) {
+		name := match[1]
+		if !seen[name] {
+			seen[name] = true
+			failed = append(failed, name)
+		}
+	}
+	return failed
+}
+
+// NewRunTestsTool 创建run_tests工具
+func NewRunTestsTool() Tool {
+	schema := ToolSchema{
+		Name:        "run_tests",
+		Description: "Run the project's tests and report whether they pass, the failed test names and the (tail of the) output. The framework (go, pytest, jest, vitest, cargo, npm) is detected from the project files next to the target. Prefer this over run_terminal_cmd for verifying changes; narrow the run with target and run to keep it fast.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"target": map[string]interface{}{
+					"type":        "string",
+					"description": "Test file, directory or Go package to run, relative to the workspace root (e.g. internal/parser, internal/parser/..., tests/test_api.py). Defaults to the whole project.",
+				},
+				"run": map[string]interface{}{
+					"type":        "string",
+					"description": "Only run tests matching this name or pattern (go test -run, pytest -k, jest/vitest -t, cargo test filter)",
+				},
+				"framework": map[string]interface{}{
+					"type":        "string",
+					"description": "Test framework, only needed when detection fails",
+					"enum":        testFrameworks,
+				},
+				"timeout_seconds": map[string]interface{}{
+					"type":        "integer",
+					"description": "Timeout for the test run in seconds (default 600, max 3600)",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: runTestsFunction,
+	}
+}
\ No newline at end of file
@@ -0,0 +1,300 @@
+package tools
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// safeCommandRule 只读命令的判定规则
+type safeCommandRule struct {
+	subcommands    map[string]bool // 允许的子命令，为空表示不限制
+	forbiddenFlags []string        // 出现即视为不安全的参数（匹配规则见 matchesFlag）
+	requiredFlags  []string        // 必须至少出现一个的参数
+}
+
+// safeCommands 无需确认即可执行的只读命令
+var safeCommands = map[string]safeCommandRule{
+	"ls": {}, "cat": {}, "head": {}, "tail": {}, "wc": {}, "pwd": {}, "echo": {},
+	"which": {}, "whoami": {}, "date": {}, "uname": {}, "file": {}, "stat": {},
+	"du": {}, "df": {}, "grep": {}, "egrep": {}, "fgrep": {},
+	"diff": {}, "basename": {}, "dirname": {}, "realpath": {}, "readlink": {},
+	"uniq": {}, "cut": {}, "nl": {}, "printenv": {}, "true": {}, "false": {},
+	"sort": {forbiddenFlags: []string{"-o", "--output"}},
+	"tree": {forbiddenFlags: []string{"-o"}},
+	"rg":   {forbiddenFlags: []string{"--pre"}},
+	"find": {forbiddenFlags: []string{"-exec", "-execdir", "-ok", "-okdir", "-delete", "-fprint", "-fls"}},
+	"gofmt": {
+		forbiddenFlags: []string{"-w"},
+		requiredFlags:  []string{"-l", "-d"},
+	},
+	"go": {
+		subcommands:    setOf("vet", "version", "list", "doc", "env"),
+		forbiddenFlags: []string{"-w", "-u", "-toolexec", "-vettool", "-exec"},
+	},
+	"git": {
+		subcommands: setOf("status", "log", "diff", "show", "rev-parse", "ls-files", "blame",
+			"describe", "shortlog", "grep", "branch", "tag", "remote", "stash"),
+		forbiddenFlags: []string{"--output", "--ext-diff", "--open-files-in-pager", "-O"},
+	},
+	"npm": {subcommands: setOf("ls", "list", "view", "outdated")},
+}
+
+// gitListingSubcommands 只有在不带位置参数时才是只读的git子命令（如 git branch 列出分支）
+var gitListingSubcommands = map[string][]string{
+	"branch": {"-a", "-r", "-v", "-vv", "--all", "--remotes", "--list", "--show-current", "--merged", "--no-merged", "--contains"},
+	"tag":    {"-l", "--list", "-n", "--contains", "--merged", "--points-at"},
+	"remote": {"-v", "--verbose"},
+}
+
+// setOf 构建字符串集合
+func setOf(items ...string) map[string]bool {
+	set := make(map[string]bool, len(items))
+	for _, item := range items {
+		set[item] = true
+	}
+	return set
+}
+
+// IsSafeCommand 判断命令是否为无副作用的只读命令，可以不经确认直接执行。
+// 任何无法确定的写法（重定向、命令替换、后台执行、环境变量前缀等）都视为不安全。
+func IsSafeCommand(command string) bool {
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return false
+	}
+
+	// 允许常见的标准错误合并与丢弃输出
+	for _, redirect := range []string{"2>&1", "2>/dev/null", ">/dev/null"} {
+		command = strings.ReplaceAll(command, redirect, " ")
+	}
+	if strings.ContainsAny(command, "<>`\n") || strings.Contains(command, "$(") {
+		return false
+	}
+
+	segments, ok := splitCommandSegments(command)
+	if !ok {
+		return false
+	}
+	for _, segment := range segments {
+		if !isSafeSimpleCommand(segment) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsAllowedCommand 判断命令的每一段是否为安全只读命令，或以允许列表中的某条命令开头（按单词比较，
+// 如 "go test" 允许 go test ./...，不允许 go tool）。与 IsSafeCommand 一样拒绝重定向、命令替换与后台执行，
+// 危险命令即使在允许列表中也不允许
+func IsAllowedCommand(command string, allowlist []string) bool {
+	if IsSafeCommand(command) {
+		return true
+	}
+	if len(allowlist) == 0 || IsDangerousCommand(command) {
+		return false
+	}
+	command = strings.TrimSpace(command)
+	for _, redirect := range []string{"2>&1", "2>/dev/null", ">/dev/null"} {
+		command = strings.ReplaceAll(command, redirect, " ")
+	}
+	if command == "" || strings.ContainsAny(command, "<>`\n") || strings.Contains(command, "$(") {
+		return false
+	}
+	segments, ok := splitCommandSegments(command)
+	if !ok {
+		return false
+	}
+	for _, args := range segments {
+		if !isSafeSimpleCommand(args) && !hasAllowedPrefix(args, allowlist) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasAllowedPrefix 参数是否以允许列表中的某条命令开头
+func hasAllowedPrefix(args []string, allowlist []string) bool {
+	for _, allowed := range allowlist {
+		prefix := strings.Fields(allowed)
+		if len(prefix) == 0 || len(prefix) > len(args) {
+			continue
+		}
+		matched := true
+		for i, word := range prefix {
+			if args[i] != word {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// splitCommandSegments 按 && || | ; 拆分命令，返回每段的参数列表
+func splitCommandSegments(command string) ([][]string, bool) {
+	var segments [][]string
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote byte
+
+	flushArg := func() {
+		if inArg {
+			args = append(args, current.String())
+			current.Reset()
+			inArg = false
+		}
+	}
+	flushSegment := func() bool {
+		flushArg()
+		if len(args) == 0 {
+			return false
+		}
+		segments = append(segments, args)
+		args = nil
+		return true
+	}
+
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' && i+1 < len(command) {
+				i++
+				current.WriteByte(command[i])
+			} else {
+				current.WriteByte(c)
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			inArg = true
+		case c == '\\':
+			if i+1 < len(command) {
+				i++
+				current.WriteByte(command[i])
+				inArg = true
+			}
+		case c == ' ' || c == '\t':
+			flushArg()
+		case c == '|' || c == ';':
+			if !flushSegment() {
+				return nil, false
+			}
+			if c == '|' && i+1 < len(command) && command[i+1] == '|' {
+				i++
+			}
+		case c == '&':
+			// 只允许 &&，单个 & 会把命令放到后台
+			if i+1 >= len(command) || command[i+1] != '&' {
+				return nil, false
+			}
+			if !flushSegment() {
+				return nil, false
+			}
+			i++
+		default:
+			current.WriteByte(c)
+			inArg = true
+		}
+	}
+	if quote != 0 || !flushSegment() {
+		return nil, false
+	}
+	return segments, true
+}
+
+// isSafeSimpleCommand 判断单条命令（不含管道与连接符）是否安全
+func isSafeSimpleCommand(args []string) bool {
+	name := args[0]
+	// 不允许路径形式的可执行文件（./script、/tmp/ls）及 VAR=value 前缀
+	if strings.ContainsAny(name, "/\\=") {
+		return false
+	}
+	if strings.EqualFold(filepath.Ext(name), ".exe") {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+
+	rule, ok := safeCommands[name]
+	if !ok {
+		return false
+	}
+
+	rest := args[1:]
+	for _, arg := range rest {
+		for _, flag := range rule.forbiddenFlags {
+			if matchesFlag(arg, flag) {
+				return false
+			}
+		}
+	}
+
+	if len(rule.requiredFlags) > 0 {
+		found := false
+		for _, arg := range rest {
+			for _, flag := range rule.requiredFlags {
+				if arg == flag {
+					found = true
+				}
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if rule.subcommands == nil {
+		return true
+	}
+
+	// 子命令必须是第一个参数（不允许 git -c key=value 之类的全局选项）
+	if len(rest) == 0 || !rule.subcommands[rest[0]] {
+		return false
+	}
+	if name == "git" {
+		if allowed, ok := gitListingSubcommands[rest[0]]; ok {
+			return onlyAllowedArgs(rest[1:], allowed)
+		}
+		// git stash 不带参数会暂存改动，只允许 list/show
+		if rest[0] == "stash" {
+			return len(rest) > 1 && (rest[1] == "list" || rest[1] == "show")
+		}
+	}
+	return true
+}
+
+// matchesFlag 判断参数是否命中指定的选项。单字符短选项也匹配合并写法（如 -lw 包含 -w），
+// 单横线长选项（如 find 的 -exec）按前缀匹配
+func matchesFlag(arg, flag string) bool {
+	if arg == flag || strings.HasPrefix(arg, flag+"=") {
+		return true
+	}
+	if strings.HasPrefix(flag, "--") {
+		return false
+	}
+	if len(flag) == 2 {
+		return strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && strings.Contains(arg[1:], flag[1:])
+	}
+	return strings.HasPrefix(arg, flag)
+}
+
+// onlyAllowedArgs 判断参数是否都在允许列表中
+func onlyAllowedArgs(args []string, allowed []string) bool {
+	for _, arg := range args {
+		ok := false
+		for _, candidate := range allowed {
+			if arg == candidate || strings.HasPrefix(arg, candidate+"=") {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
\ No newline at end of file
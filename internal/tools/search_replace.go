@@ -1,18 +1,20 @@
 package tools
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
 // SearchReplaceParams search_replace工具的参数
 type SearchReplaceParams struct {
-	FilePath  string `json:"file_path"`
-	OldString string `json:"old_string"`
-	NewString string `json:"new_string"`
+	FilePath   string `json:"file_path"`
+	OldString  string `json:"old_string"`
+	NewString  string `json:"new_string"`
+	ReplaceAll bool   `json:"replace_all,omitempty"`
+	Occurrence int    `json:"occurrence,omitempty"`
 }
 
 // SearchReplaceResult search_replace工具的返回结果
@@ -21,9 +23,13 @@ type SearchReplaceResult struct {
 	OldString    string `json:"old_string"`
 	NewString    string `json:"new_string"`
 	Replaced     bool   `json:"replaced"`
-	LineNumber   int    `json:"line_number,omitempty"`
+	Occurrences  int    `json:"occurrences"`            // old_string 在文件中出现的次数
+	Replacements int    `json:"replacements,omitempty"` // 实际替换的次数
+	LineNumber   int    `json:"line_number,omitempty"`  // 第一处替换所在的行
+	LineNumbers  []int  `json:"line_numbers,omitempty"` // 多处替换或多处匹配时各处所在的行
 	OriginalLine string `json:"original_line,omitempty"`
 	NewLine      string `json:"new_line,omitempty"`
+	Diff         string `json:"diff,omitempty"` // 替换前后的统一diff
 	Message      string `json:"message"`
 }
 
@@ -45,6 +51,15 @@ func searchReplaceFunction(params map[string]interface{}) (interface{}, error) {
 		return nil, fmt.Errorf("new_string is required")
 	}
 
+	replaceAll, _ := params["replace_all"].(bool)
+	occurrence, hasOccurrence := intParam(params, "occurrence")
+	if hasOccurrence && occurrence < 1 {
+		return nil, fmt.Errorf("occurrence must be 1 or greater (got %d)", occurrence)
+	}
+	if replaceAll && hasOccurrence {
+		return nil, fmt.Errorf("replace_all and occurrence cannot be combined")
+	}
+
 	workDir, _ := params["__work_dir__"].(string)
 
 	// 解析文件路径
@@ -67,97 +82,131 @@ func searchReplaceFunction(params map[string]interface{}) (interface{}, error) {
 	}
 
 	// 检查文件是否存在
-	if _, err := os.Stat(targetPath); os.IsNotExist(err) {
+	if _, err := statWorkspaceFile(targetPath); os.IsNotExist(err) {
 		result.Message = fmt.Sprintf("File not found: %s", targetPath)
 		return result, nil
 	}
 
-	// 读取文件内容
-	file, err := os.Open(targetPath)
+	// 读取文件内容，old_string 可以跨多行
+	data, err := readWorkspaceFile(targetPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
-	}
-	defer file.Close()
-
-	var lines []string
-	var foundLine int = -1
-	var originalLine string
-
-	scanner := bufio.NewScanner(file)
-	lineNumber := 0
-
-	for scanner.Scan() {
-		lineNumber++
-		line := scanner.Text()
-		lines = append(lines, line)
-
-		// 查找第一个匹配的行
-		if foundLine == -1 && strings.Contains(line, oldString) {
-			foundLine = lineNumber
-			originalLine = line
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
+	content := string(data)
+
+	// 查找所有（不重叠的）匹配位置
+	offsets := findOccurrences(content, oldString)
+	result.Occurrences = len(offsets)
 
 	// 如果没有找到匹配项
-	if foundLine == -1 {
+	if len(offsets) == 0 {
 		result.Message = "Old string not found in file"
 		return result, nil
 	}
 
-	// 执行替换（只替换第一个匹配项）
-	lines[foundLine-1] = strings.Replace(lines[foundLine-1], oldString, newString, 1)
-	newLine := lines[foundLine-1]
+	// 选择要替换的匹配：全部、第 N 处，或唯一的一处
+	var selected []int
+	switch {
+	case replaceAll:
+		selected = offsets
+	case hasOccurrence:
+		if occurrence > len(offsets) {
+			result.Message = fmt.Sprintf("Old string occurs only %d time(s) in the file, occurrence %d does not exist", len(offsets), occurrence)
+			result.LineNumbers = lineNumbersAt(content, offsets)
+			return result, nil
+		}
+		selected = offsets[occurrence-1 : occurrence]
+	case len(offsets) > 1:
+		result.LineNumbers = lineNumbersAt(content, offsets)
+		result.Message = fmt.Sprintf("Old string occurs %d times in the file (lines %s); add surrounding context to make it unique, or set occurrence to pick one or replace_all to change every instance",
+			len(offsets), joinInts(result.LineNumbers))
+		return result, nil
+	default:
+		selected = offsets
+	}
+
+	// 执行替换
+	var sb strings.Builder
+	last := 0
+	for _, offset := range selected {
+		sb.WriteString(content[last:offset])
+		sb.WriteString(newString)
+		last = offset + len(oldString)
+	}
+	sb.WriteString(content[last:])
+	updated := sb.String()
 
 	// 写回文件
-	err = writeLinesToFile(targetPath, lines)
-	if err != nil {
+	if err := writeWorkspaceFile(targetPath, []byte(updated), 0644); err != nil {
 		return nil, fmt.Errorf("failed to write file: %w", err)
 	}
 
+	lineNumbers := lineNumbersAt(content, selected)
 	result.Replaced = true
-	result.LineNumber = foundLine
-	result.OriginalLine = originalLine
-	result.NewLine = newLine
-	result.Message = fmt.Sprintf("Successfully replaced text on line %d", foundLine)
+	result.Replacements = len(selected)
+	result.LineNumber = lineNumbers[0]
+	result.OriginalLine = lineAt(content, selected[0])
+	result.NewLine = lineAt(updated, selected[0])
+	result.Diff = editDiff(workDir, targetPath, content, updated, true)
+	if len(selected) > 1 {
+		result.LineNumbers = lineNumbers
+		result.Message = fmt.Sprintf("Successfully replaced %d occurrences (lines %s)", len(selected), joinInts(lineNumbers))
+	} else {
+		result.Message = fmt.Sprintf("Successfully replaced text on line %d", result.LineNumber)
+	}
 
 	return result, nil
 }
 
-// writeLinesToFile 将行写入文件
-func writeLinesToFile(filePath string, lines []string) error {
-	file, err := os.Create(filePath)
-	if err != nil {
-		return err
+// findOccurrences 返回 s 中所有不重叠的 substr 的字节偏移
+func findOccurrences(s, substr string) []int {
+	var offsets []int
+	for start := 0; ; {
+		idx := strings.Index(s[start:], substr)
+		if idx < 0 {
+			return offsets
+		}
+		offsets = append(offsets, start+idx)
+		start += idx + len(substr)
 	}
-	defer file.Close()
-
-	writer := bufio.NewWriter(file)
-	defer writer.Flush()
+}
 
-	for i, line := range lines {
-		if i > 0 {
-			writer.WriteString("\n")
-		}
-		writer.WriteString(line)
+// lineNumbersAt 各字节偏移所在的行号（从1开始）
+func lineNumbersAt(content string, offsets []int) []int {
+	lines := make([]int, len(offsets))
+	line, last := 1, 0
+	for i, offset := range offsets {
+		line += strings.Count(content[last:offset], "\n")
+		last = offset
+		lines[i] = line
 	}
+	return lines
+}
 
-	// 确保文件以换行符结尾（如果原文件有的话）
-	if len(lines) > 0 {
-		writer.WriteString("\n")
+// lineAt 字节偏移所在的整行内容（不含换行符）
+func lineAt(content string, offset int) string {
+	start := strings.LastIndex(content[:offset], "\n") + 1
+	end := strings.Index(content[offset:], "\n")
+	if end < 0 {
+		return strings.TrimSuffix(content[start:], "\r")
 	}
+	return strings.TrimSuffix(content[start:offset+end], "\r")
+}
 
-	return nil
+// joinInts 以逗号连接整数
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ", ")
 }
 
 // NewSearchReplaceTool 创建search_replace工具
 func NewSearchReplaceTool() Tool {
 	schema := ToolSchema{
 		Name:        "search_replace",
-		Description: "Use this tool to propose a search and replace operation on an existing file.\n\nThe tool will replace ONE occurrence of old_string with new_string in the specified file.\n\nCRITICAL REQUIREMENTS FOR USING THIS TOOL:\n\n1. UNIQUENESS: The old_string MUST uniquely identify the specific instance you want to change. This means:\n   - Include AT LEAST 3-5 lines of context BEFORE the change point\n   - Include AT LEAST 3-5 lines of context AFTER the change point\n   - Include all whitespace, indentation, and surrounding code exactly as it appears in the file\n\n2. SINGLE INSTANCE: This tool can only change ONE instance at a time. If you need to change multiple instances:\n   - Make separate calls to this tool for each instance\n   - Each call must uniquely identify its specific instance using extensive context\n\n3. VERIFICATION: Before using this tool:\n   - If multiple instances exist, gather enough context to uniquely identify each one\n   - Plan separate tool calls for each instance",
+		Description: "Use this tool to propose a search and replace operation on an existing file.\n\nBy default the tool replaces the ONE occurrence of old_string with new_string in the specified file. If old_string occurs more than once, nothing is changed and the line numbers of every occurrence are returned.\n\nCRITICAL REQUIREMENTS FOR USING THIS TOOL:\n\n1. UNIQUENESS: Unless replace_all or occurrence is set, the old_string MUST uniquely identify the specific instance you want to change. This means:\n   - Include AT LEAST 3-5 lines of context BEFORE the change point\n   - Include AT LEAST 3-5 lines of context AFTER the change point\n   - Include all whitespace, indentation, and surrounding code exactly as it appears in the file\n\n2. MULTIPLE INSTANCES: To change every instance (e.g. renaming a variable within the file) set replace_all to true. To change one specific instance of a repeated string set occurrence to its 1-based position in the file.\n\n3. VERIFICATION: Before using this tool:\n   - If multiple instances exist, gather enough context to uniquely identify the one you want, or use occurrence or replace_all",
 		InputSchema: map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
@@ -167,12 +216,20 @@ func NewSearchReplaceTool() Tool {
 				},
 				"old_string": map[string]interface{}{
 					"type":        "string",
-					"description": "The text to replace (must be unique within the file, and must match the file contents exactly, including all whitespace and indentation)",
+					"description": "The text to replace (must be unique within the file unless replace_all or occurrence is set, and must match the file contents exactly, including all whitespace and indentation)",
 				},
 				"new_string": map[string]interface{}{
 					"type":        "string",
 					"description": "The edited text to replace the old_string (must be different from the old_string)",
 				},
+				"replace_all": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Replace every occurrence of old_string instead of requiring it to be unique. Defaults to false.",
+				},
+				"occurrence": map[string]interface{}{
+					"type":        "integer",
+					"description": "Replace only the Nth occurrence of old_string (1-based, counted from the start of the file) instead of requiring it to be unique.",
+				},
 			},
 			"required": []string{"file_path", "old_string", "new_string"},
 		},
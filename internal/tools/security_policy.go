@@ -0,0 +1,362 @@
+package tools
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// SecurityPolicy 编辑与删除文件的安全策略。路径为 glob（支持 **），不含通配符的路径同时匹配其下的所有文件；
+// 绝对路径按绝对路径匹配，相对路径按相对工作目录的路径匹配。匹配 allow_paths 的文件总是允许
+type SecurityPolicy struct {
+	AllowPaths      []string `yaml:"allow_paths,omitempty" json:"allow_paths,omitempty"`
+	DenyPaths       []string `yaml:"deny_paths,omitempty" json:"deny_paths,omitempty"`
+	AllowExtensions []string `yaml:"allow_extensions,omitempty" json:"allow_extensions,omitempty"`
+	DenyExtensions  []string `yaml:"deny_extensions,omitempty" json:"deny_extensions,omitempty"`
+	NoDefaults      bool     `yaml:"no_defaults,omitempty" json:"no_defaults,omitempty"` // 不使用内置的拒绝列表
+}
+
+// defaultDenyPaths 内置拒绝的系统目录与系统文件，以及 git 仓库目录：.git/config 中的 core.fsmonitor、
+// diff.external、core.pager 等会在自动执行的 git status/diff/log 中运行任意命令
+var defaultDenyPaths = []string{
+	"/etc", "/bin", "/sbin", "/usr/bin", "/usr/sbin", "/boot", "/sys", "/proc", "/dev",
+	`C:\Windows`, `C:\Program Files`, `C:\Program Files (x86)`, `C:\System32`,
+	"**/boot.ini", "**/ntldr", "**/bootmgr", "**/pagefile.sys", "**/hiberfil.sys", "**/autoexec.bat", "**/config.sys",
+	"**/.git", "**/.git/**",
+}
+
+// defaultDenyExtensions 内置拒绝的可执行文件与脚本扩展名
+var defaultDenyExtensions = []string{
+	".exe", ".dll", ".sys", ".bat", ".cmd", ".com", ".scr",
+	".pif", ".application", ".gadget", ".msi", ".msp", ".msc",
+}
+
+// caseInsensitivePaths 当前系统的文件路径默认不区分大小写（Windows、macOS），路径规则按不区分大小写匹配
+var caseInsensitivePaths = runtime.GOOS == "windows" || runtime.GOOS == "darwin"
+
+// pathRule 编译后的路径规则
+type pathRule struct {
+	pattern  string
+	re       *regexp.Regexp
+	absolute bool // 按绝对路径匹配
+	anywhere bool // 以 **/ 开头，工作目录外的文件也按绝对路径匹配
+}
+
+// compiledPolicy 编译后的安全策略
+type compiledPolicy struct {
+	allowPaths      []pathRule
+	denyPaths       []pathRule
+	allowExtensions map[string]bool
+	denyExtensions  map[string]bool
+}
+
+// securityState 当前生效的安全策略
+var securityState = struct {
+	sync.RWMutex
+	policy *compiledPolicy
+}{}
+
+// ValidateSecurityPolicy 检查安全策略中的路径与扩展名
+func ValidateSecurityPolicy(policy SecurityPolicy) error {
+	_, err := compileSecurityPolicy(policy)
+	return err
+}
+
+// SetSecurityPolicy 设置编辑与删除文件的安全策略，配置的拒绝列表追加在内置列表之后
+func SetSecurityPolicy(policy SecurityPolicy) error {
+	compiled, err := compileSecurityPolicy(policy)
+	if err != nil {
+		return err
+	}
+	securityState.Lock()
+	defer securityState.Unlock()
+	securityState.policy = compiled
+	return nil
+}
+
+// currentSecurityPolicy 当前策略，未设置时为内置策略
+func currentSecurityPolicy() *compiledPolicy {
+	securityState.RLock()
+	policy := securityState.policy
+	securityState.RUnlock()
+	if policy != nil {
+		return policy
+	}
+	policy, _ = compileSecurityPolicy(SecurityPolicy{})
+	securityState.Lock()
+	securityState.policy = policy
+	securityState.Unlock()
+	return policy
+}
+
+// compileSecurityPolicy 编译路径规则与扩展名列表
+func compileSecurityPolicy(policy SecurityPolicy) (*compiledPolicy, error) {
+	denyPaths := policy.DenyPaths
+	denyExtensions := policy.DenyExtensions
+	if !policy.NoDefaults {
+		denyPaths = append(append([]string{}, defaultDenyPaths...), denyPaths...)
+		denyExtensions = append(append([]string{}, defaultDenyExtensions...), denyExtensions...)
+	}
+
+	compiled := &compiledPolicy{
+		allowExtensions: make(map[string]bool),
+		denyExtensions:  make(map[string]bool),
+	}
+	var err error
+	if compiled.allowPaths, err = compilePathRules("allow_paths", policy.AllowPaths); err != nil {
+		return nil, err
+	}
+	if compiled.denyPaths, err = compilePathRules("deny_paths", denyPaths); err != nil {
+		return nil, err
+	}
+	for key, list := range map[string][]string{"allow_extensions": policy.AllowExtensions, "deny_extensions": denyExtensions} {
+		for _, ext := range list {
+			normalized := normalizeExtension(ext)
+			if normalized == "." || strings.ContainsAny(normalized, `/\`) {
+				return nil, fmt.Errorf("security_policy.%s: invalid extension %q", key, ext)
+			}
+			if key == "allow_extensions" {
+				compiled.allowExtensions[normalized] = true
+			} else {
+				compiled.denyExtensions[normalized] = true
+			}
+		}
+	}
+	return compiled, nil
+}
+
+// compilePathRules 编译路径 glob，不含通配符的路径同时匹配其下的文件
+func compilePathRules(key string, patterns []string) ([]pathRule, error) {
+	rules := make([]pathRule, 0, len(patterns))
+	for _, pattern := range patterns {
+		if strings.TrimSpace(pattern) == "" {
+			return nil, fmt.Errorf("security_policy.%s: empty path", key)
+		}
+		glob := strings.TrimSuffix(strings.ReplaceAll(pattern, `\`, "/"), "/")
+		var expr string
+		if strings.ContainsAny(glob, "*?[{") {
+			re, err := globToRegexp(glob)
+			if err != nil {
+				return nil, fmt.Errorf("security_policy.%s: invalid pattern %q: %w", key, pattern, err)
+			}
+			expr = re.String()
+		} else {
+			expr = "^" + regexp.QuoteMeta(glob) + "(?:/.*)?$"
+		}
+		if caseInsensitivePaths {
+			expr = "(?i)" + expr
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("security_policy.%s: invalid pattern %q: %w", key, pattern, err)
+		}
+		rules = append(rules, pathRule{
+			pattern:  pattern,
+			re:       re,
+			absolute: strings.HasPrefix(glob, "/") || (len(glob) > 1 && glob[1] == ':'),
+			anywhere: strings.HasPrefix(glob, "**/"),
+		})
+	}
+	return rules, nil
+}
+
+// normalizeExtension 扩展名统一为小写并带点
+func normalizeExtension(ext string) string {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+// matchPathRules 返回第一条匹配候选路径的规则
+func matchPathRules(rules []pathRule, c securityPath) (pathRule, bool) {
+	for _, rule := range rules {
+		switch {
+		case rule.absolute || (rule.anywhere && c.rel == ""):
+			if rule.re.MatchString(c.abs) {
+				return rule, true
+			}
+		case c.rel != "" && rule.re.MatchString(c.rel):
+			return rule, true
+		}
+	}
+	return pathRule{}, false
+}
+
+// securityPath 参与安全检查的路径：绝对路径与相对工作目录的路径（工作目录外为空），都使用 / 分隔
+type securityPath struct {
+	abs string
+	rel string
+}
+
+// securityPaths 规范化后的候选路径：字面的绝对路径与解析符号链接后的路径，两者都要通过检查，
+// 这样指向系统目录的符号链接、以及 macOS 上 /etc 这类本身是符号链接的目录都能被拒绝
+func securityPaths(path, workDir string) ([]securityPath, error) {
+	absPath, err := absSecurityPath(path)
+	if err != nil {
+		return nil, err
+	}
+	paths := []string{absPath}
+	if resolved := resolveSymlinks(absPath); resolved != absPath {
+		paths = append(paths, resolved)
+	}
+	var workDirs []string
+	if workDir != "" {
+		if absWorkDir, err := absSecurityPath(workDir); err == nil {
+			workDirs = append(workDirs, absWorkDir)
+			if resolved := resolveSymlinks(absWorkDir); resolved != absWorkDir {
+				workDirs = append(workDirs, resolved)
+			}
+		}
+	}
+
+	candidates := make([]securityPath, 0, len(paths))
+	for _, p := range paths {
+		c := securityPath{abs: filepath.ToSlash(p)}
+		for _, dir := range workDirs {
+			if rel, ok := relSecurityPath(dir, p); ok {
+				c.rel = rel
+				break
+			}
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, nil
+}
+
+// absSecurityPath 绝对路径。Windows 上去掉 \\?\ 前缀，并去掉每一级名称末尾的点和空格（系统创建文件时会忽略它们，
+// 否则 "a.exe." 能绕过扩展名检查）
+func absSecurityPath(path string) (string, error) {
+	if runtime.GOOS == "windows" {
+		path = strings.TrimPrefix(path, `\\?\`)
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	if runtime.GOOS != "windows" {
+		return absPath, nil
+	}
+	volume := filepath.VolumeName(absPath)
+	parts := strings.Split(absPath[len(volume):], string(filepath.Separator))
+	for i, part := range parts {
+		if trimmed := strings.TrimRight(part, ". "); trimmed != "" {
+			parts[i] = trimmed
+		}
+	}
+	return volume + strings.Join(parts, string(filepath.Separator)), nil
+}
+
+// resolveSymlinks 解析路径中的符号链接；路径不存在时解析最近的已存在的上级目录
+func resolveSymlinks(absPath string) string {
+	var rest []string
+	for dir := absPath; ; {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(append([]string{resolved}, rest...)...)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return absPath
+		}
+		rest = append([]string{filepath.Base(dir)}, rest...)
+		dir = parent
+	}
+}
+
+// relSecurityPath 相对 dir 的路径，path 不在 dir 下时返回 false；不区分大小写的系统上按小写比较（规则也不区分大小写）
+func relSecurityPath(dir, path string) (string, bool) {
+	base, target := dir, path
+	if caseInsensitivePaths {
+		base, target = strings.ToLower(base), strings.ToLower(target)
+	}
+	if filepath.VolumeName(base) != filepath.VolumeName(target) {
+		return "", false
+	}
+	rel, err := filepath.Rel(base, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// 安全策略检查的操作
+const (
+	policyWrite  = "writing"
+	policyDelete = "deleting"
+)
+
+// policyAllowsPath 路径是否匹配安全策略的 allow_paths：字面路径与解析符号链接后的路径都要匹配
+func policyAllowsPath(path, workDir string) bool {
+	candidates, err := securityPaths(path, workDir)
+	if err != nil {
+		return false
+	}
+	policy := currentSecurityPolicy()
+	for _, c := range candidates {
+		if _, ok := matchPathRules(policy.allowPaths, c); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// checkSecurityPolicy 按安全策略检查对文件的操作（policyWrite 或 policyDelete）。每个候选路径都要通过：
+// 匹配 allow_paths 的不再检查，否则不能匹配 deny_paths 或拒绝的扩展名，
+// 这样 allow_paths 中指向被拒绝位置的符号链接不能绕过检查
+func checkSecurityPolicy(action, path, workDir string) error {
+	candidates, err := securityPaths(path, workDir)
+	if err != nil {
+		return err
+	}
+	displayPath := filepath.FromSlash(candidates[0].abs)
+
+	policy := currentSecurityPolicy()
+	for _, c := range candidates {
+		if _, ok := matchPathRules(policy.allowPaths, c); ok {
+			continue
+		}
+		if rule, ok := matchPathRules(policy.denyPaths, c); ok {
+			return fmt.Errorf("%s %s is denied by the security policy (deny_paths %q); allow it with security_policy.allow_paths in the config file", action, displayPath, rule.pattern)
+		}
+		ext := strings.ToLower(filepath.Ext(c.abs))
+		if ext != "" && policy.denyExtensions[ext] && !policy.allowExtensions[ext] {
+			return fmt.Errorf("%s %s files is denied by the security policy; allow it with security_policy.allow_extensions in the config file", action, ext)
+		}
+	}
+	return nil
+}
+
+// windowsReservedNames Windows 保留的设备名，带任何扩展名都不能作为文件名
+var windowsReservedNames = map[string]bool{
+	"con": true, "prn": true, "aux": true, "nul": true,
+	"com1": true, "com2": true, "com3": true, "com4": true, "com5": true, "com6": true, "com7": true, "com8": true, "com9": true,
+	"lpt1": true, "lpt2": true, "lpt3": true, "lpt4": true, "lpt5": true, "lpt6": true, "lpt7": true, "lpt8": true, "lpt9": true,
+}
+
+// checkFileName 检查新建文件的文件名：不能包含 Windows 文件名中非法的字符（":" 在 Windows 上还表示备用数据流），
+// 也不能是 Windows 保留的设备名。盘符属于卷名，不参与检查
+func checkFileName(path string) error {
+	fileName := filepath.Base(strings.TrimPrefix(path, filepath.VolumeName(path)))
+	for _, char := range []string{"<", ">", ":", "\"", "|", "?", "*"} {
+		if strings.Contains(fileName, char) {
+			return fmt.Errorf("filename contains dangerous character: %s", char)
+		}
+	}
+	for _, r := range fileName {
+		if r < 0x20 {
+			return fmt.Errorf("filename contains control character %q", r)
+		}
+	}
+	stem := strings.ToLower(strings.TrimRight(fileName, ". "))
+	if i := strings.IndexByte(stem, '.'); i >= 0 {
+		stem = stem[:i]
+	}
+	if windowsReservedNames[strings.TrimRight(stem, " ")] {
+		return fmt.Errorf("filename %s is a reserved device name on Windows", fileName)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestSecurityPolicyAllowedSymlink allow_paths 中指向被拒绝目录的符号链接不能绕过 deny_paths
+func TestSecurityPolicyAllowedSymlink(t *testing.T) {
+	workDir := t.TempDir()
+	for _, dir := range []string{"secrets", "public"} {
+		if err := os.Mkdir(filepath.Join(workDir, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for link, target := range map[string]string{"shortcut": "secrets", "docs": "public"} {
+		if err := os.Symlink(filepath.Join(workDir, target), filepath.Join(workDir, link)); err != nil {
+			t.Skipf("symlinks not supported: %v", err)
+		}
+	}
+	policy := SecurityPolicy{AllowPaths: []string{"shortcut", "docs"}, DenyPaths: []string{"secrets", "public"}}
+	if err := SetSecurityPolicy(policy); err != nil {
+		t.Fatal(err)
+	}
+	defer SetSecurityPolicy(SecurityPolicy{})
+
+	err := checkSecurityPolicy(policyWrite, filepath.Join(workDir, "shortcut", "key.txt"), workDir)
+	if err == nil || !strings.Contains(err.Error(), `deny_paths "secrets"`) {
+		t.Errorf("writing through an allowed symlink to a denied directory: error = %v, want a deny_paths denial", err)
+	}
+	if policyAllowsPath(filepath.Join(workDir, "shortcut", "key.txt"), workDir) {
+		t.Errorf("policyAllowsPath() = true for a symlink to a directory that is not allowed")
+	}
+
+	// 链接与目标都被允许时通过
+	policy.AllowPaths = append(policy.AllowPaths, "public")
+	if err := SetSecurityPolicy(policy); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkSecurityPolicy(policyWrite, filepath.Join(workDir, "docs", "index.md"), workDir); err != nil {
+		t.Errorf("writing through an allowed symlink to an allowed directory: %v", err)
+	}
+}
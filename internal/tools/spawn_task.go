@@ -0,0 +1,198 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// 子代理的默认值与上限
+const (
+	defaultSubtaskIterations = 15
+	maxSubtaskIterations     = 40
+	maxSubtaskSummaryBytes   = 20000
+)
+
+// 子代理可用的工具集
+const (
+	ToolsetReadOnly = "read_only" // 只读工具
+	ToolsetEdit     = "edit"      // 只读工具与文件编辑工具，不能执行命令
+	ToolsetAll      = "all"       // 除 spawn_task 外的所有工具
+)
+
+// SpawnTaskParams spawn_task工具的参数
+type SpawnTaskParams struct {
+	Task          string `json:"task"`
+	Context       string `json:"context,omitempty"`
+	Tools         string `json:"tools,omitempty"`
+	MaxIterations int    `json:"max_iterations,omitempty"`
+	Explanation   string `json:"explanation,omitempty"`
+}
+
+// SpawnTaskResult spawn_task工具的返回结果
+type SpawnTaskResult struct {
+	Task      string   `json:"task"`
+	Tools     string   `json:"tools"`
+	Summary   string   `json:"summary"`
+	ToolCalls []string `json:"tool_calls,omitempty"`
+	Truncated bool     `json:"truncated,omitempty"`
+}
+
+// SubtaskRequest 交给子代理的子任务
+type SubtaskRequest struct {
+	Task          string
+	Context       string
+	MaxIterations int
+	Manager       ToolManager // 子代理可用的工具，与父代理共享工作目录与环境变量
+}
+
+// SubtaskReport 子代理的结果
+type SubtaskReport struct {
+	Summary   string   // 子代理的最终回答
+	ToolCalls []string // 子代理的工具调用，如 "read_file main.go: done"
+}
+
+// SubtaskRunner 以独立的对话运行子代理。tools 包不依赖模型客户端，由命令层提供
+type SubtaskRunner func(request SubtaskRequest) (*SubtaskReport, error)
+
+// subtaskState 子代理运行器，未设置时不注册 spawn_task
+var subtaskState = struct {
+	sync.RWMutex
+	runner SubtaskRunner
+}{}
+
+// SetSubtaskRunner 设置子代理运行器（需在注册工具前完成）
+func SetSubtaskRunner(runner SubtaskRunner) {
+	subtaskState.Lock()
+	defer subtaskState.Unlock()
+	subtaskState.runner = runner
+}
+
+// getSubtaskRunner 获取子代理运行器
+func getSubtaskRunner() SubtaskRunner {
+	subtaskState.RLock()
+	defer subtaskState.RUnlock()
+	return subtaskState.runner
+}
+
+// spawnTaskFunction 启动子代理工具函数
+func spawnTaskFunction(params map[string]interface{}) (interface{}, error) {
+	// 解析参数
+	task, ok := params["task"].(string)
+	if !ok || strings.TrimSpace(task) == "" {
+		return nil, fmt.Errorf("task is required")
+	}
+	taskContext, _ := params["context"].(string)
+	toolset, _ := params["tools"].(string)
+	if toolset == "" {
+		toolset = ToolsetReadOnly
+	}
+	maxIterations := defaultSubtaskIterations
+	if n, ok := intParam(params, "max_iterations"); ok && n > 0 {
+		maxIterations = n
+	}
+	if maxIterations > maxSubtaskIterations {
+		maxIterations = maxSubtaskIterations
+	}
+	workDir, _ := params["__work_dir__"].(string)
+	env, _ := params["__env__"].(map[string]string)
+
+	runner := getSubtaskRunner()
+	if runner == nil {
+		return nil, fmt.Errorf("sub-agents are not available in this mode")
+	}
+
+	manager, err := newSubtaskManager(toolset, workDir, env)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := runner(SubtaskRequest{
+		Task:          task,
+		Context:       taskContext,
+		MaxIterations: maxIterations,
+		Manager:       manager,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sub-agent failed: %w", err)
+	}
+
+	result := &SpawnTaskResult{
+		Task:      task,
+		Tools:     toolset,
+		Summary:   strings.TrimSpace(report.Summary),
+		ToolCalls: report.ToolCalls,
+	}
+	if result.Summary == "" {
+		result.Summary = fmt.Sprintf("The sub-agent stopped after %d steps without a final answer.", maxIterations)
+	}
+	if len(result.Summary) > maxSubtaskSummaryBytes {
+		result.Summary = truncateUTF8(result.Summary, maxSubtaskSummaryBytes)
+		result.Truncated = true
+	}
+	return result, nil
+}
+
+// newSubtaskManager 为子代理创建只包含指定工具集的工具管理器
+func newSubtaskManager(toolset, workDir string, env map[string]string) (ToolManager, error) {
+	registry := NewRegistry()
+	registry.subtask = true
+
+	if err := registry.RegisterToolset(toolset); err != nil {
+		return nil, err
+	}
+	registry.SetWorkDirectory(workDir)
+	registry.SetEnvironment(env)
+	return registry.GetManager(), nil
+}
+
+// truncateUTF8 截断到不超过 n 字节，不截断多字节字符
+func truncateUTF8(text string, n int) string {
+	if len(text) <= n {
+		return text
+	}
+	for n > 0 && text[n]&0xC0 == 0x80 {
+		n--
+	}
+	return text[:n]
+}
+
+// NewSpawnTaskTool 创建spawn_task工具
+func NewSpawnTaskTool() Tool {
+	schema := ToolSchema{
+		Name:        "spawn_task",
+		Description: "Launch a sub-agent with its own, fresh conversation to handle one isolated subtask (e.g. \"investigate how module X handles retries\" or \"update the call sites of Foo in package bar\"), and get back its final summary. Use it to keep large explorations out of your own context, and call it several times in the same turn to work on independent subtasks in parallel. The sub-agent does not see this conversation: describe the task completely and pass what it needs to know in context. By default it can only read the workspace; give it edit or all tools only for clearly separated changes.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"task": map[string]interface{}{
+					"type":        "string",
+					"description": "Complete description of the subtask and of the answer expected back (e.g. file paths and line numbers of the findings)",
+				},
+				"context": map[string]interface{}{
+					"type":        "string",
+					"description": "Background the sub-agent needs, such as relevant files, decisions already made or constraints",
+				},
+				"tools": map[string]interface{}{
+					"type":        "string",
+					"description": "Tools of the sub-agent: read_only (default) reads and searches the workspace, edit can also modify files, all can also run commands",
+					"enum":        []string{ToolsetReadOnly, ToolsetEdit, ToolsetAll},
+				},
+				"max_iterations": map[string]interface{}{
+					"type":        "integer",
+					"description": "Maximum number of model calls of the sub-agent (default 15, max 40)",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+			"required": []string{"task"},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: spawnTaskFunction,
+	}
+}
\ No newline at end of file
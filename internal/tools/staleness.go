@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// readHashes 记录代理读取或写入文件时的内容哈希，编辑前比较，防止覆盖期间的外部修改
+type readHashes struct {
+	mu     sync.Mutex
+	hashes map[string]string // 绝对路径 -> SHA-256，文件不存在时为空字符串
+}
+
+// StaleFileError 文件在代理上次读取后被修改
+type StaleFileError struct {
+	Path string
+}
+
+func (e *StaleFileError) Error() string {
+	return fmt.Sprintf("file changed since read: %s was modified outside the agent after it was last read; read it again and redo the edit on the current content", e.Path)
+}
+
+// fileHash 文件当前内容的哈希，试运行时为计划后的内容；文件不存在时返回空字符串
+func fileHash(path string) (string, error) {
+	file, err := openWorkspaceFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// record 记录文件的当前内容
+func (r *readHashes) record(paths ...string) {
+	for _, path := range paths {
+		hash, err := fileHash(path)
+		r.mu.Lock()
+		if r.hashes == nil {
+			r.hashes = make(map[string]string)
+		}
+		if err != nil {
+			delete(r.hashes, path)
+		} else {
+			r.hashes[path] = hash
+		}
+		r.mu.Unlock()
+	}
+}
+
+// check 文件读取过且内容已不同时返回 StaleFileError，没有读取过的文件不检查
+func (r *readHashes) check(path string) error {
+	r.mu.Lock()
+	known, ok := r.hashes[path]
+	r.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	hash, err := fileHash(path)
+	if err != nil || hash == known {
+		return nil
+	}
+	return &StaleFileError{Path: path}
+}
+
+// readTargets 读取类工具读取的文件（绝对路径）
+func readTargets(name string, params map[string]interface{}, workDir string) []string {
+	switch name {
+	case "read_file", "read_notebook":
+		if _, path := changeTarget(params, workDir); path != "" {
+			return []string{path}
+		}
+	case "read_files":
+		entries, err := parseReadFilesEntries(params["files"])
+		if err != nil {
+			return nil
+		}
+		paths := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			_, path := changeTarget(map[string]interface{}{"target_file": entry.TargetFile}, workDir)
+			paths = append(paths, path)
+		}
+		return paths
+	}
+	return nil
+}
+
+// checksStaleness 执行前需要检查文件是否在读取后被修改的工具
+func checksStaleness(name string) bool {
+	return IsEditTool(name) || name == "delete_file"
+}
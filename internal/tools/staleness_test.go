@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestEditAfterReplaceAll replace_all 写入的文件之后可以直接编辑，不会被当作读取后被外部修改
+func TestEditAfterReplaceAll(t *testing.T) {
+	workDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workDir, "a.go"), []byte("oldName()\nkeep()\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	registry := NewRegistry()
+	if err := registry.RegisterAllTools(); err != nil {
+		t.Fatal(err)
+	}
+	registry.SetWorkDirectory(workDir)
+	manager := registry.GetManager()
+
+	execute := func(name string, params map[string]interface{}) *ToolResult {
+		t.Helper()
+		result, err := manager.ExecuteTool(name, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Success {
+			t.Fatalf("%s failed: %s", name, result.Error)
+		}
+		return result
+	}
+
+	execute("read_file", map[string]interface{}{"target_file": "a.go", "should_read_entire_file": true})
+	preview := execute("replace_all", map[string]interface{}{"pattern": "oldName", "replacement": "newName", "include_pattern": "*.go"})
+	token := preview.Result.(*ReplaceAllResult).ConfirmToken
+	execute("replace_all", map[string]interface{}{"pattern": "oldName", "replacement": "newName", "include_pattern": "*.go", "dry_run": false, "confirm_token": token})
+	execute("search_replace", map[string]interface{}{"file_path": "a.go", "old_string": "keep()", "new_string": "kept()"})
+
+	data, err := os.ReadFile(filepath.Join(workDir, "a.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "newName()\nkept()\n" {
+		t.Errorf("a.go = %q", data)
+	}
+	edited := manager.(*DefaultToolManager).TakeEditedFiles()
+	if len(edited) != 1 || edited[0].Path != "a.go" {
+		t.Errorf("edited files = %+v, want a.go", edited)
+	}
+}
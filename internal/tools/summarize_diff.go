@@ -0,0 +1,136 @@
+package tools
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"openCursor/internal/diffsummary"
+)
+
+// maxDiffSummaryBudget 摘要token预算的上限
+const maxDiffSummaryBudget = 16000
+
+// SummarizeDiffResult summarize_diff工具的返回结果
+type SummarizeDiffResult struct {
+	Source string `json:"source"` // 摘要的是哪些改动
+	*diffsummary.Summary
+}
+
+// summarizeDiffFunction 分层摘要diff的工具函数
+func summarizeDiffFunction(params map[string]interface{}) (interface{}, error) {
+	ref, _ := params["ref"].(string)
+	staged, _ := params["staged"].(bool)
+	pathspec, _ := params["path"].(string)
+	diffFile, _ := params["diff_file"].(string)
+	workDir, _ := params["__work_dir__"].(string)
+	budget, ok := intParam(params, "token_budget")
+	if !ok || budget <= 0 {
+		budget = diffsummary.DefaultTokenBudget
+	}
+	budget = min(budget, maxDiffSummaryBudget)
+
+	var diff, source string
+	if diffFile != "" {
+		if ref != "" || staged || pathspec != "" {
+			return nil, fmt.Errorf("diff_file cannot be combined with ref, staged or path")
+		}
+		target := diffFile
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(workDir, target)
+		}
+		content, err := readWorkspaceFile(target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read diff file: %w", err)
+		}
+		diff, source = string(content), diffFile
+	} else {
+		if strings.HasPrefix(ref, "-") {
+			return nil, fmt.Errorf("invalid ref %q", ref)
+		}
+		if staged && ref != "" {
+			return nil, fmt.Errorf("staged cannot be combined with ref")
+		}
+		args := []string{"diff", "--no-color", "--no-ext-diff", "-M"}
+		switch {
+		case staged:
+			args = append(args, "--cached")
+			source = "staged changes"
+		case ref == "":
+			args = append(args, "HEAD")
+			source = "uncommitted changes"
+		case strings.Contains(ref, ".."):
+			args = append(args, ref)
+			source = "changes in " + ref
+		default:
+			// 与分叉点比较，只包含当前分支引入的改动
+			base, err := runGit(workDir, "merge-base", ref, "HEAD")
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, strings.TrimSpace(base))
+			source = "changes since " + ref
+		}
+		if pathspec != "" {
+			rel, err := gitRelativePath(workDir, pathspec)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, "--", rel)
+		}
+		output, err := runGit(workDir, args...)
+		if err != nil {
+			return nil, err
+		}
+		diff = output
+	}
+
+	files := diffsummary.Parse(diff)
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no changes found in %s", source)
+	}
+	return &SummarizeDiffResult{Source: source, Summary: diffsummary.Summarize(files, budget)}, nil
+}
+
+// NewSummarizeDiffTool 创建summarize_diff工具
+func NewSummarizeDiffTool() Tool {
+	schema := ToolSchema{
+		Name:        "summarize_diff",
+		Description: "Summarize a diff that is too large to read in full, within a token budget: the totals, then every directory (package) and file with its added and deleted lines, ordered by size, then as the budget allows the functions and types each file adds, removes (+/-) or modifies (~), and excerpts of the changed lines. Files that do not fit are counted per directory. By default summarizes uncommitted changes; ref takes a branch (changes since the merge base) or a range a..b. Use it to review or write commit messages for large changes, then read the parts that matter with git diff on single files.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"ref": map[string]interface{}{
+					"type":        "string",
+					"description": "A commit range such as main..feature or v1.2..HEAD, or a branch to compare with its merge base. Omit for uncommitted changes against HEAD",
+				},
+				"staged": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Summarize the staged changes instead (git diff --cached)",
+				},
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Only include changes under this file or directory",
+				},
+				"diff_file": map[string]interface{}{
+					"type":        "string",
+					"description": "Summarize a unified diff or patch file instead of the git repository",
+				},
+				"token_budget": map[string]interface{}{
+					"type":        "integer",
+					"description": fmt.Sprintf("Approximate size of the summary in tokens (default %d, max %d)", diffsummary.DefaultTokenBudget, maxDiffSummaryBudget),
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+			"required": []string{},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: summarizeDiffFunction,
+	}
+}
@@ -0,0 +1,146 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// syntaxCheckTimeout 外部语法检查命令的超时
+const syntaxCheckTimeout = 10 * time.Second
+
+// maxSyntaxErrors 结果中最多报告的语法错误数
+const maxSyntaxErrors = 10
+
+// syntaxCheck 编辑工具执行后是否检查目标文件的语法
+var syntaxCheck bool
+
+// SetSyntaxCheck 设置编辑后是否检查语法（Go、JSON、JavaScript、Python），错误加入工具结果的 syntax_error
+func SetSyntaxCheck(enabled bool) {
+	syntaxCheck = enabled
+}
+
+// syntaxCheckers 按扩展名的外部检查命令，{file} 替换为待检查的文件；解释器不存在时跳过
+var syntaxCheckers = map[string][]string{
+	".js":  {"node", "--check", "{file}"},
+	".mjs": {"node", "--check", "{file}"},
+	".cjs": {"node", "--check", "{file}"},
+	".py":  {"python3", "-c", pythonSyntaxCheck, "{file}"},
+}
+
+// pythonSyntaxCheck 只编译不执行、不写 .pyc，输出 文件:行:列: 错误
+const pythonSyntaxCheck = `import sys
+try:
+    compile(open(sys.argv[1], "rb").read(), sys.argv[1], "exec")
+except SyntaxError as e:
+    print("%s:%s:%s: %s" % (e.filename, e.lineno, e.offset, e.msg))
+    sys.exit(1)`
+
+// checkSyntax 检查文件内容的语法，没有错误或不支持该语言时返回空字符串
+func checkSyntax(path string, content []byte) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	switch ext {
+	case ".go":
+		return checkGoSyntax(path, content)
+	case ".json", ".ipynb":
+		return checkJSONSyntax(content)
+	}
+	if command, ok := syntaxCheckers[ext]; ok {
+		return runSyntaxChecker(command, path, content)
+	}
+	return ""
+}
+
+// checkGoSyntax 用 go/parser 检查Go源文件
+func checkGoSyntax(path string, content []byte) string {
+	_, err := parser.ParseFile(token.NewFileSet(), path, content, parser.AllErrors)
+	if err == nil {
+		return ""
+	}
+	var list scanner.ErrorList
+	if !errors.As(err, &list) {
+		return err.Error()
+	}
+	lines := make([]string, 0, maxSyntaxErrors+1)
+	for i, e := range list {
+		if i == maxSyntaxErrors {
+			lines = append(lines, fmt.Sprintf("... and %d more errors", len(list)-maxSyntaxErrors))
+			break
+		}
+		lines = append(lines, e.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// checkJSONSyntax 检查JSON文件，报告出错的行与列
+func checkJSONSyntax(content []byte) string {
+	var value interface{}
+	err := json.Unmarshal(content, &value)
+	if err == nil {
+		return ""
+	}
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		return err.Error()
+	}
+	before := content[:syntaxErr.Offset]
+	line := strings.Count(string(before), "\n") + 1
+	column := len(before) - strings.LastIndex(string(before), "\n")
+	return fmt.Sprintf("%d:%d: %v", line, column, err)
+}
+
+// runSyntaxChecker 将内容写入临时文件后运行外部检查命令（试运行时文件不在磁盘上）
+func runSyntaxChecker(command []string, path string, content []byte) string {
+	if _, err := exec.LookPath(command[0]); err != nil {
+		return ""
+	}
+	tmp, err := os.CreateTemp("", "opencursor-syntax-*"+filepath.Ext(path))
+	if err != nil {
+		return ""
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(content)
+	tmp.Close()
+	if err != nil {
+		return ""
+	}
+
+	args := make([]string, 0, len(command)-1)
+	for _, arg := range command[1:] {
+		args = append(args, strings.ReplaceAll(arg, "{file}", tmp.Name()))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), syntaxCheckTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, command[0], args...).CombinedOutput()
+	if err == nil || ctx.Err() != nil {
+		return ""
+	}
+	// 去掉 node 输出中的调用栈与版本行
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(string(output), tmp.Name(), path), "\n") {
+		if strings.HasPrefix(line, "    at ") || strings.HasPrefix(line, "Node.js v") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// withSyntaxError 在工具结果中加入 syntax_error 字段
+func withSyntaxError(result interface{}, message string) interface{} {
+	fields := map[string]interface{}{}
+	if data, err := json.Marshal(result); err == nil {
+		json.Unmarshal(data, &fields)
+	}
+	fields["syntax_error"] = message + "\nThe edited file no longer parses; fix the syntax error before continuing."
+	return fields
+}
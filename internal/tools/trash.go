@@ -0,0 +1,337 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// trashManifestName 回收站中每个会话目录下记录删除项的文件
+const trashManifestName = "manifest.json"
+
+// trashExternalDir 工作目录外的文件在会话目录中的存放位置
+const trashExternalDir = "_external"
+
+// TrashEntry 回收站中的一个被删除的文件或目录
+type TrashEntry struct {
+	OriginalPath string    `json:"original_path"` // 删除前的绝对路径
+	TrashPath    string    `json:"trash_path"`    // 相对会话目录的路径，使用 /
+	Dir          bool      `json:"dir,omitempty"`
+	DeletedAt    time.Time `json:"deleted_at"`
+}
+
+// TrashSession 回收站中一个会话删除的内容
+type TrashSession struct {
+	ID      string       `json:"id"`
+	Entries []TrashEntry `json:"entries"`
+}
+
+// trashState 当前会话的回收站，删除时移入 .opencursor/trash/<session>/
+var trashState = struct {
+	sync.Mutex
+	session string
+}{}
+
+// SetTrashSession 设置删除的文件所属的会话，为空时按进程启动时间生成
+func SetTrashSession(id string) {
+	trashState.Lock()
+	defer trashState.Unlock()
+	trashState.session = id
+}
+
+// trashSession 当前会话ID，调用时需持有锁
+func trashSession() string {
+	if trashState.session == "" {
+		trashState.session = time.Now().Format("20060102-150405")
+	}
+	return trashState.session
+}
+
+// TrashDir 工作目录的回收站目录
+func TrashDir(workDir string) string {
+	return filepath.Join(workDir, ".opencursor", "trash")
+}
+
+// inTrashPath 路径是否为回收站、在回收站中或包含回收站，比较解析符号链接后的路径
+func inTrashPath(path, workDir string) bool {
+	trash, err := filepath.Abs(TrashDir(workDir))
+	if err != nil {
+		return false
+	}
+	target, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	trash, target = resolveSymlinks(trash), resolveSymlinks(target)
+	_, inside := relSecurityPath(trash, target)
+	_, contains := relSecurityPath(target, trash)
+	return inside || contains
+}
+
+// moveToTrash 将文件或目录移入当前会话的回收站并记录，返回会话ID与在回收站中的路径
+func moveToTrash(workDir, path string, isDir bool) (string, string, error) {
+	trashState.Lock()
+	defer trashState.Unlock()
+
+	session := trashSession()
+	sessionDir := filepath.Join(TrashDir(workDir), session)
+	if err := ensureTrashDir(TrashDir(workDir)); err != nil {
+		return "", "", err
+	}
+
+	// 工作目录内的文件保留相对路径，其他文件放在 _external 下；同名时加序号
+	rel, err := filepath.Rel(workDir, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		rel = filepath.Join(trashExternalDir, strings.TrimPrefix(path, filepath.VolumeName(path)))
+	}
+	target := filepath.Join(sessionDir, rel)
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(target); os.IsNotExist(err) {
+			break
+		}
+		target = fmt.Sprintf("%s.%d", filepath.Join(sessionDir, rel), i)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return "", "", fmt.Errorf("failed to create trash directory: %w", err)
+	}
+	if err := movePath(path, target); err != nil {
+		return "", "", err
+	}
+
+	trashRel, _ := filepath.Rel(sessionDir, target)
+	entry := TrashEntry{
+		OriginalPath: path,
+		TrashPath:    filepath.ToSlash(trashRel),
+		Dir:          isDir,
+		DeletedAt:    time.Now(),
+	}
+	entries, err := readTrashManifest(sessionDir)
+	if err != nil {
+		return "", "", err
+	}
+	if err := writeTrashManifest(sessionDir, append(entries, entry)); err != nil {
+		return "", "", err
+	}
+	return session, target, nil
+}
+
+// ensureTrashDir 创建回收站目录，并让 git 忽略其中的内容
+func ensureTrashDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create trash directory: %w", err)
+	}
+	ignore := filepath.Join(dir, ".gitignore")
+	if _, err := os.Stat(ignore); os.IsNotExist(err) {
+		os.WriteFile(ignore, []byte("*\n"), 0644)
+	}
+	return nil
+}
+
+// ListTrash 列出工作目录回收站中的会话，按会话ID排序
+func ListTrash(workDir string) ([]TrashSession, error) {
+	dirs, err := os.ReadDir(TrashDir(workDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var sessions []TrashSession
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		entries, err := readTrashManifest(filepath.Join(TrashDir(workDir), dir.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) > 0 {
+			sessions = append(sessions, TrashSession{ID: dir.Name(), Entries: entries})
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].ID < sessions[j].ID
+	})
+	return sessions, nil
+}
+
+// RestoreFromTrash 将会话回收站中的内容移回原位置。paths 为空时恢复全部，否则只恢复原路径
+// （绝对路径或相对工作目录）匹配或位于其下的项；原位置已存在且 force 为 false 时不恢复该项并报错
+func RestoreFromTrash(workDir, session string, paths []string, force bool) ([]TrashEntry, error) {
+	sessionDir := filepath.Join(TrashDir(workDir), session)
+	entries, err := readTrashManifest(sessionDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("nothing to restore in trash session %s", session)
+	}
+
+	selected := func(entry TrashEntry) bool {
+		if len(paths) == 0 {
+			return true
+		}
+		for _, path := range paths {
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(workDir, path)
+			}
+			path = filepath.Clean(path)
+			if entry.OriginalPath == path || strings.HasPrefix(path, entry.OriginalPath+string(filepath.Separator)) || strings.HasPrefix(entry.OriginalPath, path+string(filepath.Separator)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var restored, remaining []TrashEntry
+	var errs []string
+	done := make(map[string]bool)
+	// 后删除的先恢复：同一路径删除多次时恢复最近的版本，较早的版本留在回收站
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if !selected(entry) || done[entry.OriginalPath] {
+			remaining = append(remaining, entry)
+			continue
+		}
+		if err := restoreTrashEntry(sessionDir, entry, force); err != nil {
+			errs = append(errs, err.Error())
+			remaining = append(remaining, entry)
+			continue
+		}
+		restored = append(restored, entry)
+		done[entry.OriginalPath] = true
+	}
+	if len(restored) == 0 && len(errs) == 0 {
+		return nil, fmt.Errorf("no deleted file in trash session %s matches %s", session, strings.Join(paths, ", "))
+	}
+
+	// 清单保持删除顺序
+	for i, j := 0, len(remaining)-1; i < j; i, j = i+1, j-1 {
+		remaining[i], remaining[j] = remaining[j], remaining[i]
+	}
+	if len(remaining) == 0 {
+		os.RemoveAll(sessionDir)
+	} else if err := writeTrashManifest(sessionDir, remaining); err != nil {
+		return restored, err
+	}
+	if len(errs) > 0 {
+		return restored, errors.New(strings.Join(errs, "; "))
+	}
+	return restored, nil
+}
+
+// restoreTrashEntry 将一项移回原位置
+func restoreTrashEntry(sessionDir string, entry TrashEntry, force bool) error {
+	source := filepath.Join(sessionDir, filepath.FromSlash(entry.TrashPath))
+	if _, err := os.Lstat(source); err != nil {
+		return fmt.Errorf("%s: missing from the trash", entry.OriginalPath)
+	}
+	if _, err := os.Lstat(entry.OriginalPath); err == nil {
+		if !force {
+			return fmt.Errorf("%s already exists (use --force to overwrite)", entry.OriginalPath)
+		}
+		if err := os.RemoveAll(entry.OriginalPath); err != nil {
+			return fmt.Errorf("%s: %w", entry.OriginalPath, err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(entry.OriginalPath), 0755); err != nil {
+		return fmt.Errorf("%s: %w", entry.OriginalPath, err)
+	}
+	if err := movePath(source, entry.OriginalPath); err != nil {
+		return fmt.Errorf("%s: %w", entry.OriginalPath, err)
+	}
+	return nil
+}
+
+// readTrashManifest 读取会话目录的清单，不存在时为空
+func readTrashManifest(sessionDir string) ([]TrashEntry, error) {
+	data, err := os.ReadFile(filepath.Join(sessionDir, trashManifestName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trash manifest: %w", err)
+	}
+	var entries []TrashEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid trash manifest %s: %w", filepath.Join(sessionDir, trashManifestName), err)
+	}
+	return entries, nil
+}
+
+// writeTrashManifest 写入会话目录的清单
+func writeTrashManifest(sessionDir string, entries []TrashEntry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(sessionDir, trashManifestName), data, 0644); err != nil {
+		return fmt.Errorf("failed to write trash manifest: %w", err)
+	}
+	return nil
+}
+
+// movePath 移动文件或目录，跨文件系统时复制后删除
+func movePath(source, target string) error {
+	err := os.Rename(source, target)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	if err := copyPath(source, target); err != nil {
+		os.RemoveAll(target)
+		return err
+	}
+	return os.RemoveAll(source)
+}
+
+// copyPath 递归复制文件或目录，保留权限，符号链接复制为链接
+func copyPath(source, target string) error {
+	info, err := os.Lstat(source)
+	if err != nil {
+		return err
+	}
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		link, err := os.Readlink(source)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(link, target)
+	case info.IsDir():
+		if err := os.MkdirAll(target, info.Mode().Perm()); err != nil {
+			return err
+		}
+		entries, err := os.ReadDir(source)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := copyPath(filepath.Join(source, entry.Name()), filepath.Join(target, entry.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
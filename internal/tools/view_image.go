@@ -0,0 +1,136 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	_ "image/gif"  // 注册 GIF 解码，读取尺寸
+	_ "image/jpeg" // 注册 JPEG 解码，读取尺寸
+	_ "image/png"  // 注册 PNG 解码，读取尺寸
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// MaxImageSize 发送给模型的单张图片的大小上限
+const MaxImageSize = 5 << 20
+
+// imageMediaTypes 视觉模型普遍支持的图片格式
+var imageMediaTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// Image 发送给视觉模型的图片
+type Image struct {
+	Path      string
+	MediaType string
+	Width     int // 无法解析尺寸时（如 WebP）为 0
+	Height    int
+	Data      []byte
+}
+
+// DataURL 图片的 data URL，用于 image_url 消息
+func (img *Image) DataURL() string {
+	return "data:" + img.MediaType + ";base64," + base64.StdEncoding.EncodeToString(img.Data)
+}
+
+// LoadImage 读取 PNG、JPEG、GIF 或 WebP 图片，格式按内容判断
+func LoadImage(path string) (*Image, error) {
+	info, err := statWorkspaceFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("image not found: %s", path)
+		}
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+	if info.Size() > MaxImageSize {
+		return nil, fmt.Errorf("image %s is %s, larger than the limit of %s; resize or crop it first", path, formatFileSize(info.Size()), formatFileSize(MaxImageSize))
+	}
+	data, err := readWorkspaceFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+
+	mediaType := http.DetectContentType(data)
+	if !imageMediaTypes[mediaType] {
+		return nil, fmt.Errorf("%s is not a PNG, JPEG, GIF or WebP image (detected %s)", path, mediaType)
+	}
+	img := &Image{Path: path, MediaType: mediaType, Data: data}
+	if config, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		img.Width, img.Height = config.Width, config.Height
+	}
+	return img, nil
+}
+
+// ViewImageResult view_image工具的返回结果。图片本身不在JSON中，由客户端作为图片消息发送给模型
+type ViewImageResult struct {
+	TargetFile string `json:"target_file"`
+	MediaType  string `json:"media_type"`
+	Width      int    `json:"width,omitempty"`
+	Height     int    `json:"height,omitempty"`
+	Size       int    `json:"size"`
+	Message    string `json:"message"`
+	Image      *Image `json:"-"`
+}
+
+// viewImageFunction 查看图片工具函数
+func viewImageFunction(params map[string]interface{}) (interface{}, error) {
+	targetFile, ok := params["target_file"].(string)
+	if !ok || targetFile == "" {
+		return nil, fmt.Errorf("target_file is required")
+	}
+	workDir, _ := params["__work_dir__"].(string)
+	filePath := targetFile
+	if !filepath.IsAbs(filePath) && workDir != "" {
+		filePath = filepath.Join(workDir, targetFile)
+	}
+
+	img, err := LoadImage(filePath)
+	if err != nil {
+		return nil, err
+	}
+	img.Path = targetFile
+	return &ViewImageResult{
+		TargetFile: targetFile,
+		MediaType:  img.MediaType,
+		Width:      img.Width,
+		Height:     img.Height,
+		Size:       len(img.Data),
+		Message:    "The image is attached in the next message.",
+		Image:      img,
+	}, nil
+}
+
+// NewViewImageTool 创建view_image工具
+func NewViewImageTool() Tool {
+	schema := ToolSchema{
+		Name:        "view_image",
+		Description: "Look at an image file in the workspace (PNG, JPEG, GIF or WebP, up to 5MB), e.g. a UI screenshot, a rendered chart or a design mockup. The image is attached to the conversation right after the tool result.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"target_file": map[string]interface{}{
+					"type":        "string",
+					"description": "The path of the image, relative to the workspace root or absolute",
+				},
+				"explanation": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explanation as to why this tool is being used, and how it contributes to the goal.",
+				},
+			},
+			"required": []string{"target_file"},
+		},
+	}
+
+	return Tool{
+		Schema:   schema,
+		Function: viewImageFunction,
+	}
+}
@@ -0,0 +1,86 @@
+package tools
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// maxWalkWorkers 并行遍历目录的goroutine上限
+const maxWalkWorkers = 16
+
+// walkFunc 并行遍历时对每个文件的回调，会被多个goroutine同时调用
+type walkFunc func(path string, d fs.DirEntry)
+
+// parallelWalk 用有上限的goroutine池并行遍历 roots 下的所有文件（不跟随符号链接），
+// skipDir 返回 true 的目录不进入。根目录不存在或无法访问时不遍历并返回错误，其下无法读取的目录被忽略，回调的顺序不确定
+func parallelWalk(roots []string, skipDir func(path string, d fs.DirEntry) bool, visit walkFunc) error {
+	workers := runtime.NumCPU()
+	if workers > maxWalkWorkers {
+		workers = maxWalkWorkers
+	}
+	w := &dirWalker{
+		sem:     make(chan struct{}, workers),
+		skipDir: skipDir,
+		visit:   visit,
+	}
+	infos := make([]os.FileInfo, len(roots))
+	for i, root := range roots {
+		info, err := os.Lstat(root)
+		if err != nil {
+			return fmt.Errorf("cannot search %s: %w", root, err)
+		}
+		infos[i] = info
+	}
+	for i, root := range roots {
+		info := infos[i]
+		if !info.IsDir() {
+			visit(root, fs.FileInfoToDirEntry(info))
+			continue
+		}
+		w.wg.Add(1)
+		w.walkDir(root)
+	}
+	w.wg.Wait()
+	return nil
+}
+
+// dirWalker 并行遍历的状态
+type dirWalker struct {
+	wg      sync.WaitGroup
+	sem     chan struct{} // 额外goroutine的名额，没有名额时在当前goroutine中遍历
+	skipDir func(path string, d fs.DirEntry) bool
+	visit   walkFunc
+}
+
+// walkDir 遍历一个目录，子目录尽量交给新的goroutine
+func (w *dirWalker) walkDir(dir string) {
+	defer w.wg.Done()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if !entry.IsDir() {
+			w.visit(path, entry)
+			continue
+		}
+		if w.skipDir != nil && w.skipDir(path, entry) {
+			continue
+		}
+		w.wg.Add(1)
+		select {
+		case w.sem <- struct{}{}:
+			go func() {
+				defer func() { <-w.sem }()
+				w.walkDir(path)
+			}()
+		default:
+			w.walkDir(path)
+		}
+	}
+}
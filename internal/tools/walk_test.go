@@ -0,0 +1,30 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestFileSearchMissingWorkspace 不存在的工作区根目录报错，而不是被悄悄跳过
+func TestFileSearchMissingWorkspace(t *testing.T) {
+	workDir, other := t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(workDir, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	registry := NewRegistry()
+	if err := registry.RegisterAllTools(); err != nil {
+		t.Fatal(err)
+	}
+	registry.SetWorkDirectory(workDir)
+	registry.SetWorkspaces([]Workspace{{Name: "api", Path: filepath.Join(other, "gone")}})
+
+	result, err := registry.GetManager().ExecuteTool("file_search", map[string]interface{}{"query": "main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Success || !strings.Contains(result.Error, "gone") {
+		t.Errorf("file_search with a missing workspace root: success=%v error=%q, want an error naming the root", result.Success, result.Error)
+	}
+}
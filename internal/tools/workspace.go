@@ -0,0 +1,128 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// workspaceParam 工具调用中选择工作区根目录的参数，省略时使用主工作目录
+const workspaceParam = "workspace"
+
+// workspaceNamePattern 工作区名称：字母、数字、点、下划线和连字符
+var workspaceNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Workspace 除主工作目录外注册的一个工作区根目录（monorepo 中的包、相关的仓库）
+type Workspace struct {
+	Name string `json:"name"`
+	Path string `json:"path"` // 绝对路径
+}
+
+// ResolveWorkspaces 检查工作区名称与目录，相对路径基于 baseDir 解析，按名称排序返回
+func ResolveWorkspaces(roots map[string]string, baseDir string) ([]Workspace, error) {
+	workspaces := make([]Workspace, 0, len(roots))
+	for name, path := range roots {
+		if !workspaceNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid workspace name %q: use letters, digits, '.', '_' and '-'", name)
+		}
+		if path == "" {
+			return nil, fmt.Errorf("workspace %q has no path", name)
+		}
+		if strings.HasPrefix(path, "~") {
+			if home, err := os.UserHomeDir(); err == nil {
+				path = filepath.Join(home, strings.TrimPrefix(path, "~"))
+			}
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("workspace %q: %w", name, err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("workspace %q: %s is not a directory", name, path)
+		}
+		workspaces = append(workspaces, Workspace{Name: name, Path: filepath.Clean(path)})
+	}
+	sort.Slice(workspaces, func(i, j int) bool {
+		return workspaces[i].Name < workspaces[j].Name
+	})
+	return workspaces, nil
+}
+
+// WorkspacePrompt 告知模型已注册的工作区根目录，没有额外的根目录时为空
+func WorkspacePrompt(workDir string, workspaces []Workspace) string {
+	if len(workspaces) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("This session spans several workspace roots. Tool calls operate on the main workspace unless the \"workspace\" parameter names another root; relative paths and commands are then resolved in that root. file_search searches all roots when no workspace is given.\n")
+	fmt.Fprintf(&b, "- (main): %s\n", workDir)
+	for _, ws := range workspaces {
+		fmt.Fprintf(&b, "- %s: %s\n", ws.Name, ws.Path)
+	}
+	return b.String()
+}
+
+// workspaceDir 取出工具调用中的 workspace 参数，返回对应根目录；未指定时返回主工作目录
+func workspaceDir(params map[string]interface{}, workDir string, workspaces []Workspace) (string, error) {
+	value, ok := params[workspaceParam]
+	if !ok {
+		return workDir, nil
+	}
+	delete(params, workspaceParam)
+	name, _ := value.(string)
+	if name == "" {
+		return workDir, nil
+	}
+	for _, ws := range workspaces {
+		if ws.Name == name {
+			return ws.Path, nil
+		}
+	}
+	names := make([]string, 0, len(workspaces))
+	for _, ws := range workspaces {
+		names = append(names, ws.Name)
+	}
+	if len(names) == 0 {
+		return "", &ParamError{Param: workspaceParam, Problem: "no other workspace roots are registered"}
+	}
+	return "", &ParamError{Param: workspaceParam, Problem: fmt.Sprintf("unknown workspace %q (available: %s)", name, strings.Join(names, ", "))}
+}
+
+// withWorkspaceParam 返回加入 workspace 参数的工具模式副本
+func withWorkspaceParam(schema ToolSchema, workspaces []Workspace) ToolSchema {
+	inputSchema, ok := schema.InputSchema.(map[string]interface{})
+	if !ok {
+		return schema
+	}
+	properties, _ := inputSchema["properties"].(map[string]interface{})
+	if _, exists := properties[workspaceParam]; exists {
+		return schema
+	}
+
+	names := make([]string, 0, len(workspaces))
+	for _, ws := range workspaces {
+		names = append(names, ws.Name)
+	}
+	copied := make(map[string]interface{}, len(inputSchema))
+	for key, value := range inputSchema {
+		copied[key] = value
+	}
+	copiedProperties := make(map[string]interface{}, len(properties)+1)
+	for key, value := range properties {
+		copiedProperties[key] = value
+	}
+	copiedProperties[workspaceParam] = map[string]interface{}{
+		"type":        "string",
+		"enum":        names,
+		"description": "Workspace root to operate in. Omit for the main workspace.",
+	}
+	copied["properties"] = copiedProperties
+	schema.InputSchema = copied
+	return schema
+}
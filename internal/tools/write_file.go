@@ -2,9 +2,7 @@ package tools
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
-	"strings"
 )
 
 // WriteFileParams write_file工具的参数
@@ -23,6 +21,7 @@ type WriteFileResult struct {
 	BytesWritten int    `json:"bytes_written"`
 	Message      string `json:"message"`
 	FileExists   bool   `json:"file_exists"`
+	Diff         string `json:"diff,omitempty"` // 写入前后的统一diff
 }
 
 // writeFileFunction 写入文件工具函数
@@ -61,7 +60,7 @@ func writeFileFunction(params map[string]interface{}) (interface{}, error) {
 
 	// 检查文件是否存在
 	fileExists := false
-	if _, err := os.Stat(filePath); err == nil {
+	if _, err := statWorkspaceFile(filePath); err == nil {
 		fileExists = true
 		result.FileExists = true
 	}
@@ -73,20 +72,24 @@ func writeFileFunction(params map[string]interface{}) (interface{}, error) {
 	}
 
 	// 执行安全检查
-	if err := performWriteSecurityChecks(filePath); err != nil {
+	if err := performWriteSecurityChecks(filePath, workDir); err != nil {
 		result.Message = fmt.Sprintf("Security check failed: %v", err)
 		return result, nil
 	}
 
 	// 确保目录存在
 	dir := filepath.Dir(filePath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	if err := makeWorkspaceDir(dir); err != nil {
 		result.Message = fmt.Sprintf("Failed to create directory: %v", err)
 		return result, nil
 	}
 
-	// 写入文件
-	err := os.WriteFile(filePath, []byte(content), 0644)
+	// 写入文件（先读取原内容用于diff）
+	var original []byte
+	if fileExists {
+		original, _ = readWorkspaceFile(filePath)
+	}
+	err := writeWorkspaceFile(filePath, []byte(content), 0644)
 	if err != nil {
 		result.Message = fmt.Sprintf("Failed to write file: %v", err)
 		return result, nil
@@ -95,6 +98,7 @@ func writeFileFunction(params map[string]interface{}) (interface{}, error) {
 	result.Written = true
 	result.Created = !fileExists
 	result.BytesWritten = len(content)
+	result.Diff = editDiff(workDir, filePath, string(original), content, fileExists)
 
 	if result.Created {
 		result.Message = fmt.Sprintf("File created successfully with %d bytes", result.BytesWritten)
@@ -105,71 +109,12 @@ func writeFileFunction(params map[string]interface{}) (interface{}, error) {
 	return result, nil
 }
 
-// performWriteSecurityChecks 执行写入安全检查
-func performWriteSecurityChecks(filePath string) error {
-	// 检查是否为系统重要目录
-	dangerousPaths := []string{
-		"/etc",
-		"/bin",
-		"/sbin",
-		"/usr/bin",
-		"/usr/sbin",
-		"/boot",
-		"/sys",
-		"/proc",
-		"/dev",
-		"C:\\Windows",
-		"C:\\Program Files",
-		"C:\\Program Files (x86)",
-		"C:\\System32",
-	}
-
-	absPath, err := filepath.Abs(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to get absolute path: %w", err)
+// performWriteSecurityChecks 执行写入安全检查：路径与扩展名按安全策略检查（见 SecurityPolicy），文件名见 checkFileName
+func performWriteSecurityChecks(filePath, workDir string) error {
+	if err := checkSecurityPolicy(policyWrite, filePath, workDir); err != nil {
+		return err
 	}
-
-	for _, dangerousPath := range dangerousPaths {
-		if strings.HasPrefix(absPath, dangerousPath) {
-			return fmt.Errorf("cannot write files to system directory: %s", dangerousPath)
-		}
-	}
-
-	// 检查文件扩展名
-	dangerousExtensions := []string{
-		".exe", ".dll", ".sys", ".bat", ".cmd", ".com", ".scr",
-		".pif", ".application", ".gadget", ".msi", ".msp", ".msc",
-	}
-
-	ext := strings.ToLower(filepath.Ext(filePath))
-	for _, dangerousExt := range dangerousExtensions {
-		if ext == dangerousExt {
-			return fmt.Errorf("cannot create potentially dangerous file type: %s", ext)
-		}
-	}
-
-	// 检查是否为隐藏的系统文件
-	fileName := filepath.Base(filePath)
-	systemFiles := []string{
-		"boot.ini", "ntldr", "bootmgr", "pagefile.sys", "hiberfil.sys",
-		"autoexec.bat", "config.sys",
-	}
-
-	for _, systemFile := range systemFiles {
-		if strings.ToLower(fileName) == systemFile {
-			return fmt.Errorf("cannot create system file: %s", systemFile)
-		}
-	}
-
-	// 检查文件名是否包含危险字符
-	dangerousChars := []string{"<", ">", ":", "\"", "|", "?", "*"}
-	for _, char := range dangerousChars {
-		if strings.Contains(fileName, char) {
-			return fmt.Errorf("filename contains dangerous character: %s", char)
-		}
-	}
-
-	return nil
+	return checkFileName(filePath)
 }
 
 // NewWriteFileTool 创建write_file工具
@@ -0,0 +1,185 @@
+// Package watch 监听工作区的文件变化，合并短时间内的连续变化后按批通知
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"openCursor/internal/index"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultDebounce 最后一次文件变化后等待多久再通知
+const DefaultDebounce = time.Second
+
+// Options 监听选项
+type Options struct {
+	Match    func(rel string) bool // 只通知匹配的文件，为nil时通知所有未被忽略的文件
+	Debounce time.Duration         // 为0时使用 DefaultDebounce
+}
+
+// Watcher 递归监听目录中未被忽略（.gitignore、.opencursorignore、node_modules、隐藏文件等）的文件
+type Watcher struct {
+	root    string
+	opts    Options
+	ignored func(rel string, isDir bool) bool
+	fs      *fsnotify.Watcher
+	changes chan []string
+	errors  chan error
+	done    chan struct{}
+}
+
+// New 开始监听 root
+func New(root string, opts Options) (*Watcher, error) {
+	if opts.Debounce <= 0 {
+		opts.Debounce = DefaultDebounce
+	}
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{
+		root:    root,
+		opts:    opts,
+		ignored: index.IgnoreFilter(root),
+		fs:      fsWatcher,
+		changes: make(chan []string),
+		errors:  make(chan error, 1),
+		done:    make(chan struct{}),
+	}
+	if err := w.addTree(root); err != nil {
+		fsWatcher.Close()
+		return nil, err
+	}
+	go w.loop()
+	return w, nil
+}
+
+// Changes 变化停止 Debounce 后送出变化的文件（相对路径，使用 /，已排序）。
+// 接收方未及时接收时，之后的变化合并到同一批中
+func (w *Watcher) Changes() <-chan []string {
+	return w.changes
+}
+
+// Errors 监听出错（如事件队列溢出、超出系统的监听数量限制）时送出错误，未接收的错误会被丢弃
+func (w *Watcher) Errors() <-chan error {
+	return w.errors
+}
+
+// Close 停止监听
+func (w *Watcher) Close() error {
+	select {
+	case <-w.done:
+		return nil
+	default:
+		close(w.done)
+		return w.fs.Close()
+	}
+}
+
+// addTree 监听目录及其中未被忽略的子目录（fsnotify 不支持递归监听）
+func (w *Watcher) addTree(dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return nil
+		}
+		if path != w.root {
+			rel, err := filepath.Rel(w.root, path)
+			if err != nil || w.ignored(filepath.ToSlash(rel), true) {
+				return filepath.SkipDir
+			}
+		}
+		return w.fs.Add(path)
+	})
+}
+
+// loop 收集文件事件，变化停止后按批送出
+func (w *Watcher) loop() {
+	pending := make(map[string]bool) // 等待防抖的变化
+	ready := make(map[string]bool)   // 已防抖、等待接收的变化
+	var timer *time.Timer
+	var fire <-chan time.Time
+	for {
+		var out chan []string
+		var batch []string
+		if len(ready) > 0 {
+			out = w.changes
+			batch = sortedKeys(ready)
+		}
+		select {
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+			if rel, ok := w.handle(event); ok {
+				pending[rel] = true
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.NewTimer(w.opts.Debounce)
+				fire = timer.C
+			}
+		case err, ok := <-w.fs.Errors:
+			if !ok {
+				return
+			}
+			select {
+			case w.errors <- err:
+			default:
+			}
+		case <-fire:
+			fire = nil
+			for rel := range pending {
+				ready[rel] = true
+			}
+			pending = make(map[string]bool)
+		case out <- batch:
+			ready = make(map[string]bool)
+		case <-w.done:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		}
+	}
+}
+
+// handle 返回需要通知的文件，新建的目录加入监听
+func (w *Watcher) handle(event fsnotify.Event) (string, bool) {
+	if event.Op == fsnotify.Chmod {
+		return "", false
+	}
+	rel, err := filepath.Rel(w.root, event.Name)
+	if err != nil || rel == "." {
+		return "", false
+	}
+	rel = filepath.ToSlash(rel)
+	if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+		if event.Op&fsnotify.Create != 0 && !w.ignored(rel, true) {
+			if err := w.addTree(event.Name); err != nil {
+				select {
+				case w.errors <- err:
+				default:
+				}
+			}
+		}
+		return "", false
+	}
+	if w.ignored(rel, false) || (w.opts.Match != nil && !w.opts.Match(rel)) {
+		return "", false
+	}
+	return rel, true
+}
+
+// sortedKeys 集合中的路径，已排序
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
\ No newline at end of file
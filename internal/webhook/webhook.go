@@ -0,0 +1,90 @@
+// Package webhook 在非交互运行结束时向配置的地址发送运行摘要，用于接入 chatops 看板等
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// EventRunFinished 运行结束事件
+const EventRunFinished = "run.finished"
+
+// 运行状态
+const (
+	StatusCompleted      = "completed"
+	StatusInterrupted    = "interrupted"
+	StatusBudgetExceeded = "budget_exceeded"
+	StatusFailed         = "failed"
+)
+
+// Config 配置文件中的 webhook 设置
+type Config struct {
+	URL     string            `yaml:"url,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty"` // 附加的请求头，如 Authorization
+}
+
+// DiffStats 运行前后工作区的差异统计
+type DiffStats struct {
+	Files      int `json:"files"`
+	Insertions int `json:"insertions"`
+	Deletions  int `json:"deletions"`
+}
+
+// Payload 发送的运行摘要
+type Payload struct {
+	Event            string    `json:"event"`
+	SessionID        string    `json:"session_id"`
+	Query            string    `json:"query"`
+	WorkDir          string    `json:"workdir"`
+	Model            string    `json:"model"`
+	Job              string    `json:"job,omitempty"` // 定时任务名
+	Success          bool      `json:"success"`
+	Status           string    `json:"status"` // completed、interrupted、budget_exceeded 或 failed
+	Error            string    `json:"error,omitempty"`
+	StartedAt        time.Time `json:"started_at"`
+	FinishedAt       time.Time `json:"finished_at"`
+	DurationSeconds  float64   `json:"duration_seconds"`
+	FilesChanged     []string  `json:"files_changed"`
+	DiffStats        DiffStats `json:"diff_stats"`
+	ToolCalls        int       `json:"tool_calls"`
+	Edits            int       `json:"edits"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	Cost             float64   `json:"cost"`
+}
+
+// Send 以 JSON POST 运行摘要，非 2xx 响应视为失败
+func Send(ctx context.Context, cfg Config, payload *Payload) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.URL, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "openCursor")
+	for key, value := range cfg.Headers {
+		req.Header.Set(key, os.ExpandEnv(value))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s returned HTTP %d", cfg.URL, resp.StatusCode)
+	}
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,157 @@
+package workflow
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 步骤的运行状态
+const (
+	StatusPending = "pending"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+)
+
+// Run 一次工作流运行的记录，每个步骤结束后保存，用于 --resume 从失败或中断的步骤继续
+type Run struct {
+	ID        string            `json:"id"`
+	Workflow  string            `json:"workflow"`
+	File      string            `json:"file"`
+	WorkDir   string            `json:"workdir"`
+	Vars      map[string]string `json:"vars,omitempty"`
+	Branch    string            `json:"branch,omitempty"` // 保存步骤检查点的影子分支
+	StartedAt time.Time         `json:"started_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	Steps     []StepRun         `json:"steps"`
+}
+
+// StepRun 一个步骤的运行结果
+type StepRun struct {
+	Name       string    `json:"name"`
+	Status     string    `json:"status"`
+	Attempts   int       `json:"attempts,omitempty"`
+	Summary    string    `json:"summary,omitempty"`    // 模型的最终回复，提供给后续步骤
+	Checkpoint string    `json:"checkpoint,omitempty"` // 步骤完成后工作区快照的提交
+	Error      string    `json:"error,omitempty"`
+	FinishedAt time.Time `json:"finished_at,omitempty"`
+}
+
+// NewRun 为工作流创建运行记录，所有步骤处于待运行状态
+func NewRun(wf *Workflow, file, workDir string, vars map[string]string) *Run {
+	suffix := make([]byte, 3)
+	rand.Read(suffix)
+	now := time.Now()
+	run := &Run{
+		ID:        now.Format("20060102-150405") + "-" + hex.EncodeToString(suffix),
+		Workflow:  wf.Name,
+		File:      file,
+		WorkDir:   workDir,
+		Vars:      vars,
+		StartedAt: now,
+		UpdatedAt: now,
+	}
+	for _, step := range wf.Steps {
+		run.Steps = append(run.Steps, StepRun{Name: step.Name, Status: StatusPending})
+	}
+	return run
+}
+
+// Sync 使运行记录的步骤与（可能已修改的）工作流一致：保留同名步骤的结果，新步骤为待运行
+func (r *Run) Sync(wf *Workflow) {
+	previous := make(map[string]StepRun, len(r.Steps))
+	for _, step := range r.Steps {
+		previous[step.Name] = step
+	}
+	r.Steps = r.Steps[:0]
+	for _, step := range wf.Steps {
+		if prev, ok := previous[step.Name]; ok {
+			r.Steps = append(r.Steps, prev)
+		} else {
+			r.Steps = append(r.Steps, StepRun{Name: step.Name, Status: StatusPending})
+		}
+	}
+}
+
+// NextStep 第一个未完成步骤的序号，全部完成时返回步骤数
+func (r *Run) NextStep() int {
+	for i, step := range r.Steps {
+		if step.Status != StatusDone {
+			return i
+		}
+	}
+	return len(r.Steps)
+}
+
+// RunPath 运行记录文件路径
+func RunPath(dir, id string) string {
+	return filepath.Join(dir, id+".json")
+}
+
+// Save 将运行记录写入 dir/<id>.json
+func (r *Run) Save(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create workflow run directory: %w", err)
+	}
+	r.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := RunPath(dir, r.ID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write workflow run: %w", err)
+	}
+	if err := os.Rename(tmp, RunPath(dir, r.ID)); err != nil {
+		return fmt.Errorf("failed to write workflow run: %w", err)
+	}
+	return nil
+}
+
+// LoadRun 读取指定ID的运行记录
+func LoadRun(dir, id string) (*Run, error) {
+	data, err := os.ReadFile(RunPath(dir, id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("workflow run %q not found in %s", id, dir)
+		}
+		return nil, fmt.Errorf("failed to read workflow run: %w", err)
+	}
+	run := &Run{}
+	if err := json.Unmarshal(data, run); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow run %s: %w", id, err)
+	}
+	return run, nil
+}
+
+// ListRuns 读取目录中的所有运行记录，最近更新的在前。无法解析的文件被跳过
+func ListRuns(dir string) ([]*Run, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read workflow run directory: %w", err)
+	}
+	var runs []*Run
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		run, err := LoadRun(dir, strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+		runs = append(runs, run)
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].UpdatedAt.After(runs[j].UpdatedAt)
+	})
+	return runs, nil
+}
\ No newline at end of file
@@ -0,0 +1,161 @@
+package workflow
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultMaxIterations 步骤未指定时允许的最多模型调用轮数
+const DefaultMaxIterations = 20
+
+// varPattern 提示词中的变量占位符 {{name}}
+var varPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_-]*)\s*\}\}`)
+
+// Workflow 由YAML文件定义的一系列代理步骤
+type Workflow struct {
+	Name        string            `yaml:"name"`
+	Description string            `yaml:"description,omitempty"`
+	Vars        map[string]string `yaml:"vars,omitempty"` // 变量默认值，可用 --var 覆盖
+	Steps       []Step            `yaml:"steps"`
+}
+
+// Step 工作流中的一个步骤
+type Step struct {
+	Name          string   `yaml:"name"`
+	Prompt        string   `yaml:"prompt"`                   // 提示词模板，{{name}} 为变量
+	Tools         []string `yaml:"tools,omitempty"`          // 步骤需要的工具，只提供这些工具；为空时提供全部工具
+	MaxIterations int      `yaml:"max_iterations,omitempty"` // 每轮最多的模型调用轮数
+	Attempts      int      `yaml:"attempts,omitempty"`       // 成功条件不满足时最多尝试几轮，默认1
+	Success       []Check  `yaml:"success,omitempty"`        // 步骤完成后必须满足的条件
+}
+
+// Check 步骤的成功条件，三者只能设置一个
+type Check struct {
+	Tests      string `yaml:"tests,omitempty"`       // 目标的测试通过（run_tests），如 ./... 或 tests/
+	Command    string `yaml:"command,omitempty"`     // 命令以状态0退出
+	FileExists string `yaml:"file_exists,omitempty"` // 文件存在
+}
+
+// UnmarshalYAML 支持简写 "tests pass"，即运行整个项目的测试
+func (c *Check) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		if strings.EqualFold(strings.TrimSpace(node.Value), "tests pass") {
+			c.Tests = "."
+			return nil
+		}
+		return fmt.Errorf("line %d: unknown success criterion %q (use \"tests pass\", tests:, command: or file_exists:)", node.Line, node.Value)
+	}
+	type plain Check
+	return node.Decode((*plain)(c))
+}
+
+// String 成功条件的简要说明
+func (c Check) String() string {
+	switch {
+	case c.Tests != "":
+		return "tests pass: " + c.Tests
+	case c.Command != "":
+		return "command succeeds: " + c.Command
+	default:
+		return "file exists: " + c.FileExists
+	}
+}
+
+// Load 读取并校验工作流文件
+func Load(path string) (*Workflow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workflow: %w", err)
+	}
+	wf := &Workflow{}
+	if err := yaml.Unmarshal(data, wf); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow %s: %w", path, err)
+	}
+	if err := wf.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return wf, nil
+}
+
+// Validate 检查步骤定义：名称唯一、有提示词、成功条件各只设置一项
+func (w *Workflow) Validate() error {
+	if len(w.Steps) == 0 {
+		return fmt.Errorf("the workflow has no steps")
+	}
+	seen := make(map[string]bool)
+	for i := range w.Steps {
+		step := &w.Steps[i]
+		if step.Name == "" {
+			step.Name = fmt.Sprintf("step-%d", i+1)
+		}
+		if seen[step.Name] {
+			return fmt.Errorf("duplicate step name %q", step.Name)
+		}
+		seen[step.Name] = true
+		if strings.TrimSpace(step.Prompt) == "" {
+			return fmt.Errorf("step %s: prompt is required", step.Name)
+		}
+		if step.MaxIterations < 0 || step.Attempts < 0 {
+			return fmt.Errorf("step %s: max_iterations and attempts must not be negative", step.Name)
+		}
+		for _, check := range step.Success {
+			set := 0
+			for _, value := range []string{check.Tests, check.Command, check.FileExists} {
+				if value != "" {
+					set++
+				}
+			}
+			if set != 1 {
+				return fmt.Errorf("step %s: every success criterion needs exactly one of tests, command or file_exists", step.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Index 按名称查找步骤的序号
+func (w *Workflow) Index(name string) (int, bool) {
+	for i, step := range w.Steps {
+		if step.Name == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Render 用变量展开步骤的提示词，变量未定义时返回错误
+func (s Step) Render(vars map[string]string) (string, error) {
+	var missing string
+	prompt := varPattern.ReplaceAllStringFunc(s.Prompt, func(match string) string {
+		name := varPattern.FindStringSubmatch(match)[1]
+		value, ok := vars[name]
+		if !ok && missing == "" {
+			missing = name
+		}
+		return value
+	})
+	if missing != "" {
+		return "", fmt.Errorf("step %s: variable {{%s}} is not defined (set it in vars: or with --var %s=...)", s.Name, missing, missing)
+	}
+	return strings.TrimSpace(prompt), nil
+}
+
+// MergeVars 合并工作流中的默认变量与 name=value 形式的覆盖
+func (w *Workflow) MergeVars(overrides []string) (map[string]string, error) {
+	vars := make(map[string]string, len(w.Vars)+len(overrides))
+	for key, value := range w.Vars {
+		vars[key] = value
+	}
+	for _, override := range overrides {
+		key, value, ok := strings.Cut(override, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --var value %q, expected NAME=VALUE", override)
+		}
+		vars[key] = value
+	}
+	return vars, nil
+}
\ No newline at end of file
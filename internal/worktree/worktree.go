@@ -0,0 +1,156 @@
+// Package worktree 统计一次运行对 git 工作区的修改
+package worktree
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// 文件的修改类型
+const (
+	StatusAdded    = "added"
+	StatusModified = "modified"
+	StatusDeleted  = "deleted"
+)
+
+// FileChange 一个文件的修改
+type FileChange struct {
+	Path       string `json:"path"`
+	Status     string `json:"status"` // added、modified 或 deleted
+	Insertions int    `json:"insertions"`
+	Deletions  int    `json:"deletions"`
+	Binary     bool   `json:"binary,omitempty"`
+}
+
+// Changes 运行前后工作区的差异
+type Changes struct {
+	Files      []FileChange `json:"files"`
+	Insertions int          `json:"insertions"`
+	Deletions  int          `json:"deletions"`
+}
+
+// Paths 修改的文件路径
+func (c Changes) Paths() []string {
+	paths := make([]string, 0, len(c.Files))
+	for _, file := range c.Files {
+		paths = append(paths, file.Path)
+	}
+	return paths
+}
+
+// Baseline 运行前的工作区状态
+type Baseline struct {
+	dir         string
+	commit      string          // 运行前的 HEAD，不是 git 仓库时为空
+	preexisting map[string]bool // 运行前已有的未跟踪文件
+}
+
+// Capture 记录运行前的工作区状态
+func Capture(dir string) *Baseline {
+	b := &Baseline{dir: dir, preexisting: make(map[string]bool)}
+	b.commit = gitOutput(dir, "rev-parse", "HEAD")
+	if b.commit == "" {
+		return b
+	}
+	for _, file := range splitLines(gitOutput(dir, "ls-files", "--others", "--exclude-standard")) {
+		b.preexisting[file] = true
+	}
+	return b
+}
+
+// IsRepository 工作区是否为有提交的 git 仓库（否则无法统计修改）
+func (b *Baseline) IsRepository() bool {
+	return b.commit != ""
+}
+
+// Changes 相对于运行前的提交修改的文件（包含新提交、工作区修改与新增的未跟踪文件）。
+// 运行前已有的未提交修改也计算在内
+func (b *Baseline) Changes() Changes {
+	changes := Changes{Files: []FileChange{}}
+	if b.commit == "" {
+		return changes
+	}
+
+	statuses := make(map[string]string)
+	for _, line := range splitLines(gitOutput(b.dir, "diff", "--name-status", "--no-renames", b.commit)) {
+		status, path, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		switch status {
+		case "A":
+			statuses[path] = StatusAdded
+		case "D":
+			statuses[path] = StatusDeleted
+		default:
+			statuses[path] = StatusModified
+		}
+	}
+	// --numstat 每行为 "新增\t删除\t路径"，二进制文件的行数为 "-"
+	for _, line := range splitLines(gitOutput(b.dir, "diff", "--numstat", "--no-renames", b.commit)) {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		file := FileChange{Path: fields[2], Status: statuses[fields[2]], Binary: fields[0] == "-"}
+		if file.Status == "" {
+			file.Status = StatusModified
+		}
+		file.Insertions, _ = strconv.Atoi(fields[0])
+		file.Deletions, _ = strconv.Atoi(fields[1])
+		changes.add(file)
+	}
+	for _, path := range splitLines(gitOutput(b.dir, "ls-files", "--others", "--exclude-standard")) {
+		if b.preexisting[path] {
+			continue
+		}
+		changes.add(FileChange{Path: path, Status: StatusAdded, Insertions: countLines(filepath.Join(b.dir, path))})
+	}
+	return changes
+}
+
+// add 添加一个文件并累计行数
+func (c *Changes) add(file FileChange) {
+	c.Files = append(c.Files, file)
+	c.Insertions += file.Insertions
+	c.Deletions += file.Deletions
+}
+
+// gitOutput 执行git命令并返回去除空白的输出，失败时返回空字符串
+func gitOutput(dir string, args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// splitLines 按行拆分，空字符串返回nil
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// countLines 文件的行数，无法读取时为0
+func countLines(path string) int {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lines := 0
+	for scanner.Scan() {
+		lines++
+	}
+	return lines
+}
\ No newline at end of file